@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// checkRows runs checkConfig and returns its exit code and the result of each item
+// that has one.
+func checkRows(t *testing.T) (int, string, map[string]string) {
+	t.Helper()
+	var out strings.Builder
+	code := checkConfig(&out)
+	results := map[string]string{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			if fields[1] == "PASS" || fields[1] == "FAIL" || fields[1] == "SKIP" {
+				results[fields[0]] = fields[1]
+			}
+		}
+	}
+	return code, out.String(), results
+}
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		openAlex int // status of HEAD /works
+		wantCode int
+		want     map[string]string
+	}{
+		{
+			name:     "passes",
+			env:      map[string]string{"STORAGE_BACKEND": "none"},
+			openAlex: http.StatusOK,
+			want:     map[string]string{"config": "PASS", "neo4j": "SKIP", "openalex": "PASS", "tls": "SKIP"},
+		},
+		{
+			name:     "OpenAlex unreachable",
+			env:      map[string]string{"STORAGE_BACKEND": "none"},
+			openAlex: http.StatusServiceUnavailable,
+			wantCode: 1,
+			want:     map[string]string{"config": "PASS", "openalex": "FAIL"},
+		},
+		{
+			name:     "missing certificate",
+			env:      map[string]string{"STORAGE_BACKEND": "none", "TLS_CERT_FILE": "missing.pem", "TLS_KEY_FILE": "missing.key"},
+			openAlex: http.StatusOK,
+			wantCode: 1,
+			want:     map[string]string{"openalex": "PASS", "tls": "FAIL"},
+		},
+		{
+			name:     "invalid configuration",
+			env:      map[string]string{"APP_ENV": "prod", "NEO4J_PASSWORD": "password"},
+			wantCode: 1,
+			want:     map[string]string{"config": "FAIL"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			t.Setenv("SEMANTIC_SCHOLAR_API_KEY", "ss-key")
+			t.Setenv("WEBHOOK_SECRET", "hook-secret")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.openAlex)
+			}))
+			defer server.Close()
+			target, _ := url.Parse(server.URL)
+			original := http.DefaultTransport
+			http.DefaultTransport = rewriteTransport{target: target, next: original}
+			defer func() { http.DefaultTransport = original }()
+
+			code, out, results := checkRows(t)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d:\n%s", code, tt.wantCode, out)
+			}
+			for item, result := range tt.want {
+				if results[item] != result {
+					t.Errorf("%s = %q, want %q:\n%s", item, results[item], result, out)
+				}
+			}
+			for _, secret := range []string{"ss-key", "hook-secret"} {
+				if strings.Contains(out, secret) {
+					t.Errorf("output reveals %s:\n%s", secret, out)
+				}
+			}
+		})
+	}
+}
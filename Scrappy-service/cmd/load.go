@@ -7,6 +7,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/Cloudforge2/scrappy/internal/api"
 	"github.com/Cloudforge2/scrappy/internal/config"   // Adjust path
 	"github.com/Cloudforge2/scrappy/internal/openalex" // Adjust path
 	"github.com/Cloudforge2/scrappy/internal/storage"  // Adjust path
@@ -19,13 +20,17 @@ func load() {
 		log.Println("Info: .env file not found, reading from OS environment")
 	}
 
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
 
 	// 1. Initialize the Neo4j Repository
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	dbRepo, err := storage.NewNeo4jRepository(cfg.Neo4jURI, cfg.Neo4jUsername, cfg.Neo4jPassword)
+	dbRepo, err := storage.NewNeo4jRepository(cfg.Neo4jURI, cfg.Neo4jUsername, cfg.Neo4jPassword, cfg.PersistTopics,
+		storage.WithSlowQueryThreshold(cfg.SlowQueryThreshold))
 	if err != nil {
 		log.Fatalf("FATAL: Could not connect to database: %v", err)
 	}
@@ -44,6 +49,20 @@ func load() {
 	}
 
 	// 4. Save the data to Neo4j
+	// Blocked authors, like in the ingest endpoints, are neither saved nor have their works
+	// fetched.
+	allowed := authors[:0]
+	for _, author := range authors {
+		if blocked, reason, err := dbRepo.IsBlocked(ctx, author.ID); err != nil {
+			log.Printf("WARN: Could not check the blocklist for author %s: %v\n", author.ID, err)
+		} else if blocked {
+			log.Printf("Not ingesting blocked author %s: %s\n", author.ID, reason)
+			continue
+		}
+		allowed = append(allowed, author)
+	}
+	authors = allowed
+
 	for _, author := range authors {
 		log.Printf("Saving author: %s (ID: %s)\n", author.DisplayName, author.ID)
 		if err := dbRepo.SaveAuthor(ctx, author); err != nil {
@@ -55,14 +74,26 @@ func load() {
 	// Example for works
 	for _, author := range authors {
 		log.Printf("Fetching works for author: %s (ID: %s)...", author.DisplayName, author.ID)
-		works, err := alexClient.FetchWorksByAuthorID(author.ID)
+		works, warnings, err := alexClient.FetchWorks(openalex.NewFilter().AuthorID(author.ID))
 		if err != nil {
 			log.Printf("WARN: Failed to fetch works for author %s: %v\n", author.DisplayName, err)
 			continue
 		}
+		if len(warnings) > 0 {
+			log.Printf("WARN: Skipped %d works of author %s that could not be decoded: %v\n", len(warnings), author.DisplayName, warnings.Samples(3))
+		}
+		// The same paratext/retracted filter as the ingest endpoints.
+		works, skipped := api.FilterWorks(cfg, works)
+		if skipped > 0 {
+			log.Printf("Skipped %d paratext or retracted works of author %s\n", skipped, author.DisplayName)
+		}
 		for _, work := range works {
+			if blocked, _, err := dbRepo.IsBlocked(ctx, work.ID); err == nil && blocked {
+				log.Printf("Not ingesting blocked work %s\n", work.ID)
+				continue
+			}
 			log.Printf("Saving work: %s (ID: %s)\n", work.Title, work.ID)
-			if err := dbRepo.SaveWork(ctx, work); err != nil {
+			if _, err := dbRepo.SaveWork(ctx, work, storage.FullSave); err != nil {
 				log.Printf("WARN: Could not save work %s: %v\n", work.Title, err)
 			}
 		}
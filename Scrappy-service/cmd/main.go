@@ -6,10 +6,16 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	// Make sure your import paths are correct for your project
 	"github.com/Cloudforge2/scrappy/internal/api"
 	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/events"
 	"github.com/Cloudforge2/scrappy/internal/openalex"
 	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
 	"github.com/Cloudforge2/scrappy/internal/storage"
@@ -22,37 +28,111 @@ func main() {
 	if err != nil {
 		log.Println("Info: .env file not found, reading from OS environment")
 	}
-	cfg := config.LoadConfig()
+	// `scrappy check-config` validates the configuration and exits instead of serving.
+	runCommand(os.Args[1:])
 
-	// 1. Initialize the Neo4j Repository (the database connection)
-	dbRepo, err := storage.NewNeo4jRepository(cfg.Neo4jURI, cfg.Neo4jUsername, cfg.Neo4jPassword)
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("FATAL: Could not connect to database: %v", err)
+		log.Fatalf("FATAL: %v", err)
+	}
+
+	// 1. Initialize the Neo4j Repository (the database connection), unless the service runs
+	// as a plain OpenAlex proxy.
+	var dbRepo storage.Repository
+	if cfg.StorageDisabled() {
+		dbRepo = storage.NewDisabledRepository()
+		log.Println("Storage is disabled (STORAGE_BACKEND=none); ingest and graph endpoints answer 501")
+	} else {
+		dbRepo, err = storage.NewNeo4jRepository(cfg.Neo4jURI, cfg.Neo4jUsername, cfg.Neo4jPassword, cfg.PersistTopics,
+			storage.WithSlowQueryThreshold(cfg.SlowQueryThreshold))
+		if err != nil {
+			log.Fatalf("FATAL: Could not connect to database: %v", err)
+		}
+	}
+	// The webhook dispatcher and the h-index drift watcher run until shutdown, which cancels
+	// them and waits for them to return before the database is closed.
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var backgroundWG sync.WaitGroup
+	// Publish work/author saved events to the configured webhooks.
+	if len(cfg.WebhookURLs) > 0 {
+		publisher := events.NewChannelPublisher(1000)
+		dispatcher := events.NewWebhookDispatcher(cfg.WebhookURLs, cfg.WebhookSecret)
+		backgroundWG.Add(1)
+		go func() {
+			defer backgroundWG.Done()
+			dispatcher.Run(background, publisher.Events())
+		}()
+		dbRepo = events.WrapRepository(dbRepo, publisher)
+		log.Printf("Publishing graph events to %d webhook(s)", len(cfg.WebhookURLs))
 	}
-	// In a real server, you'd handle graceful shutdown, but for now this is fine.
-	defer dbRepo.Close(context.Background())
 
 	// 2. Initialize the OpenAlex Client (for fetching data)
-	alexClient := openalex.NewClient()
-	semClient := semanticscholar.NewClient(cfg.SemanticScholarAPIKey)
+	alexOpts := []openalex.Option{
+		openalex.WithRateLimit(cfg.OpenAlexRateLimit, cfg.OpenAlexRateBurst),
+		openalex.WithPageJitter(cfg.OpenAlexPageJitterMin, cfg.OpenAlexPageJitterMax),
+		openalex.WithPerPage(cfg.OpenAlexPerPage),
+	}
+	if cfg.OpenAlexDebugLog {
+		alexOpts = append(alexOpts, openalex.WithDebugLogger(log.Default()))
+	}
+	alexClient := openalex.NewClient(alexOpts...)
+	semClient := semanticscholar.NewClient(cfg.SemanticScholarAPIKey,
+		semanticscholar.WithRateLimit(cfg.SSRateLimit, cfg.SSRateBurst),
+		semanticscholar.WithTimeout(cfg.SSTimeout))
 
 	// 3. Initialize the API Handler, giving it the database and the client
-	apiHandler := api.NewAPIHandler(dbRepo, alexClient, semClient)
+	apiHandler := api.NewAPIHandler(cfg, dbRepo, alexClient, semClient)
+	// Author ingestions are paged and checkpointed, so those cut short by a restart can
+	// pick up where they stopped.
+	apiHandler.ResumeIncompleteJobs(context.Background(), cfg.ResumeJobsOnStartup)
+	if !cfg.StorageDisabled() {
+		backgroundWG.Add(1)
+		go func() {
+			defer backgroundWG.Done()
+			apiHandler.WatchHIndexDrift(background)
+		}()
+	}
 
 	// 4. Set up the URL routes and connect them to your handler functions
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/fetch-authors-by-name", apiHandler.FetchAndSaveAuthorByNameHandler)
-	mux.HandleFunc("/api/fetch-author-by-id", apiHandler.FetchAndSaveWorksByAuthorHandler)
-	mux.HandleFunc("/api/fetch-works-by-name", apiHandler.FetchAndSaveWorkByNameHandler)
-	// kc
-	// mux.HandleFunc("/api/fetch-work-authorid/", apiHandler.GetAuthorWorksByIdHandler)
-	mux.HandleFunc("/api/fetch-recent-works/", apiHandler.GetAuthorWorksHandler)
-	mux.HandleFunc("/api/fetch-abstracts/", apiHandler.FetchAbstractsHandler)
+	handler := newRouter(cfg, apiHandler)
+
 	// 5. Start the web server and listen for requests
 	port := ":8083"
-	log.Printf("Starting interactive API server on http://localhost%s", port)
-	if err := http.ListenAndServe(port, mux); err != nil {
+	server := &http.Server{Addr: port, Handler: handler}
+	shutdown, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() {
+		if cfg.TLSEnabled() {
+			// ListenAndServeTLS negotiates HTTP/2 with clients that support it.
+			log.Printf("Starting interactive API server on https://localhost%s", port)
+			serveErr <- server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			log.Printf("Starting interactive API server on http://localhost%s", port)
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+	select {
+	case err := <-serveErr:
 		log.Fatalf("FATAL: Could not start server: %v", err)
+	case <-shutdown.Done():
 	}
+	stop()
 
+	// 6. Shut down: stop taking requests, stop the webhook dispatcher and the drift watcher,
+	// give the background jobs JOB_DRAIN_TIMEOUT to finish (the rest are cancelled and
+	// recorded as interrupted), then close the database.
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("WARN: Could not shut the server down cleanly: %v", err)
+	}
+	stopBackground()
+	backgroundWG.Wait()
+	apiHandler.DrainJobs(cfg.JobDrainTimeout)
+	if err := dbRepo.Close(context.Background()); err != nil {
+		log.Printf("WARN: Could not close the database: %v", err)
+	}
 }
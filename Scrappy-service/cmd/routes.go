@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Cloudforge2/scrappy/internal/api"
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/metrics"
+)
+
+// newRouter connects the URL routes to apiHandler and wraps them in the middleware every
+// request goes through: tenant scoping, panic recovery and, if enabled, compression.
+func newRouter(cfg *config.Config, apiHandler *api.APIHandler) http.Handler {
+	// Ingest routes write to the graph and page through OpenAlex, and exports scan the whole
+	// graph, so they are limited far more strictly than reads. So are the name search, which
+	// ingests its top matches, and the abstracts pages, each up to 200 works looked up in
+	// Semantic Scholar too. Reads proxying a single OpenAlex request, like /api/search and
+	// /api/fetch-recent-works/, keep the read limit. Admin and metrics routes are not limited.
+	ingestLimit := api.RateLimit{Rate: cfg.IngestRateLimit, Burst: cfg.IngestRateBurst, PerIP: cfg.RateLimitPerIP}
+	readLimit := api.RateLimit{Rate: cfg.ReadRateLimit, Burst: cfg.ReadRateBurst, PerIP: cfg.RateLimitPerIP}
+
+	// Routes that read or write the graph are wrapped in graph, so they answer 501 when
+	// storage is disabled; the others only proxy OpenAlex and Semantic Scholar.
+	graph := apiHandler.RequireStorage
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/search", readLimit.Wrap(apiHandler.SearchHandler))
+	mux.HandleFunc("/api/fetch-authors-by-name", ingestLimit.Wrap(apiHandler.FetchAndSaveAuthorByNameHandler))
+	mux.HandleFunc("/api/fetch-author-by-id", ingestLimit.Wrap(graph(apiHandler.FetchAndSaveWorksByAuthorHandler)))
+	mux.HandleFunc("/api/fetch-author-by-id/stream", ingestLimit.Wrap(graph(apiHandler.StreamAuthorIngestHandler)))
+	mux.HandleFunc("/api/fetch-works-by-name", ingestLimit.Wrap(graph(apiHandler.FetchAndSaveWorkByNameHandler)))
+	mux.HandleFunc("/api/ingest-authors-bulk", ingestLimit.Wrap(graph(apiHandler.IngestAuthorsBulkHandler)))
+	mux.HandleFunc("/api/ingest/query", ingestLimit.Wrap(graph(apiHandler.IngestQueryHandler)))
+	mux.HandleFunc("/api/fetch-venue-by-issn", ingestLimit.Wrap(graph(apiHandler.IngestVenueByISSNHandler)))
+	mux.HandleFunc("/api/authors/sync", ingestLimit.Wrap(graph(apiHandler.SyncAuthorWorksHandler)))
+	mux.HandleFunc("/api/fetch-institution-by-ror", ingestLimit.Wrap(graph(apiHandler.FetchInstitutionByRORHandler)))
+	mux.HandleFunc("/api/institutions/enrich", ingestLimit.Wrap(graph(apiHandler.EnrichInstitutionsHandler)))
+	mux.HandleFunc("/api/jobs/{id}/resume", ingestLimit.Wrap(graph(apiHandler.ResumeJobHandler)))
+	mux.HandleFunc("/api/ingest-estimate", readLimit.Wrap(apiHandler.GetIngestEstimateHandler))
+	mux.HandleFunc("/api/sample-works", ingestLimit.Wrap(apiHandler.GetSampleWorksHandler))
+	// kc
+	// mux.HandleFunc("/api/fetch-work-authorid/", apiHandler.GetAuthorWorksByIdHandler)
+	mux.HandleFunc("/api/fetch-recent-works/", readLimit.Wrap(apiHandler.GetAuthorWorksHandler))
+	mux.HandleFunc("/api/fetch-abstracts/", ingestLimit.Wrap(apiHandler.FetchAbstractsHandler))
+	mux.HandleFunc("/api/authors/ingest-history", readLimit.Wrap(graph(apiHandler.GetIngestHistoryHandler)))
+	mux.HandleFunc("/api/works/missing-abstracts", readLimit.Wrap(graph(apiHandler.GetWorksMissingAbstractHandler)))
+	mux.HandleFunc("/api/works/recommendations", readLimit.Wrap(apiHandler.GetWorkRecommendationsHandler))
+	mux.HandleFunc("/api/venues/summary", readLimit.Wrap(graph(apiHandler.GetVenueSummaryHandler)))
+	mux.HandleFunc("/api/institutions/summary", readLimit.Wrap(graph(apiHandler.GetInstitutionSummaryHandler)))
+	mux.HandleFunc("/api/works/enrich-citation-context", ingestLimit.Wrap(graph(apiHandler.EnrichCitationContextHandler)))
+	mux.HandleFunc("/api/works/enrich-embeddings", ingestLimit.Wrap(graph(apiHandler.EnrichEmbeddingsHandler)))
+	mux.HandleFunc("/api/works/similar", readLimit.Wrap(graph(apiHandler.GetSimilarWorksHandler)))
+	mux.HandleFunc("/api/works/top", readLimit.Wrap(graph(apiHandler.GetTopWorksHandler)))
+	mux.HandleFunc("/api/works/deposit", ingestLimit.Wrap(graph(apiHandler.DepositWorkHandler)))
+	mux.HandleFunc("/api/works/neighborhood", readLimit.Wrap(graph(apiHandler.GetCitationNeighborhoodHandler)))
+	mux.HandleFunc("/api/works/provenance", readLimit.Wrap(graph(apiHandler.GetWorkProvenanceHandler)))
+	mux.HandleFunc("/api/works/ris", readLimit.Wrap(apiHandler.GetWorksRISHandler))
+	mux.HandleFunc("/api/works/export", readLimit.Wrap(graph(apiHandler.ExportWorksHandler)))
+	mux.HandleFunc("/api/works/ngrams", readLimit.Wrap(apiHandler.GetWorkNgramsHandler))
+	mux.HandleFunc("/api/topics/search", readLimit.Wrap(apiHandler.SearchTopicsHandler))
+	mux.HandleFunc("/api/topics/trending", readLimit.Wrap(graph(apiHandler.GetTrendingTopicsHandler)))
+	mux.HandleFunc("/api/topics/bridge", readLimit.Wrap(graph(apiHandler.GetWorksBridgingTopicsHandler)))
+	mux.HandleFunc("/api/topics/bridge/authors", readLimit.Wrap(graph(apiHandler.GetAuthorsBridgingTopicsHandler)))
+	mux.HandleFunc("/api/authors/collaboration-map", readLimit.Wrap(graph(apiHandler.GetCollaborationMapHandler)))
+	mux.HandleFunc("/api/authors/enrich-ss", ingestLimit.Wrap(graph(apiHandler.EnrichAuthorFromSemanticScholarHandler)))
+	mux.HandleFunc("/api/authors/search", readLimit.Wrap(graph(apiHandler.SearchGraphAuthorsHandler)))
+	mux.HandleFunc("/api/authors/work-types", readLimit.Wrap(graph(apiHandler.GetAuthorWorkTypesHandler)))
+	mux.HandleFunc("/api/authors/topics", readLimit.Wrap(graph(apiHandler.GetAuthorTopicProfileHandler)))
+	mux.HandleFunc("/api/authors/funders", readLimit.Wrap(graph(apiHandler.GetAuthorFundersHandler)))
+	mux.HandleFunc("/api/authors/hindex", readLimit.Wrap(apiHandler.GetAuthorHIndexHandler))
+	mux.HandleFunc("/api/authors/hindex/drift", readLimit.Wrap(graph(apiHandler.GetAuthorHIndexDriftHandler)))
+	mux.HandleFunc("/api/authors/new-works", readLimit.Wrap(graph(apiHandler.GetAuthorNewWorksHandler)))
+	mux.HandleFunc("/api/follows", ingestLimit.Wrap(graph(apiHandler.FollowsHandler)))
+	mux.HandleFunc("/api/digest", readLimit.Wrap(graph(apiHandler.GetDigestHandler)))
+	mux.HandleFunc("/api/authors/works-by-venue", readLimit.Wrap(graph(apiHandler.GetAuthorWorksByVenueHandler)))
+	mux.HandleFunc("/api/export/graphml", ingestLimit.Wrap(graph(apiHandler.ExportGraphMLHandler)))
+	mux.HandleFunc("/api/export/jsonld", ingestLimit.Wrap(graph(apiHandler.ExportJSONLDHandler)))
+	mux.HandleFunc("/readyz", apiHandler.ReadyzHandler)
+	mux.HandleFunc("/api/admin/stats", apiHandler.AdminStatsHandler)
+	mux.HandleFunc("/api/admin/works/duplicates", graph(apiHandler.FindDuplicateWorksHandler))
+	mux.HandleFunc("/api/admin/works/merge", graph(apiHandler.MergeWorksHandler))
+	mux.HandleFunc("/api/admin/authors/merge-candidates", graph(apiHandler.AuthorMergeCandidatesHandler))
+	mux.HandleFunc("/api/admin/venues/aliases", graph(apiHandler.VenueAliasesHandler))
+	mux.HandleFunc("/api/admin/venues/merge", graph(apiHandler.MergeVenuesHandler))
+	mux.HandleFunc("/api/admin/block", graph(apiHandler.BlockHandler))
+	mux.HandleFunc("/api/admin/authors", graph(apiHandler.DeleteAuthorHandler))
+	mux.HandleFunc("/api/admin/prune", graph(apiHandler.PruneOrphansHandler))
+	mux.HandleFunc("/api/admin/works/reconcile-affiliations", graph(apiHandler.ReconcileWorkAffiliationsHandler))
+	mux.Handle("/metrics", metrics.Handler())
+
+	var handler http.Handler = apiHandler.WithTenant(api.Recover(mux))
+	if cfg.GzipResponses {
+		handler = api.Compression{Level: cfg.GzipLevel, MinSize: cfg.GzipMinSize}.Wrap(handler)
+	}
+	return handler
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/api"
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// rewriteTransport sends every request to target instead of its own host.
+type rewriteTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return t.next.RoundTrip(req)
+}
+
+// fakeUpstream answers OpenAlex and Semantic Scholar requests with one minimal entity or
+// page for whatever is asked. The clients use the default transport, which is swapped for
+// the test.
+func fakeUpstream(t *testing.T) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/recommendations/"):
+			fmt.Fprint(w, `{"recommendedPapers": []}`)
+		case r.URL.Path == "/graph/v1/paper/batch":
+			fmt.Fprint(w, `[{"paperId": "649def34f8be52c8b66281af98ae884c09aef38b"}]`)
+		case len(segments) == 2:
+			fmt.Fprintf(w, `{"id": "https://openalex.org/%s", "display_name": "name", "title": "title"}`, segments[1])
+		default:
+			fmt.Fprint(w, `{"meta": {"count": 1, "next_cursor": null}, "results": [{"id": "https://openalex.org/W1", "title": "title", "display_name": "name"}]}`)
+		}
+	}))
+	target, _ := url.Parse(server.URL)
+	original := http.DefaultTransport
+	http.DefaultTransport = rewriteTransport{target: target, next: original}
+	t.Cleanup(func() {
+		http.DefaultTransport = original
+		server.Close()
+	})
+}
+
+// newDisabledRouter returns the service's routes as configured by STORAGE_BACKEND=none.
+func newDisabledRouter(t *testing.T) http.Handler {
+	t.Helper()
+	t.Setenv("STORAGE_BACKEND", config.StorageNone)
+	t.Setenv("GZIP_RESPONSES", "false")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	alex := openalex.NewClient(openalex.WithRateLimit(1000, 100), openalex.WithPageJitter(0, 0))
+	sem := semanticscholar.NewClient("", semanticscholar.WithRateLimit(1000, 100))
+	return newRouter(cfg, api.NewAPIHandler(cfg, storage.NewDisabledRepository(), alex, sem))
+}
+
+func TestRoutesWithStorageDisabled(t *testing.T) {
+	fakeUpstream(t)
+	router := newDisabledRouter(t)
+
+	tests := []struct {
+		method string
+		target string
+		want   int
+	}{
+		// Routes that only proxy OpenAlex and Semantic Scholar keep working.
+		{http.MethodGet, "/api/search?q=graphs", http.StatusOK},
+		{http.MethodGet, "/api/fetch-authors-by-name?name=Ada", http.StatusOK},
+		{http.MethodGet, "/api/ingest-estimate?author_id=A1", http.StatusOK},
+		{http.MethodGet, "/api/sample-works?n=1&seed=1", http.StatusOK},
+		{http.MethodGet, "/api/fetch-recent-works/?id=A1", http.StatusOK},
+		{http.MethodGet, "/api/fetch-abstracts/?id=A1", http.StatusOK},
+		{http.MethodGet, "/api/works/recommendations?doi=10.1/abc", http.StatusOK},
+		{http.MethodGet, "/api/works/ris?id=W1", http.StatusOK},
+		{http.MethodGet, "/api/works/ngrams?id=W1", http.StatusOK},
+		{http.MethodGet, "/api/topics/search?q=graphs", http.StatusOK},
+		{http.MethodGet, "/api/authors/hindex?id=A1", http.StatusOK},
+		{http.MethodGet, "/readyz", http.StatusOK},
+		{http.MethodGet, "/api/admin/stats", http.StatusOK},
+		{http.MethodGet, "/metrics", http.StatusOK},
+
+		// Their options that need the graph don't.
+		{http.MethodGet, "/api/fetch-authors-by-name?name=Ada&ingest=true", http.StatusNotImplemented},
+		{http.MethodGet, "/api/sample-works?n=1&save=true", http.StatusNotImplemented},
+		{http.MethodGet, "/api/fetch-recent-works/?id=A1&source=graph", http.StatusNotImplemented},
+		{http.MethodGet, "/api/works/recommendations?doi=10.1/abc&persist=true", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/hindex?id=A1&computed=true", http.StatusNotImplemented},
+
+		// Routes that read or write the graph answer 501.
+		{http.MethodGet, "/api/fetch-author-by-id?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/fetch-author-by-id/stream?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/fetch-works-by-name?name=graphs", http.StatusNotImplemented},
+		{http.MethodPost, "/api/ingest-authors-bulk", http.StatusNotImplemented},
+		{http.MethodPost, "/api/ingest/query?filter=publication_year:2023", http.StatusNotImplemented},
+		{http.MethodGet, "/api/fetch-venue-by-issn?issn=1234-5678", http.StatusNotImplemented},
+		{http.MethodPost, "/api/authors/sync?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/fetch-institution-by-ror?ror=03yrm5c26", http.StatusNotImplemented},
+		{http.MethodPost, "/api/institutions/enrich", http.StatusNotImplemented},
+		{http.MethodPost, "/api/jobs/job-1/resume", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/ingest-history?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/works/missing-abstracts", http.StatusNotImplemented},
+		{http.MethodGet, "/api/venues/summary?id=S1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/institutions/summary?id=I1", http.StatusNotImplemented},
+		{http.MethodPost, "/api/works/enrich-citation-context?doi=10.1/abc", http.StatusNotImplemented},
+		{http.MethodPost, "/api/works/enrich-embeddings", http.StatusNotImplemented},
+		{http.MethodGet, "/api/works/similar?id=W1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/works/top", http.StatusNotImplemented},
+		{http.MethodPost, "/api/works/deposit", http.StatusNotImplemented},
+		{http.MethodGet, "/api/works/neighborhood?id=W1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/works/provenance?id=W1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/works/export?id=W1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/topics/trending", http.StatusNotImplemented},
+		{http.MethodGet, "/api/topics/bridge?a=T1&b=T2", http.StatusNotImplemented},
+		{http.MethodGet, "/api/topics/bridge/authors?a=T1&b=T2", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/collaboration-map?id=A1", http.StatusNotImplemented},
+		{http.MethodPost, "/api/authors/enrich-ss?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/search?q=Ada", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/work-types?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/topics?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/funders?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/hindex/drift?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/new-works?id=A1&since=2024-01-01", http.StatusNotImplemented},
+		{http.MethodGet, "/api/follows", http.StatusNotImplemented},
+		{http.MethodGet, "/api/digest", http.StatusNotImplemented},
+		{http.MethodGet, "/api/authors/works-by-venue?id=A1", http.StatusNotImplemented},
+		{http.MethodGet, "/api/export/graphml", http.StatusNotImplemented},
+		{http.MethodGet, "/api/export/jsonld", http.StatusNotImplemented},
+		{http.MethodGet, "/api/admin/works/duplicates", http.StatusNotImplemented},
+		{http.MethodPost, "/api/admin/works/merge", http.StatusNotImplemented},
+		{http.MethodGet, "/api/admin/authors/merge-candidates", http.StatusNotImplemented},
+		{http.MethodGet, "/api/admin/venues/aliases", http.StatusNotImplemented},
+		{http.MethodPost, "/api/admin/venues/merge", http.StatusNotImplemented},
+		{http.MethodPost, "/api/admin/block?id=A1", http.StatusNotImplemented},
+		{http.MethodDelete, "/api/admin/authors?id=A1", http.StatusNotImplemented},
+		{http.MethodPost, "/api/admin/prune", http.StatusNotImplemented},
+		{http.MethodPost, "/api/admin/works/reconcile-affiliations", http.StatusNotImplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusNotImplemented && !strings.Contains(rec.Body.String(), "STORAGE_BACKEND=none") {
+				t.Errorf("body = %s, want it to explain that storage is disabled", rec.Body)
+			}
+		})
+	}
+}
+
+func TestReadyzWithStorageDisabled(t *testing.T) {
+	router := newDisabledRouter(t)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Status       string `json:"status"`
+		Dependencies map[string]struct {
+			Status string `json:"status"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if body.Status != "ready" || body.Dependencies["neo4j"].Status != "disabled" {
+		t.Errorf("readiness = %+v, want ready with neo4j disabled", body)
+	}
+}
+
+// Routes that ingest or fan out upstream get the strict ingest limit; plain reads and
+// single OpenAlex lookups get the read limit.
+func TestRouteRateLimits(t *testing.T) {
+	fakeUpstream(t)
+	t.Setenv("INGEST_RATE_LIMIT", "0.001")
+	t.Setenv("INGEST_RATE_BURST", "1")
+	t.Setenv("READ_RATE_LIMIT", "0.001")
+	t.Setenv("READ_RATE_BURST", "2")
+	router := newDisabledRouter(t)
+
+	tests := []struct {
+		target     string
+		wantStrict bool
+	}{
+		{"/api/fetch-authors-by-name?name=Ada&ingest=false", true},
+		{"/api/fetch-abstracts/?id=A1", true},
+		{"/api/sample-works?n=1&seed=1", true},
+		{"/api/search?q=graphs", false},
+		{"/api/fetch-recent-works/?id=A1", false},
+		{"/api/ingest-estimate?author_id=A1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			var codes []int
+			for range 2 {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+				codes = append(codes, rec.Code)
+			}
+			want := []int{http.StatusOK, http.StatusOK}
+			if tt.wantStrict {
+				want[1] = http.StatusTooManyRequests
+			}
+			if codes[0] != want[0] || codes[1] != want[1] {
+				t.Errorf("statuses = %v, want %v", codes, want)
+			}
+		})
+	}
+}
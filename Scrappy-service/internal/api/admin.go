@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// FindDuplicateWorksHandler lists groups of works that share a DOI and are candidates for MergeWorksHandler.
+func (h *APIHandler) FindDuplicateWorksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	groups, err := h.repo.FindDuplicateWorksByDOI(ctx)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, groups)
+}
+
+// MergeWorksHandler folds duplicate works into one node. Expects a POST body of
+// {"keepId": "...", "mergeIds": ["...", ...]}.
+func (h *APIHandler) MergeWorksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	var req dto.MergeWorksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.KeepID == "" || len(req.MergeIDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Request must contain 'keepId' and a non-empty 'mergeIds' array")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	err := h.repo.MergeWorks(ctx, req.KeepID, req.MergeIDs)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.ErrConflictingDOIs):
+		respondWithError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	default:
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"keptId": req.KeepID, "mergedIds": req.MergeIDs})
+	}
+}
+
+// VenueAliasesHandler deals with journals that occupy several Venue nodes because OpenAlex
+// gave them new source IDs over time. GET lists the groups of venues sharing an ISSN-L;
+// POST merges each group (or only the one given with ?issn_l=...) into its venue with the
+// most works.
+func (h *APIHandler) VenueAliasesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use GET or POST")
+		return
+	}
+	groups, err := h.repo.FindVenueAliases(ctx)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if r.Method == http.MethodGet {
+		respondWithJSON(w, http.StatusOK, groups)
+		return
+	}
+
+	issnL := strings.TrimSpace(r.URL.Query().Get("issn_l"))
+	merged := map[string][]string{}
+	failed := map[string]string{}
+	for _, group := range groups {
+		if issnL != "" && group.IssnL != issnL {
+			continue
+		}
+		keepID := group.Venues[0].ID
+		var mergeIDs []string
+		for _, venue := range group.Venues[1:] {
+			mergeIDs = append(mergeIDs, venue.ID)
+		}
+		if err := h.repo.MergeVenues(ctx, keepID, mergeIDs); err != nil {
+			failed[group.IssnL] = err.Error()
+			continue
+		}
+		merged[keepID] = mergeIDs
+	}
+	if issnL != "" && len(merged) == 0 && len(failed) == 0 {
+		respondWithError(w, http.StatusNotFound, "No venues share ISSN-L "+issnL)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"merged": merged, "failed": failed})
+}
+
+// MergeVenuesHandler folds venues into one node. Expects a POST body of
+// {"keepId": "...", "mergeIds": ["...", ...]}.
+func (h *APIHandler) MergeVenuesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	var req dto.MergeVenuesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.KeepID == "" || len(req.MergeIDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Request must contain 'keepId' and a non-empty 'mergeIds' array")
+		return
+	}
+
+	keepID := canonicalOpenAlexID(req.KeepID)
+	mergeIDs := make([]string, 0, len(req.MergeIDs))
+	for _, id := range req.MergeIDs {
+		mergeIDs = append(mergeIDs, canonicalOpenAlexID(id))
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	err := h.repo.MergeVenues(ctx, keepID, mergeIDs)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.ErrConflictingISSNs):
+		respondWithError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	default:
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"keptId": keepID, "mergedIds": mergeIDs})
+	}
+}
+
+// PruneOrphansHandler deletes nodes without any relationships (POST ?labels=Work,Author;
+// Work by default) and reports how many were removed.
+func (h *APIHandler) PruneOrphansHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	labels := []string{"Work"}
+	if raw := r.URL.Query().Get("labels"); raw != "" {
+		labels = strings.Split(raw, ",")
+		for i := range labels {
+			labels[i] = strings.TrimSpace(labels[i])
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	deleted, err := h.repo.PruneOrphans(ctx, labels)
+	if errors.Is(err, storage.ErrInvalidLabel) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("%v (allowed: %s)", err, strings.Join(storage.PrunableLabels, ", ")))
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"labels": labels, "deleted": deleted})
+}
+
+// ReconcileWorkAffiliationsHandler creates the AFFILIATED_ON_WORK edges of works saved
+// before they were written, from the institutionIds of their authorships (POST), and
+// reports how many were created.
+func (h *APIHandler) ReconcileWorkAffiliationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	created, err := h.repo.ReconcileWorkAffiliations(ctx)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"created": created})
+}
+
+// AuthorMergeCandidatesHandler deals with authors OpenAlex merged that still occupy two
+// nodes. GET lists them; POST merges each old node's relationships into its canonical
+// author (or only the one given with ?id=...), leaving the old ID as an alias.
+func (h *APIHandler) AuthorMergeCandidatesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	switch r.Method {
+	case http.MethodGet:
+		candidates, err := h.repo.FindAuthorMergeCandidates(ctx)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondWithJSON(w, http.StatusOK, candidates)
+	case http.MethodPost:
+		var oldIDs []string
+		if raw := r.URL.Query().Get("id"); raw != "" {
+			oldIDs = []string{canonicalOpenAlexID(raw)}
+		} else {
+			candidates, err := h.repo.FindAuthorMergeCandidates(ctx)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			for _, c := range candidates {
+				oldIDs = append(oldIDs, c.OldID)
+			}
+		}
+		merged := []string{}
+		failed := map[string]string{}
+		for _, id := range oldIDs {
+			err := h.repo.MergeAuthorAlias(ctx, id)
+			if errors.Is(err, storage.ErrNotFound) && len(oldIDs) == 1 {
+				respondWithError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			if err != nil {
+				failed[id] = err.Error()
+				continue
+			}
+			merged = append(merged, id)
+		}
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"merged": merged, "failed": failed})
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Use GET or POST")
+	}
+}
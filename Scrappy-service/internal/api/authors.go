@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/geo"
+	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// GetCollaborationMapHandler aggregates an author's collaborations by the country of their
+// coauthors' institutions. It returns a country→shared-works map, or a GeoJSON
+// FeatureCollection of country centroids with ?format=geojson.
+func (h *APIHandler) GetCollaborationMapHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "geojson" {
+		respondWithError(w, http.StatusBadRequest, "'format' must be 'json' or 'geojson'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	counts, err := h.repo.CountCollaborationsByCountry(ctx, h.resolveAuthorID(ctx, authorID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if format != "geojson" {
+		respondWithJSON(w, http.StatusOK, counts)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, collaborationsToGeoJSON(counts))
+}
+
+// GetAuthorWorkTypesHandler returns how many of the author's works in the graph are of each
+// OpenAlex type, e.g. {"article": 41, "book-chapter": 3}, for a profile's donut chart.
+func (h *APIHandler) GetAuthorWorkTypesHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	id := h.resolveAuthorID(ctx, authorID)
+	counts, err := h.repo.GetAuthorWorkTypeBreakdown(ctx, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"authorId": id, "types": counts})
+}
+
+// collaborationsToGeoJSON turns per-country counts into point features, largest first.
+func collaborationsToGeoJSON(counts map[string]int) dto.GeoJSONFeatureCollection {
+	countries := make([]string, 0, len(counts))
+	for country := range counts {
+		countries = append(countries, country)
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		if counts[countries[i]] != counts[countries[j]] {
+			return counts[countries[i]] > counts[countries[j]]
+		}
+		return countries[i] < countries[j]
+	})
+
+	fc := dto.GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []dto.GeoJSONFeature{}}
+	for _, country := range countries {
+		feature := dto.GeoJSONFeature{
+			Type:       "Feature",
+			Properties: map[string]interface{}{"countryCode": country, "sharedWorks": counts[country]},
+		}
+		if p, ok := geo.CountryCentroid(country); ok {
+			feature.Geometry = &dto.GeoJSONPoint{Type: "Point", Coordinates: [2]float64{p.Lon, p.Lat}}
+		}
+		fc.Features = append(fc.Features, feature)
+	}
+	return fc
+}
+
+// EnrichAuthorFromSemanticScholarHandler cross-checks an ingested author against Semantic
+// Scholar, found by the author's ORCID, and stores its h-index, paper count and ID on the
+// Author node. Authors without an ORCID can't be matched reliably and get a 422.
+func (h *APIHandler) EnrichAuthorFromSemanticScholarHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	author, err := h.alexClient.FetchAuthorById(authorID)
+	if err != nil {
+		respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch author from OpenAlex: %v", err))
+		return
+	}
+	if author.Orcid == "" {
+		respondWithError(w, http.StatusUnprocessableEntity,
+			"Author has no ORCID in OpenAlex, so it can't be matched to a Semantic Scholar profile reliably")
+		return
+	}
+
+	ssAuthor, err := h.semClient.FetchAuthorByExternalID(ctx, semanticscholar.AuthorKindORCID, author.Orcid)
+	if errors.Is(err, semanticscholar.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, fmt.Sprintf("Failed to fetch author from Semantic Scholar: %v", err))
+		return
+	}
+
+	err = h.repo.SaveAuthorSSEnrichment(ctx, author.ID, storage.SSAuthorEnrichment{
+		SSAuthorID:   ssAuthor.AuthorID,
+		SSHIndex:     ssAuthor.HIndex,
+		SSPaperCount: ssAuthor.PaperCount,
+		SSHomepage:   ssAuthor.Homepage,
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Author has not been ingested yet; ingest it before enriching")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	discrepancy := author.SummaryStats.HIndex - ssAuthor.HIndex
+	if discrepancy < 0 {
+		discrepancy = -discrepancy
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                author.ID,
+		"orcid":             author.Orcid,
+		"ssAuthorId":        ssAuthor.AuthorID,
+		"ssHIndex":          ssAuthor.HIndex,
+		"ssPaperCount":      ssAuthor.PaperCount,
+		"ssHomepage":        ssAuthor.Homepage,
+		"openAlexHIndex":    author.SummaryStats.HIndex,
+		"openAlexWorks":     author.WorksCount,
+		"hIndexDiscrepancy": discrepancy,
+	})
+}
+
+// GetAuthorHIndexHandler returns an author's h-index. By default this is OpenAlex's value;
+// with computed=true it is recomputed from the works ingested into the graph, which may be
+// lower if not all of the author's works have been ingested. "source" says which one it is.
+func (h *APIHandler) GetAuthorHIndexHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	if r.URL.Query().Get("computed") == "true" {
+		if h.storageDisabled(w) {
+			return
+		}
+		id := h.resolveAuthorID(ctx, authorID)
+		hIndex, err := h.repo.ComputeHIndex(ctx, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Author is not in the graph")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"id": id, "hIndex": hIndex, "source": "graph"})
+		return
+	}
+
+	author, err := h.alexClient.FetchAuthorById(authorID)
+	if err != nil {
+		respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch author from OpenAlex: %v", err))
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"id": author.ID, "hIndex": author.SummaryStats.HIndex, "source": "openalex"})
+}
+
+// GetAuthorTopicProfileHandler returns the author's topics rolled up the topic hierarchy:
+// domains, fields, subfields and topics, each with its paper count and percentage of the
+// author's total.
+func (h *APIHandler) GetAuthorTopicProfileHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	profile, err := h.repo.GetAuthorTopicProfile(ctx, h.resolveAuthorID(ctx, authorID))
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Author is not in the graph")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSONIfChanged(w, r, profile)
+}
+
+// GetAuthorFundersHandler returns who funds the author: the funders of the author's works,
+// each with the number of works it funded and of distinct awards, most works first. The
+// list is empty when none of the works has grant data.
+func (h *APIHandler) GetAuthorFundersHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	funders, err := h.repo.GetAuthorFunders(ctx, h.resolveAuthorID(ctx, authorID))
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Author is not in the graph")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, funders)
+}
+
+// GetAuthorNewWorksHandler lists an author's works that were first saved to the graph
+// after since, e.g. ?id=A5023896336&since=2024-01-01T00:00:00Z. since is an RFC 3339
+// timestamp (any offset) or a date, read as midnight UTC.
+func (h *APIHandler) GetAuthorNewWorksHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+	since, err := parseSince(r.URL.Query().Get("since"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fields, err := fieldsParam(r, storage.NewWork{})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	works, err := h.repo.GetWorksAddedSince(ctx, h.resolveAuthorID(ctx, authorID), since)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSONIfChanged(w, r, map[string]interface{}{
+		"since": since.Format(time.RFC3339),
+		"works": fields.project(works),
+	})
+}
+
+// parseSince parses a required since parameter as an RFC 3339 timestamp or a YYYY-MM-DD
+// date (midnight UTC), and returns it in UTC.
+func parseSince(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, errors.New("Missing 'since' query parameter")
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("'since' must be an RFC 3339 timestamp or a YYYY-MM-DD date, got %q", raw)
+}
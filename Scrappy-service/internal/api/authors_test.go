@@ -0,0 +1,242 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestCollaborationsToGeoJSON(t *testing.T) {
+	fc := collaborationsToGeoJSON(map[string]int{"DE": 3, "IN": 7, "FR": 3, "ZZ": 1})
+
+	if fc.Type != "FeatureCollection" {
+		t.Errorf("type = %q", fc.Type)
+	}
+	var order []string
+	for _, f := range fc.Features {
+		order = append(order, f.Properties["countryCode"].(string))
+	}
+	// Largest first, ties by country code.
+	if want := []string{"IN", "DE", "FR", "ZZ"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	in := fc.Features[0]
+	if in.Properties["sharedWorks"] != 7 {
+		t.Errorf("IN sharedWorks = %v, want 7", in.Properties["sharedWorks"])
+	}
+	if in.Geometry == nil || in.Geometry.Type != "Point" {
+		t.Fatalf("IN geometry = %+v, want a point", in.Geometry)
+	}
+	// GeoJSON positions are [longitude, latitude].
+	if lon, lat := in.Geometry.Coordinates[0], in.Geometry.Coordinates[1]; lon < 68 || lon > 98 || lat < 6 || lat > 36 {
+		t.Errorf("IN coordinates = %v, not in India", in.Geometry.Coordinates)
+	}
+	if zz := fc.Features[3]; zz.Geometry != nil {
+		t.Errorf("unknown country has geometry %+v, want null", zz.Geometry)
+	}
+}
+
+func TestCollaborationsToGeoJSONEmpty(t *testing.T) {
+	body, err := json.Marshal(collaborationsToGeoJSON(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"type":"FeatureCollection","features":[]}`; string(body) != want {
+		t.Errorf("empty collection = %s, want %s", body, want)
+	}
+}
+
+func TestGetCollaborationMapHandler(t *testing.T) {
+	repo := newFakeRepo()
+	repo.collaborations = map[string]map[string]int{
+		"https://openalex.org/A5023888391": {"US": 4, "GB": 2},
+	}
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		check      func(t *testing.T, body []byte)
+	}{
+		{
+			name:       "json",
+			query:      "id=A5023888391",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var counts map[string]int
+				json.Unmarshal(body, &counts)
+				if want := map[string]int{"US": 4, "GB": 2}; !reflect.DeepEqual(counts, want) {
+					t.Errorf("counts = %v, want %v", counts, want)
+				}
+			},
+		},
+		{
+			name:       "geojson with url id",
+			query:      "id=https://openalex.org/A5023888391&format=geojson",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var fc dto.GeoJSONFeatureCollection
+				json.Unmarshal(body, &fc)
+				if len(fc.Features) != 2 || fc.Features[0].Properties["countryCode"] != "US" {
+					t.Errorf("features = %+v, want US then GB", fc.Features)
+				}
+			},
+		},
+		{name: "unknown format", query: "id=A5023888391&format=kml", wantStatus: http.StatusBadRequest},
+		{name: "missing id", query: "", wantStatus: http.StatusBadRequest},
+		{name: "work id", query: "id=W2741809807", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.GetCollaborationMapHandler(rec, httptest.NewRequest(http.MethodGet, "/api/authors/collaboration-map?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.check != nil {
+				tt.check(t, rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2024-01-01T00:00:00Z", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2024-01-01T02:00:00+02:00", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2023-12-31T19:30:00-05:00", time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC), false},
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"", time.Time{}, true},
+		{"2024-01-01 00:00:00", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSince(%q): err = %v, want error %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("parseSince(%q) = %v, want %v in UTC", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestGetAuthorNewWorksHandler(t *testing.T) {
+	repo := newFakeRepo()
+	firstSeen := func(hour int) time.Time { return time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC) }
+	repo.newWorks = []storage.NewWork{
+		{DehydratedWork: domain.DehydratedWork{ID: "W2", PublicationDate: "2023-06-01"}, FirstSeen: firstSeen(12)},
+		{DehydratedWork: domain.DehydratedWork{ID: "W1", PublicationDate: "2022-01-01"}, FirstSeen: firstSeen(6)},
+	}
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantSince  string
+		wantWorks  []string
+	}{
+		{"all", "id=A1&since=2024-01-01", http.StatusOK, "2024-01-01T00:00:00Z", []string{"W2", "W1"}},
+		{"utc cutoff", "id=A1&since=2024-01-01T08:00:00Z", http.StatusOK, "2024-01-01T08:00:00Z", []string{"W2"}},
+		{"offset cutoff", "id=A1&since=2024-01-01T08:00:00%2B04:00", http.StatusOK, "2024-01-01T04:00:00Z", []string{"W2", "W1"}},
+		{"offset before both", "id=A1&since=2024-01-01T08:00:00-04:00", http.StatusOK, "2024-01-01T12:00:00Z", []string{}},
+		{"missing since", "id=A1", http.StatusBadRequest, "", nil},
+		{"malformed since", "id=A1&since=last+week", http.StatusBadRequest, "", nil},
+		{"missing id", "since=2024-01-01", http.StatusBadRequest, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.GetAuthorNewWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/authors/new-works?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				Since string `json:"since"`
+				Works []struct {
+					ID              string `json:"id"`
+					PublicationDate string `json:"publication_date"`
+					FirstSeen       string `json:"firstSeen"`
+				} `json:"works"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if body.Since != tt.wantSince {
+				t.Errorf("since = %q, want %q", body.Since, tt.wantSince)
+			}
+			var ids []string
+			for _, work := range body.Works {
+				ids = append(ids, work.ID)
+				if work.PublicationDate == "" || work.FirstSeen == "" {
+					t.Errorf("work %s lacks its publication date or firstSeen: %+v", work.ID, work)
+				}
+			}
+			if len(ids) != len(tt.wantWorks) || len(ids) > 0 && !reflect.DeepEqual(ids, tt.wantWorks) {
+				t.Errorf("works = %v, want %v", ids, tt.wantWorks)
+			}
+		})
+	}
+}
+
+func TestGetAuthorTopicProfileHandler(t *testing.T) {
+	repo := newFakeRepo()
+	repo.aliases["https://openalex.org/A0"] = "https://openalex.org/A1"
+	repo.topicProfiles = map[string]*storage.TopicProfile{
+		"https://openalex.org/A1": {AuthorID: "https://openalex.org/A1", TotalPapers: 4, Domains: []storage.TopicNode{
+			{ID: "D1", DisplayName: "Physical Sciences", PaperCount: 3, Percentage: 75, Children: []storage.TopicNode{
+				{ID: "F1", DisplayName: "Computer Science", PaperCount: 3, Percentage: 75},
+			}},
+			{ID: storage.UncategorizedTopic, DisplayName: "Uncategorized", PaperCount: 1, Percentage: 25},
+		}},
+	}
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"profile", "id=A1", http.StatusOK},
+		{"through an alias", "id=A0", http.StatusOK},
+		{"not in the graph", "id=A2", http.StatusNotFound},
+		{"missing id", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.GetAuthorTopicProfileHandler(rec, httptest.NewRequest(http.MethodGet, "/api/authors/topics?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body storage.TopicProfile
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if want := repo.topicProfiles["https://openalex.org/A1"]; !reflect.DeepEqual(&body, want) {
+				t.Errorf("profile = %+v, want %+v", body, *want)
+			}
+		})
+	}
+}
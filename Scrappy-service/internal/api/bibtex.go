@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// bibtexTypes maps OpenAlex work types to BibTeX entry types; anything else is misc.
+var bibtexTypes = map[string]string{
+	"article":             "article",
+	"journal-article":     "article",
+	"review":              "article",
+	"letter":              "article",
+	"editorial":           "article",
+	"proceedings-article": "inproceedings",
+	"book":                "book",
+	"book-chapter":        "incollection",
+	"dissertation":        "phdthesis",
+	"report":              "techreport",
+}
+
+// bibtexVenueFields is the field that names the venue in each entry type; howpublished
+// for the rest.
+var bibtexVenueFields = map[string]string{
+	"article":       "journal",
+	"inproceedings": "booktitle",
+	"incollection":  "booktitle",
+	"techreport":    "institution",
+}
+
+// bibtexSpecials are the characters written as LaTeX commands, with their commands: LaTeX's
+// special characters and the accented Latin letters.
+var bibtexSpecials = bibtexSpecialChars()
+
+func bibtexSpecialChars() map[rune]string {
+	specials := map[rune]string{
+		'\\': `\textbackslash{}`, '{': `\{`, '}': `\}`, '&': `\&`, '%': `\%`, '$': `\$`,
+		'#': `\#`, '_': `\_`, '~': `\textasciitilde{}`, '^': `\textasciicircum{}`,
+		'ß': `{\ss}`, 'æ': `{\ae}`, 'Æ': `{\AE}`, 'ø': `{\o}`, 'Ø': `{\O}`, 'ł': `{\l}`,
+		'Ł': `{\L}`, 'œ': `{\oe}`, 'Œ': `{\OE}`, 'ı': `{\i}`,
+	}
+	// Accented letters, as accent command and pairs of accented and base letter.
+	for _, accent := range []struct{ cmd, pairs string }{
+		{`\'`, "áaéeíióoúuýyÁAÉEÍIÓOÚUÝYćcĆCĺlĹLńnŃNŕrŔRśsŚSźzŹZ"},
+		{"\\`", "àaèeìiòoùuÀAÈEÌIÒOÙU"},
+		{`\^`, "âaêeîiôoûuÂAÊEÎIÔOÛUĉcĈCĝgĜGĥhĤHĵjĴJŝsŜSŵwŴWŷyŶY"},
+		{`\"`, "äaëeïiöoüuÿyÄAËEÏIÖOÜUŸY"},
+		{`\~`, "ãaõoñnÃAÕOÑNĩiĨIũuŨU"},
+		{`\=`, "āaĀAēeĒEīiĪIōoŌOūuŪU"},
+		{`\.`, "żzŻZėeĖEİI"},
+		{`\c `, "çcÇCşsŞSţtŢTķkĶKļlĻLņnŅNŗrŖRģgĢG"},
+		{`\v `, "čcČCďdĎDěeĚEňnŇNřrŘRšsŠSťtŤTžzŽZ"},
+		{`\u `, "ăaĂAğgĞGŭuŬU"},
+		{`\H `, "őoŐOűuŰU"},
+		{`\r `, "åaÅAůuŮU"},
+		{`\k `, "ąaĄAęeĘEįiĮIųuŲU"},
+	} {
+		runes := []rune(accent.pairs)
+		for i := 0; i+1 < len(runes); i += 2 {
+			specials[runes[i]] = "{" + accent.cmd + string(runes[i+1]) + "}"
+		}
+	}
+	return specials
+}
+
+// bibtexEscape makes s safe inside a braced BibTeX field: whitespace is collapsed to single
+// spaces, LaTeX's special characters are escaped and accented Latin letters are written as
+// accent commands, so the entry works with plain BibTeX as well as Biber. Other non-ASCII
+// characters are kept as UTF-8.
+func bibtexEscape(s string) string {
+	var b strings.Builder
+	for _, r := range strings.Join(strings.Fields(s), " ") {
+		if special, ok := bibtexSpecials[r]; ok {
+			b.WriteString(special)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var bibtexAndPattern = regexp.MustCompile(`(?i)\sand\s`)
+
+// bibtexName escapes an author name for the author list. Names BibTeX would split, those
+// with a comma (read as "Last, First") or the word "and" (read as the next author), are
+// braced so they come through as written.
+func bibtexName(name string) string {
+	escaped := bibtexEscape(name)
+	if strings.Contains(name, ",") || bibtexAndPattern.MatchString(name) {
+		return "{" + escaped + "}"
+	}
+	return escaped
+}
+
+// writeBibTeX writes one work as a BibTeX entry keyed by its short ID, with the entry type
+// chosen from the work type and the authors in authorship order.
+func writeBibTeX(w io.Writer, work domain.Work) {
+	entryType, ok := bibtexTypes[work.Type]
+	if !ok {
+		entryType = "misc"
+	}
+	fmt.Fprintf(w, "@%s{%s,\n", entryType, strings.TrimPrefix(work.ID, "https://openalex.org/"))
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "  %s = {%s},\n", name, value)
+		}
+	}
+
+	field("title", bibtexEscape(work.Title))
+	var authors []string
+	for _, authorship := range work.Authorships {
+		if name := strings.TrimSpace(authorship.Author.DisplayName); name != "" {
+			authors = append(authors, bibtexName(name))
+		}
+	}
+	field("author", strings.Join(authors, " and "))
+	if work.PrimaryLocation != nil && work.PrimaryLocation.Source != nil {
+		venueField, ok := bibtexVenueFields[entryType]
+		if !ok {
+			venueField = "howpublished"
+		}
+		field(venueField, bibtexEscape(work.PrimaryLocation.Source.DisplayName))
+	}
+	if work.PublicationYear != 0 {
+		field("year", fmt.Sprintf("%d", work.PublicationYear))
+	}
+	field("volume", bibtexEscape(work.Biblio.Volume))
+	field("number", bibtexEscape(work.Biblio.Issue))
+	pages := bibtexEscape(work.Biblio.FirstPage)
+	if last := bibtexEscape(work.Biblio.LastPage); pages == "" {
+		pages = last
+	} else if last != "" && last != pages {
+		pages += "--" + last
+	}
+	field("pages", pages)
+	// DOIs are written as they are: the field is read verbatim, and they can't hold braces.
+	field("doi", strings.NewReplacer("{", "", "}", "").Replace(domain.NormalizeDOI(work.Doi)))
+	field("abstract", bibtexEscape(work.Abstract))
+	io.WriteString(w, "}\n\n")
+}
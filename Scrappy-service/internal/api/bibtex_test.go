@@ -0,0 +1,285 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// bibEntry is a BibTeX entry as parseBibTeX reads it, with the raw field values.
+type bibEntry struct {
+	entryType string
+	key       string
+	fields    map[string]string
+}
+
+// parseBibTeX reads the entries of s: @type{key, then name = {value} fields separated by
+// commas, up to the closing brace. Values may hold nested braces.
+func parseBibTeX(s string) ([]bibEntry, error) {
+	var entries []bibEntry
+	for {
+		at := strings.IndexByte(s, '@')
+		if at < 0 {
+			if strings.TrimSpace(s) != "" {
+				return nil, fmt.Errorf("text outside entries: %q", s)
+			}
+			return entries, nil
+		}
+		s = s[at+1:]
+		open := strings.IndexByte(s, '{')
+		comma := strings.IndexByte(s, ',')
+		if open < 0 || comma < open {
+			return nil, fmt.Errorf("entry without type and key: %q", s)
+		}
+		entry := bibEntry{entryType: s[:open], key: s[open+1 : comma], fields: map[string]string{}}
+		s = s[comma+1:]
+		for {
+			s = strings.TrimLeft(s, " \n,")
+			if strings.HasPrefix(s, "}") {
+				s = s[1:]
+				break
+			}
+			eq := strings.IndexByte(s, '=')
+			if eq < 0 {
+				return nil, fmt.Errorf("entry %s: field without value: %q", entry.key, s)
+			}
+			name := strings.TrimSpace(s[:eq])
+			s = strings.TrimLeft(s[eq+1:], " ")
+			if !strings.HasPrefix(s, "{") {
+				return nil, fmt.Errorf("entry %s: field %s isn't braced", entry.key, name)
+			}
+			end := closingBrace(s)
+			if end < 0 {
+				return nil, fmt.Errorf("entry %s: field %s is never closed", entry.key, name)
+			}
+			entry.fields[name] = s[1:end]
+			s = s[end+1:]
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// closingBrace returns the index of the brace closing the one s starts with, or -1.
+// Escaped braces don't count.
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// bibtexUnescape turns an escaped value back into text, reading each LaTeX command
+// bibtexEscape writes as its character.
+func bibtexUnescape(s string) string {
+	var b strings.Builder
+outer:
+	for len(s) > 0 {
+		longest, char := "", rune(0)
+		for r, special := range bibtexSpecials {
+			if len(special) > len(longest) && strings.HasPrefix(s, special) {
+				longest, char = special, r
+			}
+		}
+		if longest != "" {
+			b.WriteRune(char)
+			s = s[len(longest):]
+			continue outer
+		}
+		b.WriteByte(s[0])
+		s = s[1:]
+	}
+	return b.String()
+}
+
+// bibtexAuthors splits an author field into names: at " and " outside braces, with the
+// braces around a whole name dropped.
+func bibtexAuthors(field string) []string {
+	var names []string
+	depth, start := 0, 0
+	for i := 0; i < len(field); i++ {
+		switch {
+		case field[i] == '\\':
+			i++
+		case field[i] == '{':
+			depth++
+		case field[i] == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(field[i:], " and "):
+			names = append(names, field[start:i])
+			start = i + len(" and ")
+		}
+	}
+	names = append(names, field[start:])
+	for i, name := range names {
+		if strings.HasPrefix(name, "{") && closingBrace(name) == len(name)-1 {
+			name = name[1 : len(name)-1]
+		}
+		names[i] = bibtexUnescape(name)
+	}
+	return names
+}
+
+func TestWriteBibTeXRoundTrip(t *testing.T) {
+	authors := []string{
+		"Doe, Jane",
+		"Smith and Wesson Laboratory",
+		"José Núñez-Ćwik",
+		"Łukasz Øster",
+		"Anand & Sons, Ltd.",
+		"Émile Zola",
+		"山田 太郎",
+	}
+	work := domain.Work{
+		ID:              "https://openalex.org/W42",
+		Type:            "article",
+		Title:           `On {Braces}, 100% of $5 costs_ #1 ~ ^ \ in Zürich: naïve Ærø, ĳ and 中文`,
+		Doi:             "https://doi.org/10.1000/ABC{1}",
+		PublicationYear: 2021,
+		Biblio:          domain.Biblio{Volume: "7", Issue: "2", FirstPage: "12", LastPage: "34"},
+		PrimaryLocation: &domain.Location{Source: &domain.Source{DisplayName: "Journal of R&D"}},
+		Abstract:        "Multi-line\n  abstract with   spaces.",
+	}
+	for _, name := range authors {
+		work.Authorships = append(work.Authorships, domain.Authorship{Author: domain.DehydratedAuthor{DisplayName: name}})
+	}
+	work.Authorships = append(work.Authorships, domain.Authorship{Author: domain.DehydratedAuthor{DisplayName: " "}})
+
+	var b strings.Builder
+	writeBibTeX(&b, work)
+	writeBibTeX(&b, domain.Work{ID: "LOCAL-0123456789abcdef", Title: "Second"})
+	entries, err := parseBibTeX(b.String())
+	if err != nil {
+		t.Fatalf("parsing\n%s\n%v", b.String(), err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2:\n%s", len(entries), b.String())
+	}
+
+	entry := entries[0]
+	if entry.entryType != "article" || entry.key != "W42" {
+		t.Errorf("entry = @%s{%s, want @article{W42", entry.entryType, entry.key)
+	}
+	if got := bibtexAuthors(entry.fields["author"]); !reflect.DeepEqual(got, authors) {
+		t.Errorf("authors = %q, want %q", got, authors)
+	}
+	want := map[string]string{
+		"title":    work.Title,
+		"journal":  "Journal of R&D",
+		"year":     "2021",
+		"volume":   "7",
+		"number":   "2",
+		"pages":    "12--34",
+		"doi":      "10.1000/abc1",
+		"abstract": "Multi-line abstract with spaces.",
+	}
+	for name, value := range want {
+		if got := bibtexUnescape(entry.fields[name]); got != value {
+			t.Errorf("%s = %q (%q), want %q", name, got, entry.fields[name], value)
+		}
+	}
+	if len(entry.fields) != len(want)+1 {
+		t.Errorf("fields = %q, want only author and %v", entry.fields, want)
+	}
+	// Plain BibTeX doesn't read UTF-8 accents; they are written as commands.
+	for _, raw := range []string{`Z{\"u}rich`, `{\'E}mile`, `{\AE}r{\o}`, `{\L}ukasz`, `{Doe, Jane}`} {
+		if !strings.Contains(b.String(), raw) {
+			t.Errorf("entry doesn't contain %s:\n%s", raw, b.String())
+		}
+	}
+
+	if second := entries[1]; second.entryType != "misc" || second.key != "LOCAL-0123456789abcdef" || len(second.fields) != 1 {
+		t.Errorf("second entry = %+v, want a misc entry with only a title", second)
+	}
+}
+
+func TestWriteBibTeXEntryTypes(t *testing.T) {
+	tests := []struct {
+		workType  string
+		wantType  string
+		wantVenue string
+	}{
+		{"article", "article", "journal"},
+		{"journal-article", "article", "journal"},
+		{"review", "article", "journal"},
+		{"proceedings-article", "inproceedings", "booktitle"},
+		{"book-chapter", "incollection", "booktitle"},
+		{"book", "book", "howpublished"},
+		{"dissertation", "phdthesis", "howpublished"},
+		{"report", "techreport", "institution"},
+		{"dataset", "misc", "howpublished"},
+		{"", "misc", "howpublished"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.workType, func(t *testing.T) {
+			var b strings.Builder
+			writeBibTeX(&b, domain.Work{ID: "W1", Type: tt.workType, Title: "T",
+				PrimaryLocation: &domain.Location{Source: &domain.Source{DisplayName: "Venue"}}})
+			entries, err := parseBibTeX(b.String())
+			if err != nil || len(entries) != 1 {
+				t.Fatalf("parsing %q: %v", b.String(), err)
+			}
+			if entries[0].entryType != tt.wantType || entries[0].fields[tt.wantVenue] != "Venue" {
+				t.Errorf("entry = %+v, want @%s with %s = Venue", entries[0], tt.wantType, tt.wantVenue)
+			}
+		})
+	}
+}
+
+func TestWriteBibTeXPages(t *testing.T) {
+	tests := []struct {
+		first, last string
+		want        string
+	}{
+		{"12", "34", "12--34"},
+		{"12", "12", "12"},
+		{"12", "", "12"},
+		{"", "34", "34"},
+		{"e1001", "", "e1001"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		writeBibTeX(&b, domain.Work{ID: "W1", Biblio: domain.Biblio{FirstPage: tt.first, LastPage: tt.last}})
+		entries, err := parseBibTeX(b.String())
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("parsing %q: %v", b.String(), err)
+		}
+		if got := entries[0].fields["pages"]; got != tt.want {
+			t.Errorf("pages %q-%q = %q, want %q", tt.first, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestWriteRIS(t *testing.T) {
+	var b strings.Builder
+	writeRIS(&b, domain.Work{
+		ID: "https://openalex.org/W42", Type: "proceedings-article", Title: "A\n  two-line title",
+		Doi: "https://doi.org/10.1/ABC", PublicationYear: 2021, PublicationDate: "2021-06-15",
+		Biblio:          domain.Biblio{Volume: "7", FirstPage: "12", LastPage: "34"},
+		PrimaryLocation: &domain.Location{Source: &domain.Source{DisplayName: "Proceedings"}, LandingPageUrl: "https://example.org/w42"},
+		Authorships: []domain.Authorship{
+			{Author: domain.DehydratedAuthor{DisplayName: "Doe, Jane"}},
+			{Author: domain.DehydratedAuthor{DisplayName: "José Núñez"}},
+		},
+	})
+	writeRIS(&b, domain.Work{ID: "W2", Title: "Second"})
+	want := "TY  - CPAPER\r\nTI  - A two-line title\r\nAU  - Doe, Jane\r\nAU  - José Núñez\r\nPY  - 2021\r\nDA  - 2021/06/15\r\n" +
+		"T2  - Proceedings\r\nUR  - https://example.org/w42\r\nVL  - 7\r\nSP  - 12\r\nEP  - 34\r\nDO  - 10.1/abc\r\nID  - W42\r\nER  - \r\n\r\n" +
+		"TY  - GEN\r\nTI  - Second\r\nID  - W2\r\nER  - \r\n\r\n"
+	if got := b.String(); got != want {
+		t.Errorf("RIS =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// errBlocked finishes the audit record of an ingestion rejectIfBlocked turned away, and
+// is returned for jobs that can't be resumed because their subject was blocked since.
+var errBlocked = errors.New("blocked from ingestion")
+
+// rejectIfBlocked answers 403 with the blocklist reason and returns true if id (in any
+// OpenAlex ID form) is blocked. Ingestion paths call it before doing any work.
+func (h *APIHandler) rejectIfBlocked(ctx context.Context, w http.ResponseWriter, id string) bool {
+	blocked, reason, err := h.repo.IsBlocked(ctx, canonicalOpenAlexID(id))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return true
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, fmt.Sprintf("%s is blocked from ingestion: %s", id, reason))
+		return true
+	}
+	return false
+}
+
+// BlockHandler manages the ingestion blocklist. POST {"id": "...", "reason": "..."} blocks
+// an OpenAlex ID; DELETE ?id=... unblocks it.
+func (h *APIHandler) BlockHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	switch r.Method {
+	case http.MethodPost:
+		var req dto.BlockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		req.ID, req.Reason = strings.TrimSpace(req.ID), strings.TrimSpace(req.Reason)
+		if req.ID == "" || req.Reason == "" {
+			respondWithError(w, http.StatusBadRequest, "Request must contain 'id' and 'reason'")
+			return
+		}
+		id := canonicalOpenAlexID(req.ID)
+		if err := h.repo.BlockEntity(ctx, id, req.Reason); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondWithJSON(w, http.StatusOK, map[string]string{"id": id, "reason": req.Reason})
+	case http.MethodDelete:
+		raw := r.URL.Query().Get("id")
+		if raw == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing 'id' query parameter")
+			return
+		}
+		id := canonicalOpenAlexID(raw)
+		err := h.repo.UnblockEntity(ctx, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondWithJSON(w, http.StatusOK, map[string]string{"id": id, "message": "Unblocked"})
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST or DELETE")
+	}
+}
+
+// DeleteAuthorHandler removes an ingested author (DELETE ?id=...). With block=true the
+// author is also blocklisted, with the optional reason parameter, so it isn't re-ingested.
+func (h *APIHandler) DeleteAuthorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use DELETE")
+		return
+	}
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+	id := canonicalOpenAlexID(authorID)
+	block := r.URL.Query().Get("block") == "true"
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	// Block first, so nothing can re-ingest the author between the two steps.
+	if block {
+		reason := strings.TrimSpace(r.URL.Query().Get("reason"))
+		if reason == "" {
+			reason = "deleted by " + requestedBy(r)
+		}
+		if err := h.repo.BlockEntity(ctx, id, reason); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	err := h.repo.DeleteAuthor(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"id": id, "deleted": true, "blocked": block})
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestIngestionRejectsBlockedIDs(t *testing.T) {
+	requests := 0
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	})
+	repo := newFakeRepo()
+	repo.blocked["https://openalex.org/A1"] = "wrongly merged profile"
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+		body    string
+	}{
+		{"manual ingest", h.FetchAndSaveWorksByAuthorHandler, http.MethodGet, "/api/fetch-author-by-id?id=A1", ""},
+		{"manual ingest by URL", h.FetchAndSaveWorksByAuthorHandler, http.MethodGet, "/api/fetch-author-by-id?id=https://openalex.org/A1", ""},
+		{"streaming ingest", h.StreamAuthorIngestHandler, http.MethodGet, "/api/fetch-author-by-id/stream?id=A1", ""},
+		{"refresher", h.SyncAuthorWorksHandler, http.MethodPost, "/api/authors/sync?id=A1", ""},
+		{"query ingest", h.IngestQueryHandler, http.MethodPost, "/api/ingest/query", `{"filter": "publication_year:2023,author.id:A2|A1"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403: %s", rec.Code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), "wrongly merged profile") {
+				t.Errorf("body = %s, want the blocklist reason", rec.Body)
+			}
+			if requests != 0 {
+				t.Errorf("%d OpenAlex requests for a blocked ID", requests)
+			}
+		})
+	}
+}
+
+func TestAutoIngestSkipsBlockedAuthors(t *testing.T) {
+	repo := newFakeRepo()
+	repo.blocked["https://openalex.org/A1"] = "spam"
+	repo.blocked["https://openalex.org/A2"] = "spam"
+	h := newTestHandler(repo)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/fetch-authors-by-name?name=x&ingest=true", nil)
+	jobIDs, err := h.autoIngestAuthors(r, []domain.Author{{ID: "https://openalex.org/A1"}, {ID: "A2"}}, workFilter{})
+	if err != nil {
+		t.Fatalf("autoIngestAuthors: %v", err)
+	}
+	if len(jobIDs) != 0 {
+		t.Errorf("started jobs %v for blocked authors", jobIDs)
+	}
+}
+
+func TestIngestAuthorsBulkReportsBlockedAuthors(t *testing.T) {
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.RawQuery, "A1") {
+			t.Errorf("blocked author requested: %s", r.URL)
+		}
+		w.Write([]byte(`{"meta": {"count": 0}, "results": []}`))
+	})
+	repo := newFakeRepo()
+	repo.blocked["https://openalex.org/A1"] = "spam"
+	h := newTestHandler(repo)
+
+	rec := httptest.NewRecorder()
+	h.IngestAuthorsBulkHandler(rec, httptest.NewRequest(http.MethodPost, "/api/ingest-authors-bulk", strings.NewReader(`{"ids": ["A1", "A2"]}`)))
+	var body struct {
+		Blocked []string `json:"blocked"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if !reflect.DeepEqual(body.Blocked, []string{"A1"}) {
+		t.Errorf("blocked = %v, want [A1]: %d %s", body.Blocked, rec.Code, rec.Body)
+	}
+}
+
+func TestBlockHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		wantStatus  int
+		wantBlocked map[string]string
+	}{
+		{"block", http.MethodPost, "/api/admin/block", `{"id": "A2", "reason": "test account"}`, http.StatusOK,
+			map[string]string{"https://openalex.org/A1": "spam", "https://openalex.org/A2": "test account"}},
+		{"update reason", http.MethodPost, "/api/admin/block", `{"id": "https://openalex.org/A1", "reason": "duplicate"}`, http.StatusOK,
+			map[string]string{"https://openalex.org/A1": "duplicate"}},
+		{"missing reason", http.MethodPost, "/api/admin/block", `{"id": "A2", "reason": " "}`, http.StatusBadRequest,
+			map[string]string{"https://openalex.org/A1": "spam"}},
+		{"invalid body", http.MethodPost, "/api/admin/block", `{"id":`, http.StatusBadRequest,
+			map[string]string{"https://openalex.org/A1": "spam"}},
+		{"unblock", http.MethodDelete, "/api/admin/block?id=A1", "", http.StatusOK, map[string]string{}},
+		{"unblock unknown", http.MethodDelete, "/api/admin/block?id=A2", "", http.StatusNotFound,
+			map[string]string{"https://openalex.org/A1": "spam"}},
+		{"unblock without id", http.MethodDelete, "/api/admin/block", "", http.StatusBadRequest,
+			map[string]string{"https://openalex.org/A1": "spam"}},
+		{"wrong method", http.MethodGet, "/api/admin/block", "", http.StatusMethodNotAllowed,
+			map[string]string{"https://openalex.org/A1": "spam"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.blocked["https://openalex.org/A1"] = "spam"
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.BlockHandler(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !reflect.DeepEqual(repo.blocked, tt.wantBlocked) {
+				t.Errorf("blocklist = %v, want %v", repo.blocked, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+func TestDeleteAuthorHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantBlocked map[string]string
+	}{
+		{"delete", "id=A1", http.StatusOK, map[string]string{}},
+		{"delete and block", "id=A1&block=true&reason=bad+merge", http.StatusOK,
+			map[string]string{"https://openalex.org/A1": "bad merge"}},
+		{"default reason", "id=A1&block=true", http.StatusOK,
+			map[string]string{"https://openalex.org/A1": "deleted by anonymous"}},
+		// Unknown authors can still be blocked, so they can't be ingested later either.
+		{"unknown author", "id=A2&block=true&reason=spam", http.StatusNotFound,
+			map[string]string{"https://openalex.org/A2": "spam"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.authors["https://openalex.org/A1"] = true
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.DeleteAuthorHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/authors?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK && repo.authors["https://openalex.org/A1"] {
+				t.Error("author was not deleted")
+			}
+			if !reflect.DeepEqual(repo.blocked, tt.wantBlocked) {
+				t.Errorf("blocklist = %v, want %v", repo.blocked, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+func TestFilterIDs(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"publication_year:2023", nil},
+		{"authorships.author.id:A5023888391", []string{"A5023888391"}},
+		{"authorships.author.id:A1|https://openalex.org/A2,primary_location.source.id:S3", []string{"A1", "A2", "S3"}},
+		{"authorships.institutions.id:I4,cites:W5", []string{"I4", "W5"}},
+		{"authorships.author.id:!A1", nil},
+		{"topics.id:T10017", nil},
+	}
+	for _, tt := range tests {
+		if got := filterIDs(tt.filter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filterIDs(%q) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
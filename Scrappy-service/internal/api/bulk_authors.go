@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// maxBulkAuthors caps how many IDs one bulk author ingest takes (20 OpenAlex requests).
+const maxBulkAuthors = 20 * openalex.MaxIDsPerRequest
+
+// IngestAuthorsBulkHandler fetches and saves many authors at once, e.g. to hydrate coauthor
+// stubs: {"ids": ["A5023896336", ...]}. Authors are fetched 50 per OpenAlex request, not one
+// by one, and saved in batched transactions with SaveAuthors. Only the authors are saved, not their works. IDs OpenAlex doesn't know are
+// reported as missing and blocklisted ones as blocked; neither fails the request.
+func (h *APIHandler) IngestAuthorsBulkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	var req dto.BulkAuthorsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if len(req.IDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "'ids' must hold at least one author ID")
+		return
+	}
+	if len(req.IDs) > maxBulkAuthors {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d author IDs per request", maxBulkAuthors))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	ids := make([]string, 0, len(req.IDs))
+	blocked := []string{}
+	for _, raw := range req.IDs {
+		id, err := openalex.ValidateID(raw, 'A')
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		isBlocked, _, err := h.repo.IsBlocked(ctx, canonicalOpenAlexID(id))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if isBlocked {
+			blocked = append(blocked, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	missing := []string{}
+	authors, err := h.alexClient.FetchAuthorsByIDs(ctx, ids)
+	var missingErr *openalex.MissingIDsError
+	if errors.As(err, &missingErr) {
+		missing = missingErr.IDs
+		err = nil
+	}
+	if err != nil {
+		respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch authors from OpenAlex: %v", err))
+		return
+	}
+
+	job := h.startIngestJob(ctx, "authors-bulk", fmt.Sprintf("%d authors", len(authors)), requestedBy(r))
+	defer job.finishOnPanic(true)
+	// The batch spans many authors, so it is written directly rather than on the save pool,
+	// which orders writes per author.
+	err = h.repo.SaveAuthors(ctx, authors)
+	var partial *storage.SaveAuthorsError
+	errors.As(err, &partial)
+	saved := 0
+	for i, author := range authors {
+		authorErr := err
+		if partial != nil {
+			authorErr = partial.Results[i].Err
+		}
+		job.entitySaved(author.ID, author.DisplayName, authorErr)
+		if authorErr != nil {
+			log.Printf("WARN: Could not save author %s: %v", author.ID, authorErr)
+			continue
+		}
+		saved++
+	}
+	job.finish(ctx, nil)
+
+	response := map[string]interface{}{
+		"jobId":        job.event.ID,
+		"authorsSaved": saved,
+		"missing":      missing,
+		"blocked":      blocked,
+	}
+	if failures := job.failures(); len(failures) > 0 {
+		response["failedAuthors"] = failures
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
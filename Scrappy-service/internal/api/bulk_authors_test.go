@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// serveAuthorsByID answers openalex_id filter requests with the requested authors, except
+// the unknown ones, and counts the requests.
+func serveAuthorsByID(requests *int, unknown ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		var results []string
+		for _, id := range strings.Split(strings.TrimPrefix(r.URL.Query().Get("filter"), "openalex_id:"), "|") {
+			if !slices.Contains(unknown, id) {
+				results = append(results, fmt.Sprintf(`{"id": "https://openalex.org/%s", "display_name": "%s"}`, id, id))
+			}
+		}
+		fmt.Fprintf(w, `{"results": [%s]}`, strings.Join(results, ","))
+	}
+}
+
+func TestIngestAuthorsBulkHandler(t *testing.T) {
+	var requests int
+	fakeOpenAlex(t, serveAuthorsByID(&requests, "A404"))
+
+	many := make([]string, 120)
+	for i := range many {
+		many[i] = fmt.Sprintf(`"A%d"`, i+1)
+	}
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantRequests int
+		wantSaved    int
+		wantMissing  []string
+	}{
+		{"saves", `{"ids": ["A1", "https://openalex.org/A2"]}`, http.StatusOK, 1, 2, []string{}},
+		{"missing", `{"ids": ["A1", "A404"]}`, http.StatusOK, 1, 1, []string{"A404"}},
+		{"batched", `{"ids": [` + strings.Join(many, ",") + `]}`, http.StatusOK, 3, 120, []string{}},
+		{"no ids", `{"ids": []}`, http.StatusBadRequest, 0, 0, nil},
+		{"invalid id", `{"ids": ["A1", "W2"]}`, http.StatusBadRequest, 0, 0, nil},
+		{"invalid body", `["A1"]`, http.StatusBadRequest, 0, 0, nil},
+		{"too many", `{"ids": [` + strings.Repeat(`"A1",`, maxBulkAuthors) + `"A1"]}`, http.StatusBadRequest, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			repo := newFakeRepo()
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.IngestAuthorsBulkHandler(rec, httptest.NewRequest(http.MethodPost, "/api/ingest-authors-bulk", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("%d OpenAlex requests, want %d", requests, tt.wantRequests)
+			}
+			if len(repo.savedAuthors) != tt.wantSaved {
+				t.Errorf("saved %d authors, want %d", len(repo.savedAuthors), tt.wantSaved)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				JobID        string   `json:"jobId"`
+				AuthorsSaved int      `json:"authorsSaved"`
+				Missing      []string `json:"missing"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.AuthorsSaved != tt.wantSaved || !reflect.DeepEqual(body.Missing, tt.wantMissing) {
+				t.Errorf("response = %+v, want %d saved and missing %v", body, tt.wantSaved, tt.wantMissing)
+			}
+			if event := repo.event(body.JobID); event.Kind != "authors-bulk" || event.Status != storage.IngestStatusCompleted {
+				t.Errorf("ingest event = %+v, want a completed authors-bulk job", event)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
+)
+
+func TestCitationContextProps(t *testing.T) {
+	tests := []struct {
+		name     string
+		citation semanticscholar.Citation
+		want     map[string]any
+	}{
+		{
+			name:     "annotated",
+			citation: semanticscholar.Citation{Intents: []string{"methodology", "result"}, IsInfluential: true, Contexts: []string{"we follow [4]"}},
+			want:     map[string]any{"intents": []string{"methodology", "result"}, "isInfluential": true, "contexts": []string{"we follow [4]"}, "contextSource": relatedSourceSemanticScholar},
+		},
+		{
+			name:     "no annotations",
+			citation: semanticscholar.Citation{},
+			want:     map[string]any{"intents": []string{}, "isInfluential": false, "contexts": []string{}, "contextSource": relatedSourceSemanticScholar},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := citationContextProps(tt.citation); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("props = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// serveCitations answers the citations and references requests for paper ss1 with the
+// given edges, each a paperId and DOI; other papers are not found.
+func serveCitations(citations, references [][2]string) http.HandlerFunc {
+	edges := func(key string, papers [][2]string) string {
+		data := make([]string, len(papers))
+		for i, p := range papers {
+			data[i] = fmt.Sprintf(`{"intents": ["background"], "isInfluential": true, "contexts": ["see %s"], "%s": {"paperId": "%s", "title": "title of %s", "externalIds": {"DOI": "%s"}}}`,
+				p[0], key, p[0], p[0], p[1])
+		}
+		return `{"next": null, "data": [` + strings.Join(data, ",") + `]}`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/graph/v1/paper/ss1/citations":
+			fmt.Fprint(w, edges("citingPaper", citations))
+		case "/graph/v1/paper/ss1/references":
+			fmt.Fprint(w, edges("citedPaper", references))
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestEnrichCitationContextHandler(t *testing.T) {
+	fakeOpenAlex(t, serveCitations(
+		[][2]string{{"p2", "10.1/W2"}, {"p3", "10.1/w3"}, {"p4", ""}},
+		[][2]string{{"p5", "https://doi.org/10.1/w5"}, {"p6", "10.1/w1"}, {"p7", "10.1/w7"}},
+	))
+	repo := newFakeRepo()
+	for _, id := range []string{"W1", "W2", "W5", "W7", "W9"} {
+		repo.workIDs["10.1/"+strings.ToLower(id)] = "https://openalex.org/" + id
+	}
+	repo.paperIDs["https://openalex.org/W1"] = "ss1"
+	repo.paperIDs["https://openalex.org/W9"] = "gone"
+	repo.deleted["https://openalex.org/W5"] = true
+	h := newTestHandler(repo)
+
+	rec := httptest.NewRecorder()
+	h.EnrichCitationContextHandler(rec, httptest.NewRequest(http.MethodPost, "/api/works/enrich-citation-context?doi=https://doi.org/10.1/W1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Citations  int                     `json:"citations"`
+		References int                     `json:"references"`
+		Annotated  int                     `json:"annotated"`
+		Unmatched  []dto.UnmatchedCitation `json:"unmatched"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body.Citations != 3 || body.References != 3 || body.Annotated != 2 {
+		t.Errorf("response = %+v, want 3 citations, 3 references, 2 annotated", body)
+	}
+
+	// Citations point at the work, references away from it.
+	wantProps := map[string]any{"intents": []string{"background"}, "isInfluential": true, "contextSource": relatedSourceSemanticScholar}
+	for _, edge := range []struct{ key, paper string }{
+		{"https://openalex.org/W2->https://openalex.org/W1", "p2"},
+		{"https://openalex.org/W1->https://openalex.org/W7", "p7"},
+	} {
+		props, ok := repo.annotations[edge.key]
+		if !ok {
+			t.Errorf("%s not annotated; annotations: %v", edge.key, repo.annotations)
+			continue
+		}
+		for key, want := range wantProps {
+			if !reflect.DeepEqual(props[key], want) {
+				t.Errorf("%s: %s = %v, want %v", edge.key, key, props[key], want)
+			}
+		}
+		if want := []string{"see " + edge.paper}; !reflect.DeepEqual(props["contexts"], want) {
+			t.Errorf("%s: contexts = %v, want %v", edge.key, props["contexts"], want)
+		}
+	}
+	if len(repo.annotations) != 2 {
+		t.Errorf("annotations = %v, want 2", repo.annotations)
+	}
+
+	wantUnmatched := []dto.UnmatchedCitation{
+		{Direction: "citation", PaperID: "p3", Title: "title of p3", Doi: "10.1/w3"},  // not in the graph
+		{Direction: "citation", PaperID: "p4", Title: "title of p4"},                  // no DOI
+		{Direction: "reference", PaperID: "p5", Title: "title of p5", Doi: "10.1/w5"}, // deleted meanwhile
+		{Direction: "reference", PaperID: "p6", Title: "title of p6", Doi: "10.1/w1"}, // the work itself
+	}
+	if !reflect.DeepEqual(body.Unmatched, wantUnmatched) {
+		t.Errorf("unmatched = %+v, want %+v", body.Unmatched, wantUnmatched)
+	}
+}
+
+func TestEnrichCitationContextHandlerErrors(t *testing.T) {
+	fakeOpenAlex(t, serveCitations(nil, nil))
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		wantStatus int
+	}{
+		{"GET", http.MethodGet, "doi=10.1/w1", http.StatusMethodNotAllowed},
+		{"missing doi", http.MethodPost, "", http.StatusBadRequest},
+		{"zero limit", http.MethodPost, "doi=10.1/w1&limit=0", http.StatusBadRequest},
+		{"limit too large", http.MethodPost, fmt.Sprintf("doi=10.1/w1&limit=%d", semanticscholar.MaxCitations+1), http.StatusBadRequest},
+		{"work not in the graph", http.MethodPost, "doi=10.1/w8", http.StatusNotFound},
+		{"paper not in Semantic Scholar", http.MethodPost, "doi=10.1/w9", http.StatusNotFound},
+		{"no citations", http.MethodPost, "doi=10.1/w1&limit=10", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.workIDs["10.1/w1"] = "https://openalex.org/W1"
+			repo.workIDs["10.1/w9"] = "https://openalex.org/W9"
+			repo.paperIDs["https://openalex.org/W1"] = "ss1"
+			repo.paperIDs["https://openalex.org/W9"] = "gone"
+
+			rec := httptest.NewRecorder()
+			newTestHandler(repo).EnrichCitationContextHandler(rec, httptest.NewRequest(tt.method, "/api/works/enrich-citation-context?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if len(repo.annotations) != 0 {
+				t.Errorf("annotations = %v, want none", repo.annotations)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// logDecodeWarnings logs the works of an OpenAlex response that didn't decode and were
+// left out.
+func logDecodeWarnings(what string, warnings openalex.DecodeWarnings) {
+	if len(warnings) == 0 {
+		return
+	}
+	log.Printf("WARN: %d work(s) of %s could not be decoded and were left out, e.g. %v",
+		len(warnings), what, warnings.Samples(storage.MaxDecodeWarningSamples))
+}
+
+// addDecodeWarnings adds the count and the first messages of the decode warnings to a
+// response payload. Payloads without warnings are left unchanged.
+func addDecodeWarnings(payload map[string]interface{}, warnings openalex.DecodeWarnings) {
+	if len(warnings) == 0 {
+		return
+	}
+	payload["decodeWarnings"] = len(warnings)
+	payload["decodeWarningSamples"] = warnings.Samples(storage.MaxDecodeWarningSamples)
+}
+
+// setDecodeWarningsHeader reports decode warnings of a response whose body is a bare
+// array, in the X-Decode-Warnings header.
+func setDecodeWarningsHeader(w http.ResponseWriter, warnings openalex.DecodeWarnings) {
+	if len(warnings) > 0 {
+		w.Header().Set("X-Decode-Warnings", strconv.Itoa(len(warnings)))
+	}
+}
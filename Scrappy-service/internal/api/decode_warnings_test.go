@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// servePoisonedWorks serves author A1 and their works in two pages with works of an
+// unexpected shape among good ones: W2 on the first page, W4 to W9 on the second.
+func servePoisonedWorks(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/authors/A1" {
+		fmt.Fprint(w, `{"id": "https://openalex.org/A1", "display_name": "Ada", "works_count": 10}`)
+		return
+	}
+	good := func(id string) string {
+		return fmt.Sprintf(`{"id": "https://openalex.org/%s", "title": "%s", "type": "article"}`, id, id)
+	}
+	bad := func(id string) string {
+		return fmt.Sprintf(`{"id": "https://openalex.org/%s", "type": "article", "grants": [{"award_id": 42}]}`, id)
+	}
+	if r.URL.Query().Get("cursor") == "c2" {
+		results := []string{}
+		for i := 4; i <= 9; i++ {
+			results = append(results, bad(fmt.Sprint("W", i)))
+		}
+		results = append(results, good("W10"))
+		fmt.Fprintf(w, `{"meta": {"count": 10, "next_cursor": null}, "results": [%s]}`, strings.Join(results, ","))
+		return
+	}
+	fmt.Fprintf(w, `{"meta": {"count": 10, "next_cursor": "c2"}, "results": [%s, %s, %s]}`, good("W1"), bad("W2"), good("W3"))
+}
+
+func TestDecodeWarningsAreReported(t *testing.T) {
+	fakeOpenAlex(t, servePoisonedWorks)
+
+	tests := []struct {
+		name       string
+		serve      func(h *APIHandler, w http.ResponseWriter, r *http.Request)
+		method     string
+		target     string
+		wantStatus int
+		// wantWarnings is the count in the response (or its X-Decode-Warnings header for
+		// bare arrays), and wantReported the count in the job's ingest history record;
+		// -1 if there is no job.
+		wantWarnings int
+		wantReported int
+		wantSaved    []string
+	}{
+		{
+			name:         "author ingest",
+			serve:        (*APIHandler).FetchAndSaveWorksByAuthorHandler,
+			method:       http.MethodGet,
+			target:       "/api/fetch-author-by-id?id=A1",
+			wantStatus:   http.StatusAccepted,
+			wantWarnings: 1, // the first page; the rest is ingested in the background
+			wantReported: 7,
+			wantSaved:    []string{"W1", "W10", "W3"},
+		},
+		{
+			name:         "sync",
+			serve:        (*APIHandler).SyncAuthorWorksHandler,
+			method:       http.MethodPost,
+			target:       "/api/authors/sync?id=A1",
+			wantStatus:   http.StatusOK,
+			wantWarnings: 7,
+			wantReported: 7,
+			wantSaved:    []string{"W1", "W10", "W3"},
+		},
+		{
+			name:         "works from OpenAlex",
+			serve:        (*APIHandler).GetAuthorWorksHandler,
+			method:       http.MethodGet,
+			target:       "/api/authors/works?id=A1&source=openalex",
+			wantStatus:   http.StatusOK,
+			wantWarnings: 1,
+			wantReported: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.synced["https://openalex.org/A1"] = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			tt.serve(h, rec, httptest.NewRequest(tt.method, tt.target, nil))
+			h.jobs.wg.Wait()
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			if tt.wantReported < 0 {
+				var works []json.RawMessage
+				json.Unmarshal(rec.Body.Bytes(), &works)
+				if header := rec.Header().Get("X-Decode-Warnings"); header != fmt.Sprint(tt.wantWarnings) || len(works) != 2 {
+					t.Errorf("X-Decode-Warnings = %q with %d works, want %d with the 2 good ones", header, len(works), tt.wantWarnings)
+				}
+				return
+			}
+
+			var body struct {
+				JobID    string   `json:"jobId"`
+				Warnings int      `json:"decodeWarnings"`
+				Samples  []string `json:"decodeWarningSamples"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.Warnings != tt.wantWarnings || len(body.Samples) != min(tt.wantWarnings, storage.MaxDecodeWarningSamples) {
+				t.Errorf("response reports %d warnings with samples %q, want %d", body.Warnings, body.Samples, tt.wantWarnings)
+			}
+			if len(body.Samples) == 0 || !strings.Contains(body.Samples[0], "W2") {
+				t.Errorf("samples = %q, want the first naming W2", body.Samples)
+			}
+
+			var event storage.IngestEvent
+			for _, e := range repo.events {
+				event = e
+			}
+			if len(repo.events) != 1 || event.DecodeWarnings != tt.wantReported ||
+				len(event.DecodeWarningSamples) != storage.MaxDecodeWarningSamples {
+				t.Errorf("job reports %d warnings with samples %q, want %d with %d samples",
+					event.DecodeWarnings, event.DecodeWarningSamples, tt.wantReported, storage.MaxDecodeWarningSamples)
+			}
+			if event.Status != storage.IngestStatusCompleted {
+				t.Errorf("job status = %q, want the ingest to complete despite the bad works", event.Status)
+			}
+
+			var saved []string
+			for _, work := range repo.saved {
+				saved = append(saved, strings.TrimPrefix(work.ID, "https://openalex.org/"))
+			}
+			sort.Strings(saved)
+			if strings.Join(saved, " ") != strings.Join(tt.wantSaved, " ") {
+				t.Errorf("saved works = %v, want %v", saved, tt.wantSaved)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// DepositWorkHandler saves a work that doesn't come from OpenAlex (an internal tech report,
+// say), posted as a JSON body in the shape of an OpenAlex work without its id. The work
+// needs a title, a publication_year and at least one authorship whose author has a
+// display_name or an OpenAlex ID. It is saved under a LOCAL- ID derived from its DOI, or
+// its title and year, so depositing the same content again updates the same node; authors
+// given by name only get LOCAL- IDs of their own. What it writes is stamped with the
+// source "deposit". It answers 201 for a new work and 200 for a re-deposit.
+func (h *APIHandler) DepositWorkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	var work domain.Work
+	if err := json.NewDecoder(r.Body).Decode(&work); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := prepareDeposit(&work); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	job := h.startIngestJob(ctx, "deposit", work.ID, requestedBy(r))
+	defer job.finishOnPanic(true)
+
+	opts := storage.FullSave
+	opts.Source = storage.SourceDeposit
+	outcome, err := h.repo.SaveWork(ctx, work, opts)
+	job.workSaved(work, outcome, err)
+	job.finish(ctx, err)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save work to database: %v", err))
+		return
+	}
+	log.Printf("Saved deposited work %q as %s (%s)", work.Title, work.ID, outcome)
+
+	authorIDs := make([]string, 0, len(work.Authorships))
+	for _, authorship := range work.Authorships {
+		authorIDs = append(authorIDs, authorship.Author.ID)
+	}
+	status := http.StatusOK
+	if outcome == storage.SaveCreated {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, map[string]interface{}{
+		"id":        work.ID,
+		"outcome":   outcome,
+		"authorIds": authorIDs,
+	})
+}
+
+// prepareDeposit validates a deposited work and gives it and its name-only authors their
+// LOCAL- IDs. Authors given with an OpenAlex ID keep it, in its canonical form.
+func prepareDeposit(work *domain.Work) error {
+	if work.ID != "" {
+		return errors.New("'id' must not be set: deposited works get a generated ID")
+	}
+	work.Title = strings.TrimSpace(work.Title)
+	if work.Title == "" {
+		return errors.New("'title' is required")
+	}
+	if work.PublicationYear < 1000 || work.PublicationYear > time.Now().Year()+1 {
+		return errors.New("'publication_year' is required and must be a year, e.g. 2024")
+	}
+	if len(work.Authorships) == 0 {
+		return errors.New("'authorships' must hold at least one author")
+	}
+	for i := range work.Authorships {
+		author := &work.Authorships[i].Author
+		author.DisplayName = strings.TrimSpace(author.DisplayName)
+		switch {
+		case author.ID != "" && !domain.IsLocalID(author.ID):
+			id, err := openalex.ValidateID(author.ID, 'A')
+			if err != nil {
+				return fmt.Errorf("authorship %d: %w", i+1, err)
+			}
+			author.ID = canonicalOpenAlexID(id)
+		case author.ID != "":
+		case author.DisplayName != "":
+			author.ID = domain.LocalAuthorID(author.DisplayName)
+		default:
+			return fmt.Errorf("authorship %d: the author needs a 'display_name' or an OpenAlex 'id'", i+1)
+		}
+	}
+	work.ID = domain.LocalWorkID(*work)
+	return nil
+}
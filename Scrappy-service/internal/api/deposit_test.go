@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestDepositWorkHandlerValidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"not JSON", `{"title": `, "Invalid request payload"},
+		{"own id", `{"id": "W1", "title": "Report", "publication_year": 2023, "authorships": [{"author": {"display_name": "Ada"}}]}`, "'id'"},
+		{"no title", `{"publication_year": 2023, "authorships": [{"author": {"display_name": "Ada"}}]}`, "'title'"},
+		{"blank title", `{"title": "  ", "publication_year": 2023, "authorships": [{"author": {"display_name": "Ada"}}]}`, "'title'"},
+		{"no year", `{"title": "Report", "authorships": [{"author": {"display_name": "Ada"}}]}`, "'publication_year'"},
+		{"not a year", `{"title": "Report", "publication_year": 23, "authorships": [{"author": {"display_name": "Ada"}}]}`, "'publication_year'"},
+		{"far future", fmt.Sprintf(`{"title": "Report", "publication_year": %d, "authorships": [{"author": {"display_name": "Ada"}}]}`, time.Now().Year()+2), "'publication_year'"},
+		{"no authorships", `{"title": "Report", "publication_year": 2023, "authorships": []}`, "'authorships'"},
+		{"nameless author", `{"title": "Report", "publication_year": 2023, "authorships": [{"author": {"display_name": "Ada"}}, {"author": {"display_name": " "}}]}`, "authorship 2"},
+		{"author with a work ID", `{"title": "Report", "publication_year": 2023, "authorships": [{"author": {"id": "W1"}}]}`, "authorship 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			rec := httptest.NewRecorder()
+			newTestHandler(repo).DepositWorkHandler(rec, httptest.NewRequest(http.MethodPost, "/api/works/deposit", strings.NewReader(tt.body)))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantErr) {
+				t.Errorf("body = %s, want it to mention %s", rec.Body, tt.wantErr)
+			}
+			if len(repo.saved) != 0 {
+				t.Errorf("saved %+v", repo.saved)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	newTestHandler(newFakeRepo()).DepositWorkHandler(rec, httptest.NewRequest(http.MethodGet, "/api/works/deposit", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want 405", rec.Code)
+	}
+}
+
+// Depositing the same content again finds the work it saved the first time, under the same
+// generated IDs.
+func TestDepositWorkHandlerIsIdempotent(t *testing.T) {
+	repo := newFakeRepo()
+	inGraph := map[string]bool{}
+	repo.saveOutcome = func(work domain.Work) (storage.SaveOutcome, error) {
+		if inGraph[work.ID] {
+			return storage.SaveUnchanged, nil
+		}
+		inGraph[work.ID] = true
+		return storage.SaveCreated, nil
+	}
+	h := newTestHandler(repo)
+
+	type response struct {
+		ID        string   `json:"id"`
+		Outcome   string   `json:"outcome"`
+		AuthorIDs []string `json:"authorIds"`
+	}
+	deposit := func(body string) (int, response) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.DepositWorkHandler(rec, httptest.NewRequest(http.MethodPost, "/api/works/deposit", strings.NewReader(body)))
+		var got response
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		return rec.Code, got
+	}
+
+	report := `{"title": "Annual Report on Widgets", "publication_year": 2023, "authorships": [
+		{"author": {"display_name": "Ada Lovelace"}},
+		{"author": {"id": "A5023888391", "display_name": "Charles Babbage"}},
+		{"author": {"id": "LOCAL-0123456789abcdef"}}]}`
+	wantFirst := response{
+		ID:        domain.LocalWorkID(domain.Work{Title: "Annual Report on Widgets", PublicationYear: 2023}),
+		Outcome:   string(storage.SaveCreated),
+		AuthorIDs: []string{domain.LocalAuthorID("Ada Lovelace"), "https://openalex.org/A5023888391", "LOCAL-0123456789abcdef"},
+	}
+	status, first := deposit(report)
+	if status != http.StatusCreated || !reflect.DeepEqual(first, wantFirst) {
+		t.Fatalf("first deposit = %d %+v, want 201 %+v", status, first, wantFirst)
+	}
+	if repo.saveOptions.Source != storage.SourceDeposit || !repo.saveOptions.IncludeCitations {
+		t.Errorf("saved with %+v, want a full save from source %q", repo.saveOptions, storage.SourceDeposit)
+	}
+
+	// The same report, written a little differently.
+	again := `{"title": "  annual report on WIDGETS", "publication_year": 2023, "authorships": [
+		{"author": {"display_name": "ada  lovelace"}},
+		{"author": {"id": "https://openalex.org/A5023888391"}},
+		{"author": {"id": "LOCAL-0123456789abcdef"}}]}`
+	wantAgain := wantFirst
+	wantAgain.Outcome = string(storage.SaveUnchanged)
+	status, second := deposit(again)
+	if status != http.StatusOK || !reflect.DeepEqual(second, wantAgain) {
+		t.Errorf("second deposit = %d %+v, want 200 %+v", status, second, wantAgain)
+	}
+
+	status, other := deposit(`{"title": "Annual Report on Widgets", "publication_year": 2024, "authorships": [{"author": {"display_name": "Ada Lovelace"}}]}`)
+	if status != http.StatusCreated || other.ID == first.ID || other.AuthorIDs[0] != first.AuthorIDs[0] {
+		t.Errorf("next year's report = %d %+v, want a new work by the same author", status, other)
+	}
+
+	for _, work := range repo.saved {
+		if !domain.IsLocalID(work.ID) || work.Authorships[0].Author.ID != domain.LocalAuthorID("Ada Lovelace") {
+			t.Errorf("saved %s by %s, want LOCAL- IDs", work.ID, work.Authorships[0].Author.ID)
+		}
+	}
+}
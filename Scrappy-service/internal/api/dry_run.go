@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// dryRun reports whether the request asks for a dry run (dry=true): the OpenAlex URL the
+// endpoint would fetch is returned instead of being requested, to debug filters and sorts.
+func dryRun(r *http.Request) bool {
+	dry, _ := strconv.ParseBool(r.URL.Query().Get("dry"))
+	return dry
+}
+
+// respondWithOpenAlexURL answers a dry run with the OpenAlex request URL.
+func respondWithOpenAlexURL(w http.ResponseWriter, requestURL string) {
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"dry": true, "openAlexUrl": requestURL})
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "github.com/Cloudforge2/scrappy/internal/domain"
+
+// AuthorSummary is an author as listed by the name search.
+type AuthorSummary struct {
+	ID                   string `json:"id"`
+	DisplayName          string `json:"displayName"`
+	LastKnownInstitution string `json:"lastKnownInstitution,omitempty"`
+	CitedByCount         int    `json:"citedByCount,omitempty"`
+	UpdatedDate          string `json:"updatedDate,omitempty"`
+	Orcid                string `json:"orcid,omitempty"`
+	WorksCount           int    `json:"worksCount,omitempty"`
+}
+
+// NewAuthorSummary maps an OpenAlex author onto an AuthorSummary.
+func NewAuthorSummary(a domain.Author) AuthorSummary {
+	return AuthorSummary{
+		ID:                   a.ID,
+		DisplayName:          a.DisplayName,
+		LastKnownInstitution: lastKnownInstitution(a),
+		CitedByCount:         a.CitedByCount,
+		UpdatedDate:          a.UpdatedDate,
+		Orcid:                a.Orcid,
+		WorksCount:           a.WorksCount,
+	}
+}
+
+// lastKnownInstitution returns the name of the author's first last known institution.
+func lastKnownInstitution(a domain.Author) string {
+	if len(a.LastKnownInstitutions) > 0 && a.LastKnownInstitutions[0] != nil {
+		return a.LastKnownInstitutions[0].DisplayName
+	}
+	return ""
+}
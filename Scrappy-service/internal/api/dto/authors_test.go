@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestNewAuthorSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		author domain.Author
+		want   AuthorSummary
+	}{
+		{"full", domain.Author{
+			ID: "A1", DisplayName: "Ada Lovelace", Orcid: "https://orcid.org/0000-0001", CitedByCount: 42, WorksCount: 7,
+			UpdatedDate: "2024-01-01", DisplayNameAlternatives: []string{"A. Lovelace"},
+			LastKnownInstitutions: []*domain.DehydratedInstitution{{ID: "I1", DisplayName: "Analytical Society"}, {ID: "I2", DisplayName: "Royal Society"}},
+		}, AuthorSummary{
+			ID: "A1", DisplayName: "Ada Lovelace", LastKnownInstitution: "Analytical Society", CitedByCount: 42,
+			UpdatedDate: "2024-01-01", Orcid: "https://orcid.org/0000-0001", WorksCount: 7,
+		}},
+		{"no institution", domain.Author{ID: "A2", DisplayName: "Bo"}, AuthorSummary{ID: "A2", DisplayName: "Bo"}},
+		{"nil institution", domain.Author{ID: "A3", LastKnownInstitutions: []*domain.DehydratedInstitution{nil}}, AuthorSummary{ID: "A3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewAuthorSummary(tt.author); got != tt.want {
+				t.Errorf("NewAuthorSummary = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// The wire format is camelCase and leaves out what OpenAlex didn't say, unlike the
+// snake_case domain model.
+func TestAuthorSummaryJSON(t *testing.T) {
+	tests := []struct {
+		summary AuthorSummary
+		want    map[string]any
+	}{
+		{NewAuthorSummary(domain.Author{ID: "A1", DisplayName: "Ada", CitedByCount: 3, WorksCount: 2, Orcid: "o", UpdatedDate: "d",
+			LastKnownInstitutions: []*domain.DehydratedInstitution{{DisplayName: "Inst"}}}),
+			map[string]any{"id": "A1", "displayName": "Ada", "lastKnownInstitution": "Inst", "citedByCount": 3.0, "updatedDate": "d", "orcid": "o", "worksCount": 2.0}},
+		{NewAuthorSummary(domain.Author{ID: "A2"}), map[string]any{"id": "A2", "displayName": ""}},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.summary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]any
+		json.Unmarshal(data, &got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s encodes as %v, want %v", tt.summary.ID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package dto
+
+// GeoJSONFeature is a single point feature of a GeoJSON FeatureCollection. Geometry is
+// null for features whose coordinates are unknown, which GeoJSON allows.
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *GeoJSONPoint          `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONPoint is a [longitude, latitude] position.
+type GeoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// GeoJSONFeatureCollection is a GeoJSON document of point features.
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
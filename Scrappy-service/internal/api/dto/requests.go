@@ -0,0 +1,43 @@
+// Package dto holds the request and response shapes of the HTTP API, and the mappings from
+// domain types to them. The domain structs follow OpenAlex's JSON; these follow ours, so
+// either can change without the other.
+package dto
+
+// MergeWorksRequest is the body of POST /api/admin/works/merge.
+type MergeWorksRequest struct {
+	KeepID   string   `json:"keepId"`
+	MergeIDs []string `json:"mergeIds"`
+}
+
+// MergeVenuesRequest is the body of POST /api/admin/venues/merge.
+type MergeVenuesRequest struct {
+	KeepID   string   `json:"keepId"`
+	MergeIDs []string `json:"mergeIds"`
+}
+
+// BlockRequest is the body of POST /api/admin/block.
+type BlockRequest struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// BulkAuthorsRequest is the body of POST /api/ingest-authors-bulk.
+type BulkAuthorsRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// WorkExportRequest is the body of POST /api/works/export.
+type WorkExportRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// QueryIngestRequest is the body of POST /api/ingest/query.
+type QueryIngestRequest struct {
+	Filter   string `json:"filter"`
+	MaxWorks int    `json:"max_works"`
+}
+
+// FollowRequest is the body of POST /api/follows.
+type FollowRequest struct {
+	AuthorID string `json:"authorId"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"strconv"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// SearchHit is one /api/search result, reduced to what a search box shows. Hint is a short
+// qualifier: an author's last known institution, a work's year, an institution's country.
+// IsRetracted is only set on work hits.
+type SearchHit struct {
+	ID           string `json:"id"`
+	DisplayName  string `json:"displayName"`
+	Hint         string `json:"hint,omitempty"`
+	CitedByCount int    `json:"citedByCount"`
+	IsRetracted  *bool  `json:"isRetracted,omitempty"`
+}
+
+// SearchGroup holds the /api/search results of one entity type. TimedOut means the search
+// didn't answer before the deadline; Error is set when it failed. Results is empty in both cases.
+type SearchGroup struct {
+	Results  []SearchHit `json:"results"`
+	TimedOut bool        `json:"timedOut"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// NewAuthorHit maps an author onto a SearchHit.
+func NewAuthorHit(a domain.Author) SearchHit {
+	return SearchHit{ID: a.ID, DisplayName: a.DisplayName, Hint: lastKnownInstitution(a), CitedByCount: a.CitedByCount}
+}
+
+// NewWorkHit maps a work onto a SearchHit.
+func NewWorkHit(w domain.Work) SearchHit {
+	retracted := w.IsRetracted
+	hit := SearchHit{ID: w.ID, DisplayName: w.Title, CitedByCount: w.CitedByCount, IsRetracted: &retracted}
+	if w.PublicationYear != 0 {
+		hit.Hint = strconv.Itoa(w.PublicationYear)
+	}
+	return hit
+}
+
+// NewInstitutionHit maps an institution onto a SearchHit.
+func NewInstitutionHit(i domain.Institution) SearchHit {
+	return SearchHit{ID: i.ID, DisplayName: i.DisplayName, Hint: i.CountryCode, CitedByCount: i.CitedByCount}
+}
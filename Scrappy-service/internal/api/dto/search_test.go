@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestSearchHits(t *testing.T) {
+	retracted, notRetracted := true, false
+	tests := []struct {
+		name string
+		got  SearchHit
+		want SearchHit
+	}{
+		{"author", NewAuthorHit(domain.Author{ID: "A1", DisplayName: "Ada", CitedByCount: 42, WorksCount: 7,
+			LastKnownInstitutions: []*domain.DehydratedInstitution{{DisplayName: "Analytical Society"}}}),
+			SearchHit{ID: "A1", DisplayName: "Ada", Hint: "Analytical Society", CitedByCount: 42}},
+		{"author without institution", NewAuthorHit(domain.Author{ID: "A2", DisplayName: "Bo"}),
+			SearchHit{ID: "A2", DisplayName: "Bo"}},
+		{"work", NewWorkHit(domain.Work{ID: "W1", Title: "On Engines", PublicationYear: 1843, CitedByCount: 9}),
+			SearchHit{ID: "W1", DisplayName: "On Engines", Hint: "1843", CitedByCount: 9, IsRetracted: &notRetracted}},
+		{"retracted work without a year", NewWorkHit(domain.Work{ID: "W2", Title: "Retracted", IsRetracted: true}),
+			SearchHit{ID: "W2", DisplayName: "Retracted", IsRetracted: &retracted}},
+		{"institution", NewInstitutionHit(domain.Institution{ID: "I1", DisplayName: "Royal Society", CountryCode: "GB", CitedByCount: 5}),
+			SearchHit{ID: "I1", DisplayName: "Royal Society", Hint: "GB", CitedByCount: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("hit = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
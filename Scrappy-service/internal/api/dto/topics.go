@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// TopicLevel is one level of the topic hierarchy above a topic: its domain, field or
+// subfield.
+type TopicLevel struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+// TopicMatch is one /api/topics/search result: a topic with its place in the hierarchy.
+// Path spells it out from the domain down, e.g. "Physical Sciences > Computer Science >
+// Artificial Intelligence > Topic Modeling".
+type TopicMatch struct {
+	ID          string     `json:"id"`
+	DisplayName string     `json:"displayName"`
+	Domain      TopicLevel `json:"domain"`
+	Field       TopicLevel `json:"field"`
+	Subfield    TopicLevel `json:"subfield"`
+	Path        string     `json:"path"`
+}
+
+// NewTopicMatch maps a topic onto a TopicMatch. Levels OpenAlex left out are left out of
+// the path.
+func NewTopicMatch(t domain.Topic) TopicMatch {
+	var path []string
+	for _, name := range []string{t.Domain.DisplayName, t.Field.DisplayName, t.Subfield.DisplayName, t.DisplayName} {
+		if name != "" {
+			path = append(path, name)
+		}
+	}
+	return TopicMatch{
+		ID:          t.ID,
+		DisplayName: t.DisplayName,
+		Domain:      TopicLevel{ID: t.Domain.ID, DisplayName: t.Domain.DisplayName},
+		Field:       TopicLevel{ID: t.Field.ID, DisplayName: t.Field.DisplayName},
+		Subfield:    TopicLevel{ID: t.Subfield.ID, DisplayName: t.Subfield.DisplayName},
+		Path:        strings.Join(path, " > "),
+	}
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "github.com/Cloudforge2/scrappy/internal/domain"
+
+// VenueWorks is one venue of an author's works-by-venue listing.
+type VenueWorks struct {
+	Venue      string                  `json:"venue"`
+	WorksCount int                     `json:"worksCount"`
+	Works      []domain.DehydratedWork `json:"works"`
+}
+
+// UnmatchedCitation is a Semantic Scholar citation whose other paper isn't in the graph.
+// Direction is "citation" for papers citing the work and "reference" for papers it cites.
+type UnmatchedCitation struct {
+	Direction string `json:"direction"`
+	PaperID   string `json:"paperId"`
+	Title     string `json:"title"`
+	Doi       string `json:"doi,omitempty"`
+}
+
+// SimilarWork is a work ranked by the cosine similarity of its embedding to another's.
+type SimilarWork struct {
+	domain.DehydratedWork
+	Similarity float64 `json:"similarity"`
+}
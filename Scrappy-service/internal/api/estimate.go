@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+)
+
+// estimatedWorkSaveTime is the typical time SaveWork takes for one work, including its
+// authorships, topics and references.
+const estimatedWorkSaveTime = 25 * time.Millisecond
+
+// estimateIngestDuration estimates how long ingesting works works takes: one OpenAlex
+// request per page of openalex.MaxPerPage, paced by the outbound rate limit, the average
+// page jitter between pages, and one SaveWork per work. It is the single place the estimate
+// is computed, so keep it in line with how ingestion actually pages and saves.
+func estimateIngestDuration(works int, rate float64, jitterMin, jitterMax time.Duration) time.Duration {
+	if works <= 0 {
+		return 0
+	}
+	pages := (works + openalex.MaxPerPage - 1) / openalex.MaxPerPage
+
+	var perRequest time.Duration
+	if rate > 0 {
+		perRequest = time.Duration(math.Ceil(float64(time.Second) / rate))
+	}
+	jitter := (jitterMin + jitterMax) / 2
+
+	return time.Duration(pages)*perRequest + time.Duration(pages-1)*jitter + time.Duration(works)*estimatedWorkSaveTime
+}
+
+// GetIngestEstimateHandler reports how many works an ingestion would fetch and roughly how
+// long it would take, so the UI can warn before large ingests. Exactly one of author_id,
+// institution_id (an OpenAlex ID or a ROR ID) or filter (an OpenAlex works filter string)
+// must be given.
+func (h *APIHandler) GetIngestEstimateHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter string
+	given := 0
+	if raw := query.Get("author_id"); raw != "" {
+		id, err := openalex.ValidateID(raw, 'A')
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter, given = "author.id:"+id, given+1
+	}
+	if raw := query.Get("institution_id"); raw != "" {
+		id, err := h.institutionIDParam(r.Context(), raw)
+		if err != nil {
+			respondWithError(w, institutionParamStatus(err), err.Error())
+			return
+		}
+		filter, given = "institutions.id:"+id, given+1
+	}
+	if raw := query.Get("filter"); raw != "" {
+		valid, err := openalex.ValidateFilter(raw, h.cfg.FilterAllowlist)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter, given = valid, given+1
+	}
+	if given != 1 {
+		respondWithError(w, http.StatusBadRequest, "Provide exactly one of 'author_id', 'institution_id' or 'filter'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	count, err := h.alexClient.CountWorks(ctx, filter)
+	if err != nil {
+		respondWithError(w, openAlexErrorStatus(err), err.Error())
+		return
+	}
+	estimate := estimateIngestDuration(count, h.cfg.OpenAlexRateLimit, h.cfg.OpenAlexPageJitterMin, h.cfg.OpenAlexPageJitterMax)
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"filter":           filter,
+		"worksCount":       count,
+		"estimatedSeconds": int(math.Ceil(estimate.Seconds())),
+		"estimated":        estimate.Round(time.Second).String(),
+	})
+}
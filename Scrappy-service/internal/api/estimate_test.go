@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/config"
+)
+
+func TestEstimateIngestDuration(t *testing.T) {
+	const save = estimatedWorkSaveTime
+	tests := []struct {
+		name      string
+		works     int
+		rate      float64
+		jitterMin time.Duration
+		jitterMax time.Duration
+		want      time.Duration
+	}{
+		{"nothing", 0, 10, 0, 0, 0},
+		{"negative count", -1, 10, 0, 0, 0},
+		{"one work", 1, 10, 0, 0, 100*time.Millisecond + save},
+		{"one full page", 200, 10, 0, 0, 100*time.Millisecond + 200*save},
+		{"one more than a page", 201, 10, 0, 0, 200*time.Millisecond + 201*save},
+		{"jitter between pages only", 400, 10, 100 * time.Millisecond, 300 * time.Millisecond, 200*time.Millisecond + 200*time.Millisecond + 400*save},
+		{"no jitter for a single page", 200, 10, time.Second, time.Second, 100*time.Millisecond + 200*save},
+		{"unlimited rate", 201, 0, 0, 0, 201 * save},
+		{"fractional rate rounds up", 1, 3, 0, 0, 333333334*time.Nanosecond + save},
+		{"ingest of 4230 works", 4230, 10, 0, 0, 22*100*time.Millisecond + 4230*save},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateIngestDuration(tt.works, tt.rate, tt.jitterMin, tt.jitterMax); got != tt.want {
+				t.Errorf("estimateIngestDuration(%d, %v, %v, %v) = %v, want %v", tt.works, tt.rate, tt.jitterMin, tt.jitterMax, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetIngestEstimateHandler(t *testing.T) {
+	var filter string
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("filter")
+		if filter == "author.id:A404" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"meta": {"count": 4230}, "results": [{"id": "https://openalex.org/W1"}]}`))
+	})
+	h := newTestHandler(newFakeRepo(), func(cfg *config.Config) { cfg.OpenAlexRateLimit = 10 })
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantFilter string
+	}{
+		{"author", "author_id=https://openalex.org/A1", http.StatusOK, "author.id:A1"},
+		{"institution", "institution_id=I1", http.StatusOK, "institutions.id:I1"},
+		{"filter", "filter=publication_year:2023,topics.id:T10017", http.StatusOK, "publication_year:2023,topics.id:T10017"},
+		{"nothing", "", http.StatusBadRequest, ""},
+		{"two selectors", "author_id=A1&institution_id=I1", http.StatusBadRequest, ""},
+		{"malformed author", "author_id=W1", http.StatusBadRequest, ""},
+		{"malformed institution", "institution_id=nope", http.StatusBadRequest, ""},
+		{"disallowed filter", "filter=abstract.search:x", http.StatusBadRequest, ""},
+		{"unknown author", "author_id=A404", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter = ""
+			rec := httptest.NewRecorder()
+			h.GetIngestEstimateHandler(rec, httptest.NewRequest(http.MethodGet, "/api/ingest-estimate?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if filter != tt.wantFilter {
+				t.Errorf("OpenAlex filter = %q, want %q", filter, tt.wantFilter)
+			}
+			var body struct {
+				Filter           string `json:"filter"`
+				WorksCount       int    `json:"worksCount"`
+				EstimatedSeconds int    `json:"estimatedSeconds"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			// 22 pages at 10 requests a second plus 4230 saves.
+			wantSeconds := int((22*100*time.Millisecond + 4230*estimatedWorkSaveTime + time.Second - 1) / time.Second)
+			if body.Filter != tt.wantFilter || body.WorksCount != 4230 || body.EstimatedSeconds != wantSeconds {
+				t.Errorf("response = %+v, want filter %q, 4230 works and %ds", body, tt.wantFilter, wantSeconds)
+			}
+		})
+	}
+}
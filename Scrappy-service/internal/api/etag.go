@@ -0,0 +1,45 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// respondWithJSONIfChanged writes payload as a 200 JSON response with a weak ETag hashed
+// from the body, or answers 304 Not Modified without a body when the request's
+// If-None-Match already names that ETag. Polling clients then only download a response
+// when it changed. Cache-Control: no-cache makes browsers revalidate instead of reusing a
+// stale copy.
+func respondWithJSONIfChanged(w http.ResponseWriter, r *http.Request, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	sum := sha256.Sum256(response)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(response)
+}
+
+// etagMatches reports whether an If-None-Match header names etag, comparing weakly as
+// RFC 9110 asks for If-None-Match: a W/ prefix on either side is ignored.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
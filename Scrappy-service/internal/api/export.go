@@ -0,0 +1,119 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// ExportGraphMLHandler streams the whole graph as GraphML, e.g. for Gephi or Cytoscape.
+func (h *APIHandler) ExportGraphMLHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/graphml+xml")
+	w.Header().Set("Content-Disposition", `attachment; filename="scholarsphere.graphml"`)
+	bw := bufio.NewWriter(w)
+
+	io.WriteString(bw, xml.Header)
+	io.WriteString(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="node" attr.name="label" attr.type="string"/>
+  <key id="title" for="node" attr.name="title" attr.type="string"/>
+  <key id="displayName" for="node" attr.name="displayName" attr.type="string"/>
+  <key id="year" for="node" attr.name="year" attr.type="int"/>
+  <key id="type" for="edge" attr.name="type" attr.type="string"/>
+  <graph id="scholarsphere" edgedefault="directed">
+`)
+	err := h.repo.ExportGraph(r.Context(),
+		func(n storage.ExportNode) error {
+			fmt.Fprintf(bw, "    <node id=\"%s\">\n", xmlEscape(n.ID))
+			writeGraphMLData(bw, "label", n.Label)
+			writeGraphMLData(bw, "title", n.Title)
+			writeGraphMLData(bw, "displayName", n.DisplayName)
+			if n.Year != 0 {
+				writeGraphMLData(bw, "year", fmt.Sprint(n.Year))
+			}
+			_, err := io.WriteString(bw, "    </node>\n")
+			return err
+		},
+		func(e storage.ExportEdge) error {
+			_, err := fmt.Fprintf(bw, "    <edge source=\"%s\" target=\"%s\"><data key=\"type\">%s</data></edge>\n",
+				xmlEscape(e.Source), xmlEscape(e.Target), xmlEscape(e.Type))
+			return err
+		})
+	if err != nil {
+		// The response is already streaming, so the status can't change any more; the
+		// unterminated document tells the client the export is incomplete.
+		log.Printf("ERROR: GraphML export failed: %v", err)
+		bw.Flush()
+		return
+	}
+	io.WriteString(bw, "  </graph>\n</graphml>\n")
+	bw.Flush()
+}
+
+// ExportJSONLDHandler streams the whole graph as a JSON-LD document. Relationships are
+// emitted as separate node objects that reference their source by @id, which JSON-LD
+// processors merge into the source node.
+func (h *APIHandler) ExportJSONLDHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/ld+json")
+	w.Header().Set("Content-Disposition", `attachment; filename="scholarsphere.jsonld"`)
+	bw := bufio.NewWriter(w)
+
+	io.WriteString(bw, `{"@context":{"@vocab":"https://scholarsphere.example/schema#","title":"http://purl.org/dc/terms/title","displayName":"http://schema.org/name","year":"http://schema.org/datePublished"},"@graph":[`)
+	first := true
+	writeItem := func(item map[string]interface{}) error {
+		if !first {
+			io.WriteString(bw, ",\n")
+		}
+		first = false
+		b, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(b)
+		return err
+	}
+
+	err := h.repo.ExportGraph(r.Context(),
+		func(n storage.ExportNode) error {
+			item := map[string]interface{}{"@id": n.ID, "@type": n.Label}
+			if n.Title != "" {
+				item["title"] = n.Title
+			}
+			if n.DisplayName != "" {
+				item["displayName"] = n.DisplayName
+			}
+			if n.Year != 0 {
+				item["year"] = n.Year
+			}
+			return writeItem(item)
+		},
+		func(e storage.ExportEdge) error {
+			return writeItem(map[string]interface{}{"@id": e.Source, e.Type: map[string]string{"@id": e.Target}})
+		})
+	if err != nil {
+		log.Printf("ERROR: JSON-LD export failed: %v", err)
+		bw.Flush()
+		return
+	}
+	io.WriteString(bw, "]}\n")
+	bw.Flush()
+}
+
+func writeGraphMLData(w io.Writer, key, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w, "      <data key=\"%s\">%s</data>\n", key, xmlEscape(value))
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
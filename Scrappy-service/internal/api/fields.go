@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// fieldSelection is a parsed fields= parameter: the top-level JSON fields each returned
+// object keeps. A nil selection keeps everything.
+type fieldSelection map[string]bool
+
+// fieldsParam reads the comma-separated fields query parameter, e.g.
+// fields=id,title,doi, and checks it against the JSON fields of item, a value of the
+// type the endpoint returns (or of its list elements). Unknown names are rejected with
+// the list of valid ones; without the parameter the selection is nil.
+func fieldsParam(r *http.Request, item interface{}) (fieldSelection, error) {
+	raw := r.URL.Query().Get("fields")
+	if raw == "" {
+		return nil, nil
+	}
+	valid := jsonFieldNames(reflect.TypeOf(item))
+	selection := make(fieldSelection)
+	var unknown []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !valid[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		selection[name] = true
+	}
+	if len(unknown) > 0 {
+		names := make([]string, 0, len(valid))
+		for name := range valid {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown field(s) %s in 'fields'; valid fields are %s",
+			strings.Join(unknown, ", "), strings.Join(names, ", "))
+	}
+	if len(selection) == 0 {
+		return nil, fmt.Errorf("'fields' must name at least one field")
+	}
+	return selection, nil
+}
+
+// jsonFieldNames returns the names encoding/json uses for the fields of a struct type,
+// following pointers and the fields of embedded structs. Fields tagged "-" are left out.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	names := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			for embedded := range jsonFieldNames(field.Type) {
+				names[embedded] = true
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// project returns value with only the selected fields of each object: of value itself if
+// it encodes as an object, or of its elements if it encodes as a list. A nil selection
+// returns value unchanged.
+func (s fieldSelection) project(value interface{}) interface{} {
+	if s == nil {
+		return value
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return value
+	}
+	switch v := decoded.(type) {
+	case []interface{}:
+		for i, element := range v {
+			v[i] = s.keep(element)
+		}
+		return v
+	default:
+		return s.keep(v)
+	}
+}
+
+// keep drops the unselected keys of a decoded JSON object; anything else is returned as
+// it is.
+func (s fieldSelection) keep(value interface{}) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for key := range object {
+		if !s[key] {
+			delete(object, key)
+		}
+	}
+	return object
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestJSONFieldNames(t *testing.T) {
+	type inner struct {
+		Shared string `json:"shared"`
+	}
+	type item struct {
+		inner
+		ID        string `json:"id"`
+		Untagged  int
+		Optional  string `json:"optional,omitempty"`
+		Hidden    string `json:"-"`
+		unexposed string
+	}
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{"struct", item{}, []string{"Untagged", "id", "optional", "shared"}},
+		{"pointer", &item{}, []string{"Untagged", "id", "optional", "shared"}},
+		{"embedded work", dto.SimilarWork{}, []string{"doi", "id", "is_retracted", "publication_date", "publication_year", "similarity", "title"}},
+		{"not a struct", "id", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for name := range jsonFieldNames(reflect.TypeOf(tt.value)) {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsParam(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    fieldSelection
+		wantErr []string // what the error names
+	}{
+		{"omitted", "", nil, nil},
+		{"empty", "fields=", nil, nil},
+		{"selected", "fields=id,title", fieldSelection{"id": true, "title": true}, nil},
+		{"spaces and blanks", "fields=%20id%20,,title,", fieldSelection{"id": true, "title": true}, nil},
+		{"repeated", "fields=id,id", fieldSelection{"id": true}, nil},
+		{"unknown", "fields=id,cited_by_count,abstract", nil,
+			[]string{"cited_by_count, abstract", "doi, id, is_retracted, publication_date, publication_year, title"}},
+		{"case matters", "fields=ID", nil, []string{"ID"}},
+		{"nested", "fields=authorships.author.id", nil, []string{"authorships.author.id"}},
+		{"nothing named", "fields=,", nil, []string{"at least one field"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fieldsParam(httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil), domain.DehydratedWork{})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("fieldsParam: %v", err)
+			}
+			if tt.wantErr != nil && err == nil {
+				t.Fatalf("fieldsParam = %v, want an error", got)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q doesn't name %q", err, want)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selection = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldSelectionProject(t *testing.T) {
+	works := []domain.DehydratedWork{{ID: "W1", Title: "one", PublicationYear: 2020}, {ID: "W2", Doi: "10.1/b"}}
+	tests := []struct {
+		name      string
+		selection fieldSelection
+		value     interface{}
+		want      string // JSON
+	}{
+		{"list", fieldSelection{"id": true, "title": true}, works,
+			`[{"id": "W1", "title": "one"}, {"id": "W2", "title": ""}]`},
+		{"object", fieldSelection{"publication_year": true}, works[0], `{"publication_year": 2020}`},
+		{"pointer", fieldSelection{"doi": true}, &works[1], `{"doi": "10.1/b"}`},
+		{"embedded fields", fieldSelection{"id": true, "similarity": true},
+			[]dto.SimilarWork{{DehydratedWork: works[0], Similarity: 0.5}}, `[{"id": "W1", "similarity": 0.5}]`},
+		{"omitted field", fieldSelection{"lastKnownInstitution": true, "id": true},
+			dto.AuthorSummary{ID: "A1"}, `{"id": "A1"}`},
+		{"empty list", fieldSelection{"id": true}, []domain.DehydratedWork{}, `[]`},
+		{"list of non-objects", fieldSelection{"id": true}, []string{"W1"}, `["W1"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(tt.selection.project(tt.value))
+			var gotValue, wantValue interface{}
+			json.Unmarshal(got, &gotValue)
+			json.Unmarshal([]byte(tt.want), &wantValue)
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("projected = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Without a selection the value itself is returned, not a re-encoded copy.
+	if got := fieldSelection(nil).project(works); !reflect.DeepEqual(got, works) {
+		t.Errorf("project without a selection = %#v, want the works unchanged", got)
+	}
+}
+
+// A graph read endpoint returns only the selected fields, everything without fields=, and
+// a 400 listing the valid fields for unknown ones.
+func TestGetAuthorWorksHandlerFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantKeys   []string
+	}{
+		{"all fields", "", http.StatusOK, []string{"doi", "id", "is_retracted", "publication_date", "publication_year", "title"}},
+		{"selected", "&fields=title,publication_year,doi", http.StatusOK, []string{"doi", "publication_year", "title"}},
+		{"unknown", "&fields=title,authorships", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.authorWorks = []domain.DehydratedWork{
+				{ID: "W1", Doi: "10.1/a", Title: "one", PublicationYear: 2020, PublicationDate: "2020-05-01"},
+				{ID: "W2", Title: "two"},
+			}
+			rec := httptest.NewRecorder()
+			newTestHandler(repo).GetAuthorWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/fetch-recent-works/?source=graph&id=A1"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if body := rec.Body.String(); !strings.Contains(body, "authorships") || !strings.Contains(body, "publication_year") {
+					t.Errorf("error %s doesn't name the unknown field and the valid ones", body)
+				}
+				return
+			}
+			var works []map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &works); err != nil {
+				t.Fatalf("decoding %s: %v", rec.Body, err)
+			}
+			if len(works) != 2 {
+				t.Fatalf("%d works, want 2", len(works))
+			}
+			for _, work := range works {
+				keys := []string{}
+				for key := range work {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				if !reflect.DeepEqual(keys, tt.wantKeys) {
+					t.Errorf("work has fields %v, want %v", keys, tt.wantKeys)
+				}
+			}
+			if works[0]["title"] != "one" {
+				t.Errorf("W1 = %v, want its title kept", works[0])
+			}
+		})
+	}
+}
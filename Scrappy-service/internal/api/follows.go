@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// followerID identifies the user of a follow or digest request: the user query parameter,
+// else the X-User header, else the API key. Keys are stored hashed, as key:<sha256 prefix>,
+// so the graph never holds one. It returns "" for anonymous requests.
+func followerID(r *http.Request) string {
+	if user := strings.TrimSpace(r.URL.Query().Get("user")); user != "" {
+		return user
+	}
+	if user := strings.TrimSpace(r.Header.Get("X-User")); user != "" {
+		return user
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return keyActor(key)
+	}
+	return ""
+}
+
+// FollowsHandler manages the authors a user follows. GET lists them; POST {"authorId": "..."}
+// follows an author and DELETE ?id=... unfollows one. An author that isn't in the graph
+// yet is fetched from OpenAlex and ingested in a background job, whose ID is returned as
+// ingestJobId, and followed right away.
+func (h *APIHandler) FollowsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := followerID(r)
+	if userID == "" {
+		respondWithError(w, http.StatusBadRequest, "Identify the user with the 'user' query parameter, an X-User header or an X-API-Key")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	switch r.Method {
+	case http.MethodGet:
+		authors, err := h.repo.ListFollowed(ctx, userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"user": userID, "authors": authors})
+	case http.MethodPost:
+		var req dto.FollowRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		if strings.TrimSpace(req.AuthorID) == "" {
+			respondWithError(w, http.StatusBadRequest, "Request must contain 'authorId'")
+			return
+		}
+		authorID, err := openalex.ValidateID(req.AuthorID, 'A')
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		id := h.resolveAuthorID(ctx, authorID)
+		exists, err := h.repo.AuthorExists(ctx, id)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp := map[string]interface{}{"user": userID}
+		if !exists {
+			if h.rejectIfBlocked(ctx, w, id) {
+				return
+			}
+			filter, err := h.workFilterFor(r)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			author, err := h.alexClient.FetchAuthorById(authorID)
+			if err != nil {
+				respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch author from OpenAlex: %v", err))
+				return
+			}
+			jobIDs, err := h.autoIngestAuthors(r, []domain.Author{author}, filter)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if len(jobIDs) > 0 {
+				resp["ingestJobId"] = jobIDs[0]
+			}
+			// OpenAlex may have answered with the profile the ID was merged into.
+			id = canonicalOpenAlexID(author.ID)
+		}
+		if err := h.repo.Follow(ctx, userID, id); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp["authorId"] = id
+		respondWithJSON(w, http.StatusOK, resp)
+	case http.MethodDelete:
+		authorID, ok := authorIDParam(w, r)
+		if !ok {
+			return
+		}
+		id := h.resolveAuthorID(ctx, authorID)
+		err := h.repo.Unfollow(ctx, userID, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondWithJSON(w, http.StatusOK, map[string]string{"user": userID, "authorId": id, "message": "Unfollowed"})
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Use GET, POST or DELETE")
+	}
+}
+
+// GetDigestHandler returns the works first saved to the graph after since by the authors
+// the user follows, grouped by author, e.g. ?user=alice&since=2024-01-01. since is read
+// as by /api/authors/new-works.
+func (h *APIHandler) GetDigestHandler(w http.ResponseWriter, r *http.Request) {
+	userID := followerID(r)
+	if userID == "" {
+		respondWithError(w, http.StatusBadRequest, "Identify the user with the 'user' query parameter, an X-User header or an X-API-Key")
+		return
+	}
+	since, err := parseSince(r.URL.Query().Get("since"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	digest, err := h.repo.GetDigest(ctx, userID, since)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSONIfChanged(w, r, map[string]interface{}{
+		"user":    userID,
+		"since":   since.Format(time.RFC3339),
+		"authors": digest,
+	})
+}
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestFollowerID(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		want   string
+	}{
+		{"parameter", "/api/follows?user=alice", http.Header{"X-User": {"bob"}, "X-Api-Key": {"secret"}}, "alice"},
+		{"header", "/api/follows", http.Header{"X-User": {" bob "}, "X-Api-Key": {"secret"}}, "bob"},
+		{"API key", "/api/follows?user=+", http.Header{"X-Api-Key": {"secret"}}, keyActor("secret")},
+		{"anonymous", "/api/follows", http.Header{}, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		r.Header = tt.header
+		if got := followerID(r); got != tt.want {
+			t.Errorf("%s: followerID = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := keyActor("secret"); !strings.HasPrefix(got, "key:") || strings.Contains(got, "secret") {
+		t.Errorf("keyActor(secret) = %q, want a key: hash", got)
+	}
+}
+
+// serveFollowedAuthors serves authors A2, and A3 as merged into A4, with no works, and
+// counts the author requests.
+func serveFollowedAuthors(authorRequests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authors/A2":
+			*authorRequests++
+			fmt.Fprint(w, `{"id": "https://openalex.org/A2", "display_name": "Ada"}`)
+		case "/authors/A3":
+			*authorRequests++
+			fmt.Fprint(w, `{"id": "https://openalex.org/A4", "display_name": "Merged"}`)
+		case "/works":
+			fmt.Fprint(w, `{"meta": {"count": 0, "next_cursor": null}, "results": []}`)
+		default:
+			*authorRequests++
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestFollowsHandler(t *testing.T) {
+	var authorRequests int
+	fakeOpenAlex(t, serveFollowedAuthors(&authorRequests))
+	alice := http.Header{"X-User": {"alice"}}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		header     http.Header
+		body       string
+		wantStatus int
+		// wantAuthorID is the author followed or unfollowed, and wantIngested the author an
+		// ingest job was started for, if any. wantFollows are alice's follows afterwards.
+		wantAuthorID       string
+		wantIngested       string
+		wantAuthorRequests int
+		wantFollows        []string
+	}{
+		{name: "follow an author in the graph", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": "A1"}`, wantStatus: http.StatusOK, wantAuthorID: "https://openalex.org/A1",
+			wantFollows: []string{"https://openalex.org/A5", "https://openalex.org/A1"}},
+		{name: "follow again", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": "https://openalex.org/A5"}`, wantStatus: http.StatusOK, wantAuthorID: "https://openalex.org/A5",
+			wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "follow a merged ID", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": "A9"}`, wantStatus: http.StatusOK, wantAuthorID: "https://openalex.org/A1",
+			wantFollows: []string{"https://openalex.org/A5", "https://openalex.org/A1"}},
+		{name: "follow an unknown author", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": "A2"}`, wantStatus: http.StatusOK, wantAuthorID: "https://openalex.org/A2",
+			wantIngested: "https://openalex.org/A2", wantAuthorRequests: 1,
+			wantFollows: []string{"https://openalex.org/A5", "https://openalex.org/A2"}},
+		{name: "follow an author OpenAlex merged", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": "A3"}`, wantStatus: http.StatusOK, wantAuthorID: "https://openalex.org/A4",
+			wantIngested: "https://openalex.org/A4", wantAuthorRequests: 1,
+			wantFollows: []string{"https://openalex.org/A5", "https://openalex.org/A4"}},
+		{name: "follow an author OpenAlex doesn't know", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": "A404"}`, wantStatus: http.StatusNotFound, wantAuthorRequests: 1,
+			wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "follow a blocked author", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": "A6"}`, wantStatus: http.StatusForbidden, wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "follow a work", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": "W1"}`, wantStatus: http.StatusBadRequest, wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "no author", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId": " "}`, wantStatus: http.StatusBadRequest, wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "invalid body", method: http.MethodPost, target: "/api/follows", header: alice,
+			body: `{"authorId":`, wantStatus: http.StatusBadRequest, wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "anonymous", method: http.MethodPost, target: "/api/follows",
+			body: `{"authorId": "A1"}`, wantStatus: http.StatusBadRequest, wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "unfollow", method: http.MethodDelete, target: "/api/follows?id=A5", header: alice,
+			wantStatus: http.StatusOK, wantAuthorID: "https://openalex.org/A5", wantFollows: []string{}},
+		{name: "unfollow a merged ID", method: http.MethodDelete, target: "/api/follows?id=A8", header: alice,
+			wantStatus: http.StatusOK, wantAuthorID: "https://openalex.org/A5", wantFollows: []string{}},
+		{name: "unfollow an author not followed", method: http.MethodDelete, target: "/api/follows?id=A1", header: alice,
+			wantStatus: http.StatusNotFound, wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "unfollow without an id", method: http.MethodDelete, target: "/api/follows", header: alice,
+			wantStatus: http.StatusBadRequest, wantFollows: []string{"https://openalex.org/A5"}},
+		{name: "other method", method: http.MethodPut, target: "/api/follows", header: alice,
+			wantStatus: http.StatusMethodNotAllowed, wantFollows: []string{"https://openalex.org/A5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorRequests = 0
+			repo := newFakeRepo()
+			repo.synced["https://openalex.org/A1"] = time.Now()
+			repo.synced["https://openalex.org/A5"] = time.Now()
+			repo.aliases["https://openalex.org/A9"] = "https://openalex.org/A1"
+			repo.aliases["https://openalex.org/A8"] = "https://openalex.org/A5"
+			repo.blocked["https://openalex.org/A6"] = "spam"
+			repo.follows["alice"] = []string{"https://openalex.org/A5"}
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+			h.FollowsHandler(rec, r)
+			h.jobs.wg.Wait()
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := repo.follows["alice"]; len(got) != len(tt.wantFollows) || len(got) > 0 && !reflect.DeepEqual(got, tt.wantFollows) {
+				t.Errorf("alice follows %v, want %v", got, tt.wantFollows)
+			}
+			if authorRequests != tt.wantAuthorRequests {
+				t.Errorf("%d OpenAlex author requests, want %d", authorRequests, tt.wantAuthorRequests)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				User        string `json:"user"`
+				AuthorID    string `json:"authorId"`
+				IngestJobID string `json:"ingestJobId"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.User != "alice" || body.AuthorID != tt.wantAuthorID {
+				t.Errorf("response = %+v, want alice and %s", body, tt.wantAuthorID)
+			}
+			// Following an author not in the graph yet ingests them in the background.
+			if tt.wantIngested == "" {
+				if body.IngestJobID != "" || len(repo.savedAuthors) > 0 {
+					t.Errorf("job %q saved %v, want no ingest", body.IngestJobID, repo.savedAuthors)
+				}
+				return
+			}
+			event := repo.event(body.IngestJobID)
+			if event.Kind != "author" || event.TargetID != tt.wantIngested || event.Status != storage.IngestStatusCompleted || event.RequestedBy != "alice" {
+				t.Errorf("ingest job = %+v, want a completed ingest of %s for alice", event, tt.wantIngested)
+			}
+			if len(repo.savedAuthors) != 1 || repo.savedAuthors[0].ID != tt.wantIngested {
+				t.Errorf("saved authors %v, want %s", repo.savedAuthors, tt.wantIngested)
+			}
+		})
+	}
+}
+
+func TestFollowsHandlerLists(t *testing.T) {
+	repo := newFakeRepo()
+	repo.follows["alice"] = []string{"https://openalex.org/A1", "https://openalex.org/A2"}
+	h := newTestHandler(repo)
+
+	for user, want := range map[string][]string{
+		"alice": {"https://openalex.org/A2", "https://openalex.org/A1"},
+		"bob":   {},
+	} {
+		rec := httptest.NewRecorder()
+		h.FollowsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/follows?user="+user, nil))
+		var body struct {
+			User    string                   `json:"user"`
+			Authors []storage.FollowedAuthor `json:"authors"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		ids := []string{}
+		for _, author := range body.Authors {
+			ids = append(ids, author.ID)
+		}
+		if rec.Code != http.StatusOK || body.User != user || !reflect.DeepEqual(ids, want) || body.Authors == nil {
+			t.Errorf("%s: %d %s, want the authors %v", user, rec.Code, rec.Body, want)
+		}
+	}
+}
+
+func TestGetDigestHandler(t *testing.T) {
+	repo := newFakeRepo()
+	repo.digests = make(map[string][]storage.AuthorDigest)
+	newWork := func(id string, day int) storage.NewWork {
+		return storage.NewWork{DehydratedWork: domain.DehydratedWork{ID: id}, FirstSeen: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)}
+	}
+	repo.digests["alice"] = []storage.AuthorDigest{
+		{AuthorID: "A2", DisplayName: "Ada", Works: []storage.NewWork{newWork("W3", 20)}},
+		{AuthorID: "A1", DisplayName: "Zed", Works: []storage.NewWork{newWork("W3", 20), newWork("W2", 10)}},
+	}
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		name       string
+		target     string
+		header     http.Header
+		wantStatus int
+		wantSince  string
+		wantWorks  map[string][]string // by author
+	}{
+		{"grouped by author", "/api/digest?user=alice&since=2024-01-01", nil, http.StatusOK, "2024-01-01T00:00:00Z",
+			map[string][]string{"A2": {"W3"}, "A1": {"W3", "W2"}}},
+		{"user from the header", "/api/digest?since=2024-01-15", http.Header{"X-User": {"alice"}}, http.StatusOK, "2024-01-15T00:00:00Z",
+			map[string][]string{"A2": {"W3"}, "A1": {"W3"}}},
+		{"nothing new", "/api/digest?user=alice&since=2024-02-01T00:00:00Z", nil, http.StatusOK, "2024-02-01T00:00:00Z", map[string][]string{}},
+		{"nobody followed", "/api/digest?user=bob&since=2024-01-01", nil, http.StatusOK, "2024-01-01T00:00:00Z", map[string][]string{}},
+		{"missing since", "/api/digest?user=alice", nil, http.StatusBadRequest, "", nil},
+		{"malformed since", "/api/digest?user=alice&since=yesterday", nil, http.StatusBadRequest, "", nil},
+		{"anonymous", "/api/digest?since=2024-01-01", nil, http.StatusBadRequest, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+			h.GetDigestHandler(rec, r)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				User    string                 `json:"user"`
+				Since   string                 `json:"since"`
+				Authors []storage.AuthorDigest `json:"authors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if body.Since != tt.wantSince || repo.digestSince.Format(time.RFC3339) != tt.wantSince {
+				t.Errorf("since = %q, repository asked for %v, want %s", body.Since, repo.digestSince, tt.wantSince)
+			}
+			works := map[string][]string{}
+			for _, author := range body.Authors {
+				for _, work := range author.Works {
+					works[author.AuthorID] = append(works[author.AuthorID], work.ID)
+				}
+			}
+			if !reflect.DeepEqual(works, tt.wantWorks) || body.Authors == nil {
+				t.Errorf("works = %v, want %v", works, tt.wantWorks)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Compression gzips responses of at least MinSize bytes for clients that accept it, at
+// the given gzip Level (1-9). Smaller responses, responses that already have a
+// Content-Encoding or are compressed formats, and Server-Sent Events streams are passed
+// through unchanged.
+type Compression struct {
+	Level   int
+	MinSize int
+}
+
+// incompressibleTypes are Content-Type prefixes that gain nothing from gzip, or that must
+// reach the client unbuffered.
+var incompressibleTypes = []string{"text/event-stream", "image/", "video/", "audio/", "application/gzip", "application/zip"}
+
+// Wrap compresses next's responses according to the policy. At most MinSize bytes are
+// buffered to decide whether to compress; everything after that is streamed through the
+// gzip writer.
+func (c Compression) Wrap(next http.Handler) http.Handler {
+	level := c.Level
+	if level < gzip.BestSpeed || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	pool := &sync.Pool{New: func() any {
+		gz, _ := gzip.NewWriterLevel(nil, level)
+		return gz
+	}}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: c.MinSize, pool: pool, status: http.StatusOK}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter holds the status and the first bytes of a response back until it
+// knows whether to compress it.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+	pool    *sync.Pool
+
+	status      int
+	wroteHeader bool // the handler called WriteHeader
+	decided     bool // headers went out; gz is set if compressing
+	buf         []byte
+	gz          *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader, w.status = true, status
+	// Streams and bodiless responses are decided right away, so they are never held back.
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified || !w.compressible() {
+		w.passThrough()
+	}
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends what has been written so far. A response still below MinSize is sent
+// uncompressed, since the handler wants it delivered now.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		if !w.decided {
+			w.passThrough()
+		}
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// compressible reports whether the response's headers allow compressing it.
+func (w *gzipResponseWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := h.Get("Content-Type")
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// passThrough sends the headers and any buffered bytes uncompressed.
+func (w *gzipResponseWriter) passThrough() {
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) > 0 {
+		w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// startGzip switches the response to gzip and compresses the buffered bytes.
+func (w *gzipResponseWriter) startGzip() error {
+	w.decided = true
+	h := w.Header()
+	h.Set("Content-Encoding", "gzip")
+	// The length of the compressed body isn't known up front.
+	h.Del("Content-Length")
+	// net/http doesn't sniff encoded bodies, so the type is sniffed from the plain bytes.
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.gz = w.pool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// close finishes the response: a body that never reached MinSize is sent as is.
+func (w *gzipResponseWriter) close() {
+	if !w.decided {
+		if !w.wroteHeader {
+			// Nothing at all was written; let net/http send its default response.
+			return
+		}
+		w.passThrough()
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		w.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
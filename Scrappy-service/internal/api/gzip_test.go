@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	large := strings.Repeat(`{"title": "a work"},`, 100)
+	small := `{"ok": true}`
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		contentType    string
+		encoding       string // Content-Encoding set by the handler
+		setLength      bool   // the handler sets Content-Length
+		status         int
+		body           string
+		wantGzip       bool
+	}{
+		{name: "large", acceptEncoding: "gzip", contentType: "application/json", body: large, wantGzip: true},
+		{name: "large with length", acceptEncoding: "gzip, deflate", contentType: "application/json", setLength: true, body: large, wantGzip: true},
+		{name: "sniffed type", acceptEncoding: "gzip", body: large, wantGzip: true},
+		{name: "error status", acceptEncoding: "gzip", contentType: "application/json", status: http.StatusInternalServerError, body: large, wantGzip: true},
+		{name: "small", acceptEncoding: "gzip", contentType: "application/json", body: small},
+		{name: "small with length", acceptEncoding: "gzip", contentType: "application/json", setLength: true, body: small},
+		{name: "not accepted", contentType: "application/json", body: large},
+		{name: "other coding", acceptEncoding: "br", contentType: "application/json", body: large},
+		{name: "refused", acceptEncoding: "gzip;q=0, br", contentType: "application/json", body: large},
+		{name: "weighted", acceptEncoding: "br;q=1.0, GZIP;q=0.5", contentType: "application/json", body: large, wantGzip: true},
+		{name: "server-sent events", acceptEncoding: "gzip", contentType: "text/event-stream", body: large},
+		{name: "already encoded", acceptEncoding: "gzip", contentType: "application/json", encoding: "br", body: large},
+		{name: "compressed format", acceptEncoding: "gzip", contentType: "application/zip", setLength: true, body: large},
+		{name: "head", method: http.MethodHead, acceptEncoding: "gzip", contentType: "application/json", setLength: true, body: large},
+		{name: "no content", acceptEncoding: "gzip", status: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Compression{Level: gzip.BestSpeed, MinSize: 512}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				if tt.setLength {
+					w.Header().Set("Content-Length", strconv.Itoa(len(tt.body)))
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				if r.Method != http.MethodHead {
+					// Written in pieces, so the size decision spans several writes.
+					for i := 0; i < len(tt.body); i += 100 {
+						io.WriteString(w, tt.body[i:min(i+100, len(tt.body))])
+					}
+				}
+			}))
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, _ := http.NewRequest(method, server.URL, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			// Explicitly asking for an encoding stops the transport from decompressing.
+			resp, err := http.DefaultTransport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			raw, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(strings.Join(resp.Header.Values("Vary"), ","), "Accept-Encoding") {
+				t.Errorf("Vary = %q, want Accept-Encoding", resp.Header.Values("Vary"))
+			}
+			if tt.status != 0 && resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if resp.ContentLength >= 0 && resp.ContentLength != int64(len(raw)) && method != http.MethodHead {
+				t.Errorf("Content-Length = %d for %d bytes", resp.ContentLength, len(raw))
+			}
+			if tt.contentType == "" && len(tt.body) > 0 && !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+				t.Errorf("Content-Type = %q, want it sniffed from the uncompressed body", resp.Header.Get("Content-Type"))
+			}
+
+			body := raw
+			if gzipped := resp.Header.Get("Content-Encoding") == "gzip"; gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip: %v", resp.Header.Get("Content-Encoding"), tt.wantGzip)
+			}
+			if tt.wantGzip {
+				zr, err := gzip.NewReader(bytes.NewReader(raw))
+				if err != nil {
+					t.Fatalf("body is not gzip: %v", err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("decompressing: %v", err)
+				}
+			}
+			if method != http.MethodHead && string(body) != tt.body {
+				t.Errorf("body = %.60q..., want %.60q...", body, tt.body)
+			}
+		})
+	}
+}
+
+// Server-Sent Events must reach the client event by event, not held back until MinSize
+// bytes have been written.
+func TestCompressionStreamsEvents(t *testing.T) {
+	next := make(chan struct{})
+	handler := Compression{MinSize: 1 << 20}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			io.WriteString(w, "data: {}\n\n")
+			w.(http.Flusher).Flush()
+			<-next
+		}
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	buf := make([]byte, 64)
+	for i := 0; i < 3; i++ {
+		n, err := io.ReadAtLeast(resp.Body, buf, len("data: {}\n\n"))
+		if err != nil || string(buf[:n]) != "data: {}\n\n" {
+			t.Fatalf("event %d: %q, %v", i, buf[:n], err)
+		}
+		next <- struct{}{}
+	}
+}
+
+func TestCompressionLevel(t *testing.T) {
+	body := strings.Repeat("abcdefgh", 1000)
+	for _, level := range []int{gzip.BestSpeed, gzip.BestCompression, 0, 42} {
+		handler := Compression{Level: level, MinSize: 1}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, body)
+		}))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		handler.ServeHTTP(rec, req)
+
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("level %d: body is not gzip: %v", level, err)
+		}
+		if got, err := io.ReadAll(zr); err != nil || string(got) != body {
+			t.Errorf("level %d: decompressed %d bytes, %v", level, len(got), err)
+		}
+	}
+}
@@ -5,23 +5,37 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	// Use your actual module paths here
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/config"
 	"github.com/Cloudforge2/scrappy/internal/domain"
 	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/resolve"
 	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
 	"github.com/Cloudforge2/scrappy/internal/storage"
 )
 
 // APIHandler holds the dependencies for the API handlers.
 type APIHandler struct {
+	cfg        *config.Config
 	repo       storage.Repository
 	alexClient *openalex.Client
 	semClient  *semanticscholar.Client
+	papers     *resolve.PaperResolver
+	jobs       *jobRunner
+	saves      *savePool
+	ngrams     *ngramCache
+
+	openAlexProbe      *openAlexProbe
+	institutionFetches *institutionFetches
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -36,16 +50,27 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 }
 
 // NewAPIHandler creates a new handler with the necessary dependencies.
-func NewAPIHandler(repo storage.Repository, alexClient *openalex.Client, semClient *semanticscholar.Client) *APIHandler {
+func NewAPIHandler(cfg *config.Config, repo storage.Repository, alexClient *openalex.Client, semClient *semanticscholar.Client) *APIHandler {
 	return &APIHandler{
+		cfg:        cfg,
 		repo:       repo,
 		alexClient: alexClient,
 		semClient:  semClient,
+		papers:     resolve.NewPaperResolver(repo, alexClient, semClient),
+		jobs:       newJobRunner(cfg.MaxBackgroundJobs, cfg.BackgroundJobTimeout),
+		saves:      newSavePool(cfg.SavePoolShards),
+		ngrams:     newNgramCache(cfg.NgramCacheTTL),
+
+		openAlexProbe:      &openAlexProbe{ttl: cfg.ReadyzOpenAlexTTL},
+		institutionFetches: newInstitutionFetches(),
 	}
 }
 
-// FetchAndSaveAuthorByNameHandler is an HTTP handler that fetches an author from OpenAlex
-// and saves them to the Neo4j database.
+// FetchAndSaveAuthorByNameHandler searches OpenAlex for authors by name and returns the
+// matches. The top NAME_SEARCH_AUTO_INGEST matches (the best one by default) are also
+// ingested, author and works, each as a background job whose id is listed in the
+// X-Ingest-Jobs header; blocklisted authors are left out. ingest=false only searches, as
+// does every search when storage is disabled or NAME_SEARCH_AUTO_INGEST is 0.
 func (h *APIHandler) FetchAndSaveAuthorByNameHandler(w http.ResponseWriter, r *http.Request) {
 	// 1. Get the author name from the query parameters (e.g., ?name=stephen+hawking)
 	authorName := r.URL.Query().Get("name")
@@ -54,10 +79,57 @@ func (h *APIHandler) FetchAndSaveAuthorByNameHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	log.Printf("Received request to fetch authors with name: %s", authorName)
+	page, perPage, err := pageParams(r, openalex.ClampPerPage(h.cfg.OpenAlexPerPage))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var filters []string
+	if country := strings.TrimSpace(r.URL.Query().Get("country")); country != "" {
+		if !isCountryCode(country) {
+			http.Error(w, "'country' must be a two-letter ISO country code", http.StatusBadRequest)
+			return
+		}
+		filters = append(filters, "last_known_institutions.country_code:"+strings.ToUpper(country))
+	}
+	if institution := strings.TrimSpace(r.URL.Query().Get("institution")); institution != "" {
+		// A ROR ID is resolved to the institution's OpenAlex ID.
+		instID, err := h.institutionIDParam(r.Context(), institution)
+		if err != nil {
+			http.Error(w, err.Error(), institutionParamStatus(err))
+			return
+		}
+		filters = append(filters, "last_known_institutions.id:"+instID)
+	}
+
+	// Ingesting is the default wherever it is possible; only an explicit ingest=true is
+	// refused when it isn't.
+	ingest := !h.cfg.StorageDisabled() && h.cfg.NameSearchAutoIngest > 0
+	if raw := r.URL.Query().Get("ingest"); raw != "" {
+		if ingest, err = strconv.ParseBool(raw); err != nil {
+			http.Error(w, "'ingest' must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	var worksFilter workFilter
+	if ingest {
+		if h.storageDisabled(w) {
+			return
+		}
+		if h.cfg.NameSearchAutoIngest < 1 {
+			http.Error(w, "Auto-ingest of name searches is turned off (NAME_SEARCH_AUTO_INGEST=0)", http.StatusBadRequest)
+			return
+		}
+		if worksFilter, err = h.workFilterFor(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	log.Printf("Received request to fetch authors with name: %s (page %d)", authorName, page)
 
 	// 2. Use the OpenAlex client to fetch the data
-	authors, err := h.alexClient.FetchAuthorsByName(authorName)
+	authors, total, err := h.alexClient.FetchAuthorsByNamePage(authorName, page, perPage, filters...)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to fetch authors from OpenAlex: %v", err), http.StatusInternalServerError)
 		return
@@ -69,147 +141,214 @@ func (h *APIHandler) FetchAndSaveAuthorByNameHandler(w http.ResponseWriter, r *h
 	}
 
 	// Just return the authors found by their name and ID
-	type authorResponse struct {
-		ID                   string `json:"id"`
-		DisplayName          string `json:"displayName"`
-		LastKnownInstitution string `json:"lastKnownInstitution,omitempty"`
-		CitedByCount         int    `json:"citedByCount,omitempty"`
-		UpdatedDate          string `json:"updatedDate,omitempty"`
-		Orcid                string `json:"orcid,omitempty"`
-		WorksCount           int    `json:"worksCount,omitempty"`
+	var resp []dto.AuthorSummary
+	for _, a := range authors {
+		resp = append(resp, dto.NewAuthorSummary(a))
 	}
 
-	var resp []authorResponse
-	for _, a := range authors {
-		var lastInst string
-		if len(a.LastKnownInstitutions) > 0 && a.LastKnownInstitutions[0] != nil {
-			lastInst = a.LastKnownInstitutions[0].DisplayName
+	if ingest {
+		jobIDs, err := h.autoIngestAuthors(r, authors[:min(len(authors), h.cfg.NameSearchAutoIngest)], worksFilter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		resp = append(resp, authorResponse{
-			ID:                   a.ID,
-			DisplayName:          a.DisplayName,
-			LastKnownInstitution: lastInst,
-			CitedByCount:         a.CitedByCount,
-			UpdatedDate:          a.UpdatedDate,
-			Orcid:                a.Orcid,
-			WorksCount:           a.WorksCount,
-		})
+		w.Header().Set("X-Ingest-Jobs", strings.Join(jobIDs, ","))
 	}
 
+	// Pagination metadata goes in headers so the body stays the plain array clients expect.
+	writePageHeaders(w, page, perPage, total)
 	respondWithJSON(w, http.StatusOK, resp)
 }
 
+// autoIngestAuthors starts one background job per author that saves the author and then
+// all of their works page by page, as the background part of an author ingest does. The
+// jobs are resumable and share the background job slots, so a name search never waits on
+// the ingestion. Blocklisted authors are skipped. It returns the ids of the jobs started.
+func (h *APIHandler) autoIngestAuthors(r *http.Request, authors []domain.Author, filter workFilter) ([]string, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	jobIDs := make([]string, 0, len(authors))
+	for _, author := range authors {
+		blocked, _, err := h.repo.IsBlocked(ctx, canonicalOpenAlexID(author.ID))
+		if err != nil {
+			return jobIDs, err
+		}
+		if blocked {
+			log.Printf("Not auto-ingesting blocked author %s", author.ID)
+			continue
+		}
+		job := h.startIngestJob(ctx, "author", canonicalOpenAlexID(author.ID), requestedBy(r))
+		fetchedAt := time.Now().UTC()
+		ingest := authorIngest{job: job, authorID: author.ID, filter: filter, fetchedAt: fetchedAt}
+		job.makeResumable(ctx, filter.encode(), fetchedAt)
+		h.jobs.run(job, func(ctx context.Context) error {
+			err := h.saves.do(ctx, author.ID, func(ctx context.Context) error { return h.repo.SaveAuthor(ctx, author) })
+			if err != nil {
+				return fmt.Errorf("failed to save author: %w", err)
+			}
+			page, err := h.fetchAuthorWorksPage(ctx, ingest, "*")
+			if err != nil {
+				return fmt.Errorf("failed to fetch works from OpenAlex: %w", err)
+			}
+			return h.ingestAuthorPages(ctx, ingest, page)
+		})
+		log.Printf("Auto-ingesting author %s (%s) in job %s", author.DisplayName, author.ID, job.event.ID)
+		jobIDs = append(jobIDs, job.event.ID)
+	}
+	return jobIDs, nil
+}
+
 func (h *APIHandler) FetchAndSaveWorksByAuthorHandler(w http.ResponseWriter, r *http.Request) {
 	// 1. Get author ID and fetch the author (same as before)
-	authorID := r.URL.Query().Get("id")
-	if authorID == "" {
-		respondWithError(w, http.StatusBadRequest, "Missing 'id' query parameter")
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
 		return
 	}
-
-	log.Printf("Received request to ingest all works for authorssID: %s", authorID)
-	author, err := h.alexClient.FetchAuthorById(authorID)
+	filter, err := h.workFilterFor(r)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to fetch author from OpenAlex: %v", err))
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	log.Printf("Received request to ingest all works for authorssID: %s", authorID)
+
 	// 2. Save the author object itself synchronously. This is fast and should be done immediately.
 	// We'll use the request's context for this part.
 	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
 	defer cancel()
 
-	if err := h.repo.SaveAuthor(ctx, author); err != nil {
+	if h.rejectIfBlocked(ctx, w, authorID) {
+		return
+	}
+
+	// Every ingestion is audited; the job is finalized here unless it is handed to the
+	// background goroutine below.
+	job := h.startIngestJob(ctx, "author", canonicalOpenAlexID(authorID), requestedBy(r))
+	handedOff := false
+	defer func() {
+		if !handedOff {
+			job.finish(ctx, nil)
+		}
+	}()
+	defer job.finishOnPanic(true)
+
+	author, err := h.alexClient.FetchAuthorById(authorID)
+	if err != nil {
+		job.finish(ctx, err)
+		respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch author from OpenAlex: %v", err))
+		return
+	}
+
+	// OpenAlex redirects IDs of merged profiles to the surviving one. Record the merge so
+	// the old ID keeps resolving, and carry on with the canonical ID.
+	canonicalID := ""
+	if !sameOpenAlexID(authorID, author.ID) {
+		log.Printf("REDIRECT: OpenAlex author %s has been merged into %s", authorID, author.ID)
+		if err := h.repo.RecordAuthorMerge(ctx, canonicalOpenAlexID(authorID), author.ID); err != nil {
+			log.Printf("WARN: Could not record merge of author %s into %s: %v", authorID, author.ID, err)
+		}
+		authorID = author.ID
+		canonicalID = author.ID
+		job.retarget(author.ID)
+		if h.rejectIfBlocked(ctx, w, authorID) {
+			job.finish(ctx, fmt.Errorf("author %s is blocked", authorID))
+			return
+		}
+	}
+
+	err = h.saves.do(ctx, authorID, func(ctx context.Context) error { return h.repo.SaveAuthor(ctx, author) })
+	if err != nil {
+		job.finish(ctx, err)
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save author to database: %v", err))
 		return
 	}
 	log.Printf("Successfully saved author: %s (ID: %s)", author.DisplayName, author.ID)
 
-	// 3. Fetch ALL works for the author (same as before)
-	works, err := h.alexClient.FetchAllWorksByAuthorID(authorID)
+	// 3. Fetch the author's works page by page. Only the first page is fetched here; the job
+	// stores the filter and the cursor of the next page, so the background part can be
+	// resumed after a restart.
+	fetchedAt := time.Now().UTC()
+	ingest := authorIngest{job: job, authorID: authorID, filter: filter, fetchedAt: fetchedAt}
+	job.makeResumable(ctx, filter.encode(), fetchedAt)
+	page, err := h.fetchAuthorWorksPage(ctx, ingest, "*")
 	if err != nil {
+		job.finish(ctx, err)
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to fetch works from OpenAlex: %v", err))
 		return
 	}
-	if len(works) == 0 {
-		respondWithJSON(w, http.StatusOK, map[string]interface{}{"message": "Author has no works."})
+	totalWorks := author.WorksCount
+	skippedCount := page.skipped
+	if len(page.works) == 0 && page.next == "" {
+		h.recordWorksSync(ctx, authorID, fetchedAt)
+		responsePayload := map[string]interface{}{"message": "Author has no works.", "totalWorks": totalWorks, "skippedWorks": skippedCount, "jobId": job.event.ID}
+		addDecodeWarnings(responsePayload, page.warnings)
+		if canonicalID != "" {
+			responsePayload["canonicalId"] = canonicalID
+		}
+		respondWithJSON(w, http.StatusOK, responsePayload)
 		return
 	}
 
 	// --- NEW ASYNCHRONOUS LOGIC STARTS HERE ---
 
 	const initialBatchSize = 30
-	var initialWorks []domain.Work
-	var backgroundWorks []domain.Work
+	initialWorks := page.works
 
-	// 4. Split the works into an initial batch and a background batch.
-	if len(works) > initialBatchSize {
-		initialWorks = works[:initialBatchSize]
-		backgroundWorks = works[initialBatchSize:]
+	// 4. Split the first page into an initial batch and the rest, which is saved in the
+	// background before the following pages.
+	if len(page.works) > initialBatchSize {
+		initialWorks = page.works[:initialBatchSize]
+		page.works = page.works[initialBatchSize:]
 	} else {
-		initialWorks = works
-		// backgroundWorks will be empty
+		page.works = nil
 	}
 
 	// 5. Process the initial batch synchronously.
 	var savedCount int
 	for _, work := range initialWorks {
-		if err := h.repo.SaveWork(ctx, work); err != nil {
+		outcome, err := h.saveAuthorWork(ctx, authorID, work, filter.save)
+		job.workSaved(work, outcome, err)
+		if err != nil {
 			log.Printf("WARN: Could not save initial work %s: %v\n", work.Title, err)
 			continue
 		}
 		savedCount++
 	}
 	log.Printf("Synchronously saved initial batch of %d works for author %s.", savedCount, authorID)
+	initialFailures := job.failures()
 
 	// 6. Launch a goroutine to process the rest of the works in the background.
-	if len(backgroundWorks) > 0 {
-		log.Printf("Launching background task to save remaining %d works.", len(backgroundWorks))
-
-		go func() {
-			// IMPORTANT: We must create a new, independent context for the background task.
-			// The original request's context (r.Context()) will be cancelled as soon as
-			// this handler returns a response.
-			backgroundCtx := context.Background()
-
-			for _, work := range backgroundWorks {
-				// Use a reasonable timeout per work in the background.
-				workCtx, workCancel := context.WithTimeout(backgroundCtx, 30*time.Second)
-
-				if err := h.repo.SaveWork(workCtx, work); err != nil {
-					log.Printf("BACKGROUND ERROR: Could not save work %s: %v\n", work.Title, err)
-				} else {
-					log.Printf("BACKGROUND SUCCESS: Saved work: %s", work.Title)
-				}
-
-				workCancel() // Clean up the context for this single work
-			}
-			log.Printf("Background task finished for author %s. All %d works processed.", authorID, len(works))
-
-			// ✅ NEW CODE — mark author as fully ingested after background processing
-			if err := h.repo.MarkAuthorFullyIngested(backgroundCtx, authorID); err != nil {
-				log.Printf("WARN: Could not set fullyIngested flag for author %s: %v", authorID, err)
-			} else {
-				log.Printf("✅ Author %s marked as fully ingested in Neo4j.", authorID)
-			}
-		}()
-	}
+	if len(page.works) > 0 || page.next != "" {
+		log.Printf("Launching background task to save the remaining works of author %s.", authorID)
 
-	// ✅ If there are no background works, mark immediately
-	if len(backgroundWorks) == 0 {
-		if err := h.repo.MarkAuthorFullyIngested(ctx, authorID); err != nil {
-			log.Printf("WARN: Could not set fullyIngested flag for author %s: %v", authorID, err)
-		} else {
-			log.Printf("✅ Author %s marked as fully ingested in Neo4j (immediate).", authorID)
-		}
+		handedOff = true
+		h.jobs.run(job, func(backgroundCtx context.Context) error {
+			return h.ingestAuthorPages(backgroundCtx, ingest, page)
+		})
+	} else {
+		// ✅ If there are no background works, mark immediately
+		h.finishAuthorIngest(ctx, ingest)
 	}
 
 	// 7. Immediately respond to the user with a "202 Accepted" status.
 	// This tells them the process has started successfully.
 	responsePayload := map[string]interface{}{
 		"message":          "Request accepted. Initial works are being processed. The rest will be ingested in the background.",
-		"totalWorks":       len(works),
+		"totalWorks":       totalWorks,
 		"initialBatchSize": savedCount,
+		"skippedWorks":     skippedCount,
+		"jobId":            job.event.ID,
+	}
+	// Failures and decode warnings of the background pages end up in the job's ingest
+	// history record.
+	if len(initialFailures) > 0 {
+		responsePayload["failedWorks"] = initialFailures
+	}
+	addDecodeWarnings(responsePayload, page.warnings)
+	// The requested ID was an alias of a merged profile; clients should use this one.
+	if canonicalID != "" {
+		responsePayload["canonicalId"] = canonicalID
 	}
 	respondWithJSON(w, http.StatusAccepted, responsePayload)
 }
@@ -221,15 +360,36 @@ func (h *APIHandler) FetchAndSaveWorkByNameHandler(w http.ResponseWriter, r *htt
 		http.Error(w, "Missing 'name' query parameter", http.StatusBadRequest)
 		return
 	}
+	filter, err := h.workFilterFor(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if dryRun(r) {
+		respondWithOpenAlexURL(w, h.alexClient.WorksByNameURL(workName))
+		return
+	}
 
 	log.Printf("Received request to fetch and save work: %s", workName)
 
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	// The attempt is audited before anything is fetched, under the searched name until the
+	// work is known; requests that end without saving it finish the job as completed.
+	job := h.startIngestJob(ctx, "work", workName, requestedBy(r))
+	defer job.finish(ctx, nil)
+	defer job.finishOnPanic(true)
+
 	// 2. Use the OpenAlex client to fetch the data
-	works, err := h.alexClient.FetchWorksByName(workName)
+	works, warnings, err := h.alexClient.FetchWorksByName(workName)
 	if err != nil {
+		job.finish(ctx, err)
 		http.Error(w, fmt.Sprintf("Failed to fetch works from OpenAlex: %v", err), http.StatusInternalServerError)
 		return
 	}
+	logDecodeWarnings(fmt.Sprintf("search %q", workName), warnings)
 
 	if len(works) == 0 {
 		http.Error(w, fmt.Sprintf("No works found with the name: %s", workName), http.StatusNotFound)
@@ -238,27 +398,55 @@ func (h *APIHandler) FetchAndSaveWorkByNameHandler(w http.ResponseWriter, r *htt
 
 	// For this example, we'll just process the first work found.
 	work := works[0]
+	job.retarget(work.ID)
+	if filter.skips(work) {
+		job.worksSkipped(1)
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"message": "Work was skipped by the paratext/retracted ingest filter",
+			"id":      work.ID,
+			"title":   work.Title,
+			"skipped": 1,
+		})
+		return
+	}
 
 	// 3. Use the repository to save the data.
 	// NOTE: The SaveWork function is already designed to also save the author nodes
 	// and the AUTHORED relationships, so no extra steps are needed.
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
+	if h.rejectIfBlocked(ctx, w, work.ID) {
+		job.finish(ctx, errBlocked)
+		return
+	}
+	if _, existing := filter.dropExisting(ctx, h.repo, works[:1]); existing > 0 {
+		job.worksSkipped(1)
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"message": "Work is already in the graph",
+			"id":      work.ID,
+			"title":   work.Title,
+			"skipped": 1,
+		})
+		return
+	}
 
-	if err := h.repo.SaveWork(ctx, work); err != nil {
+	outcome, err := h.repo.SaveWork(ctx, work, filter.save)
+	job.workSaved(work, outcome, err)
+	job.finish(ctx, err)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to save work to database: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	log.Printf("Successfully saved work: %s (ID: %s)", work.Title, work.ID)
+	resolved := h.resolveReferencesOf(ctx, works[:1], filter.resolveReferences)
 
 	// 4. Send a success response back to the client
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Work and its authors successfully fetched and saved",
-		"id":      work.ID,
-		"title":   work.Title,
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":            "Work and its authors successfully fetched and saved",
+		"id":                 work.ID,
+		"title":              work.Title,
+		"referencesResolved": resolved,
 	})
 }
 
@@ -285,34 +473,110 @@ func (h *APIHandler) FetchAndSaveWorkByNameHandler(w http.ResponseWriter, r *htt
 
 func (h *APIHandler) GetAuthorWorksHandler(w http.ResponseWriter, r *http.Request) {
 	// Path should be registered as /api/authors/{author_id}/works
-	authorID := r.URL.Query().Get("id")
-	if authorID == "" {
-		http.Error(w, "Missing 'id' query parameter", http.StatusBadRequest)
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	onlyFulltext := r.URL.Query().Get("has_fulltext") == "true"
+	source := r.URL.Query().Get("source")
+	if source != "" && source != "openalex" && source != "graph" {
+		respondWithError(w, http.StatusBadRequest, "'source' must be 'openalex' or 'graph'")
+		return
+	}
+	sort := r.URL.Query().Get("sort")
+	if sort != "" && sort != "date" && sort != "cited" {
+		respondWithError(w, http.StatusBadRequest, "'sort' must be 'date' or 'cited'")
+		return
+	}
+	newestFirst := sort != "cited"
+	includeRetracted, err := includeRetractedParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	log.Printf("Request received: Fetch recent works for author ID %s", authorID)
+	if source == "graph" {
+		if h.storageDisabled(w) {
+			return
+		}
+		fields, err := fieldsParam(r, domain.DehydratedWork{})
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+		works, err := h.repo.GetAuthorWorks(ctx, h.resolveAuthorID(ctx, authorID), onlyFulltext, includeRetracted, newestFirst, 30)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondWithJSONIfChanged(w, r, fields.project(works))
+		return
+	}
+
 	// The Python script defaults to 30 results. We can make this a query param later if needed.
-	works, err := h.alexClient.FetchRecentWorksByAuthorID(authorID, 30)
+	filter := openalex.NewFilter().AuthorID(authorID).PerPage(30).Lean()
+	if onlyFulltext {
+		filter.HasFulltext(true)
+	}
+	if !includeRetracted {
+		filter.IsRetracted(false)
+	}
+	if newestFirst {
+		filter.Sort(openalex.SortByPublicationDate)
+	} else {
+		filter.Sort(openalex.SortByCitations)
+	}
+	if err := filter.Err(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun(r) {
+		requestURL, _ := h.alexClient.WorksURL(filter)
+		respondWithOpenAlexURL(w, requestURL)
+		return
+	}
+	works, warnings, err := h.alexClient.FetchWorks(filter)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	logDecodeWarnings("author "+authorID, warnings)
+	setDecodeWarningsHeader(w, warnings)
 
-	respondWithJSON(w, http.StatusOK, works)
+	respondWithJSONIfChanged(w, r, works)
 }
 
 type fetchAbstractsRequest struct {
 	DOIs []string `json:"dois"`
 }
 
+// FetchAbstractsHandler returns a page of the author's works, most cited first, with their
+// abstracts. per_page (default 30, at most 200) sets the page size; the cursor of the next
+// page is sent in the X-Next-Cursor header, to be passed back as ?cursor=, and is absent
+// after the last page.
 func (h *APIHandler) FetchAbstractsHandler(w http.ResponseWriter, r *http.Request) {
 	// Path should be registered as /api/authors/{author_id}/works
-	authorID := r.URL.Query().Get("id")
-	if authorID == "" {
-		http.Error(w, "Missing 'id' query parameter", http.StatusBadRequest)
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
 		return
 	}
+	perPage := 30
+	if raw := r.URL.Query().Get("per_page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > openalex.MaxPerPage {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("'per_page' must be an integer between 1 and %d", openalex.MaxPerPage))
+			return
+		}
+		perPage = n
+	}
+	cursor := r.URL.Query().Get("cursor")
+	if cursor == "" {
+		cursor = "*"
+	}
 	// var reqPayload fetchAbstractsRequest
 	// if err := json.NewDecoder(r.Body).Decode(&reqPayload); err != nil {
 	// 	respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -324,11 +588,21 @@ func (h *APIHandler) FetchAbstractsHandler(w http.ResponseWriter, r *http.Reques
 	// 	return
 	// }
 
-	abstracts, err := h.alexClient.FetchAbstractByAuthorID(authorID, 30)
+	abstracts, nextCursor, err := h.alexClient.FetchAbstractsPageByAuthorID(authorID, cursor, perPage)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, openAlexErrorStatus(err), err.Error())
 		return
 	}
+	if nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+	if err := h.mergeSemanticScholarAbstracts(r.Context(), abstracts); err != nil {
+		// The OpenAlex abstracts are still returned; the header tells clients some may be missing.
+		w.Header().Set("X-Semantic-Scholar", "unavailable")
+		if errors.Is(err, semanticscholar.ErrRateLimited) {
+			w.Header().Set("X-Semantic-Scholar", "rate-limited")
+		}
+	}
 
 	// abstracts, err := h.semClient.FetchAbstracts(reqPayload.DOIs)
 	// if err != nil {
@@ -338,3 +612,123 @@ func (h *APIHandler) FetchAbstractsHandler(w http.ResponseWriter, r *http.Reques
 
 	respondWithJSON(w, http.StatusOK, abstracts)
 }
+
+// GetIngestHistoryHandler returns the audit trail of ingestions that targeted an author,
+// most recent first.
+func (h *APIHandler) GetIngestHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	events, err := h.repo.GetIngestHistory(ctx, h.resolveAuthorID(ctx, authorID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, events)
+}
+
+// mergeSemanticScholarAbstracts fills in abstracts from Semantic Scholar for publications
+// OpenAlex has no abstract for. Works are looked up by the paperId the resolver already
+// knows for them, else by DOI, falling back to their arXiv ID, PMID, etc.; the paperIds
+// Semantic Scholar answers with are handed back to the resolver. Failures, such as
+// semanticscholar.ErrRateLimited, are logged and returned, leaving the OpenAlex data
+// unchanged, so callers can serve it as it is.
+func (h *APIHandler) mergeSemanticScholarAbstracts(ctx context.Context, pubs []openalex.Publication) error {
+	var ids []semanticscholar.PaperID
+	indexes := make(map[semanticscholar.PaperID][]int)
+	for i, pub := range pubs {
+		if len(pub.AbstractInvertedIndex) > 0 {
+			continue
+		}
+		id, ok := semanticscholar.PaperIDForWork(pub.Doi, pub.Ids)
+		if paperID, known := h.papers.LocalSemanticScholarID(ctx, resolve.OpenAlexID(pub.ID)); known {
+			id, ok = semanticscholar.PaperID{Kind: semanticscholar.KindPaperID, Value: paperID}, true
+		}
+		if !ok {
+			continue
+		}
+		if _, seen := indexes[id]; !seen {
+			ids = append(ids, id)
+		}
+		indexes[id] = append(indexes[id], i)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	papers, err := h.semClient.FetchAbstracts(ctx, ids)
+	if errors.Is(err, semanticscholar.ErrRateLimited) {
+		log.Printf("WARN: Semantic Scholar is rate limiting abstract lookups; serving OpenAlex abstracts only: %v", err)
+		return err
+	}
+	if err != nil {
+		log.Printf("WARN: Could not fetch abstracts from Semantic Scholar: %v", err)
+		return err
+	}
+	for id, paper := range papers {
+		for _, i := range indexes[id] {
+			pubs[i].Abstract = paper.Abstract
+			h.papers.Remember(ctx, pubs[i].ID, paper.PaperID)
+		}
+	}
+	return nil
+}
+
+// AdminStatsHandler reports process-level runtime statistics, such as the load on the
+// background ingestion job runner.
+func (h *APIHandler) AdminStatsHandler(w http.ResponseWriter, r *http.Request) {
+	storageBackend := h.cfg.StorageBackend
+	if h.cfg.StorageDisabled() {
+		storageBackend = "disabled"
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs":     h.jobs.stats(),
+		"savePool": h.saves.stats(),
+		"storage":  storageBackend,
+	})
+}
+
+// GetWorksMissingAbstractHandler lists works that have a DOI but no abstract yet, so a
+// backfill job can feed them to Semantic Scholar. Results are paged with an opaque cursor:
+// pass the returned nextCursor as ?cursor= to get the next chunk.
+func (h *APIHandler) GetWorksMissingAbstractHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "'limit' must be an integer between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+	cursor := r.URL.Query().Get("cursor")
+	fields, err := fieldsParam(r, domain.DehydratedWork{})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	works, err := h.repo.GetWorksMissingAbstract(ctx, cursor, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var nextCursor string
+	if len(works) == limit {
+		nextCursor = works[len(works)-1].ID
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"works":      fields.project(works),
+		"nextCursor": nextCursor,
+	})
+}
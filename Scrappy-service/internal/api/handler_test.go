@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// GetAuthorWorksHandler builds the OpenAlex request from its validated parameters; dry
+// runs show what it would send.
+func TestGetAuthorWorksHandlerBuildsFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       url.Values // filter, sort and per-page of the OpenAlex request
+	}{
+		{"defaults", "id=A1", http.StatusOK,
+			url.Values{"filter": {"author.id:A1,is_retracted:false"}, "sort": {"publication_date:desc"}, "per-page": {"30"}}},
+		{"author URL", "id=https://openalex.org/A1", http.StatusOK,
+			url.Values{"filter": {"author.id:A1,is_retracted:false"}, "sort": {"publication_date:desc"}, "per-page": {"30"}}},
+		{"newest first", "id=A1&sort=date", http.StatusOK,
+			url.Values{"filter": {"author.id:A1,is_retracted:false"}, "sort": {"publication_date:desc"}, "per-page": {"30"}}},
+		{"most cited", "id=A1&sort=cited", http.StatusOK,
+			url.Values{"filter": {"author.id:A1,is_retracted:false"}, "sort": {"cited_by_count:desc"}, "per-page": {"30"}}},
+		{"retracted included", "id=A1&include_retracted=true", http.StatusOK,
+			url.Values{"filter": {"author.id:A1"}, "sort": {"publication_date:desc"}, "per-page": {"30"}}},
+		{"everything", "id=A1&sort=cited&has_fulltext=true&include_retracted=false", http.StatusOK,
+			url.Values{"filter": {"author.id:A1,has_fulltext:true,is_retracted:false"}, "sort": {"cited_by_count:desc"}, "per-page": {"30"}}},
+		{"unknown sort", "id=A1&sort=cited_by_count:asc", http.StatusBadRequest, nil},
+		{"bad retracted flag", "id=A1&include_retracted=maybe", http.StatusBadRequest, nil},
+		{"filter in the id", "id=A1,is_oa:true", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			target := "/api/fetch-recent-works/?dry=true&" + tt.query
+			newTestHandler(newFakeRepo()).GetAuthorWorksHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.want == nil {
+				return
+			}
+			var body struct {
+				OpenAlexURL string `json:"openAlexUrl"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			requestURL, err := url.Parse(body.OpenAlexURL)
+			if err != nil {
+				t.Fatalf("parsing %q: %v", body.OpenAlexURL, err)
+			}
+			for key := range tt.want {
+				if got := requestURL.Query().Get(key); got != tt.want.Get(key) {
+					t.Errorf("%s = %q, want %q", key, got, tt.want.Get(key))
+				}
+			}
+		})
+	}
+}
+
+// Graph reads are newest first too unless sort=cited asks for the most cited.
+func TestGetAuthorWorksHandlerSortsGraphReads(t *testing.T) {
+	tests := []struct {
+		query           string
+		wantStatus      int
+		wantNewestFirst bool
+	}{
+		{"", http.StatusOK, true},
+		{"&sort=date", http.StatusOK, true},
+		{"&sort=cited", http.StatusOK, false},
+		{"&sort=publication_date:desc", http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		repo := newFakeRepo()
+		repo.newestFirst = !tt.wantNewestFirst
+		rec := httptest.NewRecorder()
+		newTestHandler(repo).GetAuthorWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/fetch-recent-works/?source=graph&id=A1"+tt.query, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%q: status = %d, want %d: %s", tt.query, rec.Code, tt.wantStatus, rec.Body)
+			continue
+		}
+		if tt.wantStatus == http.StatusOK && repo.newestFirst != tt.wantNewestFirst {
+			t.Errorf("%q: GetAuthorWorks newestFirst = %v, want %v", tt.query, repo.newestFirst, tt.wantNewestFirst)
+		}
+	}
+}
+
+// FetchAbstractsHandler fills in missing abstracts from Semantic Scholar; when it throttles
+// or fails, the OpenAlex abstracts are served alone and a header says why.
+func TestFetchAbstractsHandlerDegrades(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int // Semantic Scholar's answer to every attempt
+		wantHeader   string
+		wantAbstract string // of W2, which OpenAlex has none for
+		wantAttempts int
+	}{
+		{"merged", http.StatusOK, "", "from Semantic Scholar", 1},
+		{"rate limited", http.StatusTooManyRequests, "rate-limited", "", 4},
+		{"unavailable", http.StatusBadRequest, "unavailable", "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/graph/v1/paper/batch" {
+					attempts++
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, `[{"paperId": "p2", "abstract": "from Semantic Scholar"}]`)
+					return
+				}
+				fmt.Fprint(w, `{"meta": {"next_cursor": null}, "results": [
+					{"id": "https://openalex.org/W1", "abstract_inverted_index": {"from": [0], "OpenAlex": [1]}},
+					{"id": "https://openalex.org/W2", "doi": "https://doi.org/10.1/w2"}
+				]}`)
+			})
+
+			rec := httptest.NewRecorder()
+			newTestHandler(newFakeRepo()).FetchAbstractsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/fetch-abstracts/?id=A1", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("X-Semantic-Scholar"); got != tt.wantHeader {
+				t.Errorf("X-Semantic-Scholar = %q, want %q", got, tt.wantHeader)
+			}
+			var pubs []struct {
+				ID       string `json:"id"`
+				Abstract string `json:"abstract"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &pubs)
+			if len(pubs) != 2 || pubs[0].Abstract != "from OpenAlex" || pubs[1].Abstract != tt.wantAbstract {
+				t.Errorf("abstracts = %+v, want W1's from OpenAlex and W2's %q", pubs, tt.wantAbstract)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("%d Semantic Scholar requests, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
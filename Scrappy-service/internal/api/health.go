@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Dependency statuses reported by /readyz.
+const (
+	dependencyOK       = "ok"
+	dependencyDown     = "down"
+	dependencyDisabled = "disabled"
+	dependencySkipped  = "skipped"
+)
+
+// dependencyStatus is the outcome of one readiness check.
+type dependencyStatus struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latencyMs,omitempty"`
+	CheckedAt time.Time `json:"checkedAt,omitzero"`
+}
+
+func (s dependencyStatus) down() bool {
+	return s.Status == dependencyDown
+}
+
+// checkDependency runs ping with the readiness timeout and reports how it went.
+func checkDependency(ctx context.Context, timeout time.Duration, ping func(ctx context.Context) error) dependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	started := time.Now()
+	err := ping(ctx)
+	status := dependencyStatus{Status: dependencyOK, LatencyMs: time.Since(started).Milliseconds(), CheckedAt: started.UTC()}
+	if err != nil {
+		status.Status, status.Error = dependencyDown, err.Error()
+	}
+	return status
+}
+
+// openAlexProbe remembers the last OpenAlex check for a while, so frequent probes send
+// OpenAlex at most one request per ttl. Probes arriving while a check runs wait for it.
+type openAlexProbe struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	last dependencyStatus
+}
+
+func (p *openAlexProbe) check(ctx context.Context, timeout time.Duration, ping func(ctx context.Context) error) dependencyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.last.CheckedAt.IsZero() && time.Since(p.last.CheckedAt) < p.ttl {
+		return p.last
+	}
+	p.last = checkDependency(ctx, timeout, ping)
+	return p.last
+}
+
+// ReadyzHandler is the readiness probe. It checks that Neo4j can be reached and, with
+// READYZ_CHECK_OPENALEX, that OpenAlex answers a one-result works request; the OpenAlex
+// result is reused for READYZ_OPENALEX_TTL. The body reports each dependency's status
+// (ok, down, disabled or skipped); the response is 503 when any of them is down. Neo4j is
+// "disabled" when the service runs without storage, which doesn't make it unready.
+func (h *APIHandler) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]dependencyStatus{}
+	if h.cfg.StorageDisabled() {
+		checks["neo4j"] = dependencyStatus{Status: dependencyDisabled}
+	} else {
+		checks["neo4j"] = checkDependency(r.Context(), h.cfg.ReadyzTimeout, h.repo.Ping)
+	}
+	if h.cfg.ReadyzCheckOpenAlex {
+		checks["openalex"] = h.openAlexProbe.check(r.Context(), h.cfg.ReadyzTimeout, h.alexClient.Ping)
+	} else {
+		checks["openalex"] = dependencyStatus{Status: dependencySkipped}
+	}
+
+	code, status := http.StatusOK, "ready"
+	for _, check := range checks {
+		if check.down() {
+			code, status = http.StatusServiceUnavailable, "unavailable"
+		}
+	}
+	respondWithJSON(w, code, map[string]interface{}{
+		"status":       status,
+		"dependencies": checks,
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/metrics"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+var hIndexDriftGauge = metrics.NewGauge("scrappy_authors_hindex_drift",
+	"Fully ingested authors whose h-index computed from the graph differs from OpenAlex's by more than HINDEX_DRIFT_THRESHOLD.")
+
+// GetAuthorHIndexDriftHandler returns the h-index OpenAlex reported for an author when they
+// were last saved, the h-index computed from their works in the graph, and the delta
+// between the two. A large positive delta usually means the ingest is incomplete.
+func (h *APIHandler) GetAuthorHIndexDriftHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	drift, err := h.repo.GetHIndexDrift(ctx, h.resolveAuthorID(ctx, authorID))
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Author is not in the graph")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, drift)
+}
+
+// WatchHIndexDrift refreshes the h-index drift metric every HINDEX_DRIFT_INTERVAL until ctx
+// is cancelled. It returns at once when the interval is 0.
+func (h *APIHandler) WatchHIndexDrift(ctx context.Context) {
+	if h.cfg.HIndexDriftInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(h.cfg.HIndexDriftInterval)
+	defer ticker.Stop()
+	for {
+		countCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		drifted, err := h.repo.CountHIndexDrift(countCtx, h.cfg.HIndexDriftThreshold)
+		cancel()
+		if err != nil {
+			log.Printf("WARN: Could not count h-index drift: %v", err)
+		} else {
+			hIndexDriftGauge.Set(int64(drifted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestGetAuthorHIndexDriftHandler(t *testing.T) {
+	stored, delta := 12, 3
+	repo := newFakeRepo()
+	repo.hIndexDrift = map[string]*storage.HIndexDrift{
+		"https://openalex.org/A1": {AuthorID: "https://openalex.org/A1", OpenAlexHIndex: &stored, ComputedHIndex: 9,
+			Delta: &delta, WorksInGraph: 40, OpenAlexWorks: 52},
+		"https://openalex.org/A2": {AuthorID: "https://openalex.org/A2", ComputedHIndex: 4, WorksInGraph: 7},
+	}
+	repo.aliases["https://openalex.org/A3"] = "https://openalex.org/A1"
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       string
+	}{
+		{
+			name:       "drift",
+			query:      "id=A1",
+			wantStatus: http.StatusOK,
+			want:       `{"id":"https://openalex.org/A1","openAlexHIndex":12,"computedHIndex":9,"delta":3,"worksInGraph":40,"openAlexWorks":52}`,
+		},
+		{
+			name:       "URL id",
+			query:      "id=https://openalex.org/A1",
+			wantStatus: http.StatusOK,
+			want:       `{"id":"https://openalex.org/A1","openAlexHIndex":12,"computedHIndex":9,"delta":3,"worksInGraph":40,"openAlexWorks":52}`,
+		},
+		{
+			name:       "merged author",
+			query:      "id=A3",
+			wantStatus: http.StatusOK,
+			want:       `{"id":"https://openalex.org/A1","openAlexHIndex":12,"computedHIndex":9,"delta":3,"worksInGraph":40,"openAlexWorks":52}`,
+		},
+		{
+			name:       "no stored h-index",
+			query:      "id=A2",
+			wantStatus: http.StatusOK,
+			want:       `{"id":"https://openalex.org/A2","openAlexHIndex":null,"computedHIndex":4,"delta":null,"worksInGraph":7,"openAlexWorks":0}`,
+		},
+		{name: "not in the graph", query: "id=A404", wantStatus: http.StatusNotFound},
+		{name: "missing id", wantStatus: http.StatusBadRequest},
+		{name: "work id", query: "id=W1", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.GetAuthorHIndexDriftHandler(rec, httptest.NewRequest(http.MethodGet, "/api/authors/hindex/drift?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.want == "" {
+				return
+			}
+			var got, want any
+			json.Unmarshal(rec.Body.Bytes(), &got)
+			json.Unmarshal([]byte(tt.want), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("body = %s, want %s", rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchHIndexDrift(t *testing.T) {
+	repo := newFakeRepo()
+	// The third count fails, which must leave the last count in the metric.
+	repo.driftCounts = []int{2, 5}
+	h := newTestHandler(repo, func(cfg *config.Config) {
+		cfg.HIndexDriftInterval = 5 * time.Millisecond
+		cfg.HIndexDriftThreshold = 3
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.WatchHIndexDrift(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(repo.countedDrift()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WatchHIndexDrift didn't return after its context was cancelled")
+	}
+
+	thresholds := repo.countedDrift()
+	if len(thresholds) < 4 {
+		t.Fatalf("drift was counted %d times, want it refreshed every interval", len(thresholds))
+	}
+	for _, threshold := range thresholds {
+		if threshold != 3 {
+			t.Errorf("counted with threshold %d, want HINDEX_DRIFT_THRESHOLD (3)", threshold)
+		}
+	}
+	if got := hIndexDriftGauge.Value(); got != 5 {
+		t.Errorf("gauge = %d, want the last successful count (5)", got)
+	}
+}
+
+func TestWatchHIndexDriftDisabled(t *testing.T) {
+	repo := newFakeRepo()
+	h := newTestHandler(repo) // HIndexDriftInterval 0
+
+	done := make(chan struct{})
+	go func() {
+		h.WatchHIndexDrift(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WatchHIndexDrift kept running with a zero interval")
+	}
+	if counted := repo.countedDrift(); len(counted) != 0 {
+		t.Errorf("drift was counted %d times, want none", len(counted))
+	}
+}
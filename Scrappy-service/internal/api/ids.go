@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+)
+
+// authorIDParam reads the required 'id' query parameter and checks that it is an OpenAlex
+// author ID, before any network call is made. On failure it answers 400 and returns false.
+func authorIDParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	raw := r.URL.Query().Get("id")
+	if raw == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing 'id' query parameter")
+		return "", false
+	}
+	id, err := openalex.ValidateID(raw, 'A')
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return id, true
+}
+
+// openAlexErrorStatus maps an error from the OpenAlex client to our response status:
+// 404 when OpenAlex doesn't know the entity, 500 otherwise.
+func openAlexErrorStatus(err error) int {
+	if errors.Is(err, openalex.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+)
+
+// malformedIDs are rejected by every by-ID endpoint before OpenAlex is asked.
+var malformedIDs = []string{
+	"",
+	"banana",
+	"a5023896336",
+	"A",
+	"A12b",
+	"A1' OR '1'='1",
+	"A1&filter=x",
+	"A1/../W2",
+	"https://openalex.org/A1?select=id",
+	"https://openalex.org/a1",
+	"http://evil.example/A1",
+	"A١٢",
+}
+
+func TestByIDEndpointsRejectMalformedIDs(t *testing.T) {
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("OpenAlex was asked for %s", r.URL)
+	})
+	h := newTestHandler(newFakeRepo())
+	endpoints := []struct {
+		name      string
+		handler   http.HandlerFunc
+		wrongKind string // A well-formed ID of another entity kind.
+	}{
+		{"fetch-author-by-id", h.FetchAndSaveWorksByAuthorHandler, "W1"},
+		{"collaboration-map", h.GetCollaborationMapHandler, "I1"},
+		{"works/ngrams", h.GetWorkNgramsHandler, "A1"},
+		{"works/neighborhood", h.GetCitationNeighborhoodHandler, "S1"},
+		{"venues/summary", h.GetVenueSummaryHandler, "https://openalex.org/W1"},
+	}
+	for _, endpoint := range endpoints {
+		for _, id := range append(malformedIDs, endpoint.wrongKind) {
+			t.Run(fmt.Sprintf("%s/%q", endpoint.name, id), func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				endpoint.handler(rec, httptest.NewRequest(http.MethodGet, "/?id="+url.QueryEscape(id), nil))
+				if rec.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400: %s", rec.Code, rec.Body)
+				}
+				var body map[string]string
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["error"] == "" {
+					t.Errorf("body = %s, want the error envelope", rec.Body)
+				}
+			})
+		}
+	}
+}
+
+func TestByIDEndpointsMapOpenAlexStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		upstream   int
+		wantPath   string
+		wantStatus int
+	}{
+		{"unknown author", "A999", http.StatusNotFound, "/authors/A999", http.StatusNotFound},
+		{"unknown author in URL form", "https://openalex.org/A999", http.StatusNotFound, "/authors/A999", http.StatusNotFound},
+		{"OpenAlex failing", "A999", http.StatusBadGateway, "/authors/A999", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested := ""
+			fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+				requested = r.URL.Path
+				w.WriteHeader(tt.upstream)
+				w.Write([]byte(`<html>Not Found</html>`))
+			})
+			h := newTestHandler(newFakeRepo())
+
+			rec := httptest.NewRecorder()
+			h.FetchAndSaveWorksByAuthorHandler(rec, httptest.NewRequest(http.MethodGet, "/?id="+url.QueryEscape(tt.id), nil))
+			if requested != tt.wantPath {
+				t.Errorf("OpenAlex was asked for %q, want %q", requested, tt.wantPath)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want the JSON envelope", ct)
+			}
+		})
+	}
+}
+
+func TestOpenAlexErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&openalex.APIError{StatusCode: http.StatusNotFound}, http.StatusNotFound},
+		{fmt.Errorf("fetching: %w", &openalex.APIError{StatusCode: http.StatusNotFound}), http.StatusNotFound},
+		{&openalex.APIError{StatusCode: http.StatusTooManyRequests}, http.StatusInternalServerError},
+		{fmt.Errorf("network down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := openAlexErrorStatus(tt.err); got != tt.want {
+			t.Errorf("openAlexErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorIDParam(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   string
+		wantOK bool
+	}{
+		{"id=A5023896336", "A5023896336", true},
+		{"id=" + url.QueryEscape("https://openalex.org/A5023896336"), "A5023896336", true},
+		{"id=+A1+", "A1", true},
+		{"", "", false},
+		{"id=W1", "", false},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		got, ok := authorIDParam(rec, httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("authorIDParam(%q) = %q, %v; want %q, %v", tt.query, got, ok, tt.want, tt.wantOK)
+		}
+		if !ok && (rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "error")) {
+			t.Errorf("authorIDParam(%q) answered %d %s, want 400", tt.query, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// workFilter decides which fetched works are left out of an ingestion, and how much of
+// each of the others is saved.
+type workFilter struct {
+	skipParatext  bool
+	skipRetracted bool
+	// skipExisting leaves out works that are already in the graph. Off unless requested.
+	skipExisting bool
+	// onlyFulltext leaves out works whose full text OpenAlex hasn't indexed, for text mining.
+	onlyFulltext bool
+	// save is passed to SaveWork; lean saves leave out topics, venues, etc., and force
+	// rewrites works that haven't changed since they were saved.
+	save storage.SaveOptions
+	// resolveReferences is how many of the stub works cited by the ingested works get
+	// their title and year fetched from OpenAlex. 0 leaves the stubs as they are.
+	resolveReferences int
+}
+
+// workFilterFor starts from the configured defaults and applies the request's
+// skip_paratext / skip_retracted / skip_existing overrides, has_fulltext, its include
+// list (e.g. include=topics,venue; everything by default), force and resolve_references,
+// capped by MAX_RESOLVED_REFERENCES.
+func (h *APIHandler) workFilterFor(r *http.Request) (workFilter, error) {
+	return h.workFilterFromQuery(r.URL.Query())
+}
+
+// workFilterFromQuery is workFilterFor for query parameters that don't come from a
+// request, e.g. a persisted job's filter.
+func (h *APIHandler) workFilterFromQuery(q url.Values) (workFilter, error) {
+	f := workFilter{
+		skipParatext:  h.cfg.SkipParatextWorks,
+		skipRetracted: h.cfg.SkipRetractedWorks,
+	}
+	save, err := storage.ParseSaveOptions(q.Get("include"))
+	if err != nil {
+		return workFilter{}, fmt.Errorf("invalid 'include' query parameter: %w", err)
+	}
+	f.save = save
+	for param, target := range map[string]*bool{
+		"skip_paratext":  &f.skipParatext,
+		"skip_retracted": &f.skipRetracted,
+		"skip_existing":  &f.skipExisting,
+		"has_fulltext":   &f.onlyFulltext,
+		"force":          &f.save.Force,
+	} {
+		raw := q.Get(param)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return workFilter{}, fmt.Errorf("invalid '%s' query parameter: %q", param, raw)
+		}
+		*target = v
+	}
+	if raw := q.Get("resolve_references"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return workFilter{}, fmt.Errorf("invalid 'resolve_references' query parameter: %q", raw)
+		}
+		f.resolveReferences = min(n, h.cfg.MaxResolvedReferences)
+	}
+	return f, nil
+}
+
+// FilterWorks applies the configured ingest filter (SKIP_PARATEXT_WORKS and
+// SKIP_RETRACTED_WORKS) to works ingested outside a request, such as by the CLI loader. It
+// returns the works to save and how many were skipped.
+func FilterWorks(cfg *config.Config, works []domain.Work) ([]domain.Work, int) {
+	return workFilter{skipParatext: cfg.SkipParatextWorks, skipRetracted: cfg.SkipRetractedWorks}.apply(works)
+}
+
+// encode returns the filter as the query parameters workFilterFromQuery reads back. Every
+// setting is spelled out, so the result doesn't depend on the configured defaults.
+func (f workFilter) encode() string {
+	return url.Values{
+		"skip_paratext":      {strconv.FormatBool(f.skipParatext)},
+		"skip_retracted":     {strconv.FormatBool(f.skipRetracted)},
+		"skip_existing":      {strconv.FormatBool(f.skipExisting)},
+		"has_fulltext":       {strconv.FormatBool(f.onlyFulltext)},
+		"include":            {f.save.String()},
+		"force":              {strconv.FormatBool(f.save.Force)},
+		"resolve_references": {strconv.Itoa(f.resolveReferences)},
+	}.Encode()
+}
+
+// skips reports whether the work should not be ingested.
+func (f workFilter) skips(work domain.Work) bool {
+	return (f.skipParatext && work.IsParatext) || (f.skipRetracted && work.IsRetracted) ||
+		(f.onlyFulltext && !work.HasFulltext)
+}
+
+// apply returns the works that pass the filter and how many were skipped.
+func (f workFilter) apply(works []domain.Work) ([]domain.Work, int) {
+	if !f.skipParatext && !f.skipRetracted && !f.onlyFulltext {
+		return works, 0
+	}
+	kept := make([]domain.Work, 0, len(works))
+	for _, work := range works {
+		if !f.skips(work) {
+			kept = append(kept, work)
+		}
+	}
+	return kept, len(works) - len(kept)
+}
+
+// dropExisting returns the works that are not saved in full yet when skip_existing is set,
+// and how many were dropped. Stubs of cited works are kept, so they get saved in full. A
+// work whose lookup fails is kept, so errors never lose data.
+func (f workFilter) dropExisting(ctx context.Context, repo storage.Repository, works []domain.Work) ([]domain.Work, int) {
+	if !f.skipExisting {
+		return works, 0
+	}
+	kept := make([]domain.Work, 0, len(works))
+	for _, work := range works {
+		exists, err := repo.WorkExists(ctx, work.ID)
+		if err != nil {
+			log.Printf("WARN: Could not check whether work %s exists: %v", work.ID, err)
+		}
+		if !exists {
+			kept = append(kept, work)
+		}
+	}
+	return kept, len(works) - len(kept)
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestWorkFilterSaveOptions(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    storage.SaveOptions
+		wantErr bool
+	}{
+		{"", storage.FullSave, false},
+		{"include=topics,venue", storage.SaveOptions{IncludeTopics: true, IncludeVenue: true}, false},
+		{"include=none", storage.SaveOptions{}, false},
+		{"include=none&force=true", storage.SaveOptions{Force: true}, false},
+		{"force=1", storage.SaveOptions{IncludeTopics: true, IncludeVenue: true, IncludeGrants: true, IncludeCitations: true, Force: true}, false},
+		{"include=concepts", storage.SaveOptions{}, true},
+		{"force=maybe", storage.SaveOptions{}, true},
+	}
+	h := newTestHandler(newFakeRepo())
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		f, err := h.workFilterFromQuery(q)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, want error %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if err == nil && f.save != tt.want {
+			t.Errorf("%q: save options = %+v, want %+v", tt.query, f.save, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// institutionFetches deduplicates concurrent OpenAlex fetches of the same institution:
+// callers asking for an id that is already being fetched wait for that fetch and share its
+// result, whether they belong to the same enrichment pass or to overlapping ones.
+type institutionFetches struct {
+	mu    sync.Mutex
+	calls map[string]*institutionFetch
+}
+
+type institutionFetch struct {
+	done        chan struct{}
+	institution domain.Institution
+	err         error
+}
+
+func newInstitutionFetches() *institutionFetches {
+	return &institutionFetches{calls: make(map[string]*institutionFetch)}
+}
+
+// do returns fetch(id), running it only once for concurrent callers with the same id.
+func (f *institutionFetches) do(id string, fetch func() (domain.Institution, error)) (domain.Institution, error) {
+	f.mu.Lock()
+	if call, ok := f.calls[id]; ok {
+		f.mu.Unlock()
+		<-call.done
+		return call.institution, call.err
+	}
+	call := &institutionFetch{done: make(chan struct{})}
+	f.calls[id] = call
+	f.mu.Unlock()
+
+	call.institution, call.err = fetch()
+	close(call.done)
+
+	f.mu.Lock()
+	delete(f.calls, id)
+	f.mu.Unlock()
+	return call.institution, call.err
+}
+
+// EnrichInstitutionsHandler starts a background pass (POST ?limit=N, 1-1000, default 100)
+// that upgrades institution stubs, created from work authorships with little more than an
+// id and a name, into full nodes with OpenAlex's metadata. At most
+// INSTITUTION_ENRICH_CONCURRENCY institutions are fetched at once, all within the client's
+// rate limit. It answers 202 with the job id; the job's saved/failed counters count
+// institutions.
+func (h *APIHandler) EnrichInstitutionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "'limit' must be an integer between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	ids, err := h.repo.GetInstitutionStubs(ctx, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(ids) == 0 {
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"message": "No institution stubs to enrich.", "institutions": 0})
+		return
+	}
+
+	job := h.startIngestJob(ctx, "institutions", "", requestedBy(r))
+	h.jobs.run(job, func(ctx context.Context) error {
+		queue := make(chan string)
+		var wg sync.WaitGroup
+		for i := 0; i < h.cfg.EnrichConcurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for id := range queue {
+					institution, err := h.institutionFetches.do(id, func() (domain.Institution, error) {
+						return h.alexClient.FetchInstitutionByID(ctx, id)
+					})
+					if err == nil {
+						err = h.repo.SaveInstitution(ctx, institution)
+					}
+					job.entitySaved(id, institution.DisplayName, err)
+					if err != nil {
+						log.Printf("BACKGROUND ERROR: Could not enrich institution %s: %v", id, err)
+					}
+				}
+			}()
+		}
+	feed:
+		for _, id := range ids {
+			select {
+			case queue <- id:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(queue)
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("institution enrichment stopped: %w", err)
+		}
+		log.Printf("Institution enrichment %s finished for %d institutions.", job.event.ID, len(ids))
+		return nil
+	})
+
+	respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+		"message":      "Request accepted. Institutions are being enriched in the background.",
+		"jobId":        job.event.ID,
+		"institutions": len(ids),
+	})
+}
+
+// GetInstitutionSummaryHandler counts the works and authors the graph holds for an
+// institution. Query parameters: id (OpenAlex institution ID or ROR ID, required) and
+// include_children=true to roll up the institutions below it (its departments, say) through
+// CHILD_OF edges, which are saved when institutions are enriched.
+func (h *APIHandler) GetInstitutionSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	includeChildren := false
+	if raw := r.URL.Query().Get("include_children"); raw != "" {
+		var err error
+		if includeChildren, err = strconv.ParseBool(raw); err != nil {
+			respondWithError(w, http.StatusBadRequest, "'include_children' must be true or false")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	instID, err := h.institutionIDParam(ctx, r.URL.Query().Get("id"))
+	if err != nil {
+		respondWithError(w, institutionParamStatus(err), err.Error())
+		return
+	}
+	summary, err := h.repo.GetInstitutionWorksRolledUp(ctx, canonicalOpenAlexID(instID), includeChildren)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Institution is not in the graph")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// institutionIDParam resolves an institution given as an OpenAlex ID or a ROR ID (bare or
+// as a ror.org URL) to its bare OpenAlex ID. RORs are looked up in the graph first and
+// then in OpenAlex; one neither knows returns an error matching openalex.ErrNotFound.
+func (h *APIHandler) institutionIDParam(ctx context.Context, raw string) (string, error) {
+	if id, err := openalex.ValidateID(raw, 'I'); err == nil {
+		return id, nil
+	}
+	ror, err := openalex.NormalizeROR(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q is neither an OpenAlex institution ID nor a ROR ID", openalex.ErrInvalidID, raw)
+	}
+	if institution, err := h.repo.GetInstitutionByROR(ctx, openalex.RORURL(ror)); err == nil {
+		return openalex.ValidateID(institution.ID, 'I')
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return "", err
+	}
+	institution, err := h.alexClient.FetchInstitutionByROR(ctx, ror)
+	if err != nil {
+		return "", err
+	}
+	return openalex.ValidateID(institution.ID, 'I')
+}
+
+// institutionParamStatus is the HTTP status for an error of institutionIDParam.
+func institutionParamStatus(err error) int {
+	if errors.Is(err, openalex.ErrInvalidID) {
+		return http.StatusBadRequest
+	}
+	return openAlexErrorStatus(err)
+}
+
+// FetchInstitutionByRORHandler fetches an institution from OpenAlex by its ROR ID
+// (?ror=03yrm5c26 or ?ror=https://ror.org/03yrm5c26), saves it like the institution
+// enrichment does, and returns it. RORs OpenAlex doesn't know answer 404.
+func (h *APIHandler) FetchInstitutionByRORHandler(w http.ResponseWriter, r *http.Request) {
+	ror, err := openalex.NormalizeROR(r.URL.Query().Get("ror"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	institution, err := h.alexClient.FetchInstitutionByROR(ctx, ror)
+	if errors.Is(err, openalex.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("No institution with ROR %s in OpenAlex", ror))
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to fetch institution from OpenAlex: %v", err))
+		return
+	}
+	if err := h.repo.SaveInstitution(ctx, institution); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, institution)
+}
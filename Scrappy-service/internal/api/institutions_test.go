@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestGetInstitutionSummaryHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantRequest string // what the repository was asked for; empty if nothing
+	}{
+		{name: "institution alone", query: "id=I1", wantStatus: http.StatusOK, wantRequest: "https://openalex.org/I1 children=false"},
+		{name: "rolled up", query: "id=I1&include_children=true", wantStatus: http.StatusOK, wantRequest: "https://openalex.org/I1 children=true"},
+		{name: "explicitly alone", query: "id=https://openalex.org/I1&include_children=0", wantStatus: http.StatusOK, wantRequest: "https://openalex.org/I1 children=false"},
+		{name: "not in the graph", query: "id=I404", wantStatus: http.StatusNotFound, wantRequest: "https://openalex.org/I404 children=false"},
+		{name: "bad flag", query: "id=I1&include_children=yes", wantStatus: http.StatusBadRequest},
+		{name: "missing id", query: "include_children=true", wantStatus: http.StatusBadRequest},
+		{name: "author id", query: "id=A1", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.rollups = map[string]storage.InstitutionRollup{
+				"https://openalex.org/I1": {ID: "https://openalex.org/I1", DisplayName: "University", Children: []string{"https://openalex.org/I2"},
+					WorksCount: 4, AuthorsCount: 3, TotalCitations: 18},
+			}
+			rec := httptest.NewRecorder()
+			newTestHandler(repo).GetInstitutionSummaryHandler(rec, httptest.NewRequest(http.MethodGet, "/api/institutions/summary?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := strings.Join(repo.rolledUp, "; "); got != tt.wantRequest {
+				t.Errorf("repository asked for %q, want %q", got, tt.wantRequest)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var body storage.InstitutionRollup
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.DisplayName != "University" || body.WorksCount != 4 || body.AuthorsCount != 3 || body.TotalCitations != 18 ||
+				body.IncludeChildren != strings.Contains(tt.wantRequest, "true") {
+				t.Errorf("body = %s, want the rollup of I1", rec.Body)
+			}
+		})
+	}
+}
+
+// serveInstitutionByROR answers OpenAlex with institution I7 for ROR 03yrm5c26, 404 for
+// other institutions and ten works for works requests. It records the requests it gets.
+func serveInstitutionByROR(requests *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.Path+" "+r.URL.Query().Get("filter"))
+		switch r.URL.Path {
+		case "/institutions/ror:03yrm5c26":
+			fmt.Fprint(w, `{"id": "https://openalex.org/I7", "display_name": "University", "ror": "https://ror.org/03yrm5c26"}`)
+		case "/works":
+			fmt.Fprint(w, `{"meta": {"count": 10}, "results": []}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestFetchInstitutionByRORHandler(t *testing.T) {
+	var requests []string
+	fakeOpenAlex(t, serveInstitutionByROR(&requests))
+
+	tests := []struct {
+		name         string
+		ror          string
+		wantStatus   int
+		wantRequests int
+	}{
+		{"bare ROR", "03yrm5c26", http.StatusOK, 1},
+		{"ROR URL", "https://ror.org/03yrm5c26", http.StatusOK, 1},
+		{"unknown ROR", "https://ror.org/00f54p054", http.StatusNotFound, 1},
+		{"invalid ROR", "I7", http.StatusBadRequest, 0},
+		{"missing ROR", "", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			repo := newFakeRepo()
+			rec := httptest.NewRecorder()
+			target := "/api/fetch-institution-by-ror?ror=" + url.QueryEscape(tt.ror)
+			newTestHandler(repo).FetchInstitutionByRORHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if len(requests) != tt.wantRequests {
+				t.Errorf("OpenAlex requests = %v, want %d", requests, tt.wantRequests)
+			}
+			if rec.Code != http.StatusOK {
+				if len(repo.savedInstitutions) != 0 {
+					t.Errorf("saved %+v", repo.savedInstitutions)
+				}
+				return
+			}
+			var body domain.Institution
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.ID != "https://openalex.org/I7" || body.Ror != "https://ror.org/03yrm5c26" {
+				t.Errorf("body = %s, want institution I7", rec.Body)
+			}
+			if len(repo.savedInstitutions) != 1 || repo.savedInstitutions[0].ID != "https://openalex.org/I7" {
+				t.Errorf("saved %+v, want institution I7", repo.savedInstitutions)
+			}
+		})
+	}
+}
+
+// Institution-scoped endpoints take a ROR wherever they take an institution ID: the graph
+// is asked first, and OpenAlex only for RORs the graph doesn't know.
+func TestInstitutionParamAcceptsROR(t *testing.T) {
+	var requests []string
+	fakeOpenAlex(t, serveInstitutionByROR(&requests))
+
+	tests := []struct {
+		name         string
+		id           string
+		inGraph      bool // whether the graph knows ROR 03yrm5c26, as institution I1
+		wantStatus   int
+		wantID       string   // the institution the endpoints ask about
+		wantRequests []string // institution requests to OpenAlex
+	}{
+		{name: "OpenAlex ID", id: "I1", wantStatus: http.StatusOK, wantID: "I1"},
+		{name: "ROR in the graph", id: "03yrm5c26", inGraph: true, wantStatus: http.StatusOK, wantID: "I1"},
+		{name: "ROR URL in the graph", id: "https://ror.org/03yrm5c26", inGraph: true, wantStatus: http.StatusOK, wantID: "I1"},
+		{name: "ROR from OpenAlex", id: "03yrm5c26", wantStatus: http.StatusOK, wantID: "I7",
+			wantRequests: []string{"/institutions/ror:03yrm5c26 "}},
+		{name: "ROR URL from OpenAlex", id: "ror.org/03yrm5c26", wantStatus: http.StatusOK, wantID: "I7",
+			wantRequests: []string{"/institutions/ror:03yrm5c26 "}},
+		{name: "unknown ROR", id: "00f54p054", wantStatus: http.StatusNotFound,
+			wantRequests: []string{"/institutions/ror:00f54p054 "}},
+		{name: "neither", id: "nope", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.rollups = map[string]storage.InstitutionRollup{
+				"https://openalex.org/I1": {ID: "https://openalex.org/I1"},
+				"https://openalex.org/I7": {ID: "https://openalex.org/I7"},
+			}
+			if tt.inGraph {
+				repo.institutions = map[string]domain.Institution{
+					"https://ror.org/03yrm5c26": {ID: "https://openalex.org/I1", Ror: "https://ror.org/03yrm5c26"},
+				}
+			}
+			h := newTestHandler(repo)
+			param := url.QueryEscape(tt.id)
+
+			requests = nil
+			rec := httptest.NewRecorder()
+			h.GetInstitutionSummaryHandler(rec, httptest.NewRequest(http.MethodGet, "/api/institutions/summary?id="+param, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("summary status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if fmt.Sprint(requests) != fmt.Sprint(tt.wantRequests) {
+				t.Errorf("summary asked OpenAlex for %q, want %q", requests, tt.wantRequests)
+			}
+			if tt.wantID != "" {
+				if want := "https://openalex.org/" + tt.wantID + " children=false"; strings.Join(repo.rolledUp, "; ") != want {
+					t.Errorf("repository asked for %q, want %q", repo.rolledUp, want)
+				}
+			} else if len(repo.rolledUp) != 0 {
+				t.Errorf("repository asked for %q", repo.rolledUp)
+			}
+
+			requests = nil
+			rec = httptest.NewRecorder()
+			h.GetIngestEstimateHandler(rec, httptest.NewRequest(http.MethodGet, "/api/ingest-estimate?institution_id="+param, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("estimate status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantID != "" {
+				wantRequests := append(append([]string(nil), tt.wantRequests...), "/works institutions.id:"+tt.wantID)
+				if fmt.Sprint(requests) != fmt.Sprint(wantRequests) {
+					t.Errorf("estimate asked OpenAlex for %q, want %q", requests, wantRequests)
+				}
+			}
+		})
+	}
+}
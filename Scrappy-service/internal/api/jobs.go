@@ -0,0 +1,448 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/metrics"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+	"github.com/Cloudforge2/scrappy/internal/tenant"
+)
+
+var (
+	runningJobsGauge = metrics.NewGauge("scrappy_background_jobs_running", "Background ingestion jobs currently running.")
+	queuedJobsGauge  = metrics.NewGauge("scrappy_background_jobs_queued", "Background ingestion jobs waiting for a free slot.")
+)
+
+// errShuttingDown is the cause of the cancellation of the jobs still running when the
+// drain timeout of a shutdown runs out.
+var errShuttingDown = errors.New("service shutting down")
+
+// jobRunner bounds the background ingestion jobs of the whole process: every job gets an
+// overall deadline, and a semaphore caps how many run at the same time.
+type jobRunner struct {
+	timeout time.Duration
+	slots   chan struct{}
+
+	// base is the parent of every job's context; drain cancels it with errShuttingDown.
+	base   context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup // Jobs submitted and not finished yet, for drain.
+
+	mu     sync.Mutex
+	active map[string]bool // IDs of the jobs submitted and not finished yet
+}
+
+func newJobRunner(maxJobs int, timeout time.Duration) *jobRunner {
+	base, cancel := context.WithCancelCause(context.Background())
+	return &jobRunner{timeout: timeout, slots: make(chan struct{}, maxJobs), base: base, cancel: cancel, active: make(map[string]bool)}
+}
+
+// drain waits up to timeout for the submitted jobs to finish. The jobs still queued or
+// running then are cancelled and recorded as interrupted; drain waits for them to write
+// their audit events, at most finalizeTimeout more, so the repository can be closed
+// afterwards. It reports whether every job finished on its own.
+func (jr *jobRunner) drain(timeout, finalizeTimeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		jr.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+	}
+
+	jr.mu.Lock()
+	log.Printf("WARN: Drain timeout of %s ran out; cancelling %d background job(s)", timeout, len(jr.active))
+	jr.mu.Unlock()
+	jr.cancel(errShuttingDown)
+	select {
+	case <-done:
+	case <-time.After(finalizeTimeout):
+		log.Printf("WARN: Background jobs did not stop within %s of being cancelled", finalizeTimeout)
+	}
+	return false
+}
+
+// DrainJobs is called on shutdown, before the repository is closed: it gives the
+// background jobs up to timeout to finish, then cancels the rest, which are recorded as
+// interrupted and can be resumed like any unfinished job.
+func (h *APIHandler) DrainJobs(timeout time.Duration) {
+	if h.jobs.drain(timeout, 15*time.Second) {
+		log.Println("All background jobs finished")
+	}
+}
+
+// isActive reports whether the job with the given ID is queued or running in this process.
+func (jr *jobRunner) isActive(id string) bool {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	return jr.active[id]
+}
+
+// run executes fn in a new goroutine once a slot is free. The context handed to fn expires
+// after the configured timeout, counted from when the job was submitted. The job's audit
+// event is finalized with fn's result, or as timed out / failed if the deadline hit or fn panicked.
+func (jr *jobRunner) run(job *ingestJob, fn func(ctx context.Context) error) {
+	jr.mu.Lock()
+	jr.active[job.event.ID] = true
+	jr.mu.Unlock()
+	jr.wg.Add(1)
+
+	go func() {
+		defer jr.wg.Done()
+		defer func() {
+			jr.mu.Lock()
+			delete(jr.active, job.event.ID)
+			jr.mu.Unlock()
+		}()
+		// IMPORTANT: background jobs get a new, independent context. The request's context
+		// is cancelled as soon as the handler returns a response. Only the tenant carries
+		// over; the runner's base context only ends when a shutdown stops draining.
+		ctx, cancel := context.WithTimeout(tenant.WithTenant(jr.base, job.tenant), jr.timeout)
+		defer cancel()
+		defer job.finishOnPanic(false)
+
+		queuedJobsGauge.Inc()
+		select {
+		case jr.slots <- struct{}{}:
+			queuedJobsGauge.Dec()
+		case <-ctx.Done():
+			queuedJobsGauge.Dec()
+			job.finish(ctx, fmt.Errorf("no background slot became free: %w", ctx.Err()))
+			return
+		}
+		runningJobsGauge.Inc()
+		defer func() {
+			runningJobsGauge.Dec()
+			<-jr.slots
+		}()
+
+		job.finish(ctx, fn(ctx))
+	}()
+}
+
+// stats reports the runner's current load.
+func (jr *jobRunner) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"runningJobs":       runningJobsGauge.Value(),
+		"queuedJobs":        queuedJobsGauge.Value(),
+		"maxBackgroundJobs": cap(jr.slots),
+		"jobTimeout":        jr.timeout.String(),
+	}
+}
+
+// ingestJob tracks a single ingestion (sync or background) and keeps its
+// IngestEvent audit node up to date.
+type ingestJob struct {
+	repo   storage.Repository
+	tenant string
+
+	mu       sync.Mutex
+	event    storage.IngestEvent
+	finished bool
+
+	// committed is the event as of the last page a resumable job saved completely; it is
+	// what a resumed job picks up from.
+	committed storage.IngestEvent
+}
+
+// startIngestJob records the start of an ingestion and returns the job used to report
+// its progress. Failing to write the audit record never blocks the ingestion itself.
+func (h *APIHandler) startIngestJob(ctx context.Context, kind, targetID, requestedBy string) *ingestJob {
+	job := &ingestJob{
+		repo:   h.repo,
+		tenant: tenant.FromContext(ctx),
+		event: storage.IngestEvent{
+			ID:          newJobID(),
+			Kind:        kind,
+			TargetID:    targetID,
+			RequestedBy: requestedBy,
+			StartedAt:   time.Now().UTC(),
+			Status:      storage.IngestStatusRunning,
+		},
+	}
+	if err := h.repo.RecordIngestEvent(ctx, job.event); err != nil {
+		log.Printf("WARN: Could not record start of ingest event %s: %v", job.event.ID, err)
+	}
+	return job
+}
+
+// reopenIngestJob continues a stored, unfinished job under its original ID: the event is
+// marked running again and keeps the counters and cursor it was stored with. The job
+// gets its own copy of the cursor, so the caller's event doesn't change under it.
+func (h *APIHandler) reopenIngestJob(ctx context.Context, event storage.IngestEvent) *ingestJob {
+	if event.Resume != nil {
+		resume := *event.Resume
+		event.Resume = &resume
+	}
+	event.Status = storage.IngestStatusRunning
+	event.FinishedAt = time.Time{}
+	event.Error = ""
+	event.Tenant = ""
+	job := &ingestJob{repo: h.repo, tenant: tenant.FromContext(ctx), event: event}
+	job.committed = job.snapshot()
+	if err := h.repo.RecordIngestEvent(ctx, job.event); err != nil {
+		log.Printf("WARN: Could not record resumption of ingest event %s: %v", event.ID, err)
+	}
+	return job
+}
+
+// makeResumable turns the job into a paged ingestion that can be resumed after a restart:
+// the filter (as query parameters) and the cursor of the next page are stored with the
+// event, starting from the first page.
+func (j *ingestJob) makeResumable(ctx context.Context, filter string, fetchedAt time.Time) {
+	j.mu.Lock()
+	j.event.Resume = &storage.IngestCursor{Filter: filter, Cursor: "*", FetchedAt: fetchedAt}
+	j.committed = j.snapshot()
+	event := j.committed
+	j.mu.Unlock()
+
+	if err := j.repo.RecordIngestEvent(ctx, event); err != nil {
+		log.Printf("WARN: Could not record cursor of ingest event %s: %v", event.ID, err)
+	}
+}
+
+// pageCommitted records that every work of the current page was processed, moving the
+// stored cursor to next (empty after the last page). The counters are written in the same
+// update, so a resumed job neither skips nor counts a page twice.
+func (j *ingestJob) pageCommitted(ctx context.Context, next string) {
+	j.mu.Lock()
+	j.event.Resume.Cursor = next
+	j.event.Resume.Pages++
+	j.committed = j.snapshot()
+	event := j.committed
+	j.mu.Unlock()
+
+	if err := j.repo.RecordIngestEvent(ctx, event); err != nil {
+		// The stored state stays at the previous page, which a resume simply redoes.
+		log.Printf("WARN: Could not record progress of ingest event %s: %v", event.ID, err)
+	}
+}
+
+// snapshot copies the event, including its slices. j.mu must be held.
+func (j *ingestJob) snapshot() storage.IngestEvent {
+	event := j.event
+	event.Failures = append([]storage.WorkFailure(nil), j.event.Failures...)
+	event.DecodeWarningSamples = append([]string(nil), j.event.DecodeWarningSamples...)
+	if j.event.Resume != nil {
+		resume := *j.event.Resume
+		event.Resume = &resume
+	}
+	return event
+}
+
+// worksSkipped adds n works the filter left out.
+func (j *ingestJob) worksSkipped(n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.event.WorksSkipped += n
+}
+
+// decodeWarnings records works OpenAlex returned that didn't decode and were left out,
+// keeping the messages of the first storage.MaxDecodeWarningSamples.
+func (j *ingestJob) decodeWarnings(warnings openalex.DecodeWarnings) {
+	if len(warnings) == 0 {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.event.DecodeWarnings += len(warnings)
+	room := storage.MaxDecodeWarningSamples - len(j.event.DecodeWarningSamples)
+	if room > 0 {
+		j.event.DecodeWarningSamples = append(j.event.DecodeWarningSamples, warnings.Samples(room)...)
+	}
+}
+
+// retarget points the event at a different node, e.g. the canonical author after an
+// OpenAlex redirect. The change is written when the job finishes.
+func (j *ingestJob) retarget(targetID string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.event.TargetID = targetID
+}
+
+// workSaved increments the saved or failed counter depending on err, and for saved works
+// the counter of the save's outcome. Failures are also recorded, up to
+// storage.MaxRecordedFailures of them.
+func (j *ingestJob) workSaved(work domain.Work, outcome storage.SaveOutcome, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.countSave(work.ID, work.Title, err)
+	if err != nil {
+		return
+	}
+	switch outcome {
+	case storage.SaveCreated:
+		j.event.WorksCreated++
+	case storage.SaveUpdated:
+		j.event.WorksUpdated++
+	case storage.SaveUnchanged:
+		j.event.WorksUnchanged++
+	}
+}
+
+// entitySaved is workSaved for jobs that save other entities; the event's counters then
+// count those entities.
+func (j *ingestJob) entitySaved(id, name string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.countSave(id, name, err)
+}
+
+// countSave increments the saved or failed counter. j.mu must be held.
+func (j *ingestJob) countSave(id, name string, err error) {
+	if err != nil {
+		j.event.WorksFailed++
+		if len(j.event.Failures) < storage.MaxRecordedFailures {
+			j.event.Failures = append(j.event.Failures, storage.WorkFailure{WorkID: id, Title: name, Error: err.Error()})
+		}
+		return
+	}
+	j.event.WorksSaved++
+}
+
+// failures returns a copy of the failures recorded so far.
+func (j *ingestJob) failures() []storage.WorkFailure {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]storage.WorkFailure(nil), j.event.Failures...)
+}
+
+// finish finalizes the event. A non-nil err or a cancelled ctx marks the job as failed,
+// as timed out when ctx's deadline expired, or as interrupted when a shutdown cancelled it.
+// Only the first call has any effect.
+// A resumable job that stops early reports the counters of the pages it completed, since
+// a resume redoes the page it stopped in.
+func (j *ingestJob) finish(ctx context.Context, err error) {
+	j.mu.Lock()
+	if j.finished {
+		j.mu.Unlock()
+		return
+	}
+	j.finished = true
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	j.event.FinishedAt = time.Now().UTC()
+	j.event.Status = storage.IngestStatusCompleted
+	if err != nil {
+		j.event.Status = storage.IngestStatusFailed
+		if errors.Is(err, context.DeadlineExceeded) {
+			j.event.Status = storage.IngestStatusTimedOut
+		}
+		if errors.Is(context.Cause(ctx), errShuttingDown) {
+			j.event.Status = storage.IngestStatusInterrupted
+			err = fmt.Errorf("%v: %w", errShuttingDown, err)
+		}
+		j.event.Error = err.Error()
+		if j.event.Resume != nil {
+			j.event.WorksSaved = j.committed.WorksSaved
+			j.event.WorksFailed = j.committed.WorksFailed
+			j.event.WorksSkipped = j.committed.WorksSkipped
+			j.event.WorksCreated = j.committed.WorksCreated
+			j.event.WorksUpdated = j.committed.WorksUpdated
+			j.event.WorksUnchanged = j.committed.WorksUnchanged
+			j.event.DecodeWarnings = j.committed.DecodeWarnings
+			j.event.DecodeWarningSamples = j.committed.DecodeWarningSamples
+			j.event.Failures = j.committed.Failures
+		}
+	}
+	event := j.event
+	j.mu.Unlock()
+
+	// The job's own context may already be cancelled, so the final write gets its own.
+	writeCtx, cancel := context.WithTimeout(tenant.WithTenant(context.Background(), j.tenant), 10*time.Second)
+	defer cancel()
+	if err := j.repo.RecordIngestEvent(writeCtx, event); err != nil {
+		log.Printf("WARN: Could not finalize ingest event %s: %v", event.ID, err)
+	}
+}
+
+// finishOnPanic must be deferred by whoever runs the job. It marks the event as failed
+// if the job panicked; when repanic is set the panic is propagated after finalizing.
+func (j *ingestJob) finishOnPanic(repanic bool) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	log.Printf("ERROR: Ingest job %s panicked: %v", j.event.ID, p)
+	j.finish(context.Background(), fmt.Errorf("panic: %v", p))
+	if repanic {
+		panic(p)
+	}
+}
+
+// newJobID returns a random identifier for an ingest job.
+func newJobID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestedBy identifies the caller that triggered an ingestion: the API key WithTenant
+// authenticated the request with. Only deployments without API keys trust the X-User
+// header, which anyone can set.
+func requestedBy(r *http.Request) string {
+	if actor, ok := r.Context().Value(actorKey{}).(string); ok {
+		return actor
+	}
+	if user := strings.TrimSpace(r.Header.Get("X-User")); user != "" {
+		return user
+	}
+	return "anonymous"
+}
+
+// sameOpenAlexID reports whether two OpenAlex IDs, in bare or URL form, name the same entity.
+func sameOpenAlexID(a, b string) bool {
+	return strings.EqualFold(canonicalOpenAlexID(a), canonicalOpenAlexID(b))
+}
+
+// resolveAuthorID canonicalizes an author ID from a request and follows any recorded
+// OpenAlex merges, so reads of an old ID land on the canonical author.
+func (h *APIHandler) resolveAuthorID(ctx context.Context, id string) string {
+	id = canonicalOpenAlexID(id)
+	resolved, err := h.repo.ResolveAuthorID(ctx, id)
+	if err != nil {
+		log.Printf("WARN: Could not resolve merges for author %s: %v", id, err)
+		return id
+	}
+	return resolved
+}
+
+// resolveWorkID is resolveAuthorID for work IDs.
+func (h *APIHandler) resolveWorkID(ctx context.Context, id string) string {
+	id = canonicalOpenAlexID(id)
+	resolved, err := h.repo.ResolveWorkID(ctx, id)
+	if err != nil {
+		log.Printf("WARN: Could not resolve merges for work %s: %v", id, err)
+		return id
+	}
+	return resolved
+}
+
+// canonicalOpenAlexID expands a bare OpenAlex ID (e.g. A5023896336) into the full URL
+// form that is used as the node id in the graph.
+func canonicalOpenAlexID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" || strings.HasPrefix(id, "https://openalex.org/") {
+		return id
+	}
+	return "https://openalex.org/" + id
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func newTestJob(repo storage.Repository) *ingestJob {
+	return &ingestJob{repo: repo, event: storage.IngestEvent{ID: newJobID(), Status: storage.IngestStatusRunning}}
+}
+
+func TestJobRunnerFinalizesJobs(t *testing.T) {
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		fn         func(ctx context.Context) error
+		wantStatus string
+		wantError  string
+	}{
+		{
+			name:       "completed",
+			timeout:    time.Second,
+			fn:         func(ctx context.Context) error { return nil },
+			wantStatus: storage.IngestStatusCompleted,
+		},
+		{
+			name:       "failed",
+			timeout:    time.Second,
+			fn:         func(ctx context.Context) error { return context.Canceled },
+			wantStatus: storage.IngestStatusFailed,
+			wantError:  "context canceled",
+		},
+		{
+			name:    "deadline",
+			timeout: 20 * time.Millisecond,
+			fn: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			wantStatus: storage.IngestStatusTimedOut,
+			wantError:  "deadline exceeded",
+		},
+		{
+			name:    "ignored deadline",
+			timeout: 20 * time.Millisecond,
+			fn: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil // The job didn't notice, but the runner does.
+			},
+			wantStatus: storage.IngestStatusTimedOut,
+		},
+		{
+			name:       "panic",
+			timeout:    time.Second,
+			fn:         func(ctx context.Context) error { panic("boom") },
+			wantStatus: storage.IngestStatusFailed,
+			wantError:  "panic: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			jr := newJobRunner(2, tt.timeout)
+			job := newTestJob(repo)
+			jr.run(job, tt.fn)
+			jr.wg.Wait()
+
+			event := repo.event(job.event.ID)
+			if event.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", event.Status, tt.wantStatus)
+			}
+			if !strings.Contains(event.Error, tt.wantError) {
+				t.Errorf("error = %q, want it to contain %q", event.Error, tt.wantError)
+			}
+			if event.FinishedAt.IsZero() {
+				t.Error("finishedAt is not set")
+			}
+			if jr.isActive(job.event.ID) {
+				t.Error("finished job is still active")
+			}
+		})
+	}
+}
+
+func TestJobRunnerCapsConcurrentJobs(t *testing.T) {
+	const maxJobs = 2
+	repo := newFakeRepo()
+	jr := newJobRunner(maxJobs, 5*time.Second)
+
+	var running, peak, starts atomic.Int32
+	release := make(chan struct{})
+	full := make(chan struct{})
+	jobs := make([]*ingestJob, 6)
+	for i := range jobs {
+		jobs[i] = newTestJob(repo)
+		jr.run(jobs[i], func(ctx context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			if starts.Add(1) == maxJobs {
+				close(full)
+			}
+			<-release
+			return nil
+		})
+	}
+	<-full
+	time.Sleep(20 * time.Millisecond) // Give queued jobs a chance to (wrongly) start.
+	close(release)
+	jr.wg.Wait()
+
+	if p := peak.Load(); p > maxJobs {
+		t.Errorf("%d jobs ran at once, want at most %d", p, maxJobs)
+	}
+	for _, job := range jobs {
+		if status := repo.event(job.event.ID).Status; status != storage.IngestStatusCompleted {
+			t.Errorf("job %s: status = %q, want completed", job.event.ID, status)
+		}
+	}
+}
+
+func TestJobRunnerTimesOutQueuedJobs(t *testing.T) {
+	repo := newFakeRepo()
+	jr := newJobRunner(1, 50*time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+	blocker := newTestJob(repo)
+	jr.run(blocker, func(ctx context.Context) error {
+		select {
+		case <-release:
+		case <-time.After(time.Second):
+		}
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	ran := false
+	queued := newTestJob(repo)
+	jr.run(queued, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	deadline := time.Now().Add(time.Second)
+	for jr.isActive(queued.event.ID) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if ran {
+		t.Error("queued job ran although no slot became free before its deadline")
+	}
+	event := repo.event(queued.event.ID)
+	if event.Status != storage.IngestStatusTimedOut {
+		t.Errorf("status = %q, want %q", event.Status, storage.IngestStatusTimedOut)
+	}
+	if !strings.Contains(event.Error, "no background slot") {
+		t.Errorf("error = %q, want it to mention the missing slot", event.Error)
+	}
+}
+
+func TestIngestJobFinishIsIdempotent(t *testing.T) {
+	repo := newFakeRepo()
+	job := newTestJob(repo)
+	job.finish(context.Background(), nil)
+	job.finish(context.Background(), context.DeadlineExceeded)
+
+	if status := repo.event(job.event.ID).Status; status != storage.IngestStatusCompleted {
+		t.Errorf("status = %q, want the first result (completed) to stick", status)
+	}
+}
+
+func TestIngestJobCountsSaveOutcomes(t *testing.T) {
+	job := newTestJob(newFakeRepo())
+	saves := []struct {
+		outcome storage.SaveOutcome
+		err     error
+	}{
+		{storage.SaveCreated, nil},
+		{storage.SaveUpdated, nil},
+		{storage.SaveUnchanged, nil},
+		{storage.SaveUnchanged, nil},
+		{"", errors.New("write failed")},
+	}
+	for i, save := range saves {
+		job.workSaved(domain.Work{ID: fmt.Sprintf("W%d", i)}, save.outcome, save.err)
+	}
+
+	event := job.event
+	got := [5]int{event.WorksSaved, event.WorksFailed, event.WorksCreated, event.WorksUpdated, event.WorksUnchanged}
+	if want := [5]int{4, 1, 1, 1, 2}; got != want {
+		t.Errorf("saved, failed, created, updated, unchanged = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// serveMergedAuthor answers author requests like OpenAlex does for merged profiles: the
+// record of canonical comes back for any ID. Works requests get an empty page.
+func serveMergedAuthor(canonical string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/works" {
+			w.Write([]byte(`{"meta": {"count": 0, "next_cursor": null}, "results": []}`))
+			return
+		}
+		w.Write([]byte(`{"id": "https://openalex.org/` + canonical + `", "display_name": "Ada", "works_count": 0}`))
+	}
+}
+
+func TestFetchAuthorFollowsMerges(t *testing.T) {
+	fakeOpenAlex(t, serveMergedAuthor("A2"))
+	tests := []struct {
+		name          string
+		id            string
+		blocked       map[string]string
+		wantStatus    int
+		wantCanonical string
+		wantAliases   map[string]string
+	}{
+		{"redirect", "A1", nil, http.StatusOK, "https://openalex.org/A2",
+			map[string]string{"https://openalex.org/A1": "https://openalex.org/A2"}},
+		{"redirect by URL", "https://openalex.org/A1", nil, http.StatusOK, "https://openalex.org/A2",
+			map[string]string{"https://openalex.org/A1": "https://openalex.org/A2"}},
+		{"canonical ID", "A2", nil, http.StatusOK, "", map[string]string{}},
+		{"blocked canonical", "A1", map[string]string{"https://openalex.org/A2": "spam"}, http.StatusForbidden, "",
+			map[string]string{"https://openalex.org/A1": "https://openalex.org/A2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			for id, reason := range tt.blocked {
+				repo.blocked[id] = reason
+			}
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.FetchAndSaveWorksByAuthorHandler(rec, httptest.NewRequest(http.MethodGet, "/api/fetch-author-by-id?id="+tt.id, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !reflect.DeepEqual(repo.aliases, tt.wantAliases) {
+				t.Errorf("aliases = %v, want %v", repo.aliases, tt.wantAliases)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if len(repo.savedAuthors) > 0 {
+					t.Errorf("saved %v although the canonical author is blocked", repo.savedAuthors)
+				}
+				return
+			}
+			var body struct {
+				CanonicalID string `json:"canonicalId"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.CanonicalID != tt.wantCanonical {
+				t.Errorf("canonicalId = %q, want %q", body.CanonicalID, tt.wantCanonical)
+			}
+			if len(repo.savedAuthors) != 1 || repo.savedAuthors[0].ID != "https://openalex.org/A2" {
+				t.Errorf("saved authors %v, want only the canonical one", repo.savedAuthors)
+			}
+		})
+	}
+}
+
+// Reads of an old ID are answered with the canonical author's data.
+func TestReadsFollowAliases(t *testing.T) {
+	repo := newFakeRepo()
+	repo.aliases["https://openalex.org/A1"] = "https://openalex.org/A2"
+	repo.aliases["https://openalex.org/A0"] = "https://openalex.org/A1"
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"A0", "https://openalex.org/A2"},
+		{"A1", "https://openalex.org/A2"},
+		{"https://openalex.org/A1", "https://openalex.org/A2"},
+		{"A2", "https://openalex.org/A2"},
+		{"A3", "https://openalex.org/A3"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.GetAuthorWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/fetch-recent-works/?source=graph&id="+tt.id, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", tt.id, rec.Code, rec.Body)
+		}
+		if repo.authorWorksID != tt.want {
+			t.Errorf("%s: read works of %q, want %q", tt.id, repo.authorWorksID, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+)
+
+// maxCachedNgramWorks bounds the ngram cache; it is emptied when full.
+const maxCachedNgramWorks = 1000
+
+// ngramCache keeps recently fetched ngrams in memory for ttl.
+type ngramCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]ngramEntry
+}
+
+type ngramEntry struct {
+	ngrams    []openalex.Ngram
+	fetchedAt time.Time
+}
+
+func newNgramCache(ttl time.Duration) *ngramCache {
+	return &ngramCache{ttl: ttl, entries: make(map[string]ngramEntry)}
+}
+
+func (c *ngramCache) get(workID string) ([]openalex.Ngram, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[workID]
+	if !ok || time.Since(e.fetchedAt) > c.ttl {
+		return nil, false
+	}
+	return e.ngrams, true
+}
+
+func (c *ngramCache) put(workID string, ngrams []openalex.Ngram) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedNgramWorks {
+		c.entries = make(map[string]ngramEntry)
+	}
+	c.entries[workID] = ngramEntry{ngrams: ngrams, fetchedAt: time.Now()}
+}
+
+// GetWorkNgramsHandler proxies OpenAlex's ngrams for a work (GET /api/works/ngrams?id=W123).
+// Responses are cached in memory for NGRAM_CACHE_TTL.
+func (h *APIHandler) GetWorkNgramsHandler(w http.ResponseWriter, r *http.Request) {
+	workID, err := openalex.ValidateID(r.URL.Query().Get("id"), 'W')
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ngrams, cached := h.ngrams.get(workID)
+	if !cached {
+		ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+		defer cancel()
+
+		ngrams, err = h.alexClient.FetchWorkNgrams(ctx, workID)
+		if err != nil {
+			respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch ngrams from OpenAlex: %v", err))
+			return
+		}
+		h.ngrams.put(workID, ngrams)
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"id": canonicalOpenAlexID(workID), "ngrams": ngrams, "cached": cached})
+}
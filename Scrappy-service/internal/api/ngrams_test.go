@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestGetWorkNgramsHandlerCaches(t *testing.T) {
+	requests := 0
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/works/W404/ngrams" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"ngrams": [{"ngram": "graph", "ngram_tokens": 1, "ngram_count": 3}]}`))
+	})
+	h := newTestHandler(newFakeRepo())
+
+	steps := []struct {
+		id           string
+		wantStatus   int
+		wantCached   bool
+		wantRequests int
+	}{
+		{"W1", http.StatusOK, false, 1},
+		{"https://openalex.org/W1", http.StatusOK, true, 1},
+		{"W2", http.StatusOK, false, 2},
+		{"W404", http.StatusNotFound, false, 3},
+		{"W404", http.StatusNotFound, false, 4}, // Failures aren't cached.
+	}
+	for _, step := range steps {
+		rec := httptest.NewRecorder()
+		h.GetWorkNgramsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/works/ngrams?id="+url.QueryEscape(step.id), nil))
+		if rec.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", step.id, rec.Code, step.wantStatus, rec.Body)
+		}
+		if requests != step.wantRequests {
+			t.Errorf("%s: %d OpenAlex requests so far, want %d", step.id, requests, step.wantRequests)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var body struct {
+			ID     string `json:"id"`
+			Cached bool   `json:"cached"`
+			Ngrams []struct {
+				Ngram string `json:"ngram"`
+				Count int    `json:"ngram_count"`
+			} `json:"ngrams"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if body.Cached != step.wantCached || len(body.Ngrams) != 1 || body.Ngrams[0].Count != 3 {
+			t.Errorf("%s: response = %+v, want the ngrams with cached=%v", step.id, body, step.wantCached)
+		}
+	}
+}
+
+func TestGetWorkNgramsHandlerCacheExpires(t *testing.T) {
+	requests := 0
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"ngrams": []}`))
+	})
+	h := newTestHandler(newFakeRepo(), func(cfg *config.Config) { cfg.NgramCacheTTL = time.Millisecond })
+
+	for i := 0; i < 2; i++ {
+		h.GetWorkNgramsHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/works/ngrams?id=W1", nil))
+		time.Sleep(5 * time.Millisecond)
+	}
+	if requests != 2 {
+		t.Errorf("%d OpenAlex requests, want the expired entry fetched again", requests)
+	}
+}
+
+func TestGetAuthorWorksHandlerHasFulltext(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantFilter string // For OpenAlex requests.
+		wantLocal  bool   // For graph reads, what GetAuthorWorks is asked for.
+	}{
+		{"remote", "id=A1&dry=true", "author.id:A1,is_retracted:false", false},
+		{"remote full text", "id=A1&has_fulltext=true&dry=true", "author.id:A1,has_fulltext:true,is_retracted:false", false},
+		{"local", "id=A1&source=graph", "", false},
+		{"local full text", "id=A1&source=graph&has_fulltext=true", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.authorWorks = []domain.DehydratedWork{{ID: "W1"}}
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.GetAuthorWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/fetch-recent-works/?"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			if tt.wantFilter == "" {
+				if repo.onlyFulltext != tt.wantLocal {
+					t.Errorf("GetAuthorWorks onlyFulltext = %v, want %v", repo.onlyFulltext, tt.wantLocal)
+				}
+				return
+			}
+			var body struct {
+				OpenAlexURL string `json:"openAlexUrl"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			requestURL, err := url.Parse(body.OpenAlexURL)
+			if err != nil {
+				t.Fatalf("parsing %q: %v", body.OpenAlexURL, err)
+			}
+			if got := requestURL.Query().Get("filter"); got != tt.wantFilter {
+				t.Errorf("filter = %q, want %q", got, tt.wantFilter)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+)
+
+// pageParams reads the 1-based page and per_page query parameters, defaulting to page 1
+// and defaultPerPage. per_page above OpenAlex's maximum is clamped to it, and pages beyond
+// what OpenAlex can page to are rejected.
+func pageParams(r *http.Request, defaultPerPage int) (page, perPage int, err error) {
+	page, perPage = 1, defaultPerPage
+	if raw := r.URL.Query().Get("page"); raw != "" {
+		if page, err = strconv.Atoi(raw); err != nil || page < 1 {
+			return 0, 0, fmt.Errorf("'page' must be a positive integer")
+		}
+	}
+	if raw := r.URL.Query().Get("per_page"); raw != "" {
+		if perPage, err = strconv.Atoi(raw); err != nil || perPage < 1 {
+			return 0, 0, fmt.Errorf("'per_page' must be a positive integer")
+		}
+	}
+	// OpenAlex rejects larger pages, so asking for more just returns the largest.
+	perPage = openalex.ClampPerPage(perPage)
+	if page*perPage > openalex.MaxPagedResults {
+		return 0, 0, fmt.Errorf("only the first %d results can be paged through", openalex.MaxPagedResults)
+	}
+	return page, perPage, nil
+}
+
+// writePageHeaders reports pagination metadata for a page of total results.
+func writePageHeaders(w http.ResponseWriter, page, perPage, total int) {
+	w.Header().Set("X-Page", strconv.Itoa(page))
+	w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("X-Total-Pages", strconv.Itoa((total+perPage-1)/perPage))
+}
+
+// isCountryCode reports whether s looks like an ISO 3166-1 alpha-2 country code.
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// errQueryCapReached stops a query ingest once it has saved as many works as allowed.
+var errQueryCapReached = errors.New("query ingest cap reached")
+
+// IngestQueryHandler ingests every work matching an OpenAlex filter string, e.g.
+// {"filter": "publication_year:2023,topics.id:T10017"}, as a background job. The works are
+// paged through with a cursor and saved on the save pool, keyed by their first author, so
+// works of different authors are saved in parallel; the job stops after max_works works
+// (capped by MAX_QUERY_INGEST_WORKS). The work filter query parameters (skip_paratext,
+// skip_retracted, skip_existing) apply as for the other ingest endpoints. Filters naming a
+// blocked ID are rejected with 403, and blocked works are skipped. It answers 202 with the
+// job id, which can be followed in the ingest history.
+func (h *APIHandler) IngestQueryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	var req dto.QueryIngestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	filterString, err := openalex.ValidateFilter(req.Filter, h.cfg.FilterAllowlist)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	maxWorks := h.cfg.MaxQueryIngestWorks
+	if req.MaxWorks < 0 {
+		respondWithError(w, http.StatusBadRequest, "'max_works' must be positive")
+		return
+	}
+	if req.MaxWorks > 0 && req.MaxWorks < maxWorks {
+		maxWorks = req.MaxWorks
+	}
+	filter, err := h.workFilterFor(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun(r) {
+		respondWithOpenAlexURL(w, openalex.WorksPageURL(filterString, "*"))
+		return
+	}
+	for _, id := range filterIDs(filterString) {
+		if h.rejectIfBlocked(r.Context(), w, id) {
+			return
+		}
+	}
+
+	job := h.startIngestJob(r.Context(), "query", filterString, requestedBy(r))
+	h.jobs.run(job, func(ctx context.Context) error {
+		var (
+			inFlight      sync.WaitGroup
+			saved, failed atomic.Int64
+		)
+		submitted, skipped := 0, 0
+		warnings, err := h.alexClient.StreamWorksByFilter(filterString, func(work domain.Work) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if filter.skips(work) {
+				skipped++
+				return nil
+			}
+			if blocked, _, err := h.repo.IsBlocked(ctx, work.ID); err == nil && blocked {
+				log.Printf("Not ingesting blocked work %s", work.ID)
+				skipped++
+				return nil
+			}
+			if _, existing := filter.dropExisting(ctx, h.repo, []domain.Work{work}); existing > 0 {
+				skipped++
+				return nil
+			}
+			// Enough saves are under way to reach the cap; wait to see whether they succeed.
+			if submitted-int(failed.Load()) >= maxWorks {
+				inFlight.Wait()
+				if int(saved.Load()) >= maxWorks {
+					return errQueryCapReached
+				}
+			}
+
+			workCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			inFlight.Add(1)
+			var outcome storage.SaveOutcome
+			err := h.saves.submit(workCtx, saveKey(work), func(ctx context.Context) (err error) {
+				outcome, err = h.repo.SaveWork(ctx, work, filter.save)
+				return err
+			}, func(err error) {
+				defer inFlight.Done()
+				cancel()
+				job.workSaved(work, outcome, err)
+				if err != nil {
+					failed.Add(1)
+					log.Printf("BACKGROUND ERROR: Could not save work %s: %v", work.Title, err)
+					return
+				}
+				saved.Add(1)
+			})
+			if err != nil {
+				inFlight.Done()
+				cancel()
+				return err
+			}
+			submitted++
+			return nil
+		})
+		inFlight.Wait()
+		logDecodeWarnings(fmt.Sprintf("query %q", filterString), warnings)
+		job.decodeWarnings(warnings)
+		if errors.Is(err, errQueryCapReached) {
+			log.Printf("Query ingest %s stopped at the cap of %d works.", job.event.ID, maxWorks)
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("query ingest of %q: %w", filterString, err)
+		}
+		log.Printf("Background query ingest %s finished: %d works saved, %d skipped.", job.event.ID, saved.Load(), skipped)
+		return nil
+	})
+
+	respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+		"message":  "Request accepted. Matching works are being ingested in the background.",
+		"jobId":    job.event.ID,
+		"filter":   filterString,
+		"maxWorks": maxWorks,
+	})
+}
+
+// filterIDs returns the author, work, institution and source IDs an OpenAlex filter string
+// selects, e.g. A5023888391 of authorships.author.id:A5023888391|A5041794289. Negated
+// values (!A5023888391) exclude works, so they are left out.
+func filterIDs(filter string) []string {
+	var ids []string
+	for _, part := range strings.Split(filter, ",") {
+		_, value, _ := strings.Cut(part, ":")
+		for _, v := range strings.Split(value, "|") {
+			if id, err := openalex.ValidateID(v, 'A', 'W', 'I', 'S'); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
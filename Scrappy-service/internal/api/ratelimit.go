@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/ratelimit"
+)
+
+// RateLimit is a rate limiting policy: Rate requests per second with bursts of up to Burst.
+// With PerIP set each client IP gets its own token bucket; otherwise all clients share one.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+	PerIP bool
+}
+
+// Wrap limits a handler according to the policy, answering 429 with a Retry-After header
+// once the caller's bucket is empty. Each wrapped route keeps its own buckets, so routes
+// sharing a policy are still limited independently.
+func (p RateLimit) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	if p.Rate <= 0 {
+		return next
+	}
+	l := &routeLimiter{policy: p, buckets: make(map[string]*ratelimit.Bucket)}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := l.bucketFor(r).Allow()
+		if !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+			respondWithError(w, http.StatusTooManyRequests, fmt.Sprintf("Rate limit exceeded, retry in %d seconds", retryAfter))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// routeLimiter holds the token buckets of a single route.
+type routeLimiter struct {
+	policy RateLimit
+
+	mu      sync.Mutex
+	buckets map[string]*ratelimit.Bucket
+}
+
+func (l *routeLimiter) bucketFor(r *http.Request) *ratelimit.Bucket {
+	key := ""
+	if l.policy.PerIP {
+		key = clientIP(r)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[key]
+	if !ok {
+		// Drop idle buckets now and then so per-IP limiting doesn't grow without bound.
+		if len(l.buckets) >= 10000 {
+			for k, old := range l.buckets {
+				if old.Idle(time.Minute) {
+					delete(l.buckets, k)
+				}
+			}
+		}
+		b = ratelimit.NewBucket(l.policy.Rate, l.policy.Burst)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// clientIP returns the remote address of the request without its port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
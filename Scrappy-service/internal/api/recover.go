@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// Recover turns a panic in next into a 500 JSON error, so a bug in one handler (say, a nil
+// location on a work) fails that request instead of crashing the server. The panic is
+// logged with its stack trace and the request's id: the caller's X-Request-ID or, without
+// one, a generated id. The id is echoed in the X-Request-ID response header so a client
+// report can be matched with the log.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+		if id == "" {
+			id = newJobID()
+		}
+		w.Header().Set("X-Request-ID", id)
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// net/http uses ErrAbortHandler to abort a response on purpose; it is not a bug.
+			if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(p)
+			}
+			log.Printf("PANIC: %s %s (request %s): %v\n%s", r.Method, r.URL.Path, id, p, debug.Stack())
+			// If the handler had already started its response this only logs a superfluous
+			// WriteHeader; the client sees a truncated response.
+			respondWithError(w, http.StatusInternalServerError, "Internal server error (request "+id+")")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
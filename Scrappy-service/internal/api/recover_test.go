@@ -0,0 +1,121 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name       string
+		requestID  string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+		wantPanic  string // in the log; empty if nothing may be logged
+	}{
+		{
+			name:      "nil dereference",
+			requestID: "req-42",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				var work domain.Work
+				w.Write([]byte(work.PrimaryLocation.Source.DisplayName))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantPanic:  "nil pointer dereference",
+		},
+		{
+			name:       "panic with a value",
+			handler:    func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			wantStatus: http.StatusInternalServerError,
+			wantPanic:  "boom",
+		},
+		{
+			name:       "panic with an error",
+			requestID:  "  req-7 ",
+			handler:    func(w http.ResponseWriter, r *http.Request) { panic(errors.New("broken invariant")) },
+			wantStatus: http.StatusInternalServerError,
+			wantPanic:  "broken invariant",
+		},
+		{
+			name:      "no panic",
+			requestID: "req-1",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				respondWithJSON(w, http.StatusCreated, map[string]bool{"ok": true})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"ok":true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged bytes.Buffer
+			original := log.Writer()
+			log.SetOutput(&logged)
+			t.Cleanup(func() { log.SetOutput(original) })
+
+			req := httptest.NewRequest(http.MethodGet, "/api/works/W1", nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			Recover(tt.handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			id := rec.Header().Get("X-Request-ID")
+			if want := strings.TrimSpace(tt.requestID); id == "" || want != "" && id != want {
+				t.Errorf("X-Request-ID = %q, want %q or a generated id", id, want)
+			}
+
+			if tt.wantPanic == "" {
+				if body := strings.TrimSpace(rec.Body.String()); body != tt.wantBody {
+					t.Errorf("body = %s, want %s", rec.Body, tt.wantBody)
+				}
+				if logged.Len() != 0 {
+					t.Errorf("logged %q without a panic", logged.String())
+				}
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type = %q, want JSON", ct)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %s is not JSON: %v", rec.Body, err)
+			}
+			if msg := body["error"]; !strings.Contains(msg, id) || strings.Contains(msg, tt.wantPanic) {
+				t.Errorf("error = %q, want one naming request %s without the panic", msg, id)
+			}
+			line := logged.String()
+			for _, want := range []string{"PANIC", "/api/works/W1", "request " + id, tt.wantPanic, "goroutine"} {
+				if !strings.Contains(line, want) {
+					t.Errorf("log lacks %q:\n%s", want, line)
+				}
+			}
+		})
+	}
+}
+
+// A handler aborting its response with http.ErrAbortHandler does so on purpose: the panic
+// goes on to net/http, which closes the connection without logging.
+func TestRecoverRepanicsAbort(t *testing.T) {
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	t.Error("the abort didn't propagate")
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+)
+
+// resolveCitedStubs gives up to limit of the untitled stub works cited by citingIDs their
+// title and year, fetched from OpenAlex openalex.MaxIDsPerRequest at a time, so the works
+// they cite read as more than bare ids. It returns the number of stubs updated; stubs
+// OpenAlex doesn't know stay untitled, and those it merged into another work are recorded
+// as merged.
+func (h *APIHandler) resolveCitedStubs(ctx context.Context, citingIDs []string, limit int) (int, error) {
+	if limit <= 0 || len(citingIDs) == 0 {
+		return 0, nil
+	}
+	stubIDs, err := h.repo.GetCitedStubs(ctx, citingIDs, limit)
+	if err != nil {
+		return 0, err
+	}
+	resolved := 0
+	for start := 0; start < len(stubIDs); start += openalex.MaxIDsPerRequest {
+		end := min(start+openalex.MaxIDsPerRequest, len(stubIDs))
+		works, err := h.alexClient.FetchWorksByIDs(ctx, stubIDs[start:end])
+		if err != nil {
+			return resolved, fmt.Errorf("failed to fetch cited works: %w", err)
+		}
+		stubs := make([]domain.DehydratedWork, 0, len(works))
+		found := make(map[string]bool, len(works))
+		for _, work := range works {
+			found[work.ID] = true
+			stubs = append(stubs, domain.DehydratedWork{ID: work.ID, Title: work.Title, PublicationYear: work.PublicationYear})
+		}
+		for _, id := range stubIDs[start:end] {
+			if !found[id] {
+				h.recordWorkRedirect(ctx, id)
+			}
+		}
+		n, err := h.repo.SetStubMetadata(ctx, stubs)
+		resolved += n
+		if err != nil {
+			return resolved, err
+		}
+	}
+	return resolved, nil
+}
+
+// recordWorkRedirect looks up a work a batch fetch didn't return on its own: the single-work
+// endpoint redirects IDs OpenAlex merged into another work, and such merges are recorded so
+// the old ID resolves to the canonical work.
+func (h *APIHandler) recordWorkRedirect(ctx context.Context, id string) {
+	work, err := h.alexClient.FetchWorkIdentifiers(ctx, strings.TrimPrefix(id, "https://openalex.org/"))
+	if err != nil {
+		if !errors.Is(err, openalex.ErrNotFound) {
+			log.Printf("WARN: Could not look up work %s: %v", id, err)
+		}
+		return
+	}
+	if work.ID == "" || sameOpenAlexID(id, work.ID) {
+		return
+	}
+	log.Printf("REDIRECT: OpenAlex work %s has been merged into %s", id, work.ID)
+	if err := h.repo.RecordWorkMerge(ctx, canonicalOpenAlexID(id), canonicalOpenAlexID(work.ID)); err != nil {
+		log.Printf("WARN: %v", err)
+	}
+}
+
+// resolveReferencesOf is resolveCitedStubs for the works of an ingest, logging instead of
+// failing it: unreadable references don't make the ingested works any less saved.
+func (h *APIHandler) resolveReferencesOf(ctx context.Context, works []domain.Work, limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	ids := make([]string, 0, len(works))
+	for _, work := range works {
+		ids = append(ids, work.ID)
+	}
+	resolved, err := h.resolveCitedStubs(ctx, ids, limit)
+	if err != nil {
+		log.Printf("WARN: Could not resolve the titles of cited works: %v", err)
+	}
+	return resolved
+}
@@ -0,0 +1,598 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// newTestHandler returns a handler on repo with the configuration's defaults for what the
+// tests don't care about. configure, if given, adjusts the configuration first.
+func newTestHandler(repo storage.Repository, configure ...func(*config.Config)) *APIHandler {
+	cfg := &config.Config{
+		StorageBackend:       config.StorageNeo4j,
+		MaxBackgroundJobs:    4,
+		BackgroundJobTimeout: 5 * time.Second,
+		SavePoolShards:       2,
+		NgramCacheTTL:        time.Minute,
+	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	alex := openalex.NewClient(openalex.WithRateLimit(1000, 100), openalex.WithPageJitter(0, 0))
+	sem := semanticscholar.NewClient("", semanticscholar.WithRateLimit(1000, 100), semanticscholar.WithRetryBackoff(time.Millisecond))
+	return NewAPIHandler(cfg, repo, alex, sem)
+}
+
+// fakeOpenAlex answers the OpenAlex (and Semantic Scholar) requests of the test's handlers
+// with handler. The clients use the default transport, which is swapped for the test, so
+// tests using it can't run in parallel.
+func fakeOpenAlex(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	target, _ := url.Parse(server.URL)
+	original := http.DefaultTransport
+	http.DefaultTransport = rewriteTransport{target: target, next: original}
+	t.Cleanup(func() {
+		http.DefaultTransport = original
+		server.Close()
+	})
+}
+
+// rewriteTransport sends every request to target instead of its own host.
+type rewriteTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return t.next.RoundTrip(req)
+}
+
+// fakeRepo is an in-memory stand-in for the graph. Methods it doesn't override behave like
+// the disabled repository.
+type fakeRepo struct {
+	storage.Repository
+
+	mu     sync.Mutex
+	events map[string]storage.IngestEvent
+
+	collaborations map[string]map[string]int // country counts by author ID
+	venues         map[string]*storage.VenueSummary
+
+	// authorWorks are the works GetAuthorWorks returns and topWorks those GetTopWorks
+	// returns; onlyFulltext, newestFirst and includeRetracted are what either was last asked
+	// for.
+	authorWorks      []domain.DehydratedWork
+	topWorks         []domain.Work
+	onlyFulltext     bool
+	newestFirst      bool
+	includeRetracted bool
+
+	blocked map[string]string // reasons by blocked ID
+	authors map[string]bool   // authors DeleteAuthor can delete
+
+	// newWorks are the works GetWorksAddedSince filters; since is the cutoff it was last
+	// asked for.
+	newWorks []storage.NewWork
+	since    time.Time
+
+	// synced are the authors in the graph, with their last works sync; authors missing
+	// from it aren't in the graph.
+	synced map[string]time.Time
+	// saveOutcome decides what SaveWork does with a work; nil creates every work. saved are
+	// the works saved so far, and saveOptions the options of the last save.
+	saveOutcome func(work domain.Work) (storage.SaveOutcome, error)
+	saved       []domain.Work
+	saveOptions storage.SaveOptions
+
+	// aliases are the recorded author merges, canonical IDs by old ID. savedAuthors are the
+	// authors saved so far, and authorWorksID the author GetAuthorWorks was last asked for.
+	aliases       map[string]string
+	savedAuthors  []domain.Author
+	authorWorksID string
+
+	// workIDs are the works in the graph by normalized DOI, and paperIDs their known
+	// Semantic Scholar paperIds. annotations are the CITES properties set so far, keyed
+	// "citing->cited"; AnnotateCitation fails with ErrNotFound for works in deleted.
+	workIDs     map[string]string
+	paperIDs    map[string]string
+	annotations map[string]map[string]any
+	deleted     map[string]bool
+
+	topicProfiles map[string]*storage.TopicProfile // by author ID
+
+	// similarity are the candidates of GetSimilarityCandidates by work ID. missingEmbedding
+	// are the works GetWorksMissingEmbedding returns, and embeddings those saved so far.
+	similarity       map[string]*storage.SimilarityCandidates
+	missingEmbedding []domain.DehydratedWork
+	embeddings       map[string][]float32
+
+	// hIndexDrift is what GetHIndexDrift returns by author ID. driftCounts are the results of
+	// successive CountHIndexDrift calls, which fail once they run out; driftThresholds are
+	// the thresholds they were asked for.
+	hIndexDrift     map[string]*storage.HIndexDrift
+	driftCounts     []int
+	driftThresholds []int
+
+	// rollups are the institution summaries by ID; GetInstitutionWorksRolledUp returns
+	// them with the children it was asked for, and rolledUp records those requests.
+	rollups  map[string]storage.InstitutionRollup
+	rolledUp []string
+
+	// institutions are the institutions in the graph by ROR URL; savedInstitutions are
+	// the institutions saved so far.
+	institutions      map[string]domain.Institution
+	savedInstitutions []domain.Institution
+
+	// venueAliases are the groups FindVenueAliases returns. MergeVenues fails with
+	// venueMergeErrs[keepID] if there is one and records the merge in venueMerges otherwise.
+	venueAliases   []storage.VenueAliases
+	venueMergeErrs map[string]error
+	venueMerges    map[string][]string
+
+	// bridging are the works GetWorksBridgingTopics pages through, and bridgingAuthors the
+	// authors of GetAuthorsBridgingTopics; bridgeQuery is the last query either was asked.
+	bridging        []storage.BridgingWork
+	bridgingAuthors []storage.BridgingAuthor
+	bridgeQuery     string
+
+	// neighborhoods are what GetCitationNeighborhood returns by work ID; neighborhoodQuery
+	// is the last query it was asked.
+	neighborhoods     map[string]*storage.CitationNeighborhood
+	neighborhoodQuery string
+
+	// exportWorks are the works GetWorksForExport finds by ID; exported are the IDs it was
+	// last asked for.
+	exportWorks map[string]domain.Work
+	exported    []string
+
+	// provenance are the stamps GetWorkProvenance returns by work ID; provenanceOf is the
+	// work it was last asked about.
+	provenance   map[string]*storage.WorkProvenance
+	provenanceOf string
+
+	// follows are the authors each user follows, in the order followed. digests are what
+	// GetDigest returns by user, and digestSince the cutoff it was last asked for.
+	follows     map[string][]string
+	digests     map[string][]storage.AuthorDigest
+	digestSince time.Time
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		Repository:  storage.NewDisabledRepository(),
+		events:      make(map[string]storage.IngestEvent),
+		blocked:     make(map[string]string),
+		authors:     make(map[string]bool),
+		synced:      make(map[string]time.Time),
+		aliases:     make(map[string]string),
+		workIDs:     make(map[string]string),
+		paperIDs:    make(map[string]string),
+		annotations: make(map[string]map[string]any),
+		deleted:     make(map[string]bool),
+		embeddings:  make(map[string][]float32),
+		venueMerges: make(map[string][]string),
+		follows:     make(map[string][]string),
+	}
+}
+
+func (r *fakeRepo) RecordIngestEvent(ctx context.Context, event storage.IngestEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[event.ID] = event
+	return nil
+}
+
+func (r *fakeRepo) GetIngestEvent(ctx context.Context, id string) (storage.IngestEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[id]
+	if !ok {
+		return storage.IngestEvent{}, storage.ErrNotFound
+	}
+	return event, nil
+}
+
+func (r *fakeRepo) ListIncompleteIngestEvents(ctx context.Context) ([]storage.IngestEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []storage.IngestEvent
+	for _, event := range r.events {
+		if event.Resume != nil && (event.Status == storage.IngestStatusRunning || event.Status == storage.IngestStatusInterrupted) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// event returns the last recorded state of the ingest event with the given ID.
+func (r *fakeRepo) event(id string) storage.IngestEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[id]
+}
+
+func (r *fakeRepo) GetAuthorWorks(ctx context.Context, authorID string, onlyFulltext, includeRetracted, newestFirst bool, limit int) ([]domain.DehydratedWork, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onlyFulltext, r.newestFirst, r.authorWorksID = onlyFulltext, newestFirst, authorID
+	r.includeRetracted = includeRetracted
+	return r.authorWorks, nil
+}
+
+func (r *fakeRepo) GetTopWorks(ctx context.Context, limit int, sinceYear int, includeRetracted bool) ([]domain.Work, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.includeRetracted = includeRetracted
+	return r.topWorks, nil
+}
+
+func (r *fakeRepo) SaveAuthor(ctx context.Context, author domain.Author) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.savedAuthors = append(r.savedAuthors, author)
+	return nil
+}
+
+func (r *fakeRepo) SaveAuthors(ctx context.Context, authors []domain.Author) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.savedAuthors = append(r.savedAuthors, authors...)
+	return nil
+}
+
+func (r *fakeRepo) RecordAuthorMerge(ctx context.Context, oldID, canonicalID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[oldID] = canonicalID
+	return nil
+}
+
+func (r *fakeRepo) ResolveAuthorID(ctx context.Context, id string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.aliases[id] != "" {
+		id = r.aliases[id]
+	}
+	return id, nil
+}
+
+func (r *fakeRepo) SaveWork(ctx context.Context, work domain.Work, opts storage.SaveOptions) (storage.SaveOutcome, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saveOptions = opts
+	if r.saveOutcome == nil {
+		r.saved = append(r.saved, work)
+		return storage.SaveCreated, nil
+	}
+	outcome, err := r.saveOutcome(work)
+	if err == nil {
+		r.saved = append(r.saved, work)
+	}
+	return outcome, err
+}
+
+func (r *fakeRepo) GetAuthorWorksSynced(ctx context.Context, authorID string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	synced, ok := r.synced[authorID]
+	if !ok {
+		return time.Time{}, storage.ErrNotFound
+	}
+	return synced, nil
+}
+
+func (r *fakeRepo) SetAuthorWorksSynced(ctx context.Context, authorID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.synced[authorID] = at
+	return nil
+}
+
+func (r *fakeRepo) GetWorksAddedSince(ctx context.Context, authorID string, since time.Time) ([]storage.NewWork, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.since = since
+	works := []storage.NewWork{}
+	for _, work := range r.newWorks {
+		if work.FirstSeen.After(since) {
+			works = append(works, work)
+		}
+	}
+	return works, nil
+}
+
+func (r *fakeRepo) GetWorkIDsByDOI(ctx context.Context, dois []string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make(map[string]string)
+	for _, doi := range dois {
+		doi = domain.NormalizeDOI(doi)
+		if id, ok := r.workIDs[doi]; ok {
+			ids[doi] = id
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeRepo) FindWorkIDs(ctx context.Context, kind, value string) (storage.WorkIDs, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paperID, ok := r.paperIDs[value]
+	if kind != storage.WorkIDOpenAlex || !ok {
+		return storage.WorkIDs{}, storage.ErrNotFound
+	}
+	return storage.WorkIDs{ID: value, SSPaperID: paperID}, nil
+}
+
+func (r *fakeRepo) AnnotateCitation(ctx context.Context, citingID, citedID string, props map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.deleted[citingID] || r.deleted[citedID] {
+		return storage.ErrNotFound
+	}
+	r.annotations[citingID+"->"+citedID] = props
+	return nil
+}
+
+func (r *fakeRepo) GetAuthorTopicProfile(ctx context.Context, authorID string) (*storage.TopicProfile, error) {
+	profile, ok := r.topicProfiles[authorID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return profile, nil
+}
+
+func (r *fakeRepo) GetSimilarityCandidates(ctx context.Context, workID string, maxCandidates int) (*storage.SimilarityCandidates, error) {
+	candidates, ok := r.similarity[workID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return candidates, nil
+}
+
+func (r *fakeRepo) GetWorksMissingEmbedding(ctx context.Context, limit int) ([]domain.DehydratedWork, error) {
+	return r.missingEmbedding[:min(limit, len(r.missingEmbedding))], nil
+}
+
+func (r *fakeRepo) SaveWorkEmbedding(ctx context.Context, workID string, vec []float32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.embeddings[workID] = vec
+	return nil
+}
+
+func (r *fakeRepo) GetHIndexDrift(ctx context.Context, authorID string) (*storage.HIndexDrift, error) {
+	drift, ok := r.hIndexDrift[authorID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return drift, nil
+}
+
+func (r *fakeRepo) CountHIndexDrift(ctx context.Context, threshold int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.driftThresholds = append(r.driftThresholds, threshold)
+	if len(r.driftCounts) == 0 {
+		return 0, errors.New("database unavailable")
+	}
+	count := r.driftCounts[0]
+	r.driftCounts = r.driftCounts[1:]
+	return count, nil
+}
+
+// countedDrift returns the thresholds CountHIndexDrift was asked for so far.
+func (r *fakeRepo) countedDrift() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.driftThresholds...)
+}
+
+func (r *fakeRepo) GetInstitutionWorksRolledUp(ctx context.Context, instID string, includeChildren bool) (*storage.InstitutionRollup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rolledUp = append(r.rolledUp, fmt.Sprintf("%s children=%v", instID, includeChildren))
+	rollup, ok := r.rollups[instID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	rollup.IncludeChildren = includeChildren
+	return &rollup, nil
+}
+
+func (r *fakeRepo) GetInstitutionByROR(ctx context.Context, ror string) (*domain.Institution, error) {
+	institution, ok := r.institutions[ror]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &institution, nil
+}
+
+func (r *fakeRepo) SaveInstitution(ctx context.Context, institution domain.Institution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.savedInstitutions = append(r.savedInstitutions, institution)
+	return nil
+}
+
+func (r *fakeRepo) FindVenueAliases(ctx context.Context) ([]storage.VenueAliases, error) {
+	return r.venueAliases, nil
+}
+
+func (r *fakeRepo) MergeVenues(ctx context.Context, keepID string, mergeIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.venueMergeErrs[keepID]; err != nil {
+		return err
+	}
+	r.venueMerges[keepID] = mergeIDs
+	return nil
+}
+
+func (r *fakeRepo) GetWorksBridgingTopics(ctx context.Context, topicA, topicB string, minScore float32, page storage.PageRequest) (*storage.BridgingWorks, error) {
+	r.bridgeQuery = fmt.Sprintf("%s %s min=%v page=%d/%d", topicA, topicB, minScore, page.Page, page.PerPage)
+	works := r.bridging[min((page.Page-1)*page.PerPage, len(r.bridging)):min(page.Page*page.PerPage, len(r.bridging))]
+	return &storage.BridgingWorks{Total: len(r.bridging), Works: append([]storage.BridgingWork{}, works...)}, nil
+}
+
+func (r *fakeRepo) GetAuthorsBridgingTopics(ctx context.Context, topicA, topicB string, minPapers int, page storage.PageRequest) (*storage.BridgingAuthors, error) {
+	r.bridgeQuery = fmt.Sprintf("%s %s min=%d page=%d/%d", topicA, topicB, minPapers, page.Page, page.PerPage)
+	authors := r.bridgingAuthors[min((page.Page-1)*page.PerPage, len(r.bridgingAuthors)):min(page.Page*page.PerPage, len(r.bridgingAuthors))]
+	return &storage.BridgingAuthors{Total: len(r.bridgingAuthors), Authors: append([]storage.BridgingAuthor{}, authors...)}, nil
+}
+
+func (r *fakeRepo) GetCitationNeighborhood(ctx context.Context, workID string, depthIn, depthOut, maxNodes int) (*storage.CitationNeighborhood, error) {
+	r.neighborhoodQuery = fmt.Sprintf("%s in=%d out=%d max=%d", workID, depthIn, depthOut, maxNodes)
+	hood, ok := r.neighborhoods[workID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return hood, nil
+}
+
+func (r *fakeRepo) GetWorksForExport(ctx context.Context, ids []string) ([]domain.Work, error) {
+	r.exported = ids
+	var works []domain.Work
+	for _, id := range ids {
+		if work, ok := r.exportWorks[id]; ok {
+			works = append(works, work)
+		}
+	}
+	return works, nil
+}
+
+func (r *fakeRepo) GetWorkProvenance(ctx context.Context, workID string) (*storage.WorkProvenance, error) {
+	r.provenanceOf = workID
+	provenance, ok := r.provenance[workID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return provenance, nil
+}
+
+// AuthorExists reports the authors of synced as in the graph.
+func (r *fakeRepo) AuthorExists(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.synced[id]
+	return ok, nil
+}
+
+func (r *fakeRepo) Follow(ctx context.Context, userID, authorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.follows[userID], authorID) {
+		r.follows[userID] = append(r.follows[userID], authorID)
+	}
+	return nil
+}
+
+func (r *fakeRepo) Unfollow(ctx context.Context, userID, authorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := slices.Index(r.follows[userID], authorID)
+	if i < 0 {
+		return storage.ErrNotFound
+	}
+	r.follows[userID] = slices.Delete(r.follows[userID], i, i+1)
+	return nil
+}
+
+func (r *fakeRepo) ListFollowed(ctx context.Context, userID string) ([]storage.FollowedAuthor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	authors := []storage.FollowedAuthor{}
+	for _, id := range slices.Backward(r.follows[userID]) {
+		authors = append(authors, storage.FollowedAuthor{ID: id})
+	}
+	return authors, nil
+}
+
+func (r *fakeRepo) GetDigest(ctx context.Context, userID string, since time.Time) ([]storage.AuthorDigest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.digestSince = since
+	digest := []storage.AuthorDigest{}
+	for _, author := range r.digests[userID] {
+		var works []storage.NewWork
+		for _, work := range author.Works {
+			if work.FirstSeen.After(since) {
+				works = append(works, work)
+			}
+		}
+		if len(works) > 0 {
+			author.Works = works
+			digest = append(digest, author)
+		}
+	}
+	return digest, nil
+}
+
+func (r *fakeRepo) BlockEntity(ctx context.Context, id, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blocked[id] = reason
+	return nil
+}
+
+func (r *fakeRepo) UnblockEntity(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.blocked[id]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(r.blocked, id)
+	return nil
+}
+
+func (r *fakeRepo) IsBlocked(ctx context.Context, id string) (bool, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reason, ok := r.blocked[id]
+	return ok, reason, nil
+}
+
+func (r *fakeRepo) DeleteAuthor(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.authors[id] {
+		return storage.ErrNotFound
+	}
+	delete(r.authors, id)
+	return nil
+}
+
+func (r *fakeRepo) GetVenueSummary(ctx context.Context, venueID string, topAuthors int) (*storage.VenueSummary, error) {
+	summary, ok := r.venues[venueID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	summary.TopAuthors = summary.TopAuthors[:min(topAuthors, len(summary.TopAuthors))]
+	return summary, nil
+}
+
+func (r *fakeRepo) CountCollaborationsByCountry(ctx context.Context, authorID string) (map[string]int, error) {
+	counts, ok := r.collaborations[authorID]
+	if !ok {
+		counts = map[string]int{}
+	}
+	return counts, nil
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+	"github.com/Cloudforge2/scrappy/internal/tenant"
+)
+
+// authorIngest is a paged ingestion of an author's works, driven by a resumable job.
+type authorIngest struct {
+	job       *ingestJob
+	authorID  string
+	filter    workFilter
+	fetchedAt time.Time
+}
+
+// authorPage is one page of an author's works after filtering.
+type authorPage struct {
+	works    []domain.Work
+	next     string // cursor of the following page; empty after the last one
+	skipped  int
+	warnings openalex.DecodeWarnings
+}
+
+// fetchAuthorWorksPage fetches the page of the author's works at cursor and applies the
+// ingestion's filter, counting the skipped works and the works that didn't decode on the
+// job.
+func (h *APIHandler) fetchAuthorWorksPage(ctx context.Context, ingest authorIngest, cursor string) (authorPage, error) {
+	works, next, warnings, err := h.alexClient.FetchWorksPageByAuthorID(ingest.authorID, cursor)
+	if err != nil {
+		return authorPage{}, err
+	}
+	logDecodeWarnings("author "+ingest.authorID, warnings)
+	ingest.job.decodeWarnings(warnings)
+	works, skipped := ingest.filter.apply(works)
+	works, existing := ingest.filter.dropExisting(ctx, h.repo, works)
+	skipped += existing
+	if skipped > 0 {
+		log.Printf("Skipping %d works of author %s (paratext/retracted/existing).", skipped, ingest.authorID)
+	}
+	ingest.job.worksSkipped(skipped)
+	return authorPage{works: works, next: next, skipped: skipped, warnings: warnings}, nil
+}
+
+// ingestAuthorPages saves the remaining works of the current page and then fetches and
+// saves the following pages one at a time. The job's cursor is committed after each page,
+// so an interrupted ingestion resumes at the first page it didn't finish.
+func (h *APIHandler) ingestAuthorPages(ctx context.Context, ingest authorIngest, page authorPage) error {
+	referencesLeft := ingest.filter.resolveReferences
+	for {
+		for _, work := range page.works {
+			// ctx carries the job's overall deadline; once it expires the loop stops and
+			// the job is recorded as timed out with the pages completed so far.
+			if err := ctx.Err(); err != nil {
+				log.Printf("BACKGROUND ABORTED: Job for author %s stopped: %v", ingest.authorID, err)
+				return err
+			}
+			// Use a reasonable timeout per work in the background.
+			workCtx, workCancel := context.WithTimeout(ctx, 30*time.Second)
+			outcome, err := h.saveAuthorWork(workCtx, ingest.authorID, work, ingest.filter.save)
+			ingest.job.workSaved(work, outcome, err)
+			if err != nil {
+				log.Printf("BACKGROUND ERROR: Could not save work %s: %v\n", work.Title, err)
+			} else {
+				log.Printf("BACKGROUND SUCCESS: Saved work: %s", work.Title)
+			}
+			workCancel()
+		}
+		// A save cut short by the deadline must not count the page as done.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		referencesLeft -= h.resolveReferencesOf(ctx, page.works, referencesLeft)
+		ingest.job.pageCommitted(ctx, page.next)
+		if page.next == "" {
+			break
+		}
+
+		var err error
+		page, err = h.fetchAuthorWorksPage(ctx, ingest, page.next)
+		if err != nil {
+			return fmt.Errorf("failed to fetch works from OpenAlex: %w", err)
+		}
+	}
+	log.Printf("Background task finished for author %s. All pages processed.", ingest.authorID)
+	h.finishAuthorIngest(ctx, ingest)
+	return nil
+}
+
+// saveAuthorWork saves a work of the author on the author's save pool shard, ordered with
+// the author's other writes.
+func (h *APIHandler) saveAuthorWork(ctx context.Context, authorID string, work domain.Work, opts storage.SaveOptions) (storage.SaveOutcome, error) {
+	var outcome storage.SaveOutcome
+	err := h.saves.do(ctx, authorID, func(ctx context.Context) (err error) {
+		outcome, err = h.repo.SaveWork(ctx, work, opts)
+		return err
+	})
+	return outcome, err
+}
+
+// finishAuthorIngest marks the author as fully ingested once all of their works are saved.
+func (h *APIHandler) finishAuthorIngest(ctx context.Context, ingest authorIngest) {
+	if err := h.repo.MarkAuthorFullyIngested(ctx, ingest.authorID); err != nil {
+		log.Printf("WARN: Could not set fullyIngested flag for author %s: %v", ingest.authorID, err)
+	} else {
+		log.Printf("✅ Author %s marked as fully ingested in Neo4j.", ingest.authorID)
+	}
+	h.recordWorksSync(ctx, ingest.authorID, ingest.fetchedAt)
+}
+
+// resumeIngestJob continues a stored author ingestion in the background, from the first
+// page it hadn't finished. It fails if the job isn't resumable or is still running here,
+// and with errBlocked if its author is blocked.
+func (h *APIHandler) resumeIngestJob(ctx context.Context, event storage.IngestEvent) error {
+	switch {
+	case event.Resume == nil || event.Kind != "author":
+		return fmt.Errorf("job %s can't be resumed", event.ID)
+	case event.Status == storage.IngestStatusCompleted:
+		return fmt.Errorf("job %s has already completed", event.ID)
+	case h.jobs.isActive(event.ID):
+		return fmt.Errorf("job %s is still running", event.ID)
+	}
+	// The author may have been blocked since the job started.
+	if blocked, reason, err := h.repo.IsBlocked(ctx, canonicalOpenAlexID(event.TargetID)); err != nil {
+		return fmt.Errorf("job %s: %w", event.ID, err)
+	} else if blocked {
+		return fmt.Errorf("job %s can't be resumed: %s is %w: %s", event.ID, event.TargetID, errBlocked, reason)
+	}
+	params, err := url.ParseQuery(event.Resume.Filter)
+	if err != nil {
+		return fmt.Errorf("job %s has an invalid filter: %w", event.ID, err)
+	}
+	filter, err := h.workFilterFromQuery(params)
+	if err != nil {
+		return fmt.Errorf("job %s has an invalid filter: %w", event.ID, err)
+	}
+
+	job := h.reopenIngestJob(ctx, event)
+	ingest := authorIngest{job: job, authorID: event.TargetID, filter: filter, fetchedAt: event.Resume.FetchedAt}
+	cursor := event.Resume.Cursor
+	log.Printf("Resuming ingest job %s for author %s after %d page(s)", event.ID, event.TargetID, event.Resume.Pages)
+	h.jobs.run(job, func(backgroundCtx context.Context) error {
+		// Every page was saved; only the final bookkeeping was missed.
+		if cursor == "" {
+			h.finishAuthorIngest(backgroundCtx, ingest)
+			return nil
+		}
+		page, err := h.fetchAuthorWorksPage(backgroundCtx, ingest, cursor)
+		if err != nil {
+			return fmt.Errorf("failed to fetch works from OpenAlex: %w", err)
+		}
+		return h.ingestAuthorPages(backgroundCtx, ingest, page)
+	})
+	return nil
+}
+
+// ResumeJobHandler resumes an interrupted, timed out or failed author ingestion
+// (POST /api/jobs/{id}/resume) from the first page it hadn't finished. It answers 202 with
+// the progress the job resumes from, 404 for an unknown job, 403 if its author has been
+// blocked since and 409 if the job can't be resumed.
+func (h *APIHandler) ResumeJobHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	if id == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing job ID")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	event, err := h.repo.GetIngestEvent(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Job %s not found", id))
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := h.resumeIngestJob(ctx, event); errors.Is(err, errBlocked) {
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+		"message":    "Job resumed in the background.",
+		"jobId":      event.ID,
+		"targetId":   event.TargetID,
+		"pagesSaved": event.Resume.Pages,
+		"worksSaved": event.WorksSaved,
+	})
+}
+
+// ResumeIncompleteJobs looks for resumable ingest jobs that a previous run of the service
+// left unfinished. With resume set they are resumed; otherwise they are only logged, to
+// be resumed with POST /api/jobs/{id}/resume.
+func (h *APIHandler) ResumeIncompleteJobs(ctx context.Context, resume bool) {
+	events, err := h.repo.ListIncompleteIngestEvents(ctx)
+	if err != nil {
+		log.Printf("WARN: Could not list incomplete ingest jobs: %v", err)
+		return
+	}
+	for _, event := range events {
+		if !resume {
+			log.Printf("Incomplete ingest job %s for %s (%d page(s) saved); resume it with POST /api/jobs/%s/resume",
+				event.ID, event.TargetID, event.Resume.Pages, event.ID)
+			continue
+		}
+		if err := h.resumeIngestJob(tenant.WithTenant(ctx, event.Tenant), event); err != nil {
+			log.Printf("WARN: Could not resume ingest job %s: %v", event.ID, err)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// crashingRepo is a fakeRepo whose first save of crashOn hangs until the job is cancelled,
+// like a process stopped in the middle of a page. crashing is closed when it starts to.
+type crashingRepo struct {
+	*fakeRepo
+	crashOn  string
+	crashing chan struct{}
+	once     sync.Once
+}
+
+func (r *crashingRepo) SaveWork(ctx context.Context, work domain.Work, opts storage.SaveOptions) (storage.SaveOutcome, error) {
+	crash := false
+	if work.ID == r.crashOn {
+		r.once.Do(func() { crash = true })
+	}
+	if !crash {
+		return r.fakeRepo.SaveWork(ctx, work, opts)
+	}
+	close(r.crashing)
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+// authorWorkPages serves author A1 and their works in three pages, at cursors *, c2 and
+// c3, and records the cursors asked for.
+type authorWorkPages struct {
+	mu      sync.Mutex
+	cursors []string
+}
+
+func (p *authorWorkPages) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/authors/A1" {
+		fmt.Fprint(w, `{"id": "https://openalex.org/A1", "display_name": "Ada", "works_count": 5}`)
+		return
+	}
+	cursor := r.URL.Query().Get("cursor")
+	p.mu.Lock()
+	p.cursors = append(p.cursors, cursor)
+	p.mu.Unlock()
+	pages := map[string]struct {
+		ids  []string
+		next string
+	}{
+		"*":  {[]string{"W1", "W2"}, `"c2"`},
+		"c2": {[]string{"W3", "W4"}, `"c3"`},
+		"c3": {[]string{"W5"}, "null"},
+	}
+	page := pages[cursor]
+	results := make([]string, len(page.ids))
+	for i, id := range page.ids {
+		results[i] = fmt.Sprintf(`{"id": "https://openalex.org/%s", "title": "%s", "type": "article"}`, id, id)
+	}
+	fmt.Fprintf(w, `{"meta": {"count": 5, "next_cursor": %s}, "results": [%s]}`, page.next, strings.Join(results, ","))
+}
+
+func (p *authorWorkPages) asked() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cursors := p.cursors
+	p.cursors = nil
+	return cursors
+}
+
+func TestResumeAfterRestart(t *testing.T) {
+	tests := []struct {
+		name        string
+		crashOn     string
+		viaEndpoint bool
+		wantPages   int // committed before the restart
+		wantSaved   int
+		wantCursor  string
+		wantResumed []string // cursors fetched after the restart
+	}{
+		{"first work of page 2", "https://openalex.org/W3", false, 1, 2, "c2", []string{"c2", "c3"}},
+		{"last work of page 2", "https://openalex.org/W4", false, 1, 2, "c2", []string{"c2", "c3"}},
+		{"page 3", "https://openalex.org/W5", false, 2, 4, "c3", []string{"c3"}},
+		{"resumed with the endpoint", "https://openalex.org/W3", true, 1, 2, "c2", []string{"c2", "c3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := &authorWorkPages{}
+			fakeOpenAlex(t, upstream.ServeHTTP)
+			repo := &crashingRepo{fakeRepo: newFakeRepo(), crashOn: tt.crashOn, crashing: make(chan struct{})}
+
+			// The first process starts the ingestion and is shut down mid-page.
+			first := newTestHandler(repo)
+			rec := httptest.NewRecorder()
+			first.FetchAndSaveWorksByAuthorHandler(rec, httptest.NewRequest(http.MethodGet, "/api/fetch-author-by-id?id=A1", nil))
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want 202: %s", rec.Code, rec.Body)
+			}
+			<-repo.crashing
+			first.DrainJobs(0)
+			upstream.asked()
+
+			events, _ := repo.ListIncompleteIngestEvents(context.Background())
+			if len(events) != 1 {
+				t.Fatalf("%d incomplete jobs after the shutdown, want 1", len(events))
+			}
+			stopped := events[0]
+			if stopped.Status != storage.IngestStatusInterrupted || stopped.Resume.Pages != tt.wantPages ||
+				stopped.Resume.Cursor != tt.wantCursor || stopped.WorksSaved != tt.wantSaved {
+				t.Fatalf("stopped job = %s, %d page(s), cursor %q, %d saved; want interrupted, %d, %q, %d",
+					stopped.Status, stopped.Resume.Pages, stopped.Resume.Cursor, stopped.WorksSaved, tt.wantPages, tt.wantCursor, tt.wantSaved)
+			}
+
+			// The second process picks the job up where the first one committed it.
+			second := newTestHandler(repo)
+			if tt.viaEndpoint {
+				req := httptest.NewRequest(http.MethodPost, "/api/jobs/"+stopped.ID+"/resume", nil)
+				req.SetPathValue("id", stopped.ID)
+				rec := httptest.NewRecorder()
+				second.ResumeJobHandler(rec, req)
+				if rec.Code != http.StatusAccepted {
+					t.Fatalf("resume status = %d, want 202: %s", rec.Code, rec.Body)
+				}
+			} else {
+				second.ResumeIncompleteJobs(context.Background(), true)
+			}
+			second.jobs.wg.Wait()
+
+			if got := upstream.asked(); strings.Join(got, " ") != strings.Join(tt.wantResumed, " ") {
+				t.Errorf("cursors fetched after the restart = %v, want %v", got, tt.wantResumed)
+			}
+			done := repo.event(stopped.ID)
+			if done.Status != storage.IngestStatusCompleted || done.Resume.Pages != 3 || done.Resume.Cursor != "" {
+				t.Errorf("resumed job = %s, %d page(s), cursor %q; want completed after 3 pages", done.Status, done.Resume.Pages, done.Resume.Cursor)
+			}
+			if done.WorksSaved != 5 || done.WorksCreated != 5 || done.WorksFailed != 0 {
+				t.Errorf("report = %d saved, %d created, %d failed; want every work counted once", done.WorksSaved, done.WorksCreated, done.WorksFailed)
+			}
+			saved := map[string]bool{}
+			for _, work := range repo.saved {
+				saved[work.ID] = true
+			}
+			if len(saved) != 5 {
+				t.Errorf("saved works = %v, want all 5", saved)
+			}
+		})
+	}
+}
+
+func TestResumeIncompleteJobsOnlyLogsWithoutFlag(t *testing.T) {
+	upstream := &authorWorkPages{}
+	fakeOpenAlex(t, upstream.ServeHTTP)
+	repo := newFakeRepo()
+	repo.events["job-1"] = storage.IngestEvent{ID: "job-1", Kind: "author", TargetID: "https://openalex.org/A1",
+		Status: storage.IngestStatusInterrupted, Resume: &storage.IngestCursor{Cursor: "c2", Pages: 1}}
+
+	h := newTestHandler(repo)
+	h.ResumeIncompleteJobs(context.Background(), false)
+	h.jobs.wg.Wait()
+	if got := upstream.asked(); len(got) != 0 {
+		t.Errorf("fetched %v, want nothing", got)
+	}
+	if status := repo.event("job-1").Status; status != storage.IngestStatusInterrupted {
+		t.Errorf("status = %q, want the job left interrupted", status)
+	}
+}
+
+func TestResumeJobHandlerRejects(t *testing.T) {
+	repo := newFakeRepo()
+	repo.events["done"] = storage.IngestEvent{ID: "done", Kind: "author", Status: storage.IngestStatusCompleted, Resume: &storage.IngestCursor{}}
+	repo.events["unpaged"] = storage.IngestEvent{ID: "unpaged", Kind: "venue", Status: storage.IngestStatusFailed}
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		method string
+		id     string
+		want   int
+	}{
+		{http.MethodPost, "done", http.StatusConflict},
+		{http.MethodPost, "unpaged", http.StatusConflict},
+		{http.MethodPost, "missing", http.StatusNotFound},
+		{http.MethodGet, "done", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api/jobs/"+tt.id+"/resume", nil)
+		req.SetPathValue("id", tt.id)
+		rec := httptest.NewRecorder()
+		h.ResumeJobHandler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d: %s", tt.method, tt.id, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
+
+// An author blocked after their ingestion started isn't ingested again when the job is
+// resumed, on restart or through the endpoint.
+func TestResumeBlockedAuthor(t *testing.T) {
+	upstream := &authorWorkPages{}
+	fakeOpenAlex(t, upstream.ServeHTTP)
+	for _, viaEndpoint := range []bool{false, true} {
+		repo := newFakeRepo()
+		repo.events["job-1"] = storage.IngestEvent{ID: "job-1", Kind: "author", TargetID: "A1",
+			Status: storage.IngestStatusInterrupted, Resume: &storage.IngestCursor{Cursor: "c2", Pages: 1}}
+		repo.blocked["https://openalex.org/A1"] = "takedown request"
+		h := newTestHandler(repo)
+
+		if viaEndpoint {
+			req := httptest.NewRequest(http.MethodPost, "/api/jobs/job-1/resume", nil)
+			req.SetPathValue("id", "job-1")
+			rec := httptest.NewRecorder()
+			h.ResumeJobHandler(rec, req)
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "takedown request") {
+				t.Errorf("resume status = %d, want 403 with the reason: %s", rec.Code, rec.Body)
+			}
+		} else {
+			h.ResumeIncompleteJobs(context.Background(), true)
+		}
+		h.jobs.wg.Wait()
+		if got := upstream.asked(); len(got) != 0 {
+			t.Errorf("endpoint %v: fetched %v, want nothing", viaEndpoint, got)
+		}
+		if event := repo.event("job-1"); event.Status != storage.IngestStatusInterrupted || len(repo.saved) != 0 {
+			t.Errorf("endpoint %v: job %s with %d works saved, want it left interrupted", viaEndpoint, event.Status, len(repo.saved))
+		}
+	}
+}
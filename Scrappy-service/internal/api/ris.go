@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+)
+
+// risTypes maps OpenAlex work types to RIS reference types; anything else is GEN.
+var risTypes = map[string]string{
+	"article":             "JOUR",
+	"journal-article":     "JOUR",
+	"review":              "JOUR",
+	"letter":              "JOUR",
+	"editorial":           "JOUR",
+	"book":                "BOOK",
+	"book-chapter":        "CHAP",
+	"dissertation":        "THES",
+	"proceedings-article": "CPAPER",
+	"dataset":             "DATA",
+	"report":              "RPRT",
+	"preprint":            "UNPB",
+}
+
+// writeRIS writes one work as an RIS record, one AU line per author in authorship order.
+func writeRIS(w io.Writer, work domain.Work) {
+	field := func(tag, value string) {
+		// RIS values are single lines.
+		value = strings.Join(strings.Fields(value), " ")
+		if value != "" {
+			fmt.Fprintf(w, "%s  - %s\r\n", tag, value)
+		}
+	}
+
+	risType, ok := risTypes[work.Type]
+	if !ok {
+		risType = "GEN"
+	}
+	field("TY", risType)
+	field("TI", work.Title)
+	for _, authorship := range work.Authorships {
+		field("AU", authorship.Author.DisplayName)
+	}
+	if work.PublicationYear != 0 {
+		field("PY", fmt.Sprintf("%d", work.PublicationYear))
+	}
+	if t, _, ok := domain.ParsePublicationDate(work.PublicationDate); ok {
+		field("DA", t.Format("2006/01/02"))
+	}
+	if work.PrimaryLocation != nil {
+		if work.PrimaryLocation.Source != nil {
+			field("T2", work.PrimaryLocation.Source.DisplayName)
+		}
+		field("UR", work.PrimaryLocation.LandingPageUrl)
+	}
+	field("VL", work.Biblio.Volume)
+	field("IS", work.Biblio.Issue)
+	field("SP", work.Biblio.FirstPage)
+	field("EP", work.Biblio.LastPage)
+	field("DO", domain.NormalizeDOI(work.Doi))
+	field("AB", work.Abstract)
+	field("ID", strings.TrimPrefix(work.ID, "https://openalex.org/"))
+	io.WriteString(w, "ER  - \r\n\r\n")
+}
+
+// GetWorksRISHandler renders works in RIS format for reference managers such as EndNote and
+// Zotero. id takes one or more comma-separated OpenAlex work IDs (at most 50), and may be
+// repeated. Works are fetched from OpenAlex, so they don't need to be ingested.
+func (h *APIHandler) GetWorksRISHandler(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	for _, param := range r.URL.Query()["id"] {
+		for _, raw := range strings.Split(param, ",") {
+			if raw = strings.TrimSpace(raw); raw == "" {
+				continue
+			}
+			id, err := openalex.ValidateID(raw, 'W')
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Missing 'id' query parameter")
+		return
+	}
+	if len(ids) > openalex.MaxIDsPerRequest {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d works per request", openalex.MaxIDsPerRequest))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	works, err := h.alexClient.FetchWorksByIDs(ctx, ids)
+	if err != nil {
+		respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch works from OpenAlex: %v", err))
+		return
+	}
+	if len(works) == 0 {
+		respondWithError(w, http.StatusNotFound, "None of the works were found in OpenAlex")
+		return
+	}
+
+	// Keep the order the works were asked for in.
+	byID := make(map[string]domain.Work, len(works))
+	for _, work := range works {
+		byID[strings.TrimPrefix(work.ID, "https://openalex.org/")] = work
+	}
+	filename := "works.ris"
+	if len(ids) == 1 {
+		filename = ids[0] + ".ris"
+	}
+	w.Header().Set("Content-Type", "application/x-research-info-systems")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	bw := bufio.NewWriter(w)
+	for _, id := range ids {
+		if work, ok := byID[id]; ok {
+			writeRIS(bw, work)
+		}
+	}
+	bw.Flush()
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// defaultSampleSize is the number of works sampled when n isn't given.
+const defaultSampleSize = 100
+
+// GetSampleWorksHandler returns a random sample of OpenAlex works, e.g. to build an
+// evaluation dataset: /api/sample-works?filter=publication_year:2023&n=500&seed=42. The
+// filter is optional and checked like the other OpenAlex filters; n (default 100) can't
+// exceed openalex.MaxSampleSize. The same filter, n and seed return the same sample, so the
+// seed is echoed in the response; without one a random seed is picked. With save=true the
+// sampled works are also ingested in a background job, with the work filter query
+// parameters (skip_paratext, skip_retracted, skip_existing) applied as for the other ingest
+// endpoints, and the response carries its job id.
+func (h *APIHandler) GetSampleWorksHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filterString string
+	if raw := query.Get("filter"); raw != "" {
+		valid, err := openalex.ValidateFilter(raw, h.cfg.FilterAllowlist)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filterString = valid
+	}
+	n := defaultSampleSize
+	if raw := query.Get("n"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 {
+			respondWithError(w, http.StatusBadRequest, "'n' must be a positive integer")
+			return
+		}
+		if v > openalex.MaxSampleSize {
+			respondWithError(w, http.StatusBadRequest,
+				fmt.Sprintf("'n' can be at most %d: OpenAlex doesn't draw larger samples", openalex.MaxSampleSize))
+			return
+		}
+		n = v
+	}
+	seed := rand.IntN(1_000_000)
+	if raw := query.Get("seed"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			respondWithError(w, http.StatusBadRequest, "'seed' must be a non-negative integer")
+			return
+		}
+		seed = v
+	}
+	save := false
+	if raw := query.Get("save"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "'save' must be true or false")
+			return
+		}
+		save = v
+	}
+	var filter workFilter
+	if save {
+		if h.storageDisabled(w) {
+			return
+		}
+		var err error
+		if filter, err = h.workFilterFor(r); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	works, warnings, err := h.alexClient.SampleWorks(r.Context(), filterString, n, seed)
+	if err != nil {
+		respondWithError(w, openAlexErrorStatus(err), err.Error())
+		return
+	}
+	logDecodeWarnings(fmt.Sprintf("sample of %d (seed %d)", n, seed), warnings)
+
+	payload := map[string]interface{}{
+		"filter": filterString,
+		"n":      n,
+		"seed":   seed,
+		"count":  len(works),
+		"works":  works,
+	}
+	addDecodeWarnings(payload, warnings)
+	if save {
+		job := h.startIngestJob(r.Context(), "sample", fmt.Sprintf("%s sample=%d seed=%d", filterString, n, seed), requestedBy(r))
+		job.decodeWarnings(warnings)
+		h.jobs.run(job, func(ctx context.Context) error {
+			return h.saveSampledWorks(ctx, job, works, filter)
+		})
+		payload["jobId"] = job.event.ID
+	}
+	respondWithJSON(w, http.StatusOK, payload)
+}
+
+// saveSampledWorks saves sampled works on the save pool, keyed by their first author, and
+// waits for all of them. Blocked works are skipped.
+func (h *APIHandler) saveSampledWorks(ctx context.Context, job *ingestJob, works []domain.Work, filter workFilter) error {
+	works, existing := filter.dropExisting(ctx, h.repo, works)
+	var inFlight sync.WaitGroup
+	submitted, skipped := 0, existing
+	for _, work := range works {
+		if filter.skips(work) {
+			skipped++
+			continue
+		}
+		if blocked, _, err := h.repo.IsBlocked(ctx, canonicalOpenAlexID(work.ID)); err == nil && blocked {
+			log.Printf("Not ingesting blocked work %s", work.ID)
+			skipped++
+			continue
+		}
+		workCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		inFlight.Add(1)
+		var outcome storage.SaveOutcome
+		err := h.saves.submit(workCtx, saveKey(work), func(ctx context.Context) (err error) {
+			outcome, err = h.repo.SaveWork(ctx, work, filter.save)
+			return err
+		}, func(err error) {
+			defer inFlight.Done()
+			cancel()
+			job.workSaved(work, outcome, err)
+			if err != nil {
+				log.Printf("BACKGROUND ERROR: Could not save work %s: %v", work.Title, err)
+			}
+		})
+		if err != nil {
+			inFlight.Done()
+			cancel()
+			inFlight.Wait()
+			return fmt.Errorf("sample ingest: %w", err)
+		}
+		submitted++
+	}
+	inFlight.Wait()
+	log.Printf("Background sample ingest %s finished: %d works submitted, %d skipped.", job.event.ID, submitted, skipped)
+	return nil
+}
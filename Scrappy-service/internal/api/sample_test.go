@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// serveSample answers sample requests with a page of works picked by the seed, and records
+// the query of the last request.
+func serveSample(query *url.Values) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*query = r.URL.Query()
+		seed, _ := strconv.Atoi(query.Get("seed"))
+		perPage, _ := strconv.Atoi(query.Get("per-page"))
+		results := make([]string, perPage)
+		for i := range results {
+			results[i] = fmt.Sprintf(`{"id": "https://openalex.org/W%d", "title": "sampled", "type": "article"}`, seed*1000+i)
+		}
+		fmt.Fprintf(w, `{"meta": {"count": 5000}, "results": [%s]}`, strings.Join(results, ","))
+	}
+}
+
+func TestGetSampleWorksHandler(t *testing.T) {
+	var upstream url.Values
+	fakeOpenAlex(t, serveSample(&upstream))
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantError  string
+		wantN      int
+		wantSeed   string // empty for a random one
+		wantFilter string
+		wantSaved  int // -1 if nothing may be ingested
+		blocked    []string
+	}{
+		{name: "sample", query: "filter=publication_year:2023&n=50&seed=42", wantStatus: http.StatusOK,
+			wantN: 50, wantSeed: "42", wantFilter: "publication_year:2023", wantSaved: -1},
+		{name: "defaults", wantStatus: http.StatusOK, wantN: 100, wantSaved: -1},
+		{name: "seed zero", query: "n=3&seed=0", wantStatus: http.StatusOK, wantN: 3, wantSeed: "0", wantSaved: -1},
+		{name: "saved", query: "n=20&seed=7&save=true", wantStatus: http.StatusOK, wantN: 20, wantSeed: "7", wantSaved: 20},
+		{name: "blocked works skipped", query: "n=20&seed=7&save=true", wantStatus: http.StatusOK, wantN: 20, wantSeed: "7", wantSaved: 18,
+			blocked: []string{"https://openalex.org/W7001", "https://openalex.org/W7019"}},
+		{name: "not saved", query: "n=20&seed=7&save=false", wantStatus: http.StatusOK, wantN: 20, wantSeed: "7", wantSaved: -1},
+		{name: "beyond the ceiling", query: "n=10001", wantStatus: http.StatusBadRequest, wantError: "at most 10000"},
+		{name: "zero", query: "n=0", wantStatus: http.StatusBadRequest, wantError: "'n'"},
+		{name: "not a number", query: "n=many", wantStatus: http.StatusBadRequest, wantError: "'n'"},
+		{name: "negative seed", query: "seed=-1", wantStatus: http.StatusBadRequest, wantError: "'seed'"},
+		{name: "bad save", query: "save=maybe", wantStatus: http.StatusBadRequest, wantError: "'save'"},
+		{name: "bad filter", query: "filter=nonsense", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream = nil
+			repo := newFakeRepo()
+			for _, id := range tt.blocked {
+				repo.blocked[id] = "spam"
+			}
+			h := newTestHandler(repo)
+			rec := httptest.NewRecorder()
+			h.GetSampleWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/sample-works?"+tt.query, nil))
+			h.jobs.wg.Wait()
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if !strings.Contains(rec.Body.String(), tt.wantError) {
+					t.Errorf("body = %s, want it to mention %s", rec.Body, tt.wantError)
+				}
+				if upstream != nil {
+					t.Errorf("OpenAlex was asked for %v", upstream)
+				}
+				return
+			}
+
+			var body struct {
+				Filter string            `json:"filter"`
+				N      int               `json:"n"`
+				Seed   int               `json:"seed"`
+				Count  int               `json:"count"`
+				Works  []json.RawMessage `json:"works"`
+				JobID  string            `json:"jobId"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.N != tt.wantN || body.Count != tt.wantN || len(body.Works) != tt.wantN || body.Filter != tt.wantFilter {
+				t.Errorf("response = n %d, count %d, %d works, filter %q; want %d works for %q",
+					body.N, body.Count, len(body.Works), body.Filter, tt.wantN, tt.wantFilter)
+			}
+			if tt.wantSeed != "" && strconv.Itoa(body.Seed) != tt.wantSeed {
+				t.Errorf("seed = %d, want %s", body.Seed, tt.wantSeed)
+			}
+			// The seed answered is the one OpenAlex drew the sample with, so it can be drawn again.
+			if upstream.Get("seed") != strconv.Itoa(body.Seed) || upstream.Get("sample") != strconv.Itoa(tt.wantN) ||
+				upstream.Get("filter") != tt.wantFilter {
+				t.Errorf("OpenAlex was asked for %v, want sample=%d seed=%d filter=%q", upstream, tt.wantN, body.Seed, tt.wantFilter)
+			}
+
+			if tt.wantSaved < 0 {
+				if body.JobID != "" || len(repo.saved) > 0 {
+					t.Errorf("job %q saved %d works, want no ingest", body.JobID, len(repo.saved))
+				}
+				return
+			}
+			event := repo.event(body.JobID)
+			if event.Kind != "sample" || event.Status != storage.IngestStatusCompleted || event.WorksSaved != tt.wantSaved ||
+				len(repo.saved) != tt.wantSaved {
+				t.Errorf("job = %+v with %d works saved, want a completed sample ingest of %d", event, len(repo.saved), tt.wantSaved)
+			}
+			for _, work := range repo.saved {
+				if slices.Contains(tt.blocked, work.ID) {
+					t.Errorf("blocked work %s was saved", work.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetSampleWorksHandlerSaveNeedsStorage(t *testing.T) {
+	var upstream url.Values
+	fakeOpenAlex(t, serveSample(&upstream))
+	h := newTestHandler(newFakeRepo(), func(cfg *config.Config) { cfg.StorageBackend = config.StorageNone })
+
+	rec := httptest.NewRecorder()
+	h.GetSampleWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/sample-works?n=5&seed=1&save=true", nil))
+	if rec.Code != http.StatusNotImplemented || upstream != nil {
+		t.Errorf("status = %d after asking OpenAlex for %v, want 501 without asking", rec.Code, upstream)
+	}
+	rec = httptest.NewRecorder()
+	h.GetSampleWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/sample-works?n=5&seed=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status without save = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/metrics"
+)
+
+var (
+	saveQueuedGauge = metrics.NewGauge("scrappy_save_pool_queued", "Graph writes waiting in the save pool's shard queues.")
+	saveBusyGauge   = metrics.NewGauge("scrappy_save_pool_busy", "Save pool workers currently writing to the graph.")
+)
+
+// saveQueueSize is the number of writes a shard queues before submitters block.
+const saveQueueSize = 64
+
+// savePool runs graph writes on a fixed set of workers, one per shard. A write is routed by
+// a hash of its key, the author it belongs to: all writes for one author run in submission
+// order on the same worker, so they never interleave with each other (or with a concurrent
+// SaveAuthor of that author) and deadlock on shared MERGEs, while different authors are
+// written in parallel on the other workers.
+type savePool struct {
+	shards []chan saveTask
+}
+
+type saveTask struct {
+	ctx  context.Context
+	fn   func(ctx context.Context) error
+	done func(error)
+}
+
+func newSavePool(shards int) *savePool {
+	p := &savePool{shards: make([]chan saveTask, shards)}
+	for i := range p.shards {
+		p.shards[i] = make(chan saveTask, saveQueueSize)
+		go p.work(p.shards[i])
+	}
+	return p
+}
+
+// work runs the tasks of one shard, one at a time. Tasks whose context has already ended
+// are not run; their done gets the context's error.
+func (p *savePool) work(queue <-chan saveTask) {
+	for task := range queue {
+		saveQueuedGauge.Dec()
+		err := task.ctx.Err()
+		if err == nil {
+			saveBusyGauge.Inc()
+			err = runSaveTask(task)
+			saveBusyGauge.Dec()
+		}
+		task.done(err)
+	}
+}
+
+// runSaveTask runs a task, turning a panic into an error so one bad write can't take down
+// the worker and with it every author on its shard.
+func runSaveTask(task saveTask) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("ERROR: Save task panicked: %v", p)
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return task.fn(task.ctx)
+}
+
+// shard returns the index of the shard that writes for key. Bare and URL-form OpenAlex IDs
+// of the same entity map to the same shard.
+func (p *savePool) shard(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(canonicalOpenAlexID(key)))
+	return int(h.Sum32() % uint32(len(p.shards)))
+}
+
+// submit queues fn on key's shard and returns without waiting for it; done is called with
+// fn's error once it ran. It blocks while the shard's queue is full, and fails with ctx's
+// error if ctx ends first, in which case done is never called.
+func (p *savePool) submit(ctx context.Context, key string, fn func(ctx context.Context) error, done func(error)) error {
+	saveQueuedGauge.Inc()
+	select {
+	case p.shards[p.shard(key)] <- saveTask{ctx: ctx, fn: fn, done: done}:
+		return nil
+	case <-ctx.Done():
+		saveQueuedGauge.Dec()
+		return ctx.Err()
+	}
+}
+
+// do runs fn on key's shard and waits for it, returning its error.
+func (p *savePool) do(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	result := make(chan error, 1)
+	if err := p.submit(ctx, key, fn, func(err error) { result <- err }); err != nil {
+		return err
+	}
+	return <-result
+}
+
+// saveKey is the save pool key of a work: its first author, whose other works it is then
+// ordered with, or the work itself if it has no authors.
+func saveKey(work domain.Work) string {
+	for _, authorship := range work.Authorships {
+		if authorship.Author.ID != "" {
+			return authorship.Author.ID
+		}
+	}
+	return work.ID
+}
+
+// stats reports the pool's size and the queue depth of each shard.
+func (p *savePool) stats() map[string]interface{} {
+	depths := make([]int, len(p.shards))
+	for i, shard := range p.shards {
+		depths[i] = len(shard)
+	}
+	return map[string]interface{}{
+		"shards":      len(p.shards),
+		"queued":      saveQueuedGauge.Value(),
+		"busy":        saveBusyGauge.Value(),
+		"queueDepths": depths,
+	}
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// recordingRepo is a fakeRepo that records the order in which each author's works are
+// saved and whether saves of one author ever overlap. The first save of each author in
+// meet waits for the first save of every other one to start, so the authors only get
+// through if they are saved in parallel.
+type recordingRepo struct {
+	*fakeRepo
+	meet map[string]chan struct{} // closed when the author's first save starts
+
+	mu       sync.Mutex
+	order    map[string][]string // work IDs by author, in save order
+	active   map[string]int
+	overlaps []string // authors with two saves running at once
+	apart    []string // authors whose first save didn't meet the others'
+}
+
+func newRecordingRepo(authors ...string) *recordingRepo {
+	r := &recordingRepo{fakeRepo: newFakeRepo(), meet: map[string]chan struct{}{},
+		order: map[string][]string{}, active: map[string]int{}}
+	for _, author := range authors {
+		r.meet[author] = make(chan struct{})
+	}
+	return r
+}
+
+func (r *recordingRepo) SaveWork(ctx context.Context, work domain.Work, opts storage.SaveOptions) (storage.SaveOutcome, error) {
+	author := saveKey(work)
+	r.mu.Lock()
+	r.order[author] = append(r.order[author], work.ID)
+	first := len(r.order[author]) == 1
+	if r.active[author]++; r.active[author] > 1 {
+		r.overlaps = append(r.overlaps, author)
+	}
+	r.mu.Unlock()
+
+	if first {
+		close(r.meet[author])
+		for _, other := range r.meet {
+			select {
+			case <-other:
+			case <-time.After(2 * time.Second):
+				r.mu.Lock()
+				r.apart = append(r.apart, author)
+				r.mu.Unlock()
+			}
+		}
+	}
+	time.Sleep(100 * time.Microsecond) // Long enough for interleaving to show.
+
+	r.mu.Lock()
+	r.active[author]--
+	r.mu.Unlock()
+	return r.fakeRepo.SaveWork(ctx, work, opts)
+}
+
+// authorsOnDistinctShards returns two author IDs the pool writes on different shards.
+func authorsOnDistinctShards(t *testing.T, p *savePool) (string, string) {
+	t.Helper()
+	first := "https://openalex.org/A1"
+	for i := 2; i < 100; i++ {
+		other := fmt.Sprintf("https://openalex.org/A%d", i)
+		if p.shard(other) != p.shard(first) {
+			return first, other
+		}
+	}
+	t.Fatal("no two authors on different shards")
+	return "", ""
+}
+
+// A query ingest over two authors' interleaved works saves each author's works in order,
+// one at a time, while the two authors are saved in parallel.
+func TestQueryIngestOrdersSavesPerAuthor(t *testing.T) {
+	const perAuthor = 30
+	h := newTestHandler(nil, func(cfg *config.Config) { cfg.MaxQueryIngestWorks = 1000 })
+	a1, a2 := authorsOnDistinctShards(t, h.saves)
+	repo := newRecordingRepo(a1, a2)
+	h.repo = repo
+
+	var results, want1, want2 []string
+	for i := 0; i < perAuthor; i++ {
+		for _, author := range []string{a1, a2} {
+			id := fmt.Sprintf("https://openalex.org/W%d", len(results)+1)
+			results = append(results, fmt.Sprintf(`{"id": "%s", "title": "%s", "type": "article",
+				"authorships": [{"author": {"id": "%s"}}, {"author": {"id": "https://openalex.org/A999"}}]}`, id, id, author))
+			if author == a1 {
+				want1 = append(want1, id)
+			} else {
+				want2 = append(want2, id)
+			}
+		}
+	}
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"meta": {"count": %d, "next_cursor": null}, "results": [%s]}`, len(results), strings.Join(results, ","))
+	})
+
+	rec := httptest.NewRecorder()
+	h.IngestQueryHandler(rec, httptest.NewRequest(http.MethodPost, "/api/ingest/query", strings.NewReader(`{"filter": "publication_year:2023"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202: %s", rec.Code, rec.Body)
+	}
+	h.jobs.wg.Wait()
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if !reflect.DeepEqual(repo.order[a1], want1) || !reflect.DeepEqual(repo.order[a2], want2) {
+		t.Errorf("save order = %v, want each author's works in the order they were fetched", repo.order)
+	}
+	if len(repo.overlaps) > 0 {
+		t.Errorf("saves of %v overlapped", repo.overlaps)
+	}
+	if len(repo.apart) > 0 {
+		t.Errorf("saves of %v waited for the other author's, want the authors saved in parallel", repo.apart)
+	}
+	for _, event := range repo.events {
+		if event.Status != storage.IngestStatusCompleted || event.WorksSaved != 2*perAuthor {
+			t.Errorf("job = %s with %d works saved, want completed with %d", event.Status, event.WorksSaved, 2*perAuthor)
+		}
+	}
+}
+
+func TestSaveKey(t *testing.T) {
+	tests := []struct {
+		name string
+		work domain.Work
+		want string
+	}{
+		{"first author", domain.Work{ID: "W1", Authorships: []domain.Authorship{
+			{Author: domain.DehydratedAuthor{ID: "A1"}}, {Author: domain.DehydratedAuthor{ID: "A2"}}}}, "A1"},
+		{"first author with an id", domain.Work{ID: "W1", Authorships: []domain.Authorship{
+			{Author: domain.DehydratedAuthor{DisplayName: "Anonymous"}}, {Author: domain.DehydratedAuthor{ID: "A2"}}}}, "A2"},
+		{"no authors", domain.Work{ID: "W1"}, "W1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := saveKey(tt.work); got != tt.want {
+				t.Errorf("saveKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSavePoolShard(t *testing.T) {
+	p := newSavePool(8)
+	if p.shard("A1") != p.shard("https://openalex.org/A1") {
+		t.Error("bare and URL forms of an ID are on different shards")
+	}
+	used := map[int]bool{}
+	for i := 0; i < 200; i++ {
+		shard := p.shard(fmt.Sprintf("A%d", i))
+		if shard < 0 || shard >= 8 {
+			t.Fatalf("shard %d out of range", shard)
+		}
+		used[shard] = true
+	}
+	if len(used) != 8 {
+		t.Errorf("200 authors use %d of 8 shards", len(used))
+	}
+}
+
+func TestSavePoolTasks(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		fn      func(ctx context.Context) error
+		wantErr string
+		wantRun bool
+	}{
+		{name: "succeeds", ctx: context.Background(), fn: func(ctx context.Context) error { return nil }, wantRun: true},
+		{name: "fails", ctx: context.Background(), fn: func(ctx context.Context) error { return errors.New("write failed") }, wantErr: "write failed", wantRun: true},
+		{name: "panics", ctx: context.Background(), fn: func(ctx context.Context) error { panic("boom") }, wantErr: "panic: boom", wantRun: true},
+		{name: "cancelled", ctx: cancelled, fn: func(ctx context.Context) error { return nil }, wantErr: "context canceled"},
+	}
+	p := newSavePool(1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			err := p.do(tt.ctx, "A1", func(ctx context.Context) error {
+				ran = true
+				return tt.fn(ctx)
+			})
+			if ran != tt.wantRun {
+				t.Errorf("ran = %v, want %v", ran, tt.wantRun)
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	// The worker survives the panic.
+	if err := p.do(context.Background(), "A1", func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("write after a panic: %v", err)
+	}
+}
+
+func TestSavePoolStats(t *testing.T) {
+	p := newSavePool(2)
+	key, _ := authorsOnDistinctShards(t, p)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var done sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		done.Add(1)
+		p.submit(context.Background(), key, func(ctx context.Context) error {
+			if i == 0 {
+				close(started)
+			}
+			<-release
+			return nil
+		}, func(error) { done.Done() })
+	}
+	<-started
+
+	stats := p.stats()
+	depths := stats["queueDepths"].([]int)
+	if stats["shards"] != 2 || depths[p.shard(key)] != 3 || depths[1-p.shard(key)] != 0 {
+		t.Errorf("stats = %v, want 3 writes queued behind the running one on %s's shard", stats, key)
+	}
+	if busy := stats["busy"].(int64); busy < 1 {
+		t.Errorf("busy = %d, want the running write counted", busy)
+	}
+	close(release)
+	done.Wait()
+	if depths := p.stats()["queueDepths"].([]int); depths[0] != 0 || depths[1] != 0 {
+		t.Errorf("queue depths after the writes = %v, want none queued", depths)
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// maxSearchLimit caps the hits returned per entity type by /api/search.
+const maxSearchLimit = 25
+
+// searchTypes are the entity types /api/search knows, in display order.
+var searchTypes = []string{"authors", "works", "institutions"}
+
+// searchFuncs returns the search of each of searchTypes, mapped to search hits. Retracted
+// works are left out unless includeRetracted is set.
+func (h *APIHandler) searchFuncs(includeRetracted bool) map[string]func(ctx context.Context, q string, limit int) ([]dto.SearchHit, error) {
+	return map[string]func(ctx context.Context, q string, limit int) ([]dto.SearchHit, error){
+		"authors": func(ctx context.Context, q string, limit int) ([]dto.SearchHit, error) {
+			authors, err := h.alexClient.SearchAuthors(ctx, q, limit)
+			hits := make([]dto.SearchHit, 0, len(authors))
+			for _, a := range authors {
+				hits = append(hits, dto.NewAuthorHit(a))
+			}
+			return hits, err
+		},
+		"works": func(ctx context.Context, q string, limit int) ([]dto.SearchHit, error) {
+			var filters []string
+			if !includeRetracted {
+				filters = append(filters, openalex.NotRetractedFilter)
+			}
+			works, err := h.alexClient.SearchWorks(ctx, q, limit, filters...)
+			hits := make([]dto.SearchHit, 0, len(works))
+			for _, work := range works {
+				hits = append(hits, dto.NewWorkHit(work))
+			}
+			return hits, err
+		},
+		"institutions": func(ctx context.Context, q string, limit int) ([]dto.SearchHit, error) {
+			institutions, err := h.alexClient.SearchInstitutions(ctx, q, limit)
+			hits := make([]dto.SearchHit, 0, len(institutions))
+			for _, inst := range institutions {
+				hits = append(hits, dto.NewInstitutionHit(inst))
+			}
+			return hits, err
+		},
+	}
+}
+
+// SearchHandler backs a global search box: it searches OpenAlex authors, works and
+// institutions concurrently and returns the hits grouped by type. The whole search is
+// bounded by SEARCH_TIMEOUT; types that haven't answered by then are cancelled and
+// reported with timedOut, and a failing type is reported with its error, while the other
+// types' results are still returned.
+// Query parameters: q (required), types (comma-separated, default all) and limit (1-25,
+// default 5) hits per type, and include_retracted to also return retracted works.
+func (h *APIHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing 'q' query parameter")
+		return
+	}
+	limit := 5
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("'limit' must be an integer between 1 and %d", maxSearchLimit))
+			return
+		}
+		limit = n
+	}
+	includeRetracted, err := includeRetractedParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	funcs := h.searchFuncs(includeRetracted)
+	types := searchTypes
+	if raw := r.URL.Query().Get("types"); raw != "" {
+		types = nil
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.TrimSpace(t)
+			if _, ok := funcs[t]; !ok {
+				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown type %q in 'types'; use %s", t, strings.Join(searchTypes, ", ")))
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.cfg.SearchTimeout)
+	defer cancel()
+
+	type searchOutcome struct {
+		kind string
+		hits []dto.SearchHit
+		err  error
+	}
+	// Buffered so searches finishing after the deadline don't block.
+	outcomes := make(chan searchOutcome, len(types))
+	for _, kind := range types {
+		go func(kind string) {
+			hits, err := funcs[kind](ctx, q, limit)
+			outcomes <- searchOutcome{kind: kind, hits: hits, err: err}
+		}(kind)
+	}
+
+	groups := make(map[string]*dto.SearchGroup, len(types))
+	for _, kind := range types {
+		groups[kind] = &dto.SearchGroup{Results: []dto.SearchHit{}}
+	}
+	received := make(map[string]bool, len(types))
+collect:
+	for len(received) < len(types) {
+		select {
+		case outcome := <-outcomes:
+			received[outcome.kind] = true
+			group := groups[outcome.kind]
+			switch {
+			// Errors caused by the deadline (including the rate limiter refusing to wait
+			// past it) are timeouts, not failures.
+			case outcome.err != nil && ctx.Err() != nil:
+				group.TimedOut = true
+			case outcome.err != nil:
+				log.Printf("WARN: Search of %s for %q failed: %v", outcome.kind, q, outcome.err)
+				group.Error = outcome.err.Error()
+			default:
+				group.Results = outcome.hits
+			}
+		case <-ctx.Done():
+			break collect
+		}
+	}
+	for kind, group := range groups {
+		if !received[kind] {
+			group.TimedOut = true
+		}
+	}
+
+	response := map[string]interface{}{"query": q}
+	for kind, group := range groups {
+		response[kind] = group
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// SearchGraphAuthorsHandler searches the authors already in the graph by name, matching
+// their display name as well as their alternative names (aliases, transliterations).
+// Query parameters: q (required) and limit (1-25, default 10).
+func (h *APIHandler) SearchGraphAuthorsHandler(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing 'q' query parameter")
+		return
+	}
+	limit := 10
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("'limit' must be an integer between 1 and %d", maxSearchLimit))
+			return
+		}
+		limit = n
+	}
+	fields, err := fieldsParam(r, storage.AuthorMatch{})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	authors, err := h.repo.SearchAuthors(r.Context(), q, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, fields.project(authors))
+}
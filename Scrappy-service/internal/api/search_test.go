@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/config"
+)
+
+// searchUpstream fakes the OpenAlex search endpoints. Entities in slow answer only after
+// their request is cancelled, which cancelled records; those in failing answer 500.
+type searchUpstream struct {
+	mu        sync.Mutex
+	slow      map[string]bool
+	failing   map[string]bool
+	cancelled map[string]bool
+}
+
+func (u *searchUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	entity := strings.Trim(r.URL.Path, "/")
+	u.mu.Lock()
+	slow, failing := u.slow[entity], u.failing[entity]
+	u.mu.Unlock()
+	switch {
+	case slow:
+		select {
+		case <-r.Context().Done():
+			u.mu.Lock()
+			u.cancelled[entity] = true
+			u.mu.Unlock()
+		case <-time.After(5 * time.Second):
+		}
+		return
+	case failing:
+		http.Error(w, "upstream broke", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, `{"meta": {"count": 1}, "results": [{"id": "https://openalex.org/%s1", "display_name": "%s hit", "title": "%s hit"}]}`,
+		strings.ToUpper(entity[:1]), entity, entity)
+}
+
+func TestSearchHandlerPartialResults(t *testing.T) {
+	upstream := &searchUpstream{}
+	fakeOpenAlex(t, upstream.ServeHTTP)
+	h := newTestHandler(newFakeRepo(), func(cfg *config.Config) { cfg.SearchTimeout = 100 * time.Millisecond })
+
+	tests := []struct {
+		name          string
+		query         string
+		slow          []string
+		failing       []string
+		wantResults   []string
+		wantTimedOut  []string
+		wantFailed    []string
+		wantCancelled []string
+	}{
+		{
+			name:        "all answer",
+			query:       "q=graphs",
+			wantResults: []string{"authors", "works", "institutions"},
+		},
+		{
+			name:          "one slow, one failing",
+			query:         "q=graphs",
+			slow:          []string{"authors"},
+			failing:       []string{"institutions"},
+			wantResults:   []string{"works"},
+			wantTimedOut:  []string{"authors"},
+			wantFailed:    []string{"institutions"},
+			wantCancelled: []string{"authors"},
+		},
+		{
+			name:          "all slow",
+			query:         "q=graphs",
+			slow:          []string{"authors", "works", "institutions"},
+			wantTimedOut:  []string{"authors", "works", "institutions"},
+			wantCancelled: []string{"authors", "works", "institutions"},
+		},
+		{
+			name:        "selected types",
+			query:       "q=graphs&types=works,%20institutions",
+			slow:        []string{"authors"},
+			wantResults: []string{"works", "institutions"},
+		},
+	}
+	set := func(kinds []string) map[string]bool {
+		m := make(map[string]bool)
+		for _, kind := range kinds {
+			m[kind] = true
+		}
+		return m
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream.mu.Lock()
+			upstream.slow, upstream.failing, upstream.cancelled = set(tt.slow), set(tt.failing), map[string]bool{}
+			upstream.mu.Unlock()
+
+			started := time.Now()
+			rec := httptest.NewRecorder()
+			h.SearchHandler(rec, httptest.NewRequest(http.MethodGet, "/api/search?"+tt.query, nil))
+			if elapsed := time.Since(started); elapsed > time.Second {
+				t.Errorf("search took %v, want it cut off at the 100ms deadline", elapsed)
+			}
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if want := len(tt.wantResults) + len(tt.wantTimedOut) + len(tt.wantFailed) + 1; len(body) != want {
+				t.Errorf("response has %d keys, want %d (query and one per type): %s", len(body), want, rec.Body)
+			}
+
+			group := func(kind string) dto.SearchGroup {
+				var g dto.SearchGroup
+				if err := json.Unmarshal(body[kind], &g); err != nil {
+					t.Fatalf("%s: %v in %s", kind, err, rec.Body)
+				}
+				return g
+			}
+			for _, kind := range tt.wantResults {
+				if g := group(kind); len(g.Results) != 1 || g.TimedOut || g.Error != "" {
+					t.Errorf("%s = %+v, want its one hit", kind, g)
+				}
+			}
+			for _, kind := range tt.wantTimedOut {
+				if g := group(kind); !g.TimedOut || len(g.Results) != 0 || g.Results == nil {
+					t.Errorf("%s = %+v, want it timed out with an empty result list", kind, g)
+				}
+			}
+			for _, kind := range tt.wantFailed {
+				if g := group(kind); g.Error == "" || g.TimedOut || len(g.Results) != 0 {
+					t.Errorf("%s = %+v, want its error", kind, g)
+				}
+			}
+
+			// The outstanding requests are cancelled at the deadline.
+			deadline := time.Now().Add(time.Second)
+			for _, kind := range tt.wantCancelled {
+				for {
+					upstream.mu.Lock()
+					cancelled := upstream.cancelled[kind]
+					upstream.mu.Unlock()
+					if cancelled {
+						break
+					}
+					if time.Now().After(deadline) {
+						t.Errorf("the %s request was not cancelled", kind)
+						break
+					}
+					time.Sleep(5 * time.Millisecond)
+				}
+			}
+		})
+	}
+}
+
+// Work hits are flagged retracted or not, and retracted works are filtered out at OpenAlex
+// unless include_retracted=true. The other entity types aren't filtered.
+func TestSearchHandlerRetractedWorks(t *testing.T) {
+	var mu sync.Mutex
+	filters := map[string]string{}
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		entity := strings.Trim(r.URL.Path, "/")
+		mu.Lock()
+		filters[entity] = r.URL.Query().Get("filter")
+		mu.Unlock()
+		if entity != "works" {
+			fmt.Fprint(w, `{"meta": {"count": 0}, "results": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"meta": {"count": 1}, "results": [{"id": "https://openalex.org/W1", "title": "retracted", "is_retracted": true}]}`)
+	})
+	h := newTestHandler(newFakeRepo(), func(cfg *config.Config) { cfg.SearchTimeout = 5 * time.Second })
+
+	tests := []struct {
+		query      string
+		wantFilter string
+	}{
+		{"q=graphs", "is_retracted:false"},
+		{"q=graphs&include_retracted=false", "is_retracted:false"},
+		{"q=graphs&include_retracted=true", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			mu.Lock()
+			clear(filters)
+			mu.Unlock()
+			rec := httptest.NewRecorder()
+			h.SearchHandler(rec, httptest.NewRequest(http.MethodGet, "/api/search?"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			want := map[string]string{"authors": "", "works": tt.wantFilter, "institutions": ""}
+			if !reflect.DeepEqual(filters, want) {
+				t.Errorf("OpenAlex filters = %v, want %v", filters, want)
+			}
+			var body struct {
+				Works dto.SearchGroup `json:"works"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if hits := body.Works.Results; len(hits) != 1 || hits[0].IsRetracted == nil || !*hits[0].IsRetracted {
+				t.Errorf("work hits = %+v, want W1 flagged retracted", hits)
+			}
+		})
+	}
+}
+
+func TestSearchHandlerValidation(t *testing.T) {
+	h := newTestHandler(newFakeRepo())
+	for _, query := range []string{"", "q=%20", "q=x&limit=0", "q=x&limit=26", "q=x&limit=many", "q=x&types=authors,venues", "q=x&include_retracted=maybe"} {
+		rec := httptest.NewRecorder()
+		h.SearchHandler(rec, httptest.NewRequest(http.MethodGet, "/api/search?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// maxSimilarityCandidates bounds how many topic-sharing works a similarity query compares.
+const maxSimilarityCandidates = 5000
+
+// EnrichEmbeddingsHandler fetches SPECTER embeddings from Semantic Scholar for up to limit
+// (1-500, default 100) works that have a DOI but no embedding yet, and stores them on the
+// Work nodes. Works Semantic Scholar has no embedding for are counted as notFound.
+func (h *APIHandler) EnrichEmbeddingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > semanticscholar.MaxBatchSize {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("'limit' must be an integer between 1 and %d", semanticscholar.MaxBatchSize))
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	works, err := h.repo.GetWorksMissingEmbedding(ctx, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(works) == 0 {
+		respondWithJSON(w, http.StatusOK, map[string]int{"requested": 0, "saved": 0, "notFound": 0})
+		return
+	}
+
+	ids := make([]semanticscholar.PaperID, len(works))
+	for i, work := range works {
+		ids[i] = semanticscholar.PaperID{Kind: semanticscholar.KindDOI, Value: work.Doi}
+	}
+	papers, err := h.semClient.FetchEmbeddings(ctx, ids)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, fmt.Sprintf("Failed to fetch embeddings from Semantic Scholar: %v", err))
+		return
+	}
+
+	saved, notFound := 0, 0
+	for i, work := range works {
+		paper, ok := papers[ids[i]]
+		if !ok || paper.Embedding == nil || len(paper.Embedding.Vector) == 0 {
+			notFound++
+			continue
+		}
+		if err := h.repo.SaveWorkEmbedding(ctx, work.ID, paper.Embedding.Vector); err != nil {
+			log.Printf("WARN: Could not save embedding of work %s: %v", work.ID, err)
+			continue
+		}
+		saved++
+	}
+	respondWithJSON(w, http.StatusOK, map[string]int{"requested": len(works), "saved": saved, "notFound": notFound})
+}
+
+// GetSimilarWorksHandler ranks the works sharing a topic with the given work by the cosine
+// similarity of their SPECTER embeddings, most similar first. Candidates without an
+// embedding are skipped and counted. Query parameters: id (required), limit (1-100,
+// default 10) and include_retracted to also rank retracted works.
+func (h *APIHandler) GetSimilarWorksHandler(w http.ResponseWriter, r *http.Request) {
+	workID, err := openalex.ValidateID(r.URL.Query().Get("id"), 'W')
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := 10
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			respondWithError(w, http.StatusBadRequest, "'limit' must be an integer between 1 and 100")
+			return
+		}
+		limit = n
+	}
+	includeRetracted, err := includeRetractedParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fields, err := fieldsParam(r, dto.SimilarWork{})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	workID = h.resolveWorkID(ctx, workID)
+	candidates, err := h.repo.GetSimilarityCandidates(ctx, workID, maxSimilarityCandidates)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Work is not in the graph")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if candidates.Target == nil {
+		respondWithError(w, http.StatusConflict, "Work has no embedding yet; fetch it with POST /api/works/enrich-embeddings")
+		return
+	}
+
+	works := candidates.Candidates
+	if !includeRetracted {
+		works = make([]storage.EmbeddedWork, 0, len(candidates.Candidates))
+		for _, work := range candidates.Candidates {
+			if !work.IsRetracted {
+				works = append(works, work)
+			}
+		}
+	}
+	similar, mismatched := rankBySimilarity(candidates.Target, works)
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                      workID,
+		"similar":                 fields.project(similar),
+		"candidates":              len(candidates.Candidates) + candidates.Skipped,
+		"skippedWithoutEmbedding": candidates.Skipped + mismatched,
+	})
+}
+
+// rankBySimilarity scores works by cosine similarity to target, highest first. Works whose
+// embedding has a different dimension (another model) can't be compared; they are
+// left out and counted.
+func rankBySimilarity(target []float32, works []storage.EmbeddedWork) ([]dto.SimilarWork, int) {
+	ranked := make([]dto.SimilarWork, 0, len(works))
+	mismatched := 0
+	for _, work := range works {
+		if len(work.Embedding) != len(target) {
+			mismatched++
+			continue
+		}
+		ranked = append(ranked, dto.SimilarWork{DehydratedWork: work.DehydratedWork, Similarity: cosineSimilarity(target, work.Embedding)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Similarity > ranked[j].Similarity })
+	return ranked, mismatched
+}
+
+// cosineSimilarity of two vectors of the same length; 0 if either is all zeros.
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
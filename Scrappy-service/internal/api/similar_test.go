@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"scaled", []float32{1, 2, 3}, []float32{2, 4, 6}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"opposite", []float32{1, -1}, []float32{-1, 1}, -1},
+		{"45 degrees", []float32{1, 0}, []float32{1, 1}, 1 / math.Sqrt2},
+		{"zero vector", []float32{0, 0}, []float32{1, 1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("cosineSimilarity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// embedded returns a candidate work with the given embedding.
+func embedded(id string, vec ...float32) storage.EmbeddedWork {
+	return storage.EmbeddedWork{DehydratedWork: domain.DehydratedWork{ID: id, Title: "title of " + id}, Embedding: vec}
+}
+
+func TestRankBySimilarity(t *testing.T) {
+	target := []float32{1, 0, 0}
+	works := []storage.EmbeddedWork{
+		embedded("W-orthogonal", 0, 1, 0),
+		embedded("W-close", 0.9, 0.1, 0),
+		embedded("W-other-model", 1, 0),
+		embedded("W-same", 2, 0, 0),
+		embedded("W-opposite", -1, 0, 0),
+		embedded("W-between", 1, 1, 0),
+	}
+
+	ranked, mismatched := rankBySimilarity(target, works)
+	var ids []string
+	for _, work := range ranked {
+		ids = append(ids, work.ID)
+	}
+	if want := []string{"W-same", "W-close", "W-between", "W-orthogonal", "W-opposite"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ranking = %v, want %v", ids, want)
+	}
+	if mismatched != 1 {
+		t.Errorf("mismatched = %d, want 1 (the embedding of another dimension)", mismatched)
+	}
+	if got := ranked[0].Similarity; math.Abs(got-1) > 1e-6 {
+		t.Errorf("similarity of W-same = %v, want 1", got)
+	}
+}
+
+func TestGetSimilarWorksHandler(t *testing.T) {
+	retracted := embedded("https://openalex.org/W4", 1, 0.05)
+	retracted.IsRetracted = true
+	repo := newFakeRepo()
+	repo.similarity = map[string]*storage.SimilarityCandidates{
+		"https://openalex.org/W1": {
+			Target: []float32{1, 0},
+			Candidates: []storage.EmbeddedWork{
+				embedded("https://openalex.org/W2", 0, 1),
+				embedded("https://openalex.org/W3", 1, 0.2),
+				retracted,
+				embedded("https://openalex.org/W5", 1, 0.5, 0),
+			},
+			Skipped: 2,
+		},
+		"https://openalex.org/W9": {Skipped: 3},
+	}
+	h := newTestHandler(repo)
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantIDs     []string
+		wantSkipped int
+	}{
+		{"ranked", "id=W1", http.StatusOK, []string{"W3", "W2"}, 3},
+		{"with retracted", "id=W1&include_retracted=true", http.StatusOK, []string{"W4", "W3", "W2"}, 3},
+		{"limited", "id=W1&limit=1", http.StatusOK, []string{"W3"}, 3},
+		{"no embedding", "id=W9", http.StatusConflict, nil, 0},
+		{"not in the graph", "id=W404", http.StatusNotFound, nil, 0},
+		{"missing id", "", http.StatusBadRequest, nil, 0},
+		{"not a work", "id=A1", http.StatusBadRequest, nil, 0},
+		{"limit too large", "id=W1&limit=101", http.StatusBadRequest, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.GetSimilarWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/works/similar?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				Similar []struct {
+					ID         string  `json:"id"`
+					Similarity float64 `json:"similarity"`
+				} `json:"similar"`
+				Candidates int `json:"candidates"`
+				Skipped    int `json:"skippedWithoutEmbedding"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			var ids []string
+			for _, work := range body.Similar {
+				ids = append(ids, strings.TrimPrefix(work.ID, "https://openalex.org/"))
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("similar = %v, want %v", ids, tt.wantIDs)
+			}
+			if body.Candidates != 6 || body.Skipped != tt.wantSkipped {
+				t.Errorf("candidates = %d, skipped = %d, want 6 and %d", body.Candidates, body.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestEnrichEmbeddingsHandler(t *testing.T) {
+	var fields string
+	fakeOpenAlex(t, func(w http.ResponseWriter, r *http.Request) {
+		fields = r.URL.Query().Get("fields")
+		body, _ := io.ReadAll(r.Body)
+		var request struct {
+			IDs []string `json:"ids"`
+		}
+		json.Unmarshal(body, &request)
+		results := make([]string, len(request.IDs))
+		for i, id := range request.IDs {
+			switch id {
+			case "DOI:10.1/unknown":
+				results[i] = "null"
+			case "DOI:10.1/no-embedding":
+				results[i] = `{"paperId": "p", "embedding": null}`
+			default:
+				results[i] = fmt.Sprintf(`{"paperId": "p%d", "embedding": {"model": "specter_v2", "vector": [%d, 0.5]}}`, i, i)
+			}
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(results, ","))
+	})
+	repo := newFakeRepo()
+	repo.missingEmbedding = []domain.DehydratedWork{
+		{ID: "https://openalex.org/W1", Doi: "https://doi.org/10.1/one"},
+		{ID: "https://openalex.org/W2", Doi: "10.1/unknown"},
+		{ID: "https://openalex.org/W3", Doi: "10.1/no-embedding"},
+		{ID: "https://openalex.org/W4", Doi: "10.1/four"},
+	}
+	h := newTestHandler(repo)
+
+	rec := httptest.NewRecorder()
+	h.EnrichEmbeddingsHandler(rec, httptest.NewRequest(http.MethodPost, "/api/works/enrich-embeddings", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var body map[string]int
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if want := map[string]int{"requested": 4, "saved": 2, "notFound": 2}; !reflect.DeepEqual(body, want) {
+		t.Errorf("response = %v, want %v", body, want)
+	}
+	if !strings.Contains(fields, "embedding") {
+		t.Errorf("fields = %q, want the embedding requested", fields)
+	}
+	want := map[string][]float32{"https://openalex.org/W1": {0, 0.5}, "https://openalex.org/W4": {3, 0.5}}
+	if !reflect.DeepEqual(repo.embeddings, want) {
+		t.Errorf("saved embeddings = %v, want %v", repo.embeddings, want)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/works/enrich-embeddings", nil),
+		httptest.NewRequest(http.MethodPost, "/api/works/enrich-embeddings?limit=0", nil),
+		httptest.NewRequest(http.MethodPost, "/api/works/enrich-embeddings?limit=501", nil),
+	} {
+		rec := httptest.NewRecorder()
+		h.EnrichEmbeddingsHandler(rec, req)
+		if rec.Code < 400 || rec.Code >= 500 {
+			t.Errorf("%s %s: status = %d, want a client error", req.Method, req.URL, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+import "net/http"
+
+// RequireStorage wraps handlers that read or write the graph. With STORAGE_BACKEND=none
+// they answer 501 instead of failing on the disabled repository.
+func (h *APIHandler) RequireStorage(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.storageDisabled(w) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// storageDisabled answers 501 and returns true when the service runs without a database.
+// Handlers that only touch the graph for some options call it on those paths.
+func (h *APIHandler) storageDisabled(w http.ResponseWriter) bool {
+	if !h.cfg.StorageDisabled() {
+		return false
+	}
+	respondWithError(w, http.StatusNotImplemented,
+		"Storage is disabled (STORAGE_BACKEND=none): this service only proxies OpenAlex, so it can't ingest or read the graph")
+	return true
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// sseWriter writes Server-Sent Events to a response.
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	return &sseWriter{w: w, flusher: flusher}, true
+}
+
+// send writes one event with a JSON payload and flushes it to the client.
+func (s *sseWriter) send(event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("WARN: Could not encode %s event: %v", event, err)
+		return
+	}
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data)
+	s.flusher.Flush()
+}
+
+// StreamAuthorIngestHandler ingests an author and all of their works like
+// FetchAndSaveWorksByAuthorHandler, but within the request, streaming a "progress" event
+// ({"saved": n, "failed": f, "total": m}) after every work and a final "done" or "error"
+// event. If the client disconnects, ingestion stops and the job is recorded as failed.
+func (h *APIHandler) StreamAuthorIngestHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+	filter, err := h.workFilterFor(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if h.rejectIfBlocked(r.Context(), w, authorID) {
+		return
+	}
+	stream, ok := newSSEWriter(w)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming is not supported by this connection")
+		return
+	}
+
+	ctx := r.Context()
+	job := h.startIngestJob(ctx, "author", canonicalOpenAlexID(authorID), requestedBy(r))
+	defer job.finishOnPanic(true)
+
+	// The response status is already sent, so the status the error maps to goes in the event.
+	fail := func(status int, message string, err error) {
+		job.finish(ctx, err)
+		stream.send("error", map[string]interface{}{"error": fmt.Sprintf("%s: %v", message, err), "status": status})
+	}
+
+	author, err := h.alexClient.FetchAuthorById(authorID)
+	if err != nil {
+		fail(openAlexErrorStatus(err), "Failed to fetch author from OpenAlex", err)
+		return
+	}
+	if !sameOpenAlexID(authorID, author.ID) {
+		log.Printf("REDIRECT: OpenAlex author %s has been merged into %s", authorID, author.ID)
+		if err := h.repo.RecordAuthorMerge(ctx, canonicalOpenAlexID(authorID), author.ID); err != nil {
+			log.Printf("WARN: Could not record merge of author %s into %s: %v", authorID, author.ID, err)
+		}
+		job.retarget(author.ID)
+		if blocked, reason, err := h.repo.IsBlocked(ctx, author.ID); err == nil && blocked {
+			fail(http.StatusForbidden, "Author is blocked from ingestion", fmt.Errorf("%s: %s", author.ID, reason))
+			return
+		}
+	}
+	if err := h.saves.do(ctx, author.ID, func(ctx context.Context) error { return h.repo.SaveAuthor(ctx, author) }); err != nil {
+		fail(http.StatusInternalServerError, "Failed to save author to database", err)
+		return
+	}
+
+	fetchedAt := time.Now().UTC()
+	works, warnings, err := h.alexClient.FetchAllWorksByAuthorID(author.ID)
+	if err != nil {
+		fail(http.StatusInternalServerError, "Failed to fetch works from OpenAlex", err)
+		return
+	}
+	logDecodeWarnings("author "+author.ID, warnings)
+	job.decodeWarnings(warnings)
+	works, skipped := filter.apply(works)
+	works, existing := filter.dropExisting(ctx, h.repo, works)
+	skipped += existing
+
+	saved, failed := 0, 0
+	stream.send("progress", map[string]int{"saved": 0, "failed": 0, "total": len(works), "skipped": skipped})
+	for _, work := range works {
+		select {
+		case <-ctx.Done():
+			log.Printf("Client disconnected; stopping streamed ingest of author %s after %d works.", author.ID, saved)
+			job.finish(ctx, ctx.Err())
+			return
+		default:
+		}
+
+		outcome, err := h.saveAuthorWork(ctx, author.ID, work, filter.save)
+		job.workSaved(work, outcome, err)
+		if err != nil {
+			failed++
+			log.Printf("WARN: Could not save work %s: %v", work.Title, err)
+		} else {
+			saved++
+		}
+		stream.send("progress", map[string]int{"saved": saved, "failed": failed, "total": len(works), "skipped": skipped})
+	}
+
+	if err := h.repo.MarkAuthorFullyIngested(ctx, author.ID); err != nil {
+		log.Printf("WARN: Could not set fullyIngested flag for author %s: %v", author.ID, err)
+	}
+	h.recordWorksSync(ctx, author.ID, fetchedAt)
+	job.finish(ctx, nil)
+	done := map[string]interface{}{"id": author.ID, "saved": saved, "failed": failed, "total": len(works), "skipped": skipped}
+	if !sameOpenAlexID(authorID, author.ID) {
+		done["canonicalId"] = author.ID
+	}
+	if failures := job.failures(); len(failures) > 0 {
+		done["failedWorks"] = failures
+	}
+	addDecodeWarnings(done, warnings)
+	stream.send("done", done)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// recordWorksSync stores when an author's works were last fetched in full, so later syncs
+// only need what changed since then. Failing to record it only makes the next sync larger.
+func (h *APIHandler) recordWorksSync(ctx context.Context, authorID string, fetchedAt time.Time) {
+	if err := h.repo.SetAuthorWorksSynced(ctx, authorID, fetchedAt); err != nil {
+		log.Printf("WARN: Could not record works sync of author %s: %v", authorID, err)
+	}
+}
+
+// SyncAuthorWorksHandler incrementally refreshes an ingested author (POST ?id=...): only the
+// works OpenAlex created or updated since the author's last sync are fetched and saved, and
+// the sync time is moved forward. It reports how many works were new to the graph, how
+// many existing ones were updated, and how many were unchanged since they were last saved
+// and so not written again. Authors that were never fully ingested get 409, since
+// there is nothing to sync from.
+func (h *APIHandler) SyncAuthorWorksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+	filter, err := h.workFilterFor(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	if h.rejectIfBlocked(ctx, w, authorID) {
+		return
+	}
+	id := h.resolveAuthorID(ctx, authorID)
+	lastSync, err := h.repo.GetAuthorWorksSynced(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Author is not in the graph; ingest it first")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if lastSync.IsZero() {
+		respondWithError(w, http.StatusConflict, "Author's works were never fully ingested; ingest the author first")
+		return
+	}
+
+	job := h.startIngestJob(ctx, "sync", id, requestedBy(r))
+	defer job.finishOnPanic(true)
+
+	fetchedAt := time.Now().UTC()
+	works, warnings, err := h.alexClient.FetchWorksByAuthorUpdatedSince(ctx, id, lastSync)
+	if err != nil {
+		job.finish(ctx, err)
+		respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch updated works from OpenAlex: %v", err))
+		return
+	}
+	logDecodeWarnings("author "+id, warnings)
+	job.decodeWarnings(warnings)
+	works, skipped := filter.apply(works)
+
+	created, updated, unchanged := 0, 0, 0
+	for _, work := range works {
+		outcome, err := h.saveAuthorWork(ctx, id, work, filter.save)
+		job.workSaved(work, outcome, err)
+		if err != nil {
+			log.Printf("WARN: Could not save work %s: %v", work.Title, err)
+			continue
+		}
+		switch outcome {
+		case storage.SaveCreated:
+			created++
+		case storage.SaveUpdated:
+			updated++
+		default:
+			unchanged++
+		}
+	}
+
+	// With failed saves the sync time stays put, so the next sync retries those works.
+	failures := job.failures()
+	if len(works) == created+updated+unchanged {
+		h.recordWorksSync(ctx, id, fetchedAt)
+	}
+	job.finish(ctx, nil)
+
+	response := map[string]interface{}{
+		"id":           id,
+		"since":        lastSync.Format(time.RFC3339),
+		"created":      created,
+		"updated":      updated,
+		"unchanged":    unchanged,
+		"skippedWorks": skipped,
+	}
+	if len(failures) > 0 {
+		response["failedWorks"] = failures
+	}
+	addDecodeWarnings(response, warnings)
+	respondWithJSON(w, http.StatusOK, response)
+}
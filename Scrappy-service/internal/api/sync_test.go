@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// serveWorks answers works requests with one page of works with the given ids, and
+// records the filter of the last request.
+func serveWorks(filter *string, ids ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*filter = r.URL.Query().Get("filter")
+		results := make([]string, len(ids))
+		for i, id := range ids {
+			results[i] = fmt.Sprintf(`{"id": "https://openalex.org/%s", "title": "%s"}`, id, id)
+		}
+		fmt.Fprintf(w, `{"meta": {"count": %d, "next_cursor": null}, "results": [%s]}`, len(ids), strings.Join(results, ","))
+	}
+}
+
+func TestSyncAuthorWorksHandler(t *testing.T) {
+	lastSync := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	var filter string
+	fakeOpenAlex(t, serveWorks(&filter, "W1", "W2", "W3", "W4"))
+
+	tests := []struct {
+		name          string
+		synced        map[string]time.Time
+		outcomes      map[string]storage.SaveOutcome // SaveWork fails for works without one
+		wantStatus    int
+		wantCounts    [3]int // created, updated, unchanged
+		wantSyncMoved bool
+	}{
+		{
+			name:          "counts outcomes",
+			synced:        map[string]time.Time{"https://openalex.org/A1": lastSync},
+			outcomes:      map[string]storage.SaveOutcome{"W1": storage.SaveCreated, "W2": storage.SaveUpdated, "W3": storage.SaveUpdated, "W4": storage.SaveUnchanged},
+			wantStatus:    http.StatusOK,
+			wantCounts:    [3]int{1, 2, 1},
+			wantSyncMoved: true,
+		},
+		{
+			name:       "failed save keeps sync time",
+			synced:     map[string]time.Time{"https://openalex.org/A1": lastSync},
+			outcomes:   map[string]storage.SaveOutcome{"W1": storage.SaveCreated, "W2": storage.SaveCreated, "W3": storage.SaveCreated},
+			wantStatus: http.StatusOK,
+			wantCounts: [3]int{3, 0, 0},
+		},
+		{
+			name:       "never fully ingested",
+			synced:     map[string]time.Time{"https://openalex.org/A1": {}},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "not in the graph",
+			synced:     map[string]time.Time{},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter = ""
+			repo := newFakeRepo()
+			repo.synced = tt.synced
+			repo.saveOutcome = func(work domain.Work) (storage.SaveOutcome, error) {
+				outcome, ok := tt.outcomes[strings.TrimPrefix(work.ID, "https://openalex.org/")]
+				if !ok {
+					return "", errors.New("write failed")
+				}
+				return outcome, nil
+			}
+			h := newTestHandler(repo)
+
+			before := time.Now().UTC()
+			rec := httptest.NewRecorder()
+			h.SyncAuthorWorksHandler(rec, httptest.NewRequest(http.MethodPost, "/api/authors/sync?id=A1", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if filter != "" {
+					t.Errorf("OpenAlex was asked for %q", filter)
+				}
+				return
+			}
+
+			if want := "author.id:A1,from_updated_date:2024-03-01T12:30:00Z"; filter != want {
+				t.Errorf("filter = %q, want %q", filter, want)
+			}
+			var body struct {
+				Since     string `json:"since"`
+				Created   int    `json:"created"`
+				Updated   int    `json:"updated"`
+				Unchanged int    `json:"unchanged"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if got := [3]int{body.Created, body.Updated, body.Unchanged}; got != tt.wantCounts || body.Since != "2024-03-01T12:30:00Z" {
+				t.Errorf("response = %+v, want counts %v since 2024-03-01T12:30:00Z", body, tt.wantCounts)
+			}
+			synced := repo.synced["https://openalex.org/A1"]
+			if moved := !synced.Equal(lastSync); moved != tt.wantSyncMoved {
+				t.Errorf("sync time = %v, want it moved: %v", synced, tt.wantSyncMoved)
+			}
+			if tt.wantSyncMoved && synced.Before(before) {
+				t.Errorf("sync time = %v, want the time of the fetch", synced)
+			}
+		})
+	}
+}
+
+func TestSyncAuthorWorksHandlerRejectsGet(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHandler(newFakeRepo()).SyncAuthorWorksHandler(rec, httptest.NewRequest(http.MethodGet, "/api/authors/sync?id=A1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/tenant"
+)
+
+// WithTenant scopes every request to a tenant before handing it to next. The tenant comes
+// from the API key's configured scope (X-API-Key) or, in deployments without API keys, from
+// the X-Tenant header. Requests with neither use the shared namespace, so deployments that
+// don't configure tenants behave exactly as before. Once keys are configured, a tenant can
+// only be reached with its key: X-Tenant without one is refused. With REQUIRE_API_KEY every request
+// needs a key, except the /readyz and /metrics probes. Once API keys are configured, the
+// caller recorded by requestedBy is the key, not the X-User header.
+func (h *APIHandler) WithTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.ToLower(strings.TrimSpace(r.Header.Get("X-Tenant")))
+
+		key := r.Header.Get("X-API-Key")
+		if key == "" && h.cfg.RequireAPIKey && r.URL.Path != "/readyz" && r.URL.Path != "/metrics" {
+			respondWithError(w, http.StatusUnauthorized, "An X-API-Key is required")
+			return
+		}
+		if key == "" && name != "" && len(h.cfg.TenantAPIKeys) > 0 {
+			respondWithError(w, http.StatusUnauthorized, "An X-API-Key scoped to tenant "+name+" is required")
+			return
+		}
+		if key != "" {
+			scope, ok := h.cfg.TenantAPIKeys[key]
+			if !ok {
+				respondWithError(w, http.StatusUnauthorized, "Unknown API key")
+				return
+			}
+			if name != "" && name != scope {
+				respondWithError(w, http.StatusForbidden, "API key is not scoped to tenant "+name)
+				return
+			}
+			name = scope
+		}
+
+		if name != tenant.Shared && !tenant.Valid(name) {
+			respondWithError(w, http.StatusBadRequest, "Invalid tenant name")
+			return
+		}
+		ctx := tenant.WithTenant(r.Context(), name)
+		switch {
+		case key != "":
+			ctx = context.WithValue(ctx, actorKey{}, keyActor(key))
+		case len(h.cfg.TenantAPIKeys) > 0:
+			ctx = context.WithValue(ctx, actorKey{}, "anonymous")
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// actorKey is the context key of the caller WithTenant authenticated.
+type actorKey struct{}
+
+// keyActor identifies an API key without revealing it: key:<sha256 prefix>.
+func keyActor(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(sum[:8])
+}
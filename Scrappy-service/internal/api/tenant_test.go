@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/config"
+	"github.com/Cloudforge2/scrappy/internal/tenant"
+)
+
+func TestWithTenant(t *testing.T) {
+	keys := map[string]string{"key-a": "team-a", "key-b": "team-b"}
+	tests := []struct {
+		name       string
+		keys       map[string]string
+		requireKey bool
+		path       string
+		headers    map[string]string
+		wantStatus int
+		wantTenant string
+		wantActor  string
+	}{
+		{name: "shared by default", wantStatus: http.StatusOK, wantTenant: tenant.Shared, wantActor: "anonymous"},
+		{name: "user header without keys", headers: map[string]string{"X-User": "ada"}, wantStatus: http.StatusOK, wantActor: "ada"},
+		{name: "tenant header", headers: map[string]string{"X-Tenant": " Team-A "}, wantStatus: http.StatusOK, wantTenant: "team-a", wantActor: "anonymous"},
+		{name: "invalid tenant header", headers: map[string]string{"X-Tenant": "team a"}, wantStatus: http.StatusBadRequest},
+		{name: "key scope", keys: keys, headers: map[string]string{"X-API-Key": "key-b"}, wantStatus: http.StatusOK, wantTenant: "team-b", wantActor: keyActor("key-b")},
+		{name: "key with its own tenant", keys: keys, headers: map[string]string{"X-API-Key": "key-a", "X-Tenant": "team-a"}, wantStatus: http.StatusOK, wantTenant: "team-a", wantActor: keyActor("key-a")},
+		{name: "key for another tenant", keys: keys, headers: map[string]string{"X-API-Key": "key-a", "X-Tenant": "team-b"}, wantStatus: http.StatusForbidden},
+		{name: "unknown key", keys: keys, headers: map[string]string{"X-API-Key": "nope"}, wantStatus: http.StatusUnauthorized},
+		{name: "tenant header without a key", keys: keys, headers: map[string]string{"X-Tenant": "team-a"}, wantStatus: http.StatusUnauthorized},
+		{name: "shared without a key", keys: keys, wantStatus: http.StatusOK, wantTenant: tenant.Shared, wantActor: "anonymous"},
+		{name: "user header ignored with keys", keys: keys, headers: map[string]string{"X-User": "ada"}, wantStatus: http.StatusOK, wantActor: "anonymous"},
+		{name: "key required", keys: keys, requireKey: true, wantStatus: http.StatusUnauthorized},
+		{name: "key required except readyz", keys: keys, requireKey: true, path: "/readyz", wantStatus: http.StatusOK, wantActor: "anonymous"},
+		{name: "key required except metrics", keys: keys, requireKey: true, path: "/metrics", wantStatus: http.StatusOK, wantActor: "anonymous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(newFakeRepo(), func(cfg *config.Config) {
+				cfg.TenantAPIKeys = tt.keys
+				cfg.RequireAPIKey = tt.requireKey
+			})
+			var gotTenant, gotActor string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotTenant, gotActor = tenant.FromContext(r.Context()), requestedBy(r)
+			})
+			path := tt.path
+			if path == "" {
+				path = "/api/authors"
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.WithTenant(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", gotTenant, tt.wantTenant)
+			}
+			if gotActor != tt.wantActor {
+				t.Errorf("requestedBy = %q, want %q", gotActor, tt.wantActor)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+var topicIDPattern = regexp.MustCompile(`^T[0-9]+$`)
+
+// GetTrendingTopicsHandler returns the topics with the most works in the graph published
+// in or after a year, for a "what's hot" view. Query parameters: since, a publication
+// year (default last year), and limit (1-100, default 20).
+func (h *APIHandler) GetTrendingTopicsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			respondWithError(w, http.StatusBadRequest, "'limit' must be an integer between 1 and 100")
+			return
+		}
+		limit = n
+	}
+	sinceYear := time.Now().Year() - 1
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		year, err := strconv.Atoi(raw)
+		if err != nil || year < 1000 || year > time.Now().Year()+1 {
+			respondWithError(w, http.StatusBadRequest, "'since' must be a publication year, e.g. 2020")
+			return
+		}
+		sinceYear = year
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	topics, err := h.repo.GetTrendingTopics(ctx, sinceYear, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"since":  sinceYear,
+		"topics": topics,
+	})
+}
+
+// SearchTopicsHandler looks topics up in OpenAlex by a free-text query (q), for a topic
+// picker. Each match comes with its domain, field and subfield and the path through them.
+func (h *APIHandler) SearchTopicsHandler(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing 'q' query parameter")
+		return
+	}
+
+	topics, err := h.alexClient.FetchTopicsByName(q)
+	if err != nil {
+		respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to search topics in OpenAlex: %v", err))
+		return
+	}
+	matches := make([]dto.TopicMatch, 0, len(topics))
+	for _, topic := range topics {
+		matches = append(matches, dto.NewTopicMatch(topic))
+	}
+	respondWithJSON(w, http.StatusOK, matches)
+}
+
+// bridgeParams reads the two topics (a and b, bare or in URL form) and the page of a
+// bridge query. The topics are returned in URL form, as they are stored.
+func bridgeParams(r *http.Request) (topicA, topicB string, page storage.PageRequest, err error) {
+	query := r.URL.Query()
+	for _, param := range []struct {
+		name string
+		id   *string
+	}{{"a", &topicA}, {"b", &topicB}} {
+		short := strings.TrimPrefix(strings.TrimSpace(query.Get(param.name)), "https://openalex.org/")
+		if !topicIDPattern.MatchString(short) {
+			return "", "", page, fmt.Errorf("'%s' must be an OpenAlex topic ID, e.g. T10017", param.name)
+		}
+		*param.id = canonicalOpenAlexID(short)
+	}
+	if topicA == topicB {
+		return "", "", page, fmt.Errorf("'a' and 'b' must be different topics")
+	}
+	page.Page, page.PerPage, err = pageParams(r, 25)
+	return topicA, topicB, page, err
+}
+
+// GetWorksBridgingTopicsHandler finds interdisciplinary works: those about both topics a
+// and b with a topic score of at least min_score (0-1, default 0) for each, highest
+// combined score first. Paged with page and per_page (default 25).
+func (h *APIHandler) GetWorksBridgingTopicsHandler(w http.ResponseWriter, r *http.Request) {
+	topicA, topicB, page, err := bridgeParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var minScore float32
+	if raw := r.URL.Query().Get("min_score"); raw != "" {
+		score, err := strconv.ParseFloat(raw, 32)
+		if err != nil || score < 0 || score > 1 {
+			respondWithError(w, http.StatusBadRequest, "'min_score' must be a number between 0 and 1")
+			return
+		}
+		minScore = float32(score)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	works, err := h.repo.GetWorksBridgingTopics(ctx, topicA, topicB, minScore, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writePageHeaders(w, page.Page, page.PerPage, works.Total)
+	respondWithJSON(w, http.StatusOK, works)
+}
+
+// GetAuthorsBridgingTopicsHandler is the author counterpart of GetWorksBridgingTopicsHandler:
+// authors with at least min_papers (default 1) papers on each of topics a and b, most
+// combined papers first.
+func (h *APIHandler) GetAuthorsBridgingTopicsHandler(w http.ResponseWriter, r *http.Request) {
+	topicA, topicB, page, err := bridgeParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	minPapers := 1
+	if raw := r.URL.Query().Get("min_papers"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "'min_papers' must be a positive integer")
+			return
+		}
+		minPapers = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	authors, err := h.repo.GetAuthorsBridgingTopics(ctx, topicA, topicB, minPapers, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writePageHeaders(w, page.Page, page.PerPage, authors.Total)
+	respondWithJSON(w, http.StatusOK, authors)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestGetWorksBridgingTopicsHandler(t *testing.T) {
+	const t1, t2 = "https://openalex.org/T1", "https://openalex.org/T2"
+	tests := []struct {
+		name       string
+		query      string
+		matches    int
+		wantStatus int
+		wantQuery  string
+		wantWorks  int
+		wantTotal  string
+	}{
+		{"bridge", "a=T1&b=T2&min_score=0.3", 3, http.StatusOK, t1 + " " + t2 + " min=0.3 page=1/25", 3, "3"},
+		{"URL form", "a=" + url.QueryEscape(t1) + "&b=T2", 3, http.StatusOK, t1 + " " + t2 + " min=0 page=1/25", 3, "3"},
+		{"paged", "a=T1&b=T2&page=2&per_page=2", 3, http.StatusOK, t1 + " " + t2 + " min=0 page=2/2", 1, "3"},
+		{"no bridging works", "a=T1&b=T2", 0, http.StatusOK, t1 + " " + t2 + " min=0 page=1/25", 0, "0"},
+		{"same topic", "a=T1&b=T1", 0, http.StatusBadRequest, "", 0, ""},
+		{"same topic in URL form", "a=T1&b=" + url.QueryEscape(t1), 0, http.StatusBadRequest, "", 0, ""},
+		{"missing topic", "a=T1", 0, http.StatusBadRequest, "", 0, ""},
+		{"not a topic", "a=T1&b=W2", 0, http.StatusBadRequest, "", 0, ""},
+		{"score too high", "a=T1&b=T2&min_score=1.5", 0, http.StatusBadRequest, "", 0, ""},
+		{"negative score", "a=T1&b=T2&min_score=-0.1", 0, http.StatusBadRequest, "", 0, ""},
+		{"score not a number", "a=T1&b=T2&min_score=high", 0, http.StatusBadRequest, "", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			for i := 0; i < tt.matches; i++ {
+				repo.bridging = append(repo.bridging, storage.BridgingWork{DehydratedWork: domain.DehydratedWork{ID: fmt.Sprint("W", i+1)}})
+			}
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.GetWorksBridgingTopicsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/topics/bridge?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if repo.bridgeQuery != tt.wantQuery {
+				t.Errorf("repository asked for %q, want %q", repo.bridgeQuery, tt.wantQuery)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var body struct {
+				Total int               `json:"total"`
+				Works []json.RawMessage `json:"works"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.Works == nil || len(body.Works) != tt.wantWorks || body.Total != tt.matches {
+				t.Errorf("response = %s, want %d works of %d", rec.Body, tt.wantWorks, tt.matches)
+			}
+			if total := rec.Header().Get("X-Total-Count"); total != tt.wantTotal {
+				t.Errorf("X-Total-Count = %q, want %q", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestGetAuthorsBridgingTopicsHandler(t *testing.T) {
+	const t1, t2 = "https://openalex.org/T1", "https://openalex.org/T2"
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantQuery  string
+	}{
+		{"bridge", "a=T1&b=T2", http.StatusOK, t1 + " " + t2 + " min=1 page=1/25"},
+		{"min papers", "a=T1&b=T2&min_papers=3&per_page=10", http.StatusOK, t1 + " " + t2 + " min=3 page=1/10"},
+		{"same topic", "a=T2&b=T2", http.StatusBadRequest, ""},
+		{"zero papers", "a=T1&b=T2&min_papers=0", http.StatusBadRequest, ""},
+		{"papers not a number", "a=T1&b=T2&min_papers=many", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.bridgingAuthors = []storage.BridgingAuthor{{ID: "A1"}, {ID: "A2"}}
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.GetAuthorsBridgingTopicsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/topics/bridge/authors?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if repo.bridgeQuery != tt.wantQuery {
+				t.Errorf("repository asked for %q, want %q", repo.bridgeQuery, tt.wantQuery)
+			}
+			if rec.Code == http.StatusOK && rec.Header().Get("X-Total-Count") != "2" {
+				t.Errorf("X-Total-Count = %q, want 2", rec.Header().Get("X-Total-Count"))
+			}
+		})
+	}
+}
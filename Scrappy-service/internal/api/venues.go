@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// IngestVenueByISSNHandler looks a journal up by ISSN (with or without the hyphen), saves
+// it as a Venue and, with works=N (1-200), its N most recent works. The work filter
+// parameters (skip_*, include) apply to those works.
+func (h *APIHandler) IngestVenueByISSNHandler(w http.ResponseWriter, r *http.Request) {
+	issn, err := openalex.NormalizeISSN(r.URL.Query().Get("issn"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	maxWorks := 0
+	if raw := r.URL.Query().Get("works"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > openalex.MaxPerPage {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("'works' must be an integer between 0 and %d", openalex.MaxPerPage))
+			return
+		}
+		maxWorks = n
+	}
+	filter, err := h.workFilterFor(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	source, err := h.alexClient.FetchSourceByISSN(issn)
+	if err != nil {
+		respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch venue with ISSN %s from OpenAlex: %v", issn, err))
+		return
+	}
+	if h.rejectIfBlocked(ctx, w, source.ID) {
+		return
+	}
+	if err := h.repo.SaveVenue(ctx, source); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := map[string]interface{}{
+		"id":          source.ID,
+		"displayName": source.DisplayName,
+		"issnL":       source.IssnL,
+	}
+	if maxWorks > 0 {
+		job := h.startIngestJob(ctx, "venue", source.ID, requestedBy(r))
+		defer job.finishOnPanic(true)
+
+		works, warnings, err := h.alexClient.FetchRecentWorksBySourceID(source.ID, maxWorks)
+		if err != nil {
+			job.finish(ctx, err)
+			respondWithError(w, openAlexErrorStatus(err), fmt.Sprintf("Failed to fetch works of venue %s from OpenAlex: %v", source.ID, err))
+			return
+		}
+		works, skipped := filter.apply(works)
+		works, existing := filter.dropExisting(ctx, h.repo, works)
+		logDecodeWarnings("venue "+source.ID, warnings)
+		job.decodeWarnings(warnings)
+		saved := 0
+		for _, work := range works {
+			outcome, err := h.repo.SaveWork(ctx, work, filter.save)
+			job.workSaved(work, outcome, err)
+			if err != nil {
+				log.Printf("WARN: Could not save work %s of venue %s: %v", work.Title, source.ID, err)
+				continue
+			}
+			saved++
+		}
+		job.finish(ctx, nil)
+
+		response["worksSaved"] = saved
+		response["skippedWorks"] = skipped + existing
+		if failures := job.failures(); len(failures) > 0 {
+			response["failedWorks"] = failures
+		}
+		addDecodeWarnings(response, warnings)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetVenueSummaryHandler summarizes what the graph holds for a venue: works count,
+// citation total and median, works per year and the most prolific authors.
+// Query parameters: id (OpenAlex source ID, required) and top (1-100, default 10).
+func (h *APIHandler) GetVenueSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	venueID, err := openalex.ValidateID(r.URL.Query().Get("id"), 'S')
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	top := 10
+	if raw := r.URL.Query().Get("top"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			respondWithError(w, http.StatusBadRequest, "'top' must be an integer between 1 and 100")
+			return
+		}
+		top = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	summary, err := h.repo.GetVenueSummary(ctx, canonicalOpenAlexID(venueID), top)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Venue is not in the graph")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// GetAuthorWorksByVenueHandler answers "where does this person publish": the author's
+// ingested works grouped by venue, venues with the most works first. Works without a
+// venue are grouped under "Unknown".
+func (h *APIHandler) GetAuthorWorksByVenueHandler(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := authorIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	byVenue, err := h.repo.GetWorksByVenueForAuthor(ctx, h.resolveAuthorID(ctx, authorID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	venues := make([]dto.VenueWorks, 0, len(byVenue))
+	for venue, works := range byVenue {
+		venues = append(venues, dto.VenueWorks{Venue: venue, WorksCount: len(works), Works: works})
+	}
+	sort.Slice(venues, func(i, j int) bool {
+		if venues[i].WorksCount != venues[j].WorksCount {
+			return venues[i].WorksCount > venues[j].WorksCount
+		}
+		return venues[i].Venue < venues[j].Venue
+	})
+	respondWithJSONIfChanged(w, r, venues)
+}
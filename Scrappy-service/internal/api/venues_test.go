@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+func TestGetVenueSummaryHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantAuthors int
+	}{
+		{"summary", "id=S1", http.StatusOK, 3},
+		{"URL form", "id=" + url.QueryEscape("https://openalex.org/S1"), http.StatusOK, 3},
+		{"top", "id=S1&top=2", http.StatusOK, 2},
+		{"top too large", "id=S1&top=101", http.StatusBadRequest, 0},
+		{"top not a number", "id=S1&top=x", http.StatusBadRequest, 0},
+		{"unknown venue", "id=S9", http.StatusNotFound, 0},
+		{"not a venue", "id=A1", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.venues = map[string]*storage.VenueSummary{"https://openalex.org/S1": {
+				ID: "https://openalex.org/S1", WorksCount: 1, MedianCitations: 2,
+				WorksByYear: []storage.YearCount{{Year: 2020, Works: 1}},
+				TopAuthors:  []storage.VenueAuthor{{ID: "A1"}, {ID: "A2"}, {ID: "A3"}},
+			}}
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.GetVenueSummaryHandler(rec, httptest.NewRequest(http.MethodGet, "/api/venues/summary?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var summary storage.VenueSummary
+			if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if summary.ID != "https://openalex.org/S1" || len(summary.TopAuthors) != tt.wantAuthors {
+				t.Errorf("summary = %+v, want S1 with %d top authors", summary, tt.wantAuthors)
+			}
+		})
+	}
+}
+
+// aliasGroup returns a VenueAliases group of the given venue IDs, in order.
+func aliasGroup(issnL string, ids ...string) storage.VenueAliases {
+	group := storage.VenueAliases{IssnL: issnL}
+	for i, id := range ids {
+		group.Venues = append(group.Venues, storage.AliasedVenue{ID: id, WorksCount: len(ids) - i})
+	}
+	return group
+}
+
+func TestVenueAliasesHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		mergeErrs  map[string]error
+		wantStatus int
+		wantMerges map[string][]string
+		wantFailed []string
+	}{
+		{name: "list", method: http.MethodGet, wantStatus: http.StatusOK, wantMerges: map[string][]string{}},
+		{
+			name: "merge all", method: http.MethodPost, wantStatus: http.StatusOK,
+			wantMerges: map[string][]string{"S1": {"S2", "S3"}, "S4": {"S5"}},
+		},
+		{
+			name: "merge one", method: http.MethodPost, query: "?issn_l=9999-0000", wantStatus: http.StatusOK,
+			wantMerges: map[string][]string{"S4": {"S5"}},
+		},
+		{
+			name: "failed merge", method: http.MethodPost, mergeErrs: map[string]error{"S1": storage.ErrConflictingISSNs},
+			wantStatus: http.StatusOK, wantMerges: map[string][]string{"S4": {"S5"}}, wantFailed: []string{"1234-5678"},
+		},
+		{name: "unknown ISSN-L", method: http.MethodPost, query: "?issn_l=0000-0000", wantStatus: http.StatusNotFound, wantMerges: map[string][]string{}},
+		{name: "wrong method", method: http.MethodDelete, wantStatus: http.StatusMethodNotAllowed, wantMerges: map[string][]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.venueAliases = []storage.VenueAliases{aliasGroup("1234-5678", "S1", "S2", "S3"), aliasGroup("9999-0000", "S4", "S5")}
+			repo.venueMergeErrs = tt.mergeErrs
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.VenueAliasesHandler(rec, httptest.NewRequest(tt.method, "/api/admin/venues/aliases"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !reflect.DeepEqual(repo.venueMerges, tt.wantMerges) {
+				t.Errorf("merges = %v, want %v", repo.venueMerges, tt.wantMerges)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if tt.method == http.MethodGet {
+				var groups []storage.VenueAliases
+				json.Unmarshal(rec.Body.Bytes(), &groups)
+				if !reflect.DeepEqual(groups, repo.venueAliases) {
+					t.Errorf("groups = %+v, want %+v", groups, repo.venueAliases)
+				}
+				return
+			}
+			var body struct {
+				Merged map[string][]string `json:"merged"`
+				Failed map[string]string   `json:"failed"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if !reflect.DeepEqual(body.Merged, tt.wantMerges) {
+				t.Errorf("merged = %v, want %v", body.Merged, tt.wantMerges)
+			}
+			if len(body.Failed) != len(tt.wantFailed) {
+				t.Errorf("failed = %v, want %v", body.Failed, tt.wantFailed)
+			}
+			for _, issnL := range tt.wantFailed {
+				if body.Failed[issnL] == "" {
+					t.Errorf("failed = %v, want an error for %s", body.Failed, issnL)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeVenuesHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		mergeErr   error
+		wantStatus int
+		wantMerges map[string][]string
+	}{
+		{
+			name: "merge", method: http.MethodPost, body: `{"keepId": "S1", "mergeIds": ["https://openalex.org/S2", "S3"]}`,
+			wantStatus: http.StatusOK,
+			wantMerges: map[string][]string{"https://openalex.org/S1": {"https://openalex.org/S2", "https://openalex.org/S3"}},
+		},
+		{name: "unknown venue", method: http.MethodPost, body: `{"keepId": "S1", "mergeIds": ["S9"]}`, mergeErr: storage.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "different ISSN-Ls", method: http.MethodPost, body: `{"keepId": "S1", "mergeIds": ["S4"]}`, mergeErr: storage.ErrConflictingISSNs, wantStatus: http.StatusConflict},
+		{name: "nothing to merge", method: http.MethodPost, body: `{"keepId": "S1", "mergeIds": []}`, wantStatus: http.StatusBadRequest},
+		{name: "no venue to keep", method: http.MethodPost, body: `{"mergeIds": ["S2"]}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid JSON", method: http.MethodPost, body: `{"keepId":`, wantStatus: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.venueMergeErrs = map[string]error{"https://openalex.org/S1": tt.mergeErr}
+			h := newTestHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.MergeVenuesHandler(rec, httptest.NewRequest(tt.method, "/api/admin/venues/merge", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			want := tt.wantMerges
+			if want == nil {
+				want = map[string][]string{}
+			}
+			if !reflect.DeepEqual(repo.venueMerges, want) {
+				t.Errorf("merges = %v, want %v", repo.venueMerges, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+)
+
+// maxExportWorks caps the IDs of one POST /api/works/export.
+const maxExportWorks = 500
+
+// workExportFormat is a bibliography format ExportWorksHandler writes.
+type workExportFormat struct {
+	contentType string
+	extension   string
+	write       func(io.Writer, domain.Work)
+}
+
+var workExportFormats = map[string]workExportFormat{
+	"bibtex": {"application/x-bibtex; charset=utf-8", "bib", writeBibTeX},
+	"ris":    {"application/x-research-info-systems", "ris", writeRIS},
+}
+
+// ExportWorksHandler renders works from the graph as a bibliography file, in BibTeX
+// (format=bibtex, the default) or RIS (format=ris). A GET exports the work in id; a POST
+// exports the works whose IDs are in the body's ids (at most 500), concatenated in that
+// order. IDs are OpenAlex work IDs or the LOCAL- IDs of deposited works. Unlike
+// /api/works/ris, the works must have been ingested; IDs not in the graph are listed in the
+// X-Missing-Ids header, and it answers 404 when none are.
+func (h *APIHandler) ExportWorksHandler(w http.ResponseWriter, r *http.Request) {
+	formatName := r.URL.Query().Get("format")
+	if formatName == "" {
+		formatName = "bibtex"
+	}
+	format, ok := workExportFormats[formatName]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "'format' must be bibtex or ris")
+		return
+	}
+
+	var rawIDs []string
+	switch r.Method {
+	case http.MethodGet:
+		raw := r.URL.Query().Get("id")
+		if raw == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing 'id' query parameter")
+			return
+		}
+		rawIDs = []string{raw}
+	case http.MethodPost:
+		var req dto.WorkExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		if len(req.IDs) == 0 {
+			respondWithError(w, http.StatusBadRequest, "'ids' must hold at least one work ID")
+			return
+		}
+		if len(req.IDs) > maxExportWorks {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d work IDs per request", maxExportWorks))
+			return
+		}
+		rawIDs = req.IDs
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Use GET or POST")
+		return
+	}
+
+	ids := make([]string, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		raw = strings.TrimSpace(raw)
+		if domain.IsLocalID(raw) {
+			ids = append(ids, raw)
+			continue
+		}
+		id, err := openalex.ValidateID(raw, 'W')
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ids = append(ids, canonicalOpenAlexID(id))
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	for i, id := range ids {
+		if !domain.IsLocalID(id) {
+			ids[i] = h.resolveWorkID(ctx, id)
+		}
+	}
+	works, err := h.repo.GetWorksForExport(ctx, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read works from database: %v", err))
+		return
+	}
+	if len(works) == 0 {
+		respondWithError(w, http.StatusNotFound, "None of the works are in the graph")
+		return
+	}
+
+	found := make(map[string]bool, len(works))
+	for _, work := range works {
+		found[work.ID] = true
+	}
+	var missing []string
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, strings.TrimPrefix(id, "https://openalex.org/"))
+		}
+	}
+	if len(missing) > 0 {
+		w.Header().Set("X-Missing-Ids", strings.Join(missing, ","))
+	}
+
+	filename := "works." + format.extension
+	if r.Method == http.MethodGet {
+		filename = strings.TrimPrefix(works[0].ID, "https://openalex.org/") + "." + format.extension
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	bw := bufio.NewWriter(w)
+	for _, work := range works {
+		format.write(bw, work)
+	}
+	bw.Flush()
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestExportWorksHandler(t *testing.T) {
+	local := domain.LocalWorkID(domain.Work{Title: "Report", PublicationYear: 2023})
+	tooMany := strings.Repeat(`"W1",`, maxExportWorks) + `"W2"`
+	tests := []struct {
+		name          string
+		method        string
+		target        string
+		body          string
+		wantStatus    int
+		wantType      string
+		wantFile      string
+		wantKeys      []string // the works in the file, in order
+		wantMissing   string
+		wantRequested string // the IDs the repository was asked for, comma-separated
+	}{
+		{name: "one work", method: http.MethodGet, target: "?id=W1", wantStatus: http.StatusOK,
+			wantType: "application/x-bibtex; charset=utf-8", wantFile: "W1.bib", wantKeys: []string{"W1"},
+			wantRequested: "https://openalex.org/W1"},
+		{name: "one work as RIS", method: http.MethodGet, target: "?id=https://openalex.org/W1&format=ris", wantStatus: http.StatusOK,
+			wantType: "application/x-research-info-systems", wantFile: "W1.ris", wantKeys: []string{"W1"},
+			wantRequested: "https://openalex.org/W1"},
+		{name: "deposited work", method: http.MethodGet, target: "?id=" + local, wantStatus: http.StatusOK,
+			wantType: "application/x-bibtex; charset=utf-8", wantFile: local + ".bib", wantKeys: []string{local},
+			wantRequested: local},
+		{name: "batch in order", method: http.MethodPost, body: `{"ids": ["W2", "W404", "` + local + `", "W1"]}`, wantStatus: http.StatusOK,
+			wantType: "application/x-bibtex; charset=utf-8", wantFile: "works.bib", wantKeys: []string{"W2", local, "W1"}, wantMissing: "W404",
+			wantRequested: "https://openalex.org/W2,https://openalex.org/W404," + local + ",https://openalex.org/W1"},
+		{name: "batch as RIS", method: http.MethodPost, target: "?format=ris", body: `{"ids": ["W1", "W2"]}`, wantStatus: http.StatusOK,
+			wantType: "application/x-research-info-systems", wantFile: "works.ris", wantKeys: []string{"W1", "W2"},
+			wantRequested: "https://openalex.org/W1,https://openalex.org/W2"},
+		{name: "nothing in the graph", method: http.MethodGet, target: "?id=W404", wantStatus: http.StatusNotFound,
+			wantRequested: "https://openalex.org/W404"},
+		{name: "missing id", method: http.MethodGet, wantStatus: http.StatusBadRequest},
+		{name: "author id", method: http.MethodGet, target: "?id=A1", wantStatus: http.StatusBadRequest},
+		{name: "unknown format", method: http.MethodGet, target: "?id=W1&format=endnote", wantStatus: http.StatusBadRequest},
+		{name: "empty batch", method: http.MethodPost, body: `{"ids": []}`, wantStatus: http.StatusBadRequest},
+		{name: "batch too large", method: http.MethodPost, body: `{"ids": [` + tooMany + `]}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid id in batch", method: http.MethodPost, body: `{"ids": ["W1", "nope"]}`, wantStatus: http.StatusBadRequest},
+		{name: "not JSON", method: http.MethodPost, body: `ids=W1`, wantStatus: http.StatusBadRequest},
+		{name: "other method", method: http.MethodDelete, target: "?id=W1", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.exportWorks = map[string]domain.Work{
+				"https://openalex.org/W1": {ID: "https://openalex.org/W1", Type: "article", Title: "One"},
+				"https://openalex.org/W2": {ID: "https://openalex.org/W2", Type: "article", Title: "Two"},
+				local:                     {ID: local, Type: "report", Title: "Report"},
+			}
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/api/works/export"+tt.target, strings.NewReader(tt.body))
+			newTestHandler(repo).ExportWorksHandler(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := strings.Join(repo.exported, ","); got != tt.wantRequested {
+				t.Errorf("repository asked for %q, want %q", got, tt.wantRequested)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got, want := rec.Header().Get("Content-Disposition"), fmt.Sprintf(`attachment; filename="%s"`, tt.wantFile); got != want {
+				t.Errorf("Content-Disposition = %q, want %q", got, want)
+			}
+			if got := rec.Header().Get("X-Missing-Ids"); got != tt.wantMissing {
+				t.Errorf("X-Missing-Ids = %q, want %q", got, tt.wantMissing)
+			}
+
+			var keys []string
+			if strings.HasSuffix(tt.wantFile, ".bib") {
+				entries, err := parseBibTeX(rec.Body.String())
+				if err != nil {
+					t.Fatalf("parsing %s: %v", rec.Body, err)
+				}
+				for _, entry := range entries {
+					keys = append(keys, entry.key)
+				}
+			} else {
+				for _, line := range strings.Split(rec.Body.String(), "\r\n") {
+					if id, ok := strings.CutPrefix(line, "ID  - "); ok {
+						keys = append(keys, id)
+					}
+				}
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("works in the file = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
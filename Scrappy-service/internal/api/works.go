@@ -0,0 +1,372 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/api/dto"
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/resolve"
+	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// includeRetractedParam parses the include_retracted query parameter, which lets work
+// listings return retracted works; they are left out by default.
+func includeRetractedParam(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("include_retracted")
+	if raw == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("'include_retracted' must be true or false")
+	}
+	return include, nil
+}
+
+// relatedSourceSemanticScholar tags RELATED_TO edges created from Semantic Scholar
+// recommendations, to tell them apart from OpenAlex's related_works.
+const relatedSourceSemanticScholar = storage.SourceSemanticScholar
+
+// GetWorkRecommendationsHandler returns Semantic Scholar's recommended papers for a work.
+// Query parameters: doi (required), limit (1-500, default 10) and persist=true to also link
+// the work to recommended works already in the graph with RELATED_TO edges. The DOI is
+// mapped to a Semantic Scholar paperId by the paper resolver.
+func (h *APIHandler) GetWorkRecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	doi := domain.NormalizeDOI(r.URL.Query().Get("doi"))
+	if doi == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing 'doi' query parameter")
+		return
+	}
+	limit := 10
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > semanticscholar.MaxRecommendations {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("'limit' must be an integer between 1 and %d", semanticscholar.MaxRecommendations))
+			return
+		}
+		limit = n
+	}
+	persist := r.URL.Query().Get("persist") == "true"
+	if persist && h.storageDisabled(w) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	paperID, err := h.papers.ResolveToSemanticScholar(ctx, resolve.DOIID(doi))
+	if errors.Is(err, resolve.ErrUnresolved) {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	papers, err := h.semClient.FetchRecommendations(ctx, paperID, limit)
+	if errors.Is(err, semanticscholar.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, fmt.Sprintf("Failed to fetch recommendations from Semantic Scholar: %v", err))
+		return
+	}
+
+	recommendations := make([]domain.DehydratedWork, 0, len(papers))
+	var relatedDOIs []string
+	for _, paper := range papers {
+		work := domain.DehydratedWork{Title: paper.Title, PublicationYear: paper.Year}
+		if paper.ExternalIDs.DOI != "" {
+			work.Doi = "https://doi.org/" + domain.NormalizeDOI(paper.ExternalIDs.DOI)
+			relatedDOIs = append(relatedDOIs, paper.ExternalIDs.DOI)
+		}
+		recommendations = append(recommendations, work)
+	}
+
+	response := map[string]interface{}{"doi": doi, "recommendations": recommendations}
+	if persist {
+		linked, err := h.repo.LinkRelatedWorksByDOI(ctx, doi, relatedDOIs, relatedSourceSemanticScholar)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			log.Printf("WARN: Not persisting recommendations for %s: work is not in the graph", doi)
+		case err != nil:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		response["linked"] = linked
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// EnrichCitationContextHandler annotates the CITES relationships of the work with the given
+// DOI with Semantic Scholar's citation context: intents, isInfluential and contexts (the
+// citing sentences). Both the work's citations and its references are used; a relationship
+// is created when both works are in the graph. Citations whose other paper has no DOI or
+// isn't in the graph are listed as unmatched. limit (1-9000, default 1000) bounds each direction.
+// The paperIds of the work and of the matched works are recorded by the paper resolver.
+func (h *APIHandler) EnrichCitationContextHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Use POST")
+		return
+	}
+	doi := domain.NormalizeDOI(r.URL.Query().Get("doi"))
+	if doi == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing 'doi' query parameter")
+		return
+	}
+	limit := 1000
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > semanticscholar.MaxCitations {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("'limit' must be an integer between 1 and %d", semanticscholar.MaxCitations))
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	local, err := h.repo.GetWorkIDsByDOI(ctx, []string{doi})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	workID, ok := local[doi]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Work is not in the graph")
+		return
+	}
+
+	paperID, err := h.papers.ResolveToSemanticScholar(ctx, resolve.OpenAlexID(workID))
+	if errors.Is(err, resolve.ErrUnresolved) {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	citations, err := h.semClient.FetchCitations(ctx, paperID, limit)
+	var references []semanticscholar.Citation
+	if err == nil {
+		references, err = h.semClient.FetchReferences(ctx, paperID, limit)
+	}
+	if errors.Is(err, semanticscholar.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, fmt.Sprintf("Failed to fetch citations from Semantic Scholar: %v", err))
+		return
+	}
+
+	var otherDOIs []string
+	for _, c := range append(append([]semanticscholar.Citation(nil), citations...), references...) {
+		if c.Paper.ExternalIDs.DOI != "" {
+			otherDOIs = append(otherDOIs, c.Paper.ExternalIDs.DOI)
+		}
+	}
+	localIDs, err := h.repo.GetWorkIDsByDOI(ctx, otherDOIs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	annotated := 0
+	unmatched := []dto.UnmatchedCitation{}
+	annotate := func(direction string, c semanticscholar.Citation) error {
+		otherDOI := domain.NormalizeDOI(c.Paper.ExternalIDs.DOI)
+		otherID, ok := localIDs[otherDOI]
+		citingID, citedID := otherID, workID
+		if direction == "reference" {
+			citingID, citedID = workID, otherID
+		}
+		if ok && otherID != workID {
+			h.papers.Remember(ctx, otherID, c.Paper.PaperID)
+			err := h.repo.AnnotateCitation(ctx, citingID, citedID, citationContextProps(c))
+			if err == nil {
+				annotated++
+				return nil
+			}
+			// The other work may have been deleted since it was looked up.
+			if !errors.Is(err, storage.ErrNotFound) {
+				return err
+			}
+		}
+		unmatched = append(unmatched, dto.UnmatchedCitation{Direction: direction, PaperID: c.Paper.PaperID, Title: c.Paper.Title, Doi: otherDOI})
+		return nil
+	}
+	for _, c := range citations {
+		if err := annotate("citation", c); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	for _, c := range references {
+		if err := annotate("reference", c); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"id":         workID,
+		"doi":        doi,
+		"citations":  len(citations),
+		"references": len(references),
+		"annotated":  annotated,
+		"unmatched":  unmatched,
+	})
+}
+
+// citationContextProps are the CITES properties set from a Semantic Scholar citation.
+// Lists are never nil: a null would remove the property instead of recording that there are none.
+func citationContextProps(c semanticscholar.Citation) map[string]any {
+	intents, contexts := c.Intents, c.Contexts
+	if intents == nil {
+		intents = []string{}
+	}
+	if contexts == nil {
+		contexts = []string{}
+	}
+	return map[string]any{
+		"intents":       intents,
+		"isInfluential": c.IsInfluential,
+		"contexts":      contexts,
+		"contextSource": relatedSourceSemanticScholar,
+	}
+}
+
+// GetTopWorksHandler returns the most cited works in the graph, e.g. for a "top papers"
+// widget. Query parameters: limit (1-100, default 10) and since, a year to only rank works
+// published in or after it, and include_retracted.
+func (h *APIHandler) GetTopWorksHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			respondWithError(w, http.StatusBadRequest, "'limit' must be an integer between 1 and 100")
+			return
+		}
+		limit = n
+	}
+	sinceYear := 0
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		year, err := strconv.Atoi(raw)
+		if err != nil || year < 1000 || year > time.Now().Year()+1 {
+			respondWithError(w, http.StatusBadRequest, "'since' must be a publication year, e.g. 2020")
+			return
+		}
+		sinceYear = year
+	}
+	includeRetracted, err := includeRetractedParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fields, err := fieldsParam(r, domain.Work{})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	works, err := h.repo.GetTopWorks(ctx, limit, sinceYear, includeRetracted)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, fields.project(works))
+}
+
+// GetCitationNeighborhoodHandler returns the citation graph around a work, for
+// visualization: the works it cites up to out hops away, the works citing it up to in hops
+// away, and the CITES edges between them. Query parameters: id (required), in and out (0-3,
+// default 1) and max, the most works to return (1-1000, default 200). Beyond max the
+// least cited works are left out and truncated is true.
+func (h *APIHandler) GetCitationNeighborhoodHandler(w http.ResponseWriter, r *http.Request) {
+	workID, err := openalex.ValidateID(r.URL.Query().Get("id"), 'W')
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	depth := map[string]int{"in": 1, "out": 1}
+	for _, name := range []string{"in", "out"} {
+		if raw := r.URL.Query().Get(name); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 || n > storage.MaxNeighborhoodDepth {
+				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("'%s' must be an integer between 0 and %d", name, storage.MaxNeighborhoodDepth))
+				return
+			}
+			depth[name] = n
+		}
+	}
+	maxNodes := 200
+	if raw := r.URL.Query().Get("max"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "'max' must be an integer between 1 and 1000")
+			return
+		}
+		maxNodes = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	hood, err := h.repo.GetCitationNeighborhood(ctx, h.resolveWorkID(ctx, workID), depth["in"], depth["out"], maxNodes)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Work is not in the graph")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, hood)
+}
+
+// GetWorkProvenanceHandler returns, for debugging, where the graph's data on a work came
+// from: the sourcedFrom and lastSource stamps of the work node and of each of its
+// relationships. id is an OpenAlex work ID or the LOCAL- ID of a deposited work.
+func (h *APIHandler) GetWorkProvenanceHandler(w http.ResponseWriter, r *http.Request) {
+	workID := r.URL.Query().Get("id")
+	if !domain.IsLocalID(workID) {
+		id, err := openalex.ValidateID(workID, 'W')
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		workID = id
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	if !domain.IsLocalID(workID) {
+		workID = h.resolveWorkID(ctx, workID)
+	}
+
+	provenance, err := h.repo.GetWorkProvenance(ctx, workID)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Work is not in the graph")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, provenance)
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// Graph listings leave retracted works out unless include_retracted=true, and flag those
+// they return.
+func TestGraphListingsIncludeRetracted(t *testing.T) {
+	listings := []struct {
+		name   string
+		target string
+		serve  func(h *APIHandler, w http.ResponseWriter, r *http.Request)
+	}{
+		{"author works", "/api/fetch-recent-works/?source=graph&id=A1", (*APIHandler).GetAuthorWorksHandler},
+		{"top works", "/api/works/top?limit=5", (*APIHandler).GetTopWorksHandler},
+	}
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantAsked  bool // whether the repository was asked for retracted works
+	}{
+		{"", http.StatusOK, false},
+		{"&include_retracted=false", http.StatusOK, false},
+		{"&include_retracted=true", http.StatusOK, true},
+		{"&include_retracted=1", http.StatusOK, true},
+		{"&include_retracted=maybe", http.StatusBadRequest, false},
+	}
+	for _, listing := range listings {
+		for _, tt := range tests {
+			t.Run(listing.name+tt.query, func(t *testing.T) {
+				repo := newFakeRepo()
+				repo.authorWorks = []domain.DehydratedWork{{ID: "W1"}, {ID: "W2", IsRetracted: true}}
+				repo.topWorks = []domain.Work{{ID: "W1"}, {ID: "W2", IsRetracted: true}}
+				repo.includeRetracted = !tt.wantAsked
+				rec := httptest.NewRecorder()
+				listing.serve(newTestHandler(repo), rec, httptest.NewRequest(http.MethodGet, listing.target+tt.query, nil))
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+				}
+				if tt.wantStatus != http.StatusOK {
+					return
+				}
+				if repo.includeRetracted != tt.wantAsked {
+					t.Errorf("includeRetracted = %v, want %v", repo.includeRetracted, tt.wantAsked)
+				}
+				var works []map[string]any
+				if err := json.Unmarshal(rec.Body.Bytes(), &works); err != nil {
+					t.Fatalf("decoding %s: %v", rec.Body, err)
+				}
+				if len(works) != 2 || works[0]["is_retracted"] != false || works[1]["is_retracted"] != true {
+					t.Errorf("works = %v, want W1 and the retracted W2 flagged", works)
+				}
+			})
+		}
+	}
+}
+
+func TestGetCitationNeighborhoodHandler(t *testing.T) {
+	hood := &storage.CitationNeighborhood{
+		Root:       "https://openalex.org/W1",
+		Nodes:      []storage.NeighborhoodNode{{ID: "https://openalex.org/W1", CitedByCount: 3}, {ID: "https://openalex.org/W2", Stub: true}},
+		Edges:      []storage.CitationEdge{{Source: "https://openalex.org/W1", Target: "https://openalex.org/W2"}},
+		TotalNodes: 5,
+		Truncated:  true,
+	}
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantQuery  string // what the repository was asked; empty if nothing
+	}{
+		{"defaults", "id=W1", http.StatusOK, "https://openalex.org/W1 in=1 out=1 max=200"},
+		{"depths and cap", "id=https://openalex.org/W1&in=0&out=3&max=1000", http.StatusOK, "https://openalex.org/W1 in=0 out=3 max=1000"},
+		{"smallest cap", "id=W1&in=2&max=1", http.StatusOK, "https://openalex.org/W1 in=2 out=1 max=1"},
+		{"not in the graph", "id=W404", http.StatusNotFound, "https://openalex.org/W404 in=1 out=1 max=200"},
+		{"missing id", "in=1", http.StatusBadRequest, ""},
+		{"depth too large", "id=W1&out=4", http.StatusBadRequest, ""},
+		{"negative depth", "id=W1&in=-1", http.StatusBadRequest, ""},
+		{"depth not a number", "id=W1&in=two", http.StatusBadRequest, ""},
+		{"cap too small", "id=W1&max=0", http.StatusBadRequest, ""},
+		{"cap too large", "id=W1&max=1001", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.neighborhoods = map[string]*storage.CitationNeighborhood{"https://openalex.org/W1": hood}
+			rec := httptest.NewRecorder()
+			newTestHandler(repo).GetCitationNeighborhoodHandler(rec, httptest.NewRequest(http.MethodGet, "/api/works/neighborhood?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if repo.neighborhoodQuery != tt.wantQuery {
+				t.Errorf("repository asked for %q, want %q", repo.neighborhoodQuery, tt.wantQuery)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var body storage.CitationNeighborhood
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if !reflect.DeepEqual(&body, hood) {
+				t.Errorf("body = %s, want the neighborhood of W1", rec.Body)
+			}
+		})
+	}
+}
+
+func TestGetWorkProvenanceHandler(t *testing.T) {
+	local := "LOCAL-0123456789abcdef"
+	stamp := storage.ProvenanceStamp{SourcedFrom: []string{storage.SourceOpenAlex, storage.SourceSemanticScholar}, LastSource: storage.SourceSemanticScholar}
+	provenance := map[string]*storage.WorkProvenance{
+		"https://openalex.org/W1": {ID: "https://openalex.org/W1", ProvenanceStamp: stamp, TotalRelationships: 1,
+			Relationships: []storage.RelationshipProvenance{{Type: "CITES", Direction: "out", OtherID: "https://openalex.org/W2", OtherLabel: "Work",
+				ProvenanceStamp: storage.ProvenanceStamp{SourcedFrom: []string{storage.SourceOpenAlex}, LastSource: storage.SourceOpenAlex}}}},
+		local: {ID: local, ProvenanceStamp: storage.ProvenanceStamp{SourcedFrom: []string{storage.SourceDeposit}, LastSource: storage.SourceDeposit},
+			Relationships: []storage.RelationshipProvenance{}},
+	}
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+		wantAsked  string // the work the repository was asked about; empty if none
+	}{
+		{"OpenAlex work", "W1", http.StatusOK, "https://openalex.org/W1"},
+		{"OpenAlex URL", "https://openalex.org/W1", http.StatusOK, "https://openalex.org/W1"},
+		{"deposited work", local, http.StatusOK, local},
+		{"not in the graph", "W404", http.StatusNotFound, "https://openalex.org/W404"},
+		{"missing id", "", http.StatusBadRequest, ""},
+		{"author id", "A1", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			repo.provenance = provenance
+			rec := httptest.NewRecorder()
+			newTestHandler(repo).GetWorkProvenanceHandler(rec, httptest.NewRequest(http.MethodGet, "/api/works/provenance?id="+tt.id, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if repo.provenanceOf != tt.wantAsked {
+				t.Errorf("repository asked about %q, want %q", repo.provenanceOf, tt.wantAsked)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var body storage.WorkProvenance
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if !reflect.DeepEqual(&body, provenance[tt.wantAsked]) {
+				t.Errorf("body = %s, want the stamps of %s", rec.Body, tt.wantAsked)
+			}
+		})
+	}
+}
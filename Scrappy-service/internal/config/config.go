@@ -2,24 +2,293 @@
 
 package config
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/tenant"
+)
 
 // Config stores all configuration for the application.
 type Config struct {
+	// AppEnv is the deployment environment (EnvDev, EnvStaging or EnvProd). It picks the
+	// defaults of a few settings and how strictly the configuration is validated.
+	AppEnv string
+
+	// StorageBackend is StorageNeo4j, or StorageNone to run as a plain OpenAlex proxy
+	// without a database.
+	StorageBackend        string
 	Neo4jURI              string
 	Neo4jUsername         string
 	Neo4jPassword         string
 	SemanticScholarAPIKey string
+
+	// Semantic Scholar requests per second and burst; a rate of 0 uses the client's default,
+	// which is lower without an API key. SSTimeout bounds each request attempt.
+	SSRateLimit float64
+	SSRateBurst int
+	SSTimeout   time.Duration
+
+	// Work ingest filter defaults. Both can be overridden per request with the
+	// skip_paratext / skip_retracted query parameters. Off by default so everything is ingested.
+	SkipParatextWorks  bool
+	SkipRetractedWorks bool
+
+	// Write the Domain/Field/Subfield/Topic hierarchy and the HAS_TOPIC edges of saved works
+	// and authors. Turning it off roughly halves the write volume of an ingest, for
+	// deployments that only need the author/work/citation graph, but topic reads (topic
+	// profiles, similar works, venue and institution top topics) then know nothing about
+	// what is saved from then on.
+	PersistTopics bool
+
+	// Cypher statements taking longer than SlowQueryThreshold are logged with their query
+	// name. Zero turns the log off; the per-query durations are in /metrics either way.
+	SlowQueryThreshold time.Duration
+
+	// Background ingestion limits. Each background job is cancelled once it has run for
+	// BackgroundJobTimeout, and at most MaxBackgroundJobs run at once across the process;
+	// further jobs wait for a free slot (their deadline keeps running while they wait).
+	BackgroundJobTimeout time.Duration
+	MaxBackgroundJobs    int
+	// On shutdown, running and queued background jobs get JobDrainTimeout to finish before
+	// they are cancelled, recorded as interrupted, and the database is closed.
+	JobDrainTimeout time.Duration
+	// Resume the author ingestions a previous run left unfinished when the service starts.
+	// Off by default; they can always be resumed with POST /api/jobs/{id}/resume.
+	ResumeJobsOnStartup bool
+
+	// Number of save pool workers. Graph writes are sharded over them by author, so writes
+	// for one author are ordered while different authors are written in parallel.
+	SavePoolShards int
+
+	// Upper bound on the works a single filter-query ingest (/api/ingest/query) may save.
+	MaxQueryIngestWorks int
+	// How many of the top matches an author name search with ingest=true ingests, each as
+	// its own background job. 0 turns the auto-ingest off.
+	NameSearchAutoIngest int
+	// Upper bound on the cited stub works an ingest resolves with resolve_references.
+	MaxResolvedReferences int
+	// How many institutions an enrichment pass fetches from OpenAlex at once.
+	EnrichConcurrency int
+	// Filter keys accepted in user-supplied OpenAlex filter strings. Empty means the
+	// openalex.DefaultFilterAllowlist.
+	FilterAllowlist []string
+
+	// Per-route rate limits, in requests per second (0 disables limiting). Ingest routes are
+	// expensive and hit upstream APIs, so they get a much stricter budget than reads.
+	IngestRateLimit float64
+	IngestRateBurst int
+	ReadRateLimit   float64
+	ReadRateBurst   int
+	RateLimitPerIP  bool
+
+	// Outbound OpenAlex pacing. The rate limit is shared by all jobs in the process; keep the
+	// burst small so bursts are smoothed out. Paginated fetches also pause a random
+	// duration between OpenAlexPageJitterMin and OpenAlexPageJitterMax between pages.
+	OpenAlexRateLimit     float64
+	OpenAlexRateBurst     int
+	OpenAlexPageJitterMin time.Duration
+	OpenAlexPageJitterMax time.Duration
+	// Log every OpenAlex request (with api_key / mailto redacted) at debug level.
+	OpenAlexDebugLog bool
+	// Page size of OpenAlex list fetches and the default per_page of paged endpoints;
+	// values above OpenAlex's maximum of 200 are clamped.
+	OpenAlexPerPage int
+
+	// Webhooks receiving work.saved / author.saved events, signed with WebhookSecret.
+	// No events are published when WebhookURLs is empty.
+	WebhookURLs   []string
+	WebhookSecret string
+
+	// TenantAPIKeys maps API keys (sent as X-API-Key) to the tenant they are scoped to.
+	// Requests without a key may pick a tenant with the X-Tenant header; requests with
+	// neither use the shared namespace.
+	TenantAPIKeys map[string]string
+	// Reject requests without a valid X-API-Key (except the /readyz and /metrics probes).
+	// On by default in prod.
+	RequireAPIKey bool
+
+	// Overall deadline of a /api/search request; slower entity types are cut off.
+	SearchTimeout time.Duration
+	// Authors whose h-index computed from the graph differs from OpenAlex's by more than
+	// HIndexDriftThreshold are counted in a metric, refreshed every HIndexDriftInterval
+	// (0 disables it).
+	HIndexDriftThreshold int
+	HIndexDriftInterval  time.Duration
+
+	// How long ngrams fetched from OpenAlex are served from memory.
+	NgramCacheTTL time.Duration
+
+	// Gzip compression of responses of at least GzipMinSize bytes, at GzipLevel (1-9), for
+	// clients that send Accept-Encoding: gzip.
+	GzipResponses bool
+	GzipLevel     int
+	GzipMinSize   int
+
+	// /readyz always checks Neo4j. With ReadyzCheckOpenAlex it also sends OpenAlex a
+	// one-result request, whose outcome is reused for ReadyzOpenAlexTTL so frequent probes
+	// don't flood OpenAlex. Every check is given ReadyzTimeout.
+	ReadyzCheckOpenAlex bool
+	ReadyzOpenAlexTTL   time.Duration
+	ReadyzTimeout       time.Duration
+
+	// The server speaks HTTPS (and HTTP/2) with this certificate and key when both are set,
+	// plain HTTP when neither is.
+	TLSCertFile string
+	TLSKeyFile  string
 }
 
-// LoadConfig reads configuration from environment variables.
-func LoadConfig() *Config {
-	return &Config{
+// Storage backends.
+const (
+	StorageNeo4j = "neo4j"
+	StorageNone  = "none"
+)
+
+// Deployment environments (APP_ENV).
+const (
+	EnvDev     = "dev"
+	EnvStaging = "staging"
+	EnvProd    = "prod"
+)
+
+// profile holds the defaults that differ between environments; variables that are set
+// always win over them. OpenAlex request logging is off in every profile, dev included,
+// and only OPENALEX_DEBUG_LOG turns it on.
+type profile struct {
+	RequireAPIKey bool
+}
+
+var profiles = map[string]profile{
+	EnvDev:     {},
+	EnvStaging: {},
+	EnvProd:    {RequireAPIKey: true},
+}
+
+// TLSEnabled reports whether the server is configured to serve HTTPS.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// StorageDisabled reports whether the service runs without a database.
+func (c *Config) StorageDisabled() bool {
+	return c.StorageBackend == StorageNone
+}
+
+// LoadConfig reads configuration from environment variables. Unset variables take their
+// defaults, but malformed ones (e.g. BACKGROUND_JOB_TIMEOUT=30 without a unit) are not
+// silently replaced by them: they are all reported together in the returned error, so
+// misconfiguration shows up at startup.
+func LoadConfig() (*Config, error) {
+	var env envParser
+	appEnv := strings.ToLower(getEnv("APP_ENV", EnvDev))
+	defaults, ok := profiles[appEnv]
+	if !ok {
+		env.invalid("APP_ENV", appEnv, fmt.Sprintf("%q, %q or %q", EnvDev, EnvStaging, EnvProd))
+	}
+	cfg := &Config{
+		AppEnv:                appEnv,
+		StorageBackend:        getEnv("STORAGE_BACKEND", StorageNeo4j),
 		Neo4jURI:              getEnv("NEO4J_URI", "neo4j://localhost:7687"),
 		Neo4jUsername:         getEnv("NEO4J_USERNAME", "neo4j"),
 		Neo4jPassword:         getEnv("NEO4J_PASSWORD", "password"),
 		SemanticScholarAPIKey: os.Getenv("SEMANTIC_SCHOLAR_API_KEY"),
+		SSRateLimit:           env.Float("SEMANTIC_SCHOLAR_RATE_LIMIT", 0),
+		SSRateBurst:           env.Int("SEMANTIC_SCHOLAR_RATE_BURST", 1),
+		SSTimeout:             env.Duration("SEMANTIC_SCHOLAR_TIMEOUT", 20*time.Second),
+		SkipParatextWorks:     env.Bool("SKIP_PARATEXT_WORKS", false),
+		SkipRetractedWorks:    env.Bool("SKIP_RETRACTED_WORKS", false),
+		PersistTopics:         env.Bool("PERSIST_TOPICS", true),
+		SlowQueryThreshold:    env.NonNegDuration("NEO4J_SLOW_QUERY_THRESHOLD", time.Second),
+		BackgroundJobTimeout:  env.Duration("BACKGROUND_JOB_TIMEOUT", 30*time.Minute),
+		MaxBackgroundJobs:     env.Int("MAX_BACKGROUND_JOBS", 4),
+		JobDrainTimeout:       env.Duration("JOB_DRAIN_TIMEOUT", 30*time.Second),
+		ResumeJobsOnStartup:   env.Bool("RESUME_JOBS_ON_STARTUP", false),
+		SavePoolShards:        env.Int("SAVE_POOL_SHARDS", 4),
+		MaxQueryIngestWorks:   env.Int("MAX_QUERY_INGEST_WORKS", 10000),
+		NameSearchAutoIngest:  env.NonNegInt("NAME_SEARCH_AUTO_INGEST", 1),
+		MaxResolvedReferences: env.Int("MAX_RESOLVED_REFERENCES", 500),
+		EnrichConcurrency:     env.Int("INSTITUTION_ENRICH_CONCURRENCY", 4),
+		FilterAllowlist:       getEnvList("OPENALEX_FILTER_ALLOWLIST"),
+		IngestRateLimit:       env.Float("INGEST_RATE_LIMIT", 0.2),
+		IngestRateBurst:       env.Int("INGEST_RATE_BURST", 3),
+		ReadRateLimit:         env.Float("READ_RATE_LIMIT", 10),
+		ReadRateBurst:         env.Int("READ_RATE_BURST", 20),
+		RateLimitPerIP:        env.Bool("RATE_LIMIT_PER_IP", true),
+		OpenAlexRateLimit:     env.Float("OPENALEX_RATE_LIMIT", 8),
+		OpenAlexRateBurst:     env.Int("OPENALEX_RATE_BURST", 1),
+		OpenAlexPageJitterMin: env.Duration("OPENALEX_PAGE_JITTER_MIN", 100*time.Millisecond),
+		OpenAlexPageJitterMax: env.Duration("OPENALEX_PAGE_JITTER_MAX", 400*time.Millisecond),
+		OpenAlexDebugLog:      env.Bool("OPENALEX_DEBUG_LOG", false),
+		OpenAlexPerPage:       env.Int("OPENALEX_PER_PAGE", 25),
+		WebhookURLs:           getEnvList("WEBHOOK_URLS"),
+		WebhookSecret:         os.Getenv("WEBHOOK_SECRET"),
+		TenantAPIKeys:         env.Map("TENANT_API_KEYS"),
+		RequireAPIKey:         env.Bool("REQUIRE_API_KEY", defaults.RequireAPIKey),
+		SearchTimeout:         env.Duration("SEARCH_TIMEOUT", 2*time.Second),
+		HIndexDriftThreshold:  env.Int("HINDEX_DRIFT_THRESHOLD", 2),
+		HIndexDriftInterval:   env.NonNegDuration("HINDEX_DRIFT_INTERVAL", time.Hour),
+		NgramCacheTTL:         env.Duration("NGRAM_CACHE_TTL", time.Hour),
+		GzipResponses:         env.Bool("GZIP_RESPONSES", true),
+		GzipLevel:             env.Int("GZIP_LEVEL", 6),
+		GzipMinSize:           env.Int("GZIP_MIN_SIZE", 1024),
+		ReadyzCheckOpenAlex:   env.Bool("READYZ_CHECK_OPENALEX", false),
+		ReadyzOpenAlexTTL:     env.Duration("READYZ_OPENALEX_TTL", 30*time.Second),
+		ReadyzTimeout:         env.Duration("READYZ_TIMEOUT", 2*time.Second),
+		TLSCertFile:           os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:            os.Getenv("TLS_KEY_FILE"),
+	}
+
+	if cfg.StorageBackend != StorageNeo4j && cfg.StorageBackend != StorageNone {
+		env.invalid("STORAGE_BACKEND", cfg.StorageBackend, fmt.Sprintf("%q or %q", StorageNeo4j, StorageNone))
+	}
+	// A bucket that never refills would stall every OpenAlex call after the first burst.
+	if cfg.OpenAlexRateLimit <= 0 {
+		env.invalid("OPENALEX_RATE_LIMIT", os.Getenv("OPENALEX_RATE_LIMIT"), "a positive number")
+	}
+	// Scopes are tenant names, which are lowercase; the keys themselves are never echoed.
+	for key, scope := range cfg.TenantAPIKeys {
+		scope = strings.ToLower(scope)
+		if !tenant.Valid(scope) {
+			env.invalid("TENANT_API_KEYS", scope, "tenant names of letters, digits, '-' and '_' (at most 63 characters)")
+			continue
+		}
+		cfg.TenantAPIKeys[key] = scope
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		env.errs = append(env.errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	env.errs = append(env.errs, cfg.checkEnvironment()...)
+	if len(env.errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(env.errs...))
+	}
+	return cfg, nil
+}
+
+// checkEnvironment applies the rules that are stricter outside dev: webhooks must be
+// signed in staging and prod, and prod also needs a real Neo4j password and API keys when
+// they are required. Dev accepts anything that parses.
+func (c *Config) checkEnvironment() []error {
+	var errs []error
+	if c.AppEnv == EnvDev {
+		return nil
+	}
+	if len(c.WebhookURLs) > 0 && c.WebhookSecret == "" {
+		errs = append(errs, fmt.Errorf("WEBHOOK_SECRET must be set when WEBHOOK_URLS is (APP_ENV=%s)", c.AppEnv))
 	}
+	if c.AppEnv != EnvProd {
+		return errs
+	}
+	if !c.StorageDisabled() && (c.Neo4jPassword == "" || c.Neo4jPassword == "password") {
+		errs = append(errs, errors.New("NEO4J_PASSWORD must be set to something other than the default (APP_ENV=prod)"))
+	}
+	if c.RequireAPIKey && len(c.TenantAPIKeys) == 0 {
+		errs = append(errs, errors.New("TENANT_API_KEYS must list at least one key when REQUIRE_API_KEY is on (APP_ENV=prod)"))
+	}
+	return errs
 }
 
 // Helper function to get an environment variable or return a default.
@@ -29,3 +298,130 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// lookupEnv is os.LookupEnv, except that variables set to an empty string (KEY= in a .env
+// file) count as unset.
+func lookupEnv(key string) (string, bool) {
+	value := strings.TrimSpace(os.Getenv(key))
+	return value, value != ""
+}
+
+// envParser reads typed environment variables, collecting an error for every variable
+// that is set but can't be parsed; unset variables take the fallback.
+type envParser struct {
+	errs []error
+}
+
+func (p *envParser) invalid(key, value, want string) {
+	p.errs = append(p.errs, fmt.Errorf("%s=%q: want %s", key, value, want))
+}
+
+// Bool reads a boolean (true/false, 1/0, ...).
+func (p *envParser) Bool(key string, fallback bool) bool {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		p.invalid(key, value, "a boolean")
+		return fallback
+	}
+	return b
+}
+
+// Duration reads a positive duration such as "30m" or "250ms".
+func (p *envParser) Duration(key string, fallback time.Duration) time.Duration {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		p.invalid(key, value, "a positive duration such as 30s or 5m")
+		return fallback
+	}
+	return d
+}
+
+// NonNegDuration is Duration for settings where 0 turns something off.
+func (p *envParser) NonNegDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		p.invalid(key, value, "a non-negative duration such as 30s or 5m, or 0")
+		return fallback
+	}
+	return d
+}
+
+// Int reads a positive integer.
+func (p *envParser) Int(key string, fallback int) int {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		p.invalid(key, value, "a positive integer")
+		return fallback
+	}
+	return n
+}
+
+// NonNegInt reads a non-negative integer, for settings where 0 turns something off.
+func (p *envParser) NonNegInt(key string, fallback int) int {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		p.invalid(key, value, "a non-negative integer")
+		return fallback
+	}
+	return n
+}
+
+// Float reads a non-negative number.
+func (p *envParser) Float(key string, fallback float64) float64 {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		p.invalid(key, value, "a non-negative number")
+		return fallback
+	}
+	return f
+}
+
+// Helper function to read a comma-separated environment variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Map reads a comma-separated list of key:value pairs. Values may be secrets (API keys),
+// so malformed entries are reported by position only.
+func (p *envParser) Map(key string) map[string]string {
+	values := make(map[string]string)
+	for i, pair := range getEnvList(key) {
+		k, v, ok := strings.Cut(pair, ":")
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); !ok || k == "" || v == "" {
+			p.errs = append(p.errs, fmt.Errorf("%s: entry %d is not a key:value pair", key, i+1))
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
@@ -0,0 +1,337 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadConfig runs LoadConfig with the given environment variables set for the test.
+func loadConfig(t *testing.T, env map[string]string) (*Config, error) {
+	t.Helper()
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	return LoadConfig()
+}
+
+func TestLoadConfigOpenAlexPacing(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OpenAlexRateLimit != 8 || cfg.OpenAlexRateBurst != 1 {
+					t.Errorf("rate limit = %v/%d, want 8/1", cfg.OpenAlexRateLimit, cfg.OpenAlexRateBurst)
+				}
+				if cfg.OpenAlexPageJitterMin != 100*time.Millisecond || cfg.OpenAlexPageJitterMax != 400*time.Millisecond {
+					t.Errorf("jitter = [%v, %v], want [100ms, 400ms]", cfg.OpenAlexPageJitterMin, cfg.OpenAlexPageJitterMax)
+				}
+			},
+		},
+		{
+			name: "configured",
+			env: map[string]string{
+				"OPENALEX_RATE_LIMIT": "2.5", "OPENALEX_RATE_BURST": "3",
+				"OPENALEX_PAGE_JITTER_MIN": "50ms", "OPENALEX_PAGE_JITTER_MAX": "1s",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OpenAlexRateLimit != 2.5 || cfg.OpenAlexRateBurst != 3 {
+					t.Errorf("rate limit = %v/%d, want 2.5/3", cfg.OpenAlexRateLimit, cfg.OpenAlexRateBurst)
+				}
+				if cfg.OpenAlexPageJitterMin != 50*time.Millisecond || cfg.OpenAlexPageJitterMax != time.Second {
+					t.Errorf("jitter = [%v, %v], want [50ms, 1s]", cfg.OpenAlexPageJitterMin, cfg.OpenAlexPageJitterMax)
+				}
+			},
+		},
+		{name: "zero rate", env: map[string]string{"OPENALEX_RATE_LIMIT": "0"}, wantErr: "OPENALEX_RATE_LIMIT"},
+		{name: "negative rate", env: map[string]string{"OPENALEX_RATE_LIMIT": "-1"}, wantErr: "OPENALEX_RATE_LIMIT"},
+		{name: "zero burst", env: map[string]string{"OPENALEX_RATE_BURST": "0"}, wantErr: "OPENALEX_RATE_BURST"},
+		{name: "jitter without unit", env: map[string]string{"OPENALEX_PAGE_JITTER_MAX": "300"}, wantErr: "OPENALEX_PAGE_JITTER_MAX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(t, tt.env)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want one about %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadConfigSemanticScholar(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantRate  float64
+		wantBurst int
+		wantErr   string
+	}{
+		{name: "defaults", wantRate: 0, wantBurst: 1},
+		{name: "configured", env: map[string]string{"SEMANTIC_SCHOLAR_RATE_LIMIT": "0.5", "SEMANTIC_SCHOLAR_RATE_BURST": "2",
+			"SEMANTIC_SCHOLAR_TIMEOUT": "5s"}, wantRate: 0.5, wantBurst: 2},
+		{name: "negative rate", env: map[string]string{"SEMANTIC_SCHOLAR_RATE_LIMIT": "-1"}, wantErr: "SEMANTIC_SCHOLAR_RATE_LIMIT"},
+		{name: "zero burst", env: map[string]string{"SEMANTIC_SCHOLAR_RATE_BURST": "0"}, wantErr: "SEMANTIC_SCHOLAR_RATE_BURST"},
+		{name: "timeout without unit", env: map[string]string{"SEMANTIC_SCHOLAR_TIMEOUT": "20"}, wantErr: "SEMANTIC_SCHOLAR_TIMEOUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(t, tt.env)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want one about %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			// A rate of 0 leaves the client its default, which depends on the API key.
+			if cfg.SSRateLimit != tt.wantRate || cfg.SSRateBurst != tt.wantBurst {
+				t.Errorf("rate limit = %v/%d, want %v/%d", cfg.SSRateLimit, cfg.SSRateBurst, tt.wantRate, tt.wantBurst)
+			}
+			wantTimeout := 20 * time.Second
+			if tt.env["SEMANTIC_SCHOLAR_TIMEOUT"] != "" {
+				wantTimeout = 5 * time.Second
+			}
+			if cfg.SSTimeout != wantTimeout {
+				t.Errorf("timeout = %v, want %v", cfg.SSTimeout, wantTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTenantAPIKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "unset", want: map[string]string{}},
+		{name: "scopes", value: "k1:team-a, k2:lab_2", want: map[string]string{"k1": "team-a", "k2": "lab_2"}},
+		{name: "scopes are lowercased", value: "k1:Team-A", want: map[string]string{"k1": "team-a"}},
+		{name: "keys keep their case", value: "AbC:team", want: map[string]string{"AbC": "team"}},
+		{name: "invalid scope", value: "k1:team a", wantErr: `TENANT_API_KEYS="team a"`},
+		{name: "scope too long", value: "k1:" + strings.Repeat("a", 64), wantErr: "TENANT_API_KEYS"},
+		{name: "missing scope", value: "k1", wantErr: "TENANT_API_KEYS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(t, map[string]string{"TENANT_API_KEYS": tt.value})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want one containing %s", err, tt.wantErr)
+				}
+				if strings.Contains(err.Error(), "k1") {
+					t.Errorf("error %q reveals the API key", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if len(cfg.TenantAPIKeys) != len(tt.want) {
+				t.Fatalf("TenantAPIKeys = %v, want %v", cfg.TenantAPIKeys, tt.want)
+			}
+			for key, scope := range tt.want {
+				if cfg.TenantAPIKeys[key] != scope {
+					t.Errorf("scope of %s = %q, want %q", key, cfg.TenantAPIKeys[key], scope)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigGzip(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantEnabled bool
+		wantLevel   int
+		wantMinSize int
+	}{
+		{"defaults", nil, true, 6, 1024},
+		{"configured", map[string]string{"GZIP_LEVEL": "1", "GZIP_MIN_SIZE": "256"}, true, 1, 256},
+		{"disabled", map[string]string{"GZIP_RESPONSES": "false"}, false, 6, 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(t, tt.env)
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.GzipResponses != tt.wantEnabled || cfg.GzipLevel != tt.wantLevel || cfg.GzipMinSize != tt.wantMinSize {
+				t.Errorf("gzip = %v level %d min %d, want %v level %d min %d",
+					cfg.GzipResponses, cfg.GzipLevel, cfg.GzipMinSize, tt.wantEnabled, tt.wantLevel, tt.wantMinSize)
+			}
+		})
+	}
+}
+
+func TestLoadConfigHIndexDrift(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		wantThreshold int
+		wantInterval  time.Duration
+		wantErr       string
+	}{
+		{name: "defaults", wantThreshold: 2, wantInterval: time.Hour},
+		{name: "configured", env: map[string]string{"HINDEX_DRIFT_THRESHOLD": "5", "HINDEX_DRIFT_INTERVAL": "10m"}, wantThreshold: 5, wantInterval: 10 * time.Minute},
+		{name: "disabled", env: map[string]string{"HINDEX_DRIFT_INTERVAL": "0"}, wantThreshold: 2},
+		{name: "negative interval", env: map[string]string{"HINDEX_DRIFT_INTERVAL": "-1m"}, wantErr: "HINDEX_DRIFT_INTERVAL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(t, tt.env)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want one about %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.HIndexDriftThreshold != tt.wantThreshold || cfg.HIndexDriftInterval != tt.wantInterval {
+				t.Errorf("drift threshold %d every %v, want %d every %v",
+					cfg.HIndexDriftThreshold, cfg.HIndexDriftInterval, tt.wantThreshold, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestLoadConfigSavePoolShards(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: 4},
+		{name: "configured", value: "16", want: 16},
+		{name: "zero", value: "0", wantErr: true},
+		{name: "negative", value: "-2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{}
+			if tt.value != "" {
+				env["SAVE_POOL_SHARDS"] = tt.value
+			}
+			cfg, err := loadConfig(t, env)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "SAVE_POOL_SHARDS") {
+					t.Fatalf("LoadConfig error = %v, want one about SAVE_POOL_SHARDS", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.SavePoolShards != tt.want {
+				t.Errorf("%d shards, want %d", cfg.SavePoolShards, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigAppEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantEnv      string
+		wantDebugLog bool
+		wantRequire  bool
+		wantErr      []string
+		notAbout     string
+	}{
+		{name: "dev by default", wantEnv: EnvDev},
+		{name: "dev logs OpenAlex requests when asked", env: map[string]string{"OPENALEX_DEBUG_LOG": "true"}, wantEnv: EnvDev, wantDebugLog: true},
+		{name: "dev accepts webhooks without a secret", env: map[string]string{"WEBHOOK_URLS": "https://hooks.example.com/x"}, wantEnv: EnvDev},
+		{name: "staging", env: map[string]string{"APP_ENV": "staging"}, wantEnv: EnvStaging},
+		{
+			name:    "staging needs signed webhooks",
+			env:     map[string]string{"APP_ENV": "staging", "WEBHOOK_URLS": "https://hooks.example.com/x"},
+			wantErr: []string{"WEBHOOK_SECRET"},
+		},
+		{
+			name:        "prod",
+			env:         map[string]string{"APP_ENV": "prod", "NEO4J_PASSWORD": "s3cret", "TENANT_API_KEYS": "k1:team"},
+			wantEnv:     EnvProd,
+			wantRequire: true,
+		},
+		{
+			name:        "case-insensitive",
+			env:         map[string]string{"APP_ENV": "PROD", "NEO4J_PASSWORD": "s3cret", "TENANT_API_KEYS": "k1:team"},
+			wantEnv:     EnvProd,
+			wantRequire: true,
+		},
+		{
+			name:         "set variables win over the profile",
+			env:          map[string]string{"APP_ENV": "prod", "NEO4J_PASSWORD": "s3cret", "REQUIRE_API_KEY": "false", "OPENALEX_DEBUG_LOG": "true"},
+			wantEnv:      EnvProd,
+			wantDebugLog: true,
+		},
+		{
+			name:    "prod rejects the defaults",
+			env:     map[string]string{"APP_ENV": "prod"},
+			wantErr: []string{"NEO4J_PASSWORD", "TENANT_API_KEYS"},
+		},
+		{
+			name:    "prod rejects the default password",
+			env:     map[string]string{"APP_ENV": "prod", "NEO4J_PASSWORD": "password", "TENANT_API_KEYS": "k1:team"},
+			wantErr: []string{"NEO4J_PASSWORD"},
+		},
+		{
+			name:     "prod without storage needs no password",
+			env:      map[string]string{"APP_ENV": "prod", "STORAGE_BACKEND": "none"},
+			wantErr:  []string{"TENANT_API_KEYS"},
+			notAbout: "NEO4J_PASSWORD",
+		},
+		{
+			name:    "prod needs signed webhooks",
+			env:     map[string]string{"APP_ENV": "prod", "NEO4J_PASSWORD": "s3cret", "TENANT_API_KEYS": "k1:team", "WEBHOOK_URLS": "https://hooks.example.com/x"},
+			wantErr: []string{"WEBHOOK_SECRET"},
+		},
+		{name: "unknown", env: map[string]string{"APP_ENV": "qa"}, wantErr: []string{"APP_ENV"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(t, tt.env)
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("LoadConfig succeeded, want errors about %v", tt.wantErr)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("LoadConfig error = %v, want one about %s", err, want)
+					}
+				}
+				if tt.notAbout != "" && strings.Contains(err.Error(), tt.notAbout) {
+					t.Errorf("LoadConfig error = %v, want none about %s", err, tt.notAbout)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.AppEnv != tt.wantEnv || cfg.OpenAlexDebugLog != tt.wantDebugLog || cfg.RequireAPIKey != tt.wantRequire {
+				t.Errorf("APP_ENV=%s debug log %v require key %v, want %s, %v, %v",
+					cfg.AppEnv, cfg.OpenAlexDebugLog, cfg.RequireAPIKey, tt.wantEnv, tt.wantDebugLog, tt.wantRequire)
+			}
+		})
+	}
+}
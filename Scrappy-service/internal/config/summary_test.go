@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	if got := Redact(""); got != "(unset)" {
+		t.Errorf("Redact(\"\") = %q, want (unset)", got)
+	}
+	if got := Redact("s3cret"); got != "********" {
+		t.Errorf("Redact(s3cret) = %q, want ********", got)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"unset", "", "(unset)"},
+		{"no secrets", "neo4j://localhost:7687", "neo4j://localhost:7687"},
+		{"password", "neo4j://neo4j:s3cret@db:7687", "neo4j://neo4j:xxxxx@db:7687"},
+		{"user only", "neo4j://neo4j@db:7687", "neo4j://neo4j@db:7687"},
+		{"query token", "https://hooks.example.com/x?token=s3cret", "https://hooks.example.com/x?xxxxx"},
+		{"unparsable", "http://[::1:s3cret", "********"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactURL(tt.raw); got != tt.want {
+				t.Errorf("RedactURL(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryMasksSecrets(t *testing.T) {
+	cfg, err := loadConfig(t, map[string]string{
+		"NEO4J_URI":                "neo4j://neo4j:uri-pass@db:7687",
+		"NEO4J_PASSWORD":           "db-pass",
+		"SEMANTIC_SCHOLAR_API_KEY": "ss-key",
+		"TENANT_API_KEYS":          "key-a:team-a,key-b:team-b,key-c:team-a",
+		"WEBHOOK_URLS":             "https://hooks.example.com/x?token=hook-token",
+		"WEBHOOK_SECRET":           "hook-secret",
+	})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	values := map[string]string{}
+	for _, setting := range cfg.Summary() {
+		for _, secret := range []string{"uri-pass", "db-pass", "ss-key", "key-a", "key-b", "key-c", "hook-token", "hook-secret"} {
+			if strings.Contains(setting.Value, secret) {
+				t.Errorf("%s = %q reveals %s", setting.Name, setting.Value, secret)
+			}
+		}
+		values[setting.Name] = setting.Value
+	}
+	want := map[string]string{
+		"NEO4J_URI":                "neo4j://neo4j:xxxxx@db:7687",
+		"NEO4J_PASSWORD":           "********",
+		"SEMANTIC_SCHOLAR_API_KEY": "********",
+		"TENANT_API_KEYS":          "3 key(s) for tenants [team-a, team-b]",
+		"WEBHOOK_URLS":             "[https://hooks.example.com/x?xxxxx]",
+		"WEBHOOK_SECRET":           "********",
+		"APP_ENV":                  "dev",
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %q, want %q", name, values[name], value)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// ReconstructAbstract rebuilds abstract text from an OpenAlex abstract_inverted_index,
+// which maps every word to the positions it occurs at. It returns "" for an empty index.
+func ReconstructAbstract(index map[string][]int) string {
+	type placed struct {
+		pos  int
+		word string
+	}
+	var words []placed
+	for word, positions := range index {
+		for _, pos := range positions {
+			words = append(words, placed{pos, word})
+		}
+	}
+	sort.Slice(words, func(i, j int) bool { return words[i].pos < words[j].pos })
+
+	var b strings.Builder
+	for i, w := range words {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(w.word)
+	}
+	return b.String()
+}
+
+// UnmarshalJSON decodes an OpenAlex work and fills Abstract from the inverted index. The
+// index is dropped afterwards: it is several times the size of the text, and works are
+// held in memory by the thousand during an ingestion.
+func (w *Work) UnmarshalJSON(data []byte) error {
+	type plain Work // Drops the methods, so this doesn't recurse.
+	if err := json.Unmarshal(data, (*plain)(w)); err != nil {
+		return err
+	}
+	if w.Abstract == "" {
+		w.Abstract = ReconstructAbstract(w.AbstractInvertedIndex)
+	}
+	w.AbstractInvertedIndex = nil
+	return nil
+}
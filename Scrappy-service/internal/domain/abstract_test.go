@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReconstructAbstract(t *testing.T) {
+	tests := []struct {
+		name  string
+		index map[string][]int
+		want  string
+	}{
+		{"empty", nil, ""},
+		{"in order", map[string][]int{"Deep": {0}, "learning": {1}}, "Deep learning"},
+		{"repeated words", map[string][]int{"the": {0, 3}, "cat": {1}, "saw": {2}, "dog": {4}}, "the cat saw the dog"},
+	}
+	for _, tt := range tests {
+		if got := ReconstructAbstract(tt.index); got != tt.want {
+			t.Errorf("%s: ReconstructAbstract = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWorkUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name, data   string
+		wantAbstract string
+	}{
+		{"inverted index", `{"id": "W1", "abstract_inverted_index": {"b": [1], "a": [0]}}`, "a b"},
+		{"abstract given", `{"id": "W1", "abstract": "kept", "abstract_inverted_index": {"x": [0]}}`, "kept"},
+		{"no abstract", `{"id": "W1", "abstract_inverted_index": null}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var work Work
+			if err := json.Unmarshal([]byte(tt.data), &work); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if work.ID != "W1" || work.Abstract != tt.wantAbstract {
+				t.Errorf("work = %+v, want id W1 and abstract %q", work, tt.wantAbstract)
+			}
+			if work.AbstractInvertedIndex != nil {
+				t.Errorf("inverted index %v is kept after reconstruction", work.AbstractInvertedIndex)
+			}
+		})
+	}
+}
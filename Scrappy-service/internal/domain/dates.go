@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Precisions of a parsed publication date.
+const (
+	DatePrecisionDay   = "day"
+	DatePrecisionMonth = "month"
+	DatePrecisionYear  = "year"
+)
+
+// ParsePublicationDate parses an OpenAlex publication date. Full dates (2006-01-02) are
+// returned as is; partial ones fall back to the first day of the month (2006-01) or year
+// (2006), with the precision saying which parts are real. ok is false for empty or
+// unparseable input.
+func ParsePublicationDate(s string) (t time.Time, precision string, ok bool) {
+	s = strings.TrimSpace(s)
+	layouts := []struct {
+		layout    string
+		precision string
+	}{
+		{"2006-01-02", DatePrecisionDay},
+		{"2006-01", DatePrecisionMonth},
+		{"2006", DatePrecisionYear},
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l.layout, s); err == nil {
+			return t, l.precision, true
+		}
+	}
+	return time.Time{}, "", false
+}
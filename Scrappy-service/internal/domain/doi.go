@@ -0,0 +1,18 @@
+package domain
+
+import "strings"
+
+// NormalizeDOI reduces the different DOI spellings found in the wild
+// ("https://doi.org/10.1/ABC", "doi:10.1/abc", "10.1/abc") to a bare, lowercase DOI
+// suitable for equality comparisons. It returns "" for an empty input.
+func NormalizeDOI(doi string) string {
+	doi = strings.TrimSpace(doi)
+	lower := strings.ToLower(doi)
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"} {
+		if strings.HasPrefix(lower, prefix) {
+			lower = lower[len(prefix):]
+			break
+		}
+	}
+	return strings.TrimSpace(lower)
+}
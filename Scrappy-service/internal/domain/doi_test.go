@@ -0,0 +1,30 @@
+package domain
+
+import "testing"
+
+func TestNormalizeDOI(t *testing.T) {
+	tests := []struct {
+		doi  string
+		want string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"10.1038/nature14539", "10.1038/nature14539"},
+		{"10.1038/NATURE14539", "10.1038/nature14539"},
+		{"https://doi.org/10.1038/Nature14539", "10.1038/nature14539"},
+		{"http://doi.org/10.1038/nature14539", "10.1038/nature14539"},
+		{"https://dx.doi.org/10.1038/nature14539", "10.1038/nature14539"},
+		{"http://dx.doi.org/10.1038/nature14539", "10.1038/nature14539"},
+		{"DOI:10.1038/nature14539", "10.1038/nature14539"},
+		{"  https://DOI.org/10.1038/nature14539  ", "10.1038/nature14539"},
+		{"doi: 10.1038/nature14539", "10.1038/nature14539"},
+		// Only one prefix is stripped.
+		{"doi:https://doi.org/10.1/a", "https://doi.org/10.1/a"},
+		{"https://doi.org/", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeDOI(tt.doi); got != tt.want {
+			t.Errorf("NormalizeDOI(%q) = %q, want %q", tt.doi, got, tt.want)
+		}
+	}
+}
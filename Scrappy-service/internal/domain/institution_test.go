@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestInstitutionAssociatedInstitutions(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []AssociatedInstitution
+	}{
+		{
+			name: "relationships",
+			data: `{"id": "I1", "associated_institutions": [
+				{"id": "I2", "display_name": "University", "country_code": "DE", "type": "education", "relationship": "parent"},
+				{"id": "I3", "display_name": "Department", "relationship": "child"},
+				{"id": "I4", "display_name": "Hospital", "relationship": "related"}]}`,
+			want: []AssociatedInstitution{
+				{DehydratedInstitution{ID: "I2", DisplayName: "University", CountryCode: "DE", Type: "education"}, InstitutionParent},
+				{DehydratedInstitution{ID: "I3", DisplayName: "Department"}, InstitutionChild},
+				{DehydratedInstitution{ID: "I4", DisplayName: "Hospital"}, InstitutionRelated},
+			},
+		},
+		{name: "none", data: `{"id": "I1", "associated_institutions": []}`, want: []AssociatedInstitution{}},
+		{name: "missing", data: `{"id": "I1"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var institution Institution
+			if err := json.Unmarshal([]byte(tt.data), &institution); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(institution.AssociatedInstitutions, tt.want) {
+				t.Errorf("associated institutions = %+v, want %+v", institution.AssociatedInstitutions, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "strings"
+
+// iso639Alpha3 maps the three-letter (ISO 639-2/B, 639-2/T and 639-3) codes of common
+// languages that sometimes appear in metadata to their ISO 639-1 code.
+var iso639Alpha3 = map[string]string{
+	"ara": "ar", "ces": "cs", "cze": "cs", "dan": "da", "deu": "de", "ger": "de",
+	"ell": "el", "gre": "el", "eng": "en", "spa": "es", "fas": "fa", "per": "fa",
+	"fin": "fi", "fra": "fr", "fre": "fr", "heb": "he", "hin": "hi", "hun": "hu",
+	"ind": "id", "ita": "it", "jpn": "ja", "kor": "ko", "nld": "nl", "dut": "nl",
+	"nor": "no", "pol": "pl", "por": "pt", "ron": "ro", "rum": "ro", "rus": "ru",
+	"swe": "sv", "tha": "th", "tur": "tr", "ukr": "uk", "vie": "vi", "zho": "zh",
+	"chi": "zh",
+}
+
+// NormalizeLanguage reduces a language code ("en", "EN", "en-US", "pt_BR", "eng") to its
+// lowercase ISO 639-1 code. It returns "" for an empty input and for codes it can't map.
+func NormalizeLanguage(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if alpha2, ok := iso639Alpha3[code]; ok {
+		return alpha2
+	}
+	if len(code) != 2 || code[0] < 'a' || code[0] > 'z' || code[1] < 'a' || code[1] > 'z' {
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// LocalIDPrefix starts the IDs of entities that don't come from OpenAlex, such as
+// deposited works and the authors they name.
+const LocalIDPrefix = "LOCAL-"
+
+// IsLocalID reports whether id was generated for an entity outside OpenAlex.
+func IsLocalID(id string) bool {
+	return strings.HasPrefix(id, LocalIDPrefix)
+}
+
+// LocalWorkID returns the ID of a deposited work: a hash of its normalized DOI, or of its
+// title (case and spacing ignored) and publication year when it has no DOI. The same
+// content always gets the same ID, so depositing it again updates the same node.
+func LocalWorkID(work Work) string {
+	if doi := NormalizeDOI(work.Doi); doi != "" {
+		return localID("doi:" + doi)
+	}
+	return localID("title:" + normalizeName(work.Title) + "|" + strconv.Itoa(work.PublicationYear))
+}
+
+// LocalAuthorID returns the ID of an author known only by name (case and spacing
+// ignored). Works deposited with the same author name share the author node.
+func LocalAuthorID(name string) string {
+	return localID("author:" + normalizeName(name))
+}
+
+func localID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return LocalIDPrefix + hex.EncodeToString(sum[:8])
+}
+
+func normalizeName(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
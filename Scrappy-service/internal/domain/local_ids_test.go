@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"regexp"
+	"testing"
+)
+
+var localIDPattern = regexp.MustCompile(`^LOCAL-[0-9a-f]{16}$`)
+
+func TestLocalWorkID(t *testing.T) {
+	report := Work{Title: "Annual Report on Widgets", PublicationYear: 2023}
+	withDOI := Work{Title: "Annual Report on Widgets", PublicationYear: 2023, Doi: "https://doi.org/10.1/ABC"}
+	tests := []struct {
+		name     string
+		a, b     Work
+		wantSame bool
+	}{
+		{"same content", report, report, true},
+		{"title case and spacing ignored", report, Work{Title: "  annual report\ton   WIDGETS ", PublicationYear: 2023}, true},
+		{"other year", report, Work{Title: "Annual Report on Widgets", PublicationYear: 2024}, false},
+		{"other title", report, Work{Title: "Annual Report on Gadgets", PublicationYear: 2023}, false},
+		{"DOI forms", withDOI, Work{Doi: "doi:10.1/abc"}, true},
+		{"DOI wins over title and year", withDOI, Work{Title: "Renamed", PublicationYear: 2020, Doi: "10.1/abc"}, true},
+		{"DOI or not", withDOI, report, false},
+		{"other DOI", withDOI, Work{Title: "Annual Report on Widgets", PublicationYear: 2023, Doi: "10.1/abd"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := LocalWorkID(tt.a), LocalWorkID(tt.b)
+			if !localIDPattern.MatchString(a) || !localIDPattern.MatchString(b) {
+				t.Fatalf("IDs %q and %q aren't LOCAL- IDs", a, b)
+			}
+			if (a == b) != tt.wantSame {
+				t.Errorf("LocalWorkID = %q and %q, want the same: %v", a, b, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestLocalAuthorID(t *testing.T) {
+	id := LocalAuthorID("Ada Lovelace")
+	if !localIDPattern.MatchString(id) {
+		t.Fatalf("LocalAuthorID = %q, want a LOCAL- ID", id)
+	}
+	if got := LocalAuthorID(" ada  LOVELACE "); got != id {
+		t.Errorf("LocalAuthorID ignoring case and spacing = %q, want %q", got, id)
+	}
+	if got := LocalAuthorID("Ada Byron"); got == id {
+		t.Errorf("LocalAuthorID of another name = %q, the same ID", got)
+	}
+	// An author and a work can't collide even with the same text.
+	if LocalAuthorID("x") == LocalWorkID(Work{Title: "x"}) {
+		t.Error("author and work IDs collide")
+	}
+}
+
+func TestIsLocalID(t *testing.T) {
+	for id, want := range map[string]bool{
+		LocalAuthorID("Ada Lovelace"): true,
+		"LOCAL-0123456789abcdef":      true,
+		"https://openalex.org/W1":     false,
+		"W1":                          false,
+		"local-0123456789abcdef":      false,
+		"":                            false,
+	} {
+		if got := IsLocalID(id); got != want {
+			t.Errorf("IsLocalID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -26,28 +26,72 @@ type Author struct {
 
 // Institution corresponds to the Institution entity from OpenAlex.
 type Institution struct {
-	// ... (no changes to this struct) ...
+	ID           string            `json:"id"`
+	DisplayName  string            `json:"display_name"`
+	Ror          string            `json:"ror"`
+	CountryCode  string            `json:"country_code"`
+	Type         string            `json:"type"`
+	HomepageUrl  string            `json:"homepage_url"`
+	WorksCount   int               `json:"works_count"`
+	CitedByCount int               `json:"cited_by_count"`
+	Geo          InstitutionGeo    `json:"geo"`
+	Ids          map[string]string `json:"ids"`
+
+	AssociatedInstitutions []AssociatedInstitution `json:"associated_institutions"`
+}
+
+// Relationships of an AssociatedInstitution to the institution that lists it.
+const (
+	InstitutionParent  = "parent"
+	InstitutionChild   = "child"
+	InstitutionRelated = "related"
+)
+
+// AssociatedInstitution is an institution linked to another one, e.g. a university listed
+// as the parent of one of its departments. Relationship is InstitutionParent,
+// InstitutionChild or InstitutionRelated, seen from the listing institution.
+type AssociatedInstitution struct {
+	DehydratedInstitution
+	Relationship string `json:"relationship"`
+}
+
+// InstitutionGeo is the location of an institution.
+type InstitutionGeo struct {
+	City        string  `json:"city"`
+	Region      string  `json:"region"`
+	CountryCode string  `json:"country_code"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
 }
 
 // Work corresponds to the Work entity from OpenAlex.
 type Work struct {
-	ID                          string          `json:"id"`
-	Title                       string          `json:"title"`
-	Doi                         string          `json:"doi"`
-	Type                        string          `json:"type"` // ADDED: Critical context (journal-article, etc.)
-	PublicationDate             string          `json:"publication_date"`
-	PublicationYear             int             `json:"publication_year"`
-	CitedByCount                int             `json:"cited_by_count"`
-	IsRetracted                 bool            `json:"is_retracted"`
-	ReferencedWorks             []string        `json:"referenced_works"`
-	RelatedWorks                []string        `json:"related_works"` // ADDED: Important new relationship
-	Locations                   []Location      `json:"locations"`
-	PrimaryLocation             *Location       `json:"primary_location"`
-	BestOaLocation              *Location       `json:"best_oa_location"`
-	Grants                      []Grant         `json:"grants"`                        // ADDED: Links to funding
-	SustainableDevelopmentGoals []DehydratedSDG `json:"sustainable_development_goals"` // ADDED: Links to UN Goals
-	Topics                      []Topic         `json:"topics"`                        // MODIFIED: Replaced Concepts with the richer Topics struct
-	Authorships                 []Authorship    `json:"authorships"`
+	ID                          string            `json:"id"`
+	Title                       string            `json:"title"`
+	Doi                         string            `json:"doi"`
+	Type                        string            `json:"type"` // ADDED: Critical context (journal-article, etc.)
+	PublicationDate             string            `json:"publication_date"`
+	PublicationYear             int               `json:"publication_year"`
+	CitedByCount                int               `json:"cited_by_count"`
+	IsRetracted                 bool              `json:"is_retracted"`
+	IsParatext                  bool              `json:"is_paratext"`  // Front covers, tables of contents, errata notices, etc.
+	HasFulltext                 bool              `json:"has_fulltext"` // OpenAlex has the full text indexed (and ngrams available).
+	Language                    string            `json:"language"`     // ISO 639-1 code, e.g. "en"; empty when unknown.
+	CreatedDate                 string            `json:"created_date"`
+	UpdatedDate                 string            `json:"updated_date"` // When OpenAlex last changed the work; SaveWork skips works that haven't changed.
+	ReferencedWorks             []string          `json:"referenced_works"`
+	RelatedWorks                []string          `json:"related_works"` // ADDED: Important new relationship
+	Locations                   []Location        `json:"locations"`
+	PrimaryLocation             *Location         `json:"primary_location"`
+	Biblio                      Biblio            `json:"biblio"` // Volume, issue and pages in the primary venue.
+	BestOaLocation              *Location         `json:"best_oa_location"`
+	Grants                      []Grant           `json:"grants"`                        // ADDED: Links to funding
+	SustainableDevelopmentGoals []DehydratedSDG   `json:"sustainable_development_goals"` // ADDED: Links to UN Goals
+	Topics                      []Topic           `json:"topics"`                        // MODIFIED: Replaced Concepts with the richer Topics struct
+	Authorships                 []Authorship      `json:"authorships"`
+	Ids                         map[string]string `json:"ids"` // External identifiers: doi, mag, pmid, pmcid, ...
+	AbstractInvertedIndex       map[string][]int  `json:"abstract_inverted_index"`
+	Abstract                    string            `json:"abstract,omitempty"` // Reconstructed from AbstractInvertedIndex on decode, which is then dropped.
 }
 
 // --- Topic Hierarchy Structs (NEW) ---
@@ -124,6 +168,7 @@ type DehydratedWork struct {
 	Title           string `json:"title"`
 	PublicationYear int    `json:"publication_year"`
 	PublicationDate string `json:"publication_date"`
+	IsRetracted     bool   `json:"is_retracted"`
 }
 
 // --- Nested Relationship and Helper Structs ---
@@ -148,6 +193,15 @@ type Authorship struct {
 	Institutions   []DehydratedInstitution `json:"institutions"`
 }
 
+// Biblio is where a work sits in its venue. OpenAlex gives every part as a string, since
+// pages can be "e1234" or "iv".
+type Biblio struct {
+	Volume    string `json:"volume"`
+	Issue     string `json:"issue"`
+	FirstPage string `json:"first_page"`
+	LastPage  string `json:"last_page"`
+}
+
 // Location represents a host or repository where a Work is located.
 type Location struct {
 	IsOa           bool    `json:"is_oa"`
@@ -161,7 +215,9 @@ type Location struct {
 // including its relevance score.
 
 type Source struct {
-	ID          string `json:"id"`
-	DisplayName string `json:"display_name"`
-	Type        string `json:"type"`
+	ID          string   `json:"id"`
+	DisplayName string   `json:"display_name"`
+	Type        string   `json:"type"`
+	IssnL       string   `json:"issn_l"` // Linking ISSN; not every source has one.
+	Issn        []string `json:"issn"`
 }
@@ -0,0 +1,83 @@
+// Package events publishes notifications about entities landing in the graph, so that
+// downstream services (e.g. the recommendation engine) can react to them.
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Event types.
+const (
+	TypeWorkSaved   = "work.saved"
+	TypeAuthorSaved = "author.saved"
+)
+
+// WorkSavedEvent is published after a Work has been committed to the graph.
+type WorkSavedEvent struct {
+	WorkID          string `json:"workId"`
+	Title           string `json:"title"`
+	Doi             string `json:"doi,omitempty"`
+	PublicationYear int    `json:"publicationYear,omitempty"`
+}
+
+// AuthorSavedEvent is published after an Author has been committed to the graph.
+type AuthorSavedEvent struct {
+	AuthorID    string `json:"authorId"`
+	DisplayName string `json:"displayName"`
+	Orcid       string `json:"orcid,omitempty"`
+}
+
+// Event is the envelope delivered to consumers.
+type Event struct {
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// Publisher publishes graph events.
+type Publisher interface {
+	PublishWorkSaved(ctx context.Context, e WorkSavedEvent) error
+	PublishAuthorSaved(ctx context.Context, e AuthorSavedEvent) error
+}
+
+// NopPublisher discards every event. It is the default when no consumers are configured.
+type NopPublisher struct{}
+
+func (NopPublisher) PublishWorkSaved(context.Context, WorkSavedEvent) error     { return nil }
+func (NopPublisher) PublishAuthorSaved(context.Context, AuthorSavedEvent) error { return nil }
+
+// ChannelPublisher hands events to an in-process consumer through a buffered channel.
+// Publishing never blocks a save: when the buffer is full the event is dropped and logged.
+type ChannelPublisher struct {
+	ch chan Event
+}
+
+// NewChannelPublisher creates a publisher with room for buffer pending events.
+func NewChannelPublisher(buffer int) *ChannelPublisher {
+	return &ChannelPublisher{ch: make(chan Event, buffer)}
+}
+
+// Events returns the channel consumers read from.
+func (p *ChannelPublisher) Events() <-chan Event {
+	return p.ch
+}
+
+func (p *ChannelPublisher) PublishWorkSaved(_ context.Context, e WorkSavedEvent) error {
+	p.publish(Event{Type: TypeWorkSaved, OccurredAt: time.Now().UTC(), Data: e})
+	return nil
+}
+
+func (p *ChannelPublisher) PublishAuthorSaved(_ context.Context, e AuthorSavedEvent) error {
+	p.publish(Event{Type: TypeAuthorSaved, OccurredAt: time.Now().UTC(), Data: e})
+	return nil
+}
+
+func (p *ChannelPublisher) publish(e Event) {
+	select {
+	case p.ch <- e:
+	default:
+		log.Printf("WARN: Event buffer full, dropping %s event", e.Type)
+	}
+}
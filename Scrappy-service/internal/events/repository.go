@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// publishingRepository decorates a Repository so that successful saves publish events.
+type publishingRepository struct {
+	storage.Repository
+	publisher Publisher
+}
+
+// WrapRepository returns a Repository that publishes a WorkSaved / AuthorSaved event after
+// each SaveWork / SaveAuthor call that committed successfully, and one AuthorSaved event per
+// author SaveAuthors saved. Failed saves, and works skipped because they were unchanged,
+// publish nothing.
+func WrapRepository(repo storage.Repository, publisher Publisher) storage.Repository {
+	return &publishingRepository{Repository: repo, publisher: publisher}
+}
+
+func (r *publishingRepository) SaveWork(ctx context.Context, work domain.Work, opts storage.SaveOptions) (storage.SaveOutcome, error) {
+	outcome, err := r.Repository.SaveWork(ctx, work, opts)
+	if err != nil || outcome == storage.SaveUnchanged {
+		return outcome, err
+	}
+	err = r.publisher.PublishWorkSaved(ctx, WorkSavedEvent{
+		WorkID:          work.ID,
+		Title:           work.Title,
+		Doi:             work.Doi,
+		PublicationYear: work.PublicationYear,
+	})
+	if err != nil {
+		log.Printf("WARN: Could not publish saved event for work %s: %v", work.ID, err)
+	}
+	return outcome, nil
+}
+
+func (r *publishingRepository) SaveAuthor(ctx context.Context, author domain.Author) error {
+	if err := r.Repository.SaveAuthor(ctx, author); err != nil {
+		return err
+	}
+	r.publishAuthorSaved(ctx, author)
+	return nil
+}
+
+func (r *publishingRepository) SaveAuthors(ctx context.Context, authors []domain.Author) error {
+	err := r.Repository.SaveAuthors(ctx, authors)
+	var partial *storage.SaveAuthorsError
+	switch {
+	case err == nil:
+		for _, author := range authors {
+			r.publishAuthorSaved(ctx, author)
+		}
+	case errors.As(err, &partial):
+		for i, result := range partial.Results {
+			if result.Err == nil {
+				r.publishAuthorSaved(ctx, authors[i])
+			}
+		}
+	}
+	return err
+}
+
+func (r *publishingRepository) publishAuthorSaved(ctx context.Context, author domain.Author) {
+	err := r.publisher.PublishAuthorSaved(ctx, AuthorSavedEvent{
+		AuthorID:    author.ID,
+		DisplayName: author.DisplayName,
+		Orcid:       author.Orcid,
+	})
+	if err != nil {
+		log.Printf("WARN: Could not publish saved event for author %s: %v", author.ID, err)
+	}
+}
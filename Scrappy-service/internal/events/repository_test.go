@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// recordingPublisher remembers the IDs of the entities it was told about.
+type recordingPublisher struct {
+	ids []string
+}
+
+func (p *recordingPublisher) PublishWorkSaved(_ context.Context, e WorkSavedEvent) error {
+	p.ids = append(p.ids, e.WorkID)
+	return nil
+}
+
+func (p *recordingPublisher) PublishAuthorSaved(_ context.Context, e AuthorSavedEvent) error {
+	p.ids = append(p.ids, e.AuthorID)
+	return nil
+}
+
+// stubRepo answers saves with fixed results.
+type stubRepo struct {
+	storage.Repository
+	outcome storage.SaveOutcome
+	err     error
+}
+
+func (r stubRepo) SaveWork(context.Context, domain.Work, storage.SaveOptions) (storage.SaveOutcome, error) {
+	return r.outcome, r.err
+}
+
+func (r stubRepo) SaveAuthor(context.Context, domain.Author) error {
+	return r.err
+}
+
+func (r stubRepo) SaveAuthors(context.Context, []domain.Author) error {
+	return r.err
+}
+
+func TestWrapRepositoryPublishesOnlyCommittedSaves(t *testing.T) {
+	failed := errors.New("write failed")
+	partial := &storage.SaveAuthorsError{
+		Results: []storage.AuthorSaveResult{{ID: "A1"}, {ID: "A2", Err: failed}, {ID: "A3"}},
+		Failed:  1,
+	}
+	authors := []domain.Author{{ID: "A1"}, {ID: "A2"}, {ID: "A3"}}
+	tests := []struct {
+		name string
+		repo stubRepo
+		save func(repo storage.Repository) error
+		want []string
+	}{
+		{"work created", stubRepo{outcome: storage.SaveCreated}, saveWork, []string{"W1"}},
+		{"work updated", stubRepo{outcome: storage.SaveUpdated}, saveWork, []string{"W1"}},
+		{"work unchanged", stubRepo{outcome: storage.SaveUnchanged}, saveWork, nil},
+		{"work failed", stubRepo{err: failed}, saveWork, nil},
+		{"author saved", stubRepo{}, saveAuthor, []string{"A1"}},
+		{"author failed", stubRepo{err: failed}, saveAuthor, nil},
+		{"authors saved", stubRepo{}, func(repo storage.Repository) error {
+			return repo.SaveAuthors(context.Background(), authors)
+		}, []string{"A1", "A2", "A3"}},
+		{"authors partly saved", stubRepo{err: partial}, func(repo storage.Repository) error {
+			return repo.SaveAuthors(context.Background(), authors)
+		}, []string{"A1", "A3"}},
+		{"authors failed", stubRepo{err: failed}, func(repo storage.Repository) error {
+			return repo.SaveAuthors(context.Background(), authors)
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			err := tt.save(WrapRepository(tt.repo, publisher))
+			if !errors.Is(err, tt.repo.err) {
+				t.Errorf("save error = %v, want the repository's %v", err, tt.repo.err)
+			}
+			if !reflect.DeepEqual(publisher.ids, tt.want) {
+				t.Errorf("published %v, want %v", publisher.ids, tt.want)
+			}
+		})
+	}
+}
+
+func saveWork(repo storage.Repository) error {
+	_, err := repo.SaveWork(context.Background(), domain.Work{ID: "W1"}, storage.FullSave)
+	return err
+}
+
+func saveAuthor(repo storage.Repository) error {
+	return repo.SaveAuthor(context.Background(), domain.Author{ID: "A1"})
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, as "sha256=<hex>".
+const SignatureHeader = "X-Scrappy-Signature"
+
+// WebhookDispatcher POSTs events as JSON to a set of URLs, retrying transient failures.
+type WebhookDispatcher struct {
+	urls       []string
+	secret     []byte
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// NewWebhookDispatcher creates a dispatcher. When secret is non-empty every request is
+// signed with it (see Sign).
+func NewWebhookDispatcher(urls []string, secret string) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		urls:       urls,
+		secret:     []byte(secret),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		maxRetries: 3,
+		backoff:    500 * time.Millisecond,
+	}
+}
+
+// Sign returns the signature header value for body: "sha256=" plus the hex HMAC-SHA256
+// of the body keyed with the shared secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run delivers events until ctx is done or the channel is closed.
+func (d *WebhookDispatcher) Run(ctx context.Context, events <-chan Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			d.dispatch(ctx, e)
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatch(ctx context.Context, e Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("WARN: Could not encode %s event: %v", e.Type, err)
+		return
+	}
+	for _, url := range d.urls {
+		if err := d.deliver(ctx, url, body); err != nil {
+			log.Printf("WARN: Webhook delivery of %s event to %s failed: %v", e.Type, url, err)
+		}
+	}
+}
+
+// deliver POSTs body to url, retrying with exponential backoff on network errors and 5xx
+// responses. 4xx responses are not retried since resending won't help.
+func (d *WebhookDispatcher) deliver(ctx context.Context, url string, body []byte) error {
+	var lastErr error
+	for attempt := 0; attempt <= d.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d.backoff << (attempt - 1)):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if len(d.secret) > 0 {
+			req.Header.Set(SignatureHeader, Sign(d.secret, body))
+		}
+
+		resp, err := d.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("server responded %s", resp.Status)
+		default:
+			return fmt.Errorf("server rejected event: %s", resp.Status)
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", d.maxRetries+1, lastErr)
+}
@@ -0,0 +1,160 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret, body string
+		want         string
+	}{
+		// The HMAC-SHA256 example value from Wikipedia.
+		{"key", "The quick brown fox jumps over the lazy dog", "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"", "", "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad"},
+	}
+	for _, tt := range tests {
+		if got := Sign([]byte(tt.secret), []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q) = %s, want %s", tt.secret, tt.body, got, tt.want)
+		}
+	}
+}
+
+// newTestDispatcher returns a dispatcher to url that doesn't wait between retries.
+func newTestDispatcher(secret string, urls ...string) *WebhookDispatcher {
+	d := NewWebhookDispatcher(urls, secret)
+	d.backoff = time.Millisecond
+	return d
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // Responses in order; the last one repeats.
+		wantAttempts int32
+		wantErr      string
+	}{
+		{"ok", []int{http.StatusOK}, 1, ""},
+		{"retried 500", []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent}, 3, ""},
+		{"gives up", []int{http.StatusServiceUnavailable}, 4, "giving up after 4 attempts"},
+		{"4xx not retried", []int{http.StatusBadRequest}, 1, "rejected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer server.Close()
+
+			err := newTestDispatcher("", server.URL).deliver(context.Background(), server.URL, []byte(`{}`))
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("deliver: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("deliver = %v, want an error containing %q", err, tt.wantErr)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("%d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDeliverStopsWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher("", server.URL)
+	d.backoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.deliver(ctx, server.URL, []byte(`{}`)); err != context.DeadlineExceeded {
+		t.Errorf("deliver = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRunSignsAndDeliversToEveryURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"signed", "s3cret"},
+		{"unsigned", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan Event, 4)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				signature := r.Header.Get(SignatureHeader)
+				switch {
+				case tt.secret == "" && signature != "":
+					t.Errorf("unsigned dispatcher sent signature %q", signature)
+				case tt.secret != "" && signature != Sign([]byte(tt.secret), body):
+					t.Errorf("signature = %q, want the HMAC of the body", signature)
+				}
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+				var e Event
+				if err := json.Unmarshal(body, &e); err != nil {
+					t.Errorf("decoding event: %v", err)
+				}
+				received <- e
+			})
+			first, second := httptest.NewServer(handler), httptest.NewServer(handler)
+			defer first.Close()
+			defer second.Close()
+
+			publisher := NewChannelPublisher(1)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				newTestDispatcher(tt.secret, first.URL, second.URL).Run(ctx, publisher.Events())
+				close(done)
+			}()
+			publisher.PublishWorkSaved(ctx, WorkSavedEvent{WorkID: "W1", Title: "t"})
+
+			for i := 0; i < 2; i++ {
+				select {
+				case e := <-received:
+					if e.Type != TypeWorkSaved {
+						t.Errorf("event type = %q, want %q", e.Type, TypeWorkSaved)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("only %d of 2 webhooks received the event", i)
+				}
+			}
+			cancel()
+			<-done
+		})
+	}
+}
+
+func TestChannelPublisherDropsWhenFull(t *testing.T) {
+	p := NewChannelPublisher(1)
+	p.PublishWorkSaved(context.Background(), WorkSavedEvent{WorkID: "W1"})
+	p.PublishAuthorSaved(context.Background(), AuthorSavedEvent{AuthorID: "A1"}) // Dropped, not blocking.
+
+	e := <-p.Events()
+	if data, ok := e.Data.(WorkSavedEvent); !ok || data.WorkID != "W1" {
+		t.Errorf("event = %+v, want the saved work W1", e)
+	}
+	select {
+	case e := <-p.Events():
+		t.Errorf("got %+v, want the event published into a full buffer dropped", e)
+	default:
+	}
+}
@@ -0,0 +1,46 @@
+// Package geo holds small embedded geographic lookup tables.
+package geo
+
+import "strings"
+
+// Point is a longitude/latitude pair, in GeoJSON coordinate order.
+type Point struct {
+	Lon float64
+	Lat float64
+}
+
+// CountryCentroid returns the approximate centroid of the country with the given
+// ISO 3166-1 alpha-2 code.
+func CountryCentroid(code string) (Point, bool) {
+	p, ok := countryCentroids[strings.ToUpper(code)]
+	return p, ok
+}
+
+// countryCentroids are approximate geographic centres, good enough to place a marker on a
+// world map. Countries missing here are still reported, just without coordinates.
+var countryCentroids = map[string]Point{
+	"AE": {54.3, 23.9}, "AR": {-64.0, -34.0}, "AT": {14.6, 47.6}, "AU": {134.5, -25.7},
+	"BD": {90.3, 23.7}, "BE": {4.6, 50.6}, "BG": {25.2, 42.8}, "BR": {-53.1, -10.8},
+	"BY": {28.0, 53.5}, "CA": {-98.3, 61.4}, "CH": {8.2, 46.8}, "CL": {-71.4, -37.7},
+	"CM": {12.7, 5.7}, "CN": {103.8, 36.6}, "CO": {-73.1, 3.9}, "CR": {-84.2, 9.9},
+	"CU": {-79.0, 21.6}, "CY": {33.0, 35.0}, "CZ": {15.3, 49.7}, "DE": {10.4, 51.1},
+	"DK": {10.0, 56.0}, "DZ": {2.6, 28.2}, "EC": {-78.5, -1.4}, "EE": {25.5, 58.7},
+	"EG": {29.9, 26.5}, "ES": {-3.6, 40.2}, "ET": {39.6, 8.6}, "FI": {26.3, 64.5},
+	"FR": {2.5, 46.6}, "GB": {-2.9, 54.1}, "GE": {43.5, 42.2}, "GH": {-1.2, 7.9},
+	"GR": {22.6, 39.1}, "HK": {114.2, 22.4}, "HR": {16.4, 45.1}, "HU": {19.4, 47.2},
+	"ID": {117.2, -2.2}, "IE": {-8.1, 53.2}, "IL": {35.0, 31.5}, "IN": {79.6, 22.9},
+	"IQ": {43.7, 33.0}, "IR": {54.3, 32.6}, "IS": {-18.6, 65.0}, "IT": {12.1, 42.8},
+	"JM": {-77.3, 18.1}, "JO": {36.8, 31.2}, "JP": {138.0, 37.6}, "KE": {37.8, 0.6},
+	"KR": {127.8, 36.4}, "KW": {47.6, 29.3}, "KZ": {67.3, 48.2}, "LB": {35.9, 33.9},
+	"LK": {80.7, 7.6}, "LT": {23.9, 55.3}, "LU": {6.1, 49.8}, "LV": {24.9, 56.9},
+	"MA": {-6.3, 31.9}, "MX": {-102.5, 23.9}, "MY": {109.7, 3.8}, "NG": {8.1, 9.6},
+	"NL": {5.3, 52.1}, "NO": {15.3, 68.8}, "NP": {83.9, 28.3}, "NZ": {171.5, -41.8},
+	"OM": {56.1, 20.6}, "PE": {-74.4, -9.2}, "PH": {122.9, 11.8}, "PK": {69.4, 29.9},
+	"PL": {19.4, 52.1}, "PT": {-8.5, 39.6}, "QA": {51.2, 25.3}, "RO": {25.0, 45.9},
+	"RS": {20.8, 44.2}, "RU": {96.7, 61.9}, "SA": {44.5, 24.1}, "SE": {16.7, 62.8},
+	"SG": {103.8, 1.4}, "SI": {14.8, 46.1}, "SK": {19.5, 48.7}, "SN": {-14.5, 14.4},
+	"TH": {101.0, 15.1}, "TN": {9.6, 34.1}, "TR": {35.2, 39.1}, "TW": {121.0, 23.8},
+	"TZ": {34.8, -6.3}, "UA": {31.4, 49.0}, "UG": {32.4, 1.3}, "US": {-98.6, 39.8},
+	"UY": {-56.0, -32.8}, "UZ": {63.2, 41.8}, "VE": {-66.2, 7.1}, "VN": {106.3, 16.6},
+	"ZA": {25.1, -29.0}, "ZW": {29.9, -19.0},
+}
@@ -0,0 +1,31 @@
+package geo
+
+import "testing"
+
+func TestCountryCentroid(t *testing.T) {
+	tests := []struct {
+		code string
+		want Point
+		ok   bool
+	}{
+		{"DE", Point{10.4, 51.1}, true},
+		{"de", Point{10.4, 51.1}, true},
+		{"AU", Point{134.5, -25.7}, true},
+		{"ZZ", Point{}, false},
+		{"", Point{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := CountryCentroid(tt.code)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("CountryCentroid(%q) = %v, %v; want %v, %v", tt.code, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCountryCentroidsAreOnEarth(t *testing.T) {
+	for code, p := range countryCentroids {
+		if p.Lon < -180 || p.Lon > 180 || p.Lat < -90 || p.Lat > 90 {
+			t.Errorf("%s: %v is not a longitude/latitude pair", code, p)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package metrics is a minimal in-process metrics registry exposed in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// metric is anything that can write itself in the exposition format.
+type metric interface {
+	name() string
+	write(w http.ResponseWriter)
+}
+
+var (
+	mu       sync.Mutex
+	registry = map[string]metric{}
+)
+
+func register(m metric) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := registry[m.name()]; exists {
+		panic(fmt.Sprintf("metrics: %s registered twice", m.name()))
+	}
+	registry[m.name()] = m
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	n, help string
+	v       atomic.Int64
+}
+
+// NewGauge creates and registers a gauge.
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{n: name, help: help}
+	register(g)
+	return g
+}
+
+func (g *Gauge) Inc()         { g.v.Add(1) }
+func (g *Gauge) Dec()         { g.v.Add(-1) }
+func (g *Gauge) Set(v int64)  { g.v.Store(v) }
+func (g *Gauge) Value() int64 { return g.v.Load() }
+func (g *Gauge) name() string { return g.n }
+
+func (g *Gauge) write(w http.ResponseWriter) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", g.n, g.help, g.n, g.n, g.Value())
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	n, help string
+	v       atomic.Int64
+}
+
+// NewCounter creates and registers a counter.
+func NewCounter(name, help string) *Counter {
+	c := &Counter{n: name, help: help}
+	register(c)
+	return c
+}
+
+func (c *Counter) Inc()         { c.v.Add(1) }
+func (c *Counter) Add(n int64)  { c.v.Add(n) }
+func (c *Counter) Value() int64 { return c.v.Load() }
+func (c *Counter) name() string { return c.n }
+
+func (c *Counter) write(w http.ResponseWriter) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.n, c.help, c.n, c.n, c.Value())
+}
+
+// Handler serves every registered metric, sorted by name.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		names := make([]string, 0, len(registry))
+		for n := range registry {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		metrics := make([]metric, len(names))
+		for i, n := range names {
+			metrics[i] = registry[n]
+		}
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, m := range metrics {
+			m.write(w)
+		}
+	})
+}
+
+// DurationBuckets are histogram bucket bounds, in seconds, suited to database and HTTP calls.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec is a histogram of observations (e.g. durations in seconds) kept separately
+// for every value of one label.
+type HistogramVec struct {
+	n, help, label string
+	buckets        []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec creates and registers a histogram with the given label and bucket
+// upper bounds, in increasing order.
+func NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{n: name, help: help, label: label, buckets: buckets, series: map[string]*histogram{}}
+	register(h)
+	return h
+}
+
+// Observe records v for the label value.
+func (h *HistogramVec) Observe(labelValue string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[labelValue]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[labelValue] = s
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) name() string { return h.n }
+
+func (h *HistogramVec) write(w http.ResponseWriter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	values := make([]string, 0, len(h.series))
+	for v := range h.series {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.n, h.help, h.n)
+	for _, v := range values {
+		s := h.series[v]
+		label := fmt.Sprintf("%s=%s", h.label, strconv.Quote(v))
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", h.n, label, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", h.n, label, s.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", h.n, label, strconv.FormatFloat(s.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", h.n, label, s.count)
+	}
+}
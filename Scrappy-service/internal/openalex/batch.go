@@ -0,0 +1,116 @@
+package openalex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// MaxIDsPerRequest is how many IDs one openalex_id OR filter may hold.
+const MaxIDsPerRequest = 50
+
+// MissingIDsError is returned by FetchAuthorsByIDs, together with the entities that were
+// found, when OpenAlex returned nothing for some of the requested IDs. IDs are bare.
+type MissingIDsError struct {
+	IDs []string
+}
+
+func (e *MissingIDsError) Error() string {
+	return fmt.Sprintf("%d IDs not found in OpenAlex: %s", len(e.IDs), strings.Join(e.IDs, ", "))
+}
+
+// FetchWorksByIDs fetches up to MaxIDsPerRequest works by OpenAlex ID (bare or URL form) in
+// one request. Unknown IDs are silently missing from the result, which is in no particular order.
+func (c *Client) FetchWorksByIDs(ctx context.Context, ids []string) ([]domain.Work, error) {
+	if len(ids) > MaxIDsPerRequest {
+		return nil, fmt.Errorf("at most %d IDs per request, got %d", MaxIDsPerRequest, len(ids))
+	}
+	var works []domain.Work
+	err := c.fetchIDBatch(ctx, "works", shortIDs(ids), workSelectFields, &works)
+	return works, err
+}
+
+// FetchAuthorsByIDs fetches authors by OpenAlex ID (bare or URL form), MaxIDsPerRequest per
+// request, and returns them in the order they were asked for; duplicate IDs are fetched
+// once. If OpenAlex has no author for some IDs (including IDs merged into another profile),
+// the authors that were found are returned with a *MissingIDsError listing the others.
+func (c *Client) FetchAuthorsByIDs(ctx context.Context, ids []string) ([]domain.Author, error) {
+	var unique []string
+	seen := make(map[string]bool, len(ids))
+	for _, id := range shortIDs(ids) {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	byID := make(map[string]domain.Author, len(unique))
+	for start := 0; start < len(unique); start += MaxIDsPerRequest {
+		end := min(start+MaxIDsPerRequest, len(unique))
+		var batch []domain.Author
+		if err := c.fetchIDBatch(ctx, "authors", unique[start:end], "", &batch); err != nil {
+			return nil, err
+		}
+		for _, author := range batch {
+			byID[strings.TrimPrefix(author.ID, "https://openalex.org/")] = author
+		}
+	}
+
+	authors := make([]domain.Author, 0, len(byID))
+	var missing []string
+	for _, id := range unique {
+		if author, ok := byID[id]; ok {
+			authors = append(authors, author)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return authors, &MissingIDsError{IDs: missing}
+	}
+	return authors, nil
+}
+
+// fetchIDBatch decodes the results of one openalex_id OR-filter request on an entity
+// endpoint into results, a pointer to a slice. selectFields may be empty.
+func (c *Client) fetchIDBatch(ctx context.Context, entity string, ids []string, selectFields string, results interface{}) error {
+	queryParams := url.Values{}
+	queryParams.Set("filter", "openalex_id:"+strings.Join(ids, "|"))
+	queryParams.Set("per-page", fmt.Sprintf("%d", MaxIDsPerRequest))
+	if selectFields != "" {
+		queryParams.Set("select", selectFields)
+	}
+	return c.fetchResults(ctx, entity, queryParams, results)
+}
+
+// fetchResults requests a list of entities and decodes the "results" array of the
+// response into results, a pointer to a slice.
+func (c *Client) fetchResults(ctx context.Context, entity string, queryParams url.Values, results interface{}) error {
+	requestURL := fmt.Sprintf("%s/%s?%s", openAlexAPIBaseURL, entity, queryParams.Encode())
+
+	body, err := c.get(ctx, requestURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	apiResponse := struct {
+		Results interface{} `json:"results"`
+	}{Results: results}
+	if err := json.NewDecoder(body).Decode(&apiResponse); err != nil {
+		return fmt.Errorf("failed to decode json response: %w", err)
+	}
+	return nil
+}
+
+func shortIDs(ids []string) []string {
+	short := make([]string, len(ids))
+	for i, id := range ids {
+		short[i] = strings.TrimPrefix(strings.TrimSpace(id), "https://openalex.org/")
+	}
+	return short
+}
@@ -0,0 +1,125 @@
+package openalex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// authorsByID answers openalex_id filter requests on the authors endpoint with every
+// requested author except the unknown ones, in reverse order, and records the IDs of each
+// request.
+type authorsByID struct {
+	unknown map[string]bool
+
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (s *authorsByID) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter := r.URL.Query().Get("filter")
+	if r.URL.Path != "/authors" || !strings.HasPrefix(filter, "openalex_id:") || r.URL.Query().Get("per-page") != "50" {
+		http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+		return
+	}
+	ids := strings.Split(strings.TrimPrefix(filter, "openalex_id:"), "|")
+	s.mu.Lock()
+	s.batches = append(s.batches, ids)
+	s.mu.Unlock()
+
+	var results []string
+	for i := len(ids) - 1; i >= 0; i-- {
+		if !s.unknown[ids[i]] {
+			results = append(results, fmt.Sprintf(`{"id": "https://openalex.org/%s", "display_name": "Author %s"}`, ids[i], ids[i]))
+		}
+	}
+	fmt.Fprintf(w, `{"meta": {"count": %d}, "results": [%s]}`, len(results), strings.Join(results, ","))
+}
+
+func authorIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("A%d", i+1)
+	}
+	return ids
+}
+
+func TestFetchAuthorsByIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		ids         []string
+		unknown     []string
+		wantBatches []int
+		wantIDs     []string
+		wantMissing []string
+	}{
+		{name: "one batch", ids: []string{"A3", "A1", "A2"}, wantBatches: []int{3}, wantIDs: []string{"A3", "A1", "A2"}},
+		{name: "URL form", ids: []string{"https://openalex.org/A3", " A1 "}, wantBatches: []int{2}, wantIDs: []string{"A3", "A1"}},
+		{name: "duplicates fetched once", ids: []string{"A1", "A2", "https://openalex.org/A1"}, wantBatches: []int{2}, wantIDs: []string{"A1", "A2"}},
+		{name: "exactly one batch", ids: authorIDs(50), wantBatches: []int{50}, wantIDs: authorIDs(50)},
+		{name: "chunked", ids: authorIDs(120), wantBatches: []int{50, 50, 20}, wantIDs: authorIDs(120)},
+		{name: "missing", ids: []string{"A1", "A2", "A3", "A4"}, unknown: []string{"A2", "A4"},
+			wantBatches: []int{4}, wantIDs: []string{"A1", "A3"}, wantMissing: []string{"A2", "A4"}},
+		{name: "all missing", ids: []string{"A1"}, unknown: []string{"A1"}, wantBatches: []int{1}, wantIDs: []string{}, wantMissing: []string{"A1"}},
+		{name: "nothing asked", ids: nil, wantBatches: nil, wantIDs: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &authorsByID{unknown: map[string]bool{}}
+			for _, id := range tt.unknown {
+				server.unknown[id] = true
+			}
+			c := newTestClient(t, server.ServeHTTP)
+
+			authors, err := c.FetchAuthorsByIDs(context.Background(), tt.ids)
+			var missingErr *MissingIDsError
+			if errors.As(err, &missingErr) {
+				if !reflect.DeepEqual(missingErr.IDs, tt.wantMissing) {
+					t.Errorf("missing = %v, want %v", missingErr.IDs, tt.wantMissing)
+				}
+			} else if err != nil || tt.wantMissing != nil {
+				t.Fatalf("err = %v, want missing %v", err, tt.wantMissing)
+			}
+
+			gotIDs := []string{}
+			for _, author := range authors {
+				gotIDs = append(gotIDs, strings.TrimPrefix(author.ID, "https://openalex.org/"))
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("authors = %v, want %v in the order asked for", gotIDs, tt.wantIDs)
+			}
+			var sizes []int
+			for _, batch := range server.batches {
+				sizes = append(sizes, len(batch))
+			}
+			if !reflect.DeepEqual(sizes, tt.wantBatches) {
+				t.Errorf("batch sizes = %v, want %v", sizes, tt.wantBatches)
+			}
+		})
+	}
+}
+
+func TestFetchAuthorsByIDsFailsOnRequestError(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		(&authorsByID{}).ServeHTTP(w, r)
+	})
+	authors, err := c.FetchAuthorsByIDs(context.Background(), authorIDs(120))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want the 503", err)
+	}
+	if authors != nil || requests != 2 {
+		t.Errorf("got %d authors after %d requests, want none after the failed second one", len(authors), requests)
+	}
+}
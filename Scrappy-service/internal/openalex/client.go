@@ -1,30 +1,123 @@
 package openalex
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/Cloudforge2/scrappy/internal/domain" // IMPORTANT: Adjust this import path
+	"github.com/Cloudforge2/scrappy/internal/ratelimit"
 )
 
 const openAlexAPIBaseURL = "https://api.openalex.org"
 
+// workSelectFieldsLean is the select= list for work requests that decode into domain.Work
+// but don't need abstracts. It leaves out abstract_inverted_index, which is by far the
+// largest field of a work and can push a 200-work page past 5 MB.
+const workSelectFieldsLean = "id,title,doi,type,publication_date,publication_year,cited_by_count,is_retracted,is_paratext,has_fulltext,language,created_date,updated_date," +
+	"referenced_works,related_works,locations,primary_location,biblio,best_oa_location,grants,sustainable_development_goals," +
+	"topics,authorships,ids"
+
+// workSelectFields is the select= list for works that are ingested, abstract included.
+const workSelectFields = workSelectFieldsLean + ",abstract_inverted_index"
+
 // Client is a client for interacting with the OpenAlex API.
 type Client struct {
 	httpClient *http.Client
 	// politeMail string
+
+	// limiter is shared by every request the client makes, so concurrent jobs together stay
+	// under the upstream rate limit. Its burst is kept small so a full bucket can't be spent
+	// in one instant.
+	limiter *ratelimit.Bucket
+	// Paginated fetches sleep a random duration in [pageJitterMin, pageJitterMax] between
+	// pages, so concurrent jobs don't fire their page requests in lockstep.
+	pageJitterMin time.Duration
+	pageJitterMax time.Duration
+
+	// debugLog receives a line per request when set (see WithDebugLogger).
+	debugLog *log.Logger
+
+	// perPage is the page size of the list fetches that don't take one (see WithPerPage).
+	perPage int
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRateLimit limits the client to rate requests per second across all callers, with
+// bursts of at most burst requests. A burst of 1 makes the limiter a leaky bucket. A rate
+// of 0 or less keeps the default, as such a bucket would never refill.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *Client) {
+		if rate > 0 {
+			c.limiter = ratelimit.NewBucket(rate, burst)
+		}
+	}
+}
+
+// WithPageJitter sets the range of the random pause between page fetches.
+func WithPageJitter(min, max time.Duration) Option {
+	return func(c *Client) {
+		if max < min {
+			max = min
+		}
+		c.pageJitterMin, c.pageJitterMax = min, max
+	}
+}
+
+// WithPerPage sets the page size of the list fetches that don't take one, such as
+// FetchWorksByName and FetchWorksByAuthorID. It is clamped with ClampPerPage.
+func WithPerPage(perPage int) Option {
+	return func(c *Client) {
+		c.perPage = ClampPerPage(perPage)
+	}
+}
+
+// ClampPerPage returns perPage limited to OpenAlex's maximum of MaxPerPage; values below 1
+// fall back to DefaultPerPage.
+func ClampPerPage(perPage int) int {
+	switch {
+	case perPage < 1:
+		return DefaultPerPage
+	case perPage > MaxPerPage:
+		return MaxPerPage
+	}
+	return perPage
 }
 
 // NewClient creates a new OpenAlex API client.
 // The politeMail address is used for the "polite pool" for better performance.
-func NewClient() *Client {
-	return &Client{
+func NewClient(opts ...Option) *Client {
+	c := &Client{
 		httpClient: &http.Client{Timeout: 20 * time.Second}, // Increased timeout for potentially large API responses
 		// politeMail: politeMail,
+		limiter:       ratelimit.NewBucket(8, 1),
+		pageJitterMin: 100 * time.Millisecond,
+		pageJitterMax: 400 * time.Millisecond,
+		perPage:       DefaultPerPage,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// pause sleeps for a random duration within the configured page jitter range.
+func (c *Client) pause() {
+	d := c.pageJitterMin
+	if spread := c.pageJitterMax - c.pageJitterMin; spread > 0 {
+		d += rand.N(spread)
+	}
+	if d > 0 {
+		time.Sleep(d)
 	}
 }
 
@@ -39,9 +132,6 @@ func (c *Client) FetchAuthorById(authorID string) (domain.Author, error) {
 	if err != nil {
 		return domain.Author{}, err
 	}
-	fmt.Println("url:", url)
-	// fmt.Println("author topics:", len(author.Topics))
-
 	return author, nil
 }
 
@@ -49,8 +139,8 @@ func (c *Client) FetchAuthorsByName(name string) ([]domain.Author, error) {
 	// We must URL-encode the name to handle spaces and special characters.
 	encodedName := url.QueryEscape(name)
 
-	// URL will look like: https://api.openalex.org/authors?search=marie%20curie&mailto=...
-	requestURL := fmt.Sprintf("%s/authors?search=%s&mailto=%s", openAlexAPIBaseURL, encodedName)
+	// URL will look like: https://api.openalex.org/authors?search=marie%20curie
+	requestURL := fmt.Sprintf("%s/authors?search=%s&per-page=%d", openAlexAPIBaseURL, encodedName, c.perPage)
 
 	// The API response for a search is a paginated list, just like for filters.
 	var apiResponse struct {
@@ -66,185 +156,491 @@ func (c *Client) FetchAuthorsByName(name string) ([]domain.Author, error) {
 	return apiResponse.Results, nil
 }
 
-func (c *Client) FetchWorksByName(name string) ([]domain.Work, error) {
+// MaxPerPage is the largest page size OpenAlex accepts, DefaultPerPage the one it uses when
+// none is given, and MaxPagedResults the deepest result (page * per-page) reachable with
+// basic paging.
+const (
+	MaxPerPage      = 200
+	DefaultPerPage  = 25
+	MaxPagedResults = 10000
+)
+
+// FetchAuthorsByNamePage is FetchAuthorsByName with paging and optional filters (e.g.
+// "last_known_institutions.country_code:US"). It also returns the total number of matches.
+func (c *Client) FetchAuthorsByNamePage(name string, page, perPage int, filters ...string) ([]domain.Author, int, error) {
+	queryParams := url.Values{}
+	queryParams.Set("search", name)
+	queryParams.Set("page", fmt.Sprintf("%d", page))
+	queryParams.Set("per-page", fmt.Sprintf("%d", perPage))
+	var filterParts []string
+	for _, filter := range filters {
+		if filter != "" {
+			filterParts = append(filterParts, filter)
+		}
+	}
+	if len(filterParts) > 0 {
+		queryParams.Set("filter", strings.Join(filterParts, ","))
+	}
+	requestURL := fmt.Sprintf("%s/authors?%s", openAlexAPIBaseURL, queryParams.Encode())
+
+	var apiResponse struct {
+		Results []domain.Author `json:"results"`
+		Meta    struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}
+	if err := c.fetchAndDecode(requestURL, &apiResponse); err != nil {
+		return nil, 0, err
+	}
+	return apiResponse.Results, apiResponse.Meta.Count, nil
+}
+
+func (c *Client) FetchWorksByName(name string) ([]domain.Work, DecodeWarnings, error) {
+	// The API response for a search is a paginated list.
+	return c.collectWorks(c.WorksByNameURL(name))
+}
+
+// WorksByNameURL returns the URL FetchWorksByName requests, without requesting it.
+func (c *Client) WorksByNameURL(name string) string {
 	// URL-encode the name to handle spaces and special characters.
 	encodedName := url.QueryEscape(name)
 
 	// URL will look like: https://api.openalex.org/works?search=...
-	requestURL := fmt.Sprintf("%s/works?search=%s", openAlexAPIBaseURL, encodedName)
+	return fmt.Sprintf("%s/works?search=%s&select=%s&per-page=%d", openAlexAPIBaseURL, encodedName, workSelectFields, c.perPage)
+}
 
-	// The API response for a search is a paginated list.
-	var apiResponse struct {
-		Results []domain.Work `json:"results"`
+// FetchWorks fetches the first page of works matching f, in its sort order. Without a
+// PerPage the client's page size applies. Works that don't decode are left out and
+// reported as warnings; a Filter holding invalid values fails with its Err.
+func (c *Client) FetchWorks(f *Filter) ([]domain.Work, DecodeWarnings, error) {
+	requestURL, err := c.WorksURL(f)
+	if err != nil {
+		return nil, nil, err
 	}
+	return c.collectWorks(requestURL)
+}
 
-	// Reuse the generic helper function.
-	err := c.fetchAndDecode(requestURL, &apiResponse)
+// WorksURL returns the URL FetchWorks requests for f, without requesting it.
+func (c *Client) WorksURL(f *Filter) (string, error) {
+	q := *f
+	if q.perPage == 0 {
+		q.perPage = c.perPage
+	}
+	query, err := q.Query()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	return apiResponse.Results, nil
+	return fmt.Sprintf("%s/works?%s", openAlexAPIBaseURL, query), nil
 }
 
-func (c *Client) FetchWorksByAuthorID(authorID string, additionalFilters ...string) ([]domain.Work, error) { // Use variadic for default behavior
-	// The OpenAlex API uses a filter syntax like this:
-	// https://api.openalex.org/works?filter=author.id:A2043598041
-	// To add more filters, they are separated by commas (AND logic):
-	// https://api.openalex.org/works?filter=author.id:A2043598041,publication_year:>2020
-
-	// Construct the base filter string.
-	filterParts := []string{fmt.Sprintf("author.id:%s", authorID)}
+// FetchWorksByAuthorID fetches the first page of an author's works, ANDed with any
+// additional OpenAlex filter strings.
+//
+// Deprecated: build a Filter with AuthorID and use FetchWorks, which validates the values.
+func (c *Client) FetchWorksByAuthorID(authorID string, additionalFilters ...string) ([]domain.Work, DecodeWarnings, error) {
+	return c.FetchWorks(NewFilter().AuthorID(authorID).raw(additionalFilters...))
+}
 
-	// Append any additional filters provided.
-	for _, filter := range additionalFilters {
-		if filter != "" {
-			filterParts = append(filterParts, filter)
-		}
-	}
+// Sort orders for Filter.Sort.
+const (
+	SortByPublicationDate = "publication_date:desc"
+	SortByCitations       = "cited_by_count:desc"
+)
 
-	// Join all filter parts with a comma.
-	combinedFilters := strings.Join(filterParts, ",")
+// FetchRecentWorksByAuthorID returns an author's maxResults most cited works. Despite its
+// name it doesn't sort by date; use FetchLatestWorksByAuthorID for that.
+//
+// Deprecated: use FetchWorks with a Filter sorted by SortByCitations.
+func (c *Client) FetchRecentWorksByAuthorID(authorID string, maxResults int, additionalFilters ...string) ([]domain.Work, DecodeWarnings, error) {
+	return c.FetchAuthorWorksSorted(authorID, SortByCitations, maxResults, additionalFilters...)
+}
 
-	// Construct the final URL, ensuring the filter parameter is correctly formatted.
-	// We use url.Values to properly encode the entire filter string.
-	queryParams := url.Values{}
-	queryParams.Set("filter", combinedFilters)
+// FetchLatestWorksByAuthorID returns an author's maxResults most recently published works,
+// newest first.
+//
+// Deprecated: use FetchWorks with a Filter sorted by SortByPublicationDate.
+func (c *Client) FetchLatestWorksByAuthorID(authorID string, maxResults int, additionalFilters ...string) ([]domain.Work, DecodeWarnings, error) {
+	return c.FetchAuthorWorksSorted(authorID, SortByPublicationDate, maxResults, additionalFilters...)
+}
 
-	requestURL := fmt.Sprintf("%s/works?%s", openAlexAPIBaseURL, queryParams.Encode())
+// FetchAuthorWorksSorted returns the first maxResults of an author's works in the given
+// sort order (SortByPublicationDate or SortByCitations).
+//
+// Deprecated: use FetchWorks with a lean, sorted Filter.
+func (c *Client) FetchAuthorWorksSorted(authorID, sort string, maxResults int, additionalFilters ...string) ([]domain.Work, DecodeWarnings, error) {
+	return c.FetchWorks(authorWorksSortedFilter(authorID, sort, maxResults, additionalFilters))
+}
 
-	// The API response for a filter is a paginated list, just like for searches.
-	var apiResponse struct {
-		Results []domain.Work `json:"results"`
+// AuthorWorksSortedURL returns the URL FetchAuthorWorksSorted requests, without requesting
+// it, or "" when the arguments don't make a valid request.
+//
+// Deprecated: use Client.WorksURL with a lean, sorted Filter.
+func AuthorWorksSortedURL(authorID, sort string, maxResults int, additionalFilters ...string) string {
+	query, err := authorWorksSortedFilter(authorID, sort, maxResults, additionalFilters).Query()
+	if err != nil {
+		return ""
 	}
+	return fmt.Sprintf("%s/works?%s", openAlexAPIBaseURL, query)
+}
 
-	// We can reuse our generic helper function!
-	err := c.fetchAndDecode(requestURL, &apiResponse)
+func authorWorksSortedFilter(authorID, sort string, maxResults int, additionalFilters []string) *Filter {
+	return NewFilter().AuthorID(authorID).raw(additionalFilters...).Sort(sort).PerPage(maxResults).Lean()
+}
+
+func (c *Client) FetchAllWorksByAuthorID(authorID string) ([]domain.Work, DecodeWarnings, error) {
+	var allWorks []domain.Work
+	warnings, err := c.StreamWorksByAuthorID(authorID, func(work domain.Work) error {
+		allWorks = append(allWorks, work)
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	return apiResponse.Results, nil
+	return allWorks, warnings, nil
 }
 
-func (c *Client) FetchRecentWorksByAuthorID(authorID string, maxResults int) ([]domain.Work, error) {
-	// Calculate the year filter
-	// fiveYearsAgo := time.Now().Year() - 5
+// StreamWorksByAuthorID pages through all of an author's works and hands them to fn one at
+// a time, decoding each work straight off the response body. Unlike FetchAllWorksByAuthorID
+// it never holds more than one work in memory; an error from fn stops the iteration and is
+// returned as is. Works that don't decode are skipped and returned as warnings.
+func (c *Client) StreamWorksByAuthorID(authorID string, fn func(domain.Work) error) (DecodeWarnings, error) {
+	return c.StreamWorksByFilter("author.id:"+authorID, fn)
+}
 
-	// Corresponds to Python: f".../works?filter=authorships.author.id:{id},publication_year:>{year}&sort=publication_year:desc&per-page={max}"
-	filterValue := fmt.Sprintf("author.id:%s", authorID)
-	// encodedFilter := url.QueryEscape(filterValue)
+// StreamWorksByFilter is StreamWorksByAuthorID for an arbitrary OpenAlex filter string,
+// which should have been checked with ValidateFilter.
+func (c *Client) StreamWorksByFilter(filter string, fn func(domain.Work) error) (DecodeWarnings, error) {
+	cursor := "*"
+	var warnings DecodeWarnings
+	decoded := 0
 
-	requestURL := fmt.Sprintf(
-		"%s/works?filter=%s&sort=cited_by_count:desc&per-page=%d",
-		openAlexAPIBaseURL,
-		filterValue,
-		maxResults,
-	)
-	print(requestURL)
+	for page := 0; ; page++ {
+		if page > 0 {
+			c.pause()
+		}
+		nextCursor, pageWarnings, err := c.fetchWorks(WorksPageURL(filter, cursor), decoded, func(work domain.Work) error {
+			decoded++
+			return fn(work)
+		})
+		warnings = append(warnings, pageWarnings...)
+		decoded += len(pageWarnings)
+		if err != nil {
+			return warnings, err
+		}
 
-	var apiResponse struct {
-		Results []domain.Work `json:"results"`
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
 	}
 
-	err := c.fetchAndDecode(requestURL, &apiResponse)
+	return warnings, nil
+}
+
+// WorksPageURL returns the URL of the page of works matching filter at the given cursor
+// ("*" for the first page), as StreamWorksByFilter and FetchWorksPageByAuthorID request it.
+func WorksPageURL(filter, cursor string) string {
+	return fmt.Sprintf("%s/works?filter=%s&select=%s&per-page=%d&cursor=%s", openAlexAPIBaseURL, url.QueryEscape(filter), workSelectFields, MaxPerPage, url.QueryEscape(cursor))
+}
+
+// FetchWorksPageByAuthorID fetches one page of an author's works, starting at the given
+// OpenAlex cursor ("*" for the first page). It returns the cursor of the following page,
+// which is empty after the last one. Unlike StreamWorksByAuthorID the caller drives the
+// paging, so it can persist the cursor between pages and pick up from there later. Works
+// that don't decode are left out and returned as warnings.
+func (c *Client) FetchWorksPageByAuthorID(authorID, cursor string) ([]domain.Work, string, DecodeWarnings, error) {
+	if cursor != "*" {
+		c.pause()
+	}
+	var works []domain.Work
+	nextCursor, warnings, err := c.fetchWorks(WorksPageURL("author.id:"+authorID, cursor), 0, func(work domain.Work) error {
+		works = append(works, work)
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, "", nil, err
 	}
+	return works, nextCursor, warnings, nil
+}
 
-	return apiResponse.Results, nil
+// UpdatedSinceFilter returns the filter for works OpenAlex updated at or after since,
+// formatted as the UTC timestamp OpenAlex expects (2024-01-02T15:04:05Z).
+//
+// Deprecated: use Filter.FromUpdatedDate.
+func UpdatedSinceFilter(since time.Time) string {
+	return "from_updated_date:" + since.UTC().Format("2006-01-02T15:04:05Z")
 }
 
-func (c *Client) FetchAllWorksByAuthorID(authorID string) ([]domain.Work, error) {
-	var allWorks []domain.Work
-	cursor := "*"
-	perPage := 200
+// FetchWorksByAuthorUpdatedSince fetches the author's works that were created or updated in
+// OpenAlex since the given time, paging through all of them.
+func (c *Client) FetchWorksByAuthorUpdatedSince(ctx context.Context, authorID string, since time.Time) ([]domain.Work, DecodeWarnings, error) {
+	filter := NewFilter().AuthorID(authorID).FromUpdatedDate(since)
+	if err := filter.Err(); err != nil {
+		return nil, nil, err
+	}
+	var works []domain.Work
+	warnings, err := c.StreamWorksByFilter(filter.String(), func(work domain.Work) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		works = append(works, work)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return works, warnings, nil
+}
 
-	for {
-		url := fmt.Sprintf("%s/works?filter=author.id:%s&per-page=%d&cursor=%s", openAlexAPIBaseURL, authorID, perPage, url.QueryEscape(cursor))
+// FetchWorkIdentifiers fetches the identifiers of one work (id, doi and the ids map) by
+// anything the single-work endpoint accepts: an OpenAlex ID, "doi:10.1234/abc",
+// "mag:2741809807", ... Unknown works return an error matching ErrNotFound.
+func (c *Client) FetchWorkIdentifiers(ctx context.Context, id string) (domain.Work, error) {
+	// DOIs keep their slashes; OpenAlex doesn't match them escaped.
+	requestURL := fmt.Sprintf("%s/works/%s?select=id,doi,ids,title", openAlexAPIBaseURL, strings.ReplaceAll(url.PathEscape(id), "%2F", "/"))
 
-		var resp struct {
-			Results []domain.Work `json:"results"`
-			Meta    struct {
-				NextCursor string `json:"next_cursor"`
-			} `json:"meta"`
-		}
+	body, err := c.get(ctx, requestURL)
+	if err != nil {
+		return domain.Work{}, err
+	}
+	defer body.Close()
 
-		if err := c.fetchAndDecode(url, &resp); err != nil {
-			return nil, err
-		}
+	var work domain.Work
+	if err := json.NewDecoder(body).Decode(&work); err != nil {
+		return domain.Work{}, fmt.Errorf("failed to decode json response: %w", err)
+	}
+	return work, nil
+}
 
-		allWorks = append(allWorks, resp.Results...)
+// CountWorks returns how many works match an OpenAlex filter string (meta.count), fetching
+// a single one-field result instead of the works themselves.
+func (c *Client) CountWorks(ctx context.Context, filter string) (int, error) {
+	requestURL := fmt.Sprintf("%s/works?filter=%s&select=id&per-page=1", openAlexAPIBaseURL, url.QueryEscape(filter))
 
-		if resp.Meta.NextCursor == "" {
-			break
-		}
-		cursor = resp.Meta.NextCursor
+	body, err := c.get(ctx, requestURL)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	var apiResponse struct {
+		Meta struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}
+	if err := json.NewDecoder(body).Decode(&apiResponse); err != nil {
+		return 0, fmt.Errorf("failed to decode json response: %w", err)
 	}
+	return apiResponse.Meta.Count, nil
+}
 
-	return allWorks, nil
+// Ping checks that OpenAlex answers, with the cheapest works request there is: one result
+// of one field.
+func (c *Client) Ping(ctx context.Context) error {
+	body, err := c.get(ctx, openAlexAPIBaseURL+"/works?select=id&per-page=1")
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
+// CheckReachable sends OpenAlex a HEAD request for the works endpoint, the lightest way to
+// tell it can be reached and answers.
+func (c *Client) CheckReachable(ctx context.Context) error {
+	requestURL := openAlexAPIBaseURL + "/works?per-page=1"
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create new http request: %w", err)
+	}
+	started := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logRequest("HEAD", requestURL, "error: "+err.Error(), started, -1)
+		return fmt.Errorf("failed to execute http request: %w", err)
+	}
+	resp.Body.Close()
+	c.logRequest("HEAD", requestURL, resp.Status, started, -1)
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, URL: requestURL}
+	}
+	return nil
 }
 
 type Publication struct {
-	Title                 string           `json:"title"`
-	PublicationYear       int              `json:"publication_year"`
-	CitedByCount          int              `json:"cited_by_count"`
-	AbstractInvertedIndex map[string][]int `json:"abstract_inverted_index"`
+	ID                    string            `json:"id"`
+	Doi                   string            `json:"doi"`
+	Ids                   map[string]string `json:"ids"`
+	Title                 string            `json:"title"`
+	PublicationYear       int               `json:"publication_year"`
+	CitedByCount          int               `json:"cited_by_count"`
+	AbstractInvertedIndex map[string][]int  `json:"abstract_inverted_index"`
+	Abstract              string            `json:"abstract,omitempty"` // Reconstructed from the inverted index, else filled from Semantic Scholar.
 }
 
+// FetchAbstractByAuthorID fetches the abstracts of an author's maxResults most cited works,
+// paging through them as needed; maxResults of 0 or less fetches all of them.
 func (c *Client) FetchAbstractByAuthorID(authorID string, maxResults int) ([]Publication, error) {
-	// Calculate the year filter
-	// fiveYearsAgo := time.Now().Year() - 5
-
-	// Corresponds to Python: f".../works?filter=authorships.author.id:{id},publication_year:>{year}&sort=publication_year:desc&per-page={max}"
-	filterValue := fmt.Sprintf("author.id:%s", authorID)
-	// encodedFilter := url.QueryEscape(filterValue)
-
-	requestURL := fmt.Sprintf(
-		"%s/works?select=title,primary_location,publication_year,cited_by_count,abstract_inverted_index&filter=%s&sort=cited_by_count:desc&per-page=%d",
-		openAlexAPIBaseURL,
-		filterValue,
-		maxResults,
-	)
-	print(requestURL)
+	perPage := MaxPerPage
+	if maxResults > 0 && maxResults < perPage {
+		perPage = maxResults
+	}
+	var pubs []Publication
+	for cursor := "*"; cursor != ""; {
+		page, nextCursor, err := c.FetchAbstractsPageByAuthorID(authorID, cursor, perPage)
+		if err != nil {
+			return nil, err
+		}
+		pubs = append(pubs, page...)
+		if maxResults > 0 && len(pubs) >= maxResults {
+			return pubs[:maxResults], nil
+		}
+		cursor = nextCursor
+	}
+	return pubs, nil
+}
 
-	// Publication represents each object within the "results" array.
+// AbstractsPageURL returns the URL of a page of perPage of an author's works, most cited
+// first, with their abstracts, at the given cursor ("*" for the first page).
+func AbstractsPageURL(authorID, cursor string, perPage int) string {
+	return fmt.Sprintf(
+		"%s/works?select=id,doi,ids,title,primary_location,publication_year,cited_by_count,abstract_inverted_index&filter=%s&sort=cited_by_count:desc&per-page=%d&cursor=%s",
+		openAlexAPIBaseURL, url.QueryEscape("author.id:"+authorID), ClampPerPage(perPage), url.QueryEscape(cursor))
+}
 
+// FetchAbstractsPageByAuthorID fetches one page of an author's works with their abstracts,
+// most cited first, starting at the given OpenAlex cursor ("*" for the first page). It
+// returns the cursor of the following page, which is empty after the last one. Abstract is
+// reconstructed from the inverted index of every work that has one.
+func (c *Client) FetchAbstractsPageByAuthorID(authorID, cursor string, perPage int) ([]Publication, string, error) {
+	if cursor != "*" {
+		c.pause()
+	}
 	var apiResponse struct {
+		Meta struct {
+			NextCursor string `json:"next_cursor"`
+		} `json:"meta"`
 		Results []Publication `json:"results"`
 	}
+	if err := c.fetchAndDecode(AbstractsPageURL(authorID, cursor, perPage), &apiResponse); err != nil {
+		return nil, "", err
+	}
+	for i := range apiResponse.Results {
+		pub := &apiResponse.Results[i]
+		pub.Abstract = domain.ReconstructAbstract(pub.AbstractInvertedIndex)
+	}
+	// The last page can still carry a cursor; an empty page has none to follow.
+	if len(apiResponse.Results) == 0 {
+		return apiResponse.Results, "", nil
+	}
+	return apiResponse.Results, apiResponse.Meta.NextCursor, nil
+}
 
-	err := c.fetchAndDecode(requestURL, &apiResponse)
+// fetchAndStream performs a GET request for a paginated list and walks its "results" array
+// token by token, calling decodeResult once per element with the decoder positioned at that
+// element. It returns meta.next_cursor, which is empty on the last page.
+func (c *Client) fetchAndStream(url string, decodeResult func(dec *json.Decoder) error) (string, error) {
+	body, err := c.get(context.Background(), url)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	defer body.Close()
 
-	return apiResponse.Results, nil
+	var meta struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	dec := json.NewDecoder(body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return "", err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to decode json response: %w", err)
+		}
+		switch tok {
+		case "results":
+			if err := expectDelim(dec, '['); err != nil {
+				return "", err
+			}
+			for dec.More() {
+				if err := decodeResult(dec); err != nil {
+					return "", err
+				}
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return "", err
+			}
+		case "meta":
+			if err := dec.Decode(&meta); err != nil {
+				return "", fmt.Errorf("failed to decode json response: %w", err)
+			}
+		default:
+			// group_by and anything else we don't use.
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return "", fmt.Errorf("failed to decode json response: %w", err)
+			}
+		}
+	}
+	return meta.NextCursor, nil
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode json response: %w", err)
+	}
+	if tok != want {
+		return fmt.Errorf("failed to decode json response: expected %q, got %v", want, tok)
+	}
+	return nil
 }
 
 // fetchAndDecode is a generic helper function to perform a GET request
 // and decode the JSON response into the target interface{}.
 func (c *Client) fetchAndDecode(url string, target interface{}) error {
-	req, err := http.NewRequest("GET", url, nil)
+	body, err := c.get(context.Background(), url)
 	if err != nil {
-		return fmt.Errorf("failed to create new http request: %w", err)
+		return err
+	}
+	defer body.Close()
+
+	// Decode the JSON from the response body into the 'target'
+	// The target is a pointer, so this function modifies the original variable passed in.
+	if err := json.NewDecoder(body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode json response: %w", err)
+	}
+
+	return nil
+}
+
+// get waits for the rate limiter, performs a GET request and returns the body of a 200
+// response. The caller must close it.
+func (c *Client) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new http request: %w", err)
 	}
 
+	started := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute http request: %w", err)
+		c.logRequest("GET", url, "error: "+err.Error(), started, -1)
+		return nil, fmt.Errorf("failed to execute http request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad response from OpenAlex API (%s): %s", url, resp.Status)
+		resp.Body.Close()
+		c.logRequest("GET", url, resp.Status, started, -1)
+		return nil, &APIError{StatusCode: resp.StatusCode, Status: resp.Status, URL: url}
 	}
-
-	// Decode the JSON from the response body into the 'target'
-	// The target is a pointer, so this function modifies the original variable passed in.
-	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
-		return fmt.Errorf("failed to decode json response: %w", err)
+	if c.debugLog == nil {
+		return resp.Body, nil
 	}
-
-	return nil
+	// The size is only known once the caller has read the body, so the request is logged on Close.
+	return &loggedBody{ReadCloser: resp.Body, client: c, url: url, status: resp.Status, started: started}, nil
 }
@@ -0,0 +1,203 @@
+package openalex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// rewriteTransport sends every request to the test server instead of OpenAlex.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestClient returns a client whose requests are answered by handler. Without options
+// it has neither a rate limit worth waiting for nor pauses between pages.
+func newTestClient(t testing.TB, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+
+	c := NewClient(append([]Option{WithRateLimit(1000, 100), WithPageJitter(0, 0)}, opts...)...)
+	c.httpClient.Transport = rewriteTransport{target}
+	return c
+}
+
+// pagedWorks answers works requests with pages of one work, following the cursor up to
+// pages pages, and records when each request arrived.
+type pagedWorks struct {
+	pages int
+
+	mu       sync.Mutex
+	arrivals []time.Time
+}
+
+func (p *pagedWorks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.arrivals = append(p.arrivals, time.Now())
+	p.mu.Unlock()
+
+	page := 0
+	if cursor := r.URL.Query().Get("cursor"); cursor != "*" {
+		fmt.Sscan(cursor, &page)
+	}
+	next := ""
+	if page+1 < p.pages {
+		next = fmt.Sprint(page + 1)
+	}
+	fmt.Fprintf(w, `{"meta": {"next_cursor": %q}, "results": [{"id": "https://openalex.org/W%d", "title": "page %d"}]}`, next, page+1, page)
+}
+
+func (p *pagedWorks) times() []time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	times := append([]time.Time(nil), p.arrivals...)
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// Several jobs paging at once share the client's limiter: however they line up, no
+// stretch of time sees more requests than the bucket allows.
+func TestConcurrentPagingStaysUnderRateCeiling(t *testing.T) {
+	const (
+		rate  = 40
+		burst = 2
+		jobs  = 3
+		pages = 4
+	)
+	server := &pagedWorks{pages: pages}
+	c := newTestClient(t, server.ServeHTTP, WithRateLimit(rate, burst), WithPageJitter(2*time.Millisecond, 8*time.Millisecond))
+
+	var wg sync.WaitGroup
+	for job := 0; job < jobs; job++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := 0
+			_, err := c.StreamWorksByFilter(fmt.Sprintf("author.id:A%d", job), func(domain.Work) error {
+				n++
+				return nil
+			})
+			if err != nil {
+				t.Errorf("job %d: %v", job, err)
+			}
+			if n != pages {
+				t.Errorf("job %d streamed %d works, want %d", job, n, pages)
+			}
+		}()
+	}
+	wg.Wait()
+
+	times := server.times()
+	if len(times) != jobs*pages {
+		t.Fatalf("server saw %d requests, want %d", len(times), jobs*pages)
+	}
+	// A token bucket lets through at most burst + rate*d requests in any window of length d.
+	// One request of slack absorbs scheduling noise between the client and the server.
+	for i := range times {
+		for j := i + 1; j < len(times); j++ {
+			d := times[j].Sub(times[i]).Seconds()
+			if n, ceiling := j-i+1, burst+int(math.Floor(rate*d))+1; n > ceiling {
+				t.Fatalf("%d requests within %v, want at most %d", n, times[j].Sub(times[i]), ceiling)
+			}
+		}
+	}
+}
+
+func TestPagingPausesBetweenPages(t *testing.T) {
+	const jitterMin = 20 * time.Millisecond
+	server := &pagedWorks{pages: 3}
+	c := newTestClient(t, server.ServeHTTP, WithPageJitter(jitterMin, jitterMin+10*time.Millisecond))
+
+	if _, err := c.StreamWorksByFilter("author.id:A1", func(domain.Work) error { return nil }); err != nil {
+		t.Fatalf("StreamWorksByFilter: %v", err)
+	}
+	times := server.times()
+	if len(times) != 3 {
+		t.Fatalf("server saw %d requests, want 3", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < jitterMin {
+			t.Errorf("page %d followed page %d after %v, want at least %v", i+1, i, gap, jitterMin)
+		}
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []Option
+		wantMin, wantMax  time.Duration
+		wantDefaultBucket bool
+	}{
+		{"defaults", nil, 100 * time.Millisecond, 400 * time.Millisecond, true},
+		{"jitter", []Option{WithPageJitter(time.Millisecond, 5*time.Millisecond)}, time.Millisecond, 5 * time.Millisecond, true},
+		{"max below min", []Option{WithPageJitter(5*time.Millisecond, time.Millisecond)}, 5 * time.Millisecond, 5 * time.Millisecond, true},
+		{"no jitter", []Option{WithPageJitter(0, 0)}, 0, 0, true},
+		{"zero rate keeps the default", []Option{WithRateLimit(0, 5)}, 100 * time.Millisecond, 400 * time.Millisecond, true},
+		{"rate", []Option{WithRateLimit(2, 3)}, 100 * time.Millisecond, 400 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(tt.opts...)
+			if c.pageJitterMin != tt.wantMin || c.pageJitterMax != tt.wantMax {
+				t.Errorf("page jitter = [%v, %v], want [%v, %v]", c.pageJitterMin, c.pageJitterMax, tt.wantMin, tt.wantMax)
+			}
+			// The default bucket allows a single request at once.
+			ok1, _ := c.limiter.Allow()
+			ok2, _ := c.limiter.Allow()
+			if gotDefault := ok1 && !ok2; gotDefault != tt.wantDefaultBucket {
+				t.Errorf("limiter burst of 1 = %v, want %v", gotDefault, tt.wantDefaultBucket)
+			}
+		})
+	}
+}
+
+func TestCountWorks(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    int
+		wantErr bool
+	}{
+		{"count", http.StatusOK, `{"meta": {"count": 4230, "per_page": 1}, "results": [{"id": "https://openalex.org/W1"}]}`, 4230, false},
+		{"no matches", http.StatusOK, `{"meta": {"count": 0}, "results": []}`, 0, false},
+		{"malformed", http.StatusOK, `{"meta": `, 0, true},
+		{"not found", http.StatusNotFound, `{"error": "not found"}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				if r.URL.Path != "/works" || query.Get("filter") != "author.id:A1,is_oa:true" ||
+					query.Get("per-page") != "1" || query.Get("select") != "id" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			got, err := c.CountWorks(context.Background(), "author.id:A1,is_oa:true")
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("CountWorks = %d, %v; want %d, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
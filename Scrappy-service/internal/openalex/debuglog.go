@@ -0,0 +1,69 @@
+package openalex
+
+import (
+	"io"
+	"log"
+	"net/url"
+	"time"
+)
+
+// redactedParams are query parameters whose values never appear in logs.
+var redactedParams = []string{"api_key", "mailto"}
+
+// WithDebugLogger logs every outbound request (method, redacted URL, status, duration and
+// response size) to logger. Requests are not logged without it.
+func WithDebugLogger(logger *log.Logger) Option {
+	return func(c *Client) {
+		c.debugLog = logger
+	}
+}
+
+// redactURL replaces the values of redactedParams in rawURL. URLs that can't be parsed are
+// left out entirely rather than logged as is.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<unparseable URL>"
+	}
+	q := u.Query()
+	redacted := false
+	for _, param := range redactedParams {
+		if q.Has(param) {
+			q.Set(param, "REDACTED")
+			redacted = true
+		}
+	}
+	if redacted {
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// logRequest writes one debug line for a finished request; size is -1 when no body was read.
+func (c *Client) logRequest(method, rawURL, status string, started time.Time, size int64) {
+	if c.debugLog == nil {
+		return
+	}
+	c.debugLog.Printf("DEBUG: OpenAlex %s %s -> %s (%s, %d bytes)", method, redactURL(rawURL), status, time.Since(started).Round(time.Millisecond), size)
+}
+
+// loggedBody counts the bytes read from a response body and logs the request on Close.
+type loggedBody struct {
+	io.ReadCloser
+	client  *Client
+	url     string
+	status  string
+	started time.Time
+	size    int64
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	b.client.logRequest("GET", b.url, b.status, b.started, b.size)
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,85 @@
+package openalex
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://api.openalex.org/works?filter=author.id:A1", "https://api.openalex.org/works?filter=author.id:A1"},
+		{"https://api.openalex.org/works?api_key=s3cret&filter=x", "https://api.openalex.org/works?api_key=REDACTED&filter=x"},
+		{"https://api.openalex.org/authors/A1?mailto=me@example.org", "https://api.openalex.org/authors/A1?mailto=REDACTED"},
+		{"https://api.openalex.org/works?mailto=me@example.org&api_key=s3cret", "https://api.openalex.org/works?api_key=REDACTED&mailto=REDACTED"},
+		{"https://api.openalex.org/works?api_key=a&api_key=b", "https://api.openalex.org/works?api_key=REDACTED"},
+		{"://api_key=s3cret", "<unparseable URL>"},
+	}
+	for _, tt := range tests {
+		if got := redactURL(tt.raw); got != tt.want {
+			t.Errorf("redactURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestDebugLoggerRedactsRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus string
+		wantSize   string
+	}{
+		{"ok", http.StatusOK, "200 OK", ", 11 bytes)"},
+		{"error", http.StatusNotFound, "404 Not Found", ", -1 bytes)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged bytes.Buffer
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"meta":{}}`))
+			}, WithDebugLogger(log.New(&logged, "", 0)))
+
+			body, err := c.get(context.Background(), openAlexAPIBaseURL+"/works?filter=author.id:A1&api_key=s3cret&mailto=me@example.org")
+			if err == nil {
+				io.Copy(io.Discard, body)
+				body.Close()
+			}
+			line := logged.String()
+			for _, secret := range []string{"s3cret", "me@example.org", "me%40example.org"} {
+				if strings.Contains(line, secret) {
+					t.Errorf("log line leaks %q: %s", secret, line)
+				}
+			}
+			for _, want := range []string{"DEBUG: OpenAlex GET ", "api_key=REDACTED", "mailto=REDACTED", "filter=author.id%3AA1", tt.wantStatus, tt.wantSize} {
+				if !strings.Contains(line, want) {
+					t.Errorf("log line %q lacks %q", line, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNoDebugLoggerNoLogging(t *testing.T) {
+	var logged bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&logged)
+	t.Cleanup(func() { log.SetOutput(original) })
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{}`)) })
+	body, err := c.get(context.Background(), openAlexAPIBaseURL+"/works?api_key=s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if strings.Contains(logged.String(), "OpenAlex") {
+		t.Errorf("request logged without a debug logger: %s", logged.String())
+	}
+}
@@ -0,0 +1,86 @@
+package openalex
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// DecodeWarning is a work in a list response that didn't match domain.Work (say, a numeric
+// award_id or an array where an object was expected) and was left out.
+type DecodeWarning struct {
+	// Index is the work's position in the results, counted across pages for paged fetches.
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+func (w DecodeWarning) String() string {
+	if w.ID == "" {
+		return fmt.Sprintf("result %d: %s", w.Index, w.Error)
+	}
+	return fmt.Sprintf("result %d (%s): %s", w.Index, w.ID, w.Error)
+}
+
+// DecodeWarnings are the works a list fetch had to leave out. The other works of the
+// response are still returned.
+type DecodeWarnings []DecodeWarning
+
+// Samples returns the messages of the first n warnings.
+func (ws DecodeWarnings) Samples(n int) []string {
+	if len(ws) < n {
+		n = len(ws)
+	}
+	samples := make([]string, 0, n)
+	for _, w := range ws[:n] {
+		samples = append(samples, w.String())
+	}
+	return samples
+}
+
+// fetchWorks fetches one page of works and hands each to fn. Every result is decoded on
+// its own, so a work of an unexpected shape is left out with a warning instead of failing
+// the whole page; only malformed JSON is an error. Warning indexes start at offset. It
+// returns the cursor of the following page, if the request asked for one.
+func (c *Client) fetchWorks(url string, offset int, fn func(domain.Work) error) (string, DecodeWarnings, error) {
+	var warnings DecodeWarnings
+	index := offset
+	nextCursor, err := c.fetchAndStream(url, func(dec *json.Decoder) error {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("failed to decode work: %w", err)
+		}
+		index++
+		var work domain.Work
+		if err := json.Unmarshal(raw, &work); err != nil {
+			warnings = append(warnings, newDecodeWarning(index-1, raw, err))
+			return nil
+		}
+		return fn(work)
+	})
+	return nextCursor, warnings, err
+}
+
+// collectWorks is fetchWorks for a single page, returning its works.
+func (c *Client) collectWorks(url string) ([]domain.Work, DecodeWarnings, error) {
+	var works []domain.Work
+	_, warnings, err := c.fetchWorks(url, 0, func(work domain.Work) error {
+		works = append(works, work)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return works, warnings, nil
+}
+
+// newDecodeWarning describes a result that failed to decode, with its id when that much
+// of it can be read.
+func newDecodeWarning(index int, raw json.RawMessage, err error) DecodeWarning {
+	var ident struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(raw, &ident)
+	return DecodeWarning{Index: index, ID: ident.ID, Error: err.Error()}
+}
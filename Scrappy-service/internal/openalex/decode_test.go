@@ -0,0 +1,356 @@
+package openalex
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// worksPage returns a page of n works shaped like OpenAlex's, each with an abstract index
+// of words words, which is what makes real pages large.
+func worksPage(n, words int) []byte {
+	results := make([]map[string]any, n)
+	for i := range results {
+		index := make(map[string][]int, words)
+		for pos := 0; pos < words; pos++ {
+			word := fmt.Sprintf("word%d", pos%(words/2+1))
+			index[word] = append(index[word], pos)
+		}
+		results[i] = map[string]any{
+			"id":               fmt.Sprintf("https://openalex.org/W%d", i+1),
+			"title":            fmt.Sprintf("Work %d", i+1),
+			"doi":              fmt.Sprintf("https://doi.org/10.1/%d", i+1),
+			"type":             "article",
+			"publication_date": "2021-03-04",
+			"publication_year": 2021,
+			"cited_by_count":   i,
+			"is_retracted":     i%7 == 0,
+			"language":         "en",
+			"updated_date":     "2024-01-02T03:04:05.123456",
+			"referenced_works": []string{"https://openalex.org/W900", "https://openalex.org/W901"},
+			"primary_location": map[string]any{"is_oa": true, "source": map[string]any{"id": "https://openalex.org/S1", "display_name": "Venue", "issn_l": "1234-5678"}},
+			"biblio":           map[string]any{"volume": "7", "issue": "2", "first_page": "10", "last_page": "20"},
+			"grants":           []map[string]any{{"funder": "https://openalex.org/F1", "funder_display_name": "Funder", "award_id": "X-1"}},
+			"topics":           []map[string]any{{"id": "https://openalex.org/T1", "display_name": "Topic", "score": 0.9}},
+			"authorships": []map[string]any{{
+				"author_position": "first",
+				"author":          map[string]any{"id": "https://openalex.org/A1", "display_name": "Ada"},
+				"institutions":    []map[string]any{{"id": "https://openalex.org/I1", "display_name": "Inst", "country_code": "DE"}},
+			}},
+			"ids":                     map[string]string{"openalex": fmt.Sprintf("https://openalex.org/W%d", i+1), "pmid": "1"},
+			"abstract_inverted_index": index,
+		}
+	}
+	body, err := json.Marshal(map[string]any{"meta": map[string]any{"count": n, "next_cursor": nil}, "results": results})
+	if err != nil {
+		panic(err)
+	}
+	return body
+}
+
+func servePage(body []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}
+}
+
+func TestStreamingDecodeMatchesWholePage(t *testing.T) {
+	body := worksPage(50, 200)
+	c := newTestClient(t, servePage(body))
+
+	streamed, warnings, err := c.collectWorks(openAlexAPIBaseURL + "/works")
+	if err != nil {
+		t.Fatalf("collectWorks: %v", err)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	var whole struct {
+		Results []domain.Work `json:"results"`
+	}
+	if err := json.Unmarshal(body, &whole); err != nil {
+		t.Fatalf("decoding the whole page: %v", err)
+	}
+	if len(streamed) != len(whole.Results) {
+		t.Fatalf("streamed %d works, the whole page has %d", len(streamed), len(whole.Results))
+	}
+	for i := range streamed {
+		if !reflect.DeepEqual(streamed[i], whole.Results[i]) {
+			t.Errorf("work %d differs:\nstreamed: %+v\nwhole:    %+v", i, streamed[i], whole.Results[i])
+		}
+	}
+	if w := streamed[0]; w.Abstract == "" || w.AbstractInvertedIndex != nil || len(w.Authorships) != 1 || w.Biblio.Volume != "7" {
+		t.Errorf("work 0 = %+v, want its fields decoded and the abstract reconstructed", w)
+	}
+}
+
+func TestFetchWorksDecodesWorksOneByOne(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		offset       int
+		wantIDs      []string
+		wantWarnings []DecodeWarning
+		wantErr      bool
+	}{
+		{
+			name:    "all good",
+			body:    `{"meta": {"next_cursor": "c"}, "results": [{"id": "W1"}, {"id": "W2"}]}`,
+			wantIDs: []string{"W1", "W2"},
+		},
+		{
+			name:         "unexpected shape",
+			body:         `{"results": [{"id": "W1"}, {"id": "W2", "grants": "none"}, {"id": "W3"}], "group_by": []}`,
+			offset:       200,
+			wantIDs:      []string{"W1", "W3"},
+			wantWarnings: []DecodeWarning{{Index: 201, ID: "W2"}},
+		},
+		{name: "empty", body: `{"meta": {}, "results": []}`},
+		{name: "malformed", body: `{"results": [{"id": "W1"}, {"id": `, wantErr: true},
+		{name: "not an object", body: `[{"id": "W1"}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, servePage([]byte(tt.body)))
+			var ids []string
+			_, warnings, err := c.fetchWorks(openAlexAPIBaseURL+"/works", tt.offset, func(work domain.Work) error {
+				ids = append(ids, work.ID)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchWorks error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("works = %v, want %v", ids, tt.wantIDs)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("warnings = %v, want %v", warnings, tt.wantWarnings)
+			}
+			for i, want := range tt.wantWarnings {
+				if got := warnings[i]; got.Index != want.Index || got.ID != want.ID || got.Error == "" {
+					t.Errorf("warning %d = %+v, want index %d and id %s", i, got, want.Index, want.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestWorksURLSelectsAbstractsOnlyWhenNeeded(t *testing.T) {
+	c := NewClient()
+	lean, err := c.WorksURL(NewFilter().AuthorID("A1").Lean())
+	if err != nil {
+		t.Fatalf("WorksURL: %v", err)
+	}
+	full, err := c.WorksURL(NewFilter().AuthorID("A1"))
+	if err != nil {
+		t.Fatalf("WorksURL: %v", err)
+	}
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{"lean filter", lean, false},
+		{"filter", full, true},
+		{"paged ingestion", WorksPageURL("author.id:A1", "*"), true},
+		{"sorted listing", AuthorWorksSortedURL("A1", SortByCitations, 10), false},
+	}
+	for _, tt := range tests {
+		if got := strings.Contains(tt.url, "abstract_inverted_index"); got != tt.want {
+			t.Errorf("%s: selects abstracts = %v, want %v (%s)", tt.name, got, tt.want, tt.url)
+		}
+	}
+}
+
+// BenchmarkDecodeWorksPage compares decoding a large page whole, as a []domain.Work, with
+// the streaming decoder that hands works over one at a time. peak-heap-B is the most heap
+// in use above the baseline at any point of the decode.
+func BenchmarkDecodeWorksPage(b *testing.B) {
+	body := worksPage(200, 400)
+	c := newTestClient(b, servePage(body))
+	b.Logf("page of %d bytes", len(body))
+
+	b.Run("whole", func(b *testing.B) {
+		var peak uint64
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			base := heapInUse()
+			resp, err := c.httpClient.Get(openAlexAPIBaseURL + "/works")
+			if err != nil {
+				b.Fatal(err)
+			}
+			var page struct {
+				Results []domain.Work `json:"results"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&page)
+			resp.Body.Close()
+			if err != nil {
+				b.Fatal(err)
+			}
+			peak = max(peak, heapAbove(base))
+			runtime.KeepAlive(page)
+		}
+		b.ReportMetric(float64(peak), "peak-heap-B")
+	})
+
+	b.Run("streaming", func(b *testing.B) {
+		var peak uint64
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			base := heapInUse()
+			n := 0
+			_, _, err := c.fetchWorks(openAlexAPIBaseURL+"/works", 0, func(work domain.Work) error {
+				if n++; n%20 == 0 {
+					peak = max(peak, heapAbove(base))
+				}
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(peak), "peak-heap-B")
+	})
+}
+
+// heapInUse collects garbage and returns the bytes of heap still in use.
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
+
+// heapAbove returns how much more heap is in use now than base, without collecting.
+func heapAbove(base uint64) uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapInuse < base {
+		return 0
+	}
+	return stats.HeapInuse - base
+}
+
+// poisonedPages serves the works of a filter in two pages, each with works of an
+// unexpected shape among good ones: W2 on the first, W4 and W5 on the second.
+func poisonedPages(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("cursor") {
+	case "*", "":
+		fmt.Fprint(w, `{"meta": {"next_cursor": "c2"}, "results": [
+			{"id": "https://openalex.org/W1"},
+			{"id": "https://openalex.org/W2", "grants": [{"award_id": 12345}]},
+			{"id": "https://openalex.org/W3"}]}`)
+	case "c2":
+		fmt.Fprint(w, `{"meta": {"next_cursor": null}, "results": [
+			{"id": "https://openalex.org/W4", "primary_location": []},
+			{"title": ["not", "a", "string"]},
+			{"id": "https://openalex.org/W6"}]}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestPoisonedWorksAreLeftOut(t *testing.T) {
+	tests := []struct {
+		name         string
+		fetch        func(c *Client) ([]domain.Work, DecodeWarnings, error)
+		wantIDs      []string
+		wantWarnings []DecodeWarning
+	}{
+		{
+			name: "one page",
+			fetch: func(c *Client) ([]domain.Work, DecodeWarnings, error) {
+				works, _, warnings, err := c.FetchWorksPageByAuthorID("A1", "*")
+				return works, warnings, err
+			},
+			wantIDs:      []string{"W1", "W3"},
+			wantWarnings: []DecodeWarning{{Index: 1, ID: "W2"}},
+		},
+		{
+			name: "later page",
+			fetch: func(c *Client) ([]domain.Work, DecodeWarnings, error) {
+				works, _, warnings, err := c.FetchWorksPageByAuthorID("A1", "c2")
+				return works, warnings, err
+			},
+			wantIDs:      []string{"W6"},
+			wantWarnings: []DecodeWarning{{Index: 0, ID: "W4"}, {Index: 1}},
+		},
+		{
+			name: "streamed pages count across pages",
+			fetch: func(c *Client) ([]domain.Work, DecodeWarnings, error) {
+				var works []domain.Work
+				warnings, err := c.StreamWorksByFilter("author.id:A1", func(work domain.Work) error {
+					works = append(works, work)
+					return nil
+				})
+				return works, warnings, err
+			},
+			wantIDs:      []string{"W1", "W3", "W6"},
+			wantWarnings: []DecodeWarning{{Index: 1, ID: "W2"}, {Index: 3, ID: "W4"}, {Index: 4}},
+		},
+		{
+			name: "first page of a filter",
+			fetch: func(c *Client) ([]domain.Work, DecodeWarnings, error) {
+				return c.FetchWorks(NewFilter().AuthorID("A1"))
+			},
+			wantIDs:      []string{"W1", "W3"},
+			wantWarnings: []DecodeWarning{{Index: 1, ID: "W2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			works, warnings, err := tt.fetch(newTestClient(t, poisonedPages))
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			var ids []string
+			for _, work := range works {
+				ids = append(ids, strings.TrimPrefix(work.ID, "https://openalex.org/"))
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("works = %v, want %v", ids, tt.wantIDs)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("warnings = %v, want %v", warnings, tt.wantWarnings)
+			}
+			for i, want := range tt.wantWarnings {
+				got := warnings[i]
+				if got.Index != want.Index || strings.TrimPrefix(got.ID, "https://openalex.org/") != want.ID || got.Error == "" {
+					t.Errorf("warning %d = %+v, want index %d and id %q", i, got, want.Index, want.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWarningsSamples(t *testing.T) {
+	warnings := DecodeWarnings{
+		{Index: 3, ID: "https://openalex.org/W4", Error: "bad grants"},
+		{Index: 7, Error: "bad title"},
+	}
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{0, []string{}},
+		{1, []string{"result 3 (https://openalex.org/W4): bad grants"}},
+		{5, []string{"result 3 (https://openalex.org/W4): bad grants", "result 7: bad title"}},
+	}
+	for _, tt := range tests {
+		if got := warnings.Samples(tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Samples(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+	if got := DecodeWarnings(nil).Samples(5); len(got) != 0 {
+		t.Errorf("Samples of no warnings = %q, want none", got)
+	}
+}
@@ -0,0 +1,59 @@
+package openalex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound matches (via errors.Is) an APIError for an entity OpenAlex doesn't have.
+var ErrNotFound = errors.New("not found in OpenAlex")
+
+// ErrInvalidID is returned by ValidateID for strings that can't be OpenAlex IDs.
+var ErrInvalidID = errors.New("invalid OpenAlex ID")
+
+// APIError is returned when OpenAlex answers with a non-200 status.
+type APIError struct {
+	StatusCode int
+	Status     string
+	URL        string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("bad response from OpenAlex API (%s): %s", e.URL, e.Status)
+}
+
+// Is makes errors.Is(err, ErrNotFound) true for 404 responses.
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+var shortIDPattern = regexp.MustCompile(`^[AWIS][0-9]+$`)
+
+// ValidateID checks that id is an OpenAlex ID of one of the given entity kinds ('A'uthor,
+// 'W'ork, 'I'nstitution, 'S'ource), either bare (A5023896336) or in URL form
+// (https://openalex.org/A5023896336), and returns it in bare form. Anything else, including
+// URLs with a query string or lowercase prefixes, is rejected with ErrInvalidID.
+func ValidateID(id string, kinds ...byte) (string, error) {
+	short := strings.TrimPrefix(strings.TrimSpace(id), "https://openalex.org/")
+	if !shortIDPattern.MatchString(short) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	for _, kind := range kinds {
+		if short[0] == kind {
+			return short, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is not a %s ID", ErrInvalidID, id, kindNames(kinds))
+}
+
+func kindNames(kinds []byte) string {
+	names := map[byte]string{'A': "author", 'W': "work", 'I': "institution", 'S': "source"}
+	var parts []string
+	for _, kind := range kinds {
+		parts = append(parts, names[kind])
+	}
+	return strings.Join(parts, " or ")
+}
@@ -0,0 +1,60 @@
+package openalex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestValidateID(t *testing.T) {
+	tests := []struct {
+		id    string
+		kinds string
+		want  string // "" for an invalid ID.
+	}{
+		{"A5023896336", "A", "A5023896336"},
+		{"https://openalex.org/A5023896336", "A", "A5023896336"},
+		{" W2741809807 ", "W", "W2741809807"},
+		{"I1", "AWIS", "I1"},
+		{"S1", "WS", "S1"},
+		{"W1", "A", ""},
+		{"", "A", ""},
+		{"banana", "A", ""},
+		{"a5023896336", "A", ""},
+		{"A", "A", ""},
+		{"C12", "AWIS", ""},
+		{"A1' OR '1'='1", "A", ""},
+		{"A1\nW2", "A", ""},
+		{"https://openalex.org/A1?select=id", "A", ""},
+		{"https://openalex.org/a1", "A", ""},
+		{"http://openalex.org/A1", "A", ""},
+		{"https://api.openalex.org/authors/A1", "A", ""},
+	}
+	for _, tt := range tests {
+		got, err := ValidateID(tt.id, []byte(tt.kinds)...)
+		if got != tt.want {
+			t.Errorf("ValidateID(%q, %s) = %q, want %q", tt.id, tt.kinds, got, tt.want)
+		}
+		if (err != nil) != (tt.want == "") || err != nil && !errors.Is(err, ErrInvalidID) {
+			t.Errorf("ValidateID(%q, %s) error = %v", tt.id, tt.kinds, err)
+		}
+	}
+}
+
+func TestAPIErrorIsNotFound(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusNotFound, true},
+		{http.StatusBadRequest, false},
+		{http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		err := fmt.Errorf("fetching: %w", &APIError{StatusCode: tt.status, Status: http.StatusText(tt.status)})
+		if got := errors.Is(err, ErrNotFound); got != tt.want {
+			t.Errorf("errors.Is(%d, ErrNotFound) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
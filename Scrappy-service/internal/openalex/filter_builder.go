@@ -0,0 +1,195 @@
+package openalex
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	topicIDPattern  = regexp.MustCompile(`^T[0-9]+$`)
+	workTypePattern = regexp.MustCompile(`^[a-z][a-z-]*$`)
+	sortPattern     = regexp.MustCompile(`^[a-z][a-z0-9_.]*:(asc|desc)$`)
+)
+
+// Filter builds the query of an OpenAlex works request: the filter parameter, the sort
+// order, the page size and whether abstracts are selected. Each method validates its value
+// and records what is wrong with it instead of adding it, so a chain can be written in one
+// go and checked once with Err (or by Query, which returns the same error). Methods return
+// the Filter they are called on; the zero value is not usable, start from NewFilter.
+//
+//	f := openalex.NewFilter().AuthorID("A5023888391").PublicationYearRange(2019, 2023).
+//		Sort(openalex.SortByCitations).PerPage(30)
+type Filter struct {
+	parts   []string
+	sort    string
+	perPage int
+	lean    bool
+	errs    []error
+}
+
+// NewFilter returns an empty Filter, which matches every work.
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+func (f *Filter) add(key, value string) *Filter {
+	f.parts = append(f.parts, key+":"+value)
+	return f
+}
+
+func (f *Filter) fail(format string, args ...any) *Filter {
+	f.errs = append(f.errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidFilter}, args...)...))
+	return f
+}
+
+// AuthorID restricts the works to those of an author, given as an OpenAlex ID in bare or
+// URL form.
+func (f *Filter) AuthorID(id string) *Filter {
+	short, err := ValidateID(id, 'A')
+	if err != nil {
+		f.errs = append(f.errs, err)
+		return f
+	}
+	return f.add("author.id", short)
+}
+
+// PublicationYearRange restricts the works to those published from one year to another,
+// both included. Either bound may be 0 to leave that side open; both 0 adds nothing.
+func (f *Filter) PublicationYearRange(from, to int) *Filter {
+	switch {
+	case from < 0 || to < 0:
+		return f.fail("publication years must not be negative")
+	case from != 0 && to != 0 && from > to:
+		return f.fail("publication year range %d-%d ends before it starts", from, to)
+	case from != 0 && from == to:
+		return f.add("publication_year", strconv.Itoa(from))
+	case from != 0 && to != 0:
+		return f.add("publication_year", fmt.Sprintf("%d-%d", from, to))
+	case from != 0:
+		return f.add("publication_year", ">"+strconv.Itoa(from-1))
+	case to != 0:
+		return f.add("publication_year", "<"+strconv.Itoa(to+1))
+	}
+	return f
+}
+
+// Type restricts the works to one OpenAlex work type, such as "article" or "book-chapter".
+func (f *Filter) Type(workType string) *Filter {
+	if !workTypePattern.MatchString(workType) {
+		return f.fail("%q is not a work type", workType)
+	}
+	return f.add("type", workType)
+}
+
+// IsOA restricts the works to open access ones, or with false to closed ones.
+func (f *Filter) IsOA(oa bool) *Filter {
+	return f.add("is_oa", strconv.FormatBool(oa))
+}
+
+// HasFulltext restricts the works to those whose full text OpenAlex has indexed, or with
+// false to those it hasn't.
+func (f *Filter) HasFulltext(has bool) *Filter {
+	return f.add("has_fulltext", strconv.FormatBool(has))
+}
+
+// IsRetracted restricts the works to retracted ones, or with false leaves them out.
+func (f *Filter) IsRetracted(retracted bool) *Filter {
+	return f.add("is_retracted", strconv.FormatBool(retracted))
+}
+
+// TopicID restricts the works to those about a topic, given as an OpenAlex topic ID in
+// bare or URL form.
+func (f *Filter) TopicID(id string) *Filter {
+	short := strings.TrimPrefix(strings.TrimSpace(id), "https://openalex.org/")
+	if !topicIDPattern.MatchString(short) {
+		return f.fail("%q is not an OpenAlex topic ID", id)
+	}
+	return f.add("topics.id", short)
+}
+
+// FromUpdatedDate restricts the works to those OpenAlex created or updated at or after
+// since, formatted as the UTC timestamp OpenAlex expects (2024-01-02T15:04:05Z).
+func (f *Filter) FromUpdatedDate(since time.Time) *Filter {
+	if since.IsZero() {
+		return f.fail("from_updated_date needs a time")
+	}
+	return f.add("from_updated_date", since.UTC().Format("2006-01-02T15:04:05Z"))
+}
+
+// Sort orders the works, e.g. by SortByPublicationDate or SortByCitations. The order must
+// be a field followed by :asc or :desc.
+func (f *Filter) Sort(order string) *Filter {
+	if !sortPattern.MatchString(order) {
+		return f.fail("%q is not a sort order", order)
+	}
+	f.sort = order
+	return f
+}
+
+// PerPage sets how many works a request returns, between 1 and MaxPerPage. Left unset,
+// the client's page size applies.
+func (f *Filter) PerPage(n int) *Filter {
+	if n < 1 || n > MaxPerPage {
+		return f.fail("per-page must be between 1 and %d, not %d", MaxPerPage, n)
+	}
+	f.perPage = n
+	return f
+}
+
+// Lean leaves abstracts out of the selected fields, for listings that don't show them;
+// see workSelectFieldsLean.
+func (f *Filter) Lean() *Filter {
+	f.lean = true
+	return f
+}
+
+// raw adds filter parts as they are, for the deprecated variadic string APIs.
+func (f *Filter) raw(parts ...string) *Filter {
+	for _, part := range parts {
+		if part != "" {
+			f.parts = append(f.parts, part)
+		}
+	}
+	return f
+}
+
+// Err returns what is wrong with the values given to the Filter, joined into one error
+// matching ErrInvalidFilter or ErrInvalidID, or nil.
+func (f *Filter) Err() error {
+	return errors.Join(f.errs...)
+}
+
+// String returns the value of the filter parameter, unescaped: the valid parts joined with
+// commas, which OpenAlex ANDs together.
+func (f *Filter) String() string {
+	return strings.Join(f.parts, ",")
+}
+
+// Query renders the escaped query string of a works request: filter (left out when
+// empty), select, sort and per-page (left out when unset). It fails with Err.
+func (f *Filter) Query() (string, error) {
+	if err := f.Err(); err != nil {
+		return "", err
+	}
+	queryParams := url.Values{}
+	if len(f.parts) > 0 {
+		queryParams.Set("filter", f.String())
+	}
+	if f.lean {
+		queryParams.Set("select", workSelectFieldsLean)
+	} else {
+		queryParams.Set("select", workSelectFields)
+	}
+	if f.sort != "" {
+		queryParams.Set("sort", f.sort)
+	}
+	if f.perPage > 0 {
+		queryParams.Set("per-page", strconv.Itoa(f.perPage))
+	}
+	return queryParams.Encode(), nil
+}
@@ -0,0 +1,234 @@
+package openalex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(f *Filter) *Filter
+		want    string
+		wantErr error
+	}{
+		{"nothing", func(f *Filter) *Filter { return f }, "", nil},
+		{"author", func(f *Filter) *Filter { return f.AuthorID("A5023888391") }, "author.id:A5023888391", nil},
+		{"author URL", func(f *Filter) *Filter { return f.AuthorID("https://openalex.org/A5023888391") }, "author.id:A5023888391", nil},
+		{"work as author", func(f *Filter) *Filter { return f.AuthorID("W1") }, "", ErrInvalidID},
+		{"author with a filter", func(f *Filter) *Filter { return f.AuthorID("A1,is_oa:true") }, "", ErrInvalidID},
+		{"year range", func(f *Filter) *Filter { return f.PublicationYearRange(2019, 2023) }, "publication_year:2019-2023", nil},
+		{"single year", func(f *Filter) *Filter { return f.PublicationYearRange(2020, 2020) }, "publication_year:2020", nil},
+		{"from a year", func(f *Filter) *Filter { return f.PublicationYearRange(2019, 0) }, "publication_year:>2018", nil},
+		{"up to a year", func(f *Filter) *Filter { return f.PublicationYearRange(0, 2023) }, "publication_year:<2024", nil},
+		{"open range", func(f *Filter) *Filter { return f.PublicationYearRange(0, 0) }, "", nil},
+		{"backwards range", func(f *Filter) *Filter { return f.PublicationYearRange(2023, 2019) }, "", ErrInvalidFilter},
+		{"negative year", func(f *Filter) *Filter { return f.PublicationYearRange(-1, 2023) }, "", ErrInvalidFilter},
+		{"type", func(f *Filter) *Filter { return f.Type("article") }, "type:article", nil},
+		{"hyphenated type", func(f *Filter) *Filter { return f.Type("book-chapter") }, "type:book-chapter", nil},
+		{"capitalized type", func(f *Filter) *Filter { return f.Type("Article") }, "", ErrInvalidFilter},
+		{"type with a filter", func(f *Filter) *Filter { return f.Type("article,is_oa:true") }, "", ErrInvalidFilter},
+		{"empty type", func(f *Filter) *Filter { return f.Type("") }, "", ErrInvalidFilter},
+		{"open access", func(f *Filter) *Filter { return f.IsOA(true) }, "is_oa:true", nil},
+		{"closed access", func(f *Filter) *Filter { return f.IsOA(false) }, "is_oa:false", nil},
+		{"full text", func(f *Filter) *Filter { return f.HasFulltext(true) }, "has_fulltext:true", nil},
+		{"no full text", func(f *Filter) *Filter { return f.HasFulltext(false) }, "has_fulltext:false", nil},
+		{"retracted", func(f *Filter) *Filter { return f.IsRetracted(true) }, "is_retracted:true", nil},
+		{"not retracted", func(f *Filter) *Filter { return f.IsRetracted(false) }, "is_retracted:false", nil},
+		{"topic", func(f *Filter) *Filter { return f.TopicID("T10017") }, "topics.id:T10017", nil},
+		{"topic URL", func(f *Filter) *Filter { return f.TopicID("https://openalex.org/T10017") }, "topics.id:T10017", nil},
+		{"topic alternatives", func(f *Filter) *Filter { return f.TopicID("T1|T2") }, "", ErrInvalidFilter},
+		{"author as topic", func(f *Filter) *Filter { return f.TopicID("A1") }, "", ErrInvalidFilter},
+		{"combined in order", func(f *Filter) *Filter {
+			return f.AuthorID("A1").PublicationYearRange(2019, 2023).Type("article").IsOA(true).TopicID("T2").
+				FromUpdatedDate(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+		}, "author.id:A1,publication_year:2019-2023,type:article,is_oa:true,topics.id:T2,from_updated_date:2024-03-01T00:00:00Z", nil},
+		{"invalid value left out", func(f *Filter) *Filter { return f.AuthorID("A1").Type("not a type").IsOA(true) }, "author.id:A1,is_oa:true", ErrInvalidFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.build(NewFilter())
+			if got := f.String(); got != tt.want {
+				t.Errorf("filter = %q, want %q", got, tt.want)
+			}
+			err := f.Err()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if _, qerr := f.Query(); (qerr != nil) != (err != nil) {
+				t.Errorf("Query error = %v, want %v", qerr, err)
+			}
+		})
+	}
+}
+
+func TestFilterQuery(t *testing.T) {
+	full := "select=" + url.QueryEscape(workSelectFields)
+	lean := "select=" + url.QueryEscape(workSelectFieldsLean)
+	tests := []struct {
+		name    string
+		filter  *Filter
+		want    string
+		wantErr bool
+	}{
+		{"everything", NewFilter(), full, false},
+		{"filter", NewFilter().AuthorID("A1").IsOA(true), "filter=author.id%3AA1%2Cis_oa%3Atrue&" + full, false},
+		{"sort", NewFilter().Sort(SortByCitations), full + "&sort=cited_by_count%3Adesc", false},
+		{"ascending sort", NewFilter().Sort("publication_year:asc"), full + "&sort=publication_year%3Aasc", false},
+		{"per page", NewFilter().PerPage(30), "per-page=30&" + full, false},
+		{"largest page", NewFilter().PerPage(MaxPerPage), "per-page=200&" + full, false},
+		{"lean", NewFilter().Lean(), lean, false},
+		{"year range escaped", NewFilter().PublicationYearRange(2019, 0), "filter=publication_year%3A%3E2018&" + full, false},
+		{"all together", NewFilter().AuthorID("A1").PublicationYearRange(2019, 2023).Sort(SortByPublicationDate).PerPage(30).Lean(),
+			"filter=author.id%3AA1%2Cpublication_year%3A2019-2023&per-page=30&" + lean + "&sort=publication_date%3Adesc", false},
+		{"sort without direction", NewFilter().Sort("cited_by_count"), "", true},
+		{"two sort orders", NewFilter().Sort("cited_by_count:desc,title:asc"), "", true},
+		{"sort with a filter", NewFilter().Sort("cited_by_count:desc&filter=is_oa:true"), "", true},
+		{"empty page", NewFilter().PerPage(0), "", true},
+		{"page too large", NewFilter().PerPage(MaxPerPage + 1), "", true},
+		{"invalid filter", NewFilter().AuthorID("A1").TopicID("nope"), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.Query()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterErrReportsEveryValue(t *testing.T) {
+	err := NewFilter().AuthorID("W1").Type("Article").PerPage(500).Sort("title").Err()
+	for _, want := range []string{`"W1"`, `"Article"`, "not 500", `"title"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want it to mention %s", err, want)
+		}
+	}
+	if !errors.Is(err, ErrInvalidID) || !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("err = %v, want it to match ErrInvalidID and ErrInvalidFilter", err)
+	}
+}
+
+func TestWorksURL(t *testing.T) {
+	c := NewClient(WithPerPage(50))
+	base := openAlexAPIBaseURL + "/works?"
+
+	f := NewFilter().AuthorID("A1")
+	got, err := c.WorksURL(f)
+	if want := base + "filter=author.id%3AA1&per-page=50&select=" + url.QueryEscape(workSelectFields); err != nil || got != want {
+		t.Errorf("WorksURL = %q, %v, want the client's page size: %q", got, err, want)
+	}
+	if f.perPage != 0 {
+		t.Errorf("WorksURL set the filter's page size to %d", f.perPage)
+	}
+
+	got, err = c.WorksURL(NewFilter().AuthorID("A1").PerPage(5))
+	if want := base + "filter=author.id%3AA1&per-page=5&select=" + url.QueryEscape(workSelectFields); err != nil || got != want {
+		t.Errorf("WorksURL = %q, %v, want the filter's page size: %q", got, err, want)
+	}
+
+	if got, err := c.WorksURL(NewFilter().AuthorID("nope")); err == nil || got != "" {
+		t.Errorf("WorksURL(invalid) = %q, %v, want an error", got, err)
+	}
+}
+
+// The deprecated variadic string methods send what they always did, through a Filter.
+func TestDeprecatedAuthorWorksMethods(t *testing.T) {
+	want := openAlexAPIBaseURL + "/works?filter=author.id%3AA1%2Chas_fulltext%3Atrue&per-page=30&select=" +
+		url.QueryEscape(workSelectFieldsLean) + "&sort=publication_date%3Adesc"
+	if got := AuthorWorksSortedURL("https://openalex.org/A1", SortByPublicationDate, 30, "has_fulltext:true", ""); got != want {
+		t.Errorf("AuthorWorksSortedURL = %q, want %q", got, want)
+	}
+	if got := AuthorWorksSortedURL("W1", SortByPublicationDate, 30); got != "" {
+		t.Errorf("AuthorWorksSortedURL(W1) = %q, want \"\"", got)
+	}
+	if got := AuthorWorksSortedURL("A1", "newest", 30); got != "" {
+		t.Errorf("AuthorWorksSortedURL with sort newest = %q, want \"\"", got)
+	}
+
+	var queries []url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Write(worksPage(1, 4))
+	})
+	if _, _, err := c.FetchWorksByAuthorID("A1", "is_oa:true", "", "type:article"); err != nil {
+		t.Fatalf("FetchWorksByAuthorID: %v", err)
+	}
+	if _, _, err := c.FetchRecentWorksByAuthorID("A1", 10); err != nil {
+		t.Fatalf("FetchRecentWorksByAuthorID: %v", err)
+	}
+	if _, _, err := c.FetchLatestWorksByAuthorID("A1", 10); err != nil {
+		t.Fatalf("FetchLatestWorksByAuthorID: %v", err)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("got %d requests, want 3", len(queries))
+	}
+	if got := queries[0].Get("filter"); got != "author.id:A1,is_oa:true,type:article" {
+		t.Errorf("FetchWorksByAuthorID filter = %q", got)
+	}
+	if got := queries[1].Get("sort") + " " + queries[1].Get("per-page"); got != "cited_by_count:desc 10" {
+		t.Errorf("FetchRecentWorksByAuthorID sort and page size = %q", got)
+	}
+	if got := queries[2].Get("sort") + " " + queries[2].Get("per-page"); got != "publication_date:desc 10" {
+		t.Errorf("FetchLatestWorksByAuthorID sort and page size = %q", got)
+	}
+	if _, _, err := c.FetchWorksByAuthorID("W1"); !errors.Is(err, ErrInvalidID) || len(queries) != 3 {
+		t.Errorf("FetchWorksByAuthorID(W1) error = %v after %d requests, want ErrInvalidID and no request", err, len(queries))
+	}
+}
+
+func TestFromUpdatedDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		since   time.Time
+		want    string
+		wantErr bool
+	}{
+		{"utc", time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), "from_updated_date:2024-03-01T12:30:00Z", false},
+		{"offset converted to utc", time.Date(2024, 3, 1, 1, 30, 0, 0, time.FixedZone("", -5*3600)), "from_updated_date:2024-03-01T06:30:00Z", false},
+		{"day boundary", time.Date(2024, 3, 1, 0, 30, 0, 0, time.FixedZone("", 2*3600)), "from_updated_date:2024-02-29T22:30:00Z", false},
+		{"sub-second precision dropped", time.Date(2024, 3, 1, 12, 30, 0, 999999999, time.UTC), "from_updated_date:2024-03-01T12:30:00Z", false},
+		{"zero", time.Time{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFilter().FromUpdatedDate(tt.since)
+			if err := f.Err(); (err != nil) != tt.wantErr || err != nil && !errors.Is(err, ErrInvalidFilter) {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got := f.String(); got != tt.want {
+				t.Errorf("filter = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchWorksByAuthorUpdatedSince(t *testing.T) {
+	since := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("filter"), "author.id:A1,from_updated_date:2024-03-01T12:30:00Z"; got != want {
+			t.Errorf("filter = %q, want %q", got, want)
+		}
+		w.Write(worksPage(3, 4))
+	})
+	works, _, err := c.FetchWorksByAuthorUpdatedSince(context.Background(), "https://openalex.org/A1", since)
+	if err != nil {
+		t.Fatalf("FetchWorksByAuthorUpdatedSince: %v", err)
+	}
+	if len(works) != 3 {
+		t.Errorf("got %d works, want 3", len(works))
+	}
+
+	if _, _, err := c.FetchWorksByAuthorUpdatedSince(context.Background(), "A1", time.Time{}); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("zero since: err = %v, want ErrInvalidFilter", err)
+	}
+}
@@ -0,0 +1,53 @@
+package openalex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidFilter is returned by ValidateFilter for strings that aren't OpenAlex filters.
+var ErrInvalidFilter = errors.New("invalid OpenAlex filter")
+
+var filterKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_.]*$`)
+
+// DefaultFilterAllowlist is the set of filter keys ValidateFilter accepts when no
+// allowlist is configured.
+var DefaultFilterAllowlist = []string{
+	"publication_year", "from_publication_date", "to_publication_date", "type", "is_oa",
+	"is_retracted", "is_paratext", "has_fulltext", "has_doi", "language", "cited_by_count",
+	"author.id", "institutions.id", "primary_location.source.id", "topics.id",
+}
+
+// ValidateFilter checks an OpenAlex filter string before it is sent to OpenAlex. It must be
+// comma-separated key:value pairs with non-empty values, and contain nothing that would
+// break out of the filter query parameter. Every key must be in allowed, or when allowed is
+// empty in DefaultFilterAllowlist: publication_year, from_publication_date,
+// to_publication_date, type, is_oa, is_retracted, is_paratext, has_fulltext, has_doi,
+// language, cited_by_count, author.id, institutions.id, primary_location.source.id and
+// topics.id. Anything else is rejected with ErrInvalidFilter, so callers can't craft
+// arbitrary or expensive queries.
+func ValidateFilter(filter string, allowed []string) (string, error) {
+	if len(allowed) == 0 {
+		allowed = DefaultFilterAllowlist
+	}
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return "", fmt.Errorf("%w: empty", ErrInvalidFilter)
+	}
+	if strings.ContainsAny(filter, "&?#= \t\n") {
+		return "", fmt.Errorf("%w: %q contains characters not allowed in a filter", ErrInvalidFilter, filter)
+	}
+	for _, part := range strings.Split(filter, ",") {
+		key, value, ok := strings.Cut(part, ":")
+		if !ok || value == "" || !filterKeyPattern.MatchString(key) {
+			return "", fmt.Errorf("%w: %q is not a key:value pair", ErrInvalidFilter, part)
+		}
+		if !slices.Contains(allowed, key) {
+			return "", fmt.Errorf("%w: filtering on %q is not allowed", ErrInvalidFilter, key)
+		}
+	}
+	return filter, nil
+}
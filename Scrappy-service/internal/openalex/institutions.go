@@ -0,0 +1,66 @@
+package openalex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// FetchInstitutionByID fetches a full institution entity. Unknown IDs return an error
+// matching ErrNotFound.
+func (c *Client) FetchInstitutionByID(ctx context.Context, institutionID string) (domain.Institution, error) {
+	requestURL := fmt.Sprintf("%s/institutions/%s", openAlexAPIBaseURL, url.PathEscape(institutionID))
+
+	body, err := c.get(ctx, requestURL)
+	if err != nil {
+		return domain.Institution{}, err
+	}
+	defer body.Close()
+
+	var institution domain.Institution
+	if err := json.NewDecoder(body).Decode(&institution); err != nil {
+		return domain.Institution{}, fmt.Errorf("failed to decode json response: %w", err)
+	}
+	return institution, nil
+}
+
+// rorPattern matches a bare ROR ID: a zero, six characters of Crockford's base32 and a
+// two-digit checksum, e.g. 03yrm5c26.
+var rorPattern = regexp.MustCompile(`^0[0-9a-hjkmnp-tv-z]{6}[0-9]{2}$`)
+
+// NormalizeROR reduces the different ROR spellings (03yrm5c26, https://ror.org/03yrm5c26,
+// ror.org/03yrm5c26, ror:03yrm5c26) to the bare, lowercase ID. Anything else is rejected
+// with ErrInvalidID.
+func NormalizeROR(ror string) (string, error) {
+	bare := strings.ToLower(strings.TrimSpace(ror))
+	for _, prefix := range []string{"https://ror.org/", "http://ror.org/", "ror.org/", "ror:"} {
+		if strings.HasPrefix(bare, prefix) {
+			bare = bare[len(prefix):]
+			break
+		}
+	}
+	if !rorPattern.MatchString(bare) {
+		return "", fmt.Errorf("%w: %q is not a ROR ID", ErrInvalidID, ror)
+	}
+	return bare, nil
+}
+
+// RORURL returns a bare ROR ID in the URL form OpenAlex uses in its ror fields.
+func RORURL(bareROR string) string {
+	return "https://ror.org/" + bareROR
+}
+
+// FetchInstitutionByROR fetches a full institution by its ROR ID, in any form NormalizeROR
+// accepts. RORs OpenAlex doesn't know return an error matching ErrNotFound.
+func (c *Client) FetchInstitutionByROR(ctx context.Context, ror string) (domain.Institution, error) {
+	bare, err := NormalizeROR(ror)
+	if err != nil {
+		return domain.Institution{}, err
+	}
+	return c.FetchInstitutionByID(ctx, "ror:"+bare)
+}
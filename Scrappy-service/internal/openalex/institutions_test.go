@@ -0,0 +1,86 @@
+package openalex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNormalizeROR(t *testing.T) {
+	tests := []struct {
+		ror  string
+		want string // "" for an invalid ROR.
+	}{
+		{"03yrm5c26", "03yrm5c26"},
+		{"https://ror.org/03yrm5c26", "03yrm5c26"},
+		{"http://ror.org/03yrm5c26", "03yrm5c26"},
+		{"ror.org/03yrm5c26", "03yrm5c26"},
+		{"ror:03yrm5c26", "03yrm5c26"},
+		{" HTTPS://ROR.ORG/03YRM5C26 ", "03yrm5c26"},
+		{"", ""},
+		{"I136199984", ""},
+		{"13yrm5c26", ""},  // doesn't start with a zero
+		{"03yrm5c2x", ""},  // checksum isn't two digits
+		{"03yrl5c26", ""},  // l isn't in Crockford's base32
+		{"03yrm5c2", ""},   // too short
+		{"03yrm5c261", ""}, // too long
+		{"https://ror.org/", ""},
+		{"https://example.org/03yrm5c26", ""},
+		{"https://ror.org/03yrm5c26/", ""},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeROR(tt.ror)
+		if got != tt.want {
+			t.Errorf("NormalizeROR(%q) = %q, want %q", tt.ror, got, tt.want)
+		}
+		if (err != nil) != (tt.want == "") || err != nil && !errors.Is(err, ErrInvalidID) {
+			t.Errorf("NormalizeROR(%q) error = %v", tt.ror, err)
+		}
+	}
+}
+
+func TestFetchInstitutionByROR(t *testing.T) {
+	var paths []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path != "/institutions/ror:03yrm5c26" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"id": "https://openalex.org/I136199984", "display_name": "University", "ror": "https://ror.org/03yrm5c26"}`)
+	})
+
+	tests := []struct {
+		name     string
+		ror      string
+		wantPath string // "" if OpenAlex shouldn't be asked
+		wantErr  error
+	}{
+		{name: "bare", ror: "03yrm5c26", wantPath: "/institutions/ror:03yrm5c26"},
+		{name: "URL", ror: "https://ror.org/03yrm5c26", wantPath: "/institutions/ror:03yrm5c26"},
+		{name: "unknown", ror: "https://ror.org/00f54p054", wantPath: "/institutions/ror:00f54p054", wantErr: ErrNotFound},
+		{name: "invalid", ror: "I136199984", wantErr: ErrInvalidID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths = nil
+			institution, err := c.FetchInstitutionByROR(context.Background(), tt.ror)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || institution.ID != "https://openalex.org/I136199984" || institution.Ror != "https://ror.org/03yrm5c26" {
+				t.Errorf("FetchInstitutionByROR = %+v, %v, want I136199984", institution, err)
+			}
+			var wantPaths []string
+			if tt.wantPath != "" {
+				wantPaths = []string{tt.wantPath}
+			}
+			if fmt.Sprint(paths) != fmt.Sprint(wantPaths) {
+				t.Errorf("requested %v, want %v", paths, wantPaths)
+			}
+		})
+	}
+}
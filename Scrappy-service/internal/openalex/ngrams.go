@@ -0,0 +1,43 @@
+package openalex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// HasFulltextFilter restricts a works query to works whose full text OpenAlex has indexed,
+// which are the only ones with ngrams.
+const HasFulltextFilter = "has_fulltext:true"
+
+// NotRetractedFilter leaves retracted works out of a works query.
+const NotRetractedFilter = "is_retracted:false"
+
+// Ngram is one entry of a work's ngrams list.
+type Ngram struct {
+	Ngram         string  `json:"ngram"`
+	Count         int     `json:"ngram_count"`
+	Tokens        int     `json:"ngram_tokens"`
+	TermFrequency float64 `json:"term_frequency"`
+}
+
+// FetchWorkNgrams fetches the ngrams OpenAlex extracted from a work's full text. Works
+// without full text have an empty list.
+func (c *Client) FetchWorkNgrams(ctx context.Context, workID string) ([]Ngram, error) {
+	requestURL := fmt.Sprintf("%s/works/%s/ngrams", openAlexAPIBaseURL, url.PathEscape(workID))
+
+	body, err := c.get(ctx, requestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var apiResponse struct {
+		Ngrams []Ngram `json:"ngrams"`
+	}
+	if err := json.NewDecoder(body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode json response: %w", err)
+	}
+	return apiResponse.Ngrams, nil
+}
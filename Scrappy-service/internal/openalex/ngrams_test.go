@@ -0,0 +1,68 @@
+package openalex
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestFetchWorkNgrams(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/works/W2741809807/ngrams" {
+			t.Errorf("requested %s", r.URL.Path)
+		}
+		w.Write([]byte(`{
+			"meta": {"count": 2, "doi": "https://doi.org/10.1/a", "openalex_id": "https://openalex.org/W2741809807"},
+			"ngrams": [
+				{"ngram": "deep learning", "ngram_tokens": 2, "ngram_count": 12, "term_frequency": 0.0041},
+				{"ngram": "network", "ngram_tokens": 1, "ngram_count": 30, "term_frequency": 0.0103}
+			]
+		}`))
+	})
+
+	ngrams, err := c.FetchWorkNgrams(context.Background(), "W2741809807")
+	if err != nil {
+		t.Fatalf("FetchWorkNgrams: %v", err)
+	}
+	want := []Ngram{
+		{Ngram: "deep learning", Count: 12, Tokens: 2, TermFrequency: 0.0041},
+		{Ngram: "network", Count: 30, Tokens: 1, TermFrequency: 0.0103},
+	}
+	if !reflect.DeepEqual(ngrams, want) {
+		t.Errorf("ngrams = %+v, want %+v", ngrams, want)
+	}
+}
+
+func TestFetchWorkNgramsWithoutFulltext(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"count": 0}, "ngrams": []}`))
+	})
+	ngrams, err := c.FetchWorkNgrams(context.Background(), "W1")
+	if err != nil || len(ngrams) != 0 {
+		t.Errorf("FetchWorkNgrams = %v, %v; want an empty list", ngrams, err)
+	}
+}
+
+func TestHasFulltextFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   string
+	}{
+		{"has full text", NewFilter().AuthorID("A1").HasFulltext(true), "author.id:A1,has_fulltext:true"},
+		{"no full text", NewFilter().HasFulltext(false), "has_fulltext:false"},
+		{"constant", NewFilter().raw(HasFulltextFilter), HasFulltextFilter},
+	}
+	for _, tt := range tests {
+		query, err := tt.filter.Query()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		values, _ := url.ParseQuery(query)
+		if got := values.Get("filter"); got != tt.want {
+			t.Errorf("%s: filter = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
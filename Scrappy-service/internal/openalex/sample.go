@@ -0,0 +1,69 @@
+package openalex
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// MaxSampleSize is the largest random sample OpenAlex draws.
+const MaxSampleSize = 10000
+
+// SampleWorks returns a random sample of n (1-MaxSampleSize) works matching filter, which
+// should have been checked with ValidateFilter; an empty filter samples all works. The same
+// filter, n and seed return the same works, so a sample can be drawn again to rebuild a
+// dataset. Samples larger than MaxPerPage are fetched page by page: OpenAlex can't page a
+// sample with a cursor, and it only keeps the pages of one sample together when the seed is
+// set, which it always is here. Works that don't decode are left out and returned as
+// warnings, so fewer than n works may come back.
+func (c *Client) SampleWorks(ctx context.Context, filter string, n int, seed int) ([]domain.Work, DecodeWarnings, error) {
+	if n < 1 || n > MaxSampleSize {
+		return nil, nil, fmt.Errorf("sample size %d is not between 1 and %d", n, MaxSampleSize)
+	}
+	perPage := min(n, MaxPerPage)
+
+	works := make([]domain.Work, 0, n)
+	var warnings DecodeWarnings
+	decoded := 0
+	for page := 1; decoded < n; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if page > 1 {
+			c.pause()
+		}
+		queryParams := url.Values{}
+		if filter != "" {
+			queryParams.Set("filter", filter)
+		}
+		queryParams.Set("sample", fmt.Sprintf("%d", n))
+		queryParams.Set("seed", fmt.Sprintf("%d", seed))
+		queryParams.Set("select", workSelectFields)
+		queryParams.Set("per-page", fmt.Sprintf("%d", perPage))
+		queryParams.Set("page", fmt.Sprintf("%d", page))
+		requestURL := fmt.Sprintf("%s/works?%s", openAlexAPIBaseURL, queryParams.Encode())
+
+		pageResults := 0
+		_, pageWarnings, err := c.fetchWorks(requestURL, decoded, func(work domain.Work) error {
+			pageResults++
+			// The last page is a full page; only the first n results belong to the sample.
+			if decoded+pageResults <= n {
+				works = append(works, work)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		pageResults += len(pageWarnings)
+		warnings = append(warnings, pageWarnings...)
+		decoded += pageResults
+		// Fewer matches than n: the sample is everything there is.
+		if pageResults < perPage {
+			break
+		}
+	}
+	return works, warnings, nil
+}
@@ -0,0 +1,156 @@
+package openalex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sampleServer draws seeded samples from matching works, W1 to W<matching>. The sample is a
+// permutation picked by the seed, so the same seed returns the same works, and it is paged
+// by page number with full pages, as OpenAlex does. It records each request's query.
+type sampleServer struct {
+	matching int
+
+	mu      sync.Mutex
+	queries []url.Values
+}
+
+func (s *sampleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	s.queries = append(s.queries, query)
+	s.mu.Unlock()
+
+	seed, _ := strconv.Atoi(query.Get("seed"))
+	perPage, _ := strconv.Atoi(query.Get("per-page"))
+	page, _ := strconv.Atoi(query.Get("page"))
+	var results []string
+	for i := (page - 1) * perPage; i < page*perPage && i < s.matching; i++ {
+		// i*7 + seed runs through every residue, as 7 doesn't divide the pool size.
+		results = append(results, fmt.Sprintf(`{"id": "https://openalex.org/W%d"}`, (i*7+seed)%s.matching+1))
+	}
+	fmt.Fprintf(w, `{"meta": {"count": %d}, "results": [%s]}`, s.matching, strings.Join(results, ","))
+}
+
+func (s *sampleServer) requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queries := s.queries
+	s.queries = nil
+	return queries
+}
+
+func TestSampleWorks(t *testing.T) {
+	tests := []struct {
+		name      string
+		matching  int
+		filter    string
+		n, seed   int
+		wantCount int
+		// wantPages are the per-page and page values of the requests.
+		wantPages []string
+		wantErr   string
+	}{
+		{name: "one page", matching: 1000, filter: "publication_year:2023", n: 5, seed: 42, wantCount: 5, wantPages: []string{"5/1"}},
+		{name: "several pages", matching: 1000, filter: "publication_year:2023", n: 450, seed: 7, wantCount: 450,
+			wantPages: []string{"200/1", "200/2", "200/3"}},
+		{name: "exact pages", matching: 1000, n: 400, seed: 1, wantCount: 400, wantPages: []string{"200/1", "200/2"}},
+		{name: "fewer matches than n", matching: 120, filter: "publication_year:1901", n: 500, seed: 3, wantCount: 120,
+			wantPages: []string{"200/1"}},
+		{name: "no matches", matching: 0, n: 10, seed: 3, wantPages: []string{"10/1"}},
+		{name: "zero", matching: 10, n: 0, wantErr: "between 1 and 10000"},
+		{name: "above the ceiling", matching: 10, n: MaxSampleSize + 1, wantErr: "between 1 and 10000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &sampleServer{matching: tt.matching}
+			c := newTestClient(t, server.ServeHTTP)
+
+			works, warnings, err := c.SampleWorks(context.Background(), tt.filter, tt.n, tt.seed)
+			queries := server.requests()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SampleWorks error = %v, want %q", err, tt.wantErr)
+				}
+				if len(queries) > 0 {
+					t.Errorf("OpenAlex was asked %d times", len(queries))
+				}
+				return
+			}
+			if err != nil || len(warnings) > 0 {
+				t.Fatalf("SampleWorks: %v, warnings %v", err, warnings)
+			}
+			if len(works) != tt.wantCount {
+				t.Errorf("%d works, want %d", len(works), tt.wantCount)
+			}
+			seen := map[string]bool{}
+			for _, work := range works {
+				if seen[work.ID] {
+					t.Errorf("%s sampled twice", work.ID)
+				}
+				seen[work.ID] = true
+			}
+
+			var pages []string
+			for _, query := range queries {
+				pages = append(pages, query.Get("per-page")+"/"+query.Get("page"))
+				if query.Get("sample") != strconv.Itoa(tt.n) || query.Get("seed") != strconv.Itoa(tt.seed) {
+					t.Errorf("sample=%s seed=%s, want %d and %d", query.Get("sample"), query.Get("seed"), tt.n, tt.seed)
+				}
+				if _, has := query["filter"]; has != (tt.filter != "") || query.Get("filter") != tt.filter {
+					t.Errorf("filter = %q (set: %v), want %q", query.Get("filter"), has, tt.filter)
+				}
+				if query.Get("cursor") != "" || query.Get("select") == "" {
+					t.Errorf("query %v, want page-numbered pages with the work fields selected", query)
+				}
+			}
+			if !reflect.DeepEqual(pages, tt.wantPages) {
+				t.Errorf("pages (per-page/page) = %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestSampleWorksIsReproducible(t *testing.T) {
+	server := &sampleServer{matching: 1000}
+	c := newTestClient(t, server.ServeHTTP)
+	sample := func(seed int) []string {
+		works, _, err := c.SampleWorks(context.Background(), "publication_year:2023", 300, seed)
+		if err != nil {
+			t.Fatalf("SampleWorks(seed %d): %v", seed, err)
+		}
+		ids := make([]string, len(works))
+		for i, work := range works {
+			ids[i] = work.ID
+		}
+		return ids
+	}
+
+	first, again := sample(42), sample(42)
+	if !reflect.DeepEqual(first, again) {
+		t.Error("two samples with the same seed differ")
+	}
+	if reflect.DeepEqual(first, sample(43)) {
+		t.Error("samples with different seeds are the same")
+	}
+}
+
+func TestSampleWorksStopsWhenCancelled(t *testing.T) {
+	server := &sampleServer{matching: 1000}
+	c := newTestClient(t, server.ServeHTTP)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := c.SampleWorks(ctx, "", 500, 1); err == nil {
+		t.Error("SampleWorks with a cancelled context succeeded")
+	}
+	if n := len(server.requests()); n != 0 {
+		t.Errorf("%d requests after cancellation, want none", n)
+	}
+}
@@ -0,0 +1,51 @@
+package openalex
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// SearchAuthors returns the first limit (1-MaxPerPage) authors matching a full-text search.
+func (c *Client) SearchAuthors(ctx context.Context, query string, limit int) ([]domain.Author, error) {
+	var authors []domain.Author
+	err := c.search(ctx, "authors", query, limit, "", &authors)
+	return authors, err
+}
+
+// SearchWorks returns the first limit works matching a full-text search of their title,
+// abstract and full text, without abstracts. Filters (e.g. NotRetractedFilter) further
+// restrict the matches.
+func (c *Client) SearchWorks(ctx context.Context, query string, limit int, filters ...string) ([]domain.Work, error) {
+	var works []domain.Work
+	err := c.search(ctx, "works", query, limit, workSelectFieldsLean, &works, filters...)
+	return works, err
+}
+
+// SearchInstitutions returns the first limit institutions matching a full-text search.
+func (c *Client) SearchInstitutions(ctx context.Context, query string, limit int) ([]domain.Institution, error) {
+	var institutions []domain.Institution
+	err := c.search(ctx, "institutions", query, limit, "", &institutions)
+	return institutions, err
+}
+
+// search runs a search= request on an entity endpoint and decodes its results into
+// results, a pointer to a slice, in relevance order.
+func (c *Client) search(ctx context.Context, entity, query string, limit int, selectFields string, results interface{}, filters ...string) error {
+	if limit < 1 || limit > MaxPerPage {
+		return fmt.Errorf("limit must be between 1 and %d", MaxPerPage)
+	}
+	queryParams := url.Values{}
+	queryParams.Set("search", query)
+	queryParams.Set("per-page", fmt.Sprintf("%d", limit))
+	if selectFields != "" {
+		queryParams.Set("select", selectFields)
+	}
+	if len(filters) > 0 {
+		queryParams.Set("filter", strings.Join(filters, ","))
+	}
+	return c.fetchResults(ctx, entity, queryParams, results)
+}
@@ -0,0 +1,55 @@
+package openalex
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// ErrInvalidISSN is returned for strings that can't be an ISSN.
+var ErrInvalidISSN = errors.New("invalid ISSN")
+
+var issnPattern = regexp.MustCompile(`^([0-9]{4})-?([0-9]{3}[0-9X])$`)
+
+// NormalizeISSN returns issn in the hyphenated form OpenAlex uses (1234-567X), accepting
+// it with or without the hyphen and with a lowercase check digit.
+func NormalizeISSN(issn string) (string, error) {
+	m := issnPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(issn)))
+	if m == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidISSN, issn)
+	}
+	return m[1] + "-" + m[2], nil
+}
+
+// FetchSourceByISSN fetches the source (journal) with the given ISSN, in any of its
+// registered ISSNs. Unknown ISSNs return an error matching ErrNotFound.
+func (c *Client) FetchSourceByISSN(issn string) (domain.Source, error) {
+	normalized, err := NormalizeISSN(issn)
+	if err != nil {
+		return domain.Source{}, err
+	}
+	requestURL := fmt.Sprintf("%s/sources/issn:%s", openAlexAPIBaseURL, normalized)
+
+	var source domain.Source
+	if err := c.fetchAndDecode(requestURL, &source); err != nil {
+		return domain.Source{}, err
+	}
+	return source, nil
+}
+
+// FetchRecentWorksBySourceID fetches up to maxResults (at most MaxPerPage) of the works
+// published in a source, most recent first. Works that don't decode are left out and
+// returned as warnings.
+func (c *Client) FetchRecentWorksBySourceID(sourceID string, maxResults int) ([]domain.Work, DecodeWarnings, error) {
+	queryParams := url.Values{}
+	queryParams.Set("filter", "primary_location.source.id:"+sourceID)
+	queryParams.Set("select", workSelectFields)
+	queryParams.Set("sort", "publication_date:desc")
+	queryParams.Set("per-page", fmt.Sprintf("%d", maxResults))
+	requestURL := fmt.Sprintf("%s/works?%s", openAlexAPIBaseURL, queryParams.Encode())
+	return c.collectWorks(requestURL)
+}
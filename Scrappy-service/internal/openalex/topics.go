@@ -0,0 +1,29 @@
+package openalex
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// topicSelectFields is the select= list of topic searches: the topic and its hierarchy.
+const topicSelectFields = "id,display_name,subfield,field,domain"
+
+// FetchTopicsByName returns the topics matching a free-text search of their names,
+// descriptions and keywords, in relevance order, each with its subfield, field and domain.
+func (c *Client) FetchTopicsByName(name string) ([]domain.Topic, error) {
+	queryParams := url.Values{}
+	queryParams.Set("search", name)
+	queryParams.Set("select", topicSelectFields)
+	queryParams.Set("per-page", fmt.Sprintf("%d", c.perPage))
+	requestURL := fmt.Sprintf("%s/topics?%s", openAlexAPIBaseURL, queryParams.Encode())
+
+	var apiResponse struct {
+		Results []domain.Topic `json:"results"`
+	}
+	if err := c.fetchAndDecode(requestURL, &apiResponse); err != nil {
+		return nil, err
+	}
+	return apiResponse.Results, nil
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucketAllowsBurstThenRefuses(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		want  int // Tokens available at once from a full bucket.
+	}{
+		{"leaky bucket", 1, 1, 1},
+		{"burst", 1, 5, 5},
+		{"burst below 1", 1, 0, 1},
+		{"no refill", 0, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBucket(tt.rate, tt.burst)
+			for i := 0; i < tt.want; i++ {
+				if ok, _ := b.Allow(); !ok {
+					t.Fatalf("request %d refused, want %d allowed at once", i+1, tt.want)
+				}
+			}
+			ok, wait := b.Allow()
+			if ok {
+				t.Fatalf("request %d allowed, want the burst to be capped at %d", tt.want+1, tt.want)
+			}
+			if wait <= 0 {
+				t.Errorf("wait = %v, want a positive wait", wait)
+			}
+		})
+	}
+}
+
+func TestBucketWaitPacesRequests(t *testing.T) {
+	const rate, requests = 100, 6
+	b := NewBucket(rate, 1)
+	started := time.Now()
+	for i := 0; i < requests; i++ {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+	// The first token is there already; every other one takes 1/rate to refill.
+	if elapsed, min := time.Since(started), (requests-1)*time.Second/rate; elapsed < min-time.Millisecond {
+		t.Errorf("%d requests took %v, want at least %v", requests, elapsed, min)
+	}
+}
+
+func TestBucketWaitStopsWithContext(t *testing.T) {
+	b := NewBucket(0.001, 1)
+	b.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestBucketIdle(t *testing.T) {
+	b := NewBucket(1, 2)
+	if b.Idle(time.Hour) {
+		t.Error("a bucket created just now is idle for an hour")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if !b.Idle(time.Millisecond) {
+		t.Error("a full, unused bucket is not idle")
+	}
+	b.Allow()
+	if b.Idle(0) {
+		t.Error("a bucket missing a token is idle")
+	}
+}
@@ -0,0 +1,273 @@
+// Package resolve reconciles the paper identifiers the service deals with: OpenAlex work
+// IDs, Semantic Scholar paperIds and DOIs.
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// ErrUnresolved is returned when no mapping to the requested ID space could be found.
+var ErrUnresolved = errors.New("paper could not be resolved")
+
+// ErrInvalidID is returned by ParseAnyID for strings that aren't paper identifiers.
+var ErrInvalidID = errors.New("invalid paper identifier")
+
+// Kind is the ID space of an AnyID.
+type Kind string
+
+const (
+	OpenAlex        Kind = "openalex"        // A work ID, kept in URL form: https://openalex.org/W2741809807
+	SemanticScholar Kind = "semanticscholar" // A 40-character paperId.
+	DOI             Kind = "doi"             // A normalized DOI: 10.1038/nature14539
+)
+
+// AnyID is a paper identifier in any of the ID spaces. Build them with ParseAnyID or the
+// OpenAlexID, SemanticScholarID and DOIID helpers, which normalize the value.
+type AnyID struct {
+	Kind  Kind
+	Value string
+}
+
+func (id AnyID) String() string {
+	return string(id.Kind) + ":" + id.Value
+}
+
+var ssPaperIDPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// OpenAlexID returns the AnyID of an OpenAlex work ID, bare or in URL form.
+func OpenAlexID(id string) AnyID {
+	return AnyID{Kind: OpenAlex, Value: "https://openalex.org/" + strings.TrimPrefix(strings.TrimSpace(id), "https://openalex.org/")}
+}
+
+// SemanticScholarID returns the AnyID of a Semantic Scholar paperId.
+func SemanticScholarID(paperID string) AnyID {
+	return AnyID{Kind: SemanticScholar, Value: strings.ToLower(strings.TrimSpace(paperID))}
+}
+
+// DOIID returns the AnyID of a DOI in any of its forms.
+func DOIID(doi string) AnyID {
+	return AnyID{Kind: DOI, Value: domain.NormalizeDOI(doi)}
+}
+
+// ParseAnyID recognizes an OpenAlex work ID (W2741809807, with or without the
+// https://openalex.org/ prefix), a DOI (10.1038/nature14539, doi:..., https://doi.org/...)
+// or a Semantic Scholar paperId (40 hex characters). Anything else is rejected with
+// ErrInvalidID.
+func ParseAnyID(raw string) (AnyID, error) {
+	raw = strings.TrimSpace(raw)
+	if id, err := openalex.ValidateID(raw, 'W'); err == nil {
+		return OpenAlexID(id), nil
+	}
+	if doi := domain.NormalizeDOI(raw); strings.HasPrefix(doi, "10.") && strings.Contains(doi, "/") {
+		return AnyID{Kind: DOI, Value: doi}, nil
+	}
+	if lower := strings.ToLower(raw); ssPaperIDPattern.MatchString(lower) {
+		return AnyID{Kind: SemanticScholar, Value: lower}, nil
+	}
+	return AnyID{}, fmt.Errorf("%w: %q is not an OpenAlex work ID, DOI or Semantic Scholar paperId", ErrInvalidID, raw)
+}
+
+// maxCachedResolutions bounds each of the resolver's caches; a full cache is dropped.
+const maxCachedResolutions = 10000
+
+// PaperResolver maps paper identifiers between the ID spaces. It consults its cache
+// first, then the local graph, then OpenAlex or Semantic Scholar. Semantic Scholar
+// paperIds learned for works in the graph are stored on them (ssPaperId), so they are
+// only looked up once.
+type PaperResolver struct {
+	repo  storage.Repository
+	alex  *openalex.Client
+	sem   *semanticscholar.Client
+	cache *resolutionCache
+}
+
+// NewPaperResolver creates a resolver backed by the graph and both APIs.
+func NewPaperResolver(repo storage.Repository, alex *openalex.Client, sem *semanticscholar.Client) *PaperResolver {
+	return &PaperResolver{repo: repo, alex: alex, sem: sem, cache: newResolutionCache()}
+}
+
+// graphKinds maps the ID spaces onto the identifier kinds of storage.FindWorkIDs.
+var graphKinds = map[Kind]string{
+	OpenAlex:        storage.WorkIDOpenAlex,
+	DOI:             storage.WorkIDDOI,
+	SemanticScholar: storage.WorkIDSemanticScholar,
+}
+
+// local looks the paper up in the graph. Works that aren't there (and lookup errors, which
+// are logged) report false.
+func (p *PaperResolver) local(ctx context.Context, id AnyID) (storage.WorkIDs, bool) {
+	ids, err := p.repo.FindWorkIDs(ctx, graphKinds[id.Kind], id.Value)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("WARN: Could not look up %s in the graph: %v", id, err)
+		}
+		return storage.WorkIDs{}, false
+	}
+	return ids, true
+}
+
+// ResolveToOpenAlex returns the URL-form OpenAlex work ID of a paper. Errors match
+// ErrUnresolved when neither the graph nor the APIs know a mapping.
+func (p *PaperResolver) ResolveToOpenAlex(ctx context.Context, id AnyID) (string, error) {
+	if id.Kind == OpenAlex {
+		return id.Value, nil
+	}
+	if workID, ok := p.cache.get(OpenAlex, id); ok {
+		return workID, nil
+	}
+	if ids, ok := p.local(ctx, id); ok {
+		p.cache.put(OpenAlex, id, ids.ID)
+		return ids.ID, nil
+	}
+
+	doi := id
+	if id.Kind == SemanticScholar {
+		papers, err := p.sem.FetchPaperIDs(ctx, []semanticscholar.PaperID{{Kind: semanticscholar.KindPaperID, Value: id.Value}})
+		if err != nil {
+			return "", fmt.Errorf("failed to look up %s in Semantic Scholar: %w", id, err)
+		}
+		paper, ok := papers[semanticscholar.PaperID{Kind: semanticscholar.KindPaperID, Value: id.Value}]
+		if !ok || paper.ExternalIDs.DOI == "" {
+			return "", fmt.Errorf("%w: %s has no DOI in Semantic Scholar", ErrUnresolved, id)
+		}
+		doi = DOIID(paper.ExternalIDs.DOI)
+		if ids, ok := p.local(ctx, doi); ok {
+			p.Remember(ctx, ids.ID, id.Value)
+			return ids.ID, nil
+		}
+	}
+
+	work, err := p.alex.FetchWorkIdentifiers(ctx, "doi:"+doi.Value)
+	if errors.Is(err, openalex.ErrNotFound) {
+		return "", fmt.Errorf("%w: %s is not in OpenAlex", ErrUnresolved, id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s in OpenAlex: %w", id, err)
+	}
+	p.cache.put(OpenAlex, id, work.ID)
+	return work.ID, nil
+}
+
+// ResolveToSemanticScholar returns the Semantic Scholar paperId of a paper. Works in the
+// graph are looked up by their stored ssPaperId, then by DOI; others by the DOI (or arXiv
+// ID, PMID, ...) OpenAlex has for them. A paperId found for a work in the graph is stored
+// on it. Errors match ErrUnresolved when no mapping is known.
+func (p *PaperResolver) ResolveToSemanticScholar(ctx context.Context, id AnyID) (string, error) {
+	if id.Kind == SemanticScholar {
+		return id.Value, nil
+	}
+	if paperID, ok := p.cache.get(SemanticScholar, id); ok {
+		return paperID, nil
+	}
+
+	var lookup semanticscholar.PaperID
+	ids, inGraph := p.local(ctx, id)
+	switch {
+	case inGraph && ids.SSPaperID != "":
+		p.cache.put(SemanticScholar, id, ids.SSPaperID)
+		return ids.SSPaperID, nil
+	case inGraph && ids.Doi != "":
+		lookup = semanticscholar.PaperID{Kind: semanticscholar.KindDOI, Value: ids.Doi}
+	case id.Kind == DOI:
+		lookup = semanticscholar.PaperID{Kind: semanticscholar.KindDOI, Value: id.Value}
+	default:
+		work, err := p.alex.FetchWorkIdentifiers(ctx, strings.TrimPrefix(id.Value, "https://openalex.org/"))
+		if errors.Is(err, openalex.ErrNotFound) {
+			return "", fmt.Errorf("%w: %s is not in OpenAlex", ErrUnresolved, id)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to look up %s in OpenAlex: %w", id, err)
+		}
+		var ok bool
+		if lookup, ok = semanticscholar.PaperIDForWork(work.Doi, work.Ids); !ok {
+			return "", fmt.Errorf("%w: %s has no identifier Semantic Scholar knows", ErrUnresolved, id)
+		}
+	}
+
+	papers, err := p.sem.FetchPaperIDs(ctx, []semanticscholar.PaperID{lookup})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s in Semantic Scholar: %w", id, err)
+	}
+	paper, ok := papers[lookup]
+	if !ok || paper.PaperID == "" {
+		return "", fmt.Errorf("%w: %s is not in Semantic Scholar", ErrUnresolved, id)
+	}
+	paperID := strings.ToLower(paper.PaperID)
+	p.cache.put(SemanticScholar, id, paperID)
+	if inGraph {
+		p.Remember(ctx, ids.ID, paperID)
+	}
+	return paperID, nil
+}
+
+// LocalSemanticScholarID returns the paperId of a paper if it is already known, from the
+// cache or the graph, without asking any API. Batch lookups use it to prefer paperIds
+// over DOIs.
+func (p *PaperResolver) LocalSemanticScholarID(ctx context.Context, id AnyID) (string, bool) {
+	if id.Kind == SemanticScholar {
+		return id.Value, true
+	}
+	if paperID, ok := p.cache.get(SemanticScholar, id); ok {
+		return paperID, true
+	}
+	if ids, ok := p.local(ctx, id); ok && ids.SSPaperID != "" {
+		p.cache.put(SemanticScholar, id, ids.SSPaperID)
+		return ids.SSPaperID, true
+	}
+	return "", false
+}
+
+// Remember records that the OpenAlex work workID is the Semantic Scholar paper paperID,
+// learned by a caller from an API response. It is cached both ways and stored on the work
+// if it is in the graph; failures to store it are only logged.
+func (p *PaperResolver) Remember(ctx context.Context, workID, paperID string) {
+	if workID == "" || paperID == "" {
+		return
+	}
+	work, paper := OpenAlexID(workID), SemanticScholarID(paperID)
+	p.cache.put(SemanticScholar, work, paper.Value)
+	p.cache.put(OpenAlex, paper, work.Value)
+	err := p.repo.SetWorkSSPaperID(ctx, work.Value, paper.Value)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrStorageDisabled) {
+		log.Printf("WARN: %v", err)
+	}
+}
+
+// resolutionCache remembers resolved identifiers, per target ID space.
+type resolutionCache struct {
+	mu      sync.Mutex
+	entries map[Kind]map[AnyID]string
+}
+
+func newResolutionCache() *resolutionCache {
+	return &resolutionCache{entries: make(map[Kind]map[AnyID]string)}
+}
+
+func (c *resolutionCache) get(target Kind, id AnyID) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.entries[target][id]
+	return value, ok
+}
+
+func (c *resolutionCache) put(target Kind, id AnyID, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := c.entries[target]
+	if entries == nil || len(entries) >= maxCachedResolutions {
+		entries = make(map[AnyID]string)
+		c.entries[target] = entries
+	}
+	entries[id] = value
+}
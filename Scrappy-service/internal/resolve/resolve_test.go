@@ -0,0 +1,375 @@
+package resolve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/openalex"
+	"github.com/Cloudforge2/scrappy/internal/semanticscholar"
+	"github.com/Cloudforge2/scrappy/internal/storage"
+)
+
+// paperID returns a distinct, well-formed Semantic Scholar paperId.
+func paperID(c byte) string {
+	return strings.Repeat(string(c), 40)
+}
+
+// fakeRepo is a graph of works with their identifiers.
+type fakeRepo struct {
+	storage.Repository
+	mu    sync.Mutex
+	works []storage.WorkIDs
+}
+
+func (f *fakeRepo) FindWorkIDs(ctx context.Context, kind, value string) (storage.WorkIDs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, w := range f.works {
+		if (kind == storage.WorkIDOpenAlex && w.ID == value) || (kind == storage.WorkIDDOI && w.Doi == value) ||
+			(kind == storage.WorkIDSemanticScholar && w.SSPaperID == value) {
+			return w, nil
+		}
+	}
+	return storage.WorkIDs{}, storage.ErrNotFound
+}
+
+func (f *fakeRepo) SetWorkSSPaperID(ctx context.Context, workID, paperID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.works {
+		if f.works[i].ID == workID {
+			f.works[i].SSPaperID = paperID
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+// ssPaperIDOf returns the paperId stored on a work of the graph.
+func (f *fakeRepo) ssPaperIDOf(workID string) string {
+	ids, _ := f.FindWorkIDs(context.Background(), storage.WorkIDOpenAlex, workID)
+	return ids.SSPaperID
+}
+
+// newGraph returns a graph with W1 (DOI and paperId), W2 (DOI only) and W3 (neither).
+func newGraph() *fakeRepo {
+	return &fakeRepo{Repository: storage.NewDisabledRepository(), works: []storage.WorkIDs{
+		{ID: "https://openalex.org/W1", Doi: "10.1/one", SSPaperID: paperID('1')},
+		{ID: "https://openalex.org/W2", Doi: "10.1/two"},
+		{ID: "https://openalex.org/W3"},
+	}}
+}
+
+// fakeAPIs answers single-work OpenAlex requests from works and Semantic Scholar batch
+// requests from papers, both keyed by the identifier asked for, and counts the requests.
+type fakeAPIs struct {
+	works  map[string]string
+	papers map[string]string
+
+	mu              sync.Mutex
+	alexCalls       int
+	semanticCalls   int
+	semanticLookups []string
+}
+
+func (f *fakeAPIs) calls() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.alexCalls, f.semanticCalls
+}
+
+func (f *fakeAPIs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path == "/graph/v1/paper/batch" {
+		f.semanticCalls++
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		results := make([]string, len(body.IDs))
+		for i, id := range body.IDs {
+			f.semanticLookups = append(f.semanticLookups, id)
+			results[i] = "null"
+			if paper, ok := f.papers[id]; ok {
+				results[i] = paper
+			}
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(results, ","))
+		return
+	}
+	f.alexCalls++
+	work, ok := f.works[strings.TrimPrefix(r.URL.Path, "/works/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	fmt.Fprint(w, work)
+}
+
+// newTestResolver returns a resolver over repo whose API clients talk to apis.
+func newTestResolver(t *testing.T, repo storage.Repository, apis *fakeAPIs) *PaperResolver {
+	t.Helper()
+	server := httptest.NewServer(apis)
+	target, _ := url.Parse(server.URL)
+	original := http.DefaultTransport
+	http.DefaultTransport = rewriteTransport{target: target, next: original}
+	t.Cleanup(func() {
+		http.DefaultTransport = original
+		server.Close()
+	})
+	return NewPaperResolver(repo,
+		openalex.NewClient(openalex.WithRateLimit(1000, 100), openalex.WithPageJitter(0, 0)),
+		semanticscholar.NewClient("", semanticscholar.WithRateLimit(1000, 100)))
+}
+
+// rewriteTransport sends every request to target instead of its own host.
+type rewriteTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return t.next.RoundTrip(req)
+}
+
+// newAPIs returns APIs that know a few papers outside the graph (W30, W40, W50) and the
+// Semantic Scholar side of the graph's W2 and W3.
+func newAPIs() *fakeAPIs {
+	return &fakeAPIs{
+		works: map[string]string{
+			"doi:10.1/three": `{"id": "https://openalex.org/W30", "doi": "https://doi.org/10.1/three"}`,
+			"W3":             `{"id": "https://openalex.org/W3", "doi": "https://doi.org/10.1/four"}`,
+			"W40":            `{"id": "https://openalex.org/W40", "doi": "https://doi.org/10.1/four"}`,
+			"W50":            `{"id": "https://openalex.org/W50", "ids": {"openalex": "https://openalex.org/W50", "arxiv": "https://arxiv.org/abs/2106.15928"}}`,
+			"W60":            `{"id": "https://openalex.org/W60"}`,
+		},
+		papers: map[string]string{
+			"DOI:10.1/two":     fmt.Sprintf(`{"paperId": "%s"}`, paperID('2')),
+			"DOI:10.1/three":   fmt.Sprintf(`{"paperId": "%s"}`, paperID('3')),
+			"DOI:10.1/four":    fmt.Sprintf(`{"paperId": "%s"}`, paperID('4')),
+			"ARXIV:2106.15928": fmt.Sprintf(`{"paperId": "%s"}`, paperID('5')),
+			paperID('6'):       fmt.Sprintf(`{"paperId": "%s", "externalIds": {"DOI": "10.1/TWO"}}`, paperID('6')),
+			paperID('7'):       fmt.Sprintf(`{"paperId": "%s", "externalIds": {"DOI": "10.1/three"}}`, paperID('7')),
+			paperID('8'):       fmt.Sprintf(`{"paperId": "%s", "externalIds": {}}`, paperID('8')),
+		},
+	}
+}
+
+func TestParseAnyID(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    AnyID
+		wantErr bool
+	}{
+		{raw: "W2741809807", want: AnyID{OpenAlex, "https://openalex.org/W2741809807"}},
+		{raw: " https://openalex.org/W2741809807 ", want: AnyID{OpenAlex, "https://openalex.org/W2741809807"}},
+		{raw: "10.1038/Nature14539", want: AnyID{DOI, "10.1038/nature14539"}},
+		{raw: "https://doi.org/10.1038/nature14539", want: AnyID{DOI, "10.1038/nature14539"}},
+		{raw: "doi:10.1038/nature14539", want: AnyID{DOI, "10.1038/nature14539"}},
+		{raw: strings.ToUpper(paperID('a')), want: AnyID{SemanticScholar, paperID('a')}},
+		{raw: "A5023888391", wantErr: true},
+		{raw: "10.1038", wantErr: true},
+		{raw: paperID('a')[:39], wantErr: true},
+		{raw: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAnyID(tt.raw)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidID) {
+				t.Errorf("ParseAnyID(%q) = %v, %v; want ErrInvalidID", tt.raw, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAnyID(%q) = %v, %v; want %v", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveToOpenAlex(t *testing.T) {
+	tests := []struct {
+		name          string
+		id            AnyID
+		want          string
+		wantErr       error
+		wantCalls     [2]int // OpenAlex, Semantic Scholar
+		wantLearnedBy string // work that learns the paperId, if any
+	}{
+		{name: "already OpenAlex", id: OpenAlexID("W99"), want: "https://openalex.org/W99"},
+		{name: "DOI in the graph", id: DOIID("https://doi.org/10.1/ONE"), want: "https://openalex.org/W1"},
+		{name: "paperId in the graph", id: SemanticScholarID(paperID('1')), want: "https://openalex.org/W1"},
+		{name: "DOI from OpenAlex", id: DOIID("10.1/three"), want: "https://openalex.org/W30", wantCalls: [2]int{1, 0}},
+		{
+			name: "paperId whose DOI is in the graph", id: SemanticScholarID(paperID('6')),
+			want: "https://openalex.org/W2", wantCalls: [2]int{0, 1}, wantLearnedBy: "https://openalex.org/W2",
+		},
+		{name: "paperId whose DOI is in OpenAlex", id: SemanticScholarID(paperID('7')), want: "https://openalex.org/W30", wantCalls: [2]int{1, 1}},
+		{name: "paperId without DOI", id: SemanticScholarID(paperID('8')), wantErr: ErrUnresolved, wantCalls: [2]int{0, 1}},
+		{name: "unknown paperId", id: SemanticScholarID(paperID('9')), wantErr: ErrUnresolved, wantCalls: [2]int{0, 1}},
+		{name: "DOI unknown to OpenAlex", id: DOIID("10.1/missing"), wantErr: ErrUnresolved, wantCalls: [2]int{1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, apis := newGraph(), newAPIs()
+			p := newTestResolver(t, repo, apis)
+
+			got, err := p.ResolveToOpenAlex(context.Background(), tt.id)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveToOpenAlex(%s) = %q, %v; want %v", tt.id, got, err, tt.wantErr)
+				}
+			} else if err != nil || got != tt.want {
+				t.Fatalf("ResolveToOpenAlex(%s) = %q, %v; want %q", tt.id, got, err, tt.want)
+			}
+			if alex, semantic := apis.calls(); [2]int{alex, semantic} != tt.wantCalls {
+				t.Errorf("%d OpenAlex and %d Semantic Scholar requests, want %v", alex, semantic, tt.wantCalls)
+			}
+			if tt.wantLearnedBy != "" && repo.ssPaperIDOf(tt.wantLearnedBy) != tt.id.Value {
+				t.Errorf("paperId of %s = %q, want %q stored", tt.wantLearnedBy, repo.ssPaperIDOf(tt.wantLearnedBy), tt.id.Value)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			// Resolved IDs are cached.
+			if again, err := p.ResolveToOpenAlex(context.Background(), tt.id); err != nil || again != got {
+				t.Errorf("second ResolveToOpenAlex(%s) = %q, %v; want %q", tt.id, again, err, got)
+			}
+			if alex, semantic := apis.calls(); [2]int{alex, semantic} != tt.wantCalls {
+				t.Errorf("second resolution made requests: %d OpenAlex and %d Semantic Scholar in all", alex, semantic)
+			}
+		})
+	}
+}
+
+func TestResolveToSemanticScholar(t *testing.T) {
+	tests := []struct {
+		name          string
+		id            AnyID
+		want          string
+		wantErr       error
+		wantCalls     [2]int // OpenAlex, Semantic Scholar
+		wantLookup    string // what Semantic Scholar was asked for
+		wantLearnedBy string // work that learns the paperId, if any
+	}{
+		{name: "already Semantic Scholar", id: SemanticScholarID(strings.ToUpper(paperID('a'))), want: paperID('a')},
+		{name: "stored on the work", id: OpenAlexID("W1"), want: paperID('1')},
+		{name: "stored on the work with the DOI", id: DOIID("10.1/one"), want: paperID('1')},
+		{
+			name: "work in the graph by DOI", id: OpenAlexID("https://openalex.org/W2"), want: paperID('2'),
+			wantCalls: [2]int{0, 1}, wantLookup: "DOI:10.1/two", wantLearnedBy: "https://openalex.org/W2",
+		},
+		{
+			name: "work in the graph without DOI", id: OpenAlexID("W3"), want: paperID('4'),
+			wantCalls: [2]int{1, 1}, wantLookup: "DOI:10.1/four", wantLearnedBy: "https://openalex.org/W3",
+		},
+		{name: "DOI outside the graph", id: DOIID("10.1/three"), want: paperID('3'), wantCalls: [2]int{0, 1}, wantLookup: "DOI:10.1/three"},
+		{name: "work outside the graph", id: OpenAlexID("W40"), want: paperID('4'), wantCalls: [2]int{1, 1}, wantLookup: "DOI:10.1/four"},
+		{name: "work with only an arXiv ID", id: OpenAlexID("W50"), want: paperID('5'), wantCalls: [2]int{1, 1}, wantLookup: "ARXIV:2106.15928"},
+		{name: "work without identifiers", id: OpenAlexID("W60"), wantErr: ErrUnresolved, wantCalls: [2]int{1, 0}},
+		{name: "work unknown to OpenAlex", id: OpenAlexID("W70"), wantErr: ErrUnresolved, wantCalls: [2]int{1, 0}},
+		{name: "DOI unknown to Semantic Scholar", id: DOIID("10.1/missing"), wantErr: ErrUnresolved, wantCalls: [2]int{0, 1}, wantLookup: "DOI:10.1/missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, apis := newGraph(), newAPIs()
+			p := newTestResolver(t, repo, apis)
+
+			got, err := p.ResolveToSemanticScholar(context.Background(), tt.id)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveToSemanticScholar(%s) = %q, %v; want %v", tt.id, got, err, tt.wantErr)
+				}
+			} else if err != nil || got != tt.want {
+				t.Fatalf("ResolveToSemanticScholar(%s) = %q, %v; want %q", tt.id, got, err, tt.want)
+			}
+			if alex, semantic := apis.calls(); [2]int{alex, semantic} != tt.wantCalls {
+				t.Errorf("%d OpenAlex and %d Semantic Scholar requests, want %v", alex, semantic, tt.wantCalls)
+			}
+			if tt.wantLookup != "" && (len(apis.semanticLookups) != 1 || apis.semanticLookups[0] != tt.wantLookup) {
+				t.Errorf("Semantic Scholar was asked for %v, want [%s]", apis.semanticLookups, tt.wantLookup)
+			}
+			for _, work := range repo.works {
+				stored := work.SSPaperID != "" && work.ID != "https://openalex.org/W1"
+				if learned := work.ID == tt.wantLearnedBy; stored != learned || (learned && work.SSPaperID != tt.want) {
+					t.Errorf("paperId of %s = %q, want it stored: %v", work.ID, work.SSPaperID, learned)
+				}
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			// Resolved IDs are cached.
+			if again, err := p.ResolveToSemanticScholar(context.Background(), tt.id); err != nil || again != got {
+				t.Errorf("second ResolveToSemanticScholar(%s) = %q, %v; want %q", tt.id, again, err, got)
+			}
+			if alex, semantic := apis.calls(); [2]int{alex, semantic} != tt.wantCalls {
+				t.Errorf("second resolution made requests: %d OpenAlex and %d Semantic Scholar in all", alex, semantic)
+			}
+		})
+	}
+}
+
+func TestRemember(t *testing.T) {
+	repo, apis := newGraph(), newAPIs()
+	p := newTestResolver(t, repo, apis)
+	ctx := context.Background()
+
+	p.Remember(ctx, "W3", strings.ToUpper(paperID('c')))
+	p.Remember(ctx, "W80", paperID('d')) // Not in the graph: only cached.
+	if got := repo.ssPaperIDOf("https://openalex.org/W3"); got != paperID('c') {
+		t.Errorf("paperId of W3 = %q, want %q stored", got, paperID('c'))
+	}
+
+	tests := []struct {
+		resolve func() (string, error)
+		want    string
+	}{
+		{func() (string, error) { return p.ResolveToSemanticScholar(ctx, OpenAlexID("W3")) }, paperID('c')},
+		{func() (string, error) { return p.ResolveToOpenAlex(ctx, SemanticScholarID(paperID('c'))) }, "https://openalex.org/W3"},
+		{func() (string, error) { return p.ResolveToSemanticScholar(ctx, OpenAlexID("W80")) }, paperID('d')},
+		{func() (string, error) { return p.ResolveToOpenAlex(ctx, SemanticScholarID(paperID('d'))) }, "https://openalex.org/W80"},
+	}
+	for i, tt := range tests {
+		if got, err := tt.resolve(); err != nil || got != tt.want {
+			t.Errorf("resolution %d = %q, %v; want %q", i, got, err, tt.want)
+		}
+	}
+	if alex, semantic := apis.calls(); alex+semantic != 0 {
+		t.Errorf("%d OpenAlex and %d Semantic Scholar requests, want remembered IDs to need none", alex, semantic)
+	}
+
+	if got, ok := p.LocalSemanticScholarID(ctx, OpenAlexID("W1")); !ok || got != paperID('1') {
+		t.Errorf("LocalSemanticScholarID(W1) = %q, %v; want the stored %q", got, ok, paperID('1'))
+	}
+	if _, ok := p.LocalSemanticScholarID(ctx, OpenAlexID("W2")); ok {
+		t.Error("LocalSemanticScholarID(W2) found a paperId nobody learned")
+	}
+}
+
+func TestRememberWithStorageDisabled(t *testing.T) {
+	apis := newAPIs()
+	p := newTestResolver(t, storage.NewDisabledRepository(), apis)
+	ctx := context.Background()
+
+	p.Remember(ctx, "W2", paperID('2'))
+	if got, err := p.ResolveToSemanticScholar(ctx, OpenAlexID("W2")); err != nil || got != paperID('2') {
+		t.Errorf("ResolveToSemanticScholar(W2) = %q, %v; want the remembered paperId", got, err)
+	}
+	// Without a graph, DOIs go straight to the APIs.
+	if got, err := p.ResolveToOpenAlex(ctx, DOIID("10.1/three")); err != nil || got != "https://openalex.org/W30" {
+		t.Errorf("ResolveToOpenAlex(10.1/three) = %q, %v; want W30", got, err)
+	}
+}
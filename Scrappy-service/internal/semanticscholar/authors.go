@@ -0,0 +1,61 @@
+package semanticscholar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AuthorKindORCID looks an author up by ORCID iD.
+const AuthorKindORCID = "ORCID"
+
+// ErrNotFound is returned when Semantic Scholar has no record for the requested identifier.
+var ErrNotFound = errors.New("not found in Semantic Scholar")
+
+// AuthorResponse matches the Semantic Scholar author object.
+type AuthorResponse struct {
+	AuthorID   string `json:"authorId"`
+	Name       string `json:"name"`
+	HIndex     int    `json:"hIndex"`
+	PaperCount int    `json:"paperCount"`
+	Homepage   string `json:"homepage"`
+	URL        string `json:"url"`
+}
+
+// FetchAuthorByExternalID fetches an author by an external identifier such as an ORCID iD
+// (kind AuthorKindORCID). URL-form ORCIDs (https://orcid.org/...) are accepted.
+func (c *Client) FetchAuthorByExternalID(ctx context.Context, kind, id string) (*AuthorResponse, error) {
+	id = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(id), "https://orcid.org/"), "http://orcid.org/")
+	if id == "" {
+		return nil, fmt.Errorf("empty %s identifier", kind)
+	}
+	requestURL := fmt.Sprintf("%s/author/%s:%s?fields=%s", semanticScholarAPIBaseURL,
+		kind, url.PathEscape(id), "authorId,name,hIndex,paperCount,homepage,url")
+
+	resp, err := c.doWithRetry(func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("author %s:%s: %w", kind, id, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("api request failed with status code %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var author AuthorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&author); err != nil {
+		return nil, fmt.Errorf("failed to decode json response: %w", err)
+	}
+	return &author, nil
+}
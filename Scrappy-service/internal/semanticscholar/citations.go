@@ -0,0 +1,114 @@
+package semanticscholar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// MaxCitations bounds how many citations or references are fetched for one paper.
+const MaxCitations = 9000
+
+// citationPageSize is the largest page the citations and references endpoints serve.
+const citationPageSize = 1000
+
+// citationFields asks for Semantic Scholar's annotations of each citation along with the
+// identifiers needed to match the other paper to a local work.
+const citationFields = "intents,isInfluential,contexts,title,externalIds,year"
+
+// Citation is one edge of a paper's citation graph: the paper on the other end, plus the
+// intents (e.g. "methodology", "background", "result"), influence flag and citing sentences
+// Semantic Scholar annotated it with.
+type Citation struct {
+	Paper         PaperResponse
+	Intents       []string
+	IsInfluential bool
+	Contexts      []string
+}
+
+// citationEdge matches one entry of the citations and references endpoints; only one of
+// CitingPaper and CitedPaper is set, depending on the endpoint.
+type citationEdge struct {
+	Intents       []string       `json:"intents"`
+	IsInfluential bool           `json:"isInfluential"`
+	Contexts      []string       `json:"contexts"`
+	CitingPaper   *PaperResponse `json:"citingPaper"`
+	CitedPaper    *PaperResponse `json:"citedPaper"`
+}
+
+// FetchCitations returns up to limit papers citing paperID, which is anything the API
+// accepts as a paper identifier, e.g. PaperID.String().
+func (c *Client) FetchCitations(ctx context.Context, paperID string, limit int) ([]Citation, error) {
+	return c.fetchCitationEdges(ctx, paperID, "citations", limit)
+}
+
+// FetchReferences returns up to limit papers cited by paperID.
+func (c *Client) FetchReferences(ctx context.Context, paperID string, limit int) ([]Citation, error) {
+	return c.fetchCitationEdges(ctx, paperID, "references", limit)
+}
+
+func (c *Client) fetchCitationEdges(ctx context.Context, paperID, endpoint string, limit int) ([]Citation, error) {
+	if limit <= 0 || limit > MaxCitations {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxCitations)
+	}
+	var citations []Citation
+	for offset := 0; offset < limit; {
+		pageSize := min(citationPageSize, limit-offset)
+		requestURL := fmt.Sprintf("%s/paper/%s/%s?fields=%s&offset=%d&limit=%d", semanticScholarAPIBaseURL,
+			url.PathEscape(paperID), endpoint, citationFields, offset, pageSize)
+
+		resp, err := c.doWithRetry(func() (*http.Request, error) {
+			return http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Next *int           `json:"next"`
+			Data []citationEdge `json:"data"`
+		}
+		if err := decodePage(resp, paperID, &page); err != nil {
+			return nil, err
+		}
+
+		for _, edge := range page.Data {
+			paper := edge.CitingPaper
+			if paper == nil {
+				paper = edge.CitedPaper
+			}
+			if paper == nil {
+				continue
+			}
+			citations = append(citations, Citation{
+				Paper:         *paper,
+				Intents:       edge.Intents,
+				IsInfluential: edge.IsInfluential,
+				Contexts:      edge.Contexts,
+			})
+		}
+		if page.Next == nil || len(page.Data) == 0 {
+			break
+		}
+		offset = *page.Next
+	}
+	return citations, nil
+}
+
+// decodePage decodes a successful response into target and closes its body.
+func decodePage(resp *http.Response, paperID string, target interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("paper %s: %w", paperID, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("api request failed with status code %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode json response: %w", err)
+	}
+	return nil
+}
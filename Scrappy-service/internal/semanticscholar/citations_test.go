@@ -0,0 +1,98 @@
+package semanticscholar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFetchCitationEdges(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+"?"+r.URL.RawQuery)
+		if strings.Contains(r.URL.Path, "/missing/") {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.URL.Query().Get("fields"); got != citationFields {
+			t.Errorf("fields = %q, want %q", got, citationFields)
+		}
+		key := "citingPaper"
+		if strings.HasSuffix(r.URL.Path, "/references") {
+			key = "citedPaper"
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		next := "null"
+		if offset == 0 {
+			next = "1"
+		}
+		fmt.Fprintf(w, `{"next": %s, "data": [
+			{"intents": ["methodology"], "isInfluential": true, "contexts": ["as shown in [3]"], "%s": {"paperId": "p%d", "externalIds": {"DOI": "10.1/p%d"}}},
+			{"intents": null, "isInfluential": false, "contexts": null}
+		]}`, next, key, offset, offset)
+	})
+
+	tests := []struct {
+		name         string
+		fetch        func(ctx context.Context, paperID string, limit int) ([]Citation, error)
+		endpoint     string
+		limit        int
+		wantPapers   []string
+		wantRequests int
+	}{
+		{name: "citations", fetch: client.FetchCitations, endpoint: "citations", limit: 5, wantPapers: []string{"p0", "p1"}, wantRequests: 2},
+		{name: "references", fetch: client.FetchReferences, endpoint: "references", limit: 5, wantPapers: []string{"p0", "p1"}, wantRequests: 2},
+		{name: "limit stops paging", fetch: client.FetchCitations, endpoint: "citations", limit: 1, wantPapers: []string{"p0"}, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			citations, err := tt.fetch(context.Background(), "abc", tt.limit)
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if len(requests) != tt.wantRequests {
+				t.Errorf("requests = %v, want %d", requests, tt.wantRequests)
+			}
+			for _, request := range requests {
+				if !strings.HasPrefix(request, "/graph/v1/paper/abc/"+tt.endpoint+"?") {
+					t.Errorf("request %s, want one to the %s endpoint", request, tt.endpoint)
+				}
+			}
+			var papers []string
+			for _, c := range citations {
+				papers = append(papers, c.Paper.PaperID)
+			}
+			if !reflect.DeepEqual(papers, tt.wantPapers) {
+				t.Fatalf("papers = %v, want %v (edges without a paper skipped)", papers, tt.wantPapers)
+			}
+			first := citations[0]
+			if !first.IsInfluential || !reflect.DeepEqual(first.Intents, []string{"methodology"}) ||
+				!reflect.DeepEqual(first.Contexts, []string{"as shown in [3]"}) || first.Paper.ExternalIDs.DOI != "10.1/p0" {
+				t.Errorf("citation = %+v, want its annotations and DOI", first)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := client.FetchCitations(context.Background(), "missing", 5); !errors.Is(err, ErrNotFound) {
+			t.Errorf("error = %v, want ErrNotFound", err)
+		}
+	})
+	t.Run("limit out of range", func(t *testing.T) {
+		requests = nil
+		for _, limit := range []int{0, MaxCitations + 1} {
+			if _, err := client.FetchCitations(context.Background(), "abc", limit); err == nil {
+				t.Errorf("limit %d accepted", limit)
+			}
+		}
+		if len(requests) != 0 {
+			t.Errorf("requests = %v, want none", requests)
+		}
+	})
+}
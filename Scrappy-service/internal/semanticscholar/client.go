@@ -2,11 +2,14 @@ package semanticscholar
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/ratelimit"
 )
 
 const semanticScholarAPIBaseURL = "https://api.semanticscholar.org/graph/v1"
@@ -18,7 +21,12 @@ type RequestBody struct {
 
 // ExternalIDs matches the nested JSON object from the API.
 type ExternalIDs struct {
-	DOI string `json:"DOI"`
+	DOI           string `json:"DOI"`
+	ArXiv         string `json:"ArXiv"`
+	PubMed        string `json:"PubMed"`
+	PubMedCentral string `json:"PubMedCentral"`
+	MAG           string `json:"MAG"`
+	CorpusID      int    `json:"CorpusId"`
 }
 
 // PaperResponse matches the structure of a single paper object in the API response.
@@ -27,30 +35,117 @@ type PaperResponse struct {
 	Title       string      `json:"title"`
 	ExternalIDs ExternalIDs `json:"externalIds"`
 	Abstract    string      `json:"abstract"`
+	Year        int         `json:"year"`
+	Embedding   *Embedding  `json:"embedding"` // Only when requested, e.g. by FetchEmbeddings.
+}
+
+// Embedding is a paper's SPECTER vector.
+type Embedding struct {
+	Model  string    `json:"model"`
+	Vector []float32 `json:"vector"`
 }
 
+// MaxBatchSize is the most IDs the paper batch endpoint accepts in one request.
+const MaxBatchSize = 500
+
 // Client is a client for interacting with the Semantic Scholar API.
 type Client struct {
 	httpClient *http.Client
 	apiKey     string
+
+	// limiter is shared by every request the client makes, retries included.
+	limiter *ratelimit.Bucket
+	// retryBackoff is the wait before the first retry of a request; it doubles for each
+	// further one. A Retry-After header replaces it.
+	retryBackoff time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRateLimit limits the client to rate requests per second, with bursts of at most
+// burst requests. A rate of 0 or less keeps the default for whether a key is set.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *Client) {
+		if rate > 0 {
+			c.limiter = ratelimit.NewBucket(rate, burst)
+		}
+	}
+}
+
+// WithTimeout sets the timeout of a single request attempt.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.httpClient.Timeout = timeout
+		}
+	}
 }
 
-// NewClient creates a new API client.
-func NewClient(apiKey string) *Client {
-	return &Client{
-		httpClient: &http.Client{Timeout: 20 * time.Second},
-		apiKey:     apiKey,
+// WithRetryBackoff sets the wait before the first retry of a throttled or failed request;
+// it doubles for each further one.
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(c *Client) {
+		if backoff > 0 {
+			c.retryBackoff = backoff
+		}
 	}
 }
 
-// FetchPaperDetails fetches details for a batch of papers using their DOIs.
-// Note: Renamed from FetchAbstracts for clarity.
-func (c *Client) FetchAbstracts(dois []string) ([]*PaperResponse, error) { // Note the return type is a slice of pointers
+// NewClient creates a new API client. Without options it makes at most
+// DefaultRateLimitWithKey requests per second with an API key, and
+// DefaultRateLimitWithoutKey without one.
+func NewClient(apiKey string, opts ...Option) *Client {
+	rate := DefaultRateLimitWithoutKey
+	if apiKey != "" {
+		rate = DefaultRateLimitWithKey
+	}
+	c := &Client{
+		httpClient:   &http.Client{Timeout: 20 * time.Second},
+		apiKey:       apiKey,
+		limiter:      ratelimit.NewBucket(rate, 1),
+		retryBackoff: time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// FetchAbstracts fetches details for a batch of papers identified by DOI, arXiv ID, PMID, etc.
+// The result is keyed by the identifiers that were passed in, so callers can match papers
+// back to their own records even when Semantic Scholar reports a canonicalized ID (e.g. a
+// differently-cased DOI). Papers Semantic Scholar doesn't know are absent from the map.
+func (c *Client) FetchAbstracts(ctx context.Context, ids []PaperID) (map[PaperID]*PaperResponse, error) {
+	return c.fetchBatch(ctx, ids, "title,externalIds,abstract")
+}
+
+// FetchEmbeddings is FetchAbstracts for papers' SPECTER embeddings; papers without an
+// embedding have a nil Embedding.
+func (c *Client) FetchEmbeddings(ctx context.Context, ids []PaperID) (map[PaperID]*PaperResponse, error) {
+	return c.fetchBatch(ctx, ids, "title,externalIds,embedding")
+}
+
+// FetchPaperIDs is FetchAbstracts for papers' identifiers only: their paperId and
+// external ids.
+func (c *Client) FetchPaperIDs(ctx context.Context, ids []PaperID) (map[PaperID]*PaperResponse, error) {
+	return c.fetchBatch(ctx, ids, "externalIds")
+}
+
+// fetchBatch fetches the given fields of up to MaxBatchSize papers with one batch request.
+// Cancelling ctx stops it, including the waits between retries.
+func (c *Client) fetchBatch(ctx context.Context, ids []PaperID, fields string) (map[PaperID]*PaperResponse, error) {
+	if len(ids) > MaxBatchSize {
+		return nil, fmt.Errorf("at most %d papers per batch, got %d", MaxBatchSize, len(ids))
+	}
 
 	requestURL := fmt.Sprintf("%s/paper/batch", semanticScholarAPIBaseURL)
 
 	// We can build the request body from the function arguments for more flexibility
-	requestData := RequestBody{IDs: dois}
+	requestData := RequestBody{IDs: make([]string, len(ids))}
+	for i, id := range ids {
+		requestData.IDs[i] = id.String()
+	}
 
 	jsonData, err := json.Marshal(requestData)
 	if err != nil {
@@ -58,24 +153,23 @@ func (c *Client) FetchAbstracts(dois []string) ([]*PaperResponse, error) { // No
 		return nil, fmt.Errorf("failed to marshal request data: %w", err)
 	}
 
-	// Create the POST request
-	req, err := http.NewRequest("POST", requestURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create http request: %w", err)
-	}
-
-	// Add query parameters and headers
-	q := req.URL.Query()
-	q.Add("fields", "title,externalIds,abstract") // You could also request 'abstract' here if needed
-	req.URL.RawQuery = q.Encode()
-	req.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		req.Header.Set("x-api-key", c.apiKey)
-	}
 	// Use the client from the struct for connection reuse and consistency
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(func() (*http.Request, error) {
+		// Create the POST request
+		req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+
+		// Add query parameters and headers
+		q := req.URL.Query()
+		q.Add("fields", fields)
+		req.URL.RawQuery = q.Encode()
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send http request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -90,7 +184,16 @@ func (c *Client) FetchAbstracts(dois []string) ([]*PaperResponse, error) { // No
 		return nil, fmt.Errorf("failed to decode json response: %w", err)
 	}
 
-	// The function's responsibility is to return data, not print it.
-	// The caller (e.g., your main function) can decide to print the results.
-	return papers, nil
+	// The batch endpoint answers positionally: the i-th result (or null) belongs to the
+	// i-th requested ID, which is how responses are mapped back to the inputs.
+	if len(papers) != len(ids) {
+		return nil, fmt.Errorf("unexpected batch response: requested %d papers, got %d results", len(ids), len(papers))
+	}
+	results := make(map[PaperID]*PaperResponse, len(ids))
+	for i, paper := range papers {
+		if paper != nil {
+			results[ids[i]] = paper
+		}
+	}
+	return results, nil
 }
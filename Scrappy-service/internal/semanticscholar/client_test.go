@@ -0,0 +1,184 @@
+package semanticscholar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rewriteTransport sends every request to the test server instead of Semantic Scholar.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestClient returns a client whose requests are answered by handler, without a rate
+// limit or retry backoff worth waiting for.
+func newTestClient(t *testing.T, apiKey string, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+
+	c := NewClient(apiKey, WithRateLimit(1000, 100), WithRetryBackoff(time.Millisecond))
+	c.httpClient.Transport = rewriteTransport{target}
+	return c
+}
+
+func TestPaperIDString(t *testing.T) {
+	tests := []struct {
+		id   PaperID
+		want string
+	}{
+		{PaperID{KindPaperID, "649def34f8be52c8b66281af98ae884c09aef38b"}, "649def34f8be52c8b66281af98ae884c09aef38b"},
+		{PaperID{KindDOI, "10.1038/Nature14539"}, "DOI:10.1038/nature14539"},
+		{PaperID{KindDOI, "https://doi.org/10.1038/NATURE14539"}, "DOI:10.1038/nature14539"},
+		{PaperID{KindDOI, " doi:10.1038/nature14539 "}, "DOI:10.1038/nature14539"},
+		{PaperID{KindArXiv, "https://arxiv.org/abs/2106.15928"}, "ARXIV:2106.15928"},
+		{PaperID{KindArXiv, "arXiv:2106.15928"}, "ARXIV:2106.15928"},
+		{PaperID{KindPMID, "https://pubmed.ncbi.nlm.nih.gov/19872477/"}, "PMID:19872477"},
+		{PaperID{KindPMCID, "https://www.ncbi.nlm.nih.gov/pmc/articles/PMC2323736"}, "PMCID:2323736"},
+		{PaperID{KindPMCID, "PMC2323736"}, "PMCID:2323736"},
+		{PaperID{KindMAG, "2741809807"}, "MAG:2741809807"},
+	}
+	for _, tt := range tests {
+		if got := tt.id.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestPaperIDForWork(t *testing.T) {
+	tests := []struct {
+		name   string
+		doi    string
+		ids    map[string]string
+		want   PaperID
+		wantOK bool
+	}{
+		{"doi field", "https://doi.org/10.1/a", map[string]string{"pmid": "1"}, PaperID{KindDOI, "https://doi.org/10.1/a"}, true},
+		{"doi in ids", "", map[string]string{"doi": "10.1/b", "pmid": "1"}, PaperID{KindDOI, "10.1/b"}, true},
+		{"arxiv before pmid", "", map[string]string{"arxiv": "2106.15928", "pmid": "1"}, PaperID{KindArXiv, "2106.15928"}, true},
+		{"pmid", "", map[string]string{"pmid": "https://pubmed.ncbi.nlm.nih.gov/19872477"}, PaperID{KindPMID, "https://pubmed.ncbi.nlm.nih.gov/19872477"}, true},
+		{"pmcid", "", map[string]string{"pmcid": "PMC2323736"}, PaperID{KindPMCID, "PMC2323736"}, true},
+		{"mag", "", map[string]string{"openalex": "W1", "mag": "2741809807"}, PaperID{KindMAG, "2741809807"}, true},
+		{"nothing usable", "", map[string]string{"openalex": "W1"}, PaperID{}, false},
+		{"no ids", "", nil, PaperID{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PaperIDForWork(tt.doi, tt.ids)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("PaperIDForWork(%q, %v) = %v, %v; want %v, %v", tt.doi, tt.ids, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFetchBatchKeysResultsByRequestedID(t *testing.T) {
+	doi := PaperID{KindDOI, "https://doi.org/10.1038/NATURE14539"}
+	arxiv := PaperID{KindArXiv, "2106.15928"}
+	pmid := PaperID{KindPMID, "19872477"}
+
+	c := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/graph/v1/paper/batch" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("fields"); got != "title,externalIds,abstract" {
+			t.Errorf("fields = %q", got)
+		}
+		var body RequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+		want := []string{"DOI:10.1038/nature14539", "ARXIV:2106.15928", "PMID:19872477"}
+		if !reflect.DeepEqual(body.IDs, want) {
+			t.Errorf("requested ids = %v, want %v", body.IDs, want)
+		}
+		// Semantic Scholar answers positionally, with its own casing of the DOI, and null for
+		// papers it doesn't know.
+		w.Write([]byte(`[
+			{"paperId": "p1", "externalIds": {"DOI": "10.1038/Nature14539"}, "abstract": "first"},
+			null,
+			{"paperId": "p3", "abstract": "third"}
+		]`))
+	})
+
+	papers, err := c.FetchAbstracts(context.Background(), []PaperID{doi, arxiv, pmid})
+	if err != nil {
+		t.Fatalf("FetchAbstracts: %v", err)
+	}
+	if len(papers) != 2 {
+		t.Fatalf("got %d papers, want 2: %v", len(papers), papers)
+	}
+	if p := papers[doi]; p == nil || p.PaperID != "p1" {
+		t.Errorf("papers[doi] = %+v, want p1", p)
+	}
+	if _, ok := papers[arxiv]; ok {
+		t.Errorf("unknown paper %v is in the result", arxiv)
+	}
+	if p := papers[pmid]; p == nil || p.Abstract != "third" {
+		t.Errorf("papers[pmid] = %+v, want p3", p)
+	}
+}
+
+func TestFetchBatchRejectsMismatchedResponse(t *testing.T) {
+	c := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"paperId": "p1"}]`))
+	})
+	_, err := c.FetchPaperIDs(context.Background(), []PaperID{{KindDOI, "10.1/a"}, {KindDOI, "10.1/b"}})
+	if err == nil {
+		t.Fatal("expected an error for a response with fewer results than ids")
+	}
+}
+
+func TestFetchBatchRejectsOversizedBatch(t *testing.T) {
+	c := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request should be sent")
+	})
+	if _, err := c.FetchPaperIDs(context.Background(), make([]PaperID, MaxBatchSize+1)); err == nil {
+		t.Fatal("expected an error for more than MaxBatchSize ids")
+	}
+}
+
+func TestFetchEmbeddings(t *testing.T) {
+	var fields string
+	client := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		fields = r.URL.Query().Get("fields")
+		fmt.Fprint(w, `[
+			{"paperId": "p1", "embedding": {"model": "specter_v2", "vector": [0.25, -1.5]}},
+			{"paperId": "p2", "embedding": null},
+			null
+		]`)
+	})
+	ids := []PaperID{{KindDOI, "10.1/one"}, {KindDOI, "10.1/two"}, {KindDOI, "10.1/three"}}
+	papers, err := client.FetchEmbeddings(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("FetchEmbeddings: %v", err)
+	}
+	if !strings.Contains(fields, "embedding") {
+		t.Errorf("fields = %q, want the embedding requested", fields)
+	}
+	if e := papers[ids[0]].Embedding; e == nil || e.Model != "specter_v2" || !reflect.DeepEqual(e.Vector, []float32{0.25, -1.5}) {
+		t.Errorf("embedding of %v = %+v, want the SPECTER vector", ids[0], e)
+	}
+	if e := papers[ids[1]].Embedding; e != nil {
+		t.Errorf("embedding of %v = %+v, want nil", ids[1], e)
+	}
+	if _, ok := papers[ids[2]]; ok {
+		t.Errorf("unknown paper %v is in the results", ids[2])
+	}
+}
@@ -0,0 +1,84 @@
+package semanticscholar
+
+import (
+	"strings"
+)
+
+// IDKind identifies which identifier scheme a PaperID uses. The values are the
+// prefixes understood by the Semantic Scholar paper endpoints.
+type IDKind string
+
+const (
+	KindPaperID IDKind = ""      // A native Semantic Scholar paperId, sent without a prefix.
+	KindDOI     IDKind = "DOI"   // e.g. 10.1038/nature14539
+	KindArXiv   IDKind = "ARXIV" // e.g. 2106.15928
+	KindPMID    IDKind = "PMID"  // PubMed ID, e.g. 19872477
+	KindPMCID   IDKind = "PMCID" // PubMed Central ID, e.g. 2323736
+	KindMAG     IDKind = "MAG"   // Microsoft Academic Graph ID, which OpenAlex still maps for its works.
+)
+
+// PaperID is a typed paper identifier for Semantic Scholar lookups.
+type PaperID struct {
+	Kind  IDKind
+	Value string
+}
+
+// String returns the identifier in the prefixed form Semantic Scholar expects,
+// e.g. "ARXIV:2106.15928". URL forms (https://doi.org/..., PubMed links) are stripped.
+func (id PaperID) String() string {
+	value := normalizeIDValue(id.Kind, id.Value)
+	if id.Kind == KindPaperID {
+		return value
+	}
+	return string(id.Kind) + ":" + value
+}
+
+// normalizeIDValue strips the URL prefixes OpenAlex uses for external identifiers.
+func normalizeIDValue(kind IDKind, value string) string {
+	value = strings.TrimSpace(value)
+	var prefixes []string
+	switch kind {
+	case KindDOI:
+		prefixes = []string{"https://doi.org/", "http://doi.org/", "doi:"}
+	case KindArXiv:
+		prefixes = []string{"https://arxiv.org/abs/", "http://arxiv.org/abs/", "arXiv:", "arxiv:"}
+	case KindPMID:
+		prefixes = []string{"https://pubmed.ncbi.nlm.nih.gov/", "http://pubmed.ncbi.nlm.nih.gov/"}
+	case KindPMCID:
+		prefixes = []string{"https://www.ncbi.nlm.nih.gov/pmc/articles/", "PMC"}
+	}
+	for _, prefix := range prefixes {
+		if len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+			value = value[len(prefix):]
+		}
+	}
+	value = strings.TrimSuffix(value, "/")
+	if kind == KindDOI {
+		value = strings.ToLower(value)
+	}
+	return value
+}
+
+// PaperIDForWork picks the best identifier to look a work up by, given its DOI and the
+// OpenAlex "ids" map. DOIs are preferred, then arXiv IDs, PMIDs, PMCIDs and MAG IDs.
+func PaperIDForWork(doi string, ids map[string]string) (PaperID, bool) {
+	if doi != "" {
+		return PaperID{Kind: KindDOI, Value: doi}, true
+	}
+	candidates := []struct {
+		key  string
+		kind IDKind
+	}{
+		{"doi", KindDOI},
+		{"arxiv", KindArXiv},
+		{"pmid", KindPMID},
+		{"pmcid", KindPMCID},
+		{"mag", KindMAG},
+	}
+	for _, c := range candidates {
+		if v := ids[c.key]; v != "" {
+			return PaperID{Kind: c.kind, Value: v}, true
+		}
+	}
+	return PaperID{}, false
+}
@@ -0,0 +1,49 @@
+package semanticscholar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const semanticScholarRecommendationsURL = "https://api.semanticscholar.org/recommendations/v1"
+
+// MaxRecommendations is the largest limit the recommendations endpoint accepts.
+const MaxRecommendations = 500
+
+// FetchRecommendations returns papers Semantic Scholar recommends for the given paper.
+// paperID is anything the API accepts as a paper identifier, e.g. PaperID.String().
+func (c *Client) FetchRecommendations(ctx context.Context, paperID string, limit int) ([]PaperResponse, error) {
+	if limit <= 0 || limit > MaxRecommendations {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxRecommendations)
+	}
+	requestURL := fmt.Sprintf("%s/papers/forpaper/%s?fields=%s&limit=%d", semanticScholarRecommendationsURL,
+		url.PathEscape(paperID), "title,externalIds,year", limit)
+
+	resp, err := c.doWithRetry(func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("paper %s: %w", paperID, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("api request failed with status code %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var apiResponse struct {
+		RecommendedPapers []PaperResponse `json:"recommendedPapers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode json response: %w", err)
+	}
+	return apiResponse.RecommendedPapers, nil
+}
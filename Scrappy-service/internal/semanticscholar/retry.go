@@ -0,0 +1,96 @@
+package semanticscholar
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxAttempts bounds how often a request is sent when Semantic Scholar answers 429 or 5xx.
+// Without an API key the shared public pool throttles aggressively, so a few retries with
+// backoff turn most of those into successes.
+const maxAttempts = 4
+
+// Default request rates, in requests per second. Semantic Scholar asks keyed clients to
+// stay at 1 rps; keyless clients share one pool with everyone else, so they go slower.
+const (
+	DefaultRateLimitWithKey    = 1.0
+	DefaultRateLimitWithoutKey = 0.3
+)
+
+// ErrRateLimited is returned when Semantic Scholar still throttles a request after all
+// retries. Callers can fall back to the data they have rather than fail.
+var ErrRateLimited = errors.New("rate limited by Semantic Scholar")
+
+// throttled reports whether a response status means Semantic Scholar is throttling us. The
+// keyless pool answers 403 as well as 429 when it is saturated; with a key, 403 means the
+// key was refused.
+func (c *Client) throttled(status int) bool {
+	return status == http.StatusTooManyRequests || (status == http.StatusForbidden && c.apiKey == "")
+}
+
+// doWithRetry sends the request built by newRequest, retrying on throttled and 5xx
+// responses with exponential backoff (honoring Retry-After when present). Every attempt
+// waits for the client's rate limiter first. newRequest is called for every attempt
+// because a request body can only be read once. The API key header is added here, so
+// callers don't have to. A request still throttled after the last attempt fails with
+// ErrRateLimited.
+func (c *Client) doWithRetry(newRequest func() (*http.Request, error)) (*http.Response, error) {
+	backoff := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create http request: %w", err)
+		}
+		if c.apiKey != "" {
+			req.Header.Set("x-api-key", c.apiKey)
+		}
+		if err := c.limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("rate limiter: %w", err)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send http request: %w", err)
+		}
+		throttled := c.throttled(resp.StatusCode)
+		if !throttled && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if attempt == maxAttempts {
+			if throttled {
+				resp.Body.Close()
+				return nil, fmt.Errorf("%w: status %d after %d attempts", ErrRateLimited, resp.StatusCode, attempt)
+			}
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		wait := backoff
+		if after := retryAfter(resp.Header.Get("Retry-After")); after > 0 {
+			wait = after
+		}
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		backoff *= 2
+	}
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP date. It returns
+// 0 when the header is missing or invalid.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,209 @@
+package semanticscholar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// attempt is a request the test server received.
+type attempt struct {
+	at     time.Time
+	apiKey string
+	ids    []string
+}
+
+// scriptedServer answers the i-th request with responses[i], a status with an optional
+// Retry-After after a colon, e.g. "429:1". 200 answers with one paper. Requests beyond
+// the script fail the test.
+type scriptedServer struct {
+	t         *testing.T
+	responses []string
+
+	mu       sync.Mutex
+	attempts []attempt
+}
+
+func (s *scriptedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body RequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	s.mu.Lock()
+	n := len(s.attempts)
+	s.attempts = append(s.attempts, attempt{at: time.Now(), apiKey: r.Header.Get("x-api-key"), ids: body.IDs})
+	s.mu.Unlock()
+	if n >= len(s.responses) {
+		s.t.Errorf("unexpected request %d", n+1)
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+	status, after, _ := strings.Cut(s.responses[n], ":")
+	if after != "" {
+		w.Header().Set("Retry-After", after)
+	}
+	if status == "200" {
+		w.Write([]byte(`[{"paperId": "p1", "abstract": "abstract"}]`))
+		return
+	}
+	code := map[string]int{"400": 400, "403": 403, "429": 429, "500": 500, "502": 502, "503": 503}[status]
+	http.Error(w, "scripted "+status, code)
+}
+
+func (s *scriptedServer) requests() []attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		apiKey       string
+		responses    []string
+		wantAttempts int
+		wantErr      bool
+		wantLimited  bool // the error is ErrRateLimited
+	}{
+		{name: "429 then success", responses: []string{"429", "200"}, wantAttempts: 2},
+		{name: "429 then success with a key", apiKey: "key", responses: []string{"429", "200"}, wantAttempts: 2},
+		{name: "keyless 403 then success", responses: []string{"403", "200"}, wantAttempts: 2},
+		{name: "403 with a key is a refused key", apiKey: "key", responses: []string{"403"}, wantAttempts: 1, wantErr: true},
+		{name: "server errors retried", responses: []string{"503", "502", "200"}, wantAttempts: 3},
+		{name: "client error not retried", responses: []string{"400"}, wantAttempts: 1, wantErr: true},
+		{name: "still throttled", responses: []string{"429", "403", "429", "429"}, wantAttempts: maxAttempts, wantErr: true, wantLimited: true},
+		{name: "still failing", responses: []string{"500", "500", "500", "500"}, wantAttempts: maxAttempts, wantErr: true},
+		{name: "throttled then failing", responses: []string{"429", "429", "429", "500"}, wantAttempts: maxAttempts, wantErr: true},
+		{name: "success on the last attempt", responses: []string{"429", "500", "429", "200"}, wantAttempts: maxAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &scriptedServer{t: t, responses: tt.responses}
+			c := newTestClient(t, tt.apiKey, server.ServeHTTP)
+			id := PaperID{KindDOI, "10.1/a"}
+			papers, err := c.FetchAbstracts(context.Background(), []PaperID{id})
+
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrRateLimited) != tt.wantLimited {
+				t.Errorf("error = %v, want error %v, rate limited %v", err, tt.wantErr, tt.wantLimited)
+			}
+			if !tt.wantErr && (papers[id] == nil || papers[id].Abstract != "abstract") {
+				t.Errorf("papers = %v, want p1", papers)
+			}
+			attempts := server.requests()
+			if len(attempts) != tt.wantAttempts {
+				t.Fatalf("%d attempts, want %d", len(attempts), tt.wantAttempts)
+			}
+			// Every attempt sends the whole request again, key included.
+			for i, attempt := range attempts {
+				if attempt.apiKey != tt.apiKey || len(attempt.ids) != 1 || attempt.ids[0] != "DOI:10.1/a" {
+					t.Errorf("attempt %d sent key %q and ids %v", i+1, attempt.apiKey, attempt.ids)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		backoff   time.Duration
+		wantWaits []time.Duration // at least, between consecutive attempts
+	}{
+		{"doubles", []string{"429", "503", "200"}, 20 * time.Millisecond, []time.Duration{20 * time.Millisecond, 40 * time.Millisecond}},
+		{"Retry-After in seconds", []string{"429:1", "200"}, time.Millisecond, []time.Duration{time.Second}},
+		{"invalid Retry-After", []string{"429:soon", "200"}, 20 * time.Millisecond, []time.Duration{20 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &scriptedServer{t: t, responses: tt.responses}
+			c := newTestClient(t, "", server.ServeHTTP)
+			WithRetryBackoff(tt.backoff)(c)
+			if _, err := c.FetchAbstracts(context.Background(), []PaperID{{KindDOI, "10.1/a"}}); err != nil {
+				t.Fatalf("FetchAbstracts: %v", err)
+			}
+			attempts := server.requests()
+			if len(attempts) != len(tt.wantWaits)+1 {
+				t.Fatalf("%d attempts, want %d", len(attempts), len(tt.wantWaits)+1)
+			}
+			for i, want := range tt.wantWaits {
+				if waited := attempts[i+1].at.Sub(attempts[i].at); waited < want {
+					t.Errorf("waited %v before attempt %d, want at least %v", waited, i+2, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	server := &scriptedServer{t: t, responses: []string{"429:60"}}
+	c := newTestClient(t, "", server.ServeHTTP)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.FetchAbstracts(ctx, []PaperID{{KindDOI, "10.1/a"}})
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRateLimited) {
+		t.Errorf("error = %v, want the context's", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, want when the context ended", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header   string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"2", 2 * time.Second, 2 * time.Second},
+		{"0", 0, 0},
+		{"-1", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 59 * time.Minute, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.header); got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %v, want %v to %v", tt.header, got, tt.min, tt.max)
+		}
+	}
+	if got := retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)); got > 0 {
+		t.Errorf("retryAfter(an hour ago) = %v, want no wait", got)
+	}
+}
+
+// The default rate depends on whether the client has a key: keyless clients share
+// Semantic Scholar's public pool.
+func TestDefaultRateLimits(t *testing.T) {
+	keyless := float64(time.Second) / DefaultRateLimitWithoutKey
+	tests := []struct {
+		name         string
+		apiKey       string
+		opts         []Option
+		wantInterval time.Duration // between requests once the burst is spent
+	}{
+		{"with a key", "key", nil, time.Second},
+		{"without a key", "", nil, time.Duration(keyless)},
+		{"configured", "", []Option{WithRateLimit(4, 1)}, 250 * time.Millisecond},
+		{"zero rate keeps the default", "key", []Option{WithRateLimit(0, 5)}, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(tt.apiKey, tt.opts...)
+			if ok, _ := c.limiter.Allow(); !ok {
+				t.Fatal("first request has to wait")
+			}
+			ok, wait := c.limiter.Allow()
+			if ok || wait > tt.wantInterval || wait < tt.wantInterval-50*time.Millisecond {
+				t.Errorf("second request waits %v, want %v", wait, tt.wantInterval)
+			}
+		})
+	}
+	if DefaultRateLimitWithoutKey >= DefaultRateLimitWithKey {
+		t.Errorf("keyless rate %v isn't below the keyed rate %v", DefaultRateLimitWithoutKey, DefaultRateLimitWithKey)
+	}
+}
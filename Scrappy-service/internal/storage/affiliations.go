@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// workAffiliationRows groups a work's authorship institutions by institution, in the order
+// they first appear, with the distinct IDs of the authors affiliated there. Authorships and
+// institutions without an ID are skipped.
+func workAffiliationRows(authorships []domain.Authorship) []map[string]any {
+	rows := []map[string]any{}
+	index := map[string]int{}
+	for _, authorship := range authorships {
+		if authorship.Author.ID == "" {
+			continue
+		}
+		for _, inst := range authorship.Institutions {
+			if inst.ID == "" {
+				continue
+			}
+			i, ok := index[inst.ID]
+			if !ok {
+				i = len(rows)
+				index[inst.ID] = i
+				rows = append(rows, map[string]any{"instId": inst.ID, "authorIds": []string{}})
+			}
+			authorIDs := rows[i]["authorIds"].([]string)
+			if !slices.Contains(authorIDs, authorship.Author.ID) {
+				rows[i]["authorIds"] = append(authorIDs, authorship.Author.ID)
+			}
+		}
+	}
+	return rows
+}
+
+// ReconcileWorkAffiliations creates the AFFILIATED_ON_WORK edges of works saved before
+// SaveWork wrote them, from the institutionIds stored on their AUTHORED relationships, and
+// returns how many edges were created. Edges that exist already only get missing author
+// IDs added, so running it again creates nothing. Relationships written before tenants
+// existed carry no tenant and are reconciled with the shared namespace.
+func (r *neo4jRepository) ReconcileWorkAffiliations(ctx context.Context) (int, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	// CALL ... IN TRANSACTIONS needs an auto-commit transaction. Institutions are merged:
+	// SaveWork only stored their IDs before it created Institution nodes, so they get a stub
+	// like SaveWork makes when it has no more than the ID.
+	res, err := session.Run(ctx, `
+		MATCH (a:Author)-[au:AUTHORED]->(w:Work)
+		WHERE coalesce(au.tenant, '') = $tenant AND size(coalesce(au.institutionIds, [])) > 0
+		CALL {
+			WITH a, au, w
+			UNWIND au.institutionIds AS instId
+			MERGE (i:Institution {id: instId, tenant: $tenant})
+			ON CREATE SET `+stampSource("i")+`
+			MERGE (w)-[aw:AFFILIATED_ON_WORK]->(i)
+			SET aw.authorIds = CASE WHEN a.id IN coalesce(aw.authorIds, []) THEN aw.authorIds ELSE coalesce(aw.authorIds, []) + a.id END,
+				aw.tenant = $tenant, `+stampSource("aw")+`
+		} IN TRANSACTIONS OF 1000 ROWS
+	`, map[string]any{"tenant": tenantOf(ctx), "source": SourceOpenAlex})
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile work affiliations: %w", err)
+	}
+	summary, err := res.Consume(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile work affiliations: %w", err)
+	}
+	return summary.Counters().RelationshipsCreated(), nil
+}
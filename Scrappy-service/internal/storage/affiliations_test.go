@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+// A work saved before SaveWork wrote AFFILIATED_ON_WORK edges or Institution nodes has only
+// institutionIds on its AUTHORED relationships, which carry no tenant either.
+func TestReconcileWorkAffiliationsLegacyAuthorships(t *testing.T) {
+	r, _ := newTestRepo(t)
+	ctx, p := newSharedFixture(t, r)
+	query(t, r, ctx, `
+		CREATE (w:Work {id: $p + 'W', tenant: ''}),
+			(:Author {id: $p + 'A1', tenant: ''})-[:AUTHORED {institutionIds: [$p + 'I1', $p + 'I2']}]->(w),
+			(:Author {id: $p + 'A2', tenant: ''})-[:AUTHORED {institutionIds: [$p + 'I1']}]->(w),
+			(:Author {id: $p + 'A3', tenant: ''})-[:AUTHORED]->(w)
+	`, map[string]any{"p": p})
+
+	created, err := r.ReconcileWorkAffiliations(ctx)
+	if err != nil {
+		t.Fatalf("ReconcileWorkAffiliations: %v", err)
+	}
+	if created < 2 {
+		t.Errorf("created %d edges, want at least the work's 2", created)
+	}
+	edges := query(t, r, ctx, `
+		MATCH (:Work {id: $p + 'W'})-[aw:AFFILIATED_ON_WORK]->(i:Institution)
+		RETURN i.id AS inst, i.tenant AS instTenant, i.lastSource AS instSource, aw.tenant AS tenant, aw.authorIds AS authorIds
+		ORDER BY inst
+	`, map[string]any{"p": p})
+	want := []map[string]any{
+		{"inst": p + "I1", "instTenant": "", "instSource": SourceOpenAlex, "tenant": "", "authorIds": []any{p + "A1", p + "A2"}},
+		{"inst": p + "I2", "instTenant": "", "instSource": SourceOpenAlex, "tenant": "", "authorIds": []any{p + "A1"}},
+	}
+	if len(edges) == 2 {
+		// The authorships are matched in no particular order.
+		if ids := edges[0]["authorIds"].([]any); len(ids) == 2 && ids[0] == p+"A2" {
+			ids[0], ids[1] = ids[1], ids[0]
+		}
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("affiliations = %v, want %v", edges, want)
+	}
+
+	// The rollup counts the work now, and running it again creates nothing.
+	rollup, err := r.GetInstitutionWorksRolledUp(ctx, p+"I1", false)
+	if err != nil {
+		t.Fatalf("GetInstitutionWorksRolledUp: %v", err)
+	}
+	if rollup.WorksCount != 1 || rollup.AuthorsCount != 2 {
+		t.Errorf("rollup = %+v, want 1 work by 2 authors", *rollup)
+	}
+	if created, err := r.ReconcileWorkAffiliations(ctx); err != nil || created != 0 {
+		t.Errorf("ReconcileWorkAffiliations again = %d, %v, want 0", created, err)
+	}
+
+	// Another tenant's reconcile leaves the shared works alone.
+	other := newTestTenant(t, r)
+	if _, err := r.ReconcileWorkAffiliations(other); err != nil {
+		t.Fatalf("ReconcileWorkAffiliations for another tenant: %v", err)
+	}
+	if got := query(t, r, other, `MATCH (i:Institution {tenant: $tenant}) RETURN i.id AS id`, nil); len(got) != 0 {
+		t.Errorf("another tenant got institutions %v", got)
+	}
+}
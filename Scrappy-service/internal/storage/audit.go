@@ -0,0 +1,331 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// Ingest event statuses.
+const (
+	IngestStatusRunning   = "running"
+	IngestStatusCompleted = "completed"
+	IngestStatusFailed    = "failed"
+	IngestStatusTimedOut  = "timed_out"
+	// IngestStatusInterrupted is a job the service cancelled while shutting down, once the
+	// drain timeout ran out; it is incomplete and, if resumable, can be resumed.
+	IngestStatusInterrupted = "interrupted"
+)
+
+// IngestEvent is an audit record of a single ingestion, persisted as an
+// (:IngestEvent) node linked to its target with a :TARGETED relationship.
+type IngestEvent struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	TargetID    string    `json:"targetId"`
+	RequestedBy string    `json:"requestedBy"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt,omitempty"`
+	Status      string    `json:"status"`
+	WorksSaved  int       `json:"worksSaved"`
+	WorksFailed int       `json:"worksFailed"`
+	// WorksCreated, WorksUpdated and WorksUnchanged break WorksSaved down by SaveOutcome;
+	// unchanged works were already up to date and not written again.
+	WorksCreated   int `json:"worksCreated"`
+	WorksUpdated   int `json:"worksUpdated"`
+	WorksUnchanged int `json:"worksUnchanged"`
+	// WorksSkipped counts the fetched works the job's filter left out.
+	WorksSkipped int    `json:"worksSkipped"`
+	Error        string `json:"error,omitempty"`
+
+	// DecodeWarnings counts the works OpenAlex returned that didn't decode and were left
+	// out; DecodeWarningSamples has the messages of the first MaxDecodeWarningSamples.
+	DecodeWarnings       int      `json:"decodeWarnings,omitempty"`
+	DecodeWarningSamples []string `json:"decodeWarningSamples,omitempty"`
+
+	// Failures details the first MaxRecordedFailures works that could not be saved.
+	Failures []WorkFailure `json:"failures,omitempty"`
+
+	// Resume is the durable definition of a paged ingestion, which lets it be resumed
+	// after a restart. It is nil for jobs that can't be resumed.
+	Resume *IngestCursor `json:"resume,omitempty"`
+
+	// Tenant is the namespace of the event. It is only filled in by
+	// ListIncompleteIngestEvents, which reads across tenants.
+	Tenant string `json:"-"`
+}
+
+// IngestCursor records how far a paged ingestion got. The counters of the IngestEvent it
+// belongs to are persisted together with it, so they always cover exactly the pages
+// before Cursor.
+type IngestCursor struct {
+	// Filter is the job's work filter, encoded as URL query parameters.
+	Filter string `json:"filter"`
+	// Cursor is the OpenAlex cursor of the next page to fetch; empty once all pages are saved.
+	Cursor string `json:"cursor"`
+	// Pages counts the pages saved so far.
+	Pages int `json:"pages"`
+	// FetchedAt is when the job started fetching, recorded as the author's works sync time.
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// MaxRecordedFailures caps IngestEvent.Failures so a job where every save fails doesn't
+// produce a huge audit record; WorksFailed still counts all of them.
+const MaxRecordedFailures = 20
+
+// MaxDecodeWarningSamples caps IngestEvent.DecodeWarningSamples.
+const MaxDecodeWarningSamples = 5
+
+// WorkFailure describes a work an ingestion could not save.
+type WorkFailure struct {
+	WorkID string `json:"workId"`
+	Title  string `json:"title"`
+	Error  string `json:"error"`
+}
+
+// RecordIngestEvent creates or updates an IngestEvent node. It is called once when a
+// job starts and again when it finishes, so it must be idempotent on the event ID.
+func (r *neo4jRepository) RecordIngestEvent(ctx context.Context, event IngestEvent) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MERGE (e:IngestEvent {id: $id, tenant: $tenant})
+			SET e.kind = $kind,
+				e.targetId = $targetId,
+				e.requestedBy = $requestedBy,
+				e.startedAt = $startedAt,
+				e.finishedAt = $finishedAt,
+				e.status = $status,
+				e.worksSaved = $worksSaved,
+				e.worksFailed = $worksFailed,
+				e.worksSkipped = $worksSkipped,
+				e.worksCreated = $worksCreated,
+				e.worksUpdated = $worksUpdated,
+				e.worksUnchanged = $worksUnchanged,
+				e.error = $error,
+				e.decodeWarnings = $decodeWarnings,
+				e.decodeWarningSamples = $decodeWarningSamples,
+				e.failures = $failures,
+				e.resume = $resume
+			WITH e
+			CALL {
+				MATCH (t:Author {id: $targetId, tenant: $tenant}) RETURN t
+				UNION
+				MATCH (t:Work {id: $targetId, tenant: $tenant}) RETURN t
+				UNION
+				MATCH (t:Institution {id: $targetId, tenant: $tenant}) RETURN t
+			}
+			MERGE (e)-[:TARGETED]->(t)
+		`
+		var finishedAt any
+		if !event.FinishedAt.IsZero() {
+			finishedAt = event.FinishedAt.UTC()
+		}
+		// Neo4j can't store a list of maps, so the failures are kept as a JSON string.
+		var failures any
+		if len(event.Failures) > 0 {
+			encoded, err := json.Marshal(event.Failures)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode ingest failures: %w", err)
+			}
+			failures = string(encoded)
+		}
+		var resume any
+		if event.Resume != nil {
+			encoded, err := json.Marshal(event.Resume)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode ingest cursor: %w", err)
+			}
+			resume = string(encoded)
+		}
+		parameters := map[string]interface{}{
+			"tenant":               tenantOf(ctx),
+			"id":                   event.ID,
+			"kind":                 event.Kind,
+			"targetId":             event.TargetID,
+			"requestedBy":          event.RequestedBy,
+			"startedAt":            event.StartedAt.UTC(),
+			"finishedAt":           finishedAt,
+			"status":               event.Status,
+			"worksSaved":           event.WorksSaved,
+			"worksFailed":          event.WorksFailed,
+			"worksSkipped":         event.WorksSkipped,
+			"worksCreated":         event.WorksCreated,
+			"worksUpdated":         event.WorksUpdated,
+			"worksUnchanged":       event.WorksUnchanged,
+			"error":                event.Error,
+			"decodeWarnings":       event.DecodeWarnings,
+			"decodeWarningSamples": event.DecodeWarningSamples,
+			"failures":             failures,
+			"resume":               resume,
+		}
+		if err := r.exec(ctx, tx, "RecordIngestEvent", query, parameters); err != nil {
+			return nil, fmt.Errorf("failed to save ingest event: %w", err)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// GetIngestHistory returns the ingest events that targeted the given node,
+// most recent first.
+func (r *neo4jRepository) GetIngestHistory(ctx context.Context, targetID string) ([]IngestEvent, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetIngestHistory", `
+			MATCH (e:IngestEvent {targetId: $targetId, tenant: $tenant})
+			RETURN e
+			ORDER BY e.startedAt DESC
+		`, map[string]any{"tenant": tenantOf(ctx), "targetId": targetID})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		events := make([]IngestEvent, 0, len(records))
+		for _, record := range records {
+			node, _, err := neo4j.GetRecordValue[neo4j.Node](record, "e")
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, ingestEventFromProps(node.Props))
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ingest history for %s: %w", targetID, err)
+	}
+	return result.([]IngestEvent), nil
+}
+
+func ingestEventFromProps(props map[string]any) IngestEvent {
+	event := IngestEvent{
+		ID:           stringProp(props, "id"),
+		Kind:         stringProp(props, "kind"),
+		TargetID:     stringProp(props, "targetId"),
+		RequestedBy:  stringProp(props, "requestedBy"),
+		Status:       stringProp(props, "status"),
+		WorksSaved:   intProp(props, "worksSaved"),
+		WorksFailed:  intProp(props, "worksFailed"),
+		WorksSkipped: intProp(props, "worksSkipped"),
+		Error:        stringProp(props, "error"),
+
+		WorksCreated:   intProp(props, "worksCreated"),
+		WorksUpdated:   intProp(props, "worksUpdated"),
+		WorksUnchanged: intProp(props, "worksUnchanged"),
+
+		DecodeWarnings:       intProp(props, "decodeWarnings"),
+		DecodeWarningSamples: stringsProp(props, "decodeWarningSamples"),
+	}
+	if t, ok := props["startedAt"].(time.Time); ok {
+		event.StartedAt = t
+	}
+	if t, ok := props["finishedAt"].(time.Time); ok {
+		event.FinishedAt = t
+	}
+	if encoded := stringProp(props, "failures"); encoded != "" {
+		// A record that can't be decoded just loses its details; the counters remain.
+		_ = json.Unmarshal([]byte(encoded), &event.Failures)
+	}
+	if encoded := stringProp(props, "resume"); encoded != "" {
+		var resume IngestCursor
+		if err := json.Unmarshal([]byte(encoded), &resume); err == nil {
+			event.Resume = &resume
+		}
+	}
+	return event
+}
+
+// GetIngestEvent returns a single ingest event by ID. ErrNotFound means there is none.
+func (r *neo4jRepository) GetIngestEvent(ctx context.Context, id string) (IngestEvent, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetIngestEvent", `
+			MATCH (e:IngestEvent {id: $id, tenant: $tenant})
+			RETURN e
+		`, map[string]any{"tenant": tenantOf(ctx), "id": id})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		node, _, err := neo4j.GetRecordValue[neo4j.Node](records[0], "e")
+		if err != nil {
+			return nil, err
+		}
+		return ingestEventFromProps(node.Props), nil
+	})
+	if err != nil {
+		return IngestEvent{}, fmt.Errorf("failed to read ingest event %s: %w", id, err)
+	}
+	return result.(IngestEvent), nil
+}
+
+// ListIncompleteIngestEvents returns the resumable ingest events of all tenants that are
+// still marked running or were interrupted by a shutdown, i.e. whose process stopped
+// before they finished, oldest first.
+func (r *neo4jRepository) ListIncompleteIngestEvents(ctx context.Context) ([]IngestEvent, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "ListIncompleteIngestEvents", `
+			MATCH (e:IngestEvent)
+			WHERE e.status IN [$running, $interrupted] AND e.resume IS NOT NULL
+			RETURN e
+			ORDER BY e.startedAt
+		`, map[string]any{"running": IngestStatusRunning, "interrupted": IngestStatusInterrupted})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		events := make([]IngestEvent, 0, len(records))
+		for _, record := range records {
+			node, _, err := neo4j.GetRecordValue[neo4j.Node](record, "e")
+			if err != nil {
+				return nil, err
+			}
+			event := ingestEventFromProps(node.Props)
+			event.Tenant = stringProp(node.Props, "tenant")
+			events = append(events, event)
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incomplete ingest events: %w", err)
+	}
+	return result.([]IngestEvent), nil
+}
+
+// stringProp reads a string property from a node, returning "" when it is absent.
+func stringProp(props map[string]any, key string) string {
+	s, _ := props[key].(string)
+	return s
+}
+
+// intProp reads an integer property from a node, returning 0 when it is absent.
+func intProp(props map[string]any, key string) int {
+	n, _ := props[key].(int64)
+	return int(n)
+}
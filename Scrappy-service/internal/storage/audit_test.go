@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIngestEventCursorRoundTrip(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	other := newTestTenant(t, r)
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	cursor := func(next string, pages int) *IngestCursor {
+		return &IngestCursor{Filter: "skip_existing=true", Cursor: next, Pages: pages, FetchedAt: started}
+	}
+
+	events := []struct {
+		event   IngestEvent
+		inOther bool
+	}{
+		{event: IngestEvent{ID: "running", Kind: "author", TargetID: "A1", StartedAt: started, Status: IngestStatusRunning,
+			WorksSaved: 50, WorksCreated: 40, WorksUpdated: 10, Resume: cursor("c2", 1)}},
+		{event: IngestEvent{ID: "interrupted", Kind: "author", TargetID: "A2", StartedAt: started.Add(time.Minute), Status: IngestStatusInterrupted,
+			WorksSaved: 100, Resume: cursor("c3", 2)}, inOther: true},
+		{event: IngestEvent{ID: "completed", Kind: "author", TargetID: "A3", StartedAt: started, Status: IngestStatusCompleted,
+			Resume: cursor("", 3)}},
+		{event: IngestEvent{ID: "timed-out", Kind: "author", TargetID: "A4", StartedAt: started, Status: IngestStatusTimedOut,
+			Resume: cursor("c2", 1)}},
+		{event: IngestEvent{ID: "unpaged", Kind: "venue", TargetID: "S1", StartedAt: started, Status: IngestStatusRunning}},
+	}
+	for _, e := range events {
+		eventCtx := ctx
+		if e.inOther {
+			eventCtx = other
+		}
+		if err := r.RecordIngestEvent(eventCtx, e.event); err != nil {
+			t.Fatalf("RecordIngestEvent(%s): %v", e.event.ID, err)
+		}
+	}
+
+	got, err := r.GetIngestEvent(ctx, "running")
+	if err != nil {
+		t.Fatalf("GetIngestEvent: %v", err)
+	}
+	if !reflect.DeepEqual(got.Resume, cursor("c2", 1)) || got.WorksSaved != 50 || got.WorksCreated != 40 || got.WorksUpdated != 10 {
+		t.Errorf("event = %+v (cursor %+v), want the stored counters and cursor", got, got.Resume)
+	}
+	if _, err := r.GetIngestEvent(ctx, "interrupted"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("event of another tenant: error = %v, want ErrNotFound", err)
+	}
+
+	incomplete, err := r.ListIncompleteIngestEvents(ctx)
+	if err != nil {
+		t.Fatalf("ListIncompleteIngestEvents: %v", err)
+	}
+	tenants := map[string]string{}
+	for _, event := range incomplete {
+		if event.Tenant == tenantOf(ctx) || event.Tenant == tenantOf(other) {
+			tenants[event.ID] = event.Tenant
+		}
+	}
+	want := map[string]string{"running": tenantOf(ctx), "interrupted": tenantOf(other)}
+	if !reflect.DeepEqual(tenants, want) {
+		t.Errorf("incomplete events = %v, want %v", tenants, want)
+	}
+}
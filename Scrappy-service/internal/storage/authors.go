@@ -0,0 +1,425 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// UnknownCountry is the bucket for collaborations whose institution country is not known.
+const UnknownCountry = "unknown"
+
+// CountCollaborationsByCountry groups an author's coauthors' institutions by country code
+// and counts the distinct works shared with each country. Coauthors without a known
+// institution country are counted under UnknownCountry.
+func (r *neo4jRepository) CountCollaborationsByCountry(ctx context.Context, authorID string) (map[string]int, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "CountCollaborationsByCountry", `
+			MATCH (a:Author {id: $authorId, tenant: $tenant})-[:AUTHORED]->(w:Work)<-[r:AUTHORED]-(co:Author)
+			WHERE co <> a
+			UNWIND CASE WHEN size(coalesce(r.institutionIds, [])) = 0 THEN [null]
+				ELSE r.institutionIds END AS instId
+			OPTIONAL MATCH (i:Institution {id: instId, tenant: $tenant})
+			WITH w, CASE WHEN i.countryCode IS NULL OR i.countryCode = '' THEN $unknown
+				ELSE toUpper(i.countryCode) END AS country
+			RETURN country, count(DISTINCT w) AS works
+		`, map[string]any{"tenant": tenantOf(ctx), "authorId": authorID, "unknown": UnknownCountry})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		counts := make(map[string]int, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			counts[stringProp(props, "country")] = intProp(props, "works")
+		}
+		return counts, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count collaborations for author %s: %w", authorID, err)
+	}
+	return result.(map[string]int), nil
+}
+
+// UnknownWorkType is the bucket for works saved without a type, e.g. before types were stored.
+const UnknownWorkType = "unknown"
+
+// GetAuthorWorkTypeBreakdown counts the author's works by their OpenAlex type (article,
+// book-chapter, ...), in one aggregation. Works without a stored type are counted under
+// UnknownWorkType. An author without works, or not in the graph, gets an empty map.
+func (r *neo4jRepository) GetAuthorWorkTypeBreakdown(ctx context.Context, authorID string) (map[string]int, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetAuthorWorkTypeBreakdown", `
+			MATCH (:Author {id: $authorId, tenant: $tenant})-[:AUTHORED]->(w:Work)
+			WITH CASE WHEN w.type IS NULL OR w.type = '' THEN $unknown ELSE w.type END AS type, w
+			RETURN type, count(DISTINCT w) AS works
+		`, map[string]any{"tenant": tenantOf(ctx), "authorId": authorID, "unknown": UnknownWorkType})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		counts := make(map[string]int, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			counts[stringProp(props, "type")] = intProp(props, "works")
+		}
+		return counts, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count work types for author %s: %w", authorID, err)
+	}
+	return result.(map[string]int), nil
+}
+
+// SSAuthorEnrichment holds author metrics taken from Semantic Scholar.
+type SSAuthorEnrichment struct {
+	SSAuthorID   string
+	SSHIndex     int
+	SSPaperCount int
+	SSHomepage   string
+}
+
+// SaveAuthorSSEnrichment stores Semantic Scholar metrics on an existing Author node as
+// ssAuthorId, ssHIndex, ssPaperCount and ssHomepage. It returns ErrNotFound if the author
+// has not been ingested.
+func (r *neo4jRepository) SaveAuthorSSEnrichment(ctx context.Context, authorID string, e SSAuthorEnrichment) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "SaveAuthorSSEnrichment", `
+			MATCH (a:Author {id: $id, tenant: $tenant})
+			SET a.ssAuthorId = $ssAuthorId,
+				a.ssHIndex = $ssHIndex,
+				a.ssPaperCount = $ssPaperCount,
+				a.ssHomepage = $ssHomepage,
+				a.ssEnrichedAt = datetime(),
+				`+stampSource("a")+`
+			RETURN count(a) AS updated
+		`, map[string]any{
+			"tenant":       tenantOf(ctx),
+			"source":       SourceSemanticScholar,
+			"id":           authorID,
+			"ssAuthorId":   e.SSAuthorID,
+			"ssHIndex":     e.SSHIndex,
+			"ssPaperCount": e.SSPaperCount,
+			"ssHomepage":   e.SSHomepage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return intProp(record.AsMap(), "updated"), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save Semantic Scholar enrichment for author %s: %w", authorID, err)
+	}
+	if result.(int) == 0 {
+		return fmt.Errorf("author %s: %w", authorID, ErrNotFound)
+	}
+	return nil
+}
+
+// hIndexSubquery computes the h-index of the Author bound to a from the citedByCount of
+// their works: with the counts sorted in descending order, h is the number of positions i
+// (from 0) whose count is at least i+1. The sorting and counting happen in the database,
+// so authors with thousands of works never have them loaded into Go. Yields
+// computedHIndex and worksInGraph.
+const hIndexSubquery = `
+	CALL {
+		WITH a
+		OPTIONAL MATCH (a)-[:AUTHORED]->(w:Work)
+		WITH w, coalesce(w.citedByCount, 0) AS cited
+		ORDER BY cited DESC
+		WITH collect(cited) AS citations, count(w) AS worksInGraph
+		RETURN size([i IN range(0, size(citations) - 1) WHERE citations[i] >= i + 1]) AS computedHIndex,
+			worksInGraph
+	}`
+
+// ComputeHIndex computes an author's h-index from the citedByCount of the works ingested
+// for them, which can be lower than OpenAlex's value if not every work has been ingested.
+// It returns ErrNotFound if the author is not in the graph.
+func (r *neo4jRepository) ComputeHIndex(ctx context.Context, authorID string) (int, error) {
+	drift, err := r.GetHIndexDrift(ctx, authorID)
+	if err != nil {
+		return 0, err
+	}
+	return drift.ComputedHIndex, nil
+}
+
+// HIndexDrift compares the h-index OpenAlex reports for an author with the one computed
+// from their works in the graph.
+type HIndexDrift struct {
+	AuthorID string `json:"id"`
+	// OpenAlexHIndex is OpenAlex's summary_stats value as of the last save of the author;
+	// nil for authors saved before it was stored.
+	OpenAlexHIndex *int `json:"openAlexHIndex"`
+	ComputedHIndex int  `json:"computedHIndex"`
+	// Delta is OpenAlexHIndex minus ComputedHIndex; nil when OpenAlexHIndex is.
+	Delta         *int `json:"delta"`
+	WorksInGraph  int  `json:"worksInGraph"`
+	OpenAlexWorks int  `json:"openAlexWorks"`
+}
+
+// GetHIndexDrift returns the stored OpenAlex h-index of an author next to the one computed
+// from the graph. It returns ErrNotFound if the author is not in the graph.
+func (r *neo4jRepository) GetHIndexDrift(ctx context.Context, authorID string) (*HIndexDrift, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetHIndexDrift", `
+			MATCH (a:Author {id: $id, tenant: $tenant})`+hIndexSubquery+`
+			RETURN a.hIndex AS openAlexHIndex, a.worksCount AS openAlexWorks, computedHIndex, worksInGraph
+		`, map[string]any{"tenant": tenantOf(ctx), "id": authorID})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		props := records[0].AsMap()
+		drift := &HIndexDrift{
+			AuthorID:       authorID,
+			ComputedHIndex: intProp(props, "computedHIndex"),
+			WorksInGraph:   intProp(props, "worksInGraph"),
+			OpenAlexWorks:  intProp(props, "openAlexWorks"),
+		}
+		if _, ok := props["openAlexHIndex"].(int64); ok {
+			stored := intProp(props, "openAlexHIndex")
+			delta := stored - drift.ComputedHIndex
+			drift.OpenAlexHIndex, drift.Delta = &stored, &delta
+		}
+		return drift, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute h-index of author %s: %w", authorID, err)
+	}
+	return result.(*HIndexDrift), nil
+}
+
+// CountHIndexDrift counts the fully ingested authors, across all tenants, whose computed
+// h-index differs from OpenAlex's by more than threshold. Such a gap usually means works
+// are missing from the graph.
+func (r *neo4jRepository) CountHIndexDrift(ctx context.Context, threshold int) (int, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "CountHIndexDrift", `
+			MATCH (a:Author)
+			WHERE a.fullyIngested = true AND a.hIndex IS NOT NULL`+hIndexSubquery+`
+			WITH a, computedHIndex
+			WHERE abs(a.hIndex - computedHIndex) > $threshold
+			RETURN count(a) AS drifted
+		`, map[string]any{"threshold": threshold})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return intProp(record.AsMap(), "drifted"), nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count h-index drift: %w", err)
+	}
+	return result.(int), nil
+}
+
+// AuthorExists reports whether an Author node with the given id is in the graph.
+func (r *neo4jRepository) AuthorExists(ctx context.Context, id string) (bool, error) {
+	return r.nodeExists(ctx, `MATCH (a:Author {id: $id, tenant: $tenant}) RETURN count(*) > 0 AS found`, id)
+}
+
+// nodeExists runs a single-node existence query that takes $id and returns a boolean "found".
+func (r *neo4jRepository) nodeExists(ctx context.Context, query, id string) (bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "nodeExists", query, map[string]any{"tenant": tenantOf(ctx), "id": id})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		found, _ := record.AsMap()["found"].(bool)
+		return found, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether %s exists: %w", id, err)
+	}
+	return result.(bool), nil
+}
+
+// SetAuthorWorksSynced records that the author's works were fetched from OpenAlex at at, as
+// lastWorksSync. Incremental syncs ask OpenAlex only for works updated since then.
+func (r *neo4jRepository) SetAuthorWorksSynced(ctx context.Context, authorID string, at time.Time) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		err := r.exec(ctx, tx, "SetAuthorWorksSynced", `
+			MATCH (a:Author {id: $id, tenant: $tenant})
+			SET a.lastWorksSync = $at
+		`, map[string]any{"tenant": tenantOf(ctx), "id": authorID, "at": at.UTC()})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record works sync of author %s: %w", authorID, err)
+	}
+	return nil
+}
+
+// GetAuthorWorksSynced returns the author's lastWorksSync, or the zero time if their works
+// were never fully fetched. It returns ErrNotFound if the author is not in the graph.
+func (r *neo4jRepository) GetAuthorWorksSynced(ctx context.Context, authorID string) (time.Time, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetAuthorWorksSynced", `
+			MATCH (a:Author {id: $id, tenant: $tenant})
+			RETURN a.lastWorksSync AS lastWorksSync
+		`, map[string]any{"tenant": tenantOf(ctx), "id": authorID})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		synced, _ := records[0].AsMap()["lastWorksSync"].(time.Time)
+		return synced, nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read works sync of author %s: %w", authorID, err)
+	}
+	return result.(time.Time), nil
+}
+
+// AuthorMatch is an author found by SearchAuthors, with the full-text relevance score.
+type AuthorMatch struct {
+	ID                      string   `json:"id"`
+	DisplayName             string   `json:"displayName"`
+	DisplayNameAlternatives []string `json:"displayNameAlternatives"`
+	Orcid                   string   `json:"orcid,omitempty"`
+	WorksCount              int      `json:"worksCount"`
+	CitedByCount            int      `json:"citedByCount"`
+	Score                   float64  `json:"score"`
+}
+
+// SearchAuthors looks authors up in the graph by name through the author_names full-text
+// index, which covers displayName and the alternative names (aliases, transliterations),
+// best match first. Every term of the query must match, as a prefix for the last one, so
+// the search also works while typing.
+func (r *neo4jRepository) SearchAuthors(ctx context.Context, query string, limit int) ([]AuthorMatch, error) {
+	search := fulltextQuery(query)
+	if search == "" {
+		return []AuthorMatch{}, nil
+	}
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "SearchAuthors", `
+			CALL db.index.fulltext.queryNodes('author_names', $search) YIELD node AS a, score
+			WHERE a.tenant = $tenant
+			RETURN a.id AS id, a.displayName AS displayName,
+				coalesce(a.displayNameAlternatives, []) AS displayNameAlternatives,
+				a.orcid AS orcid, a.worksCount AS worksCount, a.citedByCount AS citedByCount, score
+			ORDER BY score DESC, citedByCount DESC
+			LIMIT $limit
+		`, map[string]any{"tenant": tenantOf(ctx), "search": search, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		matches := make([]AuthorMatch, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			score, _ := props["score"].(float64)
+			matches = append(matches, AuthorMatch{
+				ID:                      stringProp(props, "id"),
+				DisplayName:             stringProp(props, "displayName"),
+				DisplayNameAlternatives: stringsProp(props, "displayNameAlternatives"),
+				Orcid:                   stringProp(props, "orcid"),
+				WorksCount:              intProp(props, "worksCount"),
+				CitedByCount:            intProp(props, "citedByCount"),
+				Score:                   score,
+			})
+		}
+		return matches, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search authors for %q: %w", query, err)
+	}
+	return result.([]AuthorMatch), nil
+}
+
+// fulltextQuery turns free text into a Lucene query requiring every term, with the last
+// term matched as a prefix. Lucene operators in the input are escaped, so names like
+// "O'Brien-Smith" or "Smith, J." search literally.
+func fulltextQuery(text string) string {
+	terms := strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';'
+	})
+	parts := make([]string, 0, len(terms))
+	for i, term := range terms {
+		// Prefix terms bypass the analyzer, so they are lowercased and stripped of
+		// punctuation ("J." is indexed as "j") by hand.
+		term = strings.TrimFunc(term, unicode.IsPunct)
+		if term == "" {
+			continue
+		}
+		term = luceneSpecial.Replace(term)
+		if i == len(terms)-1 {
+			term = strings.ToLower(term) + "*"
+		}
+		parts = append(parts, "+"+term)
+	}
+	return strings.Join(parts, " ")
+}
+
+// luceneSpecial escapes the characters Lucene's query parser treats as syntax.
+var luceneSpecial = strings.NewReplacer(
+	`\`, `\\`, `+`, `\+`, `-`, `\-`, `!`, `\!`, `(`, `\(`, `)`, `\)`, `:`, `\:`, `^`, `\^`,
+	`[`, `\[`, `]`, `\]`, `"`, `\"`, `{`, `\{`, `}`, `\}`, `~`, `\~`, `*`, `\*`, `?`, `\?`,
+	`|`, `\|`, `&`, `\&`, `/`, `\/`,
+)
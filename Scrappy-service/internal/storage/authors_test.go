@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func authorship(authorID string, institutions ...domain.DehydratedInstitution) domain.Authorship {
+	return domain.Authorship{Author: domain.DehydratedAuthor{ID: authorID, DisplayName: authorID}, Institutions: institutions}
+}
+
+func TestCountCollaborationsByCountry(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	de := domain.DehydratedInstitution{ID: "I1", DisplayName: "Berlin", CountryCode: "de"}
+	us := domain.DehydratedInstitution{ID: "I2", DisplayName: "Boston", CountryCode: "US"}
+	nowhere := domain.DehydratedInstitution{ID: "I3", DisplayName: "Nowhere"}
+	works := []domain.Work{
+		{ID: "W1", Title: "one", Authorships: []domain.Authorship{authorship("A1"), authorship("A2", de)}},
+		{ID: "W2", Title: "two", Authorships: []domain.Authorship{authorship("A1"), authorship("A3", us), authorship("A2", de)}},
+		{ID: "W3", Title: "alone", Authorships: []domain.Authorship{authorship("A1", us)}},
+		{ID: "W4", Title: "unknown", Authorships: []domain.Authorship{authorship("A1"), authorship("A4", nowhere), authorship("A5")}},
+		{ID: "W5", Title: "elsewhere", Authorships: []domain.Authorship{authorship("A2", de), authorship("A3", us)}},
+	}
+	for _, work := range works {
+		if _, err := r.SaveWork(ctx, work, SaveOptions{}); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	tests := []struct {
+		authorID string
+		want     map[string]int
+	}{
+		// The author's own institution doesn't count, and a work counts once per country
+		// however many coauthors it has there.
+		{"A1", map[string]int{"DE": 2, "US": 1, UnknownCountry: 1}},
+		{"A3", map[string]int{"DE": 2, UnknownCountry: 1}},
+		{"A9", map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.authorID, func(t *testing.T) {
+			got, err := r.CountCollaborationsByCountry(ctx, tt.authorID)
+			if err != nil {
+				t.Fatalf("CountCollaborationsByCountry: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorWorksSynced(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	if _, err := r.GetAuthorWorksSynced(ctx, "A1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("author not in the graph: err = %v, want ErrNotFound", err)
+	}
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W1", Authorships: []domain.Authorship{authorship("A1")}}, FullSave); err != nil {
+		t.Fatal(err)
+	}
+	if synced, err := r.GetAuthorWorksSynced(ctx, "A1"); err != nil || !synced.IsZero() {
+		t.Errorf("never synced: %v, %v; want the zero time", synced, err)
+	}
+
+	at := time.Date(2024, 3, 1, 13, 30, 0, 0, time.FixedZone("CET", 3600))
+	if err := r.SetAuthorWorksSynced(ctx, "A1", at); err != nil {
+		t.Fatal(err)
+	}
+	synced, err := r.GetAuthorWorksSynced(ctx, "A1")
+	if err != nil || !synced.Equal(at) {
+		t.Errorf("synced = %v, %v; want %v", synced, err, at)
+	}
+}
+
+func TestGetHIndexDrift(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	tests := []struct {
+		name           string
+		citations      []any // citedByCount of each work; nil leaves it unset
+		openAlexHIndex any   // nil for an author saved before it was stored
+		wantComputed   int
+	}{
+		{name: "no works", openAlexHIndex: 3, wantComputed: 0},
+		{name: "textbook", citations: []any{10, 8, 5, 4, 3}, openAlexHIndex: 4, wantComputed: 4},
+		{name: "unsorted", citations: []any{3, 10, 4, 8, 5}, openAlexHIndex: 4, wantComputed: 4},
+		{name: "never cited", citations: []any{0, 0, 0}, openAlexHIndex: 0, wantComputed: 0},
+		{name: "one blockbuster", citations: []any{1000}, openAlexHIndex: 1, wantComputed: 1},
+		{name: "equal counts", citations: []any{3, 3, 3, 3, 3}, openAlexHIndex: 3, wantComputed: 3},
+		{name: "counts equal to rank", citations: []any{1, 2, 3}, openAlexHIndex: 2, wantComputed: 2},
+		{name: "missing counts are zero", citations: []any{5, nil, 5, nil}, openAlexHIndex: 2, wantComputed: 2},
+		{name: "incomplete ingest", citations: []any{9, 9}, openAlexHIndex: 7, wantComputed: 2},
+		{name: "no stored h-index", citations: []any{4, 4, 4}, wantComputed: 3},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorID := fmt.Sprintf("A%d", i+1)
+			query(t, r, ctx, `
+				CREATE (a:Author {id: $id, tenant: $tenant, hIndex: $hIndex, worksCount: 10})
+				WITH a
+				UNWIND range(0, size($citations) - 1) AS i
+				CREATE (a)-[:AUTHORED]->(:Work {id: $id + '-W' + i, tenant: $tenant, citedByCount: $citations[i]})
+			`, map[string]any{"id": authorID, "hIndex": tt.openAlexHIndex, "citations": append([]any{}, tt.citations...)})
+
+			drift, err := r.GetHIndexDrift(ctx, authorID)
+			if err != nil {
+				t.Fatalf("GetHIndexDrift: %v", err)
+			}
+			if drift.ComputedHIndex != tt.wantComputed || drift.WorksInGraph != len(tt.citations) || drift.OpenAlexWorks != 10 {
+				t.Errorf("drift = %+v, want h-index %d from %d works of 10", drift, tt.wantComputed, len(tt.citations))
+			}
+			if tt.openAlexHIndex == nil {
+				if drift.OpenAlexHIndex != nil || drift.Delta != nil {
+					t.Errorf("OpenAlex h-index %v, delta %v; want both unset", drift.OpenAlexHIndex, drift.Delta)
+				}
+			} else if stored := tt.openAlexHIndex.(int); drift.OpenAlexHIndex == nil || *drift.OpenAlexHIndex != stored ||
+				drift.Delta == nil || *drift.Delta != stored-tt.wantComputed {
+				t.Errorf("drift = %+v, want OpenAlex h-index %d and delta %d", drift, stored, stored-tt.wantComputed)
+			}
+
+			computed, err := r.ComputeHIndex(ctx, authorID)
+			if err != nil || computed != tt.wantComputed {
+				t.Errorf("ComputeHIndex = %d, %v; want %d", computed, err, tt.wantComputed)
+			}
+		})
+	}
+
+	if _, err := r.GetHIndexDrift(ctx, "A404"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetHIndexDrift(A404) error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.ComputeHIndex(ctx, "A404"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ComputeHIndex(A404) error = %v, want ErrNotFound", err)
+	}
+}
+
+// authorTopics returns the paper counts of an author's HAS_TOPIC edges by topic id.
+func authorTopics(t *testing.T, r *neo4jRepository, ctx context.Context, authorID string) map[string]int {
+	t.Helper()
+	topics := map[string]int{}
+	for _, record := range query(t, r, ctx, `
+		MATCH (:Author {id: $id, tenant: $tenant})-[h:HAS_TOPIC]->(t:Topic)
+		RETURN t.id AS id, h.paperCount AS count
+	`, map[string]any{"id": authorID}) {
+		topics[record["id"].(string)] = intProp(record, "count")
+	}
+	return topics
+}
+
+func TestSaveAuthorReconcilesTopics(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	prefix := "T-" + tenantOf(ctx)
+	cleanTopics(t, r, ctx, prefix)
+	topic := func(n, count int) domain.Topic {
+		return domain.Topic{ID: fmt.Sprintf("%s-%d", prefix, n), DisplayName: fmt.Sprint("topic ", n), Count: count}
+	}
+	id := func(n int) string { return fmt.Sprintf("%s-%d", prefix, n) }
+
+	steps := []struct {
+		name   string
+		topics []domain.Topic
+		want   map[string]int
+	}{
+		{"full fetch", []domain.Topic{topic(1, 5), topic(2, 3), topic(3, 1)}, map[string]int{id(1): 5, id(2): 3, id(3): 1}},
+		// A fetch without topics (select= left them out) keeps the stored ones.
+		{"partial fetch", nil, map[string]int{id(1): 5, id(2): 3, id(3): 1}},
+		{"fewer topics", []domain.Topic{topic(1, 6), topic(4, 2)}, map[string]int{id(1): 6, id(4): 2}},
+		{"partial fetch again", nil, map[string]int{id(1): 6, id(4): 2}},
+		// An empty list is a fetch that included topics and found none.
+		{"no topics left", []domain.Topic{}, map[string]int{}},
+	}
+	for _, step := range steps {
+		author := domain.Author{ID: "A1", DisplayName: "Ada", Topics: step.topics}
+		if err := r.SaveAuthor(ctx, author); err != nil {
+			t.Fatalf("%s: SaveAuthor: %v", step.name, err)
+		}
+		if got := authorTopics(t, r, ctx, "A1"); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: topics = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	// In a batch, each author's set is reconciled on its own.
+	for _, author := range []domain.Author{
+		{ID: "A2", Topics: []domain.Topic{topic(1, 1), topic(2, 1)}},
+		{ID: "A3", Topics: []domain.Topic{topic(2, 1), topic(3, 1)}},
+	} {
+		if err := r.SaveAuthor(ctx, author); err != nil {
+			t.Fatalf("SaveAuthor(%s): %v", author.ID, err)
+		}
+	}
+	if err := r.SaveAuthors(ctx, []domain.Author{{ID: "A2", Topics: []domain.Topic{topic(2, 4)}}, {ID: "A3"}}); err != nil {
+		t.Fatalf("SaveAuthors: %v", err)
+	}
+	if got, want := authorTopics(t, r, ctx, "A2"), map[string]int{id(2): 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("A2 topics = %v, want %v", got, want)
+	}
+	if got, want := authorTopics(t, r, ctx, "A3"), map[string]int{id(2): 1, id(3): 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("A3 topics = %v, want %v kept", got, want)
+	}
+}
+
+func TestSaveAuthorKeepsTopicsWhenNotPersisting(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	prefix := "T-" + tenantOf(ctx)
+	cleanTopics(t, r, ctx, prefix)
+
+	if err := r.SaveAuthor(ctx, domain.Author{ID: "A1", Topics: []domain.Topic{{ID: prefix + "-1", Count: 2}}}); err != nil {
+		t.Fatalf("SaveAuthor: %v", err)
+	}
+	r.persistTopics = false
+	t.Cleanup(func() { r.persistTopics = true })
+	if err := r.SaveAuthor(ctx, domain.Author{ID: "A1", Topics: []domain.Topic{{ID: prefix + "-2", Count: 1}}}); err != nil {
+		t.Fatalf("SaveAuthor: %v", err)
+	}
+	if got, want := authorTopics(t, r, ctx, "A1"), map[string]int{prefix + "-1": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("topics = %v, want %v untouched with PERSIST_TOPICS=false", got, want)
+	}
+}
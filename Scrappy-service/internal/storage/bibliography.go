@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// GetWorksForExport reads what a bibliography entry needs of each work in ids: title,
+// type, year, date, DOI, abstract, volume, issue and pages, its venue as the primary
+// location's source, and its authors in authorship order. Works are returned in the order
+// of ids; ids not in the graph are left out.
+func (r *neo4jRepository) GetWorksForExport(ctx context.Context, ids []string) ([]domain.Work, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// Authorships saved before their order was recorded fall back to first, middle,
+		// last, then name.
+		res, err := r.run(ctx, tx, "GetWorksForExport", `
+			UNWIND range(0, size($ids) - 1) AS i
+			MATCH (w:Work {id: $ids[i], tenant: $tenant})
+			OPTIONAL MATCH (w)-[:PUBLISHED_IN]->(v:Venue)
+			WITH i, w, collect(v.displayName)[0] AS venue
+			OPTIONAL MATCH (a:Author)-[au:AUTHORED]->(w)
+			WITH i, w, venue, a, au
+			ORDER BY i, au.order, CASE au.position WHEN 'first' THEN 0 WHEN 'last' THEN 2 ELSE 1 END, a.displayName
+			WITH i, w, venue, collect(CASE WHEN a IS NULL THEN NULL ELSE {id: a.id, name: a.displayName} END) AS authors
+			RETURN w.id AS id, w.title AS title, w.type AS type, w.doi AS doi,
+				w.publicationYear AS publicationYear, w.publicationDate AS publicationDate,
+				w.abstract AS abstract, w.volume AS volume, w.issue AS issue,
+				w.firstPage AS firstPage, w.lastPage AS lastPage, venue, authors
+			ORDER BY i
+		`, map[string]any{"tenant": tenantOf(ctx), "ids": ids})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		works := make([]domain.Work, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			work := domain.Work{
+				ID:              stringProp(props, "id"),
+				Title:           stringProp(props, "title"),
+				Type:            stringProp(props, "type"),
+				Doi:             stringProp(props, "doi"),
+				PublicationYear: intProp(props, "publicationYear"),
+				PublicationDate: dateProp(props, "publicationDate"),
+				Abstract:        stringProp(props, "abstract"),
+				Biblio: domain.Biblio{
+					Volume:    stringProp(props, "volume"),
+					Issue:     stringProp(props, "issue"),
+					FirstPage: stringProp(props, "firstPage"),
+					LastPage:  stringProp(props, "lastPage"),
+				},
+			}
+			if venue := stringProp(props, "venue"); venue != "" {
+				work.PrimaryLocation = &domain.Location{Source: &domain.Source{DisplayName: venue}}
+			}
+			authors, _ := props["authors"].([]any)
+			for _, raw := range authors {
+				author, _ := raw.(map[string]any)
+				work.Authorships = append(work.Authorships, domain.Authorship{
+					Author: domain.DehydratedAuthor{ID: stringProp(author, "id"), DisplayName: stringProp(author, "name")},
+				})
+			}
+			works = append(works, work)
+		}
+		return works, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read works for export: %w", err)
+	}
+	return result.([]domain.Work), nil
+}
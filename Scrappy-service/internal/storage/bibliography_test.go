@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestGetWorksForExport(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	author := func(id, name string) domain.Authorship {
+		return domain.Authorship{Author: domain.DehydratedAuthor{ID: id, DisplayName: name}}
+	}
+	// Authors in an order neither their IDs nor their names sort into.
+	paper := domain.Work{
+		ID: "W1", Title: "On {Braces} & 100% Ümlauts", Type: "article", Doi: "https://doi.org/10.1/ABC",
+		PublicationYear: 2023, PublicationDate: "2023-05-04", Abstract: "An abstract.",
+		Biblio:          domain.Biblio{Volume: "12", Issue: "3", FirstPage: "101", LastPage: "110"},
+		PrimaryLocation: &domain.Location{Source: &domain.Source{ID: "S1", DisplayName: "Journal of Tests"}},
+		Authorships:     []domain.Authorship{author("A3", "Doe, Jane"), author("A1", "Zed Zimmer"), author("A2", "Amy Adams")},
+	}
+	bare := domain.Work{ID: "W2", Title: "Bare", Authorships: []domain.Authorship{author("A1", "Zed Zimmer")}}
+	for _, work := range []domain.Work{paper, bare} {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	got, err := r.GetWorksForExport(ctx, []string{"W2", "W404", "W1"})
+	if err != nil {
+		t.Fatalf("GetWorksForExport: %v", err)
+	}
+	want := []domain.Work{
+		{ID: "W2", Title: "Bare", Authorships: []domain.Authorship{author("A1", "Zed Zimmer")}},
+		{
+			ID: "W1", Title: paper.Title, Type: "article", Doi: paper.Doi, PublicationYear: 2023, PublicationDate: "2023-05-04",
+			Abstract: "An abstract.", Biblio: paper.Biblio,
+			PrimaryLocation: &domain.Location{Source: &domain.Source{DisplayName: "Journal of Tests"}},
+			Authorships:     paper.Authorships,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetWorksForExport =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got, err := r.GetWorksForExport(newTestTenant(t, r), []string{"W1"}); err != nil || len(got) != 0 {
+		t.Errorf("GetWorksForExport for another tenant = %+v, %v, want nothing", got, err)
+	}
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// BlockEntity puts an OpenAlex ID on the blocklist, stored as a (:Blocked {id, reason, at})
+// node. Ingestion of blocked IDs is refused. Blocking an already blocked ID updates the reason.
+func (r *neo4jRepository) BlockEntity(ctx context.Context, id, reason string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		err := r.exec(ctx, tx, "BlockEntity", `
+			MERGE (b:Blocked {id: $id, tenant: $tenant})
+			SET b.reason = $reason, b.at = datetime()
+		`, map[string]any{"tenant": tenantOf(ctx), "id": id, "reason": reason})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to block %s: %w", id, err)
+	}
+	return nil
+}
+
+// UnblockEntity removes an ID from the blocklist. It returns ErrNotFound if it wasn't blocked.
+func (r *neo4jRepository) UnblockEntity(ctx context.Context, id string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "UnblockEntity", `
+			OPTIONAL MATCH (b:Blocked {id: $id, tenant: $tenant})
+			DELETE b
+			RETURN count(b) AS removed
+		`, map[string]any{"tenant": tenantOf(ctx), "id": id})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return intProp(record.AsMap(), "removed"), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unblock %s: %w", id, err)
+	}
+	if result.(int) == 0 {
+		return fmt.Errorf("%s is not blocked: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// IsBlocked reports whether id is on the blocklist, and why.
+func (r *neo4jRepository) IsBlocked(ctx context.Context, id string) (bool, string, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "IsBlocked", `
+			MATCH (b:Blocked {id: $id, tenant: $tenant})
+			RETURN b.reason AS reason
+		`, map[string]any{"tenant": tenantOf(ctx), "id": id})
+		if err != nil {
+			return nil, err
+		}
+		return res.Collect(ctx)
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check blocklist for %s: %w", id, err)
+	}
+	records := result.([]*neo4j.Record)
+	if len(records) == 0 {
+		return false, "", nil
+	}
+	return true, stringProp(records[0].AsMap(), "reason"), nil
+}
+
+// DeleteAuthor removes an Author node and its relationships. The author's works stay in
+// the graph, as they usually have other authors too. It returns ErrNotFound if the author
+// is not in the graph.
+func (r *neo4jRepository) DeleteAuthor(ctx context.Context, id string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "DeleteAuthor", `
+			OPTIONAL MATCH (a:Author {id: $id, tenant: $tenant})
+			DETACH DELETE a
+			RETURN count(a) AS removed
+		`, map[string]any{"tenant": tenantOf(ctx), "id": id})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return intProp(record.AsMap(), "removed"), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete author %s: %w", id, err)
+	}
+	if result.(int) == 0 {
+		return fmt.Errorf("author %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestBlocklist(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	other := newTestTenant(t, r)
+
+	steps := []struct {
+		name       string
+		do         func() error
+		wantErr    error
+		wantReason string // "" if the ID must not be blocked afterwards
+	}{
+		{"not blocked", func() error { return nil }, nil, ""},
+		{"block", func() error { return r.BlockEntity(ctx, "https://openalex.org/A1", "spam") }, nil, "spam"},
+		{"block again", func() error { return r.BlockEntity(ctx, "https://openalex.org/A1", "duplicate") }, nil, "duplicate"},
+		{"unblock", func() error { return r.UnblockEntity(ctx, "https://openalex.org/A1") }, nil, ""},
+		{"unblock again", func() error { return r.UnblockEntity(ctx, "https://openalex.org/A1") }, ErrNotFound, ""},
+	}
+	for _, step := range steps {
+		if err := step.do(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", step.name, err, step.wantErr)
+		}
+		blocked, reason, err := r.IsBlocked(ctx, "https://openalex.org/A1")
+		if err != nil {
+			t.Fatalf("%s: IsBlocked: %v", step.name, err)
+		}
+		if blocked != (step.wantReason != "") || reason != step.wantReason {
+			t.Errorf("%s: IsBlocked = %v, %q; want reason %q", step.name, blocked, reason, step.wantReason)
+		}
+	}
+
+	// The blocklist is per tenant.
+	if err := r.BlockEntity(ctx, "https://openalex.org/A2", "spam"); err != nil {
+		t.Fatal(err)
+	}
+	if blocked, _, err := r.IsBlocked(other, "https://openalex.org/A2"); err != nil || blocked {
+		t.Errorf("another tenant sees A2 as blocked (err %v)", err)
+	}
+}
+
+func TestDeleteAuthorKeepsWorks(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	work := domain.Work{ID: "W1", Title: "shared", PublicationYear: 2020,
+		Authorships: []domain.Authorship{authorship("https://openalex.org/A1"), authorship("https://openalex.org/A2")}}
+	if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+		t.Fatalf("SaveWork: %v", err)
+	}
+
+	if err := r.DeleteAuthor(ctx, "https://openalex.org/A1"); err != nil {
+		t.Fatalf("DeleteAuthor: %v", err)
+	}
+	if err := r.DeleteAuthor(ctx, "https://openalex.org/A1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting a deleted author: err = %v, want ErrNotFound", err)
+	}
+	records := query(t, r, ctx, `
+		MATCH (w:Work {id: 'W1', tenant: $tenant})
+		OPTIONAL MATCH (a:Author)-[:AUTHORED]->(w)
+		RETURN collect(a.id) AS authors
+	`, nil)
+	if len(records) != 1 {
+		t.Fatal("the work was deleted along with the author")
+	}
+	if authors := records[0]["authors"].([]any); len(authors) != 1 || authors[0] != "https://openalex.org/A2" {
+		t.Errorf("authors of W1 = %v, want only A2", authors)
+	}
+}
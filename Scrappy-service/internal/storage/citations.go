@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// GetWorkIDsByDOI maps each DOI (in any form) that belongs to a work in the graph to that
+// work's ID. Keys are normalized DOIs; DOIs without a local work are absent.
+func (r *neo4jRepository) GetWorkIDsByDOI(ctx context.Context, dois []string) (map[string]string, error) {
+	normalized := make([]string, 0, len(dois))
+	for _, doi := range dois {
+		if n := domain.NormalizeDOI(doi); n != "" {
+			normalized = append(normalized, n)
+		}
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetWorkIDsByDOI", `
+			MATCH (w:Work)
+			WHERE w.tenant = $tenant AND w.doiNormalized IN $dois
+			RETURN w.doiNormalized AS doi, min(w.id) AS id
+		`, map[string]any{"tenant": tenantOf(ctx), "dois": normalized})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			ids[stringProp(props, "doi")] = stringProp(props, "id")
+		}
+		return ids, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look works up by DOI: %w", err)
+	}
+	return result.(map[string]string), nil
+}
+
+// AnnotateCitation sets props (e.g. intents, isInfluential) on the CITES relationship from
+// citingID to citedID, creating the relationship if needed, and stamps it with
+// SourceSemanticScholar, where citation contexts come from. Both works must already be in
+// the graph; ErrNotFound means one of them isn't.
+func (r *neo4jRepository) AnnotateCitation(ctx context.Context, citingID, citedID string, props map[string]any) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "AnnotateCitation", `
+			MATCH (citing:Work {id: $citingId, tenant: $tenant})
+			MATCH (cited:Work {id: $citedId, tenant: $tenant})
+			MERGE (citing)-[c:CITES]->(cited)
+			SET c += $props, c.tenant = $tenant, `+stampSource("c")+`
+			RETURN count(c) AS annotated
+		`, map[string]any{"tenant": tenantOf(ctx), "citingId": citingID, "citedId": citedID, "props": props, "source": SourceSemanticScholar})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if intProp(record.AsMap(), "annotated") == 0 {
+			return nil, ErrNotFound
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to annotate citation %s -> %s: %w", citingID, citedID, err)
+	}
+	return nil
+}
+
+// GetCitedStubs returns the ids of up to limit stub works cited by the works in citingIDs
+// that have no title yet, ordered by id.
+func (r *neo4jRepository) GetCitedStubs(ctx context.Context, citingIDs []string, limit int) ([]string, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetCitedStubs", `
+			MATCH (w:Work)-[:CITES]->(stub:Work)
+			WHERE w.tenant = $tenant AND w.id IN $citingIds
+				AND stub.stub = true AND stub.title IS NULL
+			RETURN DISTINCT stub.id AS id
+			ORDER BY id
+			LIMIT $limit
+		`, map[string]any{"tenant": tenantOf(ctx), "citingIds": citingIDs, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, stringProp(record.AsMap(), "id"))
+		}
+		return ids, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cited stubs: %w", err)
+	}
+	return result.([]string), nil
+}
+
+// SetStubMetadata sets the title and publication year of stub works, so lists of cited
+// works are readable before the works themselves are ingested. The nodes stay stubs; works
+// that have been saved in full meanwhile are left alone. It returns the number of stubs
+// updated.
+func (r *neo4jRepository) SetStubMetadata(ctx context.Context, works []domain.DehydratedWork) (int, error) {
+	rows := make([]map[string]any, 0, len(works))
+	for _, work := range works {
+		rows = append(rows, map[string]any{"id": work.ID, "title": work.Title, "publicationYear": work.PublicationYear})
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "SetStubMetadata", `
+			UNWIND $rows AS row
+			MATCH (stub:Work {id: row.id, tenant: $tenant})
+			WHERE stub.stub = true
+			SET stub.title = row.title, stub.publicationYear = row.publicationYear, `+stampSource("stub")+`
+			RETURN count(stub) AS updated
+		`, map[string]any{"tenant": tenantOf(ctx), "rows": rows, "source": SourceOpenAlex})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return intProp(record.AsMap(), "updated"), nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update stub works: %w", err)
+	}
+	return result.(int), nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestGetWorkIDsByDOI(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	for _, work := range []domain.Work{
+		{ID: "W1", Title: "one", Doi: "https://doi.org/10.1/ABC"},
+		{ID: "W2", Title: "two", Doi: "10.1/def"},
+		{ID: "W3", Title: "no DOI"},
+	} {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	got, err := r.GetWorkIDsByDOI(ctx, []string{"10.1/abc", "doi:10.1/DEF", "10.1/missing", ""})
+	if err != nil {
+		t.Fatalf("GetWorkIDsByDOI: %v", err)
+	}
+	if want := map[string]string{"10.1/abc": "W1", "10.1/def": "W2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ids = %v, want %v", got, want)
+	}
+}
+
+func TestAnnotateCitation(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	for _, work := range []domain.Work{
+		{ID: "W1", Title: "citing", ReferencedWorks: []string{"W2"}},
+		{ID: "W2", Title: "cited"},
+		{ID: "W3", Title: "citing, without an edge yet"},
+	} {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+	props := map[string]any{"intents": []string{"methodology"}, "isInfluential": true, "contexts": []string{"we use [2]"}}
+
+	tests := []struct {
+		name    string
+		citing  string
+		cited   string
+		wantErr error
+	}{
+		{name: "existing edge", citing: "W1", cited: "W2"},
+		{name: "new edge", citing: "W3", cited: "W2"},
+		{name: "cited work missing", citing: "W1", cited: "W404", wantErr: ErrNotFound},
+		{name: "citing work missing", citing: "W404", cited: "W2", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.AnnotateCitation(ctx, tt.citing, tt.cited, props)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AnnotateCitation: %v", err)
+			}
+			records := query(t, r, ctx, `
+				MATCH (:Work {id: $citing, tenant: $tenant})-[c:CITES]->(:Work {id: $cited, tenant: $tenant})
+				RETURN c.intents AS intents, c.isInfluential AS influential, c.contexts AS contexts,
+					c.lastSource AS source, c.tenant AS tenant
+			`, map[string]any{"citing": tt.citing, "cited": tt.cited})
+			if len(records) != 1 {
+				t.Fatalf("%d CITES edges, want 1", len(records))
+			}
+			got := records[0]
+			if !reflect.DeepEqual(got["intents"], []any{"methodology"}) || got["influential"] != true ||
+				!reflect.DeepEqual(got["contexts"], []any{"we use [2]"}) {
+				t.Errorf("edge = %v, want the citation context", got)
+			}
+			if got["source"] != SourceSemanticScholar || got["tenant"] != tenantOf(ctx) {
+				t.Errorf("edge = %v, want it stamped with %s in the tenant", got, SourceSemanticScholar)
+			}
+		})
+	}
+
+	// Nothing was created for the missing works.
+	if n := query(t, r, ctx, `MATCH (w:Work {id: 'W404', tenant: $tenant}) RETURN w`, nil); len(n) != 0 {
+		t.Errorf("%d W404 nodes, want none", len(n))
+	}
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// DuplicateWorks is a set of Work nodes that share the same normalized DOI.
+type DuplicateWorks struct {
+	DOI     string   `json:"doi"`
+	WorkIDs []string `json:"workIds"`
+}
+
+// resolveWorkNodeID returns the id of the node a work should be saved onto: its own id,
+// unless no node exists under that id yet but another work already carries the same DOI.
+func (r *neo4jRepository) resolveWorkNodeID(ctx context.Context, tx neo4j.ManagedTransaction, workID, doiNormalized string) (string, error) {
+	if doiNormalized == "" {
+		return workID, nil
+	}
+	res, err := r.run(ctx, tx, "SaveWork/resolveDOI", `
+		OPTIONAL MATCH (self:Work {id: $id, tenant: $tenant})
+		OPTIONAL MATCH (dup:Work {doiNormalized: $doi, tenant: $tenant})
+		WHERE dup.id <> $id
+		RETURN self IS NOT NULL AS selfExists, collect(dup.id)[0] AS dupId
+	`, map[string]any{"tenant": tenantOf(ctx), "id": workID, "doi": doiNormalized})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up work by DOI: %w", err)
+	}
+	record, err := res.Single(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up work by DOI: %w", err)
+	}
+	props := record.AsMap()
+	if selfExists, _ := props["selfExists"].(bool); selfExists {
+		return workID, nil
+	}
+	if dupID := stringProp(props, "dupId"); dupID != "" {
+		return dupID, nil
+	}
+	return workID, nil
+}
+
+// FindDuplicateWorksByDOI lists groups of Work nodes that share a normalized DOI.
+func (r *neo4jRepository) FindDuplicateWorksByDOI(ctx context.Context) ([]DuplicateWorks, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "FindDuplicateWorksByDOI", `
+			MATCH (w:Work)
+			WHERE w.tenant = $tenant AND w.doiNormalized IS NOT NULL
+			WITH w.doiNormalized AS doi, collect(w.id) AS ids
+			WHERE size(ids) > 1
+			RETURN doi, ids
+			ORDER BY doi
+		`, map[string]any{"tenant": tenantOf(ctx)})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		groups := make([]DuplicateWorks, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			groups = append(groups, DuplicateWorks{DOI: stringProp(props, "doi"), WorkIDs: stringsProp(props, "ids")})
+		}
+		return groups, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate works: %w", err)
+	}
+	return result.([]DuplicateWorks), nil
+}
+
+// nodeRelationship is a relationship type re-pointed when one node is merged into another.
+// Incoming relationships have the merged node as their end node.
+type nodeRelationship struct {
+	relType  string
+	incoming bool
+}
+
+// workRelationships are the relationships re-pointed from a merged work onto the kept one.
+var workRelationships = []nodeRelationship{
+	{"AUTHORED", true},
+	{"CITES", true},
+	{"CITES", false},
+	{"PUBLISHED_IN", false},
+	{"IS_ABOUT_TOPIC", false},
+	{"IN_LANGUAGE", false},
+	{"FUNDED_BY", false},
+	{"AFFILIATED_ON_WORK", false},
+	{"RELATED_TO", true},
+	{"RELATED_TO", false},
+	{"TARGETED", true},
+}
+
+// MergeWorks folds the works in mergeIDs into keepID: their relationships are re-pointed
+// to the kept node (keeping relationship properties), their ids are recorded in its
+// alternateIds, properties it lacks are copied over, and the merged nodes are deleted.
+// Works with different non-empty DOIs are never merged (ErrConflictingDOIs).
+func (r *neo4jRepository) MergeWorks(ctx context.Context, keepID string, mergeIDs []string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "MergeWorks/lookup", `
+			MATCH (w:Work) WHERE w.tenant = $tenant AND w.id IN $ids
+			RETURN w.id AS id, coalesce(w.doiNormalized, '') AS doi
+		`, map[string]any{"tenant": tenantOf(ctx), "ids": append([]string{keepID}, mergeIDs...)})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dois := make(map[string]string, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			dois[stringProp(props, "id")] = stringProp(props, "doi")
+		}
+		keepDOI, ok := dois[keepID]
+		if !ok {
+			return nil, fmt.Errorf("work %s: %w", keepID, ErrNotFound)
+		}
+		for _, id := range mergeIDs {
+			doi, ok := dois[id]
+			if !ok {
+				return nil, fmt.Errorf("work %s: %w", id, ErrNotFound)
+			}
+			if doi != "" && keepDOI != "" && doi != keepDOI {
+				return nil, fmt.Errorf("cannot merge %s (%s) into %s (%s): %w", id, doi, keepID, keepDOI, ErrConflictingDOIs)
+			}
+			if keepDOI == "" {
+				keepDOI = doi
+			}
+		}
+
+		for _, oldID := range mergeIDs {
+			if oldID == keepID {
+				continue
+			}
+			params := map[string]any{"tenant": tenantOf(ctx), "keepId": keepID, "oldId": oldID}
+			if err := r.repointRelationships(ctx, tx, "Work", workRelationships, keepID, oldID); err != nil {
+				return nil, err
+			}
+
+			if err := r.exec(ctx, tx, "MergeWorks/fold", `
+				MATCH (keep:Work {id: $keepId, tenant: $tenant}), (old:Work {id: $oldId, tenant: $tenant})
+				SET keep.alternateIds = [x IN coalesce(keep.alternateIds, []) + [old.id] + coalesce(old.alternateIds, []) WHERE x <> keep.id | x],
+					keep.title = coalesce(keep.title, old.title),
+					keep.doi = coalesce(keep.doi, old.doi),
+					keep.doiNormalized = coalesce(keep.doiNormalized, old.doiNormalized),
+					keep.publicationYear = coalesce(keep.publicationYear, old.publicationYear),
+					keep.publicationDate = coalesce(keep.publicationDate, old.publicationDate),
+					keep.citedByCount = coalesce(keep.citedByCount, old.citedByCount)
+				DETACH DELETE old
+			`, params); err != nil {
+				return nil, fmt.Errorf("failed to merge work %s into %s: %w", oldID, keepID, err)
+			}
+		}
+		// Drop duplicate entries the concatenation above may have produced.
+		err = r.exec(ctx, tx, "MergeWorks/alternateIds", `
+			MATCH (keep:Work {id: $keepId, tenant: $tenant})
+			SET keep.alternateIds = reduce(acc = [], x IN coalesce(keep.alternateIds, []) | CASE WHEN x IN acc THEN acc ELSE acc + x END)
+		`, map[string]any{"tenant": tenantOf(ctx), "keepId": keepID})
+		return nil, err
+	})
+	return err
+}
+
+// repointRelationships moves the given relationships of the label node oldID onto keepID,
+// keeping their properties. Relationships that would connect keepID to itself are dropped.
+func (r *neo4jRepository) repointRelationships(ctx context.Context, tx neo4j.ManagedTransaction, label string, rels []nodeRelationship, keepID, oldID string) error {
+	params := map[string]any{"tenant": tenantOf(ctx), "keepId": keepID, "oldId": oldID}
+	for _, rel := range rels {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		pattern := fmt.Sprintf("(old)-[r:%s]->(x)", rel.relType)
+		merge := fmt.Sprintf("MERGE (keep)-[n:%s]->(x)", rel.relType)
+		if rel.incoming {
+			pattern = fmt.Sprintf("(x)-[r:%s]->(old)", rel.relType)
+			merge = fmt.Sprintf("MERGE (x)-[n:%s]->(keep)", rel.relType)
+		}
+		query := fmt.Sprintf(`
+			MATCH (keep:%[1]s {id: $keepId, tenant: $tenant}), (old:%[1]s {id: $oldId, tenant: $tenant})
+			MATCH %[2]s
+			WHERE x <> keep
+			%[3]s
+			SET n += properties(r)
+			DELETE r
+		`, label, pattern, merge)
+		if err := r.exec(ctx, tx, "repointRelationships", query, params); err != nil {
+			return fmt.Errorf("failed to re-point %s relationships of %s: %w", rel.relType, oldID, err)
+		}
+	}
+	return nil
+}
+
+// stringsProp reads a list-of-strings property, skipping non-string entries.
+func stringsProp(props map[string]any, key string) []string {
+	raw, _ := props[key].([]any)
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
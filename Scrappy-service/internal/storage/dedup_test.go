@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+var dedupSave = SaveOptions{IncludeVenue: true, IncludeCitations: true}
+
+func TestSaveWorkMergesOntoSameDOI(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	first := domain.Work{ID: "W1", Title: "Deep learning", Doi: "https://doi.org/10.1038/NATURE14539",
+		Authorships: []domain.Authorship{authorship("A1")}}
+	second := domain.Work{ID: "W2", Title: "Deep learning", Doi: "doi:10.1038/nature14539",
+		Authorships: []domain.Authorship{authorship("A2")}}
+	for _, work := range []domain.Work{first, second, second} {
+		if _, err := r.SaveWork(ctx, work, dedupSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	records := query(t, r, ctx, `
+		MATCH (w:Work {tenant: $tenant})
+		OPTIONAL MATCH (a:Author)-[:AUTHORED]->(w)
+		WITH w, a ORDER BY a.id
+		RETURN w.id AS id, w.alternateIds AS alternateIds, w.doiNormalized AS doi, collect(a.id) AS authors
+	`, nil)
+	if len(records) != 1 {
+		t.Fatalf("got %d works, want the second merged onto the first: %v", len(records), records)
+	}
+	got := records[0]
+	if got["id"] != "W1" || got["doi"] != "10.1038/nature14539" {
+		t.Errorf("work = %v, want W1 with the normalized DOI", got)
+	}
+	if ids := stringsProp(got, "alternateIds"); !reflect.DeepEqual(ids, []string{"W2"}) {
+		t.Errorf("alternateIds = %v, want [W2] once", ids)
+	}
+	if authors := stringsProp(got, "authors"); !reflect.DeepEqual(authors, []string{"A1", "A2"}) {
+		t.Errorf("authors = %v, want both saves' authors", authors)
+	}
+}
+
+func TestSaveWorkKeepsExistingWorkWithSameDOI(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	// Two nodes that already share a DOI (saved before deduplication) are each updated in place.
+	query(t, r, ctx, `
+		CREATE (:Work {id: 'W1', tenant: $tenant, doiNormalized: '10.1/a'}),
+			(:Work {id: 'W2', tenant: $tenant, doiNormalized: '10.1/a'})
+	`, nil)
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W2", Title: "updated", Doi: "10.1/a"}, dedupSave); err != nil {
+		t.Fatalf("SaveWork: %v", err)
+	}
+	records := query(t, r, ctx, `MATCH (w:Work {id: 'W2', tenant: $tenant}) RETURN w.title AS title`, nil)
+	if len(records) != 1 || records[0]["title"] != "updated" {
+		t.Errorf("W2 = %v, want it updated under its own id", records)
+	}
+}
+
+func TestMergeWorksPreservesRelationships(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	venue := &domain.Location{Source: &domain.Source{ID: "S1", DisplayName: "Nature"}}
+	works := []domain.Work{
+		{ID: "W1", Title: "kept", Doi: "10.1/a", Authorships: []domain.Authorship{authorship("A1")}, ReferencedWorks: []string{"W8"}},
+		{ID: "W2", Title: "duplicate", Authorships: []domain.Authorship{authorship("A2")},
+			PrimaryLocation: venue, ReferencedWorks: []string{"W9", "W1"}},
+		{ID: "W3", Title: "citing", ReferencedWorks: []string{"W2"}},
+	}
+	for _, work := range works {
+		if _, err := r.SaveWork(ctx, work, dedupSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	if err := r.MergeWorks(ctx, "W1", []string{"W2"}); err != nil {
+		t.Fatalf("MergeWorks: %v", err)
+	}
+
+	records := query(t, r, ctx, `
+		MATCH (keep:Work {id: 'W1', tenant: $tenant})-[rel]-(x)
+		RETURN type(rel) + CASE WHEN startNode(rel) = keep THEN '->' ELSE '<-' END + x.id AS edge
+	`, nil)
+	var edges []string
+	for _, record := range records {
+		edges = append(edges, stringProp(record, "edge"))
+	}
+	sort.Strings(edges)
+	// W2's citation of W1 would be a self-citation and is dropped.
+	want := []string{"AUTHORED<-A1", "AUTHORED<-A2", "CITES->W8", "CITES->W9", "CITES<-W3", "PUBLISHED_IN->S1"}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("relationships of W1 = %v, want %v", edges, want)
+	}
+
+	records = query(t, r, ctx, `
+		MATCH (w:Work {tenant: $tenant}) WHERE w.id IN ['W1', 'W2']
+		RETURN w.id AS id, w.alternateIds AS alternateIds
+	`, nil)
+	if len(records) != 1 || records[0]["id"] != "W1" {
+		t.Fatalf("works = %v, want W2 deleted", records)
+	}
+	if ids := stringsProp(records[0], "alternateIds"); !reflect.DeepEqual(ids, []string{"W2"}) {
+		t.Errorf("alternateIds = %v, want [W2]", ids)
+	}
+}
+
+func TestMergeWorksChecksDOIs(t *testing.T) {
+	tests := []struct {
+		name     string
+		dois     map[string]string // DOI by work ID; "" saves the work without one.
+		keepID   string
+		mergeIDs []string
+		wantErr  error
+	}{
+		{"same DOI", map[string]string{"W1": "10.1/a", "W2": "https://doi.org/10.1/A"}, "W1", []string{"W2"}, nil},
+		{"merged has none", map[string]string{"W1": "10.1/a", "W2": ""}, "W1", []string{"W2"}, nil},
+		{"kept has none", map[string]string{"W1": "", "W2": "10.1/a", "W3": "10.1/a"}, "W1", []string{"W2", "W3"}, nil},
+		{"different DOIs", map[string]string{"W1": "10.1/a", "W2": "10.1/b"}, "W1", []string{"W2"}, ErrConflictingDOIs},
+		{"different DOIs, kept has none", map[string]string{"W1": "", "W2": "10.1/a", "W3": "10.1/b"}, "W1", []string{"W2", "W3"}, ErrConflictingDOIs},
+		{"unknown work", map[string]string{"W1": "10.1/a"}, "W1", []string{"W2"}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ctx := newTestRepo(t)
+			for id, doi := range tt.dois {
+				// Saved directly, since SaveWork would merge works with the same DOI itself.
+				query(t, r, ctx, `CREATE (:Work {id: $id, tenant: $tenant, doiNormalized: $doi})`,
+					map[string]any{"id": id, "doi": nullIfEmpty(domain.NormalizeDOI(doi))})
+			}
+
+			err := r.MergeWorks(ctx, tt.keepID, tt.mergeIDs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MergeWorks = %v, want %v", err, tt.wantErr)
+			}
+			records := query(t, r, ctx, `MATCH (w:Work {tenant: $tenant}) RETURN w.id AS id`, nil)
+			wantWorks := 1
+			if tt.wantErr != nil {
+				wantWorks = len(tt.dois) // Nothing is merged.
+			}
+			if len(records) != wantWorks {
+				t.Errorf("%d works left, want %d", len(records), wantWorks)
+			}
+		})
+	}
+}
+
+func TestFindDuplicateWorksByDOI(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	query(t, r, ctx, `
+		CREATE (:Work {id: 'W1', tenant: $tenant, doiNormalized: '10.1/a'}),
+			(:Work {id: 'W2', tenant: $tenant, doiNormalized: '10.1/a'}),
+			(:Work {id: 'W3', tenant: $tenant, doiNormalized: '10.1/b'}),
+			(:Work {id: 'W4', tenant: $tenant})
+	`, nil)
+
+	groups, err := r.FindDuplicateWorksByDOI(ctx)
+	if err != nil {
+		t.Fatalf("FindDuplicateWorksByDOI: %v", err)
+	}
+	if len(groups) != 1 || groups[0].DOI != "10.1/a" {
+		t.Fatalf("groups = %v, want the one for 10.1/a", groups)
+	}
+	sort.Strings(groups[0].WorkIDs)
+	if !reflect.DeepEqual(groups[0].WorkIDs, []string{"W1", "W2"}) {
+		t.Errorf("work ids = %v, want [W1 W2]", groups[0].WorkIDs)
+	}
+}
+
+// The doiNormalized migration must agree with domain.NormalizeDOI, which SaveWork uses, or
+// works saved before and after it would not be recognized as the same.
+func TestDOIMigrationMatchesNormalizeDOI(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	dois := []string{
+		"10.1038/nature14539", "10.1038/NATURE14539", "https://doi.org/10.1038/Nature14539",
+		"http://doi.org/10.1/a", "https://dx.doi.org/10.1/a", "http://dx.doi.org/10.1/a",
+		"DOI:10.1/a", "  doi: 10.1/a  ", "doi:https://doi.org/10.1/a", "https://doi.org/",
+	}
+	for i, doi := range dois {
+		// A stale value, as left by an earlier version of the migration, is rewritten too.
+		query(t, r, ctx, `CREATE (:Work {id: $id, tenant: $tenant, doi: $doi, doiNormalized: 'stale'})`,
+			map[string]any{"id": fmt.Sprintf("W%d", i), "doi": doi})
+	}
+	if err := r.ensureSchema(ctx); err != nil {
+		t.Fatalf("running the migrations: %v", err)
+	}
+
+	records := query(t, r, ctx, `MATCH (w:Work {tenant: $tenant}) RETURN w.doi AS doi, w.doiNormalized AS normalized`, nil)
+	if len(records) != len(dois) {
+		t.Fatalf("got %d works, want %d", len(records), len(dois))
+	}
+	for _, record := range records {
+		doi := stringProp(record, "doi")
+		want := nullIfEmpty(domain.NormalizeDOI(doi))
+		if record["normalized"] != want {
+			t.Errorf("migrated %q to %v, NormalizeDOI gives %v", doi, record["normalized"], want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// errDisabledRead is what reads of a disabled repository return; it matches ErrNotFound.
+var errDisabledRead = fmt.Errorf("%w: %w", ErrNotFound, ErrStorageDisabled)
+
+// disabledRepository is the Repository used with STORAGE_BACKEND=none, when the service
+// only proxies OpenAlex. Writes fail with ErrStorageDisabled and reads find nothing:
+// ErrNotFound, or false / empty where a method reports absence without an error.
+type disabledRepository struct{}
+
+// NewDisabledRepository returns a Repository that stores nothing.
+func NewDisabledRepository() Repository {
+	return disabledRepository{}
+}
+
+func (disabledRepository) SaveAuthor(ctx context.Context, author domain.Author) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) SaveAuthors(ctx context.Context, authors []domain.Author) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) SaveWork(ctx context.Context, work domain.Work, opts SaveOptions) (SaveOutcome, error) {
+	return "", ErrStorageDisabled
+}
+
+func (disabledRepository) Close(ctx context.Context) error {
+	return nil
+}
+
+func (disabledRepository) Ping(ctx context.Context) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) MarkAuthorFullyIngested(ctx context.Context, authorID string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) SetAuthorWorksSynced(ctx context.Context, authorID string, at time.Time) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) GetAuthorWorksSynced(ctx context.Context, authorID string) (time.Time, error) {
+	return time.Time{}, errDisabledRead
+}
+
+func (disabledRepository) AuthorExists(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+func (disabledRepository) WorkExists(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+func (disabledRepository) RecordIngestEvent(ctx context.Context, event IngestEvent) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) GetIngestHistory(ctx context.Context, targetID string) ([]IngestEvent, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetIngestEvent(ctx context.Context, id string) (IngestEvent, error) {
+	return IngestEvent{}, errDisabledRead
+}
+
+// ListIncompleteIngestEvents reports nothing: without storage no job is ever recorded.
+func (disabledRepository) ListIncompleteIngestEvents(ctx context.Context) ([]IngestEvent, error) {
+	return nil, nil
+}
+
+func (disabledRepository) GetWorksMissingAbstract(ctx context.Context, after string, limit int) ([]domain.DehydratedWork, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetAuthorWorks(ctx context.Context, authorID string, onlyFulltext, includeRetracted, newestFirst bool, limit int) ([]domain.DehydratedWork, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetTopWorks(ctx context.Context, limit int, sinceYear int, includeRetracted bool) ([]domain.Work, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetWorksAddedSince(ctx context.Context, authorID string, since time.Time) ([]NewWork, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) Follow(ctx context.Context, userID, authorID string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) Unfollow(ctx context.Context, userID, authorID string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) ListFollowed(ctx context.Context, userID string) ([]FollowedAuthor, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetDigest(ctx context.Context, userID string, since time.Time) ([]AuthorDigest, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) CountCollaborationsByCountry(ctx context.Context, authorID string) (map[string]int, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetAuthorWorkTypeBreakdown(ctx context.Context, authorID string) (map[string]int, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) ComputeHIndex(ctx context.Context, authorID string) (int, error) {
+	return 0, errDisabledRead
+}
+
+func (disabledRepository) GetHIndexDrift(ctx context.Context, authorID string) (*HIndexDrift, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) CountHIndexDrift(ctx context.Context, threshold int) (int, error) {
+	return 0, errDisabledRead
+}
+
+func (disabledRepository) GetTrendingTopics(ctx context.Context, sinceYear int, limit int) ([]TopicTrend, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetWorksBridgingTopics(ctx context.Context, topicA, topicB string, minScore float32, page PageRequest) (*BridgingWorks, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetAuthorsBridgingTopics(ctx context.Context, topicA, topicB string, minPapers int, page PageRequest) (*BridgingAuthors, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetInstitutionByROR(ctx context.Context, ror string) (*domain.Institution, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetAuthorFunders(ctx context.Context, authorID string) ([]Funder, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetAuthorTopicProfile(ctx context.Context, authorID string) (*TopicProfile, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) SearchAuthors(ctx context.Context, query string, limit int) ([]AuthorMatch, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) FindDuplicateWorksByDOI(ctx context.Context) ([]DuplicateWorks, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) MergeWorks(ctx context.Context, keepID string, mergeIDs []string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) FindVenueAliases(ctx context.Context) ([]VenueAliases, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) MergeVenues(ctx context.Context, keepID string, mergeIDs []string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) ExportGraph(ctx context.Context, onNode func(ExportNode) error, onEdge func(ExportEdge) error) error {
+	return errDisabledRead
+}
+
+func (disabledRepository) RecordAuthorMerge(ctx context.Context, oldID, canonicalID string) error {
+	return ErrStorageDisabled
+}
+
+// ResolveAuthorID returns id unchanged: with nothing stored, no merges are known.
+func (disabledRepository) ResolveAuthorID(ctx context.Context, id string) (string, error) {
+	return id, nil
+}
+
+func (disabledRepository) RecordWorkMerge(ctx context.Context, oldID, canonicalID string) error {
+	return ErrStorageDisabled
+}
+
+// ResolveWorkID returns id unchanged: with nothing stored, no merges are known.
+func (disabledRepository) ResolveWorkID(ctx context.Context, id string) (string, error) {
+	return id, nil
+}
+
+func (disabledRepository) FindAuthorMergeCandidates(ctx context.Context) ([]AuthorAlias, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) MergeAuthorAlias(ctx context.Context, oldID string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) SaveAuthorSSEnrichment(ctx context.Context, authorID string, enrichment SSAuthorEnrichment) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) LinkRelatedWorksByDOI(ctx context.Context, doi string, relatedDOIs []string, source string) (int, error) {
+	return 0, ErrStorageDisabled
+}
+
+func (disabledRepository) GetWorkIDsByDOI(ctx context.Context, dois []string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (disabledRepository) AnnotateCitation(ctx context.Context, citingID, citedID string, props map[string]any) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) FindWorkIDs(ctx context.Context, kind, value string) (WorkIDs, error) {
+	return WorkIDs{}, errDisabledRead
+}
+
+func (disabledRepository) SetWorkSSPaperID(ctx context.Context, workID, paperID string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) GetCitedStubs(ctx context.Context, citingIDs []string, limit int) ([]string, error) {
+	return nil, nil
+}
+
+func (disabledRepository) SetStubMetadata(ctx context.Context, works []domain.DehydratedWork) (int, error) {
+	return 0, ErrStorageDisabled
+}
+
+func (disabledRepository) GetCitationNeighborhood(ctx context.Context, workID string, depthIn, depthOut, maxNodes int) (*CitationNeighborhood, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetWorksForExport(ctx context.Context, ids []string) ([]domain.Work, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetWorkProvenance(ctx context.Context, workID string) (*WorkProvenance, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) SaveWorkEmbedding(ctx context.Context, workID string, vec []float32) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) GetWorksMissingEmbedding(ctx context.Context, limit int) ([]domain.DehydratedWork, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetSimilarityCandidates(ctx context.Context, workID string, maxCandidates int) (*SimilarityCandidates, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetInstitutionStubs(ctx context.Context, limit int) ([]string, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) SaveInstitution(ctx context.Context, institution domain.Institution) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) GetInstitutionWorksRolledUp(ctx context.Context, instID string, includeChildren bool) (*InstitutionRollup, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) SaveVenue(ctx context.Context, source domain.Source) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) GetWorksByVenueForAuthor(ctx context.Context, authorID string) (map[string][]domain.DehydratedWork, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) GetVenueSummary(ctx context.Context, venueID string, topAuthors int) (*VenueSummary, error) {
+	return nil, errDisabledRead
+}
+
+func (disabledRepository) BlockEntity(ctx context.Context, id, reason string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) UnblockEntity(ctx context.Context, id string) error {
+	return ErrStorageDisabled
+}
+
+// IsBlocked reports nothing as blocked, since no blocklist can be stored.
+func (disabledRepository) IsBlocked(ctx context.Context, id string) (bool, string, error) {
+	return false, "", nil
+}
+
+func (disabledRepository) DeleteAuthor(ctx context.Context, id string) error {
+	return ErrStorageDisabled
+}
+
+func (disabledRepository) PruneOrphans(ctx context.Context, labels []string) (int, error) {
+	return 0, ErrStorageDisabled
+}
+
+func (disabledRepository) ReconcileWorkAffiliations(ctx context.Context) (int, error) {
+	return 0, ErrStorageDisabled
+}
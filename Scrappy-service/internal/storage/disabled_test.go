@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestDisabledRepository(t *testing.T) {
+	r := NewDisabledRepository()
+	ctx := context.Background()
+
+	writes := []struct {
+		name string
+		call func() error
+	}{
+		{"SaveWork", func() error { _, err := r.SaveWork(ctx, domain.Work{ID: "W1"}, FullSave); return err }},
+		{"SaveAuthor", func() error { return r.SaveAuthor(ctx, domain.Author{ID: "A1"}) }},
+		{"SaveAuthors", func() error { return r.SaveAuthors(ctx, []domain.Author{{ID: "A1"}}) }},
+		{"BlockEntity", func() error { return r.BlockEntity(ctx, "A1", "spam") }},
+		{"SetAuthorWorksSynced", func() error { return r.SetAuthorWorksSynced(ctx, "A1", time.Now()) }},
+		{"AnnotateCitation", func() error { return r.AnnotateCitation(ctx, "W1", "W2", nil) }},
+	}
+	for _, tt := range writes {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrStorageDisabled) {
+				t.Errorf("error = %v, want ErrStorageDisabled", err)
+			}
+		})
+	}
+
+	reads := []struct {
+		name string
+		call func() error
+	}{
+		{"GetAuthorTopicProfile", func() error { _, err := r.GetAuthorTopicProfile(ctx, "A1"); return err }},
+		{"GetAuthorWorksSynced", func() error { _, err := r.GetAuthorWorksSynced(ctx, "A1"); return err }},
+		{"FindWorkIDs", func() error { _, err := r.FindWorkIDs(ctx, WorkIDOpenAlex, "W1"); return err }},
+		{"ComputeHIndex", func() error { _, err := r.ComputeHIndex(ctx, "A1"); return err }},
+	}
+	for _, tt := range reads {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrStorageDisabled) {
+				t.Errorf("error = %v, want one matching ErrNotFound and ErrStorageDisabled", err)
+			}
+		})
+	}
+
+	if blocked, _, err := r.IsBlocked(ctx, "A1"); blocked || err != nil {
+		t.Errorf("IsBlocked = %v, %v, want false without an error", blocked, err)
+	}
+	if err := r.Ping(ctx); !errors.Is(err, ErrStorageDisabled) {
+		t.Errorf("Ping error = %v, want ErrStorageDisabled", err)
+	}
+}
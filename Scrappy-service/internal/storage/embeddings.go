@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// EmbeddedWork is a work together with its stored embedding.
+type EmbeddedWork struct {
+	domain.DehydratedWork
+	Embedding []float32 `json:"-"`
+}
+
+// SimilarityCandidates are the works a work is compared with for similarity: those sharing
+// at least one topic with it. Target is nil when the work itself has no embedding, and
+// Skipped counts the candidates left out because they have none.
+type SimilarityCandidates struct {
+	Target     []float32
+	Candidates []EmbeddedWork
+	Skipped    int
+}
+
+// SaveWorkEmbedding stores a SPECTER embedding on a Work node as a float array.
+// ErrNotFound means the work isn't in the graph.
+func (r *neo4jRepository) SaveWorkEmbedding(ctx context.Context, workID string, vec []float32) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "SaveWorkEmbedding", `
+			MATCH (w:Work {id: $id, tenant: $tenant})
+			SET w.embedding = $embedding, `+stampSource("w")+`
+			RETURN count(w) AS saved
+		`, map[string]any{"tenant": tenantOf(ctx), "id": workID, "embedding": vec, "source": SourceSemanticScholar})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if intProp(record.AsMap(), "saved") == 0 {
+			return nil, ErrNotFound
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save embedding of work %s: %w", workID, err)
+	}
+	return nil
+}
+
+// GetWorksMissingEmbedding returns up to limit works that have a DOI but no embedding yet.
+func (r *neo4jRepository) GetWorksMissingEmbedding(ctx context.Context, limit int) ([]domain.DehydratedWork, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetWorksMissingEmbedding", `
+			MATCH (w:Work)
+			WHERE w.tenant = $tenant AND w.doi IS NOT NULL AND w.doi <> '' AND w.embedding IS NULL
+			RETURN w.id AS id, w.doi AS doi, w.title AS title,
+				w.publicationYear AS publicationYear, w.publicationDate AS publicationDate,
+				coalesce(w.isRetracted, false) AS isRetracted
+			ORDER BY w.id
+			LIMIT $limit
+		`, map[string]any{"tenant": tenantOf(ctx), "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return dehydratedWorksFromRecords(records), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read works missing embeddings: %w", err)
+	}
+	return result.([]domain.DehydratedWork), nil
+}
+
+// GetSimilarityCandidates returns the embedding of a work and of up to maxCandidates works
+// sharing a topic with it. ErrNotFound means the work isn't in the graph.
+func (r *neo4jRepository) GetSimilarityCandidates(ctx context.Context, workID string, maxCandidates int) (*SimilarityCandidates, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetSimilarityCandidates", `
+			MATCH (w:Work {id: $id, tenant: $tenant})
+			OPTIONAL MATCH (w)-[:IS_ABOUT_TOPIC]->(:Topic)<-[:IS_ABOUT_TOPIC]-(c:Work)
+			WHERE c.tenant = $tenant AND c <> w
+			WITH w, collect(DISTINCT c)[..$max] AS candidates
+			RETURN w.embedding AS target,
+				size([c IN candidates WHERE c.embedding IS NULL]) AS skipped,
+				[c IN candidates WHERE c.embedding IS NOT NULL | {
+					id: c.id, doi: c.doi, title: c.title, publicationYear: c.publicationYear,
+					publicationDate: c.publicationDate, isRetracted: coalesce(c.isRetracted, false),
+					embedding: c.embedding
+				}] AS candidates
+		`, map[string]any{"tenant": tenantOf(ctx), "id": workID, "max": maxCandidates})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		props := records[0].AsMap()
+		similar := &SimilarityCandidates{Target: floatsProp(props, "target"), Skipped: intProp(props, "skipped")}
+		if len(similar.Target) == 0 {
+			similar.Target = nil
+		}
+		rows, _ := props["candidates"].([]any)
+		for _, row := range rows {
+			c, _ := row.(map[string]any)
+			similar.Candidates = append(similar.Candidates, EmbeddedWork{
+				DehydratedWork: domain.DehydratedWork{
+					ID:              stringProp(c, "id"),
+					Doi:             stringProp(c, "doi"),
+					Title:           stringProp(c, "title"),
+					PublicationYear: intProp(c, "publicationYear"),
+					PublicationDate: dateProp(c, "publicationDate"),
+					IsRetracted:     boolProp(c, "isRetracted"),
+				},
+				Embedding: floatsProp(c, "embedding"),
+			})
+		}
+		return similar, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read similarity candidates of work %s: %w", workID, err)
+	}
+	return result.(*SimilarityCandidates), nil
+}
+
+func floatsProp(props map[string]any, key string) []float32 {
+	raw, _ := props[key].([]any)
+	out := make([]float32, 0, len(raw))
+	for _, v := range raw {
+		if f, ok := v.(float64); ok {
+			out = append(out, float32(f))
+		}
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestEmbeddings(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	topic := func(id string) domain.Topic {
+		return domain.Topic{ID: "T-" + tenantOf(ctx) + "-" + id, DisplayName: id}
+	}
+	t.Cleanup(func() {
+		query(t, r, ctx, `MATCH (t:Topic) WHERE t.id STARTS WITH $prefix DETACH DELETE t`, map[string]any{"prefix": "T-" + tenantOf(ctx)})
+	})
+	for _, work := range []domain.Work{
+		{ID: "W1", Title: "target", Doi: "10.1/w1", Topics: []domain.Topic{topic("a")}},
+		{ID: "W2", Title: "same topic", Doi: "10.1/w2", Topics: []domain.Topic{topic("a"), topic("b")}},
+		{ID: "W3", Title: "same topic, no embedding", Doi: "10.1/w3", Topics: []domain.Topic{topic("a")}},
+		{ID: "W4", Title: "other topic", Doi: "10.1/w4", Topics: []domain.Topic{topic("b")}},
+		{ID: "W5", Title: "no DOI", Topics: []domain.Topic{topic("a")}},
+	} {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+	for id, vec := range map[string][]float32{"W1": {1, 0}, "W2": {0.5, 0.5}, "W4": {0, 1}} {
+		if err := r.SaveWorkEmbedding(ctx, id, vec); err != nil {
+			t.Fatalf("SaveWorkEmbedding(%s): %v", id, err)
+		}
+	}
+	if err := r.SaveWorkEmbedding(ctx, "W404", []float32{1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SaveWorkEmbedding(W404) error = %v, want ErrNotFound", err)
+	}
+
+	missing, err := r.GetWorksMissingEmbedding(ctx, 10)
+	if err != nil {
+		t.Fatalf("GetWorksMissingEmbedding: %v", err)
+	}
+	if len(missing) != 1 || missing[0].ID != "W3" {
+		t.Errorf("missing embeddings = %+v, want only W3 (W5 has no DOI)", missing)
+	}
+
+	candidates, err := r.GetSimilarityCandidates(ctx, "W1", 100)
+	if err != nil {
+		t.Fatalf("GetSimilarityCandidates: %v", err)
+	}
+	if !reflect.DeepEqual(candidates.Target, []float32{1, 0}) {
+		t.Errorf("target = %v, want [1 0]", candidates.Target)
+	}
+	if len(candidates.Candidates) != 1 || candidates.Candidates[0].ID != "W2" ||
+		!reflect.DeepEqual(candidates.Candidates[0].Embedding, []float32{0.5, 0.5}) {
+		t.Errorf("candidates = %+v, want W2 with its embedding", candidates.Candidates)
+	}
+	if candidates.Skipped != 2 {
+		t.Errorf("skipped = %d, want 2 (W3 and W5 share the topic but have no embedding)", candidates.Skipped)
+	}
+
+	without, err := r.GetSimilarityCandidates(ctx, "W3", 100)
+	if err != nil {
+		t.Fatalf("GetSimilarityCandidates(W3): %v", err)
+	}
+	if without.Target != nil {
+		t.Errorf("target of a work without an embedding = %v, want nil", without.Target)
+	}
+	if _, err := r.GetSimilarityCandidates(ctx, "W404", 100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSimilarityCandidates(W404) error = %v, want ErrNotFound", err)
+	}
+}
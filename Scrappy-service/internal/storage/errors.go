@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when a requested node does not exist in the graph.
+	ErrNotFound = errors.New("not found")
+	// ErrConflictingDOIs is returned when asked to merge works whose DOIs differ.
+	ErrConflictingDOIs = errors.New("works have different DOIs")
+	// ErrConflictingISSNs is returned when asked to merge venues whose ISSN-Ls differ.
+	ErrConflictingISSNs = errors.New("venues have different ISSN-Ls")
+	// ErrInvalidLabel is returned for node labels an operation doesn't support.
+	ErrInvalidLabel = errors.New("unsupported label")
+	// ErrStorageDisabled is returned by writes when the service runs without a database
+	// (STORAGE_BACKEND=none).
+	ErrStorageDisabled = errors.New("storage is disabled")
+)
+
+// AuthorSaveResult is the outcome of saving one author of a SaveAuthors call.
+type AuthorSaveResult struct {
+	ID  string
+	Err error
+}
+
+// SaveAuthorsError is returned by SaveAuthors when some authors could not be saved.
+// Results holds one entry per author, in the order given, with Err nil for those saved.
+type SaveAuthorsError struct {
+	Results []AuthorSaveResult
+	Failed  int
+}
+
+func (e *SaveAuthorsError) Error() string {
+	for _, result := range e.Results {
+		if result.Err != nil {
+			return fmt.Sprintf("%d of %d authors could not be saved, e.g. %s: %v", e.Failed, len(e.Results), result.ID, result.Err)
+		}
+	}
+	return fmt.Sprintf("%d of %d authors could not be saved", e.Failed, len(e.Results))
+}
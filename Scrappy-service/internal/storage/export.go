@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// exportLabels are the node labels included in graph exports; bookkeeping nodes such as
+// IngestEvent are left out.
+var exportLabels = []string{"Author", "Work", "Institution", "Venue", "Topic", "Subfield", "Field", "Domain"}
+
+// exportFetchSize is how many records the driver pulls from Neo4j at a time while an
+// export streams, which keeps memory flat regardless of graph size.
+const exportFetchSize = 1000
+
+// ExportNode is a node as written to a graph export.
+type ExportNode struct {
+	ID          string
+	Label       string
+	Title       string
+	DisplayName string
+	Year        int
+}
+
+// ExportEdge is a relationship as written to a graph export.
+type ExportEdge struct {
+	Source string
+	Target string
+	Type   string
+}
+
+// ExportGraph streams every exported node to onNode, then every relationship between
+// exported nodes to onEdge. Records are consumed as they arrive, never collected.
+func (r *neo4jRepository) ExportGraph(ctx context.Context, onNode func(ExportNode) error, onEdge func(ExportEdge) error) error {
+	// An auto-commit transaction is used on purpose: a managed transaction could be retried
+	// and replay records the callbacks have already written out.
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead, FetchSize: exportFetchSize})
+	defer session.Close(ctx)
+
+	// Topic hierarchy nodes are shared by all tenants and carry no tenant property.
+	params := map[string]any{"labels": exportLabels, "tenant": tenantOf(ctx)}
+
+	nodes, err := session.Run(ctx, `
+		MATCH (n)
+		WHERE n.id IS NOT NULL AND any(l IN labels(n) WHERE l IN $labels)
+			AND coalesce(n.tenant, $tenant) = $tenant
+		RETURN n.id AS id, [l IN labels(n) WHERE l IN $labels][0] AS label,
+			n.title AS title, n.displayName AS displayName, n.publicationYear AS year
+	`, params)
+	if err != nil {
+		return fmt.Errorf("failed to export nodes: %w", err)
+	}
+	for nodes.Next(ctx) {
+		props := nodes.Record().AsMap()
+		node := ExportNode{
+			ID:          stringProp(props, "id"),
+			Label:       stringProp(props, "label"),
+			Title:       stringProp(props, "title"),
+			DisplayName: stringProp(props, "displayName"),
+			Year:        intProp(props, "year"),
+		}
+		if err := onNode(node); err != nil {
+			return err
+		}
+	}
+	if err := nodes.Err(); err != nil {
+		return fmt.Errorf("failed to export nodes: %w", err)
+	}
+
+	edges, err := session.Run(ctx, `
+		MATCH (a)-[r]->(b)
+		WHERE a.id IS NOT NULL AND b.id IS NOT NULL
+			AND any(l IN labels(a) WHERE l IN $labels)
+			AND any(l IN labels(b) WHERE l IN $labels)
+			AND coalesce(a.tenant, $tenant) = $tenant AND coalesce(b.tenant, $tenant) = $tenant
+		RETURN a.id AS source, b.id AS target, type(r) AS type
+	`, params)
+	if err != nil {
+		return fmt.Errorf("failed to export relationships: %w", err)
+	}
+	for edges.Next(ctx) {
+		props := edges.Record().AsMap()
+		edge := ExportEdge{
+			Source: stringProp(props, "source"),
+			Target: stringProp(props, "target"),
+			Type:   stringProp(props, "type"),
+		}
+		if err := onEdge(edge); err != nil {
+			return err
+		}
+	}
+	if err := edges.Err(); err != nil {
+		return fmt.Errorf("failed to export relationships: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// FollowedAuthor is an author a user follows.
+type FollowedAuthor struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"displayName"`
+	FollowedAt  time.Time `json:"followedAt"`
+}
+
+// AuthorDigest is the new works of one followed author, newest first.
+type AuthorDigest struct {
+	AuthorID    string    `json:"authorId"`
+	DisplayName string    `json:"displayName"`
+	Works       []NewWork `json:"works"`
+}
+
+// Follow records that a user follows an author, as (:User {id})-[:FOLLOWS {at}]->(:Author).
+// The author node is created if it isn't in the graph yet, so it can be followed while
+// it is being ingested. Following an author again keeps the original date.
+func (r *neo4jRepository) Follow(ctx context.Context, userID, authorID string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		err := r.exec(ctx, tx, "Follow", `
+			MERGE (u:User {id: $userId, tenant: $tenant})
+			MERGE (a:Author {id: $authorId, tenant: $tenant})
+			MERGE (u)-[f:FOLLOWS]->(a)
+			ON CREATE SET f.at = datetime()
+			SET f.tenant = $tenant
+		`, map[string]any{"tenant": tenantOf(ctx), "userId": userID, "authorId": authorID})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to follow author %s for user %s: %w", authorID, userID, err)
+	}
+	return nil
+}
+
+// Unfollow removes a user's FOLLOWS relationship to an author. It returns ErrNotFound if
+// the user didn't follow the author.
+func (r *neo4jRepository) Unfollow(ctx context.Context, userID, authorID string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "Unfollow", `
+			OPTIONAL MATCH (:User {id: $userId, tenant: $tenant})-[f:FOLLOWS]->(:Author {id: $authorId, tenant: $tenant})
+			DELETE f
+			RETURN count(f) AS removed
+		`, map[string]any{"tenant": tenantOf(ctx), "userId": userID, "authorId": authorID})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return intProp(record.AsMap(), "removed"), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unfollow author %s for user %s: %w", authorID, userID, err)
+	}
+	if result.(int) == 0 {
+		return fmt.Errorf("user %s does not follow author %s: %w", userID, authorID, ErrNotFound)
+	}
+	return nil
+}
+
+// ListFollowed returns the authors a user follows, most recently followed first. A user
+// who follows nobody gets an empty list.
+func (r *neo4jRepository) ListFollowed(ctx context.Context, userID string) ([]FollowedAuthor, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "ListFollowed", `
+			MATCH (:User {id: $userId, tenant: $tenant})-[f:FOLLOWS]->(a:Author)
+			RETURN a.id AS id, a.displayName AS displayName, f.at AS followedAt
+			ORDER BY followedAt DESC, id
+		`, map[string]any{"tenant": tenantOf(ctx), "userId": userID})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		authors := make([]FollowedAuthor, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			followedAt, _ := props["followedAt"].(time.Time)
+			authors = append(authors, FollowedAuthor{
+				ID:          stringProp(props, "id"),
+				DisplayName: stringProp(props, "displayName"),
+				FollowedAt:  followedAt.UTC(),
+			})
+		}
+		return authors, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list authors followed by user %s: %w", userID, err)
+	}
+	return result.([]FollowedAuthor), nil
+}
+
+// GetDigest returns the works first saved after since by the authors a user follows,
+// grouped by author, as GetWorksAddedSince would for each of them. Followed authors
+// without new works are left out; a work by several followed authors is listed under
+// each. Authors are ordered by name.
+func (r *neo4jRepository) GetDigest(ctx context.Context, userID string, since time.Time) ([]AuthorDigest, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetDigest", `
+			MATCH (:User {id: $userId, tenant: $tenant})-[:FOLLOWS]->(a:Author)-[:AUTHORED]->(w:Work)
+			WHERE w.firstSeen > $since
+			WITH a, w
+			ORDER BY w.firstSeen DESC, w.id
+			RETURN a.id AS authorId, a.displayName AS displayName,
+				collect({id: w.id, doi: w.doi, title: w.title,
+					publicationYear: w.publicationYear, publicationDate: w.publicationDate,
+					isRetracted: coalesce(w.isRetracted, false), firstSeen: w.firstSeen}) AS works
+			ORDER BY displayName, authorId
+		`, map[string]any{"tenant": tenantOf(ctx), "userId": userID, "since": since.UTC()})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		digest := make([]AuthorDigest, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			rows, _ := props["works"].([]any)
+			works := make([]NewWork, 0, len(rows))
+			for _, row := range rows {
+				workProps, _ := row.(map[string]any)
+				firstSeen, _ := workProps["firstSeen"].(time.Time)
+				works = append(works, NewWork{DehydratedWork: dehydratedWorkFromProps(workProps), FirstSeen: firstSeen.UTC()})
+			}
+			digest = append(digest, AuthorDigest{
+				AuthorID:    stringProp(props, "authorId"),
+				DisplayName: stringProp(props, "displayName"),
+				Works:       works,
+			})
+		}
+		return digest, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read digest of user %s: %w", userID, err)
+	}
+	return result.([]AuthorDigest), nil
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestFollow(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W1", Authorships: []domain.Authorship{authorship("A1")}}, FullSave); err != nil {
+		t.Fatal(err)
+	}
+	// A2 isn't in the graph yet: it is followed while it is being ingested.
+	for _, follow := range [][2]string{{"alice", "A1"}, {"alice", "A2"}, {"bob", "A1"}} {
+		if err := r.Follow(ctx, follow[0], follow[1]); err != nil {
+			t.Fatalf("Follow(%s, %s): %v", follow[0], follow[1], err)
+		}
+	}
+	followedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query(t, r, ctx, `
+		MATCH (:User {id: 'alice', tenant: $tenant})-[f:FOLLOWS]->(:Author {id: 'A1'})
+		SET f.at = $at
+	`, map[string]any{"at": followedAt})
+	// Following again keeps the date A1 was first followed.
+	if err := r.Follow(ctx, "alice", "A1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(authors []FollowedAuthor) []string {
+		ids := []string{}
+		for _, author := range authors {
+			ids = append(ids, author.ID)
+		}
+		return ids
+	}
+	followed, err := r.ListFollowed(ctx, "alice")
+	if err != nil {
+		t.Fatalf("ListFollowed: %v", err)
+	}
+	if got := ids(followed); !reflect.DeepEqual(got, []string{"A2", "A1"}) {
+		t.Fatalf("alice follows %v, want [A2 A1], most recent first", got)
+	}
+	if followed[1].DisplayName != "A1" || !followed[1].FollowedAt.Equal(followedAt) || followed[0].FollowedAt.IsZero() {
+		t.Errorf("followed = %+v, want A1 followed on %v", followed, followedAt)
+	}
+	if n := len(query(t, r, ctx, `MATCH (a:Author {tenant: $tenant}) RETURN a`, nil)); n != 2 {
+		t.Errorf("%d authors, want A1 and the followed A2", n)
+	}
+
+	if err := r.Unfollow(ctx, "alice", "A2"); err != nil {
+		t.Fatalf("Unfollow: %v", err)
+	}
+	tests := []struct {
+		name   string
+		user   string
+		author string
+	}{
+		{"unfollowed already", "alice", "A2"},
+		{"never followed", "bob", "A2"},
+		{"unknown user", "carol", "A1"},
+		{"unknown author", "alice", "A404"},
+	}
+	for _, tt := range tests {
+		if err := r.Unfollow(ctx, tt.user, tt.author); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: Unfollow(%s, %s) error = %v, want ErrNotFound", tt.name, tt.user, tt.author, err)
+		}
+	}
+	for user, want := range map[string][]string{"alice": {"A1"}, "bob": {"A1"}, "carol": {}} {
+		followed, err := r.ListFollowed(ctx, user)
+		if err != nil || !reflect.DeepEqual(ids(followed), want) {
+			t.Errorf("%s follows %v, %v, want %v", user, ids(followed), err, want)
+		}
+	}
+	if followed, err := r.ListFollowed(newTestTenant(t, r), "alice"); err != nil || len(followed) != 0 {
+		t.Errorf("alice follows %v, %v for another tenant, want nobody", ids(followed), err)
+	}
+}
+
+func TestFollowsMoveWithMergedAuthors(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	for _, work := range []domain.Work{
+		{ID: "W1", Authorships: []domain.Authorship{authorship("A1")}},
+		{ID: "W2", Authorships: []domain.Authorship{authorship("A2")}},
+	} {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, follow := range [][2]string{{"alice", "A1"}, {"bob", "A1"}, {"bob", "A2"}} {
+		if err := r.Follow(ctx, follow[0], follow[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.RecordAuthorMerge(ctx, "A1", "A2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.MergeAuthorAlias(ctx, "A1"); err != nil {
+		t.Fatalf("MergeAuthorAlias: %v", err)
+	}
+	for _, user := range []string{"alice", "bob"} {
+		followed, err := r.ListFollowed(ctx, user)
+		if err != nil || len(followed) != 1 || followed[0].ID != "A2" {
+			t.Errorf("%s follows %+v, %v after the merge, want only A2", user, followed, err)
+		}
+	}
+}
+
+func TestGetDigest(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	zed := domain.Authorship{Author: domain.DehydratedAuthor{ID: "A1", DisplayName: "Zed"}}
+	ada := domain.Authorship{Author: domain.DehydratedAuthor{ID: "A2", DisplayName: "Ada"}}
+	day := func(month time.Month, day int) time.Time { return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC) }
+	works := []struct {
+		work      domain.Work
+		firstSeen time.Time
+	}{
+		{domain.Work{ID: "W1", Title: "old", Authorships: []domain.Authorship{zed}}, day(time.January, 1).AddDate(0, -6, 0)},
+		{domain.Work{ID: "W2", Title: "new", PublicationYear: 2023, Authorships: []domain.Authorship{zed}}, day(time.February, 1)},
+		{domain.Work{ID: "W3", Title: "joint", Authorships: []domain.Authorship{zed, ada}}, day(time.March, 1)},
+		{domain.Work{ID: "W4", Title: "on the cutoff", Authorships: []domain.Authorship{zed}}, day(time.January, 1)},
+		{domain.Work{ID: "W5", Title: "old", Authorships: []domain.Authorship{ada}}, day(time.January, 1).AddDate(0, -1, 0)},
+		{domain.Work{ID: "W6", Title: "not followed", Authorships: []domain.Authorship{authorship("A3")}}, day(time.March, 1)},
+		{domain.Work{ID: "W7", Title: "nothing new", Authorships: []domain.Authorship{authorship("A4")}}, day(time.January, 1).AddDate(-1, 0, 0)},
+	}
+	for _, w := range works {
+		if _, err := r.SaveWork(ctx, w.work, FullSave); err != nil {
+			t.Fatal(err)
+		}
+		query(t, r, ctx, `MATCH (w:Work {id: $id, tenant: $tenant}) SET w.firstSeen = $firstSeen`,
+			map[string]any{"id": w.work.ID, "firstSeen": w.firstSeen})
+	}
+	for _, author := range []string{"A1", "A2", "A4"} {
+		if err := r.Follow(ctx, "alice", author); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Follow(ctx, "bob", "A3"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		user  string
+		since time.Time
+		want  map[string][]string // works by author, in order
+		order []string            // the authors, in order
+	}{
+		{"grouped by author", "alice", day(time.January, 1),
+			map[string][]string{"A2": {"W3"}, "A1": {"W3", "W2"}}, []string{"A2", "A1"}},
+		{"later cutoff", "alice", day(time.February, 15),
+			map[string][]string{"A2": {"W3"}, "A1": {"W3"}}, []string{"A2", "A1"}},
+		{"earlier cutoff", "alice", day(time.January, 1).AddDate(0, -2, 0),
+			map[string][]string{"A2": {"W3", "W5"}, "A1": {"W3", "W2", "W4"}}, []string{"A2", "A1"}},
+		{"nothing new", "alice", day(time.April, 1), map[string][]string{}, []string{}},
+		{"another user", "bob", day(time.January, 1), map[string][]string{"A3": {"W6"}}, []string{"A3"}},
+		{"nobody followed", "carol", day(time.January, 1), map[string][]string{}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest, err := r.GetDigest(ctx, tt.user, tt.since)
+			if err != nil {
+				t.Fatalf("GetDigest: %v", err)
+			}
+			order := []string{}
+			got := map[string][]string{}
+			for _, author := range digest {
+				order = append(order, author.AuthorID)
+				for _, work := range author.Works {
+					got[author.AuthorID] = append(got[author.AuthorID], work.ID)
+				}
+			}
+			if !reflect.DeepEqual(order, tt.order) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("digest = %v in the order %v, want %v in the order %v", got, order, tt.want, tt.order)
+			}
+		})
+	}
+
+	digest, err := r.GetDigest(ctx, "alice", day(time.January, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AuthorDigest{
+		{AuthorID: "A2", DisplayName: "Ada", Works: []NewWork{
+			{DehydratedWork: domain.DehydratedWork{ID: "W3", Title: "joint"}, FirstSeen: day(time.March, 1)},
+		}},
+		{AuthorID: "A1", DisplayName: "Zed", Works: []NewWork{
+			{DehydratedWork: domain.DehydratedWork{ID: "W3", Title: "joint"}, FirstSeen: day(time.March, 1)},
+			{DehydratedWork: domain.DehydratedWork{ID: "W2", Title: "new", PublicationYear: 2023, PublicationDate: "2023-01-01"}, FirstSeen: day(time.February, 1)},
+		}},
+	}
+	if !reflect.DeepEqual(digest, want) {
+		t.Errorf("digest = %+v\nwant %+v", digest, want)
+	}
+	if digest, err := r.GetDigest(newTestTenant(t, r), "alice", day(time.January, 1)); err != nil || len(digest) != 0 {
+		t.Errorf("digest for another tenant = %+v, %v, want none", digest, err)
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// funderRows groups a work's grants by funder, in the order the funders first appear, with
+// each funder's distinct non-empty award IDs. Grants without a funder are skipped.
+func funderRows(grants []domain.Grant) []map[string]any {
+	rows := []map[string]any{}
+	index := map[string]int{}
+	for _, grant := range grants {
+		if grant.Funder == "" {
+			continue
+		}
+		i, ok := index[grant.Funder]
+		if !ok {
+			i = len(rows)
+			index[grant.Funder] = i
+			rows = append(rows, map[string]any{"funderId": grant.Funder, "displayName": grant.FunderDisplayName, "awardIds": []string{}})
+		}
+		awardIDs := rows[i]["awardIds"].([]string)
+		if grant.AwardID != "" && !slices.Contains(awardIDs, grant.AwardID) {
+			rows[i]["awardIds"] = append(awardIDs, grant.AwardID)
+		}
+	}
+	return rows
+}
+
+// Funder is a funder of an author's works, with how many of the works it funded and how
+// many distinct awards those grants name.
+type Funder struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	WorksCount  int    `json:"worksCount"`
+	AwardsCount int    `json:"awardsCount"`
+}
+
+// GetAuthorFunders returns the funders of the author's works, the one that funded the most
+// works first. Authors whose works have no grant data get an empty list; ErrNotFound is
+// returned if the author is not in the graph.
+func (r *neo4jRepository) GetAuthorFunders(ctx context.Context, authorID string) ([]Funder, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetAuthorFunders", `
+			MATCH (a:Author {id: $authorId, tenant: $tenant})
+			OPTIONAL MATCH (a)-[:AUTHORED]->(w:Work)-[fb:FUNDED_BY]->(f:Funder)
+			WITH f, count(DISTINCT w) AS works, collect(fb.awardIds) AS awardLists
+			WITH f, works, reduce(acc = [], awards IN awardLists |
+				acc + [x IN coalesce(awards, []) WHERE NOT x IN acc]) AS awards
+			RETURN f.id AS id, f.displayName AS displayName, works, size(awards) AS awards
+			ORDER BY works DESC, awards DESC, displayName
+		`, map[string]any{"tenant": tenantOf(ctx), "authorId": authorID})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		funders := []Funder{}
+		for _, record := range records {
+			props := record.AsMap()
+			if props["id"] == nil {
+				continue // None of the author's works has a funder.
+			}
+			funders = append(funders, Funder{
+				ID:          stringProp(props, "id"),
+				DisplayName: stringProp(props, "displayName"),
+				WorksCount:  intProp(props, "works"),
+				AwardsCount: intProp(props, "awards"),
+			})
+		}
+		return funders, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read funders of author %s: %w", authorID, err)
+	}
+	return result.([]Funder), nil
+}
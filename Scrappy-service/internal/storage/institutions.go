@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// GetInstitutionStubs returns the ids of up to limit Institution nodes that were only
+// created from work authorships (id, name and country) and never enriched.
+func (r *neo4jRepository) GetInstitutionStubs(ctx context.Context, limit int) ([]string, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetInstitutionStubs", `
+			MATCH (i:Institution {tenant: $tenant})
+			WHERE i.enrichedAt IS NULL
+			RETURN i.id AS id
+			ORDER BY i.id
+			LIMIT $limit
+		`, map[string]any{"tenant": tenantOf(ctx), "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, stringProp(record.AsMap(), "id"))
+		}
+		return ids, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read institution stubs: %w", err)
+	}
+	return result.([]string), nil
+}
+
+// SaveInstitution creates or updates an Institution node with the full OpenAlex metadata and
+// stamps it as enriched. Its associated institutions are linked as well, creating stubs for
+// the ones not in the graph yet: (:Institution)-[:CHILD_OF]->(:Institution) from child to
+// parent, and RELATED_TO for related institutions.
+func (r *neo4jRepository) SaveInstitution(ctx context.Context, institution domain.Institution) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		err := r.exec(ctx, tx, "SaveInstitution/node", `
+			MERGE (i:Institution {id: $id, tenant: $tenant})
+			SET i.displayName = $displayName, i.ror = $ror, i.countryCode = $countryCode,
+				i.type = $type, i.homepageUrl = $homepageUrl, i.worksCount = $worksCount,
+				i.citedByCount = $citedByCount, i.city = $city, i.latitude = $latitude,
+				i.longitude = $longitude, i.enrichedAt = datetime(),
+				`+stampSource("i")+`
+		`, map[string]any{
+			"tenant":       tenantOf(ctx),
+			"source":       SourceOpenAlex,
+			"id":           institution.ID,
+			"displayName":  institution.DisplayName,
+			"ror":          institution.Ror,
+			"countryCode":  institution.CountryCode,
+			"type":         institution.Type,
+			"homepageUrl":  institution.HomepageUrl,
+			"worksCount":   institution.WorksCount,
+			"citedByCount": institution.CitedByCount,
+			"city":         institution.Geo.City,
+			"latitude":     institution.Geo.Latitude,
+			"longitude":    institution.Geo.Longitude,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var parents, children, related []map[string]any
+		for _, assoc := range institution.AssociatedInstitutions {
+			if assoc.ID == "" || assoc.ID == institution.ID {
+				continue
+			}
+			row := map[string]any{"id": assoc.ID, "displayName": assoc.DisplayName, "countryCode": assoc.CountryCode}
+			switch assoc.Relationship {
+			case domain.InstitutionParent:
+				parents = append(parents, row)
+			case domain.InstitutionChild:
+				children = append(children, row)
+			case domain.InstitutionRelated:
+				related = append(related, row)
+			}
+		}
+		err = r.exec(ctx, tx, "SaveInstitution/hierarchy", `
+			MATCH (i:Institution {id: $id, tenant: $tenant})
+			CALL {
+				WITH i
+				UNWIND $parents AS row
+				MERGE (p:Institution {id: row.id, tenant: $tenant}) ON CREATE SET p.displayName = row.displayName, p.countryCode = row.countryCode, `+stampSource("p")+`
+				MERGE (i)-[rel:CHILD_OF]->(p)
+				SET rel.tenant = $tenant, `+stampSource("rel")+`
+			}
+			CALL {
+				WITH i
+				UNWIND $children AS row
+				MERGE (c:Institution {id: row.id, tenant: $tenant}) ON CREATE SET c.displayName = row.displayName, c.countryCode = row.countryCode, `+stampSource("c")+`
+				MERGE (c)-[rel:CHILD_OF]->(i)
+				SET rel.tenant = $tenant, `+stampSource("rel")+`
+			}
+			CALL {
+				WITH i
+				UNWIND $related AS row
+				MERGE (o:Institution {id: row.id, tenant: $tenant}) ON CREATE SET o.displayName = row.displayName, o.countryCode = row.countryCode, `+stampSource("o")+`
+				MERGE (i)-[rel:RELATED_TO]->(o)
+				SET rel.tenant = $tenant, `+stampSource("rel")+`
+			}
+		`, map[string]any{"tenant": tenantOf(ctx), "id": institution.ID, "parents": parents, "children": children, "related": related, "source": SourceOpenAlex})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save institution %s: %w", institution.ID, err)
+	}
+	return nil
+}
+
+// maxInstitutionDepth caps how many CHILD_OF levels an institution rollup descends. OpenAlex
+// hierarchies are shallow (university, faculty, department), but they do contain cycles,
+// and the cap keeps a cyclic hierarchy from blowing up the traversal.
+const maxInstitutionDepth = 6
+
+// InstitutionRollup counts the works and authors the graph holds for an institution, and
+// with IncludeChildren for the institutions below it as well. Children lists the ids of
+// those descendant institutions.
+type InstitutionRollup struct {
+	ID              string   `json:"id"`
+	DisplayName     string   `json:"displayName"`
+	IncludeChildren bool     `json:"includeChildren"`
+	Children        []string `json:"children"`
+	WorksCount      int      `json:"worksCount"`
+	AuthorsCount    int      `json:"authorsCount"`
+	TotalCitations  int      `json:"totalCitations"`
+}
+
+// GetInstitutionWorksRolledUp counts the works with an authorship at the institution and
+// the authors of those authorships. With includeChildren, institutions linked to it through
+// CHILD_OF (up to maxInstitutionDepth levels down) count too, so a department's output rolls
+// up to its university; a work is counted once however many of them it lists. Works are
+// found through their AFFILIATED_ON_WORK edges, so works saved before those existed only
+// count once ReconcileWorkAffiliations has run. It returns ErrNotFound if the institution
+// is not in the graph.
+func (r *neo4jRepository) GetInstitutionWorksRolledUp(ctx context.Context, instID string, includeChildren bool) (*InstitutionRollup, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	// Variable-length patterns never repeat a relationship within a path, and the depth cap
+	// bounds the paths a cycle can produce; DISTINCT folds institutions reached twice. The
+	// works are reached from the institutions, not by scanning every authorship, and edges
+	// written before tenants existed have no tenant and belong to the shared namespace.
+	query := fmt.Sprintf(`
+		MATCH (i:Institution {id: $id, tenant: $tenant})
+		OPTIONAL MATCH (child:Institution)-[:CHILD_OF*1..%d]->(i)
+		WHERE $includeChildren AND child.tenant = $tenant AND child <> i
+		WITH i, collect(DISTINCT child) AS children
+		WITH i, children, [i] + children AS units
+		CALL {
+			WITH units
+			UNWIND units AS unit
+			MATCH (w:Work)-[aw:AFFILIATED_ON_WORK]->(unit)
+			WHERE coalesce(aw.tenant, '') = $tenant
+			RETURN collect(DISTINCT w) AS works
+		}
+		CALL {
+			WITH units
+			UNWIND units AS unit
+			MATCH (:Work)-[aw:AFFILIATED_ON_WORK]->(unit)
+			WHERE coalesce(aw.tenant, '') = $tenant
+			UNWIND coalesce(aw.authorIds, []) AS authorId
+			RETURN count(DISTINCT authorId) AS authors
+		}
+		RETURN i.displayName AS displayName, [c IN children | c.id] AS children, size(works) AS works,
+			authors, reduce(total = 0, x IN works | total + coalesce(x.citedByCount, 0)) AS citations
+	`, maxInstitutionDepth)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetInstitutionWorksRolledUp", query, map[string]any{"tenant": tenantOf(ctx), "id": instID, "includeChildren": includeChildren})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		props := records[0].AsMap()
+		return &InstitutionRollup{
+			ID:              instID,
+			DisplayName:     stringProp(props, "displayName"),
+			IncludeChildren: includeChildren,
+			Children:        stringsProp(props, "children"),
+			WorksCount:      intProp(props, "works"),
+			AuthorsCount:    intProp(props, "authors"),
+			TotalCitations:  intProp(props, "citations"),
+		}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll up institution %s: %w", instID, err)
+	}
+	return result.(*InstitutionRollup), nil
+}
+
+// GetInstitutionByROR returns the institution whose ror property is the given ROR ID, in
+// OpenAlex's URL form (https://ror.org/03yrm5c26). Stubs have only the properties their
+// authorships carried. It returns ErrNotFound if no institution in the graph has the ROR.
+func (r *neo4jRepository) GetInstitutionByROR(ctx context.Context, ror string) (*domain.Institution, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetInstitutionByROR", `
+			MATCH (i:Institution {ror: $ror, tenant: $tenant})
+			RETURN i.id AS id, i.displayName AS displayName, i.ror AS ror, i.countryCode AS countryCode,
+				i.type AS type, i.homepageUrl AS homepageUrl, i.worksCount AS worksCount,
+				i.citedByCount AS citedByCount, i.city AS city, i.latitude AS latitude, i.longitude AS longitude
+			ORDER BY i.enrichedAt IS NULL, i.id
+			LIMIT 1
+		`, map[string]any{"tenant": tenantOf(ctx), "ror": ror})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		props := records[0].AsMap()
+		latitude, _ := props["latitude"].(float64)
+		longitude, _ := props["longitude"].(float64)
+		return &domain.Institution{
+			ID:           stringProp(props, "id"),
+			DisplayName:  stringProp(props, "displayName"),
+			Ror:          stringProp(props, "ror"),
+			CountryCode:  stringProp(props, "countryCode"),
+			Type:         stringProp(props, "type"),
+			HomepageUrl:  stringProp(props, "homepageUrl"),
+			WorksCount:   intProp(props, "worksCount"),
+			CitedByCount: intProp(props, "citedByCount"),
+			Geo:          domain.InstitutionGeo{City: stringProp(props, "city"), Latitude: latitude, Longitude: longitude},
+		}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read institution with ROR %s: %w", ror, err)
+	}
+	return result.(*domain.Institution), nil
+}
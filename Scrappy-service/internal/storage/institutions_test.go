@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// associated returns an associated institution with the given relationship.
+func associated(id, relationship string) domain.AssociatedInstitution {
+	return domain.AssociatedInstitution{
+		DehydratedInstitution: domain.DehydratedInstitution{ID: id, DisplayName: "name of " + id},
+		Relationship:          relationship,
+	}
+}
+
+func TestGetInstitutionWorksRolledUp(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	// University U has faculty F, which has department D. F lists U as its parent and D as
+	// its child; R is only related. X and Y are each other's parent, and C is a child of X.
+	institutions := []domain.Institution{
+		{ID: "F", DisplayName: "Faculty", AssociatedInstitutions: []domain.AssociatedInstitution{
+			associated("U", domain.InstitutionParent), associated("D", domain.InstitutionChild),
+			associated("R", domain.InstitutionRelated), associated("F", domain.InstitutionParent), {},
+		}},
+		{ID: "X", DisplayName: "X", AssociatedInstitutions: []domain.AssociatedInstitution{
+			associated("Y", domain.InstitutionParent), associated("C", domain.InstitutionChild),
+		}},
+		{ID: "Y", DisplayName: "Y", AssociatedInstitutions: []domain.AssociatedInstitution{associated("X", domain.InstitutionParent)}},
+	}
+	for _, institution := range institutions {
+		if err := r.SaveInstitution(ctx, institution); err != nil {
+			t.Fatalf("SaveInstitution(%s): %v", institution.ID, err)
+		}
+	}
+	inst := func(id string) domain.DehydratedInstitution { return domain.DehydratedInstitution{ID: id} }
+	works := []domain.Work{
+		{ID: "W1", CitedByCount: 10, Authorships: []domain.Authorship{authorship("A1", inst("U"))}},
+		{ID: "W2", CitedByCount: 5, Authorships: []domain.Authorship{authorship("A2", inst("F"))}},
+		{ID: "W3", CitedByCount: 2, Authorships: []domain.Authorship{authorship("A3", inst("D")), authorship("A4", inst("D"))}},
+		// Listing the department and the university counts once.
+		{ID: "W4", CitedByCount: 1, Authorships: []domain.Authorship{authorship("A1", inst("U"), inst("D"))}},
+		{ID: "W5", CitedByCount: 100, Authorships: []domain.Authorship{authorship("A5", inst("R"))}},
+		{ID: "W6", CitedByCount: 7, Authorships: []domain.Authorship{authorship("A6", inst("X"))}},
+		{ID: "W7", CitedByCount: 3, Authorships: []domain.Authorship{authorship("A7", inst("C"))}},
+	}
+	for _, work := range works {
+		if _, err := r.SaveWork(ctx, work, SaveOptions{}); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		id              string
+		includeChildren bool
+		want            InstitutionRollup
+	}{
+		{"university alone", "U", false, InstitutionRollup{Children: []string{}, WorksCount: 2, AuthorsCount: 1, TotalCitations: 11}},
+		{"university rolled up", "U", true, InstitutionRollup{Children: []string{"D", "F"}, WorksCount: 4, AuthorsCount: 4, TotalCitations: 18}},
+		{"faculty rolled up", "F", true, InstitutionRollup{DisplayName: "Faculty", Children: []string{"D"}, WorksCount: 3, AuthorsCount: 4, TotalCitations: 8}},
+		{"department without children", "D", true, InstitutionRollup{DisplayName: "name of D", Children: []string{}, WorksCount: 2, AuthorsCount: 3, TotalCitations: 3}},
+		{"related institution", "R", true, InstitutionRollup{DisplayName: "name of R", Children: []string{}, WorksCount: 1, AuthorsCount: 1, TotalCitations: 100}},
+		{"cycle", "Y", true, InstitutionRollup{DisplayName: "Y", Children: []string{"C", "X"}, WorksCount: 2, AuthorsCount: 2, TotalCitations: 10}},
+		{"cycle from the other side", "X", true, InstitutionRollup{DisplayName: "X", Children: []string{"C", "Y"}, WorksCount: 2, AuthorsCount: 2, TotalCitations: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			got, err := r.GetInstitutionWorksRolledUp(ctx, tt.id, tt.includeChildren)
+			if err != nil {
+				t.Fatalf("GetInstitutionWorksRolledUp: %v", err)
+			}
+			sort.Strings(got.Children)
+			want := tt.want
+			want.ID, want.IncludeChildren = tt.id, tt.includeChildren
+			if want.DisplayName == "" && tt.id == "U" {
+				want.DisplayName = "name of U"
+			}
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("rollup = %+v, want %+v", *got, want)
+			}
+		})
+	}
+
+	links := query(t, r, ctx, `
+		MATCH (a:Institution {tenant: $tenant})-[rel]->(b:Institution)
+		RETURN a.id + ' ' + type(rel) + ' ' + b.id AS link ORDER BY link
+	`, nil)
+	var got []string
+	for _, link := range links {
+		got = append(got, link["link"].(string))
+	}
+	want := []string{"C CHILD_OF X", "D CHILD_OF F", "F CHILD_OF U", "F RELATED_TO R", "X CHILD_OF Y", "Y CHILD_OF X"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("institution links = %v, want %v", got, want)
+	}
+
+	if _, err := r.GetInstitutionWorksRolledUp(ctx, "I404", true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetInstitutionWorksRolledUp(I404) error = %v, want ErrNotFound", err)
+	}
+}
+
+// Authorships saved before tenants existed are AFFILIATED_ON_WORK edges without a tenant,
+// in the shared namespace, and still roll up.
+func TestGetInstitutionWorksRolledUpLegacyEdges(t *testing.T) {
+	r, _ := newTestRepo(t)
+	ctx, p := newSharedFixture(t, r)
+	query(t, r, ctx, `
+		CREATE (u:Institution {id: $p + 'U', tenant: '', displayName: 'University'}),
+			(d:Institution {id: $p + 'D', tenant: ''})-[:CHILD_OF]->(u),
+			(:Work {id: $p + 'W1', tenant: '', citedByCount: 4})-[:AFFILIATED_ON_WORK {authorIds: [$p + 'A1', $p + 'A2']}]->(u),
+			(w2:Work {id: $p + 'W2', tenant: '', citedByCount: 1})-[:AFFILIATED_ON_WORK {authorIds: [$p + 'A1']}]->(d),
+			(w2)-[:AFFILIATED_ON_WORK {tenant: '', authorIds: [$p + 'A3']}]->(u)
+	`, map[string]any{"p": p})
+
+	tests := []struct {
+		includeChildren bool
+		want            InstitutionRollup
+	}{
+		{false, InstitutionRollup{Children: []string{}, WorksCount: 2, AuthorsCount: 3, TotalCitations: 5}},
+		{true, InstitutionRollup{Children: []string{p + "D"}, WorksCount: 2, AuthorsCount: 3, TotalCitations: 5}},
+	}
+	for _, tt := range tests {
+		got, err := r.GetInstitutionWorksRolledUp(ctx, p+"U", tt.includeChildren)
+		if err != nil {
+			t.Fatalf("GetInstitutionWorksRolledUp: %v", err)
+		}
+		want := tt.want
+		want.ID, want.DisplayName, want.IncludeChildren = p+"U", "University", tt.includeChildren
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("rollup = %+v, want %+v", *got, want)
+		}
+	}
+	// Another tenant sees none of it.
+	if _, err := r.GetInstitutionWorksRolledUp(newTestTenant(t, r), p+"U", true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("rollup for another tenant error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetInstitutionByROR(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	const ror = "https://ror.org/03yrm5c26"
+
+	// I1 is only a stub from a work's authorship; I2 shares its ROR and is enriched.
+	stub := domain.DehydratedInstitution{ID: "I1", DisplayName: "Stub", Ror: ror}
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W1", Authorships: []domain.Authorship{authorship("A1", stub)}}, SaveOptions{}); err != nil {
+		t.Fatalf("SaveWork: %v", err)
+	}
+	got, err := r.GetInstitutionByROR(ctx, ror)
+	if err != nil || got.ID != "I1" || got.Ror != ror {
+		t.Fatalf("GetInstitutionByROR with only the stub = %+v, %v, want I1", got, err)
+	}
+
+	enriched := domain.Institution{ID: "I2", DisplayName: "University", Ror: ror, CountryCode: "US", WorksCount: 12,
+		Geo: domain.InstitutionGeo{City: "Cambridge", Latitude: 42.37, Longitude: -71.11}}
+	if err := r.SaveInstitution(ctx, enriched); err != nil {
+		t.Fatalf("SaveInstitution: %v", err)
+	}
+	got, err = r.GetInstitutionByROR(ctx, ror)
+	if err != nil {
+		t.Fatalf("GetInstitutionByROR: %v", err)
+	}
+	if !reflect.DeepEqual(*got, enriched) {
+		t.Errorf("GetInstitutionByROR = %+v, want the enriched %+v", *got, enriched)
+	}
+
+	if _, err := r.GetInstitutionByROR(ctx, "https://ror.org/00f54p054"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetInstitutionByROR(unknown) error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.GetInstitutionByROR(newTestTenant(t, r), ror); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetInstitutionByROR for another tenant error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// maxMergeHops bounds how far ResolveAuthorID and ResolveWorkID follow MERGED_INTO chains.
+const maxMergeHops = 5
+
+// RecordAuthorMerge notes that OpenAlex merged the author oldID into canonicalID. The old
+// ID is kept as a marker node pointing at the canonical author via MERGED_INTO.
+func (r *neo4jRepository) RecordAuthorMerge(ctx context.Context, oldID, canonicalID string) error {
+	if err := r.recordMerge(ctx, "Author", oldID, canonicalID); err != nil {
+		return fmt.Errorf("failed to record merge of author %s into %s: %w", oldID, canonicalID, err)
+	}
+	return nil
+}
+
+// ResolveAuthorID follows MERGED_INTO markers from id to the canonical author ID. IDs
+// that were never merged, or aren't in the graph at all, resolve to themselves.
+func (r *neo4jRepository) ResolveAuthorID(ctx context.Context, id string) (string, error) {
+	resolved, err := r.resolveMerges(ctx, "Author", id)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve author %s: %w", id, err)
+	}
+	return resolved, nil
+}
+
+// RecordWorkMerge notes that OpenAlex merged the work oldID into canonicalID, like
+// RecordAuthorMerge. An old node that is still a stub keeps its CITES edges until the
+// canonical work is ingested; reads of the old ID follow the marker.
+func (r *neo4jRepository) RecordWorkMerge(ctx context.Context, oldID, canonicalID string) error {
+	if err := r.recordMerge(ctx, "Work", oldID, canonicalID); err != nil {
+		return fmt.Errorf("failed to record merge of work %s into %s: %w", oldID, canonicalID, err)
+	}
+	return nil
+}
+
+// ResolveWorkID follows MERGED_INTO markers from id to the canonical work ID. IDs that
+// were never merged, or aren't in the graph at all, resolve to themselves.
+func (r *neo4jRepository) ResolveWorkID(ctx context.Context, id string) (string, error) {
+	resolved, err := r.resolveMerges(ctx, "Work", id)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve work %s: %w", id, err)
+	}
+	return resolved, nil
+}
+
+// recordMerge keeps oldID as a label node marked mergedInto canonicalID, pointing at it
+// via MERGED_INTO. A work marker that doesn't exist yet is created as a stub.
+func (r *neo4jRepository) recordMerge(ctx context.Context, label, oldID, canonicalID string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		err := r.exec(ctx, tx, "Record"+label+"Merge", fmt.Sprintf(`
+			MERGE (old:%[1]s {id: $oldId, tenant: $tenant})
+			ON CREATE SET old.stub = CASE WHEN $label = 'Work' THEN true ELSE null END
+			SET old.mergedInto = $canonicalId
+			MERGE (canonical:%[1]s {id: $canonicalId, tenant: $tenant})
+			ON CREATE SET canonical.stub = CASE WHEN $label = 'Work' THEN true ELSE null END
+			MERGE (old)-[m:MERGED_INTO]->(canonical)
+			SET m.tenant = $tenant
+		`, label), map[string]any{"tenant": tenantOf(ctx), "label": label, "oldId": oldID, "canonicalId": canonicalID})
+		return nil, err
+	})
+	return err
+}
+
+// resolveMerges follows MERGED_INTO markers from the label node id to the end of the chain.
+func (r *neo4jRepository) resolveMerges(ctx context.Context, label, id string) (string, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "Resolve"+label+"ID", fmt.Sprintf(`
+			MATCH p = (a:%[1]s {id: $id, tenant: $tenant})-[:MERGED_INTO*1..%[2]d]->(c:%[1]s)
+			WHERE NOT (c)-[:MERGED_INTO]->()
+			RETURN c.id AS id
+			ORDER BY length(p) DESC
+			LIMIT 1
+		`, label, maxMergeHops), map[string]any{"tenant": tenantOf(ctx), "id": id})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return id, nil
+		}
+		return stringProp(records[0].AsMap(), "id"), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.(string), nil
+}
+
+// authorRelationships are the relationships moved from a merged-away author onto the
+// canonical one. MERGED_INTO itself stays, so the old ID keeps resolving.
+var authorRelationships = []nodeRelationship{
+	{"AUTHORED", false},
+	{"AFFILIATED_WITH", false},
+	{"CURRENTLY_AT", false},
+	{"HAS_TOPIC", false},
+	{"TARGETED", true},
+	{"FOLLOWS", true},
+}
+
+// AuthorAlias is an author ID OpenAlex merged into another whose node still holds data of
+// its own, i.e. one person split over two nodes.
+type AuthorAlias struct {
+	OldID         string `json:"oldId"`
+	CanonicalID   string `json:"canonicalId"`
+	Relationships int    `json:"relationships"`
+}
+
+// FindAuthorMergeCandidates lists the MERGED_INTO markers whose old node still has
+// relationships besides MERGED_INTO, typically because it was ingested before OpenAlex
+// merged it. They are safe to merge with MergeAuthorAlias without review.
+func (r *neo4jRepository) FindAuthorMergeCandidates(ctx context.Context) ([]AuthorAlias, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "FindAuthorMergeCandidates", `
+			MATCH (old:Author)-[:MERGED_INTO]->(canonical:Author)
+			WHERE old.tenant = $tenant
+			MATCH (old)-[rel]-()
+			WHERE type(rel) <> 'MERGED_INTO'
+			RETURN old.id AS oldId, canonical.id AS canonicalId, count(rel) AS relationships
+			ORDER BY oldId
+		`, map[string]any{"tenant": tenantOf(ctx)})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		aliases := make([]AuthorAlias, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			aliases = append(aliases, AuthorAlias{
+				OldID:         stringProp(props, "oldId"),
+				CanonicalID:   stringProp(props, "canonicalId"),
+				Relationships: intProp(props, "relationships"),
+			})
+		}
+		return aliases, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find author merge candidates: %w", err)
+	}
+	return result.([]AuthorAlias), nil
+}
+
+// MergeAuthorAlias moves the AUTHORED, AFFILIATED_WITH, CURRENTLY_AT, HAS_TOPIC, TARGETED
+// and FOLLOWS relationships of oldID onto the author it was merged into, leaving oldID as a
+// bare marker. It returns ErrNotFound if oldID has no MERGED_INTO marker.
+func (r *neo4jRepository) MergeAuthorAlias(ctx context.Context, oldID string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "MergeAuthorAlias", `
+			MATCH (old:Author {id: $oldId, tenant: $tenant})-[:MERGED_INTO]->(canonical:Author)
+			RETURN canonical.id AS canonicalId
+		`, map[string]any{"tenant": tenantOf(ctx), "oldId": oldID})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("author alias %s: %w", oldID, ErrNotFound)
+		}
+		canonicalID := stringProp(records[0].AsMap(), "canonicalId")
+		return nil, r.repointRelationships(ctx, tx, "Author", authorRelationships, canonicalID, oldID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge author %s into its canonical author: %w", oldID, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestResolveAuthorID(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	for _, merge := range [][2]string{{"A1", "A2"}, {"A0", "A1"}, {"A5", "A6"}} {
+		if err := r.RecordAuthorMerge(ctx, merge[0], merge[1]); err != nil {
+			t.Fatalf("RecordAuthorMerge(%s, %s): %v", merge[0], merge[1], err)
+		}
+	}
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"A1", "A2"},
+		{"A0", "A2"}, // Chains are followed to the end.
+		{"A2", "A2"},
+		{"A5", "A6"},
+		{"A9", "A9"}, // Not in the graph at all.
+	}
+	for _, tt := range tests {
+		if got, err := r.ResolveAuthorID(ctx, tt.id); err != nil || got != tt.want {
+			t.Errorf("ResolveAuthorID(%s) = %q, %v; want %q", tt.id, got, err, tt.want)
+		}
+	}
+
+	// Recording the same merge again changes nothing.
+	if err := r.RecordAuthorMerge(ctx, "A1", "A2"); err != nil {
+		t.Fatal(err)
+	}
+	records := query(t, r, ctx, `MATCH (:Author {id: 'A1', tenant: $tenant})-[m:MERGED_INTO]->() RETURN count(m) AS n`, nil)
+	if n := records[0]["n"].(int64); n != 1 {
+		t.Errorf("%d MERGED_INTO edges from A1, want 1", n)
+	}
+}
+
+func TestAuthorAliasesAreMergeCandidates(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	// A1 was ingested before OpenAlex merged it into A2; A3 is only a marker.
+	for _, work := range []domain.Work{
+		{ID: "W1", Title: "old profile", Authorships: []domain.Authorship{authorship("A1")}},
+		{ID: "W2", Title: "new profile", Authorships: []domain.Authorship{authorship("A2")}},
+		{ID: "W3", Title: "both", Authorships: []domain.Authorship{authorship("A1"), authorship("A2")}},
+	} {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, merge := range [][2]string{{"A1", "A2"}, {"A3", "A2"}} {
+		if err := r.RecordAuthorMerge(ctx, merge[0], merge[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	candidates, err := r.FindAuthorMergeCandidates(ctx)
+	if err != nil {
+		t.Fatalf("FindAuthorMergeCandidates: %v", err)
+	}
+	want := []AuthorAlias{{OldID: "A1", CanonicalID: "A2", Relationships: 2}}
+	if !reflect.DeepEqual(candidates, want) {
+		t.Errorf("candidates = %+v, want %+v", candidates, want)
+	}
+
+	if err := r.MergeAuthorAlias(ctx, "A1"); err != nil {
+		t.Fatalf("MergeAuthorAlias: %v", err)
+	}
+	works, err := r.GetAuthorWorks(ctx, "A2", false, true, true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(works) != 3 {
+		t.Errorf("A2 has %d works after the merge, want 3 (W3 once): %v", len(works), works)
+	}
+	if candidates, _ := r.FindAuthorMergeCandidates(ctx); len(candidates) != 0 {
+		t.Errorf("candidates after the merge = %+v, want none", candidates)
+	}
+	if resolved, _ := r.ResolveAuthorID(ctx, "A1"); resolved != "A2" {
+		t.Errorf("A1 resolves to %q after the merge, want A2", resolved)
+	}
+	if err := r.MergeAuthorAlias(ctx, "A2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("merging a canonical author: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// MaxNeighborhoodDepth bounds how many CITES hops GetCitationNeighborhood follows in
+// either direction.
+const MaxNeighborhoodDepth = 3
+
+// NeighborhoodNode is a work of a citation neighborhood. Stubs are cited works that have
+// not been ingested themselves and may lack a title.
+type NeighborhoodNode struct {
+	ID              string `json:"id"`
+	Title           string `json:"title,omitempty"`
+	PublicationYear int    `json:"publicationYear,omitempty"`
+	CitedByCount    int    `json:"citedByCount"`
+	Stub            bool   `json:"stub"`
+}
+
+// CitationEdge is a CITES relationship: Source cites Target.
+type CitationEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// CitationNeighborhood is the citation graph around a work: the work itself first, then
+// its neighbors most cited first, and the CITES edges between any two of them. TotalNodes
+// counts the nodes before truncation to maxNodes.
+type CitationNeighborhood struct {
+	Root       string             `json:"root"`
+	Nodes      []NeighborhoodNode `json:"nodes"`
+	Edges      []CitationEdge     `json:"edges"`
+	TotalNodes int                `json:"totalNodes"`
+	Truncated  bool               `json:"truncated"`
+}
+
+// GetCitationNeighborhood collects the works within depthOut CITES hops from workID (its
+// references, their references, ...) and within depthIn hops to it (its citers, their
+// citers, ...), depths clamped to 0-MaxNeighborhoodDepth. When there are more than maxNodes
+// works, the work itself is kept and the rest are cut deterministically: highest
+// citedByCount first, ties by id. Edges are all CITES relationships among the kept works.
+// ErrNotFound means the work is not in the graph.
+func (r *neo4jRepository) GetCitationNeighborhood(ctx context.Context, workID string, depthIn, depthOut, maxNodes int) (*CitationNeighborhood, error) {
+	depthIn = min(max(depthIn, 0), MaxNeighborhoodDepth)
+	depthOut = min(max(depthOut, 0), MaxNeighborhoodDepth)
+	maxNodes = max(maxNodes, 1)
+
+	// Variable-length bounds can't be parameters; both are clamped integers.
+	refs := "WITH root, [] AS refs"
+	if depthOut > 0 {
+		refs = fmt.Sprintf(`OPTIONAL MATCH (root)-[:CITES*1..%d]->(ref:Work)
+		WHERE ref.tenant = $tenant AND ref <> root
+		WITH root, collect(DISTINCT ref) AS refs`, depthOut)
+	}
+	citers := "WITH root, refs, [] AS citers"
+	if depthIn > 0 {
+		citers = fmt.Sprintf(`OPTIONAL MATCH (citer:Work)-[:CITES*1..%d]->(root)
+		WHERE citer.tenant = $tenant AND citer <> root
+		WITH root, refs, collect(DISTINCT citer) AS citers`, depthIn)
+	}
+	query := fmt.Sprintf(`
+		MATCH (root:Work {id: $id, tenant: $tenant})
+		%s
+		%s
+		UNWIND [root] + refs + citers AS n
+		WITH DISTINCT root, n
+		RETURN n.id AS id, n.title AS title, n.publicationYear AS publicationYear,
+			coalesce(n.citedByCount, 0) AS citedByCount, coalesce(n.stub, false) AS stub,
+			n = root AS isRoot
+	`, refs, citers)
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetCitationNeighborhood/nodes", query, map[string]any{"tenant": tenantOf(ctx), "id": workID})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+
+		hood := &CitationNeighborhood{Root: workID, Edges: []CitationEdge{}}
+		var root NeighborhoodNode
+		neighbors := make([]NeighborhoodNode, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			node := NeighborhoodNode{
+				ID:              stringProp(props, "id"),
+				Title:           stringProp(props, "title"),
+				PublicationYear: intProp(props, "publicationYear"),
+				CitedByCount:    intProp(props, "citedByCount"),
+				Stub:            boolProp(props, "stub"),
+			}
+			if boolProp(props, "isRoot") {
+				root = node
+			} else {
+				neighbors = append(neighbors, node)
+			}
+		}
+		slices.SortFunc(neighbors, func(a, b NeighborhoodNode) int {
+			return cmp.Or(cmp.Compare(b.CitedByCount, a.CitedByCount), cmp.Compare(a.ID, b.ID))
+		})
+		hood.TotalNodes = len(neighbors) + 1
+		if len(neighbors) > maxNodes-1 {
+			neighbors, hood.Truncated = neighbors[:maxNodes-1], true
+		}
+		hood.Nodes = append([]NeighborhoodNode{root}, neighbors...)
+
+		ids := make([]string, 0, len(hood.Nodes))
+		for _, node := range hood.Nodes {
+			ids = append(ids, node.ID)
+		}
+		res, err = r.run(ctx, tx, "GetCitationNeighborhood/edges", `
+			MATCH (a:Work)-[:CITES]->(b:Work)
+			WHERE a.tenant = $tenant AND a.id IN $ids AND b.tenant = $tenant AND b.id IN $ids
+			RETURN a.id AS source, b.id AS target
+			ORDER BY source, target
+		`, map[string]any{"tenant": tenantOf(ctx), "ids": ids})
+		if err != nil {
+			return nil, err
+		}
+		records, err = res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			props := record.AsMap()
+			hood.Edges = append(hood.Edges, CitationEdge{Source: stringProp(props, "source"), Target: stringProp(props, "target")})
+		}
+		return hood, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read citation neighborhood of %s: %w", workID, err)
+	}
+	return result.(*CitationNeighborhood), nil
+}
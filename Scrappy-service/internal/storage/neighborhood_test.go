@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestGetCitationNeighborhood(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	// R cites A and B, A cites C and C cites D. X cites R, Y cites X and Z cites Y. B was
+	// only ever referenced, so it is a stub; U is unrelated.
+	works := []domain.Work{
+		{ID: "R", Title: "root", PublicationYear: 2020, CitedByCount: 3, ReferencedWorks: []string{"A", "B"}},
+		{ID: "A", Title: "a", PublicationYear: 2015, CitedByCount: 50, ReferencedWorks: []string{"C"}},
+		{ID: "C", Title: "c", CitedByCount: 5, ReferencedWorks: []string{"D"}},
+		{ID: "D", Title: "d", CitedByCount: 1},
+		{ID: "X", Title: "x", CitedByCount: 20, ReferencedWorks: []string{"R"}},
+		{ID: "Y", Title: "y", CitedByCount: 20, ReferencedWorks: []string{"X"}},
+		{ID: "Z", Title: "z", CitedByCount: 7, ReferencedWorks: []string{"Y"}},
+		{ID: "U", Title: "u", CitedByCount: 1000, ReferencedWorks: []string{"D"}},
+	}
+	for _, work := range works {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		in, out, max  int
+		wantNodes     []string // in order
+		wantEdges     []string
+		wantTotal     int
+		wantTruncated bool
+	}{
+		{name: "work alone", in: 0, out: 0, max: 200,
+			wantNodes: []string{"R"}, wantEdges: []string{}, wantTotal: 1},
+		{name: "one hop", in: 1, out: 1, max: 200,
+			wantNodes: []string{"R", "A", "X", "B"}, wantEdges: []string{"R->A", "R->B", "X->R"}, wantTotal: 4},
+		{name: "references only", in: 0, out: 2, max: 200,
+			wantNodes: []string{"R", "A", "C", "B"}, wantEdges: []string{"A->C", "R->A", "R->B"}, wantTotal: 4},
+		{name: "citers only", in: 2, out: 0, max: 200,
+			wantNodes: []string{"R", "X", "Y"}, wantEdges: []string{"X->R", "Y->X"}, wantTotal: 3},
+		{name: "three hops", in: 3, out: 3, max: 200,
+			wantNodes: []string{"R", "A", "X", "Y", "Z", "C", "D", "B"},
+			wantEdges: []string{"A->C", "C->D", "R->A", "R->B", "X->R", "Y->X", "Z->Y"}, wantTotal: 8},
+		{name: "depths clamped", in: 5, out: 9, max: 200,
+			wantNodes: []string{"R", "A", "X", "Y", "Z", "C", "D", "B"},
+			wantEdges: []string{"A->C", "C->D", "R->A", "R->B", "X->R", "Y->X", "Z->Y"}, wantTotal: 8},
+		{name: "truncated to the most cited", in: 3, out: 3, max: 3,
+			wantNodes: []string{"R", "A", "X"}, wantEdges: []string{"R->A", "X->R"}, wantTotal: 8, wantTruncated: true},
+		{name: "ties cut by id", in: 2, out: 0, max: 2,
+			wantNodes: []string{"R", "X"}, wantEdges: []string{"X->R"}, wantTotal: 3, wantTruncated: true},
+		{name: "work kept however small the cap", in: 1, out: 1, max: 1,
+			wantNodes: []string{"R"}, wantEdges: []string{}, wantTotal: 4, wantTruncated: true},
+		{name: "exactly at the cap", in: 1, out: 1, max: 4,
+			wantNodes: []string{"R", "A", "X", "B"}, wantEdges: []string{"R->A", "R->B", "X->R"}, wantTotal: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hood, err := r.GetCitationNeighborhood(ctx, "R", tt.in, tt.out, tt.max)
+			if err != nil {
+				t.Fatalf("GetCitationNeighborhood: %v", err)
+			}
+			var nodes []string
+			for _, node := range hood.Nodes {
+				nodes = append(nodes, node.ID)
+			}
+			edges := []string{}
+			for _, edge := range hood.Edges {
+				edges = append(edges, edge.Source+"->"+edge.Target)
+			}
+			if !reflect.DeepEqual(nodes, tt.wantNodes) {
+				t.Errorf("nodes = %v, want %v", nodes, tt.wantNodes)
+			}
+			if !reflect.DeepEqual(edges, tt.wantEdges) {
+				t.Errorf("edges = %v, want %v", edges, tt.wantEdges)
+			}
+			if hood.Root != "R" || hood.TotalNodes != tt.wantTotal || hood.Truncated != tt.wantTruncated {
+				t.Errorf("root, total, truncated = %s, %d, %v, want R, %d, %v", hood.Root, hood.TotalNodes, hood.Truncated, tt.wantTotal, tt.wantTruncated)
+			}
+		})
+	}
+
+	hood, err := r.GetCitationNeighborhood(ctx, "R", 1, 1, 200)
+	if err != nil {
+		t.Fatalf("GetCitationNeighborhood: %v", err)
+	}
+	want := []NeighborhoodNode{
+		{ID: "R", Title: "root", PublicationYear: 2020, CitedByCount: 3},
+		{ID: "A", Title: "a", PublicationYear: 2015, CitedByCount: 50},
+		{ID: "X", Title: "x", CitedByCount: 20},
+		{ID: "B", Stub: true},
+	}
+	if !reflect.DeepEqual(hood.Nodes, want) {
+		t.Errorf("nodes = %+v, want %+v", hood.Nodes, want)
+	}
+
+	if _, err := r.GetCitationNeighborhood(ctx, "W404", 1, 1, 200); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCitationNeighborhood(W404) error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.GetCitationNeighborhood(newTestTenant(t, r), "R", 1, 1, 200); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCitationNeighborhood for another tenant error = %v, want ErrNotFound", err)
+	}
+}
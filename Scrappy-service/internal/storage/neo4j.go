@@ -3,8 +3,11 @@ package storage
 import (
 	"context" // ADDED: Need this for error comparison
 	"fmt"
-	"time"
+	"log"
 	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Cloudforge2/scrappy/internal/domain" // Assumed package path
 	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
@@ -13,19 +16,104 @@ import (
 // Repository defines the interface for all database operations.
 type Repository interface {
 	SaveAuthor(ctx context.Context, author domain.Author) error
-	SaveWork(ctx context.Context, work domain.Work) error
+	SaveAuthors(ctx context.Context, authors []domain.Author) error
+	SaveWork(ctx context.Context, work domain.Work, opts SaveOptions) (SaveOutcome, error)
+	GetWorkProvenance(ctx context.Context, workID string) (*WorkProvenance, error)
 	Close(ctx context.Context) error
+	Ping(ctx context.Context) error
 
 	MarkAuthorFullyIngested(ctx context.Context, authorID string) error
+	SetAuthorWorksSynced(ctx context.Context, authorID string, at time.Time) error
+	GetAuthorWorksSynced(ctx context.Context, authorID string) (time.Time, error)
+
+	AuthorExists(ctx context.Context, id string) (bool, error)
+	WorkExists(ctx context.Context, id string) (bool, error)
+
+	RecordIngestEvent(ctx context.Context, event IngestEvent) error
+	GetIngestHistory(ctx context.Context, targetID string) ([]IngestEvent, error)
+	GetIngestEvent(ctx context.Context, id string) (IngestEvent, error)
+	ListIncompleteIngestEvents(ctx context.Context) ([]IngestEvent, error)
+
+	GetWorksMissingAbstract(ctx context.Context, after string, limit int) ([]domain.DehydratedWork, error)
+	GetAuthorWorks(ctx context.Context, authorID string, onlyFulltext, includeRetracted, newestFirst bool, limit int) ([]domain.DehydratedWork, error)
+	GetWorksAddedSince(ctx context.Context, authorID string, since time.Time) ([]NewWork, error)
+	Follow(ctx context.Context, userID, authorID string) error
+	Unfollow(ctx context.Context, userID, authorID string) error
+	ListFollowed(ctx context.Context, userID string) ([]FollowedAuthor, error)
+	GetDigest(ctx context.Context, userID string, since time.Time) ([]AuthorDigest, error)
+	GetTopWorks(ctx context.Context, limit int, sinceYear int, includeRetracted bool) ([]domain.Work, error)
+	CountCollaborationsByCountry(ctx context.Context, authorID string) (map[string]int, error)
+	GetAuthorWorkTypeBreakdown(ctx context.Context, authorID string) (map[string]int, error)
+	ComputeHIndex(ctx context.Context, authorID string) (int, error)
+	GetHIndexDrift(ctx context.Context, authorID string) (*HIndexDrift, error)
+	CountHIndexDrift(ctx context.Context, threshold int) (int, error)
+	GetAuthorTopicProfile(ctx context.Context, authorID string) (*TopicProfile, error)
+	GetAuthorFunders(ctx context.Context, authorID string) ([]Funder, error)
+	GetTrendingTopics(ctx context.Context, sinceYear int, limit int) ([]TopicTrend, error)
+	GetWorksBridgingTopics(ctx context.Context, topicA, topicB string, minScore float32, page PageRequest) (*BridgingWorks, error)
+	GetAuthorsBridgingTopics(ctx context.Context, topicA, topicB string, minPapers int, page PageRequest) (*BridgingAuthors, error)
+	SearchAuthors(ctx context.Context, query string, limit int) ([]AuthorMatch, error)
+
+	FindDuplicateWorksByDOI(ctx context.Context) ([]DuplicateWorks, error)
+	MergeWorks(ctx context.Context, keepID string, mergeIDs []string) error
+	FindVenueAliases(ctx context.Context) ([]VenueAliases, error)
+	MergeVenues(ctx context.Context, keepID string, mergeIDs []string) error
+
+	ExportGraph(ctx context.Context, onNode func(ExportNode) error, onEdge func(ExportEdge) error) error
+
+	RecordAuthorMerge(ctx context.Context, oldID, canonicalID string) error
+	ResolveAuthorID(ctx context.Context, id string) (string, error)
+	RecordWorkMerge(ctx context.Context, oldID, canonicalID string) error
+	ResolveWorkID(ctx context.Context, id string) (string, error)
+	FindAuthorMergeCandidates(ctx context.Context) ([]AuthorAlias, error)
+	MergeAuthorAlias(ctx context.Context, oldID string) error
+
+	SaveAuthorSSEnrichment(ctx context.Context, authorID string, enrichment SSAuthorEnrichment) error
+
+	LinkRelatedWorksByDOI(ctx context.Context, doi string, relatedDOIs []string, source string) (int, error)
+	GetWorkIDsByDOI(ctx context.Context, dois []string) (map[string]string, error)
+	FindWorkIDs(ctx context.Context, kind, value string) (WorkIDs, error)
+	SetWorkSSPaperID(ctx context.Context, workID, paperID string) error
+	AnnotateCitation(ctx context.Context, citingID, citedID string, props map[string]any) error
+	GetCitedStubs(ctx context.Context, citingIDs []string, limit int) ([]string, error)
+	SetStubMetadata(ctx context.Context, works []domain.DehydratedWork) (int, error)
+	GetCitationNeighborhood(ctx context.Context, workID string, depthIn, depthOut, maxNodes int) (*CitationNeighborhood, error)
+	GetWorksForExport(ctx context.Context, ids []string) ([]domain.Work, error)
+	SaveWorkEmbedding(ctx context.Context, workID string, vec []float32) error
+	GetWorksMissingEmbedding(ctx context.Context, limit int) ([]domain.DehydratedWork, error)
+	GetSimilarityCandidates(ctx context.Context, workID string, maxCandidates int) (*SimilarityCandidates, error)
+
+	GetInstitutionStubs(ctx context.Context, limit int) ([]string, error)
+	SaveInstitution(ctx context.Context, institution domain.Institution) error
+	GetInstitutionWorksRolledUp(ctx context.Context, instID string, includeChildren bool) (*InstitutionRollup, error)
+	GetInstitutionByROR(ctx context.Context, ror string) (*domain.Institution, error)
+
+	SaveVenue(ctx context.Context, source domain.Source) error
+	GetWorksByVenueForAuthor(ctx context.Context, authorID string) (map[string][]domain.DehydratedWork, error)
+	GetVenueSummary(ctx context.Context, venueID string, topAuthors int) (*VenueSummary, error)
+
+	BlockEntity(ctx context.Context, id, reason string) error
+	UnblockEntity(ctx context.Context, id string) error
+	IsBlocked(ctx context.Context, id string) (bool, string, error)
+	DeleteAuthor(ctx context.Context, id string) error
+	PruneOrphans(ctx context.Context, labels []string) (int, error)
+	ReconcileWorkAffiliations(ctx context.Context) (int, error)
 }
 
 // neo4jRepository implements the Repository interface for Neo4j.
 type neo4jRepository struct {
 	driver neo4j.DriverWithContext
+	topics *topicCache
+	// persistTopics is off when the topic hierarchy isn't wanted; SaveWork and SaveAuthor
+	// then skip it, whatever their options say.
+	persistTopics bool
+	// slowQuery is the duration above which a statement is logged; zero disables the log.
+	slowQuery time.Duration
 }
 
 // NewNeo4jRepository creates a new repository and verifies the connection to the database.
-func NewNeo4jRepository(uri, username, password string) (Repository, error) {
+// Without persistTopics no topics are written.
+func NewNeo4jRepository(uri, username, password string, persistTopics bool, opts ...Option) (Repository, error) {
 	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(username, password, ""))
 	if err != nil {
 		return nil, fmt.Errorf("could not create neo4j driver: %w", err)
@@ -34,7 +122,28 @@ func NewNeo4jRepository(uri, username, password string) (Repository, error) {
 		return nil, fmt.Errorf("could not connect to neo4j: %w", err)
 	}
 	fmt.Println("Successfully connected to Neo4j")
-	return &neo4jRepository{driver: driver}, nil
+	repo := &neo4jRepository{driver: driver, topics: newTopicCache(), persistTopics: persistTopics}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	if err := repo.ensureSchema(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// CheckNeo4j connects to the database and disconnects again, without touching the schema
+// or any data. It is the dry connectivity check of check-config.
+func CheckNeo4j(ctx context.Context, uri, username, password string) error {
+	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(username, password, ""))
+	if err != nil {
+		return fmt.Errorf("could not create neo4j driver: %w", err)
+	}
+	defer driver.Close(ctx)
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		return fmt.Errorf("could not connect to neo4j: %w", err)
+	}
+	return nil
 }
 
 // Close closes the connection to the database.
@@ -42,222 +151,394 @@ func (r *neo4jRepository) Close(ctx context.Context) error {
 	return r.driver.Close(ctx)
 }
 
+// Ping checks that the database can be reached. While it can't, the database may be
+// restored or replaced, so the topic cache is dropped and the hierarchy ensured again.
+func (r *neo4jRepository) Ping(ctx context.Context) error {
+	if err := r.driver.VerifyConnectivity(ctx); err != nil {
+		r.topics.reset()
+		return err
+	}
+	return nil
+}
+
 // SaveAuthor creates or updates an Author node with all its properties and relationships.
 func (r *neo4jRepository) SaveAuthor(ctx context.Context, author domain.Author) error {
-	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
+	return r.saveAuthorBatch(ctx, []domain.Author{author})
+}
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		query := `
-			MERGE (a:Author {id: $id})
-			ON CREATE SET
-				a.displayName = $displayName,
-				a.displayNameAlternatives = $displayNameAlternatives,
-				a.orcid = $orcid,
-				a.worksCount = $worksCount,
-				a.citedByCount = $citedByCount,
-				a.updatedDate = $updatedDate,
-				a.lastFetched = $lastFetched,
-				a.fullyIngested = false 
-			ON MATCH SET
-				a.displayName = $displayName,
-				a.displayNameAlternatives = $displayNameAlternatives,
-				a.orcid = $orcid,
-				a.worksCount = $worksCount,
-				a.citedByCount = $citedByCount,
-				a.updatedDate = $updatedDate,
-				a.lastFetched = $lastFetched
-		`
+// authorSaveBatchSize is how many authors SaveAuthors writes per transaction.
+const authorSaveBatchSize = 100
+
+// SaveAuthors saves many authors like SaveAuthor, but batched: each batch of authors is
+// written in one transaction, with one UNWIND statement per kind of node or relationship
+// instead of one statement per author. When a batch fails, its authors are retried one by
+// one, so a single bad author doesn't fail the others; the error is then a
+// *SaveAuthorsError telling which authors were not saved.
+func (r *neo4jRepository) SaveAuthors(ctx context.Context, authors []domain.Author) error {
+	results := make([]AuthorSaveResult, len(authors))
+	failed := 0
+	for start := 0; start < len(authors); start += authorSaveBatchSize {
+		batch := authors[start:min(start+authorSaveBatchSize, len(authors))]
+		err := r.saveAuthorBatch(ctx, batch)
+		for i, author := range batch {
+			result := AuthorSaveResult{ID: author.ID, Err: err}
+			if err != nil && len(batch) > 1 && ctx.Err() == nil {
+				result.Err = r.saveAuthorBatch(ctx, []domain.Author{author})
+			}
+			if result.Err != nil {
+				failed++
+			}
+			results[start+i] = result
+		}
+	}
+	if failed > 0 {
+		return &SaveAuthorsError{Results: results, Failed: failed}
+	}
+	return nil
+}
+
+// saveAuthorBatch writes authors, their affiliations, current institutions and topics in
+// one transaction. Authors only come from OpenAlex, so everything is stamped with
+// SourceOpenAlex.
+func (r *neo4jRepository) saveAuthorBatch(ctx context.Context, authors []domain.Author) error {
+	var (
+		nodes, affiliations, current, topics []map[string]any
+		topicSets                            []map[string]any
+		allTopics                            []domain.Topic
+	)
+	for _, author := range authors {
+		if !r.persistTopics {
+			author.Topics = nil
+		}
 		decodedID, _ := url.QueryUnescape(author.ID)
-		parameters := map[string]interface{}{
+		nodes = append(nodes, map[string]any{
 			"id":                      decodedID,
 			"displayName":             author.DisplayName,
 			"displayNameAlternatives": author.DisplayNameAlternatives,
-			"orcid":                   author.Orcid,
-			"worksCount":              author.WorksCount,
-			"citedByCount":            author.CitedByCount,
-			"updatedDate":             author.UpdatedDate,
-			"lastFetched":             time.Now().UTC().Format(time.RFC3339),
-		}
-		if _, err := tx.Run(ctx, query, parameters); err != nil {
-			return nil, fmt.Errorf("failed to save author node: %w", err)
-		}
+			// Lists can't be full-text indexed, so the aliases are also kept as one string
+			// for the author_names index.
+			"nameAliases":  strings.Join(author.DisplayNameAlternatives, "\n"),
+			"orcid":        author.Orcid,
+			"worksCount":   author.WorksCount,
+			"citedByCount": author.CitedByCount,
+			"hIndex":       author.SummaryStats.HIndex,
+			"updatedDate":  author.UpdatedDate,
+		})
 
 		for _, affiliation := range author.Affiliations {
-			instQuery := `
-				MERGE (i:Institution {id: $instId}) ON CREATE SET i.displayName = $instDisplayName
-				MERGE (a:Author {id: $authorId})
-				MERGE (a)-[:AFFILIATED_WITH]->(i)
-			`
-			instParams := map[string]interface{}{
+			if affiliation.Institution.ID == "" {
+				continue
+			}
+			var firstYear, lastYear any
+			if len(affiliation.Years) > 0 {
+				firstYear, lastYear = slices.Min(affiliation.Years), slices.Max(affiliation.Years)
+			}
+			years := affiliation.Years
+			if years == nil {
+				years = []int{}
+			}
+			affiliations = append(affiliations, map[string]any{
+				"authorId":        decodedID,
 				"instId":          affiliation.Institution.ID,
 				"instDisplayName": affiliation.Institution.DisplayName,
-				"authorId":        decodedID,
-			}
-			if _, err := tx.Run(ctx, instQuery, instParams); err != nil {
-				return nil, fmt.Errorf("failed to save author affiliation: %w", err)
+				"instCountryCode": affiliation.Institution.CountryCode,
+				"instRor":         affiliation.Institution.Ror,
+				"years":           years,
+				"firstYear":       firstYear,
+				"lastYear":        lastYear,
+			})
+		}
+
+		institutions := []map[string]any{}
+		for _, inst := range author.LastKnownInstitutions {
+			if inst == nil || inst.ID == "" {
+				continue
 			}
+			institutions = append(institutions, map[string]any{"id": inst.ID, "displayName": inst.DisplayName, "countryCode": inst.CountryCode})
 		}
+		current = append(current, map[string]any{"authorId": decodedID, "institutions": institutions})
 
-		// Create the Topic hierarchy relationships for the author
-		fmt.Println("author topics:", len(author.Topics))
+		// Topics is nil when the response had no topics at all (e.g. select= left them out,
+		// or PERSIST_TOPICS is off); the stored edges are then kept rather than wiped.
+		if author.Topics == nil {
+			continue
+		}
+		topicIDs := make([]string, 0, len(author.Topics))
 		for _, topic := range author.Topics {
-			topicQuery := `
-				// Find the author this topic belongs to
-				MATCH (a:Author {id: $authorId})
-
-				// Use MERGE to create the entire hierarchy path idempotently.
-				// This ensures that "Computer Science" is created only once.
-				MERGE (d:Domain {id: $domainId}) ON CREATE SET d.displayName = $domainName
-				MERGE (f:Field {id: $fieldId}) ON CREATE SET f.displayName = $fieldName
-				MERGE (s:Subfield {id: $subfieldId}) ON CREATE SET s.displayName = $subfieldName
-				MERGE (t:Topic {id: $topicId}) ON CREATE SET t.displayName = $topicName
-
-				// Merge the relationships between the hierarchy levels
-				MERGE (t)-[:IN_SUBFIELD]->(s)
-				MERGE (s)-[:IN_FIELD]->(f)
-				MERGE (f)-[:IN_DOMAIN]->(d)
-
-				// Finally, connect the author to the specific topic and set the paper count
-				// as a property on the relationship.
-				MERGE (a)-[r:HAS_TOPIC]->(t)
-				SET r.paperCount = $count
-			`
-			topicParams := map[string]interface{}{
-				"authorId":     decodedID,
-				"count":        topic.Count,
-				"topicId":      topic.ID,
-				"topicName":    topic.DisplayName,
-				"subfieldId":   topic.Subfield.ID,
-				"subfieldName": topic.Subfield.DisplayName,
-				"fieldId":      topic.Field.ID,
-				"fieldName":    topic.Field.DisplayName,
-				"domainId":     topic.Domain.ID,
-				"domainName":   topic.Domain.DisplayName,
+			if topic.ID == "" {
+				continue
 			}
-			if _, err := tx.Run(ctx, topicQuery, topicParams); err != nil {
-				return nil, fmt.Errorf("failed to save author topic hierarchy: %w", err)
+			topicIDs = append(topicIDs, topic.ID)
+			topics = append(topics, map[string]any{"authorId": decodedID, "topicId": topic.ID, "count": topic.Count})
+		}
+		topicSets = append(topicSets, map[string]any{"authorId": decodedID, "topicIds": topicIDs})
+		allTopics = append(allTopics, author.Topics...)
+	}
+	if err := r.ensureTopicHierarchy(ctx, allTopics); err != nil {
+		return err
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// hIndex is OpenAlex's summary_stats value.
+		nodeQuery := `
+			UNWIND $authors AS row
+			MERGE (a:Author {id: row.id, tenant: $tenant})
+			ON CREATE SET a.fullyIngested = false
+			SET a.displayName = row.displayName,
+				a.displayNameAlternatives = row.displayNameAlternatives,
+				a.nameAliases = row.nameAliases,
+				a.orcid = row.orcid,
+				a.worksCount = row.worksCount,
+				a.citedByCount = row.citedByCount,
+				a.hIndex = row.hIndex,
+				a.updatedDate = row.updatedDate,
+				a.lastFetched = $lastFetched,
+				` + stampSource("a") + `
+		`
+		nodeParams := map[string]any{
+			"tenant":      tenantOf(ctx),
+			"authors":     nodes,
+			"lastFetched": time.Now().UTC().Format(time.RFC3339),
+			"source":      SourceOpenAlex,
+		}
+		if err := r.exec(ctx, tx, "SaveAuthor/node", nodeQuery, nodeParams); err != nil {
+			return nil, fmt.Errorf("failed to save author node: %w", err)
+		}
+
+		if len(affiliations) > 0 {
+			affiliationQuery := `
+				UNWIND $affiliations AS row
+				MERGE (i:Institution {id: row.instId, tenant: $tenant}) ON CREATE SET i.displayName = row.instDisplayName
+				SET i.countryCode = CASE WHEN row.instCountryCode = '' THEN i.countryCode ELSE row.instCountryCode END,
+					i.ror = CASE WHEN row.instRor = '' THEN i.ror ELSE row.instRor END,
+					` + stampSource("i") + `
+				MERGE (a:Author {id: row.authorId, tenant: $tenant})
+				MERGE (a)-[af:AFFILIATED_WITH]->(i)
+				SET af.tenant = $tenant, ` + stampSource("af") + `
+				// A response without years (e.g. a partial select=) keeps the known ones.
+				FOREACH (_ IN CASE WHEN size(row.years) = 0 THEN [] ELSE [1] END |
+					SET af.years = row.years, af.firstYear = row.firstYear, af.lastYear = row.lastYear
+				)
+			`
+			affiliationParams := map[string]any{"tenant": tenantOf(ctx), "affiliations": affiliations, "source": SourceOpenAlex}
+			if err := r.exec(ctx, tx, "SaveAuthor/affiliation", affiliationQuery, affiliationParams); err != nil {
+				return nil, fmt.Errorf("failed to save author affiliations: %w", err)
 			}
 		}
+
+		// Last known institutions are where the author is now. Unlike the historical
+		// AFFILIATED_WITH edges, CURRENTLY_AT edges to institutions OpenAlex no longer lists
+		// are removed.
+		currentQuery := `
+			UNWIND $authors AS row
+			MATCH (a:Author {id: row.authorId, tenant: $tenant})
+			OPTIONAL MATCH (a)-[old:CURRENTLY_AT]->(prev:Institution)
+			WHERE NOT prev.id IN [inst IN row.institutions | inst.id]
+			DELETE old
+			WITH DISTINCT a, row
+			UNWIND row.institutions AS inst
+			MERGE (i:Institution {id: inst.id, tenant: $tenant}) ON CREATE SET i.displayName = inst.displayName
+			SET i.countryCode = CASE WHEN inst.countryCode = '' THEN i.countryCode ELSE inst.countryCode END,
+				` + stampSource("i") + `
+			MERGE (a)-[c:CURRENTLY_AT]->(i)
+			SET c.tenant = $tenant, ` + stampSource("c") + `
+		`
+		currentParams := map[string]any{"tenant": tenantOf(ctx), "authors": current, "source": SourceOpenAlex}
+		if err := r.exec(ctx, tx, "SaveAuthor/currentlyAt", currentQuery, currentParams); err != nil {
+			return nil, fmt.Errorf("failed to save authors' current institutions: %w", err)
+		}
+
+		if len(topicSets) == 0 {
+			return nil, nil
+		}
+		// A fetch that included topics replaces the author's set, so HAS_TOPIC edges to
+		// topics OpenAlex no longer lists are removed first.
+		staleQuery := `
+			UNWIND $authors AS row
+			MATCH (a:Author {id: row.authorId, tenant: $tenant})-[r:HAS_TOPIC]->(t:Topic)
+			WHERE NOT t.id IN row.topicIds
+			DELETE r
+		`
+		staleParams := map[string]any{"tenant": tenantOf(ctx), "authors": topicSets}
+		if err := r.exec(ctx, tx, "SaveAuthor/staleTopics", staleQuery, staleParams); err != nil {
+			return nil, fmt.Errorf("failed to remove authors' stale topics: %w", err)
+		}
+		// The topic hierarchy was created beforehand by ensureTopicHierarchy; the paper
+		// count goes on the relationship.
+		topicQuery := `
+			UNWIND $topics AS row
+			MATCH (a:Author {id: row.authorId, tenant: $tenant})
+			MATCH (t:Topic {id: row.topicId})
+			MERGE (a)-[r:HAS_TOPIC]->(t)
+			SET r.paperCount = row.count, r.tenant = $tenant, ` + stampSource("r") + `
+		`
+		topicParams := map[string]any{"tenant": tenantOf(ctx), "topics": topics, "source": SourceOpenAlex}
+		if err := r.exec(ctx, tx, "SaveAuthor/topic", topicQuery, topicParams); err != nil {
+			return nil, fmt.Errorf("failed to save author topics: %w", err)
+		}
 		return nil, nil
 	})
 	return err
 }
 
 // SaveWork creates or updates a Work node with all its rich properties and relationships in a single transaction.
-func (r *neo4jRepository) SaveWork(ctx context.Context, work domain.Work) error {
+// opts selects which of the optional relationships are written. Works OpenAlex hasn't
+// updated since they were saved are skipped; the outcome tells which case applied.
+func (r *neo4jRepository) SaveWork(ctx context.Context, work domain.Work, opts SaveOptions) (SaveOutcome, error) {
+	opts.IncludeTopics = opts.IncludeTopics && r.persistTopics
+	if opts.IncludeTopics {
+		if err := r.ensureTopicHierarchy(ctx, work.Topics); err != nil {
+			return "", err
+		}
+	}
+	rows := newWorkSaveRows(work, opts)
+	source := opts.source()
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		// 1. Create or Update the Work node itself with its properties
-		workQuery := `
-			MERGE (w:Work {id: $id})
-			ON CREATE SET
-				w.title = $title, w.publicationYear = $pubYear, w.publicationDate = $publicationDate,
-				w.citedByCount = $citedByCount, w.doi = $doi, w.isRetracted = $isRetracted,
-				w.isOa = $isOa, w.pdfUrl = $pdfUrl
-			ON MATCH SET
-				w.title = $title, w.publicationYear = $pubYear, w.publicationDate = $publicationDate,
-				w.citedByCount = $citedByCount, w.doi = $doi, w.isRetracted = $isRetracted,
-				w.isOa = $isOa, w.pdfUrl = $pdfUrl
-		`
-		isOa := false
-		pdfUrl := ""
-		if work.BestOaLocation != nil {
-			isOa = work.BestOaLocation.IsOa
-			pdfUrl = work.BestOaLocation.PdfUrl
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// 0. The same paper may already be in the graph under another ID (e.g. imported by
+		// DOI only). In that case the existing node is updated instead of creating a duplicate.
+		doiNormalized := domain.NormalizeDOI(work.Doi)
+		nodeID, err := r.resolveWorkNodeID(ctx, tx, work.ID, doiNormalized)
+		if err != nil {
+			return nil, err
+		}
+		var alternateID any
+		if nodeID != work.ID {
+			alternateID = work.ID
+			log.Printf("Work %s has the same DOI as existing work %s; merging onto it", work.ID, nodeID)
 		}
-		//decodedWorkID, _ := url.QueryUnescape(work.ID)
-		workParams := map[string]interface{}{
-			"id": work.ID, "title": work.Title, "pubYear": work.PublicationYear,
-			"publicationDate": work.PublicationDate, "citedByCount": work.CitedByCount,
-			"doi": work.Doi, "isRetracted": work.IsRetracted, "isOa": isOa, "pdfUrl": pdfUrl,
+		outcome, err := r.workSaveOutcome(ctx, tx, nodeID, work, opts)
+		if err != nil || outcome == SaveUnchanged {
+			return outcome, err
 		}
-		if _, err := tx.Run(ctx, workQuery, workParams); err != nil {
+
+		// 1. Create or Update the Work node itself with its properties
+		rows.node["tenant"], rows.node["id"], rows.node["alternateId"] = tenantOf(ctx), nodeID, alternateID
+		if err := r.exec(ctx, tx, "SaveWork/node", saveWorkNodeQuery, rows.node); err != nil {
 			return nil, fmt.Errorf("failed to save work node: %w", err)
 		}
+		params := func(name string, value any) map[string]any {
+			return map[string]any{"tenant": tenantOf(ctx), "workId": nodeID, "source": source, name: value}
+		}
 
-		// 2. Create/Update Authorship relationships (enriched with institutions)
-		for _, authorship := range work.Authorships {
-			var instIds []string
-			for _, inst := range authorship.Institutions {
-				instIds = append(instIds, inst.ID)
+		// The language is a node rather than a property, so works can be counted by language
+		// over a handful of nodes. A work has one language; a changed one replaces the old.
+		if rows.language != "" {
+			if err := r.exec(ctx, tx, "SaveWork/language", saveWorkLanguageQuery, params("language", rows.language)); err != nil {
+				return nil, fmt.Errorf("failed to save work language: %w", err)
 			}
-			authorQuery := `
-				MERGE (a:Author {id: $authorId}) ON CREATE SET a.displayName = $authorName
-				MERGE (w:Work {id: $workId})
-				MERGE (a)-[r:AUTHORED]->(w)
-				SET r.position = $position, r.institutionIds = $institutionIds
-			`
-			authorParams := map[string]interface{}{
-				"authorId": authorship.Author.ID, "authorName": authorship.Author.DisplayName,
-				"workId": work.ID, "position": authorship.AuthorPosition, "institutionIds": instIds,
+		}
+
+		// 2. Create/Update Authorship relationships (enriched with institutions).
+		if len(rows.authorships) > 0 {
+			if err := r.exec(ctx, tx, "SaveWork/authorship", saveWorkAuthorshipsQuery, params("authorships", rows.authorships)); err != nil {
+				return nil, fmt.Errorf("failed to save authorships: %w", err)
+			}
+		}
+		if len(rows.institutions) > 0 {
+			if err := r.exec(ctx, tx, "SaveWork/institution", saveWorkInstitutionsQuery, params("institutions", rows.institutions)); err != nil {
+				return nil, fmt.Errorf("failed to save authorship institutions: %w", err)
 			}
-			if _, err := tx.Run(ctx, authorQuery, authorParams); err != nil {
-				return nil, fmt.Errorf("failed to save authorship: %w", err)
+		}
+
+		// The institutionIds property can't be traversed, so the authorship institutions are
+		// linked to the work as well, one AFFILIATED_ON_WORK edge per institution with the
+		// authors affiliated there. Institutions the authorships no longer list are unlinked.
+		// A response without authorships leaves the edges alone.
+		if len(work.Authorships) > 0 {
+			if err := r.exec(ctx, tx, "SaveWork/affiliations", saveWorkAffiliationsQuery, params("affiliations", rows.affiliations)); err != nil {
+				return nil, fmt.Errorf("failed to save work affiliations: %w", err)
 			}
 		}
 
-		// 3. Create/Update Publication Venue relationship
-		if work.PrimaryLocation != nil && work.PrimaryLocation.Source != nil {
-			venueQuery := `
-				MERGE (v:Venue {id: $venueId}) ON CREATE SET v.displayName = $venueName
-				MERGE (w:Work {id: $workId})
-				MERGE (w)-[:PUBLISHED_IN]->(v)
-			`
-			venueParams := map[string]interface{}{
-				"workId": work.ID, "venueId": work.PrimaryLocation.Source.ID,
-				"venueName": work.PrimaryLocation.Source.DisplayName,
+		// 3. Create/Update Publication Venue relationship. A source ID not seen before whose
+		// ISSN-L an existing venue already has is the same journal under a new ID (e.g. after
+		// a publisher migration): the work is linked to that venue and the ID recorded on it.
+		if opts.IncludeVenue && work.PrimaryLocation != nil && work.PrimaryLocation.Source != nil && work.PrimaryLocation.Source.ID != "" {
+			venue := work.PrimaryLocation.Source
+			venueID, err := r.resolveVenueNodeID(ctx, tx, venue.ID, venue.IssnL)
+			if err != nil {
+				return nil, err
 			}
-			if _, err := tx.Run(ctx, venueQuery, venueParams); err != nil {
+			var venueAlternateID any
+			if venueID != venue.ID {
+				venueAlternateID = venue.ID
+				log.Printf("Venue %s has the same ISSN-L as existing venue %s; linking work %s to it", venue.ID, venueID, nodeID)
+			}
+			venueParams := params("venueId", venueID)
+			venueParams["venueName"], venueParams["issnL"], venueParams["alternateId"] = venue.DisplayName, nullIfEmpty(venue.IssnL), venueAlternateID
+			if err := r.exec(ctx, tx, "SaveWork/venue", saveWorkVenueQuery, venueParams); err != nil {
 				return nil, fmt.Errorf("failed to save venue relationship: %w", err)
 			}
 		}
 
-		// 5. Create Topic relationships and their full hierarchy
-
-		for _, topic := range work.Topics {
-			topicQuery := `
-				// Find the work this topic belongs to
-				MATCH (w:Work {id: $workId})
-
-				// Use MERGE to create the entire hierarchy path idempotently.
-				// This ensures that "Computer Science" is created only once, for example.
-				MERGE (d:Domain {id: $domainId}) ON CREATE SET d.displayName = $domainName
-				MERGE (f:Field {id: $fieldId}) ON CREATE SET f.displayName = $fieldName
-				MERGE (s:Subfield {id: $subfieldId}) ON CREATE SET s.displayName = $subfieldName
-				MERGE (t:Topic {id: $topicId}) ON CREATE SET t.displayName = $topicName
-
-				// Merge the relationships between the hierarchy levels
-				MERGE (t)-[:IN_SUBFIELD]->(s)
-				MERGE (s)-[:IN_FIELD]->(f)
-				MERGE (f)-[:IN_DOMAIN]->(d)
-
-				// Finally, connect the work to the specific topic and set the relevance score
-				// on the relationship.
-				MERGE (w)-[r:IS_ABOUT_TOPIC]->(t)
-				SET r.score = $score
-			`
-			topicParams := map[string]interface{}{
-				"workId":       work.ID,
-				"topicId":      topic.ID,
-				"topicName":    topic.DisplayName,
-				"score":        topic.Score,
-				"subfieldId":   topic.Subfield.ID,
-				"subfieldName": topic.Subfield.DisplayName,
-				"fieldId":      topic.Field.ID,
-				"fieldName":    topic.Field.DisplayName,
-				"domainId":     topic.Domain.ID,
-				"domainName":   topic.Domain.DisplayName,
+		// 4. Create CITES relationships to the referenced works. Those not in the graph yet
+		// are created as stubs, with only their id and stub = true until they are saved.
+		if len(rows.refIDs) > 0 {
+			if err := r.exec(ctx, tx, "SaveWork/cites", saveWorkCitesQuery, params("refIds", rows.refIDs)); err != nil {
+				return nil, fmt.Errorf("failed to save cited works: %w", err)
 			}
-			if _, err := tx.Run(ctx, topicQuery, topicParams); err != nil {
-				return nil, fmt.Errorf("failed to save work topic hierarchy: %w", err)
+		}
+
+		// 5. Link the work to its funders, one FUNDED_BY edge per funder with the award IDs
+		// of its grants. Funders the work no longer lists are unlinked.
+		if opts.IncludeGrants {
+			if err := r.exec(ctx, tx, "SaveWork/grants", saveWorkGrantsQuery, params("grants", rows.grants)); err != nil {
+				return nil, fmt.Errorf("failed to save work grants: %w", err)
 			}
 		}
-		return nil, nil
+
+		// 6. Create Topic relationships; the hierarchy above them already exists.
+		if len(rows.topics) > 0 {
+			if err := r.exec(ctx, tx, "SaveWork/topic", saveWorkTopicsQuery, params("topics", rows.topics)); err != nil {
+				return nil, fmt.Errorf("failed to save work topics: %w", err)
+			}
+		}
+		return outcome, nil
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return result.(SaveOutcome), nil
+}
+
+// workSaveOutcome decides what saving work onto the node nodeID amounts to. The save is
+// skipped (SaveUnchanged) when the node is a full work whose updatedDate is at least the
+// incoming one and which was saved with every part opts asks for, unless opts.Force is
+// set. Works without an updatedDate, on either side, are always written.
+func (r *neo4jRepository) workSaveOutcome(ctx context.Context, tx neo4j.ManagedTransaction, nodeID string, work domain.Work, opts SaveOptions) (SaveOutcome, error) {
+	res, err := r.run(ctx, tx, "SaveWork/outcome", `
+		OPTIONAL MATCH (w:Work {id: $id, tenant: $tenant})
+		RETURN w IS NOT NULL AS exists, coalesce(w.stub, false) AS stub,
+			coalesce(w.updatedDate, '') AS updatedDate, coalesce(w.savedParts, []) AS savedParts
+	`, map[string]any{"tenant": tenantOf(ctx), "id": nodeID})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up stored work: %w", err)
+	}
+	record, err := res.Single(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up stored work: %w", err)
+	}
+	props := record.AsMap()
+	if exists, _ := props["exists"].(bool); !exists || boolProp(props, "stub") {
+		return SaveCreated, nil
+	}
+	stored := stringProp(props, "updatedDate")
+	if opts.Force || nodeID != work.ID || work.UpdatedDate == "" || stored == "" || work.UpdatedDate > stored {
+		return SaveUpdated, nil
+	}
+	savedParts := stringsProp(props, "savedParts")
+	for _, part := range opts.parts() {
+		if !slices.Contains(savedParts, part) {
+			return SaveUpdated, nil
+		}
+	}
+	return SaveUnchanged, nil
 }
 
 // MarkAuthorFullyIngested sets the 'fullyIngested' flag to true for the given Author node.
@@ -267,11 +548,11 @@ func (r *neo4jRepository) MarkAuthorFullyIngested(ctx context.Context, authorID
 
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		decodedID, _ := url.QueryUnescape(authorID) // ✅
-		_, err := tx.Run(ctx, `
-			MATCH (a:Author {id: $id})
+		err := r.exec(ctx, tx, "MarkAuthorFullyIngested", `
+			MATCH (a:Author {id: $id, tenant: $tenant})
 			SET a.fullyIngested = true
 			RETURN a
-		`, map[string]any{"id": decodedID})
+		`, map[string]any{"tenant": tenantOf(ctx), "id": decodedID})
 
 		return nil, err
 	})
@@ -281,4 +562,3 @@ func (r *neo4jRepository) MarkAuthorFullyIngested(ctx context.Context, authorID
 	}
 	return nil
 }
-
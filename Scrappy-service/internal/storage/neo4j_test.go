@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/tenant"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// newTestRepo connects to the database named by NEO4J_TEST_URI, and skips the test when it
+// isn't set. Each test gets a tenant of its own, so tests don't see each other's nodes; they
+// are deleted when the test ends.
+func newTestRepo(t testing.TB) (*neo4jRepository, context.Context) {
+	t.Helper()
+	uri := os.Getenv("NEO4J_TEST_URI")
+	if uri == "" {
+		t.Skip("NEO4J_TEST_URI is not set")
+	}
+	repo, err := NewNeo4jRepository(uri, os.Getenv("NEO4J_TEST_USERNAME"), os.Getenv("NEO4J_TEST_PASSWORD"), true)
+	if err != nil {
+		t.Fatalf("connecting to %s: %v", uri, err)
+	}
+	r := repo.(*neo4jRepository)
+	t.Cleanup(func() { r.Close(context.Background()) })
+	return r, newTestTenant(t, r)
+}
+
+// newTestTenant returns a context scoped to a new tenant, whose nodes are deleted when the
+// test ends.
+func newTestTenant(t testing.TB, r *neo4jRepository) context.Context {
+	t.Helper()
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	name := "test-" + hex.EncodeToString(suffix)
+	t.Cleanup(func() { cleanTenant(t, r, name) })
+	return tenant.WithTenant(context.Background(), name)
+}
+
+// newSharedFixture returns a context scoped to the shared namespace, which holds the data
+// written before tenants existed, and a prefix for the IDs of the nodes a test creates
+// there. Nodes whose id starts with the prefix are deleted when the test ends.
+func newSharedFixture(t testing.TB, r *neo4jRepository) (context.Context, string) {
+	t.Helper()
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	prefix := "test-" + hex.EncodeToString(suffix) + "-"
+	t.Cleanup(func() {
+		_, err := neo4j.ExecuteQuery(context.Background(), r.driver, `MATCH (n) WHERE n.id STARTS WITH $prefix DETACH DELETE n`,
+			map[string]any{"prefix": prefix}, neo4j.EagerResultTransformer)
+		if err != nil {
+			t.Errorf("cleaning up shared fixture %s: %v", prefix, err)
+		}
+	})
+	return tenant.WithTenant(context.Background(), tenant.Shared), prefix
+}
+
+// cleanTenant deletes every node of the tenant.
+func cleanTenant(t testing.TB, r *neo4jRepository, name string) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := neo4j.ExecuteQuery(ctx, r.driver, `MATCH (n {tenant: $tenant}) DETACH DELETE n`,
+		map[string]any{"tenant": name}, neo4j.EagerResultTransformer)
+	if err != nil {
+		t.Errorf("cleaning up tenant %s: %v", name, err)
+	}
+}
+
+// query runs a statement directly against the database, outside the repository, and returns
+// its records. Tests use it to set up and inspect what the repository's methods don't expose.
+func query(t testing.TB, r *neo4jRepository, ctx context.Context, stmt string, params map[string]any) []map[string]any {
+	t.Helper()
+	if params == nil {
+		params = map[string]any{}
+	}
+	params["tenant"] = tenantOf(ctx)
+	result, err := neo4j.ExecuteQuery(ctx, r.driver, stmt, params, neo4j.EagerResultTransformer)
+	if err != nil {
+		t.Fatalf("running %q: %v", stmt, err)
+	}
+	records := make([]map[string]any, 0, len(result.Records))
+	for _, record := range result.Records {
+		records = append(records, record.AsMap())
+	}
+	return records
+}
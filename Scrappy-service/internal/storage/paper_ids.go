@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// WorkIDs are a work's identifiers in the ID spaces the service deals with: its OpenAlex
+// id, its normalized DOI and its Semantic Scholar paperId, each empty when unknown.
+type WorkIDs struct {
+	ID        string
+	Doi       string
+	SSPaperID string
+}
+
+// Identifier kinds FindWorkIDs looks works up by.
+const (
+	WorkIDOpenAlex        = "openalex"
+	WorkIDDOI             = "doi"
+	WorkIDSemanticScholar = "semanticscholar"
+)
+
+// workIDProperties maps the identifier kinds onto the Work properties holding them.
+var workIDProperties = map[string]string{
+	WorkIDOpenAlex:        "id",
+	WorkIDDOI:             "doiNormalized",
+	WorkIDSemanticScholar: "ssPaperId",
+}
+
+// FindWorkIDs looks a work up by one of its identifiers (a URL-form OpenAlex id, a
+// normalized DOI or a Semantic Scholar paperId) and returns all of its known identifiers.
+// ErrNotFound means no work in the graph has it.
+func (r *neo4jRepository) FindWorkIDs(ctx context.Context, kind, value string) (WorkIDs, error) {
+	property, ok := workIDProperties[kind]
+	if !ok {
+		return WorkIDs{}, fmt.Errorf("unknown work identifier kind %q", kind)
+	}
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	// The property name comes from workIDProperties, never from the caller.
+	query := fmt.Sprintf(`
+		MATCH (w:Work)
+		WHERE w.%s = $value AND w.tenant = $tenant
+		RETURN w.id AS id, w.doiNormalized AS doi, w.ssPaperId AS ssPaperId
+		ORDER BY w.id
+		LIMIT 1
+	`, property)
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "FindWorkIDs", query, map[string]any{"tenant": tenantOf(ctx), "value": value})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		props := records[0].AsMap()
+		return WorkIDs{ID: stringProp(props, "id"), Doi: stringProp(props, "doi"), SSPaperID: stringProp(props, "ssPaperId")}, nil
+	})
+	if err != nil {
+		return WorkIDs{}, fmt.Errorf("failed to look up work by %s %s: %w", kind, value, err)
+	}
+	return result.(WorkIDs), nil
+}
+
+// SetWorkSSPaperID records the Semantic Scholar paperId of a work, so later lookups don't
+// have to ask Semantic Scholar again. ErrNotFound means the work isn't in the graph.
+func (r *neo4jRepository) SetWorkSSPaperID(ctx context.Context, workID, paperID string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "SetWorkSSPaperID", `
+			MATCH (w:Work {id: $id, tenant: $tenant})
+			SET w.ssPaperId = $paperId, `+stampSource("w")+`
+			RETURN count(w) AS updated
+		`, map[string]any{"tenant": tenantOf(ctx), "id": workID, "paperId": paperID, "source": SourceSemanticScholar})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if intProp(record.AsMap(), "updated") == 0 {
+			return nil, ErrNotFound
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record Semantic Scholar paperId of work %s: %w", workID, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestFindWorkIDs(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W1", Title: "one", Doi: "https://doi.org/10.1/ABC"}, SaveOptions{}); err != nil {
+		t.Fatalf("SaveWork: %v", err)
+	}
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W2", Title: "two"}, SaveOptions{}); err != nil {
+		t.Fatalf("SaveWork: %v", err)
+	}
+	if err := r.SetWorkSSPaperID(ctx, "W1", "ss-1"); err != nil {
+		t.Fatalf("SetWorkSSPaperID: %v", err)
+	}
+
+	tests := []struct {
+		kind, value string
+		want        WorkIDs
+		wantErr     error
+	}{
+		{WorkIDOpenAlex, "W1", WorkIDs{ID: "W1", Doi: "10.1/abc", SSPaperID: "ss-1"}, nil},
+		{WorkIDDOI, "10.1/abc", WorkIDs{ID: "W1", Doi: "10.1/abc", SSPaperID: "ss-1"}, nil},
+		{WorkIDSemanticScholar, "ss-1", WorkIDs{ID: "W1", Doi: "10.1/abc", SSPaperID: "ss-1"}, nil},
+		{WorkIDOpenAlex, "W2", WorkIDs{ID: "W2"}, nil},
+		{WorkIDDOI, "10.1/missing", WorkIDs{}, ErrNotFound},
+		{WorkIDSemanticScholar, "ss-2", WorkIDs{}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		got, err := r.FindWorkIDs(ctx, tt.kind, tt.value)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("FindWorkIDs(%s, %s) = %+v, %v; want %+v, %v", tt.kind, tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if _, err := r.FindWorkIDs(ctx, "mag", "1"); err == nil {
+		t.Error("FindWorkIDs accepted an unknown identifier kind")
+	}
+
+	// The mapping belongs to the tenant that learned it.
+	if _, err := r.FindWorkIDs(newTestTenant(t, r), WorkIDSemanticScholar, "ss-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other tenant: FindWorkIDs error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSetWorkSSPaperIDNeedsTheWork(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	if err := r.SetWorkSSPaperID(ctx, "W-missing", "ss-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetWorkSSPaperID error = %v, want ErrNotFound", err)
+	}
+}
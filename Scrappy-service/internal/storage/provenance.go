@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// Sources stamped on the nodes and relationships a write touches.
+const (
+	SourceOpenAlex        = "openalex"
+	SourceSemanticScholar = "semanticscholar"
+	SourceDeposit         = "deposit" // Works posted to the API rather than fetched.
+)
+
+// stampSource returns the Cypher SET items that record $source on the node or relationship
+// bound to alias: the source is appended to its sourcedFrom list unless already there, and
+// becomes its lastSource. Enrichments thus add to the sources of what they touch, and
+// saving again from the same source leaves sourcedFrom as it is.
+func stampSource(alias string) string {
+	return fmt.Sprintf(`%[1]s.sourcedFrom = CASE WHEN $source IN coalesce(%[1]s.sourcedFrom, []) THEN %[1]s.sourcedFrom ELSE coalesce(%[1]s.sourcedFrom, []) + $source END,
+				%[1]s.lastSource = $source`, alias)
+}
+
+// MaxProvenanceRelationships caps the relationships GetWorkProvenance returns.
+const MaxProvenanceRelationships = 1000
+
+// ProvenanceStamp is the provenance a write leaves on a node or relationship: every
+// source that wrote it, in the order they first did, and the latest.
+type ProvenanceStamp struct {
+	SourcedFrom []string `json:"sourcedFrom"`
+	LastSource  string   `json:"lastSource,omitempty"`
+}
+
+// RelationshipProvenance is the stamp of one relationship of a work. Direction is "out"
+// for relationships from the work and "in" for those to it.
+type RelationshipProvenance struct {
+	Type       string `json:"type"`
+	Direction  string `json:"direction"`
+	OtherID    string `json:"otherId"`
+	OtherLabel string `json:"otherLabel"`
+	ProvenanceStamp
+}
+
+// WorkProvenance is the stamp of a work and of its relationships, ordered by type and the
+// ID of the node at the other end. Relationships are capped at MaxProvenanceRelationships;
+// TotalRelationships counts them all.
+type WorkProvenance struct {
+	ID string `json:"id"`
+	ProvenanceStamp
+	Relationships      []RelationshipProvenance `json:"relationships"`
+	TotalRelationships int                      `json:"totalRelationships"`
+}
+
+// GetWorkProvenance returns the provenance stamps of a work and its relationships. Nodes
+// and relationships written before stamping was introduced have an empty sourcedFrom.
+// ErrNotFound means the work isn't in the graph.
+func (r *neo4jRepository) GetWorkProvenance(ctx context.Context, workID string) (*WorkProvenance, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetWorkProvenance", `
+			MATCH (w:Work {id: $id, tenant: $tenant})
+			OPTIONAL MATCH (w)-[rel]-(other)
+			WITH w, rel, other
+			ORDER BY type(rel), coalesce(other.id, other.code)
+			WITH w, collect(CASE WHEN rel IS NULL THEN NULL ELSE {
+				type: type(rel), out: startNode(rel) = w, otherId: coalesce(other.id, other.code),
+				otherLabel: labels(other)[0], sourcedFrom: rel.sourcedFrom, lastSource: rel.lastSource
+			} END) AS rels
+			RETURN w.id AS id, w.sourcedFrom AS sourcedFrom, w.lastSource AS lastSource,
+				rels[..$limit] AS relationships, size(rels) AS total
+		`, map[string]any{"tenant": tenantOf(ctx), "id": workID, "limit": MaxProvenanceRelationships})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		props := records[0].AsMap()
+		provenance := &WorkProvenance{
+			ID:                 stringProp(props, "id"),
+			ProvenanceStamp:    provenanceStamp(props),
+			Relationships:      []RelationshipProvenance{},
+			TotalRelationships: intProp(props, "total"),
+		}
+		rels, _ := props["relationships"].([]any)
+		for _, raw := range rels {
+			rel, _ := raw.(map[string]any)
+			direction := "in"
+			if boolProp(rel, "out") {
+				direction = "out"
+			}
+			provenance.Relationships = append(provenance.Relationships, RelationshipProvenance{
+				Type:            stringProp(rel, "type"),
+				Direction:       direction,
+				OtherID:         stringProp(rel, "otherId"),
+				OtherLabel:      stringProp(rel, "otherLabel"),
+				ProvenanceStamp: provenanceStamp(rel),
+			})
+		}
+		return provenance, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provenance of work %s: %w", workID, err)
+	}
+	return result.(*WorkProvenance), nil
+}
+
+func provenanceStamp(props map[string]any) ProvenanceStamp {
+	return ProvenanceStamp{SourcedFrom: stringsProp(props, "sourcedFrom"), LastSource: stringProp(props, "lastSource")}
+}
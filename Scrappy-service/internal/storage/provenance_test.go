@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestProvenanceStamps(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	work := domain.Work{ID: "W1", Title: "stamped", Authorships: []domain.Authorship{authorship("A1")}, ReferencedWorks: []string{"W2"}}
+	citer := domain.Work{ID: "W0", Title: "citer", ReferencedWorks: []string{"W1"}}
+
+	stamp := func(last string, sources ...string) ProvenanceStamp {
+		return ProvenanceStamp{SourcedFrom: sources, LastSource: last}
+	}
+	rel := func(relType, direction, otherID, otherLabel string, s ProvenanceStamp) RelationshipProvenance {
+		return RelationshipProvenance{Type: relType, Direction: direction, OtherID: otherID, OtherLabel: otherLabel, ProvenanceStamp: s}
+	}
+	openAlex := stamp(SourceOpenAlex, SourceOpenAlex)
+
+	steps := []struct {
+		name         string
+		write        func() error
+		wantWork     ProvenanceStamp
+		wantAuthored ProvenanceStamp
+		wantCitesOut ProvenanceStamp
+		wantCitesIn  ProvenanceStamp // W0 cites W1; zero until W0 is saved
+	}{
+		{
+			name:         "saved from OpenAlex",
+			write:        func() error { _, err := r.SaveWork(ctx, work, FullSave); return err },
+			wantWork:     openAlex,
+			wantAuthored: openAlex,
+			wantCitesOut: openAlex,
+		},
+		{
+			name: "saved again from OpenAlex",
+			write: func() error {
+				_, err := r.SaveWork(ctx, work, SaveOptions{Force: true, IncludeCitations: true})
+				return err
+			},
+			wantWork:     openAlex,
+			wantAuthored: openAlex,
+			wantCitesOut: openAlex,
+		},
+		{
+			name:         "Semantic Scholar paperId",
+			write:        func() error { return r.SetWorkSSPaperID(ctx, "W1", "p1") },
+			wantWork:     stamp(SourceSemanticScholar, SourceOpenAlex, SourceSemanticScholar),
+			wantAuthored: openAlex,
+			wantCitesOut: openAlex,
+		},
+		{
+			name:         "Semantic Scholar citation context",
+			write:        func() error { return r.AnnotateCitation(ctx, "W1", "W2", map[string]any{"isInfluential": true}) },
+			wantWork:     stamp(SourceSemanticScholar, SourceOpenAlex, SourceSemanticScholar),
+			wantAuthored: openAlex,
+			wantCitesOut: stamp(SourceSemanticScholar, SourceOpenAlex, SourceSemanticScholar),
+		},
+		{
+			// The last source changes, the order sources first wrote in doesn't.
+			name: "OpenAlex once more",
+			write: func() error {
+				_, err := r.SaveWork(ctx, work, SaveOptions{Force: true, IncludeCitations: true})
+				return err
+			},
+			wantWork:     stamp(SourceOpenAlex, SourceOpenAlex, SourceSemanticScholar),
+			wantAuthored: openAlex,
+			wantCitesOut: stamp(SourceOpenAlex, SourceOpenAlex, SourceSemanticScholar),
+		},
+		{
+			name: "cited by a deposit",
+			write: func() error {
+				_, err := r.SaveWork(ctx, citer, SaveOptions{IncludeCitations: true, Source: SourceDeposit})
+				return err
+			},
+			// Citing W1 doesn't write its data, so only the edge is the deposit's.
+			wantWork:     stamp(SourceOpenAlex, SourceOpenAlex, SourceSemanticScholar),
+			wantAuthored: openAlex,
+			wantCitesOut: stamp(SourceOpenAlex, SourceOpenAlex, SourceSemanticScholar),
+			wantCitesIn:  stamp(SourceDeposit, SourceDeposit),
+		},
+	}
+	for _, step := range steps {
+		if err := step.write(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got, err := r.GetWorkProvenance(ctx, "W1")
+		if err != nil {
+			t.Fatalf("%s: GetWorkProvenance: %v", step.name, err)
+		}
+		want := &WorkProvenance{ID: "W1", ProvenanceStamp: step.wantWork, Relationships: []RelationshipProvenance{
+			rel("AUTHORED", "in", "A1", "Author", step.wantAuthored),
+		}}
+		if step.wantCitesIn.LastSource != "" {
+			want.Relationships = append(want.Relationships, rel("CITES", "in", "W0", "Work", step.wantCitesIn))
+		}
+		want.Relationships = append(want.Relationships, rel("CITES", "out", "W2", "Work", step.wantCitesOut))
+		want.TotalRelationships = len(want.Relationships)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: provenance =\n%+v\nwant\n%+v", step.name, got, want)
+		}
+	}
+
+	// The stub W2 was created by W1's save and is stamped like any other node it wrote.
+	stub, err := r.GetWorkProvenance(ctx, "W2")
+	if err != nil {
+		t.Fatalf("GetWorkProvenance(W2): %v", err)
+	}
+	if want := stamp(SourceOpenAlex, SourceOpenAlex); !reflect.DeepEqual(stub.ProvenanceStamp, want) {
+		t.Errorf("stub stamped %+v, want %+v", stub.ProvenanceStamp, want)
+	}
+
+	// So are the institutions an enriched institution lists.
+	institution := domain.Institution{ID: "I1", AssociatedInstitutions: []domain.AssociatedInstitution{
+		{DehydratedInstitution: domain.DehydratedInstitution{ID: "I2"}, Relationship: domain.InstitutionParent},
+	}}
+	if err := r.SaveInstitution(ctx, institution); err != nil {
+		t.Fatalf("SaveInstitution: %v", err)
+	}
+	parent := query(t, r, ctx, `MATCH (i:Institution {id: 'I2', tenant: $tenant}) RETURN i.sourcedFrom AS sourcedFrom`, nil)
+	if len(parent) != 1 || !reflect.DeepEqual(stringsProp(parent[0], "sourcedFrom"), []string{SourceOpenAlex}) {
+		t.Errorf("parent institution = %v, want it sourced from %s", parent, SourceOpenAlex)
+	}
+
+	if _, err := r.GetWorkProvenance(ctx, "W404"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetWorkProvenance(W404) error = %v, want ErrNotFound", err)
+	}
+}
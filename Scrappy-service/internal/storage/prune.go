@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// PrunableLabels are the labels PruneOrphans may delete nodes of. The topic hierarchy, audit
+// events and the blocklist are never pruned.
+var PrunableLabels = []string{"Work", "Author", "Institution", "Venue"}
+
+// PruneOrphans deletes the current tenant's nodes of the given labels that have no
+// relationships at all, e.g. works whose only author was deleted, and returns how many were
+// removed. Labels outside PrunableLabels are rejected with ErrInvalidLabel. Running it
+// again only finds what was orphaned since.
+func (r *neo4jRepository) PruneOrphans(ctx context.Context, labels []string) (int, error) {
+	for _, label := range labels {
+		if !slices.Contains(PrunableLabels, label) {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+		}
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	deleted := 0
+	for _, label := range labels {
+		// Labels can't be parameters; they were checked against PrunableLabels above. The
+		// tenant lookup is served by the <label>_tenant index, and CALL ... IN TRANSACTIONS
+		// needs an auto-commit transaction.
+		query := fmt.Sprintf(`
+			MATCH (n:%s) WHERE n.tenant = $tenant AND NOT (n)--()
+			CALL {
+				WITH n
+				DELETE n
+			} IN TRANSACTIONS OF 10000 ROWS
+		`, label)
+		res, err := session.Run(ctx, query, map[string]any{"tenant": tenantOf(ctx)})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to prune orphaned %s nodes: %w", label, err)
+		}
+		summary, err := res.Consume(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to prune orphaned %s nodes: %w", label, err)
+		}
+		deleted += summary.Counters().NodesDeleted()
+	}
+	return deleted, nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SaveOptions selects what SaveWork writes besides the Work node and its AUTHORED edges
+// (with the authors and their institutions), which are always saved. Lean saves make bulk
+// loads much cheaper. Everything is MERGEd, so saving a work again with more options later
+// adds the missing parts to the existing nodes without duplicating anything.
+//
+// IncludeCitations writes a CITES relationship to every referenced work, creating works
+// that aren't in the graph yet as stubs. IncludeGrants writes a FUNDED_BY relationship to
+// every funder of the work.
+//
+// A work whose stored updatedDate is as recent as the incoming one, and that was saved
+// with every part asked for, is not written again (SaveUnchanged) unless Force is set.
+//
+// Source is where the work's data comes from, e.g. SourceDeposit for works posted to the
+// API; it is stamped on every node and relationship the save writes. Empty means
+// SourceOpenAlex.
+type SaveOptions struct {
+	IncludeTopics    bool
+	IncludeVenue     bool
+	IncludeGrants    bool
+	IncludeCitations bool
+	Force            bool
+	Source           string
+}
+
+// SaveOutcome is what SaveWork did with a work.
+type SaveOutcome string
+
+const (
+	SaveCreated   SaveOutcome = "created"   // The work was new to the graph, or only a stub.
+	SaveUpdated   SaveOutcome = "updated"   // The stored work was rewritten.
+	SaveUnchanged SaveOutcome = "unchanged" // Skipped: the stored work was already up to date.
+)
+
+// FullSave writes everything; it is the default for all ingestion.
+var FullSave = SaveOptions{IncludeTopics: true, IncludeVenue: true, IncludeGrants: true, IncludeCitations: true}
+
+// parts returns the names of the optional parts the options include.
+func (o SaveOptions) parts() []string {
+	parts := []string{}
+	for _, part := range []struct {
+		name string
+		set  bool
+	}{{"topics", o.IncludeTopics}, {"venue", o.IncludeVenue}, {"grants", o.IncludeGrants}, {"citations", o.IncludeCitations}} {
+		if part.set {
+			parts = append(parts, part.name)
+		}
+	}
+	return parts
+}
+
+// source returns the source to stamp, SourceOpenAlex unless Source is set.
+func (o SaveOptions) source() string {
+	if o.Source == "" {
+		return SourceOpenAlex
+	}
+	return o.Source
+}
+
+// String returns the options in the form ParseSaveOptions accepts. Force and Source are
+// not part of it.
+func (o SaveOptions) String() string {
+	parts := o.parts()
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseSaveOptions parses a comma-separated list of the optional parts to save, e.g.
+// "topics,venue". Valid parts are topics, venue, grants and citations; "none" saves only
+// works and authorships. An empty string means FullSave.
+func ParseSaveOptions(include string) (SaveOptions, error) {
+	include = strings.TrimSpace(include)
+	if include == "" {
+		return FullSave, nil
+	}
+	var opts SaveOptions
+	for _, part := range strings.Split(include, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "topics":
+			opts.IncludeTopics = true
+		case "venue":
+			opts.IncludeVenue = true
+		case "grants":
+			opts.IncludeGrants = true
+		case "citations":
+			opts.IncludeCitations = true
+		case "none":
+		default:
+			return SaveOptions{}, fmt.Errorf("unknown part %q (want topics, venue, grants, citations or none)", part)
+		}
+	}
+	return opts, nil
+}
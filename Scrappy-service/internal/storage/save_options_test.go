@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestParseSaveOptions(t *testing.T) {
+	tests := []struct {
+		include string
+		want    SaveOptions
+		wantErr bool
+	}{
+		{"", FullSave, false},
+		{"  ", FullSave, false},
+		{"none", SaveOptions{}, false},
+		{"topics,venue", SaveOptions{IncludeTopics: true, IncludeVenue: true}, false},
+		{" Grants , CITATIONS ", SaveOptions{IncludeGrants: true, IncludeCitations: true}, false},
+		{"topics,venue,grants,citations", FullSave, false},
+		{"topics,concepts", SaveOptions{}, true},
+		{"topics,", SaveOptions{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSaveOptions(tt.include)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSaveOptions(%q) = %+v, %v; want %+v, error %v", tt.include, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil {
+			if again, _ := ParseSaveOptions(got.String()); again != got {
+				t.Errorf("ParseSaveOptions(%q) doesn't round-trip: %+v", got.String(), again)
+			}
+		}
+	}
+}
+
+// richWork returns a work with every optional part SaveOptions selects: topics (whose ids
+// start with topicPrefix), a venue, a grant and references.
+func richWork(id, topicPrefix string) domain.Work {
+	parent := func(level string) domain.TopicParent {
+		return domain.TopicParent{ID: topicPrefix + "-" + level, DisplayName: level}
+	}
+	return domain.Work{
+		ID: id, Title: "rich " + id, PublicationYear: 2020, UpdatedDate: "2024-01-01",
+		Authorships:     []domain.Authorship{authorship("A1", domain.DehydratedInstitution{ID: "I1", CountryCode: "DE"})},
+		PrimaryLocation: &domain.Location{Source: &domain.Source{ID: "S1", DisplayName: "Journal"}},
+		Grants:          []domain.Grant{{Funder: "F1", FunderDisplayName: "Funder", AwardID: "G-1"}},
+		ReferencedWorks: []string{"W-ref-1", "W-ref-2"},
+		Topics: []domain.Topic{{ID: topicPrefix + "-1", DisplayName: "topic", Score: 0.9,
+			Subfield: parent("subfield"), Field: parent("field"), Domain: parent("domain")}},
+	}
+}
+
+// cleanTopics deletes the global topic hierarchy nodes whose ids start with prefix.
+func cleanTopics(t testing.TB, r *neo4jRepository, ctx context.Context, prefix string) {
+	t.Cleanup(func() {
+		query(t, r, ctx, `
+			MATCH (n) WHERE (n:Topic OR n:Subfield OR n:Field OR n:Domain) AND n.id STARTS WITH $prefix
+			DETACH DELETE n
+		`, map[string]any{"prefix": prefix})
+	})
+}
+
+// graphCounts counts the tenant's nodes by label and relationships by type, and the topic
+// nodes with ids starting with topicPrefix.
+func graphCounts(t *testing.T, r *neo4jRepository, ctx context.Context, topicPrefix string) map[string]int64 {
+	t.Helper()
+	counts := map[string]int64{}
+	for _, record := range query(t, r, ctx, `
+		MATCH (n {tenant: $tenant}) UNWIND labels(n) AS label RETURN label AS name, count(*) AS n
+		UNION ALL
+		MATCH ()-[rel {tenant: $tenant}]->() RETURN type(rel) AS name, count(*) AS n
+		UNION ALL
+		MATCH (topic:Topic) WHERE topic.id STARTS WITH $prefix RETURN 'Topic' AS name, count(*) AS n
+	`, map[string]any{"prefix": topicPrefix}) {
+		counts[record["name"].(string)] += record["n"].(int64)
+	}
+	return counts
+}
+
+func TestLeanSaveAndEnrichment(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	prefix := "T-" + tenantOf(ctx)
+	cleanTopics(t, r, ctx, prefix)
+	work := richWork("W1", prefix)
+
+	full := map[string]int64{"Work": 3, "Author": 1, "Institution": 1, "Venue": 1, "Funder": 1, "Topic": 1,
+		"AUTHORED": 1, "AFFILIATED_ON_WORK": 1, "PUBLISHED_IN": 1, "FUNDED_BY": 1, "CITES": 2, "IS_ABOUT_TOPIC": 1}
+	steps := []struct {
+		name string
+		opts SaveOptions
+		want map[string]int64 // Counts of the labels and types in full.
+	}{
+		{"lean", SaveOptions{}, map[string]int64{"Work": 1, "Author": 1, "Institution": 1, "AUTHORED": 1, "AFFILIATED_ON_WORK": 1}},
+		{"topics and venue", SaveOptions{IncludeTopics: true, IncludeVenue: true}, map[string]int64{"Work": 1, "Author": 1,
+			"Institution": 1, "Venue": 1, "Topic": 1, "AUTHORED": 1, "AFFILIATED_ON_WORK": 1, "PUBLISHED_IN": 1, "IS_ABOUT_TOPIC": 1}},
+		{"enrichment", FullSave, full},
+		{"enrichment again", SaveOptions{IncludeTopics: true, IncludeVenue: true, IncludeGrants: true, IncludeCitations: true, Force: true}, full},
+		{"lean again", SaveOptions{Force: true}, full},
+	}
+	for _, step := range steps {
+		if _, err := r.SaveWork(ctx, work, step.opts); err != nil {
+			t.Fatalf("%s: SaveWork: %v", step.name, err)
+		}
+		counts := graphCounts(t, r, ctx, prefix)
+		for name := range full {
+			if counts[name] != step.want[name] {
+				t.Errorf("%s: %d %s, want %d", step.name, counts[name], name, step.want[name])
+			}
+		}
+	}
+}
+
+func TestSaveWorkSkipsPartsSavedBefore(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	prefix := "T-" + tenantOf(ctx)
+	cleanTopics(t, r, ctx, prefix)
+	work := richWork("W1", prefix)
+
+	// A work is only skipped as unchanged if everything asked for was saved before.
+	steps := []struct {
+		opts SaveOptions
+		want SaveOutcome
+	}{
+		{SaveOptions{IncludeTopics: true}, SaveCreated},
+		{SaveOptions{IncludeTopics: true}, SaveUnchanged},
+		{SaveOptions{}, SaveUnchanged},
+		{SaveOptions{IncludeVenue: true}, SaveUpdated},
+		{SaveOptions{IncludeTopics: true, IncludeVenue: true}, SaveUnchanged},
+		{SaveOptions{IncludeVenue: true, Force: true}, SaveUpdated},
+	}
+	for i, step := range steps {
+		got, err := r.SaveWork(ctx, work, step.opts)
+		if err != nil {
+			t.Fatalf("step %d: SaveWork: %v", i, err)
+		}
+		if got != step.want {
+			t.Errorf("step %d: saving with %v: %s, want %s", i, step.opts, got, step.want)
+		}
+	}
+}
+
+// BenchmarkSaveWork compares lean saves, as for bulk loads, with full ones.
+func BenchmarkSaveWork(b *testing.B) {
+	for _, opts := range []SaveOptions{{}, {IncludeTopics: true, IncludeVenue: true}, FullSave} {
+		b.Run(opts.String(), func(b *testing.B) {
+			r, ctx := newTestRepo(b)
+			prefix := "T-" + tenantOf(ctx)
+			cleanTopics(b, r, ctx, prefix)
+			opts.Force = true
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.SaveWork(ctx, richWork(fmt.Sprintf("W%d", i), prefix), opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Partial OpenAlex responses leave nested objects zero-valued; saving them must not merge
+// {id: ""} nodes.
+func TestSaveWorkSkipsEmptyIDs(t *testing.T) {
+	r, _ := newTestRepo(t)
+
+	tests := []struct {
+		name    string
+		partial func(work *domain.Work)
+		want    map[string]int64 // counts of the labels and types in richWork's full save
+		// wantHierarchy are the hierarchy nodes and links of the work's topic, and
+		// wantCached whether the topic is cached as complete.
+		wantHierarchy []string
+		wantCached    bool
+	}{
+		{
+			name:    "complete",
+			partial: func(work *domain.Work) {},
+			want: map[string]int64{"Author": 1, "Institution": 1, "Venue": 1, "Topic": 1,
+				"AUTHORED": 1, "AFFILIATED_ON_WORK": 1, "PUBLISHED_IN": 1, "IS_ABOUT_TOPIC": 1},
+			wantHierarchy: []string{"Topic", "Subfield", "Field", "Domain", "IN_SUBFIELD", "IN_FIELD", "IN_DOMAIN"},
+			wantCached:    true,
+		},
+		{
+			name:    "topic without field",
+			partial: func(work *domain.Work) { work.Topics[0].Field = domain.TopicParent{} },
+			want: map[string]int64{"Author": 1, "Institution": 1, "Venue": 1, "Topic": 1,
+				"AUTHORED": 1, "AFFILIATED_ON_WORK": 1, "PUBLISHED_IN": 1, "IS_ABOUT_TOPIC": 1},
+			wantHierarchy: []string{"Topic", "Subfield", "Domain", "IN_SUBFIELD"},
+		},
+		{
+			name: "topic without hierarchy",
+			partial: func(work *domain.Work) {
+				topic := &work.Topics[0]
+				topic.Subfield, topic.Field, topic.Domain = domain.TopicParent{}, domain.TopicParent{}, domain.TopicParent{}
+			},
+			want: map[string]int64{"Author": 1, "Institution": 1, "Venue": 1, "Topic": 1,
+				"AUTHORED": 1, "AFFILIATED_ON_WORK": 1, "PUBLISHED_IN": 1, "IS_ABOUT_TOPIC": 1},
+			wantHierarchy: []string{"Topic"},
+		},
+		{
+			name:    "topic without id",
+			partial: func(work *domain.Work) { work.Topics[0] = domain.Topic{DisplayName: "nameless"} },
+			want: map[string]int64{"Author": 1, "Institution": 1, "Venue": 1,
+				"AUTHORED": 1, "AFFILIATED_ON_WORK": 1, "PUBLISHED_IN": 1},
+		},
+		{
+			name: "authorship and institution without id",
+			partial: func(work *domain.Work) {
+				work.Authorships = []domain.Authorship{
+					authorship("A1", domain.DehydratedInstitution{DisplayName: "nameless"}),
+					{Author: domain.DehydratedAuthor{DisplayName: "Anonymous"}},
+				}
+			},
+			want:          map[string]int64{"Author": 1, "Venue": 1, "Topic": 1, "AUTHORED": 1, "PUBLISHED_IN": 1, "IS_ABOUT_TOPIC": 1},
+			wantHierarchy: []string{"Topic", "Subfield", "Field", "Domain", "IN_SUBFIELD", "IN_FIELD", "IN_DOMAIN"},
+			wantCached:    true,
+		},
+		{
+			name:    "venue without id",
+			partial: func(work *domain.Work) { work.PrimaryLocation.Source.ID = "" },
+			want: map[string]int64{"Author": 1, "Institution": 1, "Topic": 1,
+				"AUTHORED": 1, "AFFILIATED_ON_WORK": 1, "IS_ABOUT_TOPIC": 1},
+			wantHierarchy: []string{"Topic", "Subfield", "Field", "Domain", "IN_SUBFIELD", "IN_FIELD", "IN_DOMAIN"},
+			wantCached:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestTenant(t, r)
+			prefix := "T-" + tenantOf(ctx)
+			cleanTopics(t, r, ctx, prefix)
+			work := richWork("W1", prefix)
+			tt.partial(&work)
+
+			if _, err := r.SaveWork(ctx, work, SaveOptions{IncludeTopics: true, IncludeVenue: true}); err != nil {
+				t.Fatalf("SaveWork: %v", err)
+			}
+			counts := graphCounts(t, r, ctx, prefix)
+			for _, name := range []string{"Author", "Institution", "Venue", "Topic", "AUTHORED", "AFFILIATED_ON_WORK", "PUBLISHED_IN", "IS_ABOUT_TOPIC"} {
+				if counts[name] != tt.want[name] {
+					t.Errorf("%d %s, want %d", counts[name], name, tt.want[name])
+				}
+			}
+			empty := query(t, r, ctx, `
+				MATCH (n) WHERE n.id = '' AND (n.tenant = $tenant OR n:Topic OR n:Subfield OR n:Field OR n:Domain)
+				RETURN labels(n) AS labels
+			`, nil)
+			if len(empty) > 0 {
+				t.Errorf("nodes without an id: %v", empty)
+			}
+
+			var hierarchy []string
+			for _, record := range query(t, r, ctx, `
+				MATCH (n) WHERE (n:Topic OR n:Subfield OR n:Field OR n:Domain) AND n.id STARTS WITH $prefix
+				RETURN labels(n)[0] AS name
+				UNION ALL
+				MATCH (n)-[rel:IN_SUBFIELD|IN_FIELD|IN_DOMAIN]->() WHERE n.id STARTS WITH $prefix
+				RETURN type(rel) AS name
+			`, map[string]any{"prefix": prefix}) {
+				hierarchy = append(hierarchy, record["name"].(string))
+			}
+			if !reflect.DeepEqual(sortedCopy(hierarchy), sortedCopy(tt.wantHierarchy)) {
+				t.Errorf("hierarchy = %v, want %v", hierarchy, tt.wantHierarchy)
+			}
+			if cached := r.topics.has(prefix + "-1"); cached != tt.wantCached {
+				t.Errorf("topic cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+// A topic first saved with a partial hierarchy gets the rest of it when it comes in full.
+func TestPartialTopicHierarchyIsCompletedLater(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	prefix := "T-" + tenantOf(ctx)
+	cleanTopics(t, r, ctx, prefix)
+
+	partial := richWork("W1", prefix)
+	partial.Topics[0].Field, partial.Topics[0].Domain = domain.TopicParent{}, domain.TopicParent{}
+	for _, work := range []domain.Work{partial, richWork("W2", prefix)} {
+		if _, err := r.SaveWork(ctx, work, SaveOptions{IncludeTopics: true}); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+	paths := query(t, r, ctx, `
+		MATCH (t:Topic {id: $id})-[:IN_SUBFIELD]->(:Subfield)-[:IN_FIELD]->(:Field)-[:IN_DOMAIN]->(:Domain)
+		RETURN count(*) AS n
+	`, map[string]any{"id": prefix + "-1"})
+	if n := intProp(paths[0], "n"); n != 1 {
+		t.Errorf("%d complete hierarchy paths, want 1", n)
+	}
+	if !r.topics.has(prefix + "-1") {
+		t.Error("completed topic is not cached")
+	}
+}
+
+// sortedCopy returns names sorted, leaving names as they are.
+func sortedCopy(names []string) []string {
+	names = append([]string{}, names...)
+	sort.Strings(names)
+	return names
+}
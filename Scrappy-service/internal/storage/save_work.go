@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// The statements of SaveWork. They are built once, with their provenance stamps, rather
+// than on every save. Each writes all rows of its kind in one UNWIND, so a work costs the
+// same handful of statements however many authors and topics it has.
+var (
+	saveWorkNodeQuery = `
+		MERGE (w:Work {id: $id, tenant: $tenant})
+		ON CREATE SET
+			w.title = $title, w.publicationYear = $pubYear, w.publicationDate = $publicationDate,
+			w.citedByCount = $citedByCount, w.doi = $doi, w.isRetracted = $isRetracted,
+			w.isOa = $isOa, w.pdfUrl = $pdfUrl, w.firstSeen = datetime()
+		ON MATCH SET
+			w.title = $title, w.publicationYear = $pubYear, w.publicationDate = $publicationDate,
+			w.citedByCount = $citedByCount, w.doi = $doi, w.isRetracted = $isRetracted,
+			w.isOa = $isOa, w.pdfUrl = $pdfUrl,
+			// A stub is first seen when it is saved itself, not when it was cited.
+			w.firstSeen = CASE WHEN w.stub THEN datetime() ELSE w.firstSeen END
+		SET w.doiNormalized = $doiNormalized, w.publicationDatePrecision = $publicationDatePrecision,
+			w.abstract = CASE WHEN $abstract = '' THEN w.abstract ELSE $abstract END,
+			w.hasFulltext = $hasFulltext, w.createdDate = coalesce($createdDate, w.createdDate),
+			w.updatedDate = coalesce($updatedDate, w.updatedDate),
+			` + stampSource("w") + `,
+			w.type = coalesce($type, w.type), w.volume = coalesce($volume, w.volume),
+			w.issue = coalesce($issue, w.issue), w.firstPage = coalesce($firstPage, w.firstPage),
+			w.lastPage = coalesce($lastPage, w.lastPage),
+			// savedParts are the optional parts (SaveOptions) the work was ever saved with.
+			w.savedParts = reduce(acc = coalesce(w.savedParts, []), p IN $savedParts | CASE WHEN p IN acc THEN acc ELSE acc + p END)
+		// A work cited before it was saved itself was a stub until now.
+		REMOVE w.stub
+		FOREACH (altId IN CASE WHEN $alternateId IS NULL OR $alternateId IN coalesce(w.alternateIds, []) THEN [] ELSE [$alternateId] END |
+			SET w.alternateIds = coalesce(w.alternateIds, []) + altId
+		)
+	`
+
+	saveWorkLanguageQuery = `
+		MATCH (w:Work {id: $workId, tenant: $tenant})
+		OPTIONAL MATCH (w)-[old:IN_LANGUAGE]->(other:Language)
+		WHERE other.code <> $language
+		DELETE old
+		WITH DISTINCT w
+		MERGE (l:Language {code: $language})
+		MERGE (w)-[r:IN_LANGUAGE]->(l)
+		SET r.tenant = $tenant, ` + stampSource("r") + `
+	`
+
+	saveWorkAuthorshipsQuery = `
+		MATCH (w:Work {id: $workId, tenant: $tenant})
+		UNWIND $authorships AS row
+		MERGE (a:Author {id: row.authorId, tenant: $tenant}) ON CREATE SET a.displayName = row.authorName
+		MERGE (a)-[r:AUTHORED]->(w)
+		SET r.position = row.position, r.order = row.order, r.institutionIds = row.institutionIds, r.tenant = $tenant,
+			` + stampSource("a") + `,
+			` + stampSource("r") + `
+	`
+
+	// The authorship institutions are kept as nodes too, so their country is known.
+	saveWorkInstitutionsQuery = `
+		UNWIND $institutions AS row
+		MERGE (i:Institution {id: row.instId, tenant: $tenant}) ON CREATE SET i.displayName = row.displayName
+		SET i.countryCode = CASE WHEN row.countryCode = '' THEN i.countryCode ELSE row.countryCode END,
+			i.ror = CASE WHEN row.ror = '' THEN i.ror ELSE row.ror END,
+			` + stampSource("i") + `
+	`
+
+	saveWorkAffiliationsQuery = `
+		MATCH (w:Work {id: $workId, tenant: $tenant})
+		OPTIONAL MATCH (w)-[old:AFFILIATED_ON_WORK]->(prev:Institution)
+		WHERE NOT prev.id IN [row IN $affiliations | row.instId]
+		DELETE old
+		WITH DISTINCT w
+		UNWIND $affiliations AS row
+		MATCH (i:Institution {id: row.instId, tenant: $tenant})
+		MERGE (w)-[aw:AFFILIATED_ON_WORK]->(i)
+		SET aw.authorIds = row.authorIds, aw.tenant = $tenant, ` + stampSource("aw") + `
+	`
+
+	saveWorkVenueQuery = `
+		MERGE (v:Venue {id: $venueId, tenant: $tenant}) ON CREATE SET v.displayName = $venueName
+		SET v.issnL = coalesce(v.issnL, $issnL), ` + stampSource("v") + `
+		FOREACH (altId IN CASE WHEN $alternateId IS NULL OR $alternateId IN coalesce(v.alternateIds, []) THEN [] ELSE [$alternateId] END |
+			SET v.alternateIds = coalesce(v.alternateIds, []) + altId
+		)
+		MERGE (w:Work {id: $workId, tenant: $tenant})
+		MERGE (w)-[p:PUBLISHED_IN]->(v)
+		SET p.tenant = $tenant, ` + stampSource("p") + `
+	`
+
+	saveWorkCitesQuery = `
+		MATCH (w:Work {id: $workId, tenant: $tenant})
+		UNWIND $refIds AS refId
+		WITH w, refId WHERE refId <> w.id
+		// Only stubs created here are stamped: citing a work doesn't write its data.
+		MERGE (ref:Work {id: refId, tenant: $tenant}) ON CREATE SET ref.stub = true, ` + stampSource("ref") + `
+		MERGE (w)-[c:CITES]->(ref)
+		SET c.tenant = $tenant, ` + stampSource("c") + `
+	`
+
+	saveWorkGrantsQuery = `
+		MATCH (w:Work {id: $workId, tenant: $tenant})
+		OPTIONAL MATCH (w)-[old:FUNDED_BY]->(prev:Funder)
+		WHERE NOT prev.id IN [g IN $grants | g.funderId]
+		DELETE old
+		WITH DISTINCT w
+		UNWIND $grants AS g
+		MERGE (f:Funder {id: g.funderId, tenant: $tenant}) ON CREATE SET f.displayName = g.displayName
+		MERGE (w)-[fb:FUNDED_BY]->(f)
+		SET fb.awardIds = g.awardIds, fb.tenant = $tenant,
+			` + stampSource("f") + `,
+			` + stampSource("fb") + `
+	`
+
+	// The topic hierarchy was created beforehand by ensureTopicHierarchy; the relevance
+	// score goes on the relationship.
+	saveWorkTopicsQuery = `
+		MATCH (w:Work {id: $workId, tenant: $tenant})
+		UNWIND $topics AS row
+		MATCH (t:Topic {id: row.topicId})
+		MERGE (w)-[r:IS_ABOUT_TOPIC]->(t)
+		SET r.score = row.score, r.tenant = $tenant, ` + stampSource("r") + `
+	`
+)
+
+// workSaveRows is what SaveWork writes of a work, as statement parameters built once per
+// work, before its transaction, so retries of the transaction don't build them again.
+// Authorships and institutions without an id are left out: partial responses can carry
+// them, and they would be merged as {id: ""} nodes.
+type workSaveRows struct {
+	node         map[string]any // Parameters of saveWorkNodeQuery; tenant, id and alternateId are set in the transaction.
+	language     string
+	authorships  []map[string]any
+	institutions []map[string]any
+	affiliations []map[string]any
+	refIDs       []string
+	grants       []map[string]any
+	topics       []map[string]any
+}
+
+// newWorkSaveRows builds the rows of work for a save with opts. Only the parts opts asks
+// for are built.
+func newWorkSaveRows(work domain.Work, opts SaveOptions) *workSaveRows {
+	rows := &workSaveRows{language: domain.NormalizeLanguage(work.Language)}
+
+	isOa := false
+	pdfUrl := ""
+	if work.BestOaLocation != nil {
+		isOa = work.BestOaLocation.IsOa
+		pdfUrl = work.BestOaLocation.PdfUrl
+	}
+	// publicationDate is stored as a date so range queries work; partial dates are
+	// padded to the first of the month/year and flagged through publicationDatePrecision.
+	var publicationDate, datePrecision any
+	if t, precision, ok := domain.ParsePublicationDate(work.PublicationDate); ok {
+		publicationDate, datePrecision = neo4j.DateOf(t), precision
+	} else if work.PublicationYear != 0 {
+		publicationDate = neo4j.DateOf(time.Date(work.PublicationYear, time.January, 1, 0, 0, 0, 0, time.UTC))
+		datePrecision = domain.DatePrecisionYear
+	}
+	// Dates, a DOI and bibliographic details missing from the response keep the stored ones.
+	rows.node = map[string]any{
+		"title": work.Title, "pubYear": work.PublicationYear,
+		"publicationDate": publicationDate, "publicationDatePrecision": datePrecision, "citedByCount": work.CitedByCount,
+		"doi": work.Doi, "isRetracted": work.IsRetracted, "isOa": isOa, "pdfUrl": pdfUrl,
+		"doiNormalized": nullIfEmpty(domain.NormalizeDOI(work.Doi)), "abstract": work.Abstract,
+		"hasFulltext": work.HasFulltext, "createdDate": nullIfEmpty(work.CreatedDate), "updatedDate": nullIfEmpty(work.UpdatedDate),
+		"savedParts": opts.parts(), "source": opts.source(),
+		"type": nullIfEmpty(work.Type), "volume": nullIfEmpty(work.Biblio.Volume), "issue": nullIfEmpty(work.Biblio.Issue),
+		"firstPage": nullIfEmpty(work.Biblio.FirstPage), "lastPage": nullIfEmpty(work.Biblio.LastPage),
+	}
+
+	rows.authorships = make([]map[string]any, 0, len(work.Authorships))
+	institutionRows := make(map[string]map[string]any)
+	for order, authorship := range work.Authorships {
+		if authorship.Author.ID == "" {
+			continue
+		}
+		var instIDs []string
+		for _, inst := range authorship.Institutions {
+			if inst.ID == "" {
+				continue
+			}
+			instIDs = append(instIDs, inst.ID)
+			// An institution listed on several authorships gets one row: its first display
+			// name, as it is only set on create, and the last country and ROR given.
+			row, ok := institutionRows[inst.ID]
+			if !ok {
+				row = map[string]any{"instId": inst.ID, "displayName": inst.DisplayName, "countryCode": "", "ror": ""}
+				institutionRows[inst.ID] = row
+				rows.institutions = append(rows.institutions, row)
+			}
+			if inst.CountryCode != "" {
+				row["countryCode"] = inst.CountryCode
+			}
+			if inst.Ror != "" {
+				row["ror"] = inst.Ror
+			}
+		}
+		rows.authorships = append(rows.authorships, map[string]any{
+			"authorId": authorship.Author.ID, "authorName": authorship.Author.DisplayName,
+			"position": authorship.AuthorPosition, "order": order, "institutionIds": instIDs,
+		})
+	}
+	rows.affiliations = workAffiliationRows(work.Authorships)
+
+	if opts.IncludeCitations {
+		rows.refIDs = make([]string, 0, len(work.ReferencedWorks))
+		for _, ref := range work.ReferencedWorks {
+			if ref != "" {
+				rows.refIDs = append(rows.refIDs, ref)
+			}
+		}
+	}
+	if opts.IncludeGrants {
+		rows.grants = funderRows(work.Grants)
+	}
+	if opts.IncludeTopics {
+		rows.topics = make([]map[string]any, 0, len(work.Topics))
+		for _, topic := range work.Topics {
+			if topic.ID != "" {
+				rows.topics = append(rows.topics, map[string]any{"topicId": topic.ID, "score": topic.Score})
+			}
+		}
+	}
+	return rows
+}
@@ -0,0 +1,349 @@
+package storage
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// legacySaveParams are the parameters SaveWork passed before its writes were batched, one
+// map per statement; authorships, institutions and topics had a statement each. A nil map
+// is a statement that wasn't issued.
+type legacySaveParams struct {
+	node         map[string]any
+	language     map[string]any
+	authorships  []map[string]any
+	institutions []map[string]any
+	affiliations map[string]any
+	cites        map[string]any
+	grants       map[string]any
+	topics       []map[string]any
+}
+
+// legacyWorkSaveParams builds the parameters as SaveWork did before newWorkSaveRows, for a
+// work saved onto the node nodeID. It is the reference the batched rows are checked against.
+func legacyWorkSaveParams(work domain.Work, opts SaveOptions, tenant, nodeID string) legacySaveParams {
+	var p legacySaveParams
+	var doiParam, alternateID any
+	if doiNormalized := domain.NormalizeDOI(work.Doi); doiNormalized != "" {
+		doiParam = doiNormalized
+	}
+	if nodeID != work.ID {
+		alternateID = work.ID
+	}
+	isOa := false
+	pdfUrl := ""
+	if work.BestOaLocation != nil {
+		isOa = work.BestOaLocation.IsOa
+		pdfUrl = work.BestOaLocation.PdfUrl
+	}
+	var publicationDate, datePrecision any
+	if t, precision, ok := domain.ParsePublicationDate(work.PublicationDate); ok {
+		publicationDate, datePrecision = neo4j.DateOf(t), precision
+	} else if work.PublicationYear != 0 {
+		publicationDate = neo4j.DateOf(time.Date(work.PublicationYear, time.January, 1, 0, 0, 0, 0, time.UTC))
+		datePrecision = domain.DatePrecisionYear
+	}
+	var createdDate, updatedDate any
+	if work.CreatedDate != "" {
+		createdDate = work.CreatedDate
+	}
+	if work.UpdatedDate != "" {
+		updatedDate = work.UpdatedDate
+	}
+	p.node = map[string]any{
+		"tenant": tenant,
+		"id":     nodeID, "title": work.Title, "pubYear": work.PublicationYear,
+		"publicationDate": publicationDate, "publicationDatePrecision": datePrecision, "citedByCount": work.CitedByCount,
+		"doi": work.Doi, "isRetracted": work.IsRetracted, "isOa": isOa, "pdfUrl": pdfUrl,
+		"doiNormalized": doiParam, "alternateId": alternateID, "abstract": work.Abstract,
+		"hasFulltext": work.HasFulltext, "createdDate": createdDate, "updatedDate": updatedDate,
+		"savedParts": opts.parts(), "source": opts.source(),
+		"type": nullIfEmpty(work.Type), "volume": nullIfEmpty(work.Biblio.Volume), "issue": nullIfEmpty(work.Biblio.Issue),
+		"firstPage": nullIfEmpty(work.Biblio.FirstPage), "lastPage": nullIfEmpty(work.Biblio.LastPage),
+	}
+	if language := domain.NormalizeLanguage(work.Language); language != "" {
+		p.language = map[string]any{"tenant": tenant, "workId": nodeID, "language": language, "source": opts.source()}
+	}
+	for order, authorship := range work.Authorships {
+		if authorship.Author.ID == "" {
+			continue
+		}
+		var instIds []string
+		for _, inst := range authorship.Institutions {
+			if inst.ID != "" {
+				instIds = append(instIds, inst.ID)
+			}
+		}
+		p.authorships = append(p.authorships, map[string]any{
+			"tenant":   tenant,
+			"authorId": authorship.Author.ID, "authorName": authorship.Author.DisplayName,
+			"workId": nodeID, "position": authorship.AuthorPosition, "order": order, "institutionIds": instIds,
+			"source": opts.source(),
+		})
+		for _, inst := range authorship.Institutions {
+			if inst.ID == "" {
+				continue
+			}
+			p.institutions = append(p.institutions, map[string]any{
+				"tenant": tenant,
+				"instId": inst.ID, "instDisplayName": inst.DisplayName, "instCountryCode": inst.CountryCode,
+				"instRor": inst.Ror, "source": opts.source(),
+			})
+		}
+	}
+	if len(work.Authorships) > 0 {
+		p.affiliations = map[string]any{
+			"tenant": tenant,
+			"workId": nodeID, "affiliations": workAffiliationRows(work.Authorships), "source": opts.source(),
+		}
+	}
+	if opts.IncludeCitations {
+		var refIds []string
+		for _, ref := range work.ReferencedWorks {
+			if ref != "" && ref != nodeID {
+				refIds = append(refIds, ref)
+			}
+		}
+		if len(refIds) > 0 {
+			p.cites = map[string]any{"tenant": tenant, "workId": nodeID, "refIds": refIds, "source": opts.source()}
+		}
+	}
+	if opts.IncludeGrants {
+		p.grants = map[string]any{"tenant": tenant, "workId": nodeID, "grants": funderRows(work.Grants), "source": opts.source()}
+	}
+	if opts.IncludeTopics {
+		for _, topic := range work.Topics {
+			if topic.ID == "" {
+				continue
+			}
+			p.topics = append(p.topics, map[string]any{
+				"tenant": tenant, "workId": nodeID, "topicId": topic.ID, "score": topic.Score, "source": opts.source(),
+			})
+		}
+	}
+	return p
+}
+
+// withoutStatementParams returns rows without the parameters the batched statements pass
+// once rather than per row.
+func withoutStatementParams(rows []map[string]any) []map[string]any {
+	out := []map[string]any{}
+	for _, row := range rows {
+		row = maps.Clone(row)
+		delete(row, "tenant")
+		delete(row, "workId")
+		delete(row, "source")
+		out = append(out, row)
+	}
+	return out
+}
+
+// mergedInstitutions is the effect of the per-row institution statements, as one row per
+// institution: the first display name, set on create only, and the last country and ROR
+// that weren't empty.
+func mergedInstitutions(statements []map[string]any) []map[string]any {
+	rows := []map[string]any{}
+	index := map[string]int{}
+	for _, params := range statements {
+		i, ok := index[params["instId"].(string)]
+		if !ok {
+			i = len(rows)
+			index[params["instId"].(string)] = i
+			rows = append(rows, map[string]any{"instId": params["instId"], "displayName": params["instDisplayName"], "countryCode": "", "ror": ""})
+		}
+		if params["instCountryCode"] != "" {
+			rows[i]["countryCode"] = params["instCountryCode"]
+		}
+		if params["instRor"] != "" {
+			rows[i]["ror"] = params["instRor"]
+		}
+	}
+	return rows
+}
+
+// emptyIfNil makes nil and empty row lists compare equal; either writes nothing.
+func emptyIfNil[T any](rows []T) []T {
+	if rows == nil {
+		return []T{}
+	}
+	return rows
+}
+
+// saveWorkFixtures are works covering what newWorkSaveRows treats differently.
+func saveWorkFixtures() map[string]domain.Work {
+	berlin := domain.DehydratedInstitution{ID: "I1", DisplayName: "Berlin", CountryCode: "DE", Ror: "https://ror.org/01hcx6992"}
+	return map[string]domain.Work{
+		"empty": {ID: "W0"},
+		"rich":  richWork("W1", "T"),
+		"complete": {
+			ID: "W2", Title: "Complete", PublicationYear: 2021, PublicationDate: "2021-06-15", CitedByCount: 12,
+			Doi: "https://doi.org/10.1000/ABC", IsRetracted: true, HasFulltext: true, Abstract: "An abstract.",
+			Language: "EN", CreatedDate: "2021-07-01", UpdatedDate: "2024-02-03", Type: "article",
+			Biblio:         domain.Biblio{Volume: "7", Issue: "2", FirstPage: "12", LastPage: "34"},
+			BestOaLocation: &domain.Location{IsOa: true, PdfUrl: "https://example.org/w2.pdf"},
+			Authorships: []domain.Authorship{
+				{Author: domain.DehydratedAuthor{ID: "A1", DisplayName: "One"}, AuthorPosition: "first", Institutions: []domain.DehydratedInstitution{berlin}},
+				{Author: domain.DehydratedAuthor{ID: "A2", DisplayName: "Two"}, AuthorPosition: "last"},
+			},
+			Grants: []domain.Grant{{Funder: "F1", AwardID: "G-1"}, {Funder: "F1", AwardID: "G-2"}, {Funder: "F2"}, {AwardID: "G-3"}},
+			Topics: []domain.Topic{{ID: "T1", Score: 0.9}, {ID: "T2", Score: 0.5}},
+		},
+		"month precision": {ID: "W3", PublicationYear: 2021, PublicationDate: "2021-06"},
+		"year only":       {ID: "W4", PublicationYear: 2019},
+		"unparsable date": {ID: "W5", PublicationYear: 2018, PublicationDate: "someday"},
+		"missing ids": {
+			ID: "W6",
+			Authorships: []domain.Authorship{
+				{Author: domain.DehydratedAuthor{DisplayName: "Nameless"}, Institutions: []domain.DehydratedInstitution{berlin}},
+				authorship("A1", domain.DehydratedInstitution{DisplayName: "No id"}, berlin),
+				authorship("A2", domain.DehydratedInstitution{}),
+			},
+			ReferencedWorks: []string{"", "W7", ""},
+			Topics:          []domain.Topic{{Score: 0.4}, {ID: "T1", Score: 0.3}},
+		},
+		"shared institutions": {
+			ID: "W8",
+			Authorships: []domain.Authorship{
+				authorship("A1", domain.DehydratedInstitution{ID: "I1", DisplayName: "Berlin"}, domain.DehydratedInstitution{ID: "I2", DisplayName: "Boston", CountryCode: "US"}),
+				authorship("A2", berlin),
+				authorship("A1", domain.DehydratedInstitution{ID: "I2", DisplayName: "Boston (renamed)", Ror: "https://ror.org/03vek6s52"}),
+			},
+		},
+		"self reference": {ID: "W9", ReferencedWorks: []string{"W10", "W9", "W10"}},
+		"only self":      {ID: "W11", ReferencedWorks: []string{"W11"}},
+	}
+}
+
+// newWorkSaveRows must write what the per-row statements did before it: the same node
+// properties, and rows that amount to the same writes.
+func TestNewWorkSaveRowsMatchesPerRowParams(t *testing.T) {
+	options := []SaveOptions{{}, FullSave, {IncludeTopics: true}, {IncludeCitations: true, IncludeGrants: true, Source: SourceDeposit}}
+	for name, work := range saveWorkFixtures() {
+		for _, opts := range options {
+			t.Run(name+"/"+opts.String()+"/"+opts.source(), func(t *testing.T) {
+				rows := newWorkSaveRows(work, opts)
+				legacy := legacyWorkSaveParams(work, opts, "tenant", work.ID)
+
+				node := maps.Clone(rows.node)
+				node["tenant"], node["id"], node["alternateId"] = "tenant", work.ID, nil
+				if !reflect.DeepEqual(node, legacy.node) {
+					t.Errorf("node = %v\nwant %v", node, legacy.node)
+				}
+				if (legacy.language == nil) != (rows.language == "") || legacy.language != nil && legacy.language["language"] != rows.language {
+					t.Errorf("language = %q, want the statement %v", rows.language, legacy.language)
+				}
+				if want := withoutStatementParams(legacy.authorships); !reflect.DeepEqual(emptyIfNil(rows.authorships), want) {
+					t.Errorf("authorships = %v\nwant %v", rows.authorships, want)
+				}
+				if want := mergedInstitutions(legacy.institutions); !reflect.DeepEqual(emptyIfNil(rows.institutions), want) {
+					t.Errorf("institutions = %v\nwant %v", rows.institutions, want)
+				}
+				// SaveWork writes the affiliations only for works with authorships, as before.
+				if legacy.affiliations != nil && !reflect.DeepEqual(rows.affiliations, legacy.affiliations["affiliations"]) {
+					t.Errorf("affiliations = %v, want %v", rows.affiliations, legacy.affiliations["affiliations"])
+				}
+				// A work citing itself is now skipped by saveWorkCitesQuery rather than here.
+				refIDs := []string{}
+				for _, ref := range rows.refIDs {
+					if ref != work.ID {
+						refIDs = append(refIDs, ref)
+					}
+				}
+				var wantRefIDs []string
+				if legacy.cites != nil {
+					wantRefIDs = legacy.cites["refIds"].([]string)
+				}
+				if !reflect.DeepEqual(refIDs, emptyIfNil(wantRefIDs)) {
+					t.Errorf("refIDs = %v, want %v", rows.refIDs, wantRefIDs)
+				}
+				if (legacy.grants == nil) != (rows.grants == nil) || legacy.grants != nil && !reflect.DeepEqual(rows.grants, legacy.grants["grants"]) {
+					t.Errorf("grants = %v, want the statement %v", rows.grants, legacy.grants)
+				}
+				if want := withoutStatementParams(legacy.topics); !reflect.DeepEqual(emptyIfNil(rows.topics), want) {
+					t.Errorf("topics = %v\nwant %v", rows.topics, want)
+				}
+			})
+		}
+	}
+	if !strings.Contains(saveWorkCitesQuery, "refId <> w.id") {
+		t.Errorf("saveWorkCitesQuery doesn't skip the work itself:\n%s", saveWorkCitesQuery)
+	}
+}
+
+func TestNewWorkSaveRowsMergesInstitutions(t *testing.T) {
+	rows := newWorkSaveRows(saveWorkFixtures()["shared institutions"], SaveOptions{})
+	want := []map[string]any{
+		{"instId": "I1", "displayName": "Berlin", "countryCode": "DE", "ror": "https://ror.org/01hcx6992"},
+		{"instId": "I2", "displayName": "Boston", "countryCode": "US", "ror": "https://ror.org/03vek6s52"},
+	}
+	if !reflect.DeepEqual(rows.institutions, want) {
+		t.Errorf("institutions = %v\nwant %v", rows.institutions, want)
+	}
+	if got := rows.authorships[2]["institutionIds"]; !reflect.DeepEqual(got, []string{"I2"}) {
+		t.Errorf("third authorship's institutionIds = %v, want [I2]", got)
+	}
+}
+
+// largeWork is a work with many authors, institutions, references and topics, as for the
+// big collaborations that made per-row statements slow. Its topics are under topicPrefix.
+func largeWork(id, topicPrefix string) domain.Work {
+	work := richWork(id, topicPrefix)
+	work.Authorships = nil
+	for i := 0; i < 50; i++ {
+		work.Authorships = append(work.Authorships, authorship(fmt.Sprintf("A%d", i),
+			domain.DehydratedInstitution{ID: fmt.Sprintf("I%d", i%10), CountryCode: "DE"},
+			domain.DehydratedInstitution{ID: fmt.Sprintf("I%d", i%7+10), CountryCode: "US"}))
+	}
+	work.ReferencedWorks = nil
+	for i := 0; i < 40; i++ {
+		work.ReferencedWorks = append(work.ReferencedWorks, fmt.Sprintf("W-ref-%d", i))
+	}
+	topic := work.Topics[0]
+	for i := 2; i <= 5; i++ {
+		topic.ID = fmt.Sprintf("%s-%d", topicPrefix, i)
+		work.Topics = append(work.Topics, topic)
+	}
+	return work
+}
+
+// BenchmarkSaveWorkParams compares building a large work's rows with building its per-row
+// parameters as SaveWork did before.
+func BenchmarkSaveWorkParams(b *testing.B) {
+	work := largeWork("W1", "T")
+	b.Run("rows", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			newWorkSaveRows(work, FullSave)
+		}
+	})
+	b.Run("per row", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			legacyWorkSaveParams(work, FullSave, "tenant", work.ID)
+		}
+	})
+}
+
+// BenchmarkSaveWorksBatch100 saves batches of 100 large works, as an ingest page does.
+func BenchmarkSaveWorksBatch100(b *testing.B) {
+	r, ctx := newTestRepo(b)
+	prefix := "T-" + tenantOf(ctx)
+	cleanTopics(b, r, ctx, prefix)
+	opts := FullSave
+	opts.Force = true
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			if _, err := r.SaveWork(ctx, largeWork(fmt.Sprintf("W%d", j), prefix), opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
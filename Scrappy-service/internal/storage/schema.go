@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// schemaStatements create the indexes the repository's queries rely on. They are
+// idempotent and run every time the repository is created.
+var schemaStatements = []string{
+	`CREATE INDEX work_id IF NOT EXISTS FOR (w:Work) ON (w.id)`,
+	`CREATE INDEX author_id IF NOT EXISTS FOR (a:Author) ON (a.id)`,
+	`CREATE INDEX institution_id IF NOT EXISTS FOR (i:Institution) ON (i.id)`,
+	`CREATE INDEX work_doi_normalized IF NOT EXISTS FOR (w:Work) ON (w.doiNormalized)`,
+	`CREATE INDEX work_ss_paper_id IF NOT EXISTS FOR (w:Work) ON (w.ssPaperId)`,
+	`CREATE INDEX ingest_event_target IF NOT EXISTS FOR (e:IngestEvent) ON (e.targetId)`,
+	`CREATE INDEX ingest_event_id IF NOT EXISTS FOR (e:IngestEvent) ON (e.id)`,
+	`CREATE INDEX ingest_event_status IF NOT EXISTS FOR (e:IngestEvent) ON (e.status)`,
+	`CREATE INDEX work_publication_date IF NOT EXISTS FOR (w:Work) ON (w.publicationDate)`,
+	`CREATE INDEX work_publication_year IF NOT EXISTS FOR (w:Work) ON (w.publicationYear)`,
+	`CREATE INDEX work_tenant_publication_year IF NOT EXISTS FOR (w:Work) ON (w.tenant, w.publicationYear)`,
+	`CREATE INDEX work_cited_by_count IF NOT EXISTS FOR (w:Work) ON (w.citedByCount)`,
+	`CREATE INDEX author_tenant IF NOT EXISTS FOR (a:Author) ON (a.tenant)`,
+	`CREATE INDEX work_tenant IF NOT EXISTS FOR (w:Work) ON (w.tenant)`,
+	`CREATE INDEX institution_ror IF NOT EXISTS FOR (i:Institution) ON (i.ror)`,
+	`CREATE INDEX institution_tenant IF NOT EXISTS FOR (i:Institution) ON (i.tenant)`,
+	`CREATE INDEX venue_tenant IF NOT EXISTS FOR (v:Venue) ON (v.tenant)`,
+	`CREATE INDEX venue_issn_l IF NOT EXISTS FOR (v:Venue) ON (v.issnL)`,
+	`CREATE INDEX language_code IF NOT EXISTS FOR (l:Language) ON (l.code)`,
+	`CREATE INDEX funder_id IF NOT EXISTS FOR (f:Funder) ON (f.id)`,
+	`CREATE INDEX blocked_id IF NOT EXISTS FOR (b:Blocked) ON (b.id)`,
+	`CREATE INDEX user_id IF NOT EXISTS FOR (u:User) ON (u.id)`,
+	`CREATE FULLTEXT INDEX author_names IF NOT EXISTS FOR (a:Author) ON EACH [a.displayName, a.nameAliases]`,
+}
+
+// migrationStatements backfill properties introduced after data was first written. They
+// only touch nodes that still need it, so running them again is cheap.
+var migrationStatements = []string{
+	// doiNormalized is what SaveWork and FindDuplicateWorksByDOI match on. The CASE mirrors
+	// domain.NormalizeDOI; values an earlier, partial version of this migration left behind
+	// are rewritten too.
+	`MATCH (w:Work) WHERE w.doi IS NOT NULL AND w.doi <> ''
+	 WITH w, toLower(trim(w.doi)) AS doi
+	 WITH w, trim(CASE
+		WHEN doi STARTS WITH 'https://doi.org/' THEN substring(doi, 16)
+		WHEN doi STARTS WITH 'http://doi.org/' THEN substring(doi, 15)
+		WHEN doi STARTS WITH 'https://dx.doi.org/' THEN substring(doi, 19)
+		WHEN doi STARTS WITH 'http://dx.doi.org/' THEN substring(doi, 18)
+		WHEN doi STARTS WITH 'doi:' THEN substring(doi, 4)
+		ELSE doi END) AS doi
+	 WHERE w.doiNormalized IS NULL OR w.doiNormalized <> doi
+	 CALL {
+		WITH w, doi
+		SET w.doiNormalized = CASE WHEN doi = '' THEN null ELSE doi END
+	 } IN TRANSACTIONS OF 10000 ROWS`,
+	// publicationDate used to be stored as the raw OpenAlex string.
+	`MATCH (w:Work) WHERE w.publicationDate IS :: STRING NOT NULL
+	 CALL {
+		WITH w
+		SET w.publicationDatePrecision = CASE size(w.publicationDate) WHEN 4 THEN 'year' WHEN 7 THEN 'month' ELSE 'day' END,
+			w.publicationDate = CASE WHEN w.publicationDate =~ '\\d{4}(-\\d{2}){0,2}' THEN date(w.publicationDate) ELSE null END
+	 } IN TRANSACTIONS OF 10000 ROWS`,
+	// Nodes written before tenants existed belong to the shared namespace. Scoped nodes are
+	// merged on (id, tenant), so they need the property to be found again. One statement
+	// per label, so each only scans its own label.
+	tenantMigration("Author"),
+	tenantMigration("Work"),
+	tenantMigration("Institution"),
+	tenantMigration("Venue"),
+	tenantMigration("IngestEvent"),
+	// nameAliases (the alternative names as one string) feeds the author_names full-text index.
+	`MATCH (a:Author) WHERE a.nameAliases IS NULL AND a.displayNameAlternatives IS NOT NULL
+	 CALL {
+		WITH a
+		SET a.nameAliases = reduce(s = '', name IN a.displayNameAlternatives |
+			CASE WHEN s = '' THEN name ELSE s + '\n' + name END)
+	 } IN TRANSACTIONS OF 10000 ROWS`,
+}
+
+// tenantMigration puts the label nodes without a tenant into the shared namespace.
+func tenantMigration(label string) string {
+	return fmt.Sprintf(`MATCH (n:%s) WHERE n.tenant IS NULL
+	 CALL {
+		WITH n
+		SET n.tenant = ''
+	 } IN TRANSACTIONS OF 10000 ROWS`, label)
+}
+
+// ensureSchema creates missing indexes and runs the data migrations. The topic cache is
+// dropped afterwards, as nothing it remembers is known to survive a schema reset.
+func (r *neo4jRepository) ensureSchema(ctx context.Context) error {
+	defer r.topics.reset()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	// Schema changes and CALL ... IN TRANSACTIONS need auto-commit transactions.
+	for _, stmt := range append(append([]string{}, schemaStatements...), migrationStatements...) {
+		res, err := session.Run(ctx, stmt, nil)
+		if err == nil {
+			_, err = res.Consume(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set up schema (%s): %w", stmt, err)
+		}
+	}
+	return nil
+}
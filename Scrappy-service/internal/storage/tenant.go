@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/Cloudforge2/scrappy/internal/tenant"
+)
+
+// tenantOf returns the value for the $tenant query parameter. Author, Work, Institution,
+// Venue and IngestEvent nodes are merged on (id, tenant) and reads filter on it, so every
+// tenant sees only the nodes it ingested. The Topic hierarchy is shared by all tenants.
+func tenantOf(ctx context.Context) string {
+	return tenant.FromContext(ctx)
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+// The same OpenAlex entities ingested by two tenants are two sets of nodes, and each
+// tenant's reads only ever see its own.
+func TestTenantsAreIsolated(t *testing.T) {
+	r, ctxA := newTestRepo(t)
+	ctxB := newTestTenant(t, r)
+
+	save := func(ctx context.Context, work domain.Work) {
+		t.Helper()
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+	shared := func(title string) domain.Work {
+		return domain.Work{ID: "W1", Title: title, PublicationYear: 2020, CitedByCount: 5,
+			Authorships: []domain.Authorship{authorship("A1", domain.DehydratedInstitution{ID: "I1", CountryCode: "DE"}), authorship("A2")}}
+	}
+	save(ctxA, shared("seen by A"))
+	save(ctxB, shared("seen by B"))
+	save(ctxB, domain.Work{ID: "W2", Title: "only B", PublicationYear: 2021, CitedByCount: 50,
+		Authorships: []domain.Authorship{authorship("A1", domain.DehydratedInstitution{ID: "I2", CountryCode: "US"}), authorship("A2")}})
+
+	t.Run("merges don't collide", func(t *testing.T) {
+		records := query(t, r, ctxA, `
+			MATCH (w:Work {id: 'W1'}) WHERE w.tenant IN [$tenant, $other]
+			RETURN w.tenant AS tenant, w.title AS title ORDER BY title
+		`, map[string]any{"other": tenantOf(ctxB)})
+		if len(records) != 2 || records[0]["title"] != "seen by A" || records[1]["title"] != "seen by B" {
+			t.Errorf("W1 nodes = %v, want one per tenant with its own title", records)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		check func(t *testing.T, ctx context.Context, want string)
+	}{
+		{"WorkExists", func(t *testing.T, ctx context.Context, want string) {
+			exists, err := r.WorkExists(ctx, "W2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists != (want == "B") {
+				t.Errorf("W2 exists = %v for tenant %s", exists, want)
+			}
+		}},
+		{"GetAuthorWorks", func(t *testing.T, ctx context.Context, want string) {
+			works, err := r.GetAuthorWorks(ctx, "A1", false, true, true, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantWorks := map[string]int{"A": 1, "B": 2}[want]
+			if len(works) != wantWorks {
+				t.Fatalf("got %d works, want %d: %v", len(works), wantWorks, works)
+			}
+			for _, work := range works {
+				if work.ID == "W1" && work.Title != "seen by "+want {
+					t.Errorf("W1 title = %q for tenant %s", work.Title, want)
+				}
+			}
+		}},
+		{"GetTopWorks", func(t *testing.T, ctx context.Context, want string) {
+			works, err := r.GetTopWorks(ctx, 10, 0, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, work := range works {
+				if work.ID == "W2" && want != "B" || work.ID == "W1" && work.Title != "seen by "+want {
+					t.Errorf("tenant %s sees %s (%q)", want, work.ID, work.Title)
+				}
+			}
+		}},
+		{"CountCollaborationsByCountry", func(t *testing.T, ctx context.Context, want string) {
+			counts, err := r.CountCollaborationsByCountry(ctx, "A2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := counts["US"]; ok != (want == "B") {
+				t.Errorf("tenant %s sees B's US collaboration: %v", want, counts)
+			}
+		}},
+		{"GetWorksForExport", func(t *testing.T, ctx context.Context, want string) {
+			works, err := r.GetWorksForExport(ctx, []string{"W1", "W2"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, work := range works {
+				if work.ID == "W2" && want != "B" || work.ID == "W1" && work.Title != "seen by "+want {
+					t.Errorf("tenant %s sees %s (%q)", want, work.ID, work.Title)
+				}
+			}
+		}},
+	}
+	contexts := map[string]context.Context{"A": ctxA, "B": ctxB}
+	for _, tt := range tests {
+		for name, ctx := range contexts {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				tt.check(t, ctx, name)
+			})
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/metrics"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+var queryDurations = metrics.NewHistogramVec("scrappy_neo4j_query_duration_seconds",
+	"Duration of the Cypher statements run by the repository, from sending them to consuming their result.",
+	"query", metrics.DurationBuckets)
+
+// Option configures a Neo4j repository.
+type Option func(*neo4jRepository)
+
+// WithSlowQueryThreshold logs every statement that takes longer than threshold, by query
+// name. Zero turns the log off.
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(r *neo4jRepository) {
+		r.slowQuery = threshold
+	}
+}
+
+// run runs a statement in tx and times it under name, a short identifier of the statement
+// (e.g. "SaveWork/authorship") used in the metrics and the slow query log instead of the
+// query text or its parameters. The duration is taken when the result is consumed with
+// Collect, Single or Consume. Once ctx is done no statement is sent anymore, so a
+// cancelled request doesn't keep a transaction issuing statements.
+func (r *neo4jRepository) run(ctx context.Context, tx neo4j.ManagedTransaction, name, query string, params map[string]any) (neo4j.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	res, err := tx.Run(ctx, query, params)
+	if err != nil {
+		r.observeQuery(name, time.Since(start))
+		return nil, err
+	}
+	return &timedResult{Result: res, done: func() { r.observeQuery(name, time.Since(start)) }}, nil
+}
+
+// exec runs a statement whose records aren't needed, timed like run, and consumes its result.
+func (r *neo4jRepository) exec(ctx context.Context, tx neo4j.ManagedTransaction, name, query string, params map[string]any) error {
+	res, err := r.run(ctx, tx, name, query, params)
+	if err != nil {
+		return err
+	}
+	_, err = res.Consume(ctx)
+	return err
+}
+
+func (r *neo4jRepository) observeQuery(name string, took time.Duration) {
+	queryDurations.Observe(name, took.Seconds())
+	if r.slowQuery > 0 && took > r.slowQuery {
+		log.Printf("WARN: Slow query %s took %s (threshold %s)", name, took.Round(time.Millisecond), r.slowQuery)
+	}
+}
+
+// timedResult calls done the first time its records are consumed as a whole.
+type timedResult struct {
+	neo4j.Result
+	once sync.Once
+	done func()
+}
+
+func (t *timedResult) Collect(ctx context.Context) ([]*neo4j.Record, error) {
+	defer t.once.Do(t.done)
+	return t.Result.Collect(ctx)
+}
+
+func (t *timedResult) Single(ctx context.Context) (*neo4j.Record, error) {
+	defer t.once.Do(t.done)
+	return t.Result.Single(ctx)
+}
+
+func (t *timedResult) Consume(ctx context.Context) (neo4j.ResultSummary, error) {
+	defer t.once.Do(t.done)
+	return t.Result.Consume(ctx)
+}
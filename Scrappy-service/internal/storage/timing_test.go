@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/metrics"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// fakeTx is a transaction whose statements take delay each and do nothing. afterRun, if
+// set, is called after each statement.
+type fakeTx struct {
+	delay    time.Duration
+	afterRun func()
+	queries  int
+}
+
+func (tx *fakeTx) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.Result, error) {
+	time.Sleep(tx.delay)
+	tx.queries++
+	if tx.afterRun != nil {
+		tx.afterRun()
+	}
+	return fakeResult{}, nil
+}
+
+// fakeResult is an empty result.
+type fakeResult struct {
+	neo4j.Result
+}
+
+func (fakeResult) Collect(ctx context.Context) ([]*neo4j.Record, error) { return nil, nil }
+
+func (fakeResult) Consume(ctx context.Context) (neo4j.ResultSummary, error) { return nil, nil }
+
+// captureLog collects what the standard logger writes during the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(original) })
+	return &buf
+}
+
+// observations returns how many durations the query duration histogram holds for name.
+func observations(t *testing.T, name string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	prefix := fmt.Sprintf("scrappy_neo4j_query_duration_seconds_count{query=%q} ", name)
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line, prefix)
+		}
+	}
+	return "0"
+}
+
+func TestRunTimesStatements(t *testing.T) {
+	tests := []struct {
+		name         string
+		threshold    time.Duration
+		delay        time.Duration // of the statement
+		consumeDelay time.Duration // between running it and consuming its result
+		wantLog      bool
+	}{
+		{name: "slow", threshold: 5 * time.Millisecond, delay: 20 * time.Millisecond, wantLog: true},
+		{name: "fast", threshold: time.Second},
+		{name: "slow to consume", threshold: 5 * time.Millisecond, consumeDelay: 20 * time.Millisecond, wantLog: true},
+		{name: "log off", delay: 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			r := &neo4jRepository{slowQuery: tt.threshold}
+			name := "TestRunTimesStatements/" + tt.name
+
+			res, err := r.run(context.Background(), &fakeTx{delay: tt.delay}, name, "RETURN $secret", map[string]any{"secret": "payload-value"})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			time.Sleep(tt.consumeDelay)
+			res.Collect(context.Background())
+			res.Consume(context.Background()) // Observed once, however the result is consumed.
+
+			if logged := strings.Contains(logs.String(), "WARN: Slow query "+name+" took"); logged != tt.wantLog {
+				t.Errorf("logged %q, want the slow query logged: %v", logs, tt.wantLog)
+			}
+			if strings.Contains(logs.String(), "payload-value") || strings.Contains(logs.String(), "RETURN") {
+				t.Errorf("log %q reveals the statement", logs)
+			}
+			if n := observations(t, name); n != "1" {
+				t.Errorf("%s observations of %s, want 1", n, name)
+			}
+		})
+	}
+}
+
+func TestRunStopsOnCancellation(t *testing.T) {
+	r := &neo4jRepository{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tx := &fakeTx{afterRun: cancel}
+
+	// The request is cancelled during the first of three statements; the others aren't sent.
+	rels := []nodeRelationship{{"PUBLISHED_IN", true}, {"TARGETED", true}, {"CITES", false}}
+	err := r.repointRelationships(ctx, tx, "Venue", rels, "S1", "S2")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("repointRelationships error = %v, want context.Canceled", err)
+	}
+	if tx.queries != 1 {
+		t.Errorf("%d statements sent, want 1", tx.queries)
+	}
+
+	if err := r.exec(ctx, tx, "TestRunStopsOnCancellation", "RETURN 1", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("exec error = %v, want context.Canceled", err)
+	}
+	if tx.queries != 1 {
+		t.Errorf("%d statements sent after cancellation, want none", tx.queries-1)
+	}
+}
@@ -0,0 +1,467 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// topicCache remembers which topics already have their Domain/Field/Subfield/Topic nodes in
+// the graph. Concurrent SaveWork transactions MERGEing the same hierarchy nodes used to
+// deadlock each other, so the hierarchy is now created up front, once per topic, in its own
+// short transaction, and the work/author transactions only MATCH the Topic node.
+//
+// The cache belongs to a repository instance, so it starts empty whenever the repository
+// is (re)created, and can be dropped with reset.
+type topicCache struct {
+	mu      sync.RWMutex
+	ensured map[string]struct{}
+
+	// ensureMu serializes hierarchy creation within the process, so two transactions of
+	// ours never MERGE the same nodes at the same time.
+	ensureMu sync.Mutex
+}
+
+func newTopicCache() *topicCache {
+	return &topicCache{ensured: make(map[string]struct{})}
+}
+
+func (c *topicCache) has(topicID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.ensured[topicID]
+	return ok
+}
+
+func (c *topicCache) add(topicID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ensured[topicID] = struct{}{}
+}
+
+// reset forgets every ensured topic, e.g. after the database was swapped or wiped. Ping
+// calls it when the database can't be reached, and ensureSchema after a schema run.
+func (c *topicCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ensured = make(map[string]struct{})
+}
+
+// ensureTopicHierarchy makes sure every topic and its full hierarchy exist in the graph.
+// Topics seen before are skipped without a round trip, and topics without an id are
+// ignored.
+func (r *neo4jRepository) ensureTopicHierarchy(ctx context.Context, topics []domain.Topic) error {
+	var missing []domain.Topic
+	for _, topic := range topics {
+		if topic.ID != "" && !r.topics.has(topic.ID) {
+			missing = append(missing, topic)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	r.topics.ensureMu.Lock()
+	defer r.topics.ensureMu.Unlock()
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	for _, topic := range missing {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Another goroutine may have ensured it while we waited for the lock.
+		if r.topics.has(topic.ID) {
+			continue
+		}
+		query, params, complete := topicHierarchyQuery(topic)
+		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			err := r.exec(ctx, tx, "SaveTopicHierarchy", query, params)
+			return nil, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save topic hierarchy of %s: %w", topic.ID, err)
+		}
+		// A partial hierarchy is completed the next time the topic comes with all of it.
+		if complete {
+			r.topics.add(topic.ID)
+		}
+	}
+	return nil
+}
+
+// topicHierarchyQuery builds the MERGEs of a topic and the levels above it. Partial
+// OpenAlex responses (e.g. with select=) can leave the subfield, field or domain empty;
+// those levels are skipped instead of being merged as {id: ""} nodes, and only adjacent
+// levels that are both present are linked. complete reports whether no level was missing.
+func topicHierarchyQuery(topic domain.Topic) (query string, params map[string]any, complete bool) {
+	levels := []struct {
+		variable, label, rel string // rel links the level to the one above it
+		parent               domain.TopicParent
+	}{
+		{"t", "Topic", "IN_SUBFIELD", domain.TopicParent{ID: topic.ID, DisplayName: topic.DisplayName}},
+		{"s", "Subfield", "IN_FIELD", topic.Subfield},
+		{"f", "Field", "IN_DOMAIN", topic.Field},
+		{"d", "Domain", "", topic.Domain},
+	}
+	// Use MERGE to create the hierarchy path idempotently. This ensures that
+	// "Computer Science" is created only once.
+	var merges, links []string
+	params = map[string]any{}
+	complete = true
+	for i, level := range levels {
+		if level.parent.ID == "" {
+			complete = false
+			continue
+		}
+		merges = append(merges, fmt.Sprintf("MERGE (%s:%s {id: $%sId}) ON CREATE SET %s.displayName = $%sName",
+			level.variable, level.label, level.variable, level.variable, level.variable))
+		params[level.variable+"Id"] = level.parent.ID
+		params[level.variable+"Name"] = level.parent.DisplayName
+		if i > 0 && levels[i-1].parent.ID != "" {
+			below := levels[i-1]
+			links = append(links, fmt.Sprintf("MERGE (%s)-[:%s]->(%s)", below.variable, below.rel, level.variable))
+		}
+	}
+	return strings.Join(append(merges, links...), "\n"), params, complete
+}
+
+// UncategorizedTopic is the ID and name of the bucket for topics whose subfield, field or
+// domain is missing from the graph.
+const UncategorizedTopic = "uncategorized"
+
+// TopicProfile is an author's HAS_TOPIC paper counts rolled up the topic hierarchy.
+type TopicProfile struct {
+	AuthorID    string      `json:"authorId"`
+	TotalPapers int         `json:"totalPapers"`
+	Domains     []TopicNode `json:"domains"`
+}
+
+// TopicNode is a domain, field, subfield or topic of a TopicProfile. PaperCount sums the
+// topics below it, and Percentage is its share of the profile's TotalPapers.
+type TopicNode struct {
+	ID          string      `json:"id"`
+	DisplayName string      `json:"displayName"`
+	PaperCount  int         `json:"paperCount"`
+	Percentage  float64     `json:"percentage"`
+	Children    []TopicNode `json:"children,omitempty"`
+}
+
+// topicPath is one of an author's topics with its hierarchy, domain first.
+type topicPath struct {
+	levels     [4]TopicNode
+	paperCount int
+}
+
+// GetAuthorTopicProfile returns the author's topics grouped by subfield, field and domain,
+// largest first at every level. Topics with an incomplete hierarchy (saved by older
+// versions) are grouped under UncategorizedTopic at every level above them.
+func (r *neo4jRepository) GetAuthorTopicProfile(ctx context.Context, authorID string) (*TopicProfile, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetAuthorTopicProfile", `
+			MATCH (a:Author {id: $authorId, tenant: $tenant})
+			OPTIONAL MATCH (a)-[r:HAS_TOPIC]->(t:Topic)
+			OPTIONAL MATCH (t)-[:IN_SUBFIELD]->(s:Subfield)-[:IN_FIELD]->(f:Field)-[:IN_DOMAIN]->(d:Domain)
+			RETURN t.id AS topicId, t.displayName AS topicName, coalesce(r.paperCount, 0) AS paperCount,
+				s.id AS subfieldId, s.displayName AS subfieldName,
+				f.id AS fieldId, f.displayName AS fieldName,
+				d.id AS domainId, d.displayName AS domainName
+		`, map[string]any{"tenant": tenantOf(ctx), "authorId": authorID})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+
+		var paths []topicPath
+		for _, record := range records {
+			props := record.AsMap()
+			if props["topicId"] == nil {
+				continue // The author has no topics.
+			}
+			path := topicPath{paperCount: intProp(props, "paperCount")}
+			path.levels[3] = TopicNode{ID: stringProp(props, "topicId"), DisplayName: stringProp(props, "topicName")}
+			if props["domainId"] == nil {
+				for i := 0; i < 3; i++ {
+					path.levels[i] = TopicNode{ID: UncategorizedTopic, DisplayName: "Uncategorized"}
+				}
+			} else {
+				path.levels[0] = TopicNode{ID: stringProp(props, "domainId"), DisplayName: stringProp(props, "domainName")}
+				path.levels[1] = TopicNode{ID: stringProp(props, "fieldId"), DisplayName: stringProp(props, "fieldName")}
+				path.levels[2] = TopicNode{ID: stringProp(props, "subfieldId"), DisplayName: stringProp(props, "subfieldName")}
+			}
+			paths = append(paths, path)
+		}
+		return buildTopicProfile(authorID, paths), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topic profile of author %s: %w", authorID, err)
+	}
+	return result.(*TopicProfile), nil
+}
+
+// buildTopicProfile nests topic paths into a tree, summing paper counts at every level.
+func buildTopicProfile(authorID string, paths []topicPath) *TopicProfile {
+	profile := &TopicProfile{AuthorID: authorID, Domains: []TopicNode{}}
+	for _, path := range paths {
+		profile.TotalPapers += path.paperCount
+		nodes := &profile.Domains
+		for _, level := range path.levels {
+			i := slices.IndexFunc(*nodes, func(n TopicNode) bool { return n.ID == level.ID })
+			if i < 0 {
+				*nodes = append(*nodes, level)
+				i = len(*nodes) - 1
+			}
+			(*nodes)[i].PaperCount += path.paperCount
+			nodes = &(*nodes)[i].Children
+		}
+	}
+	finishTopicNodes(profile.Domains, profile.TotalPapers)
+	return profile
+}
+
+// finishTopicNodes sorts nodes (and their children) largest first and sets their
+// percentages of total, rounded to one decimal.
+func finishTopicNodes(nodes []TopicNode, total int) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].PaperCount != nodes[j].PaperCount {
+			return nodes[i].PaperCount > nodes[j].PaperCount
+		}
+		return nodes[i].DisplayName < nodes[j].DisplayName
+	})
+	for i := range nodes {
+		if total > 0 {
+			nodes[i].Percentage = math.Round(float64(nodes[i].PaperCount)*1000/float64(total)) / 10
+		}
+		finishTopicNodes(nodes[i].Children, total)
+	}
+}
+
+// TopicTrend is a topic with the number of works in the graph published in or after a
+// year, and the field it belongs to (empty if the hierarchy is incomplete).
+type TopicTrend struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	WorksCount  int    `json:"worksCount"`
+	FieldID     string `json:"fieldId"`
+	FieldName   string `json:"fieldName"`
+}
+
+// GetTrendingTopics returns the limit topics with the most works published in or after
+// sinceYear, busiest first. Retracted works and stubs don't count.
+func (r *neo4jRepository) GetTrendingTopics(ctx context.Context, sinceYear int, limit int) ([]TopicTrend, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// The (tenant, publicationYear) index narrows the works down before any topic is read.
+		res, err := r.run(ctx, tx, "GetTrendingTopics", `
+			MATCH (w:Work)
+			WHERE w.tenant = $tenant AND w.publicationYear >= $sinceYear
+				AND coalesce(w.isRetracted, false) = false AND w.stub IS NULL
+			MATCH (w)-[:IS_ABOUT_TOPIC]->(t:Topic)
+			WITH t, count(DISTINCT w) AS works
+			ORDER BY works DESC, t.displayName
+			LIMIT $limit
+			OPTIONAL MATCH (t)-[:IN_SUBFIELD]->(:Subfield)-[:IN_FIELD]->(f:Field)
+			RETURN t.id AS id, t.displayName AS displayName, works,
+				coalesce(f.id, '') AS fieldId, coalesce(f.displayName, '') AS fieldName
+			ORDER BY works DESC, displayName
+		`, map[string]any{"tenant": tenantOf(ctx), "sinceYear": sinceYear, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		trends := make([]TopicTrend, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			trends = append(trends, TopicTrend{
+				ID:          stringProp(props, "id"),
+				DisplayName: stringProp(props, "displayName"),
+				WorksCount:  intProp(props, "works"),
+				FieldID:     stringProp(props, "fieldId"),
+				FieldName:   stringProp(props, "fieldName"),
+			})
+		}
+		return trends, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trending topics since %d: %w", sinceYear, err)
+	}
+	return result.([]TopicTrend), nil
+}
+
+// PageRequest selects a 1-based page of PerPage results.
+type PageRequest struct {
+	Page    int
+	PerPage int
+}
+
+func (p PageRequest) skip() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// BridgingWork is a work about both topics of a bridge query, with its score for each.
+type BridgingWork struct {
+	domain.DehydratedWork
+	ScoreA        float64 `json:"scoreA"`
+	ScoreB        float64 `json:"scoreB"`
+	CombinedScore float64 `json:"combinedScore"`
+}
+
+// BridgingWorks is a page of BridgingWork and the number of matches across all pages.
+type BridgingWorks struct {
+	Total int            `json:"total"`
+	Works []BridgingWork `json:"works"`
+}
+
+// GetWorksBridgingTopics returns the works with IS_ABOUT_TOPIC scores of at least minScore
+// for both topics, highest combined score first. Retracted works are left out.
+func (r *neo4jRepository) GetWorksBridgingTopics(ctx context.Context, topicA, topicB string, minScore float32, page PageRequest) (*BridgingWorks, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetWorksBridgingTopics", `
+			MATCH (:Topic {id: $topicA})<-[ra:IS_ABOUT_TOPIC]-(w:Work {tenant: $tenant})-[rb:IS_ABOUT_TOPIC]->(:Topic {id: $topicB})
+			WHERE ra.score >= $minScore AND rb.score >= $minScore
+				AND coalesce(w.isRetracted, false) = false
+			WITH w, ra.score AS scoreA, rb.score AS scoreB
+			ORDER BY scoreA + scoreB DESC, w.publicationYear DESC, w.id
+			WITH collect({work: w, scoreA: scoreA, scoreB: scoreB}) AS matches
+			RETURN size(matches) AS total, [m IN matches[$skip..$skip + $limit] | {
+				id: m.work.id, doi: m.work.doi, title: m.work.title,
+				publicationYear: m.work.publicationYear, publicationDate: m.work.publicationDate,
+				isRetracted: m.work.isRetracted, scoreA: m.scoreA, scoreB: m.scoreB
+			}] AS works
+		`, map[string]any{
+			"tenant":   tenantOf(ctx),
+			"topicA":   topicA,
+			"topicB":   topicB,
+			"minScore": float64(minScore),
+			"skip":     page.skip(),
+			"limit":    page.PerPage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		props := record.AsMap()
+		page := &BridgingWorks{Total: intProp(props, "total"), Works: []BridgingWork{}}
+		rows, _ := props["works"].([]any)
+		for _, row := range rows {
+			m, _ := row.(map[string]any)
+			scoreA, _ := m["scoreA"].(float64)
+			scoreB, _ := m["scoreB"].(float64)
+			page.Works = append(page.Works, BridgingWork{
+				DehydratedWork: domain.DehydratedWork{
+					ID:              stringProp(m, "id"),
+					Doi:             stringProp(m, "doi"),
+					Title:           stringProp(m, "title"),
+					PublicationYear: intProp(m, "publicationYear"),
+					PublicationDate: dateProp(m, "publicationDate"),
+					IsRetracted:     boolProp(m, "isRetracted"),
+				},
+				ScoreA:        scoreA,
+				ScoreB:        scoreB,
+				CombinedScore: scoreA + scoreB,
+			})
+		}
+		return page, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read works bridging %s and %s: %w", topicA, topicB, err)
+	}
+	return result.(*BridgingWorks), nil
+}
+
+// BridgingAuthor is an author with papers on both topics of a bridge query.
+type BridgingAuthor struct {
+	ID             string `json:"id"`
+	DisplayName    string `json:"displayName"`
+	PapersA        int    `json:"papersA"`
+	PapersB        int    `json:"papersB"`
+	CombinedPapers int    `json:"combinedPapers"`
+}
+
+// BridgingAuthors is a page of BridgingAuthor and the number of matches across all pages.
+type BridgingAuthors struct {
+	Total   int              `json:"total"`
+	Authors []BridgingAuthor `json:"authors"`
+}
+
+// GetAuthorsBridgingTopics returns the authors whose HAS_TOPIC paper counts are at least
+// minPapers for both topics, most combined papers first.
+func (r *neo4jRepository) GetAuthorsBridgingTopics(ctx context.Context, topicA, topicB string, minPapers int, page PageRequest) (*BridgingAuthors, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetAuthorsBridgingTopics", `
+			MATCH (:Topic {id: $topicA})<-[ra:HAS_TOPIC]-(a:Author {tenant: $tenant})-[rb:HAS_TOPIC]->(:Topic {id: $topicB})
+			WHERE ra.paperCount >= $minPapers AND rb.paperCount >= $minPapers
+			WITH a, ra.paperCount AS papersA, rb.paperCount AS papersB
+			ORDER BY papersA + papersB DESC, a.displayName, a.id
+			WITH collect({author: a, papersA: papersA, papersB: papersB}) AS matches
+			RETURN size(matches) AS total, [m IN matches[$skip..$skip + $limit] | {
+				id: m.author.id, displayName: m.author.displayName,
+				papersA: m.papersA, papersB: m.papersB
+			}] AS authors
+		`, map[string]any{
+			"tenant":    tenantOf(ctx),
+			"topicA":    topicA,
+			"topicB":    topicB,
+			"minPapers": minPapers,
+			"skip":      page.skip(),
+			"limit":     page.PerPage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		props := record.AsMap()
+		page := &BridgingAuthors{Total: intProp(props, "total"), Authors: []BridgingAuthor{}}
+		rows, _ := props["authors"].([]any)
+		for _, row := range rows {
+			m, _ := row.(map[string]any)
+			papersA, papersB := intProp(m, "papersA"), intProp(m, "papersB")
+			page.Authors = append(page.Authors, BridgingAuthor{
+				ID:             stringProp(m, "id"),
+				DisplayName:    stringProp(m, "displayName"),
+				PapersA:        papersA,
+				PapersB:        papersB,
+				CombinedPapers: papersA + papersB,
+			})
+		}
+		return page, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authors bridging %s and %s: %w", topicA, topicB, err)
+	}
+	return result.(*BridgingAuthors), nil
+}
@@ -0,0 +1,504 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j/config"
+)
+
+func TestTopicCacheConcurrentUse(t *testing.T) {
+	cache := newTopicCache()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				id := fmt.Sprintf("T%d", i%5)
+				cache.add(id)
+				cache.has(id)
+				if g == 0 && i%100 == 0 {
+					cache.reset()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	cache.add("T1")
+	if !cache.has("T1") {
+		t.Error("added topic is not cached")
+	}
+	cache.reset()
+	if cache.has("T1") {
+		t.Error("topic is still cached after reset")
+	}
+}
+
+// A repository that loses its database forgets the topics it ensured, since the database it
+// reconnects to may not have them.
+func TestPingFailureResetsTopicCache(t *testing.T) {
+	driver, err := neo4j.NewDriverWithContext("neo4j://127.0.0.1:1", neo4j.NoAuth(), func(c *config.Config) {
+		c.SocketConnectTimeout = 100 * time.Millisecond
+		c.ConnectionAcquisitionTimeout = 200 * time.Millisecond
+	})
+	if err != nil {
+		t.Fatalf("creating driver: %v", err)
+	}
+	defer driver.Close(context.Background())
+	r := &neo4jRepository{driver: driver, topics: newTopicCache()}
+	r.topics.add("T1")
+
+	if err := r.Ping(context.Background()); err == nil {
+		t.Fatal("Ping of an unreachable database succeeded")
+	}
+	if r.topics.has("T1") {
+		t.Error("topic cache survived a failed Ping")
+	}
+}
+
+func TestTopicHierarchyQuery(t *testing.T) {
+	parent := func(id string) domain.TopicParent { return domain.TopicParent{ID: id, DisplayName: "name of " + id} }
+	tests := []struct {
+		name         string
+		topic        domain.Topic
+		wantMerges   []string
+		wantLinks    []string
+		wantComplete bool
+	}{
+		{
+			name:         "complete",
+			topic:        domain.Topic{ID: "T1", Subfield: parent("S1"), Field: parent("F1"), Domain: parent("D1")},
+			wantMerges:   []string{"(t:Topic", "(s:Subfield", "(f:Field", "(d:Domain"},
+			wantLinks:    []string{"(t)-[:IN_SUBFIELD]->(s)", "(s)-[:IN_FIELD]->(f)", "(f)-[:IN_DOMAIN]->(d)"},
+			wantComplete: true,
+		},
+		{
+			name:       "missing field",
+			topic:      domain.Topic{ID: "T1", Subfield: parent("S1"), Domain: parent("D1")},
+			wantMerges: []string{"(t:Topic", "(s:Subfield", "(d:Domain"},
+			wantLinks:  []string{"(t)-[:IN_SUBFIELD]->(s)"},
+		},
+		{
+			name:       "topic only",
+			topic:      domain.Topic{ID: "T1"},
+			wantMerges: []string{"(t:Topic"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params, complete := topicHierarchyQuery(tt.topic)
+			if complete != tt.wantComplete {
+				t.Errorf("complete = %v, want %v", complete, tt.wantComplete)
+			}
+			merges := strings.Count(query, "MERGE (") - strings.Count(query, ")-[:")
+			if merges != len(tt.wantMerges) {
+				t.Errorf("query merges %d nodes, want %d:\n%s", merges, len(tt.wantMerges), query)
+			}
+			for _, want := range append(tt.wantMerges, tt.wantLinks...) {
+				if !strings.Contains(query, want) {
+					t.Errorf("query lacks %s:\n%s", want, query)
+				}
+			}
+			if links := strings.Count(query, ")-[:"); links != len(tt.wantLinks) {
+				t.Errorf("query has %d links, want %d:\n%s", links, len(tt.wantLinks), query)
+			}
+			for key, value := range params {
+				if value == "" && strings.HasSuffix(key, "Id") {
+					t.Errorf("param %s is empty", key)
+				}
+			}
+			if params["tId"] != "T1" {
+				t.Errorf("tId = %v, want T1", params["tId"])
+			}
+		})
+	}
+}
+
+// retryCounter is a driver logger counting the transactions the driver retried because of
+// a deadlock.
+type retryCounter struct {
+	deadlocks atomic.Int32
+}
+
+func (l *retryCounter) Error(name, id string, err error)        {}
+func (l *retryCounter) Warnf(name, id, msg string, args ...any) {}
+func (l *retryCounter) Infof(name, id, msg string, args ...any) {}
+func (l *retryCounter) Debugf(name, id, msg string, args ...any) {
+	if line := fmt.Sprintf(msg, args...); strings.HasPrefix(line, "Retrying transaction") && strings.Contains(line, "Deadlock") {
+		l.deadlocks.Add(1)
+	}
+}
+
+// Many works sharing a few topics, saved concurrently, must neither fail nor deadlock on
+// the shared hierarchy nodes.
+func TestSaveWorkSharedTopicsConcurrently(t *testing.T) {
+	base, ctx := newTestRepo(t)
+	const works, topics, goroutines = 200, 5, 8
+
+	retries := &retryCounter{}
+	driver, err := neo4j.NewDriverWithContext(os.Getenv("NEO4J_TEST_URI"),
+		neo4j.BasicAuth(os.Getenv("NEO4J_TEST_USERNAME"), os.Getenv("NEO4J_TEST_PASSWORD"), ""),
+		func(c *config.Config) { c.Log = retries })
+	if err != nil {
+		t.Fatalf("creating driver: %v", err)
+	}
+	defer driver.Close(context.Background())
+	r := &neo4jRepository{driver: driver, topics: newTopicCache(), persistTopics: true}
+
+	// Topic nodes are global; ids unique to the test keep them apart from other data.
+	prefix := "T-" + tenantOf(ctx)
+	t.Cleanup(func() {
+		query(t, base, ctx, `
+			MATCH (n) WHERE (n:Topic OR n:Subfield OR n:Field OR n:Domain) AND n.id STARTS WITH $prefix
+			DETACH DELETE n
+		`, map[string]any{"prefix": prefix})
+	})
+	hierarchy := func(level string) domain.TopicParent {
+		return domain.TopicParent{ID: prefix + "-" + level, DisplayName: level}
+	}
+	shared := make([]domain.Topic, topics)
+	for i := range shared {
+		shared[i] = domain.Topic{ID: fmt.Sprintf("%s-%d", prefix, i), DisplayName: fmt.Sprint("topic ", i), Score: 0.5,
+			Subfield: hierarchy("subfield"), Field: hierarchy("field"), Domain: hierarchy("domain")}
+	}
+
+	queue := make(chan domain.Work)
+	errs := make(chan error, works)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for work := range queue {
+				if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+					errs <- fmt.Errorf("SaveWork(%s): %w", work.ID, err)
+				}
+			}
+		}()
+	}
+	for i := 0; i < works; i++ {
+		queue <- domain.Work{ID: fmt.Sprintf("W%d", i), Title: "stress", Topics: shared,
+			Authorships: []domain.Authorship{authorship("A1"), authorship(fmt.Sprintf("A%d", i+2))}}
+	}
+	close(queue)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if n := retries.deadlocks.Load(); n > 0 {
+		t.Errorf("%d transactions were retried after a deadlock", n)
+	}
+	for _, topic := range shared {
+		if !r.topics.has(topic.ID) {
+			t.Errorf("topic %s is not cached", topic.ID)
+		}
+	}
+	records := query(t, base, ctx, `
+		MATCH (t:Topic) WHERE t.id STARTS WITH $prefix
+		OPTIONAL MATCH (w:Work {tenant: $tenant})-[:IS_ABOUT_TOPIC]->(t)
+		WITH t, count(w) AS works
+		MATCH (t)-[:IN_SUBFIELD]->(:Subfield)-[:IN_FIELD]->(:Field)-[:IN_DOMAIN]->(d:Domain)
+		RETURN t.id AS id, works, d.id AS domain
+	`, map[string]any{"prefix": prefix})
+	if len(records) != topics {
+		t.Fatalf("%d topics with their full hierarchy, want %d", len(records), topics)
+	}
+	for _, record := range records {
+		if intProp(record, "works") != works || record["domain"] != prefix+"-domain" {
+			t.Errorf("topic %v, want %d works under the one domain", record, works)
+		}
+	}
+	domains := query(t, base, ctx, `MATCH (d:Domain {id: $id}) RETURN count(d) AS n`, map[string]any{"id": prefix + "-domain"})
+	if n := intProp(domains[0], "n"); n != 1 {
+		t.Errorf("%d domain nodes, want 1", n)
+	}
+}
+
+func TestBuildTopicProfile(t *testing.T) {
+	node := func(id string, papers int, percentage float64, children ...TopicNode) TopicNode {
+		return TopicNode{ID: id, DisplayName: "name of " + id, PaperCount: papers, Percentage: percentage, Children: children}
+	}
+	path := func(papers int, ids ...string) topicPath {
+		p := topicPath{paperCount: papers}
+		for i, id := range ids {
+			p.levels[i] = TopicNode{ID: id, DisplayName: "name of " + id}
+		}
+		return p
+	}
+	uncategorized := TopicNode{ID: UncategorizedTopic, DisplayName: "Uncategorized"}
+	uncategorizedPath := func(papers int, topic string) topicPath {
+		p := topicPath{paperCount: papers, levels: [4]TopicNode{uncategorized, uncategorized, uncategorized}}
+		p.levels[3] = TopicNode{ID: topic, DisplayName: "name of " + topic}
+		return p
+	}
+	bucket := func(papers int, percentage float64, children ...TopicNode) TopicNode {
+		n := uncategorized
+		n.PaperCount, n.Percentage, n.Children = papers, percentage, children
+		return n
+	}
+
+	tests := []struct {
+		name      string
+		paths     []topicPath
+		wantTotal int
+		want      []TopicNode
+	}{
+		{
+			name: "rolled up and sorted",
+			paths: []topicPath{
+				path(5, "D2", "F3", "S4", "T4"),
+				path(3, "D1", "F2", "S3", "T3"),
+				path(6, "D1", "F1", "S1", "T1"),
+				uncategorizedPath(3, "T5"),
+				path(3, "D1", "F1", "S2", "T2"),
+			},
+			wantTotal: 20,
+			want: []TopicNode{
+				node("D1", 12, 60,
+					node("F1", 9, 45,
+						node("S1", 6, 30, node("T1", 6, 30)),
+						node("S2", 3, 15, node("T2", 3, 15))),
+					node("F2", 3, 15, node("S3", 3, 15, node("T3", 3, 15)))),
+				node("D2", 5, 25, node("F3", 5, 25, node("S4", 5, 25, node("T4", 5, 25)))),
+				bucket(3, 15, bucket(3, 15, bucket(3, 15, node("T5", 3, 15)))),
+			},
+		},
+		{
+			name: "uncategorized topics share one bucket",
+			paths: []topicPath{
+				uncategorizedPath(1, "T1"),
+				uncategorizedPath(2, "T2"),
+			},
+			wantTotal: 3,
+			want: []TopicNode{
+				bucket(3, 100, bucket(3, 100, bucket(3, 100, node("T2", 2, 66.7), node("T1", 1, 33.3)))),
+			},
+		},
+		{
+			name: "percentages round to one decimal, ties by name",
+			paths: []topicPath{
+				path(1, "D3", "F3", "S3", "T3"),
+				path(1, "D1", "F1", "S1", "T1"),
+				path(1, "D2", "F2", "S2", "T2"),
+			},
+			wantTotal: 3,
+			want: []TopicNode{
+				node("D1", 1, 33.3, node("F1", 1, 33.3, node("S1", 1, 33.3, node("T1", 1, 33.3)))),
+				node("D2", 1, 33.3, node("F2", 1, 33.3, node("S2", 1, 33.3, node("T2", 1, 33.3)))),
+				node("D3", 1, 33.3, node("F3", 1, 33.3, node("S3", 1, 33.3, node("T3", 1, 33.3)))),
+			},
+		},
+		{
+			name:      "topics without papers",
+			paths:     []topicPath{path(0, "D1", "F1", "S1", "T1")},
+			wantTotal: 0,
+			want:      []TopicNode{node("D1", 0, 0, node("F1", 0, 0, node("S1", 0, 0, node("T1", 0, 0))))},
+		},
+		{
+			name: "no topics",
+			want: []TopicNode{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := buildTopicProfile("A1", tt.paths)
+			if profile.AuthorID != "A1" || profile.TotalPapers != tt.wantTotal {
+				t.Errorf("profile of %s with %d papers, want A1 with %d", profile.AuthorID, profile.TotalPapers, tt.wantTotal)
+			}
+			if !reflect.DeepEqual(profile.Domains, tt.want) {
+				t.Errorf("domains =\n%+v\nwant\n%+v", profile.Domains, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAuthorTopicProfile(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	// Topic nodes are global; ids unique to the test keep them apart from other data.
+	prefix := "T-" + tenantOf(ctx) + "-"
+	t.Cleanup(func() {
+		query(t, r, ctx, `
+			MATCH (n) WHERE (n:Topic OR n:Subfield OR n:Field OR n:Domain) AND n.id STARTS WITH $prefix
+			DETACH DELETE n
+		`, map[string]any{"prefix": prefix})
+	})
+	query(t, r, ctx, `
+		CREATE (d:Domain {id: $prefix + 'D1', displayName: 'Physical Sciences'}),
+			(f:Field {id: $prefix + 'F1', displayName: 'Computer Science'})-[:IN_DOMAIN]->(d),
+			(s:Subfield {id: $prefix + 'S1', displayName: 'Machine Learning'})-[:IN_FIELD]->(f),
+			(t1:Topic {id: $prefix + 'T1', displayName: 'Neural Networks'})-[:IN_SUBFIELD]->(s),
+			(t2:Topic {id: $prefix + 'T2', displayName: 'Kernel Methods'})-[:IN_SUBFIELD]->(s),
+			(old:Topic {id: $prefix + 'T3', displayName: 'Saved before hierarchies'}),
+			(a:Author {id: 'A1', tenant: $tenant}),
+			(a)-[:HAS_TOPIC {paperCount: 5, tenant: $tenant}]->(t1),
+			(a)-[:HAS_TOPIC {paperCount: 3, tenant: $tenant}]->(t2),
+			(a)-[:HAS_TOPIC {paperCount: 2, tenant: $tenant}]->(old),
+			(:Author {id: 'A2', tenant: $tenant})
+	`, map[string]any{"prefix": prefix})
+
+	profile, err := r.GetAuthorTopicProfile(ctx, "A1")
+	if err != nil {
+		t.Fatalf("GetAuthorTopicProfile: %v", err)
+	}
+	if profile.TotalPapers != 10 || len(profile.Domains) != 2 {
+		t.Fatalf("profile = %+v, want 10 papers in 2 domains", profile)
+	}
+	first, bucket := profile.Domains[0], profile.Domains[1]
+	if first.ID != prefix+"D1" || first.PaperCount != 8 || first.Percentage != 80 {
+		t.Errorf("first domain = %+v, want D1 with 8 papers, 80%%", first)
+	}
+	subfield := first.Children[0].Children[0]
+	if subfield.DisplayName != "Machine Learning" || len(subfield.Children) != 2 || subfield.Children[0].DisplayName != "Neural Networks" {
+		t.Errorf("subfield = %+v, want Machine Learning with its two topics, largest first", subfield)
+	}
+	if bucket.ID != UncategorizedTopic || bucket.PaperCount != 2 || bucket.Percentage != 20 {
+		t.Errorf("second domain = %+v, want the uncategorized bucket with 2 papers, 20%%", bucket)
+	}
+
+	empty, err := r.GetAuthorTopicProfile(ctx, "A2")
+	if err != nil {
+		t.Fatalf("GetAuthorTopicProfile(A2): %v", err)
+	}
+	if empty.TotalPapers != 0 || len(empty.Domains) != 0 {
+		t.Errorf("profile of an author without topics = %+v, want an empty one", empty)
+	}
+	if _, err := r.GetAuthorTopicProfile(ctx, "A404"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAuthorTopicProfile(A404) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetWorksBridgingTopics(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	prefix := "T-" + tenantOf(ctx)
+	cleanTopics(t, r, ctx, prefix)
+	a, b, c := prefix+"-a", prefix+"-b", prefix+"-c"
+
+	query(t, r, ctx, `
+		UNWIND [$a, $b, $c] AS id
+		MERGE (:Topic {id: id})
+	`, map[string]any{"a": a, "b": b, "c": c})
+	query(t, r, ctx, `
+		UNWIND $works AS row
+		CREATE (w:Work {id: row.id, tenant: $tenant, title: row.id, publicationYear: row.year, isRetracted: row.retracted})
+		WITH w, row
+		UNWIND keys(row.scores) AS topic
+		MATCH (t:Topic {id: topic})
+		CREATE (w)-[:IS_ABOUT_TOPIC {score: row.scores[topic], tenant: $tenant}]->(t)
+	`, map[string]any{"works": []any{
+		map[string]any{"id": "W1", "year": 2019, "retracted": false, "scores": map[string]any{a: 0.9, b: 0.8}},
+		map[string]any{"id": "W2", "year": 2020, "retracted": false, "scores": map[string]any{a: 0.5, b: 0.5}},
+		map[string]any{"id": "W3", "year": 2021, "retracted": false, "scores": map[string]any{a: 0.75, b: 0.25}},
+		map[string]any{"id": "W4", "year": 2021, "retracted": false, "scores": map[string]any{a: 0.95, c: 0.9}},
+		map[string]any{"id": "W5", "year": 2021, "retracted": true, "scores": map[string]any{a: 0.9, b: 0.9}},
+	}})
+
+	tests := []struct {
+		name      string
+		a, b      string
+		minScore  float32
+		page      PageRequest
+		wantTotal int
+		wantIDs   []string
+	}{
+		{"above the threshold", a, b, 0.3, PageRequest{1, 10}, 2, []string{"W1", "W2"}},
+		// W2 and W3 tie on the combined score; the newer comes first.
+		{"no threshold", a, b, 0, PageRequest{1, 10}, 3, []string{"W1", "W3", "W2"}},
+		{"second page", a, b, 0, PageRequest{2, 2}, 3, []string{"W2"}},
+		{"either order", b, a, 0.3, PageRequest{1, 10}, 2, []string{"W1", "W2"}},
+		{"threshold nothing reaches", a, b, 0.85, PageRequest{1, 10}, 0, []string{}},
+		{"other topic", a, c, 0, PageRequest{1, 10}, 1, []string{"W4"}},
+		{"no bridging works", b, c, 0, PageRequest{1, 10}, 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetWorksBridgingTopics(ctx, tt.a, tt.b, tt.minScore, tt.page)
+			if err != nil {
+				t.Fatalf("GetWorksBridgingTopics: %v", err)
+			}
+			ids := []string{}
+			for _, work := range got.Works {
+				ids = append(ids, work.ID)
+				if work.ScoreA < float64(tt.minScore) || work.ScoreB < float64(tt.minScore) || work.CombinedScore != work.ScoreA+work.ScoreB {
+					t.Errorf("%s scores %v + %v = %v, want both at least %v", work.ID, work.ScoreA, work.ScoreB, work.CombinedScore, tt.minScore)
+				}
+			}
+			if got.Total != tt.wantTotal || !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("got %v of %d, want %v of %d", ids, got.Total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+
+	// Scores are reported per topic in the order asked for.
+	got, _ := r.GetWorksBridgingTopics(ctx, b, a, 0.3, PageRequest{1, 1})
+	if len(got.Works) != 1 || got.Works[0].ScoreA != 0.8 || got.Works[0].ScoreB != 0.9 {
+		t.Errorf("W1 bridging b and a = %+v, want scores 0.8 and 0.9", got.Works)
+	}
+}
+
+func TestGetAuthorsBridgingTopics(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	prefix := "T-" + tenantOf(ctx)
+	cleanTopics(t, r, ctx, prefix)
+	a, b := prefix+"-a", prefix+"-b"
+
+	query(t, r, ctx, `
+		UNWIND [$a, $b] AS id
+		MERGE (:Topic {id: id})
+	`, map[string]any{"a": a, "b": b})
+	query(t, r, ctx, `
+		UNWIND $authors AS row
+		CREATE (au:Author {id: row.id, tenant: $tenant, displayName: row.name})
+		WITH au, row
+		UNWIND keys(row.papers) AS topic
+		MATCH (t:Topic {id: topic})
+		CREATE (au)-[:HAS_TOPIC {paperCount: row.papers[topic], tenant: $tenant}]->(t)
+	`, map[string]any{"authors": []any{
+		map[string]any{"id": "A1", "name": "Ada", "papers": map[string]any{a: 5, b: 3}},
+		map[string]any{"id": "A2", "name": "Cy", "papers": map[string]any{a: 1, b: 1}},
+		map[string]any{"id": "A3", "name": "Dee", "papers": map[string]any{a: 10, b: 0}},
+		map[string]any{"id": "A4", "name": "Bob", "papers": map[string]any{a: 4, b: 4}},
+		map[string]any{"id": "A5", "name": "Eve", "papers": map[string]any{a: 9}},
+	}})
+
+	tests := []struct {
+		minPapers int
+		page      PageRequest
+		wantTotal int
+		wantIDs   []string
+	}{
+		// A1 and A4 tie on combined papers; they are then ordered by name.
+		{1, PageRequest{1, 10}, 3, []string{"A1", "A4", "A2"}},
+		{2, PageRequest{1, 10}, 2, []string{"A1", "A4"}},
+		{4, PageRequest{1, 10}, 1, []string{"A4"}},
+		{1, PageRequest{2, 2}, 3, []string{"A2"}},
+		{20, PageRequest{1, 10}, 0, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := r.GetAuthorsBridgingTopics(ctx, a, b, tt.minPapers, tt.page)
+		if err != nil {
+			t.Fatalf("GetAuthorsBridgingTopics: %v", err)
+		}
+		ids := []string{}
+		for _, author := range got.Authors {
+			ids = append(ids, author.ID)
+		}
+		if got.Total != tt.wantTotal || !reflect.DeepEqual(ids, tt.wantIDs) {
+			t.Errorf("min %d, page %v: got %v of %d, want %v of %d", tt.minPapers, tt.page, ids, got.Total, tt.wantIDs, tt.wantTotal)
+		}
+	}
+}
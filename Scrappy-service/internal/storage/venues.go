@@ -0,0 +1,381 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// VenueSummary describes what the graph holds for a venue (journal, conference, repository).
+type VenueSummary struct {
+	ID              string        `json:"id"`
+	DisplayName     string        `json:"displayName"`
+	WorksCount      int           `json:"worksCount"`
+	TotalCitations  int           `json:"totalCitations"`
+	MedianCitations float64       `json:"medianCitations"`
+	WorksByYear     []YearCount   `json:"worksByYear"`
+	TopAuthors      []VenueAuthor `json:"topAuthors"`
+}
+
+// YearCount is one bucket of a publication-year histogram.
+type YearCount struct {
+	Year  int `json:"year"`
+	Works int `json:"works"`
+}
+
+// VenueAuthor is an author ranked by the number of their works published in a venue.
+type VenueAuthor struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	Works       int    `json:"works"`
+}
+
+// SaveVenue creates or updates a Venue node from a full OpenAlex source entity, including
+// its ISSNs.
+func (r *neo4jRepository) SaveVenue(ctx context.Context, source domain.Source) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		err := r.exec(ctx, tx, "SaveVenue", `
+			MERGE (v:Venue {id: $id, tenant: $tenant})
+			SET v.displayName = $displayName, v.type = $type, v.issnL = $issnL, v.issn = $issn,
+				`+stampSource("v")+`
+		`, map[string]any{
+			"tenant":      tenantOf(ctx),
+			"source":      SourceOpenAlex,
+			"id":          source.ID,
+			"displayName": source.DisplayName,
+			"type":        source.Type,
+			"issnL":       source.IssnL,
+			"issn":        source.Issn,
+		})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save venue %s: %w", source.ID, err)
+	}
+	return nil
+}
+
+// UnknownVenue is the GetWorksByVenueForAuthor bucket for works without a venue.
+const UnknownVenue = "Unknown"
+
+// GetWorksByVenueForAuthor groups an author's works by the display name of the venue they
+// were published in, most cited first within each venue. Works without a PUBLISHED_IN edge
+// are listed under UnknownVenue.
+func (r *neo4jRepository) GetWorksByVenueForAuthor(ctx context.Context, authorID string) (map[string][]domain.DehydratedWork, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetWorksByVenueForAuthor", `
+			MATCH (:Author {id: $authorId, tenant: $tenant})-[:AUTHORED]->(w:Work)
+			OPTIONAL MATCH (w)-[:PUBLISHED_IN]->(v:Venue)
+			RETURN coalesce(v.displayName, v.id, $unknown) AS venue,
+				w.id AS id, w.doi AS doi, w.title AS title,
+				w.publicationYear AS publicationYear, w.publicationDate AS publicationDate,
+				coalesce(w.isRetracted, false) AS isRetracted
+			ORDER BY w.citedByCount DESC, w.id
+		`, map[string]any{"tenant": tenantOf(ctx), "authorId": authorID, "unknown": UnknownVenue})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		byVenue := make(map[string][]domain.DehydratedWork)
+		for i, work := range dehydratedWorksFromRecords(records) {
+			venue := stringProp(records[i].AsMap(), "venue")
+			byVenue[venue] = append(byVenue[venue], work)
+		}
+		return byVenue, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read works by venue of author %s: %w", authorID, err)
+	}
+	return result.(map[string][]domain.DehydratedWork), nil
+}
+
+// GetVenueSummary computes works count, citation totals and median, a publication-year
+// histogram and the topAuthors most prolific authors for a venue from its PUBLISHED_IN and
+// AUTHORED edges. It returns ErrNotFound if the venue is not in the graph.
+func (r *neo4jRepository) GetVenueSummary(ctx context.Context, venueID string, topAuthors int) (*VenueSummary, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	params := map[string]any{"tenant": tenantOf(ctx), "id": venueID, "limit": topAuthors}
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetVenueSummary/works", `
+			MATCH (v:Venue {id: $id, tenant: $tenant})
+			OPTIONAL MATCH (w:Work)-[:PUBLISHED_IN]->(v)
+			RETURN v.displayName AS displayName,
+				[x IN collect(w) | coalesce(x.citedByCount, 0)] AS citations,
+				[x IN collect(w) WHERE x.publicationYear IS NOT NULL | x.publicationYear] AS years
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		props := records[0].AsMap()
+		citations := intsProp(props, "citations")
+		summary := &VenueSummary{
+			ID:              venueID,
+			DisplayName:     stringProp(props, "displayName"),
+			WorksCount:      len(citations),
+			MedianCitations: median(citations),
+			WorksByYear:     yearHistogram(intsProp(props, "years")),
+			TopAuthors:      []VenueAuthor{},
+		}
+		for _, c := range citations {
+			summary.TotalCitations += c
+		}
+
+		res, err = r.run(ctx, tx, "GetVenueSummary/authors", `
+			MATCH (:Venue {id: $id, tenant: $tenant})<-[:PUBLISHED_IN]-(w:Work)<-[:AUTHORED]-(a:Author)
+			RETURN a.id AS id, a.displayName AS displayName, count(DISTINCT w) AS works
+			ORDER BY works DESC, id
+			LIMIT $limit
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err = res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			props := record.AsMap()
+			summary.TopAuthors = append(summary.TopAuthors, VenueAuthor{
+				ID:          stringProp(props, "id"),
+				DisplayName: stringProp(props, "displayName"),
+				Works:       intProp(props, "works"),
+			})
+		}
+		return summary, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize venue %s: %w", venueID, err)
+	}
+	return result.(*VenueSummary), nil
+}
+
+// median returns the median of values, or 0 for an empty slice. values is sorted in place.
+func median(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Ints(values)
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return float64(values[mid])
+	}
+	return float64(values[mid-1]+values[mid]) / 2
+}
+
+// yearHistogram counts occurrences of each year, ordered by year.
+func yearHistogram(years []int) []YearCount {
+	counts := make(map[int]int)
+	for _, y := range years {
+		counts[y]++
+	}
+	histogram := make([]YearCount, 0, len(counts))
+	for y, n := range counts {
+		histogram = append(histogram, YearCount{Year: y, Works: n})
+	}
+	sort.Slice(histogram, func(i, j int) bool { return histogram[i].Year < histogram[j].Year })
+	return histogram
+}
+
+// intsProp reads a list-of-integers property, skipping non-integer entries.
+func intsProp(props map[string]any, key string) []int {
+	raw, _ := props[key].([]any)
+	out := make([]int, 0, len(raw))
+	for _, v := range raw {
+		if n, ok := v.(int64); ok {
+			out = append(out, int(n))
+		}
+	}
+	return out
+}
+
+// resolveVenueNodeID returns the id of the Venue node a work's source should be linked to:
+// its own id, unless no node exists under that id yet but another venue already carries
+// the same linking ISSN, i.e. the journal was seen before under another OpenAlex source.
+func (r *neo4jRepository) resolveVenueNodeID(ctx context.Context, tx neo4j.ManagedTransaction, venueID, issnL string) (string, error) {
+	if issnL == "" {
+		return venueID, nil
+	}
+	res, err := r.run(ctx, tx, "SaveWork/resolveVenue", `
+		OPTIONAL MATCH (self:Venue {id: $id, tenant: $tenant})
+		OPTIONAL MATCH (alias:Venue {issnL: $issnL, tenant: $tenant})
+		WHERE alias.id <> $id
+		RETURN self IS NOT NULL AS selfExists, collect(alias.id)[0] AS aliasId
+	`, map[string]any{"tenant": tenantOf(ctx), "id": venueID, "issnL": issnL})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up venue by ISSN-L: %w", err)
+	}
+	record, err := res.Single(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up venue by ISSN-L: %w", err)
+	}
+	props := record.AsMap()
+	if selfExists, _ := props["selfExists"].(bool); selfExists {
+		return venueID, nil
+	}
+	if aliasID := stringProp(props, "aliasId"); aliasID != "" {
+		return aliasID, nil
+	}
+	return venueID, nil
+}
+
+// VenueAliases is a set of Venue nodes sharing a linking ISSN: one journal under several
+// OpenAlex source IDs, e.g. after a publisher migration. Venues are listed with the most
+// works first, the natural one to keep.
+type VenueAliases struct {
+	IssnL  string         `json:"issnL"`
+	Venues []AliasedVenue `json:"venues"`
+}
+
+// AliasedVenue is one of the venues of a VenueAliases group.
+type AliasedVenue struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	WorksCount  int    `json:"worksCount"`
+}
+
+// FindVenueAliases lists groups of Venue nodes that share an issnL and are candidates for
+// MergeVenues.
+func (r *neo4jRepository) FindVenueAliases(ctx context.Context) ([]VenueAliases, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "FindVenueAliases", `
+			MATCH (v:Venue)
+			WHERE v.tenant = $tenant AND v.issnL IS NOT NULL AND v.issnL <> ''
+			WITH v.issnL AS issnL, collect(v) AS venues
+			WHERE size(venues) > 1
+			UNWIND venues AS v
+			OPTIONAL MATCH (w:Work)-[:PUBLISHED_IN]->(v)
+			WITH issnL, v, count(w) AS works
+			ORDER BY issnL, works DESC, v.id
+			RETURN issnL, collect({id: v.id, displayName: coalesce(v.displayName, ''), works: works}) AS venues
+			ORDER BY issnL
+		`, map[string]any{"tenant": tenantOf(ctx)})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		groups := make([]VenueAliases, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			group := VenueAliases{IssnL: stringProp(props, "issnL")}
+			venues, _ := props["venues"].([]any)
+			for _, raw := range venues {
+				venue, _ := raw.(map[string]any)
+				group.Venues = append(group.Venues, AliasedVenue{
+					ID:          stringProp(venue, "id"),
+					DisplayName: stringProp(venue, "displayName"),
+					WorksCount:  intProp(venue, "works"),
+				})
+			}
+			groups = append(groups, group)
+		}
+		return groups, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find venue aliases: %w", err)
+	}
+	return result.([]VenueAliases), nil
+}
+
+// venueRelationships are the relationships re-pointed from a merged venue onto the kept one.
+var venueRelationships = []nodeRelationship{
+	{"PUBLISHED_IN", true},
+	{"TARGETED", true},
+}
+
+// MergeVenues folds the venues in mergeIDs into keepID: their PUBLISHED_IN (and TARGETED)
+// relationships are re-pointed to the kept node, their ids are recorded in its
+// alternateIds, properties it lacks are copied over, and the merged nodes are deleted.
+// Venues with different non-empty ISSN-Ls are never merged (ErrConflictingISSNs).
+func (r *neo4jRepository) MergeVenues(ctx context.Context, keepID string, mergeIDs []string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "MergeVenues/lookup", `
+			MATCH (v:Venue) WHERE v.tenant = $tenant AND v.id IN $ids
+			RETURN v.id AS id, coalesce(v.issnL, '') AS issnL
+		`, map[string]any{"tenant": tenantOf(ctx), "ids": append([]string{keepID}, mergeIDs...)})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		issnLs := make(map[string]string, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			issnLs[stringProp(props, "id")] = stringProp(props, "issnL")
+		}
+		keepISSN, ok := issnLs[keepID]
+		if !ok {
+			return nil, fmt.Errorf("venue %s: %w", keepID, ErrNotFound)
+		}
+		for _, id := range mergeIDs {
+			issnL, ok := issnLs[id]
+			if !ok {
+				return nil, fmt.Errorf("venue %s: %w", id, ErrNotFound)
+			}
+			if issnL != "" && keepISSN != "" && issnL != keepISSN {
+				return nil, fmt.Errorf("cannot merge %s (%s) into %s (%s): %w", id, issnL, keepID, keepISSN, ErrConflictingISSNs)
+			}
+			if keepISSN == "" {
+				keepISSN = issnL
+			}
+		}
+
+		for _, oldID := range mergeIDs {
+			if oldID == keepID {
+				continue
+			}
+			if err := r.repointRelationships(ctx, tx, "Venue", venueRelationships, keepID, oldID); err != nil {
+				return nil, err
+			}
+			if err := r.exec(ctx, tx, "MergeVenues/fold", `
+				MATCH (keep:Venue {id: $keepId, tenant: $tenant}), (old:Venue {id: $oldId, tenant: $tenant})
+				SET keep.alternateIds = [x IN coalesce(keep.alternateIds, []) + [old.id] + coalesce(old.alternateIds, []) WHERE x <> keep.id | x],
+					keep.displayName = coalesce(keep.displayName, old.displayName),
+					keep.type = coalesce(keep.type, old.type),
+					keep.issnL = coalesce(keep.issnL, old.issnL),
+					keep.issn = coalesce(keep.issn, old.issn)
+				DETACH DELETE old
+			`, map[string]any{"tenant": tenantOf(ctx), "keepId": keepID, "oldId": oldID}); err != nil {
+				return nil, fmt.Errorf("failed to merge venue %s into %s: %w", oldID, keepID, err)
+			}
+		}
+		// Drop duplicate entries the concatenation above may have produced.
+		err = r.exec(ctx, tx, "MergeVenues/alternateIds", `
+			MATCH (keep:Venue {id: $keepId, tenant: $tenant})
+			SET keep.alternateIds = reduce(acc = [], x IN coalesce(keep.alternateIds, []) | CASE WHEN x IN acc THEN acc ELSE acc + x END)
+		`, map[string]any{"tenant": tenantOf(ctx), "keepId": keepID})
+		return nil, err
+	})
+	return err
+}
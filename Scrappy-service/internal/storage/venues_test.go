@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []int
+		want   float64
+	}{
+		{nil, 0},
+		{[]int{7}, 7},
+		{[]int{3, 1, 2}, 2},
+		{[]int{10, 0, 4, 1}, 2.5},
+		{[]int{5, 5, 5, 100}, 5},
+	}
+	for _, tt := range tests {
+		if got := median(append([]int(nil), tt.values...)); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestYearHistogram(t *testing.T) {
+	tests := []struct {
+		years []int
+		want  []YearCount
+	}{
+		{nil, []YearCount{}},
+		{[]int{2020}, []YearCount{{2020, 1}}},
+		{[]int{2021, 2019, 2021, 2020, 2021}, []YearCount{{2019, 1}, {2020, 1}, {2021, 3}}},
+	}
+	for _, tt := range tests {
+		if got := yearHistogram(tt.years); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("yearHistogram(%v) = %v, want %v", tt.years, got, tt.want)
+		}
+	}
+}
+
+func TestGetVenueSummary(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	venue := func(id string) *domain.Location {
+		return &domain.Location{Source: &domain.Source{ID: id, DisplayName: "Journal " + id}}
+	}
+	works := []domain.Work{
+		{ID: "W1", PublicationYear: 2019, CitedByCount: 10, PrimaryLocation: venue("S1"), Authorships: []domain.Authorship{authorship("A1"), authorship("A2")}},
+		{ID: "W2", PublicationYear: 2021, CitedByCount: 0, PrimaryLocation: venue("S1"), Authorships: []domain.Authorship{authorship("A1")}},
+		{ID: "W3", PublicationYear: 2021, CitedByCount: 4, PrimaryLocation: venue("S1"), Authorships: []domain.Authorship{authorship("A1"), authorship("A3")}},
+		{ID: "W4", CitedByCount: 1, PrimaryLocation: venue("S1"), Authorships: []domain.Authorship{authorship("A2")}},
+		{ID: "W5", PublicationYear: 2022, CitedByCount: 3, PrimaryLocation: venue("S2"), Authorships: []domain.Authorship{authorship("A3")}},
+	}
+	for _, work := range works {
+		if _, err := r.SaveWork(ctx, work, SaveOptions{IncludeVenue: true}); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	tests := []struct {
+		venueID string
+		top     int
+		want    *VenueSummary
+		wantErr error
+	}{
+		{"S1", 2, &VenueSummary{
+			ID: "S1", DisplayName: "Journal S1", WorksCount: 4, TotalCitations: 15, MedianCitations: 2.5,
+			WorksByYear: []YearCount{{2019, 1}, {2021, 2}},
+			TopAuthors:  []VenueAuthor{{"A1", "A1", 3}, {"A2", "A2", 2}},
+		}, nil},
+		// A venue with one work still gets its one-bar histogram.
+		{"S2", 10, &VenueSummary{
+			ID: "S2", DisplayName: "Journal S2", WorksCount: 1, TotalCitations: 3, MedianCitations: 3,
+			WorksByYear: []YearCount{{2022, 1}},
+			TopAuthors:  []VenueAuthor{{"A3", "A3", 1}},
+		}, nil},
+		{"S9", 10, nil, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.venueID, func(t *testing.T) {
+			got, err := r.GetVenueSummary(ctx, tt.venueID, tt.top)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetVenueSummary error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetVenueSummary = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// journal returns the primary location of a work published in the source id with the
+// given linking ISSN.
+func journal(id, issnL string) *domain.Location {
+	return &domain.Location{Source: &domain.Source{ID: id, DisplayName: "Journal " + id, IssnL: issnL}}
+}
+
+// venueLinks returns the venue each saved work is published in.
+func venueLinks(t *testing.T, r *neo4jRepository, ctx context.Context) map[string]string {
+	t.Helper()
+	records := query(t, r, ctx, `
+		MATCH (w:Work {tenant: $tenant})-[:PUBLISHED_IN]->(v:Venue)
+		RETURN w.id AS work, v.id AS venue
+	`, nil)
+	links := make(map[string]string, len(records))
+	for _, record := range records {
+		links[record["work"].(string)] = record["venue"].(string)
+	}
+	return links
+}
+
+func TestSaveWorkMatchesVenuesByISSNL(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	works := []domain.Work{
+		{ID: "W1", PrimaryLocation: journal("S1", "1234-5678")},
+		{ID: "W2", PrimaryLocation: journal("S2", "1234-5678")}, // The same journal under a new ID.
+		{ID: "W3", PrimaryLocation: journal("S3", "9999-0000")},
+		{ID: "W4", PrimaryLocation: journal("S4", "")}, // No ISSN-L: never matched.
+		{ID: "W5", PrimaryLocation: journal("S2", "1234-5678")},
+	}
+	for _, work := range works {
+		if _, err := r.SaveWork(ctx, work, SaveOptions{IncludeVenue: true}); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+
+	want := map[string]string{"W1": "S1", "W2": "S1", "W3": "S3", "W4": "S4", "W5": "S1"}
+	if links := venueLinks(t, r, ctx); !reflect.DeepEqual(links, want) {
+		t.Errorf("works are published in %v, want %v", links, want)
+	}
+	records := query(t, r, ctx, `
+		MATCH (v:Venue {tenant: $tenant})
+		RETURN v.id AS id, v.issnL AS issnL, v.alternateIds AS alternateIds
+		ORDER BY id
+	`, nil)
+	if len(records) != 3 {
+		t.Fatalf("got venues %v, want S1, S3 and S4", records)
+	}
+	if records[0]["issnL"] != "1234-5678" || !reflect.DeepEqual(stringsProp(records[0], "alternateIds"), []string{"S2"}) {
+		t.Errorf("S1 = %v, want its ISSN-L and S2 recorded once as an alternate ID", records[0])
+	}
+	if records[2]["issnL"] != nil {
+		t.Errorf("S4 issnL = %v, want none", records[2]["issnL"])
+	}
+
+	// A source already in the graph under its own ID keeps its node.
+	query(t, r, ctx, `CREATE (:Venue {id: 'S6', tenant: $tenant, issnL: '9999-0000'})`, nil)
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W6", PrimaryLocation: journal("S6", "9999-0000")}, SaveOptions{IncludeVenue: true}); err != nil {
+		t.Fatalf("SaveWork(W6): %v", err)
+	}
+	if venue := venueLinks(t, r, ctx)["W6"]; venue != "S6" {
+		t.Errorf("W6 is published in %s, want its own existing venue S6", venue)
+	}
+}
+
+func TestFindVenueAliasesAndMergeVenues(t *testing.T) {
+	r, ctx := newTestRepo(t)
+
+	// Venues created before ISSN-L matching, so the journal has three nodes.
+	query(t, r, ctx, `
+		CREATE (:Venue {id: 'S1', tenant: $tenant, issnL: '1234-5678', displayName: 'Old name'}),
+			(:Venue {id: 'S2', tenant: $tenant, issnL: '1234-5678', displayName: 'New name', alternateIds: ['S0']}),
+			(:Venue {id: 'S3', tenant: $tenant, issnL: '1234-5678'}),
+			(:Venue {id: 'S4', tenant: $tenant, issnL: '9999-0000'}),
+			(:Venue {id: 'S5', tenant: $tenant})
+	`, nil)
+	query(t, r, ctx, `
+		UNWIND [['W1', 'S1'], ['W2', 'S2'], ['W3', 'S2'], ['W4', 'S3'], ['W5', 'S4']] AS row
+		MATCH (v:Venue {id: row[1], tenant: $tenant})
+		CREATE (:Work {id: row[0], tenant: $tenant})-[:PUBLISHED_IN {tenant: $tenant}]->(v)
+	`, nil)
+
+	groups, err := r.FindVenueAliases(ctx)
+	if err != nil {
+		t.Fatalf("FindVenueAliases: %v", err)
+	}
+	want := []VenueAliases{{IssnL: "1234-5678", Venues: []AliasedVenue{
+		{ID: "S2", DisplayName: "New name", WorksCount: 2},
+		{ID: "S1", DisplayName: "Old name", WorksCount: 1},
+		{ID: "S3", DisplayName: "", WorksCount: 1},
+	}}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("FindVenueAliases = %+v, want %+v", groups, want)
+	}
+
+	if err := r.MergeVenues(ctx, "S2", []string{"S1", "S3"}); err != nil {
+		t.Fatalf("MergeVenues: %v", err)
+	}
+	links := venueLinks(t, r, ctx)
+	wantLinks := map[string]string{"W1": "S2", "W2": "S2", "W3": "S2", "W4": "S2", "W5": "S4"}
+	if !reflect.DeepEqual(links, wantLinks) {
+		t.Errorf("works are published in %v, want %v", links, wantLinks)
+	}
+	records := query(t, r, ctx, `MATCH (v:Venue {tenant: $tenant}) RETURN v.id AS id, v.alternateIds AS alternateIds ORDER BY id`, nil)
+	if len(records) != 3 || records[0]["id"] != "S2" {
+		t.Fatalf("venues after the merge = %v, want S2, S4 and S5", records)
+	}
+	if ids := stringsProp(records[0], "alternateIds"); !reflect.DeepEqual(ids, []string{"S0", "S1", "S3"}) {
+		t.Errorf("S2 alternateIds = %v, want [S0 S1 S3]", ids)
+	}
+	if groups, _ := r.FindVenueAliases(ctx); len(groups) != 0 {
+		t.Errorf("FindVenueAliases after the merge = %+v, want none", groups)
+	}
+
+	tests := []struct {
+		name     string
+		keepID   string
+		mergeIDs []string
+		wantErr  error
+	}{
+		{"different ISSN-Ls", "S2", []string{"S4"}, ErrConflictingISSNs},
+		{"unknown venue to merge", "S2", []string{"S9"}, ErrNotFound},
+		{"unknown venue to keep", "S9", []string{"S5"}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.MergeVenues(ctx, tt.keepID, tt.mergeIDs); !errors.Is(err, tt.wantErr) {
+				t.Errorf("MergeVenues(%s, %v) error = %v, want %v", tt.keepID, tt.mergeIDs, err, tt.wantErr)
+			}
+		})
+	}
+	if links := venueLinks(t, r, ctx); !reflect.DeepEqual(links, wantLinks) {
+		t.Errorf("failed merges moved works: %v", links)
+	}
+}
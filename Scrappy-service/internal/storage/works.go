@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// GetWorksMissingAbstract returns up to limit works that have a DOI but no abstract stored,
+// ordered by id. Pass the id of the last work of the previous page as after to continue
+// from there ("" starts from the beginning), which keeps paging cheap on large graphs.
+func (r *neo4jRepository) GetWorksMissingAbstract(ctx context.Context, after string, limit int) ([]domain.DehydratedWork, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetWorksMissingAbstract", `
+			MATCH (w:Work)
+			WHERE w.tenant = $tenant AND w.id > $after
+				AND w.doi IS NOT NULL AND w.doi <> ''
+				AND (w.abstract IS NULL OR w.abstract = '')
+			RETURN w.id AS id, w.doi AS doi, w.title AS title,
+				w.publicationYear AS publicationYear, w.publicationDate AS publicationDate,
+				coalesce(w.isRetracted, false) AS isRetracted
+			ORDER BY w.id
+			LIMIT $limit
+		`, map[string]any{"tenant": tenantOf(ctx), "after": after, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return dehydratedWorksFromRecords(records), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read works missing abstracts: %w", err)
+	}
+	return result.([]domain.DehydratedWork), nil
+}
+
+// GetAuthorWorks returns up to limit of an author's ingested works, most cited first, or
+// most recently published first with newestFirst. With onlyFulltext set, only works whose
+// full text OpenAlex has indexed are returned; retracted works are left out unless
+// includeRetracted is set.
+func (r *neo4jRepository) GetAuthorWorks(ctx context.Context, authorID string, onlyFulltext, includeRetracted, newestFirst bool, limit int) ([]domain.DehydratedWork, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetAuthorWorks", `
+			MATCH (:Author {id: $authorId, tenant: $tenant})-[:AUTHORED]->(w:Work)
+			WHERE (NOT $onlyFulltext OR w.hasFulltext = true)
+				AND ($includeRetracted OR coalesce(w.isRetracted, false) = false)
+			RETURN w.id AS id, w.doi AS doi, w.title AS title,
+				w.publicationYear AS publicationYear, w.publicationDate AS publicationDate,
+				coalesce(w.isRetracted, false) AS isRetracted
+			// toString orders dates and legacy string dates alike; works without a date go last.
+			ORDER BY CASE WHEN $newestFirst THEN coalesce(toString(w.publicationDate), '') END DESC,
+				w.citedByCount DESC, w.id
+			LIMIT $limit
+		`, map[string]any{"tenant": tenantOf(ctx), "authorId": authorID, "onlyFulltext": onlyFulltext, "includeRetracted": includeRetracted,
+			"newestFirst": newestFirst, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return dehydratedWorksFromRecords(records), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read works of author %s: %w", authorID, err)
+	}
+	return result.([]domain.DehydratedWork), nil
+}
+
+// NewWork is a work together with when it was first saved to the graph.
+type NewWork struct {
+	domain.DehydratedWork
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// GetWorksAddedSince returns the author's works that were first saved after since, newest
+// first. Works saved before firstSeen was recorded never match.
+func (r *neo4jRepository) GetWorksAddedSince(ctx context.Context, authorID string, since time.Time) ([]NewWork, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetWorksAddedSince", `
+			MATCH (:Author {id: $authorId, tenant: $tenant})-[:AUTHORED]->(w:Work)
+			WHERE w.firstSeen > $since
+			RETURN w.id AS id, w.doi AS doi, w.title AS title,
+				w.publicationYear AS publicationYear, w.publicationDate AS publicationDate,
+				coalesce(w.isRetracted, false) AS isRetracted, w.firstSeen AS firstSeen
+			ORDER BY w.firstSeen DESC, w.id
+		`, map[string]any{"tenant": tenantOf(ctx), "authorId": authorID, "since": since.UTC()})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		works := make([]NewWork, 0, len(records))
+		for i, work := range dehydratedWorksFromRecords(records) {
+			firstSeen, _ := records[i].AsMap()["firstSeen"].(time.Time)
+			works = append(works, NewWork{DehydratedWork: work, FirstSeen: firstSeen.UTC()})
+		}
+		return works, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read new works of author %s: %w", authorID, err)
+	}
+	return result.([]NewWork), nil
+}
+
+// dehydratedWorksFromRecords maps records with id, doi, title, publicationYear,
+// publicationDate and isRetracted columns onto DehydratedWorks.
+func dehydratedWorksFromRecords(records []*neo4j.Record) []domain.DehydratedWork {
+	works := make([]domain.DehydratedWork, 0, len(records))
+	for _, record := range records {
+		works = append(works, dehydratedWorkFromProps(record.AsMap()))
+	}
+	return works
+}
+
+// dehydratedWorkFromProps is dehydratedWorksFromRecords for one record, or one map of
+// those columns collected in a query.
+func dehydratedWorkFromProps(props map[string]any) domain.DehydratedWork {
+	return domain.DehydratedWork{
+		ID:              stringProp(props, "id"),
+		Doi:             stringProp(props, "doi"),
+		Title:           stringProp(props, "title"),
+		PublicationYear: intProp(props, "publicationYear"),
+		PublicationDate: dateProp(props, "publicationDate"),
+		IsRetracted:     boolProp(props, "isRetracted"),
+	}
+}
+
+// boolProp reads a boolean property, returning false when it is absent.
+func boolProp(props map[string]any, key string) bool {
+	b, _ := props[key].(bool)
+	return b
+}
+
+// nullIfEmpty turns an empty string into a null parameter, so coalesce keeps the stored
+// value of a property the response left out.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// dateProp reads a date property as YYYY-MM-DD. Dates written before they were stored as
+// Neo4j dates are still plain strings and are returned unchanged.
+func dateProp(props map[string]any, key string) string {
+	switch v := props[key].(type) {
+	case neo4j.Date:
+		return v.Time().Format("2006-01-02")
+	case time.Time:
+		return v.Format("2006-01-02")
+	case string:
+		return v
+	}
+	return ""
+}
+
+// WorkExists reports whether the work with the given id has been saved in full. Stub
+// nodes, created for works that are only cited, don't count.
+func (r *neo4jRepository) WorkExists(ctx context.Context, id string) (bool, error) {
+	return r.nodeExists(ctx, `MATCH (w:Work {id: $id, tenant: $tenant}) WHERE coalesce(w.stub, false) = false RETURN count(*) > 0 AS found`, id)
+}
+
+// LinkRelatedWorksByDOI creates (:Work)-[:RELATED_TO {source}]->(:Work) edges from the work
+// with the given DOI to every work in relatedDOIs that is already in the graph. Unknown
+// DOIs are skipped rather than creating stub nodes. It returns the number of edges linked;
+// ErrNotFound means the source work itself isn't in the graph.
+func (r *neo4jRepository) LinkRelatedWorksByDOI(ctx context.Context, doi string, relatedDOIs []string, source string) (int, error) {
+	normalized := make([]string, 0, len(relatedDOIs))
+	for _, related := range relatedDOIs {
+		if n := domain.NormalizeDOI(related); n != "" {
+			normalized = append(normalized, n)
+		}
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "LinkRelatedWorksByDOI", `
+			MATCH (w:Work {doiNormalized: $doi, tenant: $tenant})
+			WITH w LIMIT 1
+			OPTIONAL MATCH (other:Work)
+			WHERE other.tenant = $tenant AND other.doiNormalized IN $related AND other <> w
+			FOREACH (_ IN CASE WHEN other IS NULL THEN [] ELSE [1] END |
+				MERGE (w)-[rel:RELATED_TO {source: $source}]->(other)
+				SET rel.tenant = $tenant, `+stampSource("rel")+`
+			)
+			// Grouping by w leaves no row at all when the source work doesn't exist.
+			RETURN w.id AS id, count(other) AS linked
+		`, map[string]any{"tenant": tenantOf(ctx), "doi": domain.NormalizeDOI(doi), "related": normalized, "source": source})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNotFound
+		}
+		return intProp(records[0].AsMap(), "linked"), nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to link related works of %s: %w", doi, err)
+	}
+	return result.(int), nil
+}
+
+// GetTopWorks returns the limit most cited works in the graph. With sinceYear > 0 only
+// works published in or after that year are ranked, which the work_publication_year
+// index narrows down before sorting. Retracted works are left out unless includeRetracted
+// is set.
+func (r *neo4jRepository) GetTopWorks(ctx context.Context, limit int, sinceYear int, includeRetracted bool) ([]domain.Work, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	// Separate queries, so the planner can pick the index for each case: the year range,
+	// or the citation count order.
+	query := `
+		MATCH (w:Work)
+		WHERE w.citedByCount IS NOT NULL AND w.tenant = $tenant`
+	if sinceYear > 0 {
+		query = `
+		MATCH (w:Work)
+		WHERE w.publicationYear >= $sinceYear AND w.tenant = $tenant`
+	}
+	query += `
+			AND ($includeRetracted OR coalesce(w.isRetracted, false) = false)
+		RETURN w.id AS id, w.doi AS doi, w.title AS title, w.publicationYear AS publicationYear,
+			w.publicationDate AS publicationDate, w.citedByCount AS citedByCount,
+			w.isRetracted AS isRetracted, w.hasFulltext AS hasFulltext, w.isOa AS isOa, w.pdfUrl AS pdfUrl
+		ORDER BY w.citedByCount DESC, w.id
+		LIMIT $limit`
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := r.run(ctx, tx, "GetTopWorks", query, map[string]any{"tenant": tenantOf(ctx), "sinceYear": sinceYear, "includeRetracted": includeRetracted, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		works := make([]domain.Work, 0, len(records))
+		for _, record := range records {
+			props := record.AsMap()
+			work := domain.Work{
+				ID:              stringProp(props, "id"),
+				Doi:             stringProp(props, "doi"),
+				Title:           stringProp(props, "title"),
+				PublicationYear: intProp(props, "publicationYear"),
+				PublicationDate: dateProp(props, "publicationDate"),
+				CitedByCount:    intProp(props, "citedByCount"),
+			}
+			work.IsRetracted = boolProp(props, "isRetracted")
+			work.HasFulltext = boolProp(props, "hasFulltext")
+			if boolProp(props, "isOa") {
+				work.BestOaLocation = &domain.Location{IsOa: true, PdfUrl: stringProp(props, "pdfUrl")}
+			}
+			works = append(works, work)
+		}
+		return works, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read top works: %w", err)
+	}
+	return result.([]domain.Work), nil
+}
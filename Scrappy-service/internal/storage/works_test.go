@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Cloudforge2/scrappy/internal/domain"
+)
+
+func TestGetAuthorWorksOnlyFulltext(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	works := []domain.Work{
+		{ID: "W1", Title: "with full text", HasFulltext: true, PublicationYear: 2020, Authorships: []domain.Authorship{authorship("A1")}},
+		{ID: "W2", Title: "without", PublicationYear: 2021, Authorships: []domain.Authorship{authorship("A1")}},
+		{ID: "W3", Title: "retracted", HasFulltext: true, IsRetracted: true, PublicationYear: 2022, Authorships: []domain.Authorship{authorship("A1")}},
+	}
+	for _, work := range works {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+	// A work saved before hasFulltext was stored has no property and is left out.
+	query(t, r, ctx, `
+		MATCH (a:Author {id: 'A1', tenant: $tenant})
+		CREATE (a)-[:AUTHORED {tenant: $tenant}]->(:Work {id: 'W4', tenant: $tenant, publicationYear: 2019})
+	`, nil)
+
+	tests := []struct {
+		name             string
+		onlyFulltext     bool
+		includeRetracted bool
+		want             []string
+	}{
+		{"all", false, true, []string{"W3", "W2", "W1", "W4"}},
+		{"full text", true, true, []string{"W3", "W1"}},
+		{"full text, not retracted", true, false, []string{"W1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetAuthorWorks(ctx, "A1", tt.onlyFulltext, tt.includeRetracted, true, 10)
+			if err != nil {
+				t.Fatalf("GetAuthorWorks: %v", err)
+			}
+			var ids []string
+			for _, work := range got {
+				ids = append(ids, work.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("works = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("works = %v, want %v", ids, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+// Newest first orders by publication date, whatever its precision or how it was stored,
+// most cited first on the same date; works without a date come last.
+func TestGetAuthorWorksNewestFirst(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	works := []domain.Work{
+		{ID: "W1", PublicationDate: "2021-03-01", CitedByCount: 5},
+		{ID: "W2", PublicationDate: "2023-06-15", CitedByCount: 1},
+		{ID: "W3", PublicationDate: "2023-06-15", CitedByCount: 9},
+		{ID: "W4", PublicationYear: 2022},
+		{ID: "W5", PublicationDate: "2020-01-01", CitedByCount: 2},
+		{ID: "W6", CitedByCount: 3},
+	}
+	for _, work := range works {
+		work.Title = work.ID
+		work.Authorships = []domain.Authorship{authorship("A1")}
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+	// W5 was saved when dates were stored as strings.
+	query(t, r, ctx, `MATCH (w:Work {id: 'W5', tenant: $tenant}) SET w.publicationDate = '2024-02-01'`, nil)
+
+	tests := []struct {
+		name        string
+		newestFirst bool
+		limit       int
+		want        []string
+	}{
+		{"newest first", true, 10, []string{"W5", "W3", "W2", "W4", "W1", "W6"}},
+		{"latest only", true, 2, []string{"W5", "W3"}},
+		{"most cited", false, 10, []string{"W3", "W1", "W6", "W5", "W2", "W4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.GetAuthorWorks(ctx, "A1", false, true, tt.newestFirst, tt.limit)
+			if err != nil {
+				t.Fatalf("GetAuthorWorks: %v", err)
+			}
+			ids := []string{}
+			for _, work := range got {
+				ids = append(ids, work.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("works = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveWorkStampsFirstSeenOnCreateOnly(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	work := domain.Work{ID: "W1", Title: "first", PublicationYear: 2020, Authorships: []domain.Authorship{authorship("A1")}}
+	firstSeen := func() time.Time {
+		t.Helper()
+		records := query(t, r, ctx, `MATCH (w:Work {id: 'W1', tenant: $tenant}) RETURN w.firstSeen AS firstSeen`, nil)
+		seen, ok := records[0]["firstSeen"].(time.Time)
+		if !ok {
+			t.Fatalf("firstSeen = %#v, want a datetime", records[0]["firstSeen"])
+		}
+		return seen
+	}
+
+	before := time.Now()
+	if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+		t.Fatal(err)
+	}
+	created := firstSeen()
+	if created.Before(before.Add(-time.Minute)) || created.After(time.Now().Add(time.Minute)) {
+		t.Errorf("firstSeen = %v, want about now", created)
+	}
+
+	work.Title = "updated"
+	opts := FullSave
+	opts.Force = true
+	if _, err := r.SaveWork(ctx, work, opts); err != nil {
+		t.Fatal(err)
+	}
+	if updated := firstSeen(); !updated.Equal(created) {
+		t.Errorf("firstSeen moved from %v to %v on update", created, updated)
+	}
+
+	// A stub, created when a citing work was saved, isn't seen until it is saved itself.
+	cites := domain.Work{ID: "W2", Title: "cites", ReferencedWorks: []string{"W3"}, Authorships: []domain.Authorship{authorship("A1")}}
+	if _, err := r.SaveWork(ctx, cites, FullSave); err != nil {
+		t.Fatal(err)
+	}
+	stubSeen := func() any {
+		return query(t, r, ctx, `MATCH (w:Work {id: 'W3', tenant: $tenant}) RETURN w.firstSeen AS firstSeen`, nil)[0]["firstSeen"]
+	}
+	if seen := stubSeen(); seen != nil {
+		t.Errorf("stub W3 has firstSeen %v", seen)
+	}
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W3", Title: "cited"}, FullSave); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stubSeen().(time.Time); !ok {
+		t.Error("W3 has no firstSeen after it was saved")
+	}
+}
+
+func TestGetWorksAddedSince(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	for _, id := range []string{"W1", "W2", "W3"} {
+		work := domain.Work{ID: id, Title: id, PublicationDate: "2023-06-01", Authorships: []domain.Authorship{authorship("A1")}}
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatal(err)
+		}
+	}
+	query(t, r, ctx, `
+		UNWIND [['W1', '2024-01-01T06:00:00Z'], ['W2', '2024-01-01T12:00:00+02:00'], ['W3', '2024-01-02T00:00:00Z']] AS row
+		MATCH (w:Work {id: row[0], tenant: $tenant})
+		SET w.firstSeen = datetime(row[1])
+	`, nil)
+	query(t, r, ctx, `MATCH (w:Work {id: 'W3', tenant: $tenant}) REMOVE w.firstSeen`, nil) // Saved before firstSeen existed.
+
+	berlin := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name  string
+		since time.Time
+		want  []string
+	}{
+		{"all", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), []string{"W2", "W1"}},
+		{"cutoff is exclusive", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), []string{}},
+		{"between", time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), []string{"W2"}},
+		{"other zone", time.Date(2024, 1, 1, 6, 30, 0, 0, berlin), []string{"W2", "W1"}},
+		{"after all", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			works, err := r.GetWorksAddedSince(ctx, "A1", tt.since)
+			if err != nil {
+				t.Fatalf("GetWorksAddedSince: %v", err)
+			}
+			ids := []string{}
+			for _, work := range works {
+				ids = append(ids, work.ID)
+				if work.PublicationDate != "2023-06-01" || work.FirstSeen.Location() != time.UTC {
+					t.Errorf("work %s: publication date %q, firstSeen %v", work.ID, work.PublicationDate, work.FirstSeen)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("works = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveWorkOutcomes(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	stored := func() map[string]any {
+		t.Helper()
+		return query(t, r, ctx, `
+			MATCH (w:Work {id: 'W1', tenant: $tenant})
+			RETURN w.title AS title, w.createdDate AS createdDate, w.updatedDate AS updatedDate
+		`, nil)[0]
+	}
+
+	steps := []struct {
+		name        string
+		title       string
+		updatedDate string
+		force       bool
+		want        SaveOutcome
+		wantTitle   string
+		wantUpdated string
+	}{
+		{"new", "v1", "2024-03-01T10:00:00", false, SaveCreated, "v1", "2024-03-01T10:00:00"},
+		{"equal timestamps", "v2", "2024-03-01T10:00:00", false, SaveUnchanged, "v1", "2024-03-01T10:00:00"},
+		{"older", "v0", "2024-02-01T10:00:00", false, SaveUnchanged, "v1", "2024-03-01T10:00:00"},
+		{"newer", "v3", "2024-03-02T08:00:00", false, SaveUpdated, "v3", "2024-03-02T08:00:00"},
+		{"forced", "v4", "2024-03-02T08:00:00", true, SaveUpdated, "v4", "2024-03-02T08:00:00"},
+		// Without a date there is nothing to compare, so the work is written, keeping the date.
+		{"no date", "v5", "", false, SaveUpdated, "v5", "2024-03-02T08:00:00"},
+	}
+	for _, step := range steps {
+		work := domain.Work{ID: "W1", Title: step.title, CreatedDate: "2020-01-01", UpdatedDate: step.updatedDate}
+		got, err := r.SaveWork(ctx, work, SaveOptions{Force: step.force})
+		if err != nil {
+			t.Fatalf("%s: SaveWork: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: outcome = %s, want %s", step.name, got, step.want)
+		}
+		props := stored()
+		if props["title"] != step.wantTitle || props["updatedDate"] != step.wantUpdated || props["createdDate"] != "2020-01-01" {
+			t.Errorf("%s: stored %v, want title %s updated %s created 2020-01-01", step.name, props, step.wantTitle, step.wantUpdated)
+		}
+	}
+
+	// A stub isn't a saved work yet: saving it creates it, whatever its dates.
+	if _, err := r.SaveWork(ctx, domain.Work{ID: "W2", ReferencedWorks: []string{"W3"}}, SaveOptions{IncludeCitations: true}); err != nil {
+		t.Fatalf("SaveWork(W2): %v", err)
+	}
+	if got, err := r.SaveWork(ctx, domain.Work{ID: "W3", UpdatedDate: "2024-01-01"}, SaveOptions{}); err != nil || got != SaveCreated {
+		t.Errorf("saving stub W3 = %s, %v; want %s", got, err, SaveCreated)
+	}
+}
+
+// A deposited work saved again under its generated ID updates the node it created, and its
+// name-only authors are the same LOCAL- author nodes.
+func TestSaveDepositedWorkTwice(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	work := domain.Work{Title: "Annual Report on Widgets", PublicationYear: 2023, Authorships: []domain.Authorship{
+		{Author: domain.DehydratedAuthor{ID: domain.LocalAuthorID("Ada Lovelace"), DisplayName: "Ada Lovelace"}},
+		authorship("A1"),
+	}}
+	work.ID = domain.LocalWorkID(work)
+	opts := FullSave
+	opts.Source = SourceDeposit
+
+	for i, want := range []SaveOutcome{SaveCreated, SaveUpdated} {
+		outcome, err := r.SaveWork(ctx, work, opts)
+		if err != nil {
+			t.Fatalf("deposit %d: %v", i+1, err)
+		}
+		if outcome != want {
+			t.Errorf("deposit %d: outcome = %s, want %s", i+1, outcome, want)
+		}
+	}
+
+	counts := graphCounts(t, r, ctx, "T-"+tenantOf(ctx))
+	if counts["Work"] != 1 || counts["Author"] != 2 || counts["AUTHORED"] != 2 {
+		t.Errorf("graph = %v, want 1 work, 2 authors and 2 AUTHORED edges", counts)
+	}
+	provenance, err := r.GetWorkProvenance(ctx, work.ID)
+	if err != nil {
+		t.Fatalf("GetWorkProvenance: %v", err)
+	}
+	if want := []string{SourceDeposit}; !reflect.DeepEqual(provenance.SourcedFrom, want) || provenance.LastSource != SourceDeposit {
+		t.Errorf("work stamped %+v, want only %q", provenance.ProvenanceStamp, SourceDeposit)
+	}
+}
+
+func TestGetTopWorksRetracted(t *testing.T) {
+	r, ctx := newTestRepo(t)
+	for _, work := range []domain.Work{
+		{ID: "W1", Title: "most cited", PublicationYear: 2018, CitedByCount: 50, IsRetracted: true},
+		{ID: "W2", Title: "recent", PublicationYear: 2022, CitedByCount: 20},
+		{ID: "W3", Title: "old", PublicationYear: 2015, CitedByCount: 10},
+		{ID: "W4", Title: "recent retracted", PublicationYear: 2023, CitedByCount: 5, IsRetracted: true},
+	} {
+		if _, err := r.SaveWork(ctx, work, FullSave); err != nil {
+			t.Fatalf("SaveWork(%s): %v", work.ID, err)
+		}
+	}
+	// W5 was saved before isRetracted was stored and counts as not retracted.
+	query(t, r, ctx, `CREATE (:Work {id: 'W5', tenant: $tenant, title: 'legacy', publicationYear: 2021, citedByCount: 1})`, nil)
+
+	tests := []struct {
+		name             string
+		sinceYear        int
+		includeRetracted bool
+		want             []string
+		wantRetracted    []string
+	}{
+		{"not retracted", 0, false, []string{"W2", "W3", "W5"}, nil},
+		{"with retracted", 0, true, []string{"W1", "W2", "W3", "W4", "W5"}, []string{"W1", "W4"}},
+		{"recent, not retracted", 2020, false, []string{"W2", "W5"}, nil},
+		{"recent, with retracted", 2020, true, []string{"W2", "W4", "W5"}, []string{"W4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			works, err := r.GetTopWorks(ctx, 10, tt.sinceYear, tt.includeRetracted)
+			if err != nil {
+				t.Fatalf("GetTopWorks: %v", err)
+			}
+			ids, retracted := []string{}, []string(nil)
+			for _, work := range works {
+				ids = append(ids, work.ID)
+				if work.IsRetracted {
+					retracted = append(retracted, work.ID)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.want) || !reflect.DeepEqual(retracted, tt.wantRetracted) {
+				t.Errorf("works = %v with %v retracted, want %v with %v", ids, retracted, tt.want, tt.wantRetracted)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package tenant carries the tenant (project or team) a request acts on behalf of.
+//
+// Ingested nodes are stamped with the tenant that created them and reads only see their own
+// tenant's nodes. The empty tenant, Shared, is the global namespace every deployment used
+// before tenants existed; it stays the default when no tenant is given.
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Shared is the global, tenant-less namespace.
+const Shared = ""
+
+type contextKey struct{}
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Valid reports whether name can be used as a tenant: lowercase letters, digits, '-' and
+// '_', starting with a letter or digit, at most 63 characters.
+func Valid(name string) bool {
+	return validName.MatchString(name)
+}
+
+// WithTenant returns a copy of ctx scoped to the given tenant.
+func WithTenant(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// FromContext returns the tenant ctx is scoped to, or Shared.
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(contextKey{}).(string)
+	return name
+}
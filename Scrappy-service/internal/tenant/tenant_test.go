@@ -0,0 +1,43 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"team-a", true},
+		{"lab_42", true},
+		{"0team", true},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+		{"", false},
+		{"Team", false},
+		{"-team", false},
+		{"team a", false},
+		{"team'}) MATCH (n", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.name); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Shared {
+		t.Errorf("FromContext of an unscoped context = %q, want Shared", got)
+	}
+	ctx := WithTenant(context.Background(), "team-a")
+	if got := FromContext(ctx); got != "team-a" {
+		t.Errorf("FromContext = %q, want team-a", got)
+	}
+	if got := FromContext(WithTenant(ctx, Shared)); got != Shared {
+		t.Errorf("FromContext after rescoping to Shared = %q", got)
+	}
+}